@@ -1,31 +1,259 @@
 package main
 
 import (
+	"arena_demo/pkg/accesslog"
+	"arena_demo/pkg/arena"
 	"arena_demo/pkg/core"
+	"arena_demo/pkg/typed"
+	"arena_demo/pkg/zlog"
+	"context"
+	"encoding/json"
+	"errors"
+	_ "expvar" // 注册 /debug/vars
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var engine *core.Engine
 
+// quotes 是 /quote 背后的泛型引擎：请求与结果都是本文件定义的类型，不经过 core.Task
+var quotes *typed.Engine[quoteReq, quoteResp]
+
+// logTail 保留最近的订单日志，供 /logs 查看
+var logTail = zlog.NewRingSink(256, 512)
+
+// inflight 限制同时等待 Core 回复的请求数 (环境变量 ARENA_MAX_INFLIGHT，默认 4096，<= 0 表示不限)
+var inflight = core.NewInflightLimiter(envInt("ARENA_MAX_INFLIGHT", 4096))
+
+// accessLog 把每个 HTTP 请求记录到标准输出 (环境变量 ARENA_ACCESS_LOG=0 关闭，ARENA_ACCESS_LOG_SAMPLE=N 每 N 个请求记录 1 个)
+var accessLog = accesslog.New(os.Stdout, envInt("ARENA_ACCESS_LOG_SAMPLE", 1))
+
+// envInt 读取整数环境变量，未设置或非法时返回 def
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// limited 给提交任务的 Handler 加上在途请求上限，超出立即 503
+func limited(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !inflight.TryAcquire() {
+			setRetryAfter(w, engine)
+			writeError(w, core.CodeQueueFull, "too many in-flight requests")
+			return
+		}
+		defer inflight.Release()
+		h(w, r)
+	}
+}
+
+// respBufPool 复用序列化响应用的 buffer
+var respBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// writeResult 按 Accept Header 协商格式并写出响应
+func writeResult(w http.ResponseWriter, r *http.Request, encode func(m core.Marshaler, dst []byte) []byte) {
+	m := core.MarshalerFor(r.Header.Get("Accept"))
+	bp := respBufPool.Get().(*[]byte)
+	*bp = encode(m, (*bp)[:0])
+	w.Header().Set("Content-Type", m.ContentType())
+	w.Write(*bp)
+	respBufPool.Put(bp)
+}
+
+// writeError 以 {"code":"...","message":"..."} 的形式写出错误，状态码由错误码决定
+func writeError(w http.ResponseWriter, code core.ErrorCode, msg string) {
+	bp := respBufPool.Get().(*[]byte)
+	*bp = core.AppendErrorJSON((*bp)[:0], code, msg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code.HTTPStatus())
+	w.Write(*bp)
+	respBufPool.Put(bp)
+}
+
+// orderLogOut 是订单日志的输出 (测试中替换)
+var orderLogOut io.Writer = os.Stdout
+
+// printOrderLog 把订单日志写到 orderLogOut (标准输出)
+// 不用 fmt.Printf：把 []byte 装进 interface 参数会分配；前缀与日志拼成一次 Write，并发请求的日志行不会交错
+func printOrderLog(log []byte) {
+	if len(log) == 0 {
+		return
+	}
+	bp := respBufPool.Get().(*[]byte)
+	*bp = append(append((*bp)[:0], "[AsyncLog] "...), log...)
+	orderLogOut.Write(*bp)
+	respBufPool.Put(bp)
+}
+
+// writeEngineError 把引擎返回的 error 翻译为错误码后写出
+func writeEngineError(w http.ResponseWriter, err error) {
+	writeError(w, core.CodeOf(err), err.Error())
+}
+
+// writeSubmitError 写出 TrySubmit 的拒绝原因
+// 队列满/限流时附带 Retry-After，让客户端按积压的排空时间退避，而不是立刻重试
+func writeSubmitError(w http.ResponseWriter, e *core.Engine, err error) {
+	if c := core.CodeOf(err); c == core.CodeQueueFull || c == core.CodeRateLimited {
+		setRetryAfter(w, e)
+	}
+	writeEngineError(w, err)
+}
+
+// setRetryAfter 按引擎的预计排空时间设置 Retry-After (秒)
+func setRetryAfter(w http.ResponseWriter, e *core.Engine) {
+	w.Header().Set("Retry-After", strconv.Itoa(core.RetryAfterSeconds(e.DrainEstimate())))
+}
+
 func main() {
 	// 1. 启动 Core (C World)
+	// 默认引擎 + 一个独立的风控引擎，二者互不共享队列/Arena/状态
 	engine = core.NewEngine()
+	engine.LogTail = logTail
+	engine.EnableREDMetrics()     // /metrics 中按任务类型的请求数、拒绝数与耗时
+	engine.EnableCommandQueue(64) // /admin 的查询、清零、暂停走独立的命令通道，不占数据队列
 	engine.Start()
+	core.Register("default", engine)
+
+	risk := core.NewEngine()
+	risk.Start()
+	core.Register("risk", risk)
+
+	quotes = typed.New(1024, priceQuote)
+	quotes.Start()
+
+	// 本机低延迟通道：Unix Domain Socket 上的二进制任务帧 (格式见 core/ipc.go)
+	sock := filepath.Join(os.TempDir(), "arena_demo.sock")
+	os.Remove(sock)
+	go func() {
+		if err := engine.ServeUnix(sock); err != nil {
+			fmt.Println("[IPC] unix socket disabled:", err)
+		}
+	}()
 
 	// 2. 启动 HTTP Server (Go World)
-	http.HandleFunc("/calc", handleCalc)
-	http.HandleFunc("/order", handleOrder)
+	http.HandleFunc("/calc", limited(handleCalc))
+	http.HandleFunc("/order", limited(handleOrder))
+	http.HandleFunc("/batch", limited(handleBatch))
+	http.HandleFunc("/orders", limited(handleOrders))
+	http.HandleFunc("/quote", limited(handleQuote))
+	http.HandleFunc("/stats", handleStats)
+	http.HandleFunc("/backlog", handleBacklog)
+	http.HandleFunc("/logs", handleLogs)
+	http.HandleFunc("/metrics", handleMetrics)
+	http.HandleFunc("GET /admin/volume/{uid}", handleVolume)
+	http.HandleFunc("DELETE /admin/volume/{uid}", handleVolume)
+	http.HandleFunc("POST /admin/pause", handlePause)
+	http.HandleFunc("POST /admin/resume", handlePause)
+	http.HandleFunc("/e/{name}/calc", limited(handleCalc))
+	http.HandleFunc("/e/{name}/order", limited(handleOrder))
 
 	fmt.Println("Hybrid Server listening on :8080")
 	fmt.Println("  - /calc?val=10  -> Calc Task")
 	fmt.Println("  - /order?p=100&q=5 -> Order Task")
+	fmt.Println("  - /batch?o=100:5:1&o=20:1:2 -> All-or-nothing Batch (price:qty:uid)")
+	fmt.Println("  - POST /orders [{\"price\":100,\"qty\":5,\"uid\":1}] -> Independent Orders, per-order status (JSON)")
+	fmt.Println("  - /e/risk/order?p=100&q=5 -> Order Task on named engine")
+	fmt.Println("  - /quote?leg=100&leg=20.5&q=3 -> Basket Quote (typed.Engine)")
+	fmt.Println("  - /stats           -> Engine Stats (JSON)")
+	fmt.Println("  - /backlog?max=500ms -> Queue Backlog / Drain Estimate (503 when over max or paused)")
+	fmt.Println("  - /logs?n=50       -> Recent Order Logs (in-memory)")
+	fmt.Println("  - GET|DELETE /admin/volume/1 -> Inspect / Reset UserVolume")
+	fmt.Println("  - POST /admin/pause|resume -> Freeze / Unfreeze the worker (queued tasks kept)")
+	fmt.Println("  - /debug/vars      -> Engine Stats (expvar)")
+	fmt.Println("  - /metrics         -> Engine Stats (Prometheus)")
+	fmt.Println("  - unix://" + sock + " -> Binary Task Frames")
+
+	accessLog.SetEnabled(envInt("ARENA_ACCESS_LOG", 1) != 0)
+	http.ListenAndServe(":8080", accessLog.Wrap(http.DefaultServeMux))
+}
+
+// engineFor 根据路径中的 {name} 选择引擎，未指定时使用默认引擎
+func engineFor(w http.ResponseWriter, r *http.Request) *core.Engine {
+	name := r.PathValue("name")
+	if name == "" {
+		return engine
+	}
+	e := core.Lookup(name)
+	if e == nil {
+		writeError(w, core.CodeNotFound, "unknown engine: "+name)
+	}
+	return e
+}
+
+// withTrace 从请求中提取 Trace 上下文，为本次处理开启子 span 写入 task，并在响应中回写 traceparent
+// trace-id 与 flags 沿用上游，span-id 是新生成的子 span
+func withTrace(w http.ResponseWriter, r *http.Request, task *core.Task) {
+	trace, _, flags, ok := core.ParseTraceparent(r.Header.Get(core.TraceHeader))
+	if !ok {
+		return
+	}
+	task.TraceID, task.SpanID = trace, core.NewSpanID()
+	var buf [64]byte
+	w.Header().Set(core.TraceHeader, string(core.AppendTraceparent(buf[:0], trace, task.SpanID, flags)))
+}
+
+// withCorrID 为 task 设置关联 ID (客户端提供或引擎生成)，并在响应中回写
+// 客户端提供的值非法时写出 400 并返回 false，Handler 应直接返回
+func withCorrID(w http.ResponseWriter, r *http.Request, task *core.Task) bool {
+	id, err := core.CorrIDFrom(r.Header.Get(core.CorrIDHeader))
+	if err != nil {
+		writeEngineError(w, err)
+		return false
+	}
+	task.CorrID = id
+	var buf [20]byte
+	w.Header().Set(core.CorrIDHeader, string(strconv.AppendUint(buf[:0], task.CorrID, 10)))
+	return true
+}
+
+// withCancel 让 task 在请求结束 (客户端断开、超时) 时被取消 (见 core/cancel.go)
+// 返回的 stop 必须在 Handler 返回前调用，解除与请求 Context 的关联
+func withCancel(r *http.Request, task *core.Task) (stop func() bool) {
+	cancel := new(atomic.Bool)
+	task.Cancel = cancel
+	return context.AfterFunc(r.Context(), func() { cancel.Store(true) })
+}
+
+// await 等待 Worker 的回复；请求先结束时返回 ok=false (排队中的任务已被取消，不会再有回复)
+func await(r *http.Request, resp chan any) (v any, ok bool) {
+	select {
+	case v = <-resp:
+		return v, true
+	case <-r.Context().Done():
+		return nil, false
+	}
+}
 
-	http.ListenAndServe(":8080", nil)
+// clientIP 解析请求的对端地址，失败时返回 nil
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
 }
 
 func handleCalc(w http.ResponseWriter, r *http.Request) {
+	engine := engineFor(w, r)
+	if engine == nil {
+		return
+	}
 	valStr := r.URL.Query().Get("val")
 	val, _ := strconv.Atoi(valStr)
 
@@ -37,21 +265,43 @@ func handleCalc(w http.ResponseWriter, r *http.Request) {
 		Type:  core.TaskTypeCalc,
 		Value: val,
 		Resp:  respChan,
+		QoS:   core.ParseQoS(r.Header.Get(core.QoSHeader)),
+		// 计算接口要求快速失败：队列满直接 503
+		Overflow: core.OverflowReject,
+	}
+	withTrace(w, r, &task)
+	if !withCorrID(w, r, &task) {
+		return
 	}
+	defer withCancel(r, &task)()
 
 	// 如果队列满了，这里可以选择阻塞或者报错
-	if !engine.Queue.Push(task) {
-		http.Error(w, "Core Busy", 503)
+	if err := engine.TrySubmit(task); err != nil {
+		writeSubmitError(w, engine, err)
 		return
 	}
 
-	// 4. 等待结果：Go <- C
-	result := <-respChan
+	// 4. 等待结果：Go <- C (客户端已经断开时不再等待)
+	v, ok := await(r, respChan)
+	if !ok {
+		return
+	}
+	result, ok := core.AsCalc(v)
+	if !ok {
+		writeError(w, core.CodeInternal, "unexpected result")
+		return
+	}
 
-	fmt.Fprintf(w, "Calc Result: %v\n", result)
+	writeResult(w, r, func(m core.Marshaler, dst []byte) []byte {
+		return m.AppendCalc(dst, result)
+	})
 }
 
 func handleOrder(w http.ResponseWriter, r *http.Request) {
+	engine := engineFor(w, r)
+	if engine == nil {
+		return
+	}
 	price, _ := strconv.ParseFloat(r.URL.Query().Get("p"), 64)
 	qty, _ := strconv.Atoi(r.URL.Query().Get("q"))
 	uid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
@@ -61,31 +311,357 @@ func handleOrder(w http.ResponseWriter, r *http.Request) {
 
 	respChan := make(chan any, 1)
 
-	// 预分配 Log Buffer (可以使用 sync.Pool 复用)
-	logBuf := make([]byte, 0, 1024)
-
 	task := core.Task{
 		Type:     core.TaskTypeOrder,
 		Price:    price,
 		Quantity: qty,
 		Value:    uid, // Reuse Value as UserID
 		Resp:     respChan,
-		LogBuf:   logBuf,
+		ClientIP: clientIP(r),
+		QoS:      core.ParseQoS(r.Header.Get(core.QoSHeader)),
+		// 订单可以短暂等待 Core 追上来
+		Overflow: core.OverflowBlock,
 	}
+	withTrace(w, r, &task)
+	if !withCorrID(w, r, &task) {
+		return
+	}
+	defer withCancel(r, &task)()
+
+	// Log Buffer 按日志的预计长度从分级池中取，响应写完后归还
+	task.LogBuf = core.GetLogBuf(task)
 
-	if !engine.Queue.Push(task) {
-		http.Error(w, "Core Busy", 503)
+	if err := engine.TrySubmit(task); err != nil {
+		core.PutLogBuf(task.LogBuf)
+		writeSubmitError(w, engine, err)
 		return
 	}
 
 	// 4. 获取结果
-	resAny := <-respChan
-	result := resAny.(core.OrderResult)
+	v, ok := await(r, respChan)
+	if !ok {
+		// 客户端已经断开：任务可能已经在 Worker 上写 LogBuf，不能归还，交给 GC
+		return
+	}
+	defer core.PutLogBuf(task.LogBuf)
+	result, ok := core.AsOrder(v)
+	if !ok {
+		writeError(w, core.CodeInternal, "unexpected result")
+		return
+	}
+
+	// 5. 打印 Core 返回的日志 (异步打印，不影响 Core)；被拒绝的订单同样有日志 (说明拒绝原因)
+	// result.Log 指向 task.LogBuf，必须在 defer 归还之前打印完
+	printOrderLog(result.Log)
+	if result.Err != nil {
+		writeEngineError(w, result.Err)
+		return
+	}
+
+	writeResult(w, r, func(m core.Marshaler, dst []byte) []byte {
+		return m.AppendOrder(dst, result)
+	})
+}
+
+// handleBatch 同步执行一篮子订单，全部成功或全部失败
+// 每个 o 参数的格式为 price:qty:uid
+func handleBatch(w http.ResponseWriter, r *http.Request) {
+	engine := engineFor(w, r)
+	if engine == nil {
+		return
+	}
+
+	params := r.URL.Query()["o"]
+	orders := make([]core.Order, 0, len(params))
+	for _, p := range params {
+		parts := strings.Split(p, ":")
+		if len(parts) != 3 {
+			writeError(w, core.CodeValidation, "bad order: "+p)
+			return
+		}
+		price, _ := strconv.ParseFloat(parts[0], 64)
+		qty, _ := strconv.Atoi(parts[1])
+		uid, _ := strconv.Atoi(parts[2])
+		orders = append(orders, core.Order{Price: price, Quantity: qty, UserID: uid})
+	}
 
-	// 5. 打印 Core 返回的日志 (异步打印，不影响 Core)
-	if len(result.Log) > 0 {
-		fmt.Printf("[AsyncLog] %s", result.Log)
+	respChan := make(chan any, 1)
+	task := core.Task{
+		Type:     core.TaskTypeBatchOrder,
+		Orders:   orders,
+		Resp:     respChan,
+		QoS:      core.ParseQoS(r.Header.Get(core.QoSHeader)),
+		Overflow: core.OverflowBlock,
+	}
+	withTrace(w, r, &task)
+	if !withCorrID(w, r, &task) {
+		return
+	}
+	defer withCancel(r, &task)()
+
+	if err := engine.TrySubmit(task); err != nil {
+		writeSubmitError(w, engine, err)
+		return
+	}
+
+	v, ok := await(r, respChan)
+	if !ok {
+		return
+	}
+	result, ok := core.AsBatch(v)
+	if !ok {
+		writeError(w, core.CodeInternal, "unexpected result")
+		return
+	}
+	if result.Err != nil {
+		writeError(w, core.CodeOf(result.Err), fmt.Sprintf("order %d: %v", result.FailedIndex, result.Err))
+		return
+	}
+	fmt.Fprintf(w, "Batch Total: %.2f\nOrders: %d\n", result.Total, len(orders))
+}
+
+// maxOrdersPerRequest 是 /orders 单个请求最多携带的订单数
+const maxOrdersPerRequest = 1024
+
+// orderReq 是 /orders 请求体数组中的一项，uid 缺省为 1 (与 /order 相同)
+type orderReq struct {
+	Price float64 `json:"price"`
+	Qty   int     `json:"qty"`
+	UID   int     `json:"uid"`
+}
+
+// orderStatus 是 /orders 响应数组中的一项，与请求一一对应：
+// 成功时 ok=true 并给出 total；失败时给出错误码 (见 core.ErrorCode) 与错误信息
+type orderStatus struct {
+	OK    bool           `json:"ok"`
+	Total float64        `json:"total,omitempty"`
+	Code  core.ErrorCode `json:"code,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// handleOrders 批量下单：请求体是订单的 JSON 数组，一次 SubmitBatch 提交，响应是逐笔状态的 JSON 数组
+//
+//	POST /orders  [{"price":100,"qty":5,"uid":1},{"price":0,"qty":1}]
+//	-> [{"ok":true,"total":500},{"ok":false,"code":"validation","error":"core: invalid order"}]
+//
+// 与 /batch 不同，每笔订单独立执行、独立成败 (没有事务语义)。
+// 请求体本身非法时整个请求返回错误；否则总是 200，逐笔失败 (参数非法、被拒绝、超出限额) 体现在对应的状态里
+func handleOrders(w http.ResponseWriter, r *http.Request) {
+	engine := engineFor(w, r)
+	if engine == nil {
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, core.CodeValidation, "POST a JSON array of orders")
+		return
+	}
+	var reqs []orderReq
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		writeError(w, core.CodeValidation, "bad orders: "+err.Error())
+		return
+	}
+	if len(reqs) > maxOrdersPerRequest {
+		writeError(w, core.CodeValidation, fmt.Sprintf("too many orders: %d > %d", len(reqs), maxOrdersPerRequest))
+		return
+	}
+
+	statuses := make([]orderStatus, len(reqs))
+	tasks := make([]core.Task, 0, len(reqs))
+	index := make([]int, 0, len(reqs)) // tasks[i] 对应 reqs[index[i]]
+	// 所有订单共用请求级的 QoS、Trace 与关联 ID
+	base := core.Task{
+		Type:     core.TaskTypeOrder,
+		ClientIP: clientIP(r),
+		QoS:      core.ParseQoS(r.Header.Get(core.QoSHeader)),
+		Overflow: core.OverflowBlock,
+	}
+	withTrace(w, r, &base)
+	if !withCorrID(w, r, &base) {
+		return
+	}
+	defer withCancel(r, &base)() // 所有订单共用一个取消标记
+	for i, o := range reqs {
+		// 单笔订单在 Worker 中不做参数校验 (与 /batch 的 validateOrder 相同的规则在这里提前检查)
+		if o.Price <= 0 || o.Qty <= 0 || o.UID < 0 {
+			statuses[i] = orderStatus{Code: core.CodeOf(core.ErrInvalidOrder), Error: core.ErrInvalidOrder.Error()}
+			continue
+		}
+		if o.UID == 0 {
+			o.UID = 1
+		}
+		task := base
+		task.Price, task.Quantity, task.Value = o.Price, o.Qty, o.UID
+		task.Resp = make(chan any, 1)
+		tasks = append(tasks, task)
+		index = append(index, i)
+	}
+
+	for i, err := range engine.SubmitBatch(tasks) {
+		if err != nil {
+			statuses[index[i]] = orderStatus{Code: core.CodeOf(err), Error: err.Error()}
+			tasks[i].Resp = nil
+		}
+	}
+	for i, t := range tasks {
+		if t.Resp == nil {
+			continue
+		}
+		v, ok := await(r, t.Resp)
+		if !ok {
+			return
+		}
+		result, ok := core.AsOrder(v)
+		switch {
+		case !ok:
+			statuses[index[i]] = orderStatus{Code: core.CodeInternal, Error: "unexpected result"}
+		case result.Err != nil:
+			statuses[index[i]] = orderStatus{Code: core.CodeOf(result.Err), Error: result.Err.Error()}
+		default:
+			statuses[index[i]] = orderStatus{OK: true, Total: result.Total}
+		}
 	}
 
-	fmt.Fprintf(w, "Order Total: %.2f\nProcessed At: %d\nLog: %s", result.Total, result.ProcessedAt, result.Log)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// quoteReq 是 /quote 的请求：一篮子腿的单价，每条腿的数量相同
+type quoteReq struct {
+	Legs []float64
+	Qty  int
+}
+
+// quoteResp 是 /quote 的结果
+type quoteResp struct {
+	Total float64 `json:"total"`
+	Legs  int     `json:"legs"`
+}
+
+// priceQuote 在 quotes 的 Worker 上计算报价：各腿金额先写进 Arena 上的临时切片，再求和
+// 返回值不引用 Arena，处理完之后 Arena 被 Reset
+func priceQuote(req quoteReq, mem *arena.Arena) quoteResp {
+	amounts := arena.MakeSlice[float64](mem, 0, len(req.Legs))
+	for _, p := range req.Legs {
+		amounts = append(amounts, p*float64(req.Qty))
+	}
+	var total float64
+	for _, a := range amounts {
+		total += a
+	}
+	return quoteResp{Total: total, Legs: len(amounts)}
+}
+
+// handleQuote 对一篮子腿报价，由 typed.Engine 处理 (与 /order 的 core.Engine 相互独立)
+//
+//	GET /quote?leg=100&leg=20.5&q=3  ->  {"total":361.5,"legs":2}
+func handleQuote(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	req := quoteReq{Qty: 1}
+	if q := params.Get("q"); q != "" {
+		req.Qty, _ = strconv.Atoi(q)
+	}
+	for _, s := range params["leg"] {
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil || p <= 0 {
+			writeError(w, core.CodeValidation, "bad leg: "+s)
+			return
+		}
+		req.Legs = append(req.Legs, p)
+	}
+	if len(req.Legs) == 0 || req.Qty <= 0 {
+		writeError(w, core.CodeValidation, "need at least one leg and a positive q")
+		return
+	}
+
+	result, err := quotes.Call(r.Context(), req)
+	switch {
+	case errors.Is(err, typed.ErrFull) || errors.Is(err, typed.ErrStopped):
+		writeError(w, core.CodeQueueFull, err.Error())
+		return
+	case err != nil:
+		// 客户端已经断开
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleStats 以 JSON 返回所有已注册引擎的状态快照
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	stats := map[string]core.Stats{}
+	for _, name := range core.Names() {
+		if e := core.Lookup(name); e != nil {
+			stats[name] = e.Stats()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleBacklog 以 JSON 返回默认引擎的积压情况，供自动扩缩容与就绪探针使用
+// 带 max (如 500ms) 时作为就绪探针：估计的排空时间超过 max 或 Worker 已暂停时返回 503，
+// 探针据此把实例摘出负载均衡，扩缩容控制器据此调用 Scale 增加分片
+func handleBacklog(w http.ResponseWriter, r *http.Request) {
+	info := engine.Backlog()
+	w.Header().Set("Content-Type", "application/json")
+	if s := r.URL.Query().Get("max"); s != "" {
+		limit, err := time.ParseDuration(s)
+		if err != nil {
+			writeError(w, core.CodeValidation, "bad max: "+s)
+			return
+		}
+		if info.Paused || info.DrainTime > limit {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+	json.NewEncoder(w).Encode(info)
+}
+
+// handleMetrics 以 Prometheus 文本格式返回所有已注册引擎的指标
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	bp := respBufPool.Get().(*[]byte)
+	*bp = core.AppendPrometheus((*bp)[:0])
+	w.Header().Set("Content-Type", core.PrometheusContentType)
+	w.Write(*bp)
+	respBufPool.Put(bp)
+}
+
+// handleLogs 返回最近的 n 行订单日志 (默认全部)
+func handleLogs(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range logTail.Snapshot(n) {
+		w.Write(line)
+	}
+}
+
+// handleVolume 查看 (GET) 或清零 (DELETE) 某个用户在默认引擎上的 UserVolume
+func handleVolume(w http.ResponseWriter, r *http.Request) {
+	uid, err := strconv.Atoi(r.PathValue("uid"))
+	if err != nil {
+		writeError(w, core.CodeValidation, "bad uid")
+		return
+	}
+	if r.Method == http.MethodDelete {
+		if err := engine.TryResetUserVolume(uid); err != nil {
+			writeEngineError(w, err)
+			return
+		}
+	}
+	v, err := engine.TryGetUserVolume(uid)
+	if err != nil {
+		writeEngineError(w, err)
+		return
+	}
+	fmt.Fprintf(w, "uid=%d volume=%.2f\n", uid, v)
+}
+
+// handlePause 暂停/恢复默认引擎的 Worker (维护用)，暂停期间请求照常入队，恢复后按顺序处理
+func handlePause(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/pause") {
+		engine.Pause()
+	} else {
+		engine.Resume()
+	}
+	fmt.Fprintf(w, "paused=%t queued=%d\n", engine.Paused(), engine.Stats().QueueLen)
 }
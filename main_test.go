@@ -0,0 +1,219 @@
+package main
+
+import (
+	"arena_demo/pkg/core"
+	"arena_demo/pkg/typed"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 部分成功的批量下单：每笔订单得到自己的状态，失败的不影响其余订单
+func TestHandleOrdersMixed(t *testing.T) {
+	e := core.NewEngineSync()
+	e.SetUserLimit(2, 150)
+	saved := engine
+	engine = e
+	t.Cleanup(func() { engine = saved })
+
+	body := `[{"price":100,"qty":5,"uid":1},{"price":0,"qty":1},{"price":100,"qty":1,"uid":2},{"price":100,"qty":1,"uid":2},{"price":2.5,"qty":2}]`
+	w := httptest.NewRecorder()
+	handleOrders(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var got []orderStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad response %q: %v", w.Body, err)
+	}
+	want := []orderStatus{
+		{OK: true, Total: 500},
+		{Code: core.CodeValidation, Error: core.ErrInvalidOrder.Error()},
+		{OK: true, Total: 100},
+		{Code: core.CodePositionLimit, Error: core.ErrPositionLimit.Error()}, // 累计 200 超过 150
+		{OK: true, Total: 5},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("statuses:\n got %+v\nwant %+v", got, want)
+	}
+	if v := e.GetUserVolume(1); v != 505 {
+		t.Fatalf("UserVolume[1] = %v, want 505", v)
+	}
+	if v := e.GetUserVolume(2); v != 100 {
+		t.Fatalf("UserVolume[2] = %v, want 100 (the rejected order did not apply)", v)
+	}
+
+	// 请求体本身非法时整个请求失败
+	w = httptest.NewRecorder()
+	handleOrders(w, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"price":1}`)))
+	if w.Code == http.StatusOK {
+		t.Fatal("a non-array body was accepted")
+	}
+}
+
+// 客户端断开后 Handler 立即返回，排队中的任务被取消，不执行也不修改状态
+func TestHandlersCancelOnDisconnect(t *testing.T) {
+	e := core.NewEngine()
+	e.Start()
+	saved := engine
+	engine = e
+	t.Cleanup(func() {
+		engine = saved
+		e.Pause() // 停住 Worker，不让它在之后的测试里空转
+	})
+
+	for i, tc := range []struct {
+		name   string
+		handle http.HandlerFunc
+		target string
+	}{
+		{"calc", handleCalc, "/calc?val=1"},
+		{"order", handleOrder, "/order?p=10&q=1&uid=3"},
+		{"batch", handleBatch, "/batch?o=10:1:3"},
+	} {
+		e.Pause()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			tc.handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.target, nil).WithContext(ctx))
+			close(done)
+		}()
+		for e.Stats().QueueLen == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: handler still waiting after the client went away", tc.name)
+		}
+		e.Resume()
+		e.Flush()
+		if got := e.Stats().Cancelled; got != uint64(i+1) {
+			t.Fatalf("%s: Cancelled = %d, want %d", tc.name, got, i+1)
+		}
+	}
+	if v := e.GetUserVolume(3); v != 0 {
+		t.Fatalf("UserVolume[3] = %v, cancelled orders must not apply", v)
+	}
+}
+
+// /quote 由 typed.Engine 处理：结果正确，参数非法与引擎停止时返回错误
+func TestHandleQuote(t *testing.T) {
+	q := typed.New(64, priceQuote)
+	q.Start()
+	saved := quotes
+	quotes = q
+	t.Cleanup(func() { quotes = saved })
+
+	w := httptest.NewRecorder()
+	handleQuote(w, httptest.NewRequest(http.MethodGet, "/quote?leg=100&leg=20.5&q=3", nil))
+	var got quoteResp
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got != (quoteResp{Total: 361.5, Legs: 2}) {
+		t.Fatalf("quote: status %d, %q (%v)", w.Code, w.Body, err)
+	}
+
+	w = httptest.NewRecorder()
+	handleQuote(w, httptest.NewRequest(http.MethodGet, "/quote?leg=-1", nil))
+	if w.Code != core.CodeValidation.HTTPStatus() {
+		t.Fatalf("bad leg: status %d, want %d", w.Code, core.CodeValidation.HTTPStatus())
+	}
+
+	q.Stop()
+	w = httptest.NewRecorder()
+	handleQuote(w, httptest.NewRequest(http.MethodGet, "/quote?leg=1", nil))
+	if w.Code != core.CodeQueueFull.HTTPStatus() {
+		t.Fatalf("stopped engine: status %d, want %d", w.Code, core.CodeQueueFull.HTTPStatus())
+	}
+}
+
+// 被拒绝的订单也打印 Core 返回的日志，然后才写出错误
+func TestHandleOrderLogsRejection(t *testing.T) {
+	e := core.NewEngineSync()
+	e.SetUserLimit(4, 50)
+	saved, savedOut := engine, orderLogOut
+	var out bytes.Buffer
+	engine, orderLogOut = e, &out
+	t.Cleanup(func() { engine, orderLogOut = saved, savedOut })
+
+	w := httptest.NewRecorder()
+	handleOrder(w, httptest.NewRequest(http.MethodGet, "/order?p=100&q=1&uid=4", nil))
+	if w.Code != core.CodePositionLimit.HTTPStatus() {
+		t.Fatalf("status %d, want %d: %s", w.Code, core.CodePositionLimit.HTTPStatus(), w.Body)
+	}
+	if !strings.HasPrefix(out.String(), "[AsyncLog] ") || !strings.Contains(out.String(), "uid=4") {
+		t.Fatalf("order log for a rejected order = %q", out.String())
+	}
+}
+
+// 非法的 X-Correlation-ID 被拒绝 (400)，而不是换成另一个 ID；合法的原样回写
+func TestHandleOrderCorrID(t *testing.T) {
+	e := core.NewEngineSync()
+	saved := engine
+	engine = e
+	t.Cleanup(func() { engine = saved })
+
+	req := httptest.NewRequest(http.MethodGet, "/order?p=10&q=1&uid=6", nil)
+	req.Header.Set(core.CorrIDHeader, "req-7")
+	w := httptest.NewRecorder()
+	handleOrder(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("non-numeric correlation id: status %d, want 400", w.Code)
+	}
+	if v := e.GetUserVolume(6); v != 0 {
+		t.Fatalf("rejected request reached the engine: UserVolume[6] = %v", v)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/order?p=10&q=1&uid=6", nil)
+	req.Header.Set(core.CorrIDHeader, "9001")
+	w = httptest.NewRecorder()
+	handleOrder(w, req)
+	if w.Code != http.StatusOK || w.Header().Get(core.CorrIDHeader) != "9001" {
+		t.Fatalf("numeric correlation id: status %d, echoed %q", w.Code, w.Header().Get(core.CorrIDHeader))
+	}
+}
+
+// traceparent 端到端：请求 Header → Task → 订单日志 → 响应 Header
+// 响应沿用 trace-id 与 flags，span-id 是新的子 span，订单日志记录的正是这个子 span
+func TestHandleOrderTrace(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parent  = "00f067aa0ba902b7"
+	)
+	e := core.NewEngineSync()
+	saved, savedOut := engine, orderLogOut
+	var out bytes.Buffer
+	engine, orderLogOut = e, &out
+	t.Cleanup(func() { engine, orderLogOut = saved, savedOut })
+
+	req := httptest.NewRequest(http.MethodGet, "/order?p=10&q=1&uid=8", nil)
+	req.Header.Set(core.TraceHeader, "00-"+traceID+"-"+parent+"-00")
+	w := httptest.NewRecorder()
+	handleOrder(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	got := w.Header().Get(core.TraceHeader)
+	_, _, flags, ok := core.ParseTraceparent(got)
+	if !ok {
+		t.Fatalf("response traceparent %q does not parse", got)
+	}
+	if !strings.HasPrefix(got, "00-"+traceID+"-") || flags != 0 {
+		t.Fatalf("response traceparent %q: want trace-id %s and unsampled flags", got, traceID)
+	}
+	if got[36:52] == parent {
+		t.Fatalf("response traceparent %q echoes the parent span instead of a child span", got)
+	}
+	spanHex := got[36:52]
+	log := out.String()
+	if !strings.Contains(log, "trace_id="+traceID) || !strings.Contains(log, "span_id="+spanHex) {
+		t.Fatalf("order log %q: want trace_id=%s span_id=%s", log, traceID, spanHex)
+	}
+}
@@ -0,0 +1,236 @@
+// Package accesslog 是 HTTP 访问日志中间件
+//
+// Log.Wrap 是一个 net/http 中间件，每个请求结束后记录一行：
+//
+//	ts=2024-01-02T03:04:05.678Z method=GET path=/order status=200 dur_us=183 corr_id=42 msg=access
+//
+// ts 来自 zlog 的 sysclock 时间缓存 (1ms 精度)；dur_us 用 time.Now 的单调时钟计量，sysclock 的精度不够衡量单个请求。
+// corr_id 取 Handler 回写的 X-Correlation-ID (core.CorrIDHeader)，没有时取请求 Header，都没有时为 "-"。
+//
+// 请求 goroutine 只负责用 zlog 把一行编码进池化的 buffer (零分配)，然后交给后台 goroutine：
+// 后台 goroutine 把积压的行攒进 bufio.Writer，队列取空时才 Flush，所以慢的 w (终端、管道) 不会拖慢请求。
+// 队列满时这一行直接丢弃并计入 Dropped，请求永远不会因为日志而阻塞。
+//
+// 采样：每 sample 个请求记录 1 个；5xx 按 Error 级别记录，永远不被采样丢弃 (见 zlog.Sampler)，
+// 4xx 按 Warn 级别。级别同时决定 zlog.SetSink 的路由：被 Sink 取走的行不再写入 w
+package accesslog
+
+import (
+	"arena_demo/pkg/core"
+	"arena_demo/pkg/zlog"
+	"bufio"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// accessQueueSize 等待后台写出的行数上限
+const accessQueueSize = 1024
+
+// accessLineSize 每行 buffer 的初始容量，足够放一行带长路径的访问日志
+const accessLineSize = 512
+
+// Log 把 HTTP 请求记录到 w，由 New 创建
+type Log struct {
+	w       io.Writer
+	sampler *zlog.Sampler
+	enabled atomic.Bool
+
+	lines chan *accessEntry
+	quit  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// accessEntry 是一个请求用到的全部状态，用完放回 accessPool，稳态下请求不分配
+type accessEntry struct {
+	sw   statusWriter
+	line []byte
+}
+
+var accessPool = sync.Pool{
+	New: func() any {
+		return &accessEntry{line: make([]byte, 0, accessLineSize)}
+	},
+}
+
+// statusWriter 记录 Handler 写出的状态码
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap 供 http.ResponseController 找到底层的 ResponseWriter (Flush、SetWriteDeadline 等)
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// New 创建一个写入 w 的访问日志，每 sample 个请求记录 1 个 (sample <= 1 表示全部记录)
+// 创建后即开启，后台 goroutine 一直运行到 Close
+func New(w io.Writer, sample int) *Log {
+	a := &Log{
+		w:       w,
+		sampler: zlog.NewSampler(sample),
+		lines:   make(chan *accessEntry, accessQueueSize),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	a.enabled.Store(true)
+	go a.flush()
+	return a
+}
+
+// SetEnabled 开启或关闭访问日志，可以在运行中随时调用；关闭后 Wrap 的中间件直接调用下一个 Handler
+func (a *Log) SetEnabled(on bool) {
+	a.enabled.Store(on)
+}
+
+// Enabled 报告访问日志是否开启
+func (a *Log) Enabled() bool {
+	return a.enabled.Load()
+}
+
+// Wrap 返回记录访问日志的 Handler
+func (a *Log) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled.Load() {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ent := accessPool.Get().(*accessEntry)
+		ent.sw = statusWriter{ResponseWriter: w}
+		start := time.Now()
+		h.ServeHTTP(&ent.sw, r)
+		a.record(ent, r, time.Since(start))
+	})
+}
+
+// record 编码一行并交给后台 goroutine，被采样丢弃或队列已满时直接归还 ent
+func (a *Log) record(ent *accessEntry, r *http.Request, dur time.Duration) {
+	status := ent.sw.status
+	if status == 0 {
+		// Handler 什么都没写，net/http 会补一个 200
+		status = http.StatusOK
+	}
+	lv := zlog.InfoLevel
+	switch {
+	case status >= 500:
+		lv = zlog.ErrorLevel
+	case status >= 400:
+		lv = zlog.WarnLevel
+	}
+	corr := headerValue(ent.sw.Header(), corrIDKey)
+	if corr == "" {
+		corr = headerValue(r.Header, corrIDKey)
+	}
+	if corr == "" {
+		corr = "-"
+	}
+	ent.sw = statusWriter{}
+	l := zlog.Wrap(ent.line[:0]).Sample(a.sampler, lv)
+	if l == nil {
+		accessPool.Put(ent)
+		return
+	}
+	l.Time("ts", zlog.TimeRFC3339).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Int("status", status).
+		Int("dur_us", int(dur/time.Microsecond)).
+		Str("corr_id", corr).
+		Msg("access")
+	ent.line = l.Bytes()
+	select {
+	case a.lines <- ent:
+	default:
+		a.dropped.Add(1)
+		accessPool.Put(ent)
+	}
+}
+
+// corrIDKey 是 core.CorrIDHeader 的规范形式；Header.Get 每次都要规范化 key，
+// 对 "X-Correlation-ID" 这种不规范的写法会分配一个新字符串，这里事先算好直接查 map
+var corrIDKey = http.CanonicalHeaderKey(core.CorrIDHeader)
+
+// headerValue 与 h.Get 相同，但 key 必须已经是规范形式
+func headerValue(h http.Header, key string) string {
+	if v := h[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// flush 是后台 goroutine：写出队列中的行，队列取空时 Flush 一次
+func (a *Log) flush() {
+	defer close(a.done)
+	bw := bufio.NewWriterSize(a.w, 64*1024)
+	for {
+		select {
+		case ent := <-a.lines:
+			a.writeEntry(bw, ent)
+			if len(a.lines) == 0 {
+				bw.Flush()
+			}
+		case <-a.quit:
+			for {
+				select {
+				case ent := <-a.lines:
+					a.writeEntry(bw, ent)
+				default:
+					bw.Flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeEntry 把 ent 中的行写入 bw 并归还 ent
+// 写失败的行直接丢弃：bufio.Writer 出错后不再写出，日志不能反过来影响请求
+func (a *Log) writeEntry(bw *bufio.Writer, ent *accessEntry) {
+	if len(ent.line) > 0 {
+		if _, err := bw.Write(ent.line); err == nil {
+			a.written.Add(1)
+		}
+	}
+	accessPool.Put(ent)
+}
+
+// Close 写出所有已记录的行后停止后台 goroutine
+// 之后的请求照常处理，但它们的日志不再写出；重复调用是安全的
+func (a *Log) Close() {
+	a.once.Do(func() {
+		a.enabled.Store(false)
+		close(a.quit)
+	})
+	<-a.done
+}
+
+// Written 返回已写出的行数
+func (a *Log) Written() uint64 {
+	return a.written.Load()
+}
+
+// Dropped 返回因后台队列已满而丢弃的行数 (不含被采样丢弃的)
+func (a *Log) Dropped() uint64 {
+	return a.dropped.Load()
+}
@@ -0,0 +1,48 @@
+package accesslog
+
+import (
+	"arena_demo/pkg/core"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// 每个请求一行访问日志，字段齐全：corr_id 优先取 Handler 回写的，其次取请求的；关闭时不记录
+func TestAccessLogOneLinePerRequest(t *testing.T) {
+	var out bytes.Buffer
+	al := New(&out, 1)
+	h := al.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/order" {
+			w.Header().Set(core.CorrIDHeader, "42")
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/order?uid=1", nil))
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set(core.CorrIDHeader, "req-7")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	al.SetEnabled(false)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ignored", nil))
+	al.Close()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []*regexp.Regexp{
+		regexp.MustCompile(`^ts=\S+Z method=POST path=/order status=201 dur_us=\d+ corr_id=42 msg=access$`),
+		regexp.MustCompile(`^ts=\S+Z method=GET path=/stats status=200 dur_us=\d+ corr_id=req-7 msg=access$`),
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d access-log lines, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for i, re := range want {
+		if !re.MatchString(lines[i]) {
+			t.Errorf("line %d = %q, want %s", i, lines[i], re)
+		}
+	}
+	if al.Written() != 2 || al.Dropped() != 0 {
+		t.Fatalf("Written = %d, Dropped = %d; want 2 and 0", al.Written(), al.Dropped())
+	}
+}
@@ -0,0 +1,88 @@
+// Package alloc 在 Arena 之上加一层堆兜底：Arena 放得下时分配在 Arena 上，放不下时退回 Go 堆
+//
+// Arena 写满时 arena.New / MakeSlice 直接 panic，一个异常大的任务就会整体失败。
+// 开启 Fallback 后，这一次放不下的分配改为普通的 new/make：任务照常完成，代价是这块内存重新受 GC 管理。
+// 每次兜底都会计数并调用 OnFallback，用来记录日志、据此调大 Arena (见 arena.AcquireSized / core.SetTaskArena)。
+//
+//	al := &alloc.Allocator{Mem: mem, Fallback: true}
+//	buf := alloc.MakeSlice[float64](al, 0, n) // n 很大时落在堆上
+//
+// 注意：
+//   - 兜底得到的内存不会随 Arena Reset 失效，但也不应在任务结束后继续使用 (与 Arena 上的内存同等对待)
+//   - Fallback 为 false 时行为与直接使用 Arena 完全相同 (空间不足时 panic)
+//   - Allocator 不是并发安全的，与它的 Arena 一样只由一个 goroutine 使用；计数器可以跨 goroutine 读取
+package alloc
+
+import (
+	"arena_demo/pkg/arena"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Allocator 优先在 Mem 上分配，Fallback 为 true 时空间不足退回堆分配
+type Allocator struct {
+	// Mem 是当前使用的 Arena
+	Mem *arena.Arena
+	// Fallback 为 true 时 Arena 空间不足退回堆分配，否则 panic
+	Fallback bool
+	// OnFallback 非空时在每次退回堆分配之后调用，size 为这次分配的字节数
+	OnFallback func(size int)
+
+	heapAllocs atomic.Uint64
+	heapBytes  atomic.Uint64
+}
+
+// New 分配一个清零的 T，优先使用 Arena
+func New[T any](al *Allocator) *T {
+	p, err := arena.TryNew[T](al.Mem)
+	if err == nil {
+		return p
+	}
+	if !al.Fallback {
+		panic("arena: out of memory")
+	}
+	var zero T
+	al.fellBack(int(unsafe.Sizeof(zero)))
+	return new(T)
+}
+
+// MakeSlice 分配一个 [0, capacity) 清零的切片，优先使用 Arena
+func MakeSlice[T any](al *Allocator, length, capacity int) []T {
+	s, err := arena.TryMakeSlice[T](al.Mem, length, capacity)
+	if err == nil {
+		return s
+	}
+	return heapSlice[T](al, length, capacity)
+}
+
+// MakeSliceNoZero 与 MakeSlice 相同，但在 Arena 上分配时不清零 (堆上的总是清零的)
+func MakeSliceNoZero[T any](al *Allocator, length, capacity int) []T {
+	var zero T
+	if al.Mem.CanFit(int(unsafe.Sizeof(zero))*capacity, int(unsafe.Alignof(zero))) {
+		return arena.MakeSliceNoZero[T](al.Mem, length, capacity)
+	}
+	return heapSlice[T](al, length, capacity)
+}
+
+// heapSlice 是切片在 Arena 放不下时的兜底
+func heapSlice[T any](al *Allocator, length, capacity int) []T {
+	if !al.Fallback {
+		panic("arena: out of memory")
+	}
+	var zero T
+	al.fellBack(int(unsafe.Sizeof(zero)) * capacity)
+	return make([]T, length, capacity)
+}
+
+func (al *Allocator) fellBack(size int) {
+	al.heapAllocs.Add(1)
+	al.heapBytes.Add(uint64(size))
+	if al.OnFallback != nil {
+		al.OnFallback(size)
+	}
+}
+
+// HeapAllocs 返回累计退回堆分配的次数与字节数，可在任意 goroutine 调用
+func (al *Allocator) HeapAllocs() (count, bytes uint64) {
+	return al.heapAllocs.Load(), al.heapBytes.Load()
+}
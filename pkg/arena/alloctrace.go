@@ -0,0 +1,70 @@
+package arena
+
+import "reflect"
+
+// AllocInfo 描述一次分配 (调试用)
+type AllocInfo struct {
+	Size   int    // 请求的字节数
+	Align  int    // 对齐要求
+	Offset int    // 分配结果在 Arena 中的起始偏移 (已包含对齐填充)
+	Type   string // 分配的类型 (切片为 "[]T")，不经过 New/MakeSlice 等泛型入口的分配为空
+
+	gen uint64 // 分配时 Arena 的代数，DumpHex 据此忽略 Reset 之前的记录
+}
+
+// allocTraceSize 是调试构建下记录的最近分配条数
+const allocTraceSize = 64
+
+// allocTrace 是最近 allocTraceSize 次分配的环形记录
+type allocTrace struct {
+	ring [allocTraceSize]AllocInfo
+	n    uint64 // 累计记录次数
+}
+
+// note 记录一次分配；非调试构建下只剩累计计数和 EnableCompaction 的记录 (未开启时一次 nil 判断)
+func (a *Arena) note(size, align, offset int) {
+	a.allocs++
+	a.bytes += uint64(size)
+	if a.live != nil {
+		a.live.record(offset, size, align)
+	}
+	if !debug {
+		return
+	}
+	if a.trace == nil {
+		a.trace = &allocTrace{}
+	}
+	a.trace.ring[a.trace.n%allocTraceSize] = AllocInfo{Size: size, Align: align, Offset: offset, gen: a.gen}
+	a.trace.n++
+}
+
+// noteType 为刚刚记录的那次分配补上类型名 (仅调试构建)
+func noteType[T any](a *Arena, slice bool) {
+	if !debug || a.trace == nil || a.trace.n == 0 {
+		return
+	}
+	name := reflect.TypeFor[T]().String()
+	if slice {
+		name = "[]" + name
+	}
+	a.trace.ring[(a.trace.n-1)%allocTraceSize].Type = name
+}
+
+// LastAllocations 按时间顺序 (从旧到新) 返回最近的分配记录，最多 64 条
+// 仅在调试构建 (-tags arena_debug) 下有数据，生产构建总是返回 nil
+// 用于排查 "到底是谁吃掉了 64MB"，通常在 out of memory panic 前后调用
+func (a *Arena) LastAllocations() []AllocInfo {
+	if !debug || a.trace == nil {
+		return nil
+	}
+	t := a.trace
+	count := t.n
+	if count > allocTraceSize {
+		count = allocTraceSize
+	}
+	out := make([]AllocInfo, 0, count)
+	for i := t.n - count; i < t.n; i++ {
+		out = append(out, t.ring[i%allocTraceSize])
+	}
+	return out
+}
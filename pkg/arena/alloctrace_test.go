@@ -0,0 +1,37 @@
+package arena
+
+import "testing"
+
+// 调试构建下记录最近 64 次分配 (从旧到新)，生产构建下没有记录
+//
+//	go test -tags arena_debug -run LastAllocations ./pkg/arena
+func TestLastAllocations(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+
+	const n = allocTraceSize + 6
+	for i := 1; i <= n; i++ {
+		MakeSlice[byte](a, i, i)
+	}
+	got := a.LastAllocations()
+	if !debug {
+		if got != nil {
+			t.Fatalf("release build recorded %d allocations", len(got))
+		}
+		return
+	}
+	if len(got) != allocTraceSize {
+		t.Fatalf("len(LastAllocations) = %d, want %d", len(got), allocTraceSize)
+	}
+	for i, info := range got {
+		if want := n - allocTraceSize + 1 + i; info.Size != want || info.Type != "[]uint8" {
+			t.Fatalf("record %d = %+v, want a []uint8 of size %d", i, info, want)
+		}
+		if i > 0 && info.Offset < got[i-1].Offset+got[i-1].Size {
+			t.Fatalf("record %d at offset %d overlaps the previous one", i, info.Offset)
+		}
+	}
+	if last := got[len(got)-1]; last.Offset+last.Size != a.Used() {
+		t.Fatalf("newest record ends at %d, Used = %d", last.Offset+last.Size, a.Used())
+	}
+}
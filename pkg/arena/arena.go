@@ -1,7 +1,9 @@
 package arena
 
 import (
+	"errors"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
@@ -9,104 +11,409 @@ import (
 type Arena struct {
 	buf    []byte
 	offset int
+	high   int    // 历史最高水位 (Reset 不清零)
+	allocs uint64 // 累计分配次数 (Reset 不清零，见 TotalAllocs)
+	bytes  uint64 // 累计分配字节数 (Reset 不清零，见 TotalBytes)
+	gen    uint64 // 代数，每次 Reset +1，用于检测 use-after-reset
+	scopes int    // 当前打开的 Scope 层数
+
+	acquireGen uint64 // 借出代数，每次从池中 Acquire +1，用于检测跨池的所有权错误
+
+	trace *allocTrace // 最近分配记录，仅调试构建使用
+	live  *liveTable  // 非空表示已开启碎片整理 (见 EnableCompaction)
+
+	handles *handleTable // NewHandle 的记录，首次调用时创建
+
+	mapping []byte // 非空表示 buf 来自文件映射 (见 AcquireMapped)，包含文件头
+
+	parent *Arena // 非空表示这是从 parent 切出的子区域 (见 Region)
+
+	sealed *sealState // 非空表示已密封为只读 (见 Seal)
 }
 
+// defaultSize 是池中每个 Arena 的默认大小 (64MB)
+const defaultSize = 64 * 1024 * 1024
+
 // 全局对象池，复用 Arena 对象本身及其底层的 buf
 // 避免反复向 OS 申请大块内存
 var arenaPool = sync.Pool{
 	New: func() any {
+		poolCreated.Add(1)
+		poolBytes.Add(defaultSize)
 		// 默认分配 64MB 的块，根据需要调整
 		return &Arena{
-			buf:    make([]byte, 64*1024*1024),
+			buf:    make([]byte, defaultSize),
 			offset: 0,
 		}
 	},
 }
 
-// Acquire 从全局池中借出一个 Arena
+// 池统计 (sync.Pool 本身不暴露大小，只能自己记录 Acquire/Release 的差值)
+var (
+	poolCreated  atomic.Int64 // Pool.New 创建过的 Arena 总数
+	poolBytes    atomic.Int64 // Pool.New 创建过的 Arena 总字节数
+	poolAcquired atomic.Int64
+	poolReleased atomic.Int64
+)
+
+// Acquire 从全局池中借出一个 Arena (开启 EnableLocalCache 时先查当前 P 的缓存槽位)
 // 必须配合 Release 使用
 func Acquire() *Arena {
-	return arenaPool.Get().(*Arena)
+	a := localGet()
+	if a == nil {
+		a = arenaPool.Get().(*Arena)
+	}
+	a.acquireGen++
+	poolAcquired.Add(1)
+	return a
 }
 
 // Release 重置 Arena 并归还给全局池
 // 调用后，之前通过该 Arena 分配的所有指针都将失效（逻辑上）
 // 严禁在 Release 后继续使用这些指针！
+// 文件映射的 Arena (AcquireMapped) 不会被重置或归还，而是 Sync 后解除映射
 func (a *Arena) Release() {
+	if a.parent != nil {
+		panic("arena: Release of region")
+	}
+	if a.mapping != nil {
+		_ = a.unmap()
+		return
+	}
+	if a.sealed != nil {
+		// 保护不撤销就归还，下一个借用者的第一次写入就会崩溃
+		if err := a.Unseal(); err != nil {
+			panic("arena: Release of sealed arena: " + err.Error())
+		}
+	}
 	a.Reset()
-	arenaPool.Put(a)
+	a.live = nil
+	a.handles = nil
+	poolReleased.Add(1)
+	// AcquireSized 借出的 Arena 回到各自的档，不能混进默认大小的池
+	if !putSized(a) && !localPut(a) {
+		arenaPool.Put(a)
+	}
+}
+
+// PoolStats 返回全局池的统计快照
+//   - inUse:  当前被借出未归还的 Arena 个数
+//   - pooled: 当前闲置在池中的 Arena 个数
+//   - bytes:  池创建过的所有 Arena 的总内存 (借出 + 闲置)
+//
+// 注意：sync.Pool 在 GC 时可能悄悄丢弃闲置对象，所以 pooled/bytes 是上界
+func PoolStats() (inUse, pooled int, bytes int64) {
+	created := poolCreated.Load()
+	bytes = poolBytes.Load()
+	released := poolReleased.Load()
+	acquired := poolAcquired.Load()
+	inUse = int(acquired - released)
+	pooled = int(created) - inUse
+	if pooled < 0 {
+		pooled = 0
+	}
+	return inUse, pooled, bytes
 }
 
 // Reset 仅重置偏移量，不归还给 Pool
 // 适用于同一个 Arena 被同一个线程反复复用的场景
 func (a *Arena) Reset() {
+	if a.offset > a.high {
+		a.high = a.offset
+	}
 	a.offset = 0
+	a.gen++
+	a.scopes = 0
 }
 
-// New 在 Arena 上分配一个 T 类型对象
-// 返回 *T
-func New[T any](a *Arena) *T {
-	var zero T
-	size := int(unsafe.Sizeof(zero))
-	align := int(unsafe.Alignof(zero))
+// Generation 返回当前代数 (每次 Reset 递增)
+func (a *Arena) Generation() uint64 {
+	return a.gen
+}
 
-	// 处理对齐
+// AcquireGen 返回借出代数 (每次从池中 Acquire 递增)
+// 组件可以在拿到 Arena 时记下它，之后断言没有变化：变了说明这个 Arena 已经被 Release 并被别人重新借走，
+// 手里的指针/Handle 属于上一个持有者。Reset 不改变借出代数
+func (a *Arena) AcquireGen() uint64 {
+	return a.acquireGen
+}
+
+// Used 返回当前已分配的字节数 (包含对齐填充)
+// Arena 不是并发安全的，只能由持有者读取；跨线程观测请使用引擎发布的统计
+func (a *Arena) Used() int {
+	return a.offset
+}
+
+// Cap 返回 Arena 的总容量
+func (a *Arena) Cap() int {
+	return len(a.buf)
+}
+
+// Remaining 返回剩余可分配的字节数 (未扣除下一次分配的对齐填充)，密封期间为 0
+func (a *Arena) Remaining() int {
+	if a.sealed != nil {
+		return 0
+	}
+	return len(a.buf) - a.offset
+}
+
+// CanFit 报告接下来一次 size 字节、按 align 对齐的分配能否成功 (已计入对齐填充)
+// 要做 "要么全部建成、要么不动" 的多次分配时，可先把各段按顺序累加后整体校验，避免建到一半 OOM panic
+// Arena 是固定大小的单块内存，不会扩容，所以这里的结果就是最终结果
+// align 必须是 2 的幂 (通常取 unsafe.Alignof)，否则 panic
+func (a *Arena) CanFit(size, align int) bool {
+	if align <= 0 || align&(align-1) != 0 {
+		panic("arena: align must be a power of two")
+	}
 	padding := (align - (a.offset % align)) % align
-	if a.offset+padding+size > len(a.buf) {
-		// 内存不足时的策略：
-		// 1. 简单 panic (当前实现)
-		// 2. 自动扩容 (分配更大的 buf 并链接起来，较复杂)
-		panic("arena: out of memory")
+	return size >= 0 && a.sealed == nil && a.offset+padding+size <= len(a.buf)
+}
+
+// HighWater 返回自创建以来的最高使用量
+func (a *Arena) HighWater() int {
+	if a.offset > a.high {
+		return a.offset
 	}
+	return a.high
+}
 
-	a.offset += padding
-	ptr := unsafe.Pointer(&a.buf[a.offset])
-	a.offset += size
+// TotalAllocs 返回自创建以来的累计分配次数，Reset/Release 都不清零
+// 与 TotalBytes 一起按时间差分即得到分配速率 (次/秒、字节/秒)：HighWater 只反映峰值，
+// 某类任务每次都比预期多分配几倍时峰值可能不变，速率却会明显上升
+// 与 Used 相同只能由持有者读取
+func (a *Arena) TotalAllocs() uint64 {
+	return a.allocs
+}
+
+// TotalBytes 返回自创建以来累计分配的字节数 (按请求的大小，不含对齐填充)，Reset/Release 都不清零
+func (a *Arena) TotalBytes() uint64 {
+	return a.bytes
+}
+
+// 分配函数成对提供，区别只在是否清零：
+//   - New / MakeSlice:             内存清零，与 new / make 语义一致 (默认选择)
+//   - NewNoZero / MakeSliceNoZero: 不清零，内容是上一次 Reset 前留下的脏数据
+//
+// NoZero 版本只适合调用方马上完整覆盖的场景 (如整体赋值一个大结构体、copy 填满切片)，
+// 省掉一次与对象大小成正比的清零；读到未覆盖的部分是未定义行为
+
+// New 在 Arena 上分配一个清零的 T 类型对象
+// 返回 *T
+func New[T any](a *Arena) *T {
+	p := NewNoZero[T](a)
 
 	// 必须清零内存，因为这是复用的 buf，可能包含脏数据
 	// 对于小对象，编译器通常会优化这个 clear 操作
-	*(*T)(ptr) = zero
+	var zero T
+	*p = zero
 
-	return (*T)(ptr)
+	return p
+}
+
+// NewNoZero 与 New 相同，但不清零
+func NewNoZero[T any](a *Arena) *T {
+	checkNoPointers[T]()
+	var zero T
+	p := (*T)(a.alloc(int(unsafe.Sizeof(zero)), int(unsafe.Alignof(zero))))
+	noteType[T](a, false)
+	return p
 }
 
-// MakeSlice 在 Arena 上分配一个 T 类型的切片
+// MakeSlice 在 Arena 上分配一个 T 类型的切片，[0, capacity) 全部清零
 // length: 切片长度, capacity: 切片容量
+//
+// 偏移按 capacity 推进，所以 [length, capacity) 这段已经预留，append 到 cap 为止都写在 Arena 上。
+// 但 append 超过 cap 时 Go 会悄悄在堆上重新分配并拷贝，之后的数据就不在 Arena 上了 (GC 压力回来了)，
+// 编译器和运行时都不会告警。可以用 CheckSlice 检查，或用 MakeSliceTracked + Slice.Append (调试构建下自动检查)
 func MakeSlice[T any](a *Arena, length, capacity int) []T {
+	s := MakeSliceNoZero[T](a, length, capacity)
+
+	// 清零切片内存
+	// T 不含指针 (checkNoPointers)，clear 编译为 runtime.memclrNoHeapPointers (向量化，1MB 约 20µs)，
+	// 比逐元素赋零的循环快一个数量级，不要改写成循环；
+	// 注意：对于大块内存，清零仍有与大小成正比的开销，如果确认会立即覆盖请使用 MakeSliceNoZero
+	clear(s[:capacity])
+
+	return s
+}
+
+// MakeSliceNoZero 与 MakeSlice 相同，但不清零
+func MakeSliceNoZero[T any](a *Arena, length, capacity int) []T {
+	checkNoPointers[T]()
 	var zero T
-	elemSize := int(unsafe.Sizeof(zero))
-	elemAlign := int(unsafe.Alignof(zero))
+	basePtr := a.alloc(int(unsafe.Sizeof(zero))*capacity, int(unsafe.Alignof(zero)))
+	noteType[T](a, true)
+
+	// 使用 unsafe.Slice 构造切片头 (Go 1.17+)，而不是手工拼 SliceHeader
+	return unsafe.Slice((*T)(basePtr), capacity)[:length]
+}
+
+// TryNew 与 New 相同，但 Arena 空间不足时返回 ErrArenaFull 而不是 panic
+// 供库代码使用：调用方可以自己决定如何降级 (如改为堆分配)
+// 热路径上 OOM 属于编程错误，仍然应该使用 New
+func TryNew[T any](a *Arena) (*T, error) {
+	checkNoPointers[T]()
+	var zero T
+	ptr, ok := a.tryAlloc(int(unsafe.Sizeof(zero)), int(unsafe.Alignof(zero)))
+	if !ok {
+		return nil, ErrArenaFull
+	}
+	noteType[T](a, false)
+	p := (*T)(ptr)
+	*p = zero
+	return p, nil
+}
+
+// TryMakeSlice 与 MakeSlice 相同，但 Arena 空间不足时返回 ErrArenaFull 而不是 panic
+func TryMakeSlice[T any](a *Arena, length, capacity int) ([]T, error) {
+	checkNoPointers[T]()
+	var zero T
+	basePtr, ok := a.tryAlloc(int(unsafe.Sizeof(zero))*capacity, int(unsafe.Alignof(zero)))
+	if !ok {
+		return nil, ErrArenaFull
+	}
+	noteType[T](a, true)
+	s := unsafe.Slice((*T)(basePtr), capacity)
+	clear(s)
+	return s[:length], nil
+}
 
-	size := elemSize * capacity
+// ErrArenaFull Arena 剩余空间不足以完成这次分配 (TryNew / TryMakeSlice 返回)
+var ErrArenaFull = errors.New("arena: out of memory")
 
+// alloc 按 align 对齐分配 size 字节，返回起始地址 (不清零)
+func (a *Arena) alloc(size, align int) unsafe.Pointer {
+	ptr, ok := a.tryAlloc(size, align)
+	if !ok {
+		// 内存不足时的策略：
+		// 1. 简单 panic (当前实现，需要降级的调用方使用 TryNew / TryMakeSlice)
+		// 2. 自动扩容 (分配更大的 buf 并链接起来，较复杂)
+		//    实现时注意对齐：这里的 padding 按偏移计算，只有 buf 起始地址至少 CacheLine 对齐时才等价于按地址对齐
+		//    (现有的 buf 都满足：池中的 make、AcquireSized、文件映射的数据区、按 regionAlign 切出的子区域)；
+		//    新的块同样必须 CacheLine 对齐，偏移在块边界归零，allocAligned 的 alignedOffset 要改用当前块的起始地址
+		a.outOfMemory()
+	}
+	return ptr
+}
+
+// tryAlloc 是 alloc 的实现，空间不足时返回 ok=false，不修改偏移量
+func (a *Arena) tryAlloc(size, align int) (unsafe.Pointer, bool) {
 	// 处理对齐
-	padding := (elemAlign - (a.offset % elemAlign)) % elemAlign
-	if a.offset+padding+size > len(a.buf) {
-		panic("arena: out of memory")
+	padding := (align - (a.offset % align)) % align
+	if size < 0 || a.offset+padding+size > len(a.buf) || a.sealed != nil {
+		return nil, false
 	}
 
 	a.offset += padding
-	basePtr := unsafe.Pointer(&a.buf[a.offset])
+	a.note(size, align, a.offset)
+	ptr := unsafe.Add(unsafe.Pointer(unsafe.SliceData(a.buf)), a.offset)
 	a.offset += size
+	return ptr, true
+}
+
+// Pop 按栈 (LIFO) 纪律释放最近一次分配的 size 字节
+// 介于 "逐个释放" 和 "整体 Reset" 之间的折中：只回退偏移量，成本为 O(1)
+// 调用者必须保证按分配的逆序 Pop，且 size 与分配时一致；
+// 对齐产生的填充不会被回收，下一次分配时会重新对齐
+func (a *Arena) Pop(size int) {
+	if size < 0 || size > a.offset {
+		panic("arena: pop underflow")
+	}
+	a.offset -= size
+}
 
-	// 构造切片头
-	// sliceHeader := struct {
-	// 	Data uintptr
-	// 	Len  int
-	// 	Cap  int
-	// }{uintptr(basePtr), length, capacity}
-	// return *(*[]T)(unsafe.Pointer(&sliceHeader))
+// PopValue 释放通过 New 得到的最后一个对象 p
+// 会校验 p 确实位于栈顶，防止误释放中间的对象
+func PopValue[T any](a *Arena, p *T) {
+	size := int(unsafe.Sizeof(*p))
+	end := int(uintptr(unsafe.Pointer(p))-uintptr(unsafe.Pointer(unsafe.SliceData(a.buf)))) + size
+	if end != a.offset {
+		panic("arena: pop of non-top allocation")
+	}
+	a.Pop(size)
+}
 
-	// 使用 unsafe.Slice 更安全 (Go 1.17+)
-	s := unsafe.Slice((*T)(basePtr), capacity)
+// OffsetOf 返回 p 相对于 Arena 起始地址的偏移，配合 At 使用
+// (持久化 Arena 重新映射后地址会变，只有偏移是稳定的)
+func OffsetOf[T any](a *Arena, p *T) int {
+	return int(uintptr(unsafe.Pointer(p)) - uintptr(unsafe.Pointer(unsafe.SliceData(a.buf))))
+}
+
+// At 返回偏移 off 处的 *T，用于取回持久化 Arena 中上次写入的数据
+// off 必须来自 OffsetOf (或按分配顺序推算)，且 [off, off+sizeof(T)) 必须位于已分配区域内
+func At[T any](a *Arena, off int) *T {
+	var zero T
+	if off < 0 || off+int(unsafe.Sizeof(zero)) > a.offset || off%int(unsafe.Alignof(zero)) != 0 {
+		panic("arena: At out of range")
+	}
+	return (*T)(unsafe.Pointer(&a.buf[off]))
+}
+
+// SliceFrom 把 Arena 上从 ptr 开始的 n 个连续的 T 还原为切片 (如只保存了首元素指针的数组)
+// 之后的下标访问都有 Go 的边界检查，不必再做 unsafe.Add 指针运算
+//
+// 调试构建下检查 [ptr, ptr+n*sizeof(T)) 完全落在已分配区域内，越界 (n 过大、ptr 不属于该 Arena) 时 panic；
+// 正式构建中检查被消除，等同于 unsafe.Slice(ptr, n)，n 是否正确由调用方负责
+func SliceFrom[T any](a *Arena, ptr *T, n int) []T {
+	if debug {
+		var zero T
+		base := uintptr(unsafe.Pointer(unsafe.SliceData(a.buf)))
+		end := base + uintptr(a.offset)
+		p, size := uintptr(unsafe.Pointer(ptr)), unsafe.Sizeof(zero)
+		// 用除法比较，n 很大时 n*size 不会溢出绕过检查
+		if n < 0 || p < base || p > end || size > 0 && uintptr(n) > (end-p)/size {
+			panic("arena: SliceFrom out of range")
+		}
+	}
+	return unsafe.Slice(ptr, n)
+}
 
-	// 清零切片内存 (如果需要)
-	// 注意：对于大块内存，清零可能有开销，如果确认会立即覆盖可跳过
-	// 这里为了安全默认清零
-	var empty T
-	for i := 0; i < capacity; i++ {
-		s[i] = empty
+// PlaceAt 在指定偏移 off 处放置一个 T (Placement New)，不移动分配游标，也不清零
+// 用于精确控制内存布局 (如在固定偏移处写报文头)；与 At 不同，off 可以位于尚未分配的区域，
+// 只要求 [off, off+sizeof(T)) 落在 Arena 容量以内且满足 T 的对齐
+// 注意：Arena 不知道这块内存已被占用，之后的 New/MakeSlice 可能分配到同一位置，
+// 是否与其它分配重叠完全由调用方负责 (通常先用 MakeSlice 预留一整段，再在其中 PlaceAt)
+func PlaceAt[T any](a *Arena, off int) *T {
+	checkNoPointers[T]()
+	var zero T
+	// 与 len-size 比较而不是 off+size，避免 off 接近 MaxInt 时加法溢出绕过检查
+	if off < 0 || off > len(a.buf)-int(unsafe.Sizeof(zero)) {
+		panic("arena: PlaceAt out of range")
 	}
+	if off%int(unsafe.Alignof(zero)) != 0 {
+		panic("arena: PlaceAt misaligned")
+	}
+	return (*T)(unsafe.Add(unsafe.Pointer(unsafe.SliceData(a.buf)), off))
+}
+
+// MakeSliceMax 分配一个长度为 0、容量至少为 minCap 的切片，
+// 并把容量扩展到 Arena 剩余的全部空间，让后续 append 拥有最大的余量而不会逃逸到堆上
+// 实际容量即 cap(返回值)；调用后 Arena 被占满，直到 Reset/Pop 前无法再分配
+//
+// 注意：为了避免清零整个剩余区域 (可能有几十 MB)，容量部分不会被清零，
+// 只能通过 append 写入，不要把它 reslice 到 len 之外去读取
+func MakeSliceMax[T any](a *Arena, minCap int) []T {
+	checkNoPointers[T]()
+	var zero T
+	elemSize := int(unsafe.Sizeof(zero))
+	elemAlign := int(unsafe.Alignof(zero))
+
+	padding := (elemAlign - (a.offset % elemAlign)) % elemAlign
+	start := a.offset + padding
+	if start+minCap*elemSize > len(a.buf) || a.sealed != nil {
+		a.outOfMemory()
+	}
+	// 零大小的元素不占空间，也不能作除数
+	capacity := minCap
+	if elemSize != 0 {
+		capacity = (len(a.buf) - start) / elemSize
+	}
+
+	a.offset = start
+	a.note(capacity*elemSize, elemAlign, a.offset)
+	noteType[T](a, true)
+	basePtr := unsafe.Add(unsafe.Pointer(unsafe.SliceData(a.buf)), a.offset)
+	a.offset += capacity * elemSize
 
-	return s[:length]
+	return unsafe.Slice((*T)(basePtr), capacity)[:0]
 }
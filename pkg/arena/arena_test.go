@@ -0,0 +1,350 @@
+package arena
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// 并发 Acquire/Release 时池统计不丢计数：全部借出时 inUse 增加 workers*per，全部归还后回到原值
+func TestPoolStatsConcurrent(t *testing.T) {
+	const workers, per = 8, 16
+	baseInUse, _, baseBytes := PoolStats()
+
+	var held, released sync.WaitGroup
+	held.Add(workers)
+	released.Add(workers)
+	release := make(chan struct{})
+	for range workers {
+		go func() {
+			defer released.Done()
+			as := make([]*Arena, 0, per)
+			for range per {
+				as = append(as, AcquireSized(4096))
+			}
+			held.Done()
+			<-release
+			for _, a := range as {
+				a.Release()
+			}
+		}()
+	}
+	held.Wait()
+	inUse, pooled, bytes := PoolStats()
+	if inUse != baseInUse+workers*per {
+		t.Fatalf("inUse = %d while all held, want %d", inUse, baseInUse+workers*per)
+	}
+	if bytes < baseBytes || pooled < 0 {
+		t.Fatalf("bytes = %d (was %d), pooled = %d", bytes, baseBytes, pooled)
+	}
+	close(release)
+	released.Wait()
+	if inUse, _, _ := PoolStats(); inUse != baseInUse {
+		t.Fatalf("inUse = %d after releasing everything, want %d", inUse, baseInUse)
+	}
+}
+
+// 按分配的逆序 Pop，偏移量逐级回退，之后的分配复用同一块内存
+func TestPopLIFO(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	base := a.Used()
+	x := New[uint64](a)
+	mid := a.Used()
+	y := New[uint64](a)
+	z := New[[3]uint64](a)
+	PopValue(a, z)
+	PopValue(a, y)
+	if a.Used() != mid {
+		t.Fatalf("Used after popping y, z = %d, want %d", a.Used(), mid)
+	}
+	if y2 := New[uint64](a); y2 != y {
+		t.Fatal("allocation after Pop did not reuse the popped slot")
+	}
+	a.Pop(8)
+	PopValue(a, x)
+	if a.Used() != base {
+		t.Fatalf("Used after popping everything = %d, want %d", a.Used(), base)
+	}
+}
+
+func TestPopGuards(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	x := New[uint64](a)
+	New[uint64](a)
+	if r := catchPanic(func() { PopValue(a, x) }); r != "arena: pop of non-top allocation" {
+		t.Fatalf("PopValue of non-top: panic = %v", r)
+	}
+	if r := catchPanic(func() { a.Pop(a.Used() + 1) }); r != "arena: pop underflow" {
+		t.Fatalf("Pop past the start: panic = %v", r)
+	}
+}
+
+func TestMakeSliceMaxTakesRemaining(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	New[byte](a) // 让起点需要对齐填充
+
+	want := (a.Remaining() - (8 - a.Used()%8)) / 8
+	s := MakeSliceMax[uint64](a, 10)
+	if len(s) != 0 || cap(s) != want {
+		t.Fatalf("len = %d, cap = %d; want 0, %d", len(s), cap(s), want)
+	}
+	if a.Remaining() >= 8 {
+		t.Fatalf("Remaining = %d after MakeSliceMax, want less than one element", a.Remaining())
+	}
+	p := &s[:1][0]
+	for i := range cap(s) {
+		s = append(s, uint64(i))
+	}
+	if &s[0] != p || !Owns(a, s) {
+		t.Fatal("append within the returned capacity left the arena")
+	}
+
+	if r := catchPanic(func() { MakeSliceMax[uint64](a, 1) }); r != "arena: out of memory" {
+		t.Fatalf("MakeSliceMax on a full arena: panic = %v", r)
+	}
+
+	// 零大小的元素：容量就是 minCap，不占用 Arena
+	b := AcquireSized(64)
+	defer b.Release()
+	if z := MakeSliceMax[struct{}](b, 5); len(z) != 0 || cap(z) != 5 || b.Used() != 0 {
+		t.Fatalf("zero-size elements: len = %d, cap = %d, used = %d; want 0, 5, 0", len(z), cap(z), b.Used())
+	}
+}
+
+// bigStruct 是清零开销明显的大结构体
+type bigStruct struct {
+	ID   uint64
+	Data [4096]byte
+}
+
+// Reset 后同一位置重新分配：New 读到零值，NewNoZero 读到上一代留下的数据
+func TestNewNoZeroKeepsDirtyMemory(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+
+	p := New[bigStruct](a)
+	p.ID = 42
+	p.Data[4095] = 7
+	a.Reset()
+	if q := NewNoZero[bigStruct](a); q != p || q.ID != 42 || q.Data[4095] != 7 {
+		t.Fatalf("NewNoZero at %p (first at %p): ID = %d, last byte = %d", q, p, q.ID, q.Data[4095])
+	}
+	a.Reset()
+	if q := New[bigStruct](a); q.ID != 0 || q.Data[4095] != 0 {
+		t.Fatalf("New returned dirty memory: ID = %d, last byte = %d", q.ID, q.Data[4095])
+	}
+}
+
+// Try 系列成功时与 New/MakeSlice 一样返回清零的 Arena 内存，空间不足时返回 ErrArenaFull 且不移动偏移量
+func TestTryAllocReportsFull(t *testing.T) {
+	a := AcquireSized(1 << 15)
+	defer a.Release()
+
+	dirty := MakeSlice[byte](a, a.Cap(), a.Cap())
+	for i := range dirty {
+		dirty[i] = 0xff
+	}
+	a.Reset()
+
+	s, err := TryMakeSlice[uint32](a, 3, 16)
+	if err != nil || len(s) != 3 || cap(s) != 16 || !Owns(a, s) {
+		t.Fatalf("TryMakeSlice = len %d cap %d, err %v; want a 3/16 arena slice", len(s), cap(s), err)
+	}
+	for i, v := range s[:cap(s)] {
+		if v != 0 {
+			t.Fatalf("TryMakeSlice returned dirty memory at %d: %#x", i, v)
+		}
+	}
+	var n int
+	for {
+		p, err := TryNew[bigStruct](a)
+		if err != nil {
+			if err != ErrArenaFull {
+				t.Fatalf("TryNew: err = %v, want ErrArenaFull", err)
+			}
+			break
+		}
+		if p.ID != 0 || p.Data[0] != 0 || !Owns(a, unsafe.Slice(p, 1)) {
+			t.Fatalf("TryNew %d returned dirty or foreign memory", n)
+		}
+		n++
+	}
+	if n == 0 {
+		t.Fatal("TryNew failed on an arena with room")
+	}
+
+	used := a.Used()
+	if _, err := TryMakeSlice[byte](a, 0, a.Remaining()+1); err != ErrArenaFull {
+		t.Fatalf("TryMakeSlice past capacity: err = %v, want ErrArenaFull", err)
+	}
+	if a.Used() != used {
+		t.Fatalf("failed allocation moved Used from %d to %d", used, a.Used())
+	}
+	if catchPanic(func() { New[bigStruct](a) }) == nil {
+		t.Fatal("New did not panic where TryNew reports ErrArenaFull")
+	}
+	if _, err := TryMakeSlice[byte](a, a.Remaining(), a.Remaining()); err != nil {
+		t.Fatalf("TryMakeSlice of exactly the remaining space: %v", err)
+	}
+}
+
+// 累计分配计数按请求的大小累加 (不含对齐填充)，Reset 不清零，失败的分配不计入
+func TestTotalAllocsSurviveReset(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	allocs, bytes := a.TotalAllocs(), a.TotalBytes() // 池中的 Arena 可能已有计数
+
+	New[byte](a)
+	New[uint64](a)
+	MakeSlice[uint32](a, 3, 10)
+	if da, db := a.TotalAllocs()-allocs, a.TotalBytes()-bytes; da != 3 || db != 1+8+40 {
+		t.Fatalf("after 3 allocations: +%d allocs, +%d bytes; want +3, +49", da, db)
+	}
+
+	a.Reset()
+	if da, db := a.TotalAllocs()-allocs, a.TotalBytes()-bytes; da != 3 || db != 49 {
+		t.Fatalf("Reset changed the totals to +%d allocs, +%d bytes", da, db)
+	}
+	if _, err := TryMakeSlice[byte](a, 0, a.Cap()+1); err == nil {
+		t.Fatal("oversized TryMakeSlice succeeded")
+	}
+	MakeSlice[byte](a, 100, 100)
+	if da, db := a.TotalAllocs()-allocs, a.TotalBytes()-bytes; da != 4 || db != 149 {
+		t.Fatalf("after Reset and one more allocation: +%d allocs, +%d bytes; want +4, +149", da, db)
+	}
+}
+
+// 调用方随后整体赋值时，NewNoZero 省掉的是一次 4KB 清零
+func BenchmarkNewLarge(b *testing.B) {
+	a := AcquireSized(1 << 20)
+	defer a.Release()
+	v := bigStruct{ID: 1}
+
+	b.Run("New", func(b *testing.B) {
+		b.SetBytes(int64(unsafe.Sizeof(v)))
+		for b.Loop() {
+			if a.Remaining() < int(unsafe.Sizeof(v)) {
+				a.Reset()
+			}
+			*New[bigStruct](a) = v
+		}
+	})
+	b.Run("NewNoZero", func(b *testing.B) {
+		b.SetBytes(int64(unsafe.Sizeof(v)))
+		for b.Loop() {
+			if a.Remaining() < int(unsafe.Sizeof(v)) {
+				a.Reset()
+			}
+			*NewNoZero[bigStruct](a) = v
+		}
+	})
+}
+
+// Reset 后在写满 0xFF 的内存上重新分配：MakeSlice 清零整个 [0, cap)，不只是 [0, len)
+func TestMakeSliceZeroesDirtyMemory(t *testing.T) {
+	a := AcquireSized(1 << 21)
+	defer a.Release()
+
+	dirty := MakeSliceNoZero[byte](a, a.Cap(), a.Cap())
+	for i := range dirty {
+		dirty[i] = 0xFF
+	}
+	a.Reset()
+	b := MakeSlice[byte](a, 10, 1<<20)
+	New[byte](a) // 奇数偏移之后的多字节元素
+	w := MakeSlice[uint64](a, 0, 1000)
+	s := MakeSlice[bigStruct](a, 3, 3)
+	for i, c := range b[:cap(b)] {
+		if c != 0 {
+			t.Fatalf("[]byte: element %d = %#x", i, c)
+		}
+	}
+	for i, v := range w[:cap(w)] {
+		if v != 0 {
+			t.Fatalf("[]uint64: element %d = %#x", i, v)
+		}
+	}
+	for i := range s {
+		if s[i] != (bigStruct{}) {
+			t.Fatalf("[]bigStruct: element %d not zeroed", i)
+		}
+	}
+}
+
+// zeroLoop 是 MakeSlice 改用 clear 之前的逐元素清零
+//
+//go:noinline
+func zeroLoop[T any](s []T) {
+	var empty T
+	for i := 0; i < len(s); i++ {
+		s[i] = empty
+	}
+}
+
+// 清零 1MB：逐元素循环对比 MakeSlice 的 clear (memclrNoHeapPointers)
+func BenchmarkZero1MB(b *testing.B) {
+	const size = 1 << 20
+	a := AcquireSized(size)
+	defer a.Release()
+
+	b.Run("loop", func(b *testing.B) {
+		b.SetBytes(size)
+		for b.Loop() {
+			a.Reset()
+			zeroLoop(MakeSliceNoZero[byte](a, size, size))
+		}
+	})
+	b.Run("MakeSlice", func(b *testing.B) {
+		b.SetBytes(size)
+		for b.Loop() {
+			a.Reset()
+			MakeSlice[byte](a, size, size)
+		}
+	})
+}
+
+// Release 之后重新借出的同一个 Arena 代数加一；Reset 不改变代数
+func TestAcquireGenBumps(t *testing.T) {
+	for _, size := range []int{0, 1 << 12} { // 默认池与按大小分档的池
+		acquire := func() *Arena {
+			if size == 0 {
+				return Acquire()
+			}
+			return AcquireSized(size)
+		}
+		a := acquire()
+		gen := a.AcquireGen()
+		if gen == 0 {
+			t.Fatalf("size %d: AcquireGen = 0 after Acquire", size)
+		}
+		New[uint64](a)
+		a.Reset()
+		if a.AcquireGen() != gen {
+			t.Fatalf("size %d: Reset changed AcquireGen %d -> %d", size, gen, a.AcquireGen())
+		}
+		// sync.Pool 不保证归还的对象一定再被取到 (-race 下会随机丢弃)，多试几次
+		reused := false
+		for range 100 {
+			a.Release()
+			b := acquire()
+			if b != a {
+				a = b
+				gen = b.AcquireGen()
+				continue
+			}
+			if b.AcquireGen() != gen+1 {
+				t.Fatalf("size %d: AcquireGen = %d after Release+Acquire, want %d", size, b.AcquireGen(), gen+1)
+			}
+			reused = true
+			break
+		}
+		a.Release()
+		if !reused {
+			t.Logf("size %d: pool never returned the same arena", size)
+		}
+	}
+}
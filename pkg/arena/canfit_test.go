@@ -0,0 +1,44 @@
+package arena
+
+import "testing"
+
+// CanFit 计入对齐填充：结果必须与随后的 TryMakeSlice 是否成功一致
+func TestCanFitCountsPadding(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	New[byte](a) // offset = 1，下一次 8 字节对齐的分配要填充 7 字节
+	rest := a.Cap() - 8
+	if !a.CanFit(rest, 8) {
+		t.Fatalf("CanFit(%d, 8) = false at offset 1", rest)
+	}
+	if a.CanFit(rest+1, 8) {
+		t.Fatalf("CanFit(%d, 8) = true, but padding leaves only %d bytes", rest+1, rest)
+	}
+	if !a.CanFit(rest+7, 1) {
+		t.Fatalf("CanFit(%d, 1) = false, no padding is needed", rest+7)
+	}
+	if a.CanFit(-1, 1) {
+		t.Fatal("CanFit accepted a negative size")
+	}
+
+	if _, err := TryMakeSlice[uint64](a, rest/8+1, rest/8+1); err == nil {
+		t.Fatal("allocation that CanFit rejected succeeded")
+	}
+	if _, err := TryMakeSlice[uint64](a, rest/8, rest/8); err != nil {
+		t.Fatalf("allocation that CanFit accepted failed: %v", err)
+	}
+	if a.Remaining() != 0 || a.CanFit(1, 1) {
+		t.Fatalf("Remaining = %d after filling the arena", a.Remaining())
+	}
+}
+
+func TestCanFitRejectsBadAlign(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	for _, align := range []int{0, -8, 3, 12} {
+		if r := catchPanic(func() { a.CanFit(8, align) }); r == nil {
+			t.Errorf("CanFit(8, %d) did not panic", align)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package arena
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// cacheLine 是占满一个缓存行的类型
+type cacheLine [CacheLine]byte
+
+// 模拟按块扩容：当前块放不下下一组分配时换一个新块继续同一串分配。
+// 新块 (不同档位的 AcquireSized、从另一个 Arena 奇数偏移之后切出的 Region) 的起始地址都与第一个块一样 CacheLine 对齐、
+// 偏移从 0 开始，所以跨块之后按偏移补齐的 Region 与按地址补齐的 NewAlignedBytes 仍然 64 字节对齐
+func TestAlignmentAcrossChunks(t *testing.T) {
+	parent := AcquireSized(1 << 16)
+	defer parent.Release()
+	var chunks []*Arena
+	defer func() {
+		for _, c := range chunks {
+			if c.Parent() == nil {
+				c.Release()
+			}
+		}
+	}()
+	grow := func() *Arena {
+		var c *Arena
+		switch len(chunks) % 3 {
+		case 0:
+			c = AcquireSized(1 << 12)
+		case 1:
+			c = AcquireSized(1 << 13)
+		default:
+			MakeSlice[byte](parent, 3, 3) // 父 Arena 的偏移不是 64 的倍数
+			c = parent.Region(1 << 12)
+		}
+		chunks = append(chunks, c)
+		return c
+	}
+	aligned := func(p unsafe.Pointer) bool { return uintptr(p)%CacheLine == 0 }
+
+	const group = 4 * CacheLine // 一组分配加上最坏情况的填充
+	a := grow()
+	for i := 0; len(chunks) < 7; i++ {
+		if a.Remaining() < group {
+			a = grow()
+			if base := unsafe.Pointer(unsafe.SliceData(a.buf)); !aligned(base) || a.Used() != 0 {
+				t.Fatalf("chunk %d: base %p, Used %d; want a CacheLine aligned, empty chunk", len(chunks), base, a.Used())
+			}
+		}
+		odd := 1 + i%(CacheLine-1)
+		MakeSlice[byte](a, odd, odd)
+		if b := NewAlignedBytes(a, CacheLine, CacheLine); !aligned(unsafe.Pointer(&b[0])) {
+			t.Fatalf("chunk %d, group %d: NewAlignedBytes at %p", len(chunks), i, &b[0])
+		}
+		New[byte](a)
+		r := a.Region(CacheLine)
+		if line := New[cacheLine](r); !aligned(unsafe.Pointer(line)) {
+			t.Fatalf("chunk %d, group %d: cache line in a region at %p", len(chunks), i, line)
+		}
+	}
+}
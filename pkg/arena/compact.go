@@ -0,0 +1,124 @@
+package arena
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// 碎片整理 (Compaction)
+//
+// Arena 默认只支持 LIFO 释放 (Pop/Scope/Reset)，长寿命的 Arena 上如果中间的对象先死，
+// 就会留下无法复用的空洞。开启 EnableCompaction 后 Arena 会记录每次分配，
+// 调用方用 Free 标记死亡的对象，再用 Compact 把存活的分配依次滑到前面，回收空洞
+//
+// 契约很重，使用前务必确认全部满足：
+//   - Arena 不知道谁持有指针：调用方必须自己跟踪所有指向被移动对象的指针 (包括对象之间的内部指针)，
+//     并在 relocate 回调里逐个修正；漏掉一个就是悬垂指针，且不会有任何报错
+//   - 被移动的是原始字节 (memmove)，对象内指向 Arena 自身的指针同样需要调用方修正
+//   - 切片头按起始地址匹配：切片的 data 指针就是 old，len/cap 不变，只需替换 data
+//   - Compact 期间及回调内不能在该 Arena 上分配；打开的 Scope 会在整理后失效 (调试构建下 panic)
+//   - Slice (MakeSliceTracked) 的代数不变但地址已变，同样需要在回调中重建
+//   - EnableCompaction 之前已有的分配不会被记录，视为固定区域，永远不会被移动
+//
+// 记录本身在 Go 堆上 (每次分配 32 字节)，未开启时分配路径只多一次 nil 判断
+
+// block 是一次被记录的分配
+type block struct {
+	off, size, align int
+	dead             bool
+}
+
+// liveTable 是按偏移递增排列的分配记录
+type liveTable struct {
+	base   int // 开启时的偏移，之前的区域是固定的
+	blocks []block
+}
+
+// EnableCompaction 开始记录此后的每次分配，使 Free / Compact 可用
+// 只应在 Go World 初始化时调用一次 (记录表在堆上增长)
+func (a *Arena) EnableCompaction() {
+	if a.live == nil {
+		a.live = &liveTable{base: a.offset}
+	}
+}
+
+// record 由 note 在每次分配时调用
+// 分配偏移单调递增，所以任何起始偏移 >= off 的旧记录必然已被 Pop/Scope/Reset 回收，一并丢弃
+func (t *liveTable) record(off, size, align int) {
+	t.trim(off)
+	t.blocks = append(t.blocks, block{off: off, size: size, align: align})
+}
+
+// trim 丢弃起始偏移 >= end 的记录 (已被回退掉的分配)
+func (t *liveTable) trim(end int) {
+	n := len(t.blocks)
+	for n > 0 && t.blocks[n-1].off >= end {
+		n--
+	}
+	t.blocks = t.blocks[:n]
+	if t.base > end {
+		t.base = end
+	}
+}
+
+// Free 标记起始地址为 p 的分配已经死亡，它占用的空间在下一次 Compact 时回收
+// p 必须是 New/MakeSlice 等返回的起始地址 (切片用 unsafe.SliceData)；
+// 未开启 EnableCompaction、或 p 不是一个存活分配的起点时 panic
+func (a *Arena) Free(p unsafe.Pointer) {
+	if a.live == nil {
+		panic("arena: Free requires EnableCompaction")
+	}
+	t := a.live
+	t.trim(a.offset)
+	off := int(uintptr(p) - uintptr(unsafe.Pointer(unsafe.SliceData(a.buf))))
+	i := sort.Search(len(t.blocks), func(i int) bool { return t.blocks[i].off >= off })
+	// 零长度的分配可能与下一个分配同址，跳过已经死亡的那些
+	for ; i < len(t.blocks) && t.blocks[i].off == off; i++ {
+		if !t.blocks[i].dead {
+			t.blocks[i].dead = true
+			return
+		}
+	}
+	panic("arena: Free of unknown allocation")
+}
+
+// Compact 把所有存活的分配按原来的顺序滑动到前面，消除 Free 留下的空洞，
+// 每移动一个分配调用一次 relocate(old, new)，调用方据此修正自己持有的指针 (契约见文件头)
+// 新位置与旧位置模 align 同余，所以原有的对齐 (包括 MakeVec 的绝对地址对齐) 保持不变
+// 整理后 Used() 缩小为最后一个存活分配的末尾
+func (a *Arena) Compact(relocate func(old, new unsafe.Pointer)) {
+	if a.live == nil {
+		panic("arena: Compact requires EnableCompaction")
+	}
+	if a.sealed != nil {
+		panic("arena: Compact of sealed arena")
+	}
+	if debug && a.scopes > 0 {
+		panic("arena: Compact with open scopes")
+	}
+	t := a.live
+	t.trim(a.offset)
+	base := unsafe.Pointer(unsafe.SliceData(a.buf))
+	cur := t.base
+	kept := t.blocks[:0]
+	for _, b := range t.blocks {
+		if b.dead {
+			continue
+		}
+		off := cur + (b.off-cur)&(b.align-1)
+		if off != b.off {
+			copy(a.buf[off:off+b.size], a.buf[b.off:b.off+b.size])
+			if relocate != nil {
+				relocate(unsafe.Add(base, b.off), unsafe.Add(base, off))
+			}
+			b.off = off
+		}
+		cur = off + b.size
+		kept = append(kept, b)
+	}
+	t.blocks = kept
+	if a.offset > a.high {
+		a.high = a.offset
+	}
+	a.offset = cur
+}
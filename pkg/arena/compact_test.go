@@ -0,0 +1,82 @@
+package arena
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// 释放中间的两块后整理：存活的分配按原顺序前移，回调修正根指针后内容不变，Used 缩小
+func TestCompactMovesLiveAllocations(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	pinned := New[uint64](a) // 开启前的分配是固定区域
+	*pinned = 99
+	a.EnableCompaction()
+
+	x := New[uint64](a)
+	gap1 := MakeSlice[byte](a, 100, 100)
+	y := New[[2]uint32](a)
+	gap2 := New[[64]byte](a)
+	z := MakeSlice[uint64](a, 4, 4)
+	*x, *y = 1, [2]uint32{2, 3}
+	copy(z, []uint64{4, 5, 6, 7})
+	before := a.Used()
+
+	a.Free(unsafe.Pointer(unsafe.SliceData(gap1)))
+	a.Free(unsafe.Pointer(gap2))
+
+	// 调用方跟踪的根：旧地址 -> 需要修正的指针
+	roots := map[unsafe.Pointer]func(unsafe.Pointer){
+		unsafe.Pointer(x):                   func(p unsafe.Pointer) { x = (*uint64)(p) },
+		unsafe.Pointer(y):                   func(p unsafe.Pointer) { y = (*[2]uint32)(p) },
+		unsafe.Pointer(unsafe.SliceData(z)): func(p unsafe.Pointer) { z = unsafe.Slice((*uint64)(p), len(z)) },
+	}
+	moved := 0
+	a.Compact(func(old, new unsafe.Pointer) {
+		fix, ok := roots[old]
+		if !ok {
+			t.Fatalf("relocate called for an untracked address %p", old)
+		}
+		fix(new)
+		moved++
+	})
+
+	if moved != 2 {
+		t.Fatalf("relocate called %d times, want 2 (y and z)", moved)
+	}
+	if *pinned != 99 || *x != 1 || *y != [2]uint32{2, 3} || z[0] != 4 || z[3] != 7 {
+		t.Fatalf("contents changed: pinned=%d x=%d y=%v z=%v", *pinned, *x, *y, z)
+	}
+	// pinned, x, y, z 紧挨着排列，原来的对齐填充也一并消失
+	if got, want := a.Used(), 8+8+8+32; got != want || got >= before {
+		t.Fatalf("Used = %d after Compact, want %d (from %d)", got, want, before)
+	}
+	if uintptr(unsafe.Pointer(unsafe.SliceData(z)))%unsafe.Alignof(z[0]) != 0 {
+		t.Fatal("moved slice lost its alignment")
+	}
+	if !Owns(a, z) || OffsetOf(a, &z[3])+8 != a.Used() {
+		t.Fatal("last live allocation does not end at Used")
+	}
+
+	// 整理后继续分配从新的末尾开始，已整理过的分配仍然可以 Free
+	w := New[uint64](a)
+	if OffsetOf(a, w) != OffsetOf(a, &z[3])+8 {
+		t.Fatalf("allocation after Compact at %d", OffsetOf(a, w))
+	}
+	a.Free(unsafe.Pointer(unsafe.SliceData(z)))
+	if r := catchPanic(func() { a.Free(unsafe.Pointer(unsafe.SliceData(z))) }); r != "arena: Free of unknown allocation" {
+		t.Fatalf("double Free: panic = %v", r)
+	}
+}
+
+func TestCompactRequiresEnable(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	p := New[uint64](a)
+	if r := catchPanic(func() { a.Free(unsafe.Pointer(p)) }); r != "arena: Free requires EnableCompaction" {
+		t.Fatalf("Free: panic = %v", r)
+	}
+	if r := catchPanic(func() { a.Compact(nil) }); r != "arena: Compact requires EnableCompaction" {
+		t.Fatalf("Compact: panic = %v", r)
+	}
+}
@@ -0,0 +1,23 @@
+package arena
+
+import "unsafe"
+
+// BytesToString 零拷贝地把 []byte 视为 string
+// 返回的 string 与 b 共享同一块内存，因此：
+//   - 只在 b 的底层内存 (通常是 Arena) 存活期间有效，Reset/Release 后严禁再使用
+//   - 在 string 被使用期间不得修改 b，否则会破坏 string 不可变的语义
+func BytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// StringToBytes 零拷贝地把 string 视为 []byte
+// 返回的切片是只读的：写入它是未定义行为 (字符串常量可能位于只读段，写入会直接崩溃)
+func StringToBytes(s string) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
@@ -0,0 +1,58 @@
+package arena
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestConvertAliases(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	b := MakeSlice[byte](a, 5, 5)
+	copy(b, "hello")
+	s := BytesToString(b)
+	if s != "hello" || unsafe.StringData(s) != &b[0] {
+		t.Fatalf("BytesToString = %q, not a view of b", s)
+	}
+	// 视图与 arena 内存是同一块：arena 中的修改 (在释放/复用之前) 直接反映到字符串上
+	b[0] = 'j'
+	if s != "jello" {
+		t.Fatalf("string view = %q after writing b, want %q", s, "jello")
+	}
+
+	back := StringToBytes(s)
+	if len(back) != 5 || &back[0] != &b[0] {
+		t.Fatal("StringToBytes did not return a view of the same memory")
+	}
+	if BytesToString(nil) != "" || StringToBytes("") != nil {
+		t.Fatal("empty conversions must not touch memory")
+	}
+}
+
+// 在 -race 下运行：多个 goroutine 同时只读同一块 arena 内存的视图不构成数据竞争
+func TestConvertConcurrentReads(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	b := MakeSlice[byte](a, 64, 64)
+	for i := range b {
+		b[i] = byte('a' + i%26)
+	}
+	s := BytesToString(b)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if v := StringToBytes(s); v[63] != b[63] || len(s) != 64 {
+					t.Error("view changed under concurrent reads")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
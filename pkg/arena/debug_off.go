@@ -0,0 +1,6 @@
+//go:build !arena_debug
+
+package arena
+
+// debug 为 false 时所有调试检查都会被编译器消除
+const debug = false
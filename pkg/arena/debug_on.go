@@ -0,0 +1,6 @@
+//go:build arena_debug
+
+package arena
+
+// debug 为 true 时启用额外的运行时检查 (go build -tags arena_debug)
+const debug = true
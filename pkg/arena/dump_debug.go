@@ -0,0 +1,86 @@
+//go:build arena_debug
+
+package arena
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// DumpHex 把 buf[:Used()] 以 "偏移 | 十六进制 | ASCII" 的形式写入 w，用于排查内存踩踏和布局/对齐问题
+// 仅调试构建 (-tags arena_debug) 提供，生产构建中调用它会编译失败
+//
+// 分配记录 (LastAllocations，最近 64 次) 覆盖到的区域按分配切成段，每段前有一行注释：
+//
+//	-- alloc @00000010 size=24 align=8 type=[]int32
+//	-- padding @0000000c size=4
+//	-- untracked @00000000 size=4096        (更早的、已被挤出记录环的分配)
+//
+// 每段内部每行 16 字节，行首是该行在 Arena 中的绝对偏移
+func (a *Arena) DumpHex(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "arena used=%d cap=%d gen=%d\n", a.offset, len(a.buf), a.gen)
+
+	// 只保留当前代、仍在已分配范围内的记录 (LastAllocations 按时间顺序，栈式分配下偏移也递增)
+	var allocs []AllocInfo
+	for _, info := range a.LastAllocations() {
+		if info.gen != a.gen || info.Offset+info.Size > a.offset {
+			continue
+		}
+		// Pop/Scope 回退后重新分配的记录会与更早的记录重叠，以新的为准
+		for len(allocs) > 0 && allocs[len(allocs)-1].Offset+allocs[len(allocs)-1].Size > info.Offset {
+			allocs = allocs[:len(allocs)-1]
+		}
+		allocs = append(allocs, info)
+	}
+
+	pos := 0
+	for _, info := range allocs {
+		if info.Offset > pos {
+			label := "untracked"
+			if info.Offset-pos < info.Align {
+				label = "padding"
+			}
+			dumpSegment(bw, a.buf, pos, info.Offset, label, "")
+		}
+		typ := info.Type
+		if typ == "" {
+			typ = "?"
+		}
+		dumpSegment(bw, a.buf, info.Offset, info.Offset+info.Size, "alloc",
+			fmt.Sprintf(" align=%d type=%s", info.Align, typ))
+		pos = info.Offset + info.Size
+	}
+	if pos < a.offset {
+		dumpSegment(bw, a.buf, pos, a.offset, "untracked", "")
+	}
+	return bw.Flush()
+}
+
+// dumpSegment 写出 [from, to) 一段，先写一行注释，再每 16 字节一行
+func dumpSegment(w *bufio.Writer, buf []byte, from, to int, label, extra string) {
+	fmt.Fprintf(w, "-- %s @%08x size=%d%s\n", label, from, to-from, extra)
+	for off := from; off < to; off += 16 {
+		row := buf[off:min(off+16, to)]
+		fmt.Fprintf(w, "%08x  ", off)
+		for i := 0; i < 16; i++ {
+			if i < len(row) {
+				fmt.Fprintf(w, "%02x ", row[i])
+			} else {
+				w.WriteString("   ")
+			}
+			if i == 7 {
+				w.WriteByte(' ')
+			}
+		}
+		w.WriteString(" |")
+		for _, c := range row {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			w.WriteByte(c)
+		}
+		w.WriteString("|\n")
+	}
+}
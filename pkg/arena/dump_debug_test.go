@@ -0,0 +1,51 @@
+//go:build arena_debug
+
+package arena
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// DumpHex 在已知分配的偏移处标注类型与大小，只有调试构建才有：
+//
+//	go test -tags arena_debug -run DumpHex ./pkg/arena
+func TestDumpHex(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+	// Reset 之前的分配记录不出现在 dump 中
+	New[[3]uint64](a)
+	a.Reset()
+
+	*New[uint32](a) = 0xdeadbeef
+	*New[uint64](a) = 0x0102030405060708
+	copy(MakeSlice[byte](a, 5, 5), "hello")
+
+	var buf bytes.Buffer
+	if err := a.DumpHex(&buf); err != nil {
+		t.Fatalf("DumpHex: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"arena used=21 cap=65536 ",
+		"-- alloc @00000000 size=4 align=4 type=uint32\n" +
+			"00000000  ef be ad de",
+		"-- padding @00000004 size=4\n",
+		"-- alloc @00000008 size=8 align=8 type=uint64\n" +
+			"00000008  08 07 06 05 04 03 02 01",
+		"-- alloc @00000010 size=5 align=1 type=[]uint8\n" +
+			"00000010  68 65 6c 6c 6f",
+		"|hello|\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump lacks %q", want)
+		}
+	}
+	if strings.Contains(out, "[3]uint64") || strings.Contains(out, "untracked") {
+		t.Error("dump shows an allocation from before Reset")
+	}
+	if t.Failed() {
+		t.Logf("dump:\n%s", out)
+	}
+}
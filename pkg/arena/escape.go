@@ -0,0 +1,52 @@
+package arena
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// 检测 "append 超过容量后悄悄逃逸到堆上" 的 Arena 切片
+//
+// append 不经过 Arena，无法拦截，只能事后检查数据指针：仍然指向 a.buf 内部的切片还在 Arena 上，
+// 否则就是被 append 搬到了堆上。CheckSlice 在任意构建下都返回检查结果；
+// 调试构建 (-tags arena_debug) 下发现逃逸还会向 stderr 打印一行警告并计数 (Escapes)，
+// 方便在压测中把 "偶尔超长的那一批" 找出来
+
+// escapes 调试构建下检测到的逃逸次数
+var escapes atomic.Int64
+
+// Owns 报告 s 的底层数组是否位于 a 的内存中 (nil/空容量的切片返回 true：没有数据可以逃逸)
+func Owns[T any](a *Arena, s []T) bool {
+	if cap(s) == 0 {
+		return true
+	}
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(a.buf)))
+	p := uintptr(unsafe.Pointer(unsafe.SliceData(s)))
+	var zero T
+	return p >= base && p+uintptr(cap(s))*unsafe.Sizeof(zero) <= base+uintptr(len(a.buf))
+}
+
+// CheckSlice 与 Owns 相同，调试构建下不在 Arena 上时打印警告并计入 Escapes
+func CheckSlice[T any](a *Arena, s []T) bool {
+	if Owns(a, s) {
+		return true
+	}
+	if debug {
+		noteEscape[T](len(s))
+	}
+	return false
+}
+
+// Escapes 返回调试构建下检测到的逃逸次数 (生产构建总是 0)
+func Escapes() int64 {
+	return escapes.Load()
+}
+
+func noteEscape[T any](n int) {
+	escapes.Add(1)
+	fmt.Fprintf(os.Stderr, "arena: []%s (len=%d) grew past its arena capacity and was moved to the heap\n",
+		reflect.TypeFor[T]().String(), n)
+}
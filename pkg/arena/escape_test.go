@@ -0,0 +1,43 @@
+package arena
+
+import (
+	"os"
+	"testing"
+)
+
+// append 到容量以内仍在 Arena 上；超过容量被搬到堆上后 CheckSlice 报告逃逸，调试构建下计入 Escapes
+func TestCheckSliceDetectsOverAppend(t *testing.T) {
+	a := AcquireSized(1 << 12)
+	defer a.Release()
+
+	s := MakeSlice[int64](a, 0, 4)
+	if !CheckSlice(a, s) {
+		t.Fatal("fresh arena slice reported as escaped")
+	}
+	s = append(s, 1, 2, 3, 4)
+	if !CheckSlice(a, s) {
+		t.Fatal("append within capacity reported as escaped")
+	}
+	if !CheckSlice[int64](a, nil) {
+		t.Fatal("nil slice reported as escaped")
+	}
+
+	before := Escapes()
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull) // 调试构建的警告
+	s = append(s, 5)
+	ok := CheckSlice(a, s)
+	os.Stderr.Close()
+	os.Stderr = stderr
+	if ok || Owns(a, s) {
+		t.Fatal("slice appended past its arena capacity still reported as arena-backed")
+	}
+
+	want := before
+	if debug {
+		want++
+	}
+	if got := Escapes(); got != want {
+		t.Fatalf("Escapes = %d, want %d (debug = %v)", got, want, debug)
+	}
+}
@@ -0,0 +1,185 @@
+package arena
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// 按 Handle 单独释放
+//
+// Pop 只能释放栈顶且不做任何校验，Free/Compact 需要调用方修正指针。
+// NewHandle 在得到指针的同时返回一个 Handle (偏移 + 大小 + 代数 + 分配序号)，
+// FreeHandle 凭 Handle 归还这一块：恰好是栈顶时直接回退偏移，否则放入空闲链表，
+// 之后的 NewHandle 会按首次适配 (First Fit) 复用这些空洞
+//
+// 校验：
+//   - 代数不同 (Arena 已 Reset)：FreeHandle / Deref panic
+//   - 分配序号对不上 (已经释放过，或这块内存已被另一次 NewHandle 复用)：panic，即 double free / use-after-free
+//
+// 限制：
+//   - 空闲链表只服务于 NewHandle，New/MakeSlice 仍然只从栈顶分配
+//   - Pop/Scope 回退到某个 Handle 之下后，该 Handle 视为失效，调用方不应再使用
+//   - 不要与 EnableCompaction 混用 (Compact 会移动 Handle 指向的内存)
+//
+// 记录在 Go 堆上 (map + 切片)，只有调用过 NewHandle 的 Arena 才会创建
+
+// Handle 标识一次可单独释放的分配
+type Handle struct {
+	off, size int
+	gen       uint64
+	id        uint64
+}
+
+// Offset 返回分配的起始偏移
+func (h Handle) Offset() int { return h.off }
+
+// Size 返回分配的字节数
+func (h Handle) Size() int { return h.size }
+
+// span 是空闲链表中的一段 [off, off+size)
+type span struct{ off, size int }
+
+// handleTable 是某一代的 Handle 记录
+type handleTable struct {
+	gen  uint64
+	next uint64
+	live map[int]uint64 // 起始偏移 -> 分配序号
+	free []span         // 按偏移递增，相邻的段已合并
+}
+
+// handleTable 返回与当前代数同步的记录，Reset 后第一次使用时清空
+// 同时丢弃已被 Pop/Scope 回退掉的空闲段
+func (a *Arena) handleTable() *handleTable {
+	t := a.handles
+	if t == nil {
+		t = &handleTable{gen: a.gen, live: make(map[int]uint64)}
+		a.handles = t
+	}
+	if t.gen != a.gen {
+		clear(t.live)
+		t.free = t.free[:0]
+		t.gen = a.gen
+	}
+	n := len(t.free)
+	for n > 0 && t.free[n-1].off >= a.offset {
+		n--
+	}
+	t.free = t.free[:n]
+	if n > 0 {
+		if s := &t.free[n-1]; s.off+s.size > a.offset {
+			s.size = a.offset - s.off
+		}
+	}
+	return t
+}
+
+// NewHandle 与 New 相同 (返回清零的 *T)，同时返回用于 FreeHandle 的 Handle
+// 优先复用空闲链表中的空洞，没有合适的空洞时从栈顶分配
+func NewHandle[T any](a *Arena) (*T, Handle) {
+	checkNoPointers[T]()
+	var zero T
+	// 零大小的类型也占 1 字节，保证每个 Handle 的起始偏移互不相同
+	size, align := max(int(unsafe.Sizeof(zero)), 1), int(unsafe.Alignof(zero))
+	t := a.handleTable()
+	base := unsafe.Pointer(unsafe.SliceData(a.buf))
+	off, ok := t.take(size, align)
+	if ok {
+		// 复用空洞不经过 alloc，单独计入累计计数
+		a.allocs++
+		a.bytes += uint64(size)
+	} else {
+		off = int(uintptr(a.alloc(size, align)) - uintptr(base))
+	}
+	p := (*T)(unsafe.Add(base, off))
+	*p = zero
+	t.next++
+	t.live[off] = t.next
+	return p, Handle{off: off, size: size, gen: a.gen, id: t.next}
+}
+
+// FreeHandle 归还 h 对应的分配，之后通过它得到的指针全部失效
+// h 已经释放过、或来自 Reset 之前时 panic
+func (a *Arena) FreeHandle(h Handle) {
+	t := a.check(h, "FreeHandle")
+	delete(t.live, h.off)
+	if h.off+h.size != a.offset {
+		t.insert(span{h.off, h.size})
+		return
+	}
+	// 栈顶：直接回退，紧挨着的空闲段 (已合并，最多一段) 一并退还
+	if a.offset > a.high {
+		a.high = a.offset
+	}
+	a.offset = h.off
+	if n := len(t.free); n > 0 && t.free[n-1].off+t.free[n-1].size == a.offset {
+		a.offset = t.free[n-1].off
+		t.free = t.free[:n-1]
+	}
+}
+
+// Deref 返回 h 对应的 *T，h 已经释放过、或来自 Reset 之前时 panic
+// T 必须与 NewHandle 时的类型相同
+func Deref[T any](a *Arena, h Handle) *T {
+	a.check(h, "Deref")
+	return (*T)(unsafe.Add(unsafe.Pointer(unsafe.SliceData(a.buf)), h.off))
+}
+
+// HandleLive 报告 h 是否仍然有效 (未释放且 Arena 未 Reset)
+func (a *Arena) HandleLive(h Handle) bool {
+	if a.handles == nil || h.gen != a.gen || h.off+h.size > a.offset {
+		return false
+	}
+	return a.handleTable().live[h.off] == h.id
+}
+
+func (a *Arena) check(h Handle, op string) *handleTable {
+	if a.handles == nil || h.gen != a.gen {
+		panic("arena: " + op + " of handle from before Reset")
+	}
+	if h.off+h.size > a.offset {
+		panic("arena: " + op + " of handle below Pop/Scope")
+	}
+	t := a.handleTable()
+	if t.live[h.off] != h.id {
+		panic("arena: " + op + " of freed handle (double free / use after free)")
+	}
+	return t
+}
+
+// take 按首次适配从空闲链表中切出 size 字节 (按 align 对齐)，切剩的前后两段留在链表中
+func (t *handleTable) take(size, align int) (int, bool) {
+	for i, s := range t.free {
+		pad := (align - s.off%align) % align
+		if pad+size > s.size {
+			continue
+		}
+		off := s.off + pad
+		var rest []span
+		if pad > 0 {
+			rest = append(rest, span{s.off, pad})
+		}
+		if tail := s.size - pad - size; tail > 0 {
+			rest = append(rest, span{off + size, tail})
+		}
+		t.free = append(t.free[:i], append(rest, t.free[i+1:]...)...)
+		return off, true
+	}
+	return 0, false
+}
+
+// insert 按偏移插入空闲段，并与前后相邻的段合并
+func (t *handleTable) insert(s span) {
+	i := sort.Search(len(t.free), func(i int) bool { return t.free[i].off > s.off })
+	if i > 0 && t.free[i-1].off+t.free[i-1].size == s.off {
+		i--
+		t.free[i].size += s.size
+	} else {
+		t.free = append(t.free, span{})
+		copy(t.free[i+1:], t.free[i:])
+		t.free[i] = s
+	}
+	if j := i + 1; j < len(t.free) && t.free[i].off+t.free[i].size == t.free[j].off {
+		t.free[i].size += t.free[j].size
+		t.free = append(t.free[:j], t.free[j+1:]...)
+	}
+}
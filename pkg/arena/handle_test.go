@@ -0,0 +1,83 @@
+package arena
+
+import "testing"
+
+// 中间的一块释放后进入空闲链表，下一次 NewHandle 原地复用且内容被清零，栈顶不动
+func TestHandleReuse(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	_, h1 := NewHandle[uint64](a)
+	p2, h2 := NewHandle[uint64](a)
+	_, h3 := NewHandle[uint64](a)
+	*p2 = 42
+	used := a.Used()
+
+	a.FreeHandle(h2)
+	if a.HandleLive(h2) || !a.HandleLive(h1) || !a.HandleLive(h3) {
+		t.Fatal("HandleLive wrong after freeing the middle handle")
+	}
+	p, h := NewHandle[uint64](a)
+	if h.Offset() != h2.Offset() || p != p2 || *p != 0 {
+		t.Fatalf("reused handle at %d (freed %d), value %d", h.Offset(), h2.Offset(), *p)
+	}
+	if a.Used() != used {
+		t.Fatalf("Used = %d after reusing a hole, want %d", a.Used(), used)
+	}
+
+	// 放不进空洞的分配从栈顶走，不会切坏相邻的块
+	a.FreeHandle(h1)
+	_, big := NewHandle[[2]uint64](a)
+	if big.Offset() != used {
+		t.Fatalf("16-byte handle at %d, want the top %d", big.Offset(), used)
+	}
+}
+
+// 释放栈顶直接回退偏移，并带走紧挨着的空闲段
+func TestHandleFreeTopRewinds(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	base := a.Used()
+	_, h1 := NewHandle[uint64](a)
+	_, h2 := NewHandle[uint64](a)
+	_, h3 := NewHandle[uint64](a)
+
+	a.FreeHandle(h2)
+	a.FreeHandle(h3)
+	if a.Used() != h2.Offset() {
+		t.Fatalf("Used = %d, want %d (top and the hole below it)", a.Used(), h2.Offset())
+	}
+	a.FreeHandle(h1)
+	if a.Used() != base {
+		t.Fatalf("Used = %d after freeing everything, want %d", a.Used(), base)
+	}
+}
+
+// double free、复用后的旧 Handle、Reset 之前的 Handle 都被拒绝
+func TestHandleMisuse(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	_, h1 := NewHandle[uint64](a)
+	_, keep := NewHandle[uint64](a)
+
+	a.FreeHandle(h1)
+	if r := catchPanic(func() { a.FreeHandle(h1) }); r != "arena: FreeHandle of freed handle (double free / use after free)" {
+		t.Fatalf("double free: panic = %v", r)
+	}
+	_, reused := NewHandle[uint64](a)
+	if reused.Offset() != h1.Offset() {
+		t.Fatal("hole was not reused")
+	}
+	if r := catchPanic(func() { Deref[uint64](a, h1) }); r != "arena: Deref of freed handle (double free / use after free)" {
+		t.Fatalf("stale handle after reuse: panic = %v", r)
+	}
+	*Deref[uint64](a, reused) = 7
+
+	a.Reset()
+	if a.HandleLive(keep) {
+		t.Fatal("handle live after Reset")
+	}
+	if r := catchPanic(func() { a.FreeHandle(keep) }); r != "arena: FreeHandle of handle from before Reset" {
+		t.Fatalf("handle from before Reset: panic = %v", r)
+	}
+}
@@ -0,0 +1,31 @@
+package arena
+
+import "unsafe"
+
+// Make2D 在一次分配中创建 rows×cols 的清零矩阵，代替 rows+1 次分配
+//
+// 内存布局 (一整块，中间没有填充)：
+//
+//	[行头 0 .. 行头 rows-1][row 0: cols 个 float64][row 1] ... [row rows-1]
+//
+// 行与行首尾相接 (&m[i][0] == &m[i-1][cols-1] + 8)，按行遍历就是顺序扫描一整段内存。
+// 每行的 cap 固定为 cols：append 超过 cap 会像普通切片一样搬到堆上，而不会覆盖下一行。
+//
+// 行头 (切片头) 含有指针，但它们只指向同一个 Arena 内部，生命周期与 Arena 相同，GC 无需看见；
+// Reset 之后整个矩阵 (包括外层切片) 一起失效。空间不足时 panic
+func Make2D(a *Arena, rows, cols int) [][]float64 {
+	if rows < 0 || cols < 0 {
+		panic("arena: negative matrix size")
+	}
+	const hdr = int(unsafe.Sizeof([]float64(nil)))
+	base := a.alloc(rows*hdr+rows*cols*8, int(unsafe.Alignof([]float64(nil))))
+	noteType[[]float64](a, true)
+
+	m := unsafe.Slice((*[]float64)(base), rows)
+	data := unsafe.Slice((*float64)(unsafe.Add(base, rows*hdr)), rows*cols)
+	clear(data)
+	for i := range m {
+		m[i] = data[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return m
+}
@@ -0,0 +1,61 @@
+package arena
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// 行与行首尾相接，整个矩阵 (含行头) 在 Arena 上；索引写入互不干扰，append 越过 cap 不会覆盖下一行
+func TestMake2DContiguous(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+
+	dirty := MakeSlice[byte](a, a.Cap(), a.Cap())
+	for i := range dirty {
+		dirty[i] = 0xff
+	}
+	a.Reset()
+	New[byte](a) // 从未对齐的偏移开始
+
+	const rows, cols = 5, 7
+	m := Make2D(a, rows, cols)
+	if len(m) != rows || !Owns(a, m) {
+		t.Fatalf("len = %d, header slice on arena = %v", len(m), Owns(a, m))
+	}
+	for i, row := range m {
+		if len(row) != cols || cap(row) != cols || !Owns(a, row) {
+			t.Fatalf("row %d: len %d cap %d, on arena %v", i, len(row), cap(row), Owns(a, row))
+		}
+		if i > 0 && uintptr(unsafe.Pointer(&row[0])) != uintptr(unsafe.Pointer(&m[i-1][cols-1]))+8 {
+			t.Fatalf("row %d does not start right after row %d", i, i-1)
+		}
+		for j, v := range row {
+			if v != 0 {
+				t.Fatalf("m[%d][%d] = %v, want zeroed memory", i, j, v)
+			}
+		}
+	}
+
+	for i := range rows {
+		for j := range cols {
+			m[i][j] = float64(i*cols + j)
+		}
+	}
+	flat := unsafe.Slice(&m[0][0], rows*cols)
+	for k, v := range flat {
+		if v != float64(k) {
+			t.Fatalf("flat[%d] = %v, want %d (row-major)", k, v, k)
+		}
+	}
+	grown := append(m[0], -1)
+	if m[1][0] != cols || Owns(a, grown) {
+		t.Fatalf("append past row 0 overwrote row 1 (m[1][0] = %v) or stayed on the arena", m[1][0])
+	}
+
+	if e := Make2D(a, 0, 3); len(e) != 0 {
+		t.Fatalf("Make2D(0, 3) has %d rows", len(e))
+	}
+	if r := catchPanic(func() { Make2D(a, -1, 2) }); r == nil {
+		t.Fatal("negative size did not panic")
+	}
+}
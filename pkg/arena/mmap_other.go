@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package arena
+
+import "errors"
+
+// AcquireMapped 在不支持 mmap 的平台上总是失败
+func AcquireMapped(path string, size int) (*Arena, error) {
+	return nil, errors.New("arena: mapped arena not supported on this platform")
+}
+
+// Sync 在不支持 mmap 的平台上是空操作
+func (a *Arena) Sync() error {
+	return nil
+}
+
+func (a *Arena) unmap() error {
+	return nil
+}
@@ -0,0 +1,85 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package arena
+
+import (
+	"encoding/binary"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mappedMagic 标识一个由 AcquireMapped 初始化过的文件
+const mappedMagic = "ARENAMAP"
+
+// mappedHeader 是文件头的大小，占满一个 Cache Line，保证数据区起始地址依然 64 字节对齐
+// 布局: [8]magic | [8]offset | [8]high
+const mappedHeader = CacheLine
+
+// AcquireMapped 用文件 path 的 mmap(MAP_SHARED) 映射作为 Arena 的底层内存，得到一个简单的持久化存储
+// 文件不存在时创建，不足 size 时扩展；已初始化过的文件会恢复上次 Sync 时的分配偏移，
+// 之后的分配追加在旧数据之后，旧数据可以通过 At 按偏移取回
+//
+// 注意：
+//   - 映射内存不受 GC 管理，也不会被 GC 扫描，只能存放不含指针的数据 (定长数组、数值)
+//   - 写入只保证在 Sync 之后落盘；进程崩溃时未 Sync 的偏移会丢失 (数据页通常仍会被 OS 写回)
+//   - 映射的 Arena 不进入全局池，Release 会 Sync 并 munmap，之后不可再使用
+func AcquireMapped(path string, size int) (*Arena, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// mmap 建立后文件描述符即可关闭，映射依然有效
+	defer f.Close()
+
+	total := mappedHeader + size
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < int64(total) {
+		if err := f.Truncate(int64(total)); err != nil {
+			return nil, err
+		}
+	}
+	m, err := syscall.Mmap(int(f.Fd()), 0, total, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Arena{buf: m[mappedHeader:], mapping: m}
+	if string(m[:len(mappedMagic)]) == mappedMagic {
+		a.offset = min(int(binary.LittleEndian.Uint64(m[8:])), size)
+		a.high = min(int(binary.LittleEndian.Uint64(m[16:])), size)
+	} else {
+		copy(m, mappedMagic)
+	}
+	return a, nil
+}
+
+// Sync 把分配偏移写入文件头，并同步 msync(MS_SYNC) 整个映射，返回后数据已落盘
+// 对普通 (非映射) Arena 是空操作
+func (a *Arena) Sync() error {
+	if a.mapping == nil {
+		return nil
+	}
+	binary.LittleEndian.PutUint64(a.mapping[8:], uint64(a.offset))
+	binary.LittleEndian.PutUint64(a.mapping[16:], uint64(a.HighWater()))
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC,
+		uintptr(unsafe.Pointer(unsafe.SliceData(a.mapping))), uintptr(len(a.mapping)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// unmap 是映射 Arena 的 Release：落盘后解除映射
+func (a *Arena) unmap() error {
+	err := a.Sync()
+	if uerr := syscall.Munmap(a.mapping); err == nil {
+		err = uerr
+	}
+	a.buf, a.mapping = nil, nil
+	a.offset = 0
+	return err
+}
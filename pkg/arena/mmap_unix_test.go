@@ -0,0 +1,41 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package arena
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// 写入、Release (Sync + munmap) 之后重新映射同一个文件，数据与分配偏移都能取回
+func TestMappedReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.arena")
+	a, err := AcquireMapped(path, 1<<16)
+	if err != nil {
+		t.Fatalf("AcquireMapped: %v", err)
+	}
+	tbl := MakeSlice[uint64](a, 16, 16)
+	for i := range tbl {
+		tbl[i] = uint64(i) * 3
+	}
+	off := OffsetOf(a, &tbl[5])
+	used := a.Used()
+	a.Release()
+
+	b, err := AcquireMapped(path, 1<<16)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer b.Release()
+	if b.Used() != used {
+		t.Fatalf("Used after reopen = %d, want %d", b.Used(), used)
+	}
+	if got := *At[uint64](b, off); got != 15 {
+		t.Fatalf("value after reopen = %d, want 15", got)
+	}
+	// 新的分配追加在旧数据之后
+	p := New[uint64](b)
+	if OffsetOf(b, p) < used {
+		t.Fatal("allocation after reopen overwrote persisted data")
+	}
+}
@@ -0,0 +1,88 @@
+package arena
+
+import (
+	"runtime"
+	"sync/atomic"
+	_ "unsafe" // go:linkname
+)
+
+// 每个 P 一个槽位的 Arena 缓存 (可选，见 EnableLocalCache)
+//
+// 热路径上的 "Acquire -> 用 -> Release" 大多发生在同一个 P 上。开启后 Release 先把 Arena 放进当前 P 的槽位，
+// 下一次同一个 P 上的 Acquire 直接取走：一次 procPin + 一次原子交换，不经过 sync.Pool
+// (后者的 Get/Put 还有 interface 装箱、poolLocal 查找，私有槽位被占时要进共享链表，P 之间还会互相偷)
+// 槽位被占或者当前 P 的槽位为空时退回全局池，行为与未开启时相同
+//
+// 内存代价：每个 P 最多常驻一个 Arena (默认 64MB)，GOMAXPROCS=8 时最多多占 512MB。
+// 与 sync.Pool 不同，GC 不会清理这些槽位，需要归还内存时调用 DrainLocalCache。
+// PoolStats 把槽位中的 Arena 计为 pooled
+
+// localSlot 独占一个缓存行，不同 P 的槽位之间没有伪共享
+// 槽位只由 procPin 住的、所属 P 上的 goroutine 读写，不需要原子操作
+type localSlot struct {
+	a *Arena
+	_ [56]byte
+}
+
+// localSlots 按 P 编号索引，nil 表示未开启
+var localSlots atomic.Pointer[[]localSlot]
+
+//go:linkname procPin runtime.procPin
+func procPin() int
+
+//go:linkname procUnpin runtime.procUnpin
+func procUnpin()
+
+// EnableLocalCache 为当前的每个 P 开启一个 Arena 缓存槽位，应在启动时调用一次
+// 之后调大 GOMAXPROCS 的话，编号超出的 P 直接使用全局池
+// race 构建中不开启 (race detector 识别不了 procPin 提供的独占)，始终使用全局池
+func EnableLocalCache() {
+	if raceEnabled {
+		return
+	}
+	slots := make([]localSlot, runtime.GOMAXPROCS(0))
+	localSlots.Store(&slots)
+}
+
+// DrainLocalCache 丢弃所有槽位中缓存的 Arena，槽位继续可用
+// 槽位不能被其它 P 上的 goroutine 读写，所以这里不逐个取出，而是整体换成一组新的空槽位：
+// 旧槽位不再被引用后，其中的 Arena 连同 buf 交给 GC 回收 (PoolStats 不会因此减少，本来就是上界)
+func DrainLocalCache() {
+	if localSlots.Load() != nil {
+		EnableLocalCache()
+	}
+}
+
+// localGet 取走当前 P 槽位中的 Arena，没有时返回 nil
+func localGet() *Arena {
+	p := localSlots.Load()
+	if p == nil {
+		return nil
+	}
+	slots := *p
+	var a *Arena
+	pid := procPin()
+	if pid < len(slots) {
+		a = slots[pid].a
+		slots[pid].a = nil
+	}
+	procUnpin()
+	return a
+}
+
+// localPut 把 a 放进当前 P 的空槽位，槽位已被占用 (或未开启) 时返回 false
+func localPut(a *Arena) bool {
+	p := localSlots.Load()
+	if p == nil {
+		return false
+	}
+	slots := *p
+	ok := false
+	pid := procPin()
+	if pid < len(slots) && slots[pid].a == nil {
+		slots[pid].a = a
+		ok = true
+	}
+	procUnpin()
+	return ok
+}
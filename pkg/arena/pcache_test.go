@@ -0,0 +1,100 @@
+package arena
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// withLocalCache 在测试期间开启 P 本地缓存，结束时关闭 (丢弃槽位中的 Arena)
+func withLocalCache(tb testing.TB) {
+	EnableLocalCache()
+	tb.Cleanup(func() { localSlots.Store(nil) })
+}
+
+// 并发 Acquire/Release 经过本地槽位时，同一个 Arena 不会同时交给两个持有者，内容也不会串
+func TestLocalCacheConcurrent(t *testing.T) {
+	withLocalCache(t)
+
+	const workers, rounds = 4, 500
+	var mu sync.Mutex
+	held := map[*Arena]int{}
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				a := Acquire()
+				mu.Lock()
+				if owner, busy := held[a]; busy {
+					mu.Unlock()
+					t.Errorf("worker %d got an arena still held by worker %d", w, owner)
+					return
+				}
+				held[a] = w
+				mu.Unlock()
+
+				if a.Used() != 0 {
+					t.Errorf("acquired arena not reset: Used = %d", a.Used())
+				}
+				s := MakeSlice[int](a, 64, 64)
+				for j := range s {
+					s[j] = w*rounds + i
+				}
+				if i%8 == 0 {
+					runtime.Gosched() // 让其它 worker 在持有期间插进来
+				}
+				for j := range s {
+					if s[j] != w*rounds+i {
+						t.Errorf("worker %d: slot %d overwritten with %d", w, j, s[j])
+						break
+					}
+				}
+
+				mu.Lock()
+				delete(held, a)
+				mu.Unlock()
+				a.Release()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// 同一个 P 上 Release 之后的 Acquire 取回同一个 Arena (race 构建不开启缓存，跳过)
+func TestLocalCacheReuse(t *testing.T) {
+	if raceEnabled {
+		t.Skip("local cache is disabled under -race")
+	}
+	withLocalCache(t)
+	// 测试 goroutine 可能在两次调用之间被迁移到别的 P，多试几次
+	for range 10 {
+		a := Acquire()
+		a.Release()
+		b := Acquire()
+		b.Release()
+		if b == a {
+			return
+		}
+	}
+	t.Fatal("Acquire after Release never returned the cached arena")
+}
+
+func BenchmarkAcquireReleaseParallel(b *testing.B) {
+	run := func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				a := Acquire()
+				*New[int](a) = 1
+				a.Release()
+			}
+		})
+	}
+	b.Run("pool", run)
+	b.Run("local", func(b *testing.B) {
+		withLocalCache(b)
+		run(b)
+	})
+}
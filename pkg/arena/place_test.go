@@ -0,0 +1,104 @@
+package arena
+
+import (
+	"math"
+	"testing"
+)
+
+type header struct {
+	Magic uint32
+	Len   uint16
+	Flags uint16
+	Seq   uint64
+}
+
+// PlaceAt 返回 Arena 中固定偏移处的 T：不移动游标，写入的内容就是那段内存
+func TestPlaceAt(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	frame := MakeSlice[byte](a, 256, 256) // 先预留一整段，再在其中放置
+	used := a.Used()
+	base := OffsetOf(a, &frame[0])
+
+	h := PlaceAt[header](a, base+16)
+	h.Magic, h.Len, h.Seq = 0xCAFEBABE, 240, 7
+	if a.Used() != used {
+		t.Fatalf("PlaceAt moved the cursor: Used %d -> %d", used, a.Used())
+	}
+	if frame[16] != 0xBE || frame[19] != 0xCA || frame[20] != 240 || frame[24] != 7 {
+		t.Fatalf("header bytes = % x", frame[16:32])
+	}
+	if PlaceAt[header](a, base+16) != h {
+		t.Fatal("PlaceAt at the same offset returned a different pointer")
+	}
+
+	// 尚未分配的区域同样可以放置，只要在容量以内
+	last := PlaceAt[uint64](a, a.Cap()-8)
+	*last = math.MaxUint64
+	if a.Used() != used {
+		t.Fatal("PlaceAt beyond Used moved the cursor")
+	}
+}
+
+func TestPlaceAtValidation(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	for name, c := range map[string]struct {
+		off  int
+		want string
+	}{
+		"negative":   {-8, "arena: PlaceAt out of range"},
+		"past end":   {a.Cap() - 8, "arena: PlaceAt out of range"}, // header 16 字节
+		"at end":     {a.Cap(), "arena: PlaceAt out of range"},
+		"overflow":   {math.MaxInt - 4, "arena: PlaceAt out of range"},
+		"misaligned": {12, "arena: PlaceAt misaligned"},
+	} {
+		if r := catchPanic(func() { PlaceAt[header](a, c.off) }); r != c.want {
+			t.Errorf("%s (off %d): panic = %v, want %q", name, c.off, r, c.want)
+		}
+	}
+	if r := catchPanic(func() { PlaceAt[header](a, a.Cap()-16) }); r != nil {
+		t.Fatalf("last fitting offset: panic = %v", r)
+	}
+}
+
+// SliceFrom 还原出的切片与原数组共享内存；调试构建下越过已分配区域的 n 与不属于该 Arena 的指针被拒绝
+func TestSliceFromBounds(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	arr := MakeSlice[uint32](a, 8, 8)
+	for i := range arr {
+		arr[i] = uint32(i * 10)
+	}
+	s := SliceFrom(a, &arr[2], 6)
+	if len(s) != 6 || &s[0] != &arr[2] || s[5] != 70 {
+		t.Fatalf("SliceFrom = %v, want arr[2:8]", s)
+	}
+	if len(SliceFrom(a, &arr[0], 0)) != 0 {
+		t.Fatal("empty SliceFrom has elements")
+	}
+
+	var outside uint32
+	bad := map[string]func(){
+		"n past used": func() { SliceFrom(a, &arr[2], 7) },
+		"huge n":      func() { SliceFrom(a, &arr[0], math.MaxInt/2) },
+		"negative n":  func() { SliceFrom(a, &arr[0], -1) },
+		"foreign ptr": func() { SliceFrom(a, &outside, 1) },
+	}
+	for name, f := range bad {
+		if !debug {
+			// 正式构建不检查，其余情形是未定义行为 (-race 下 checkptr 直接 fatal)，只验证不多余地 panic
+			if name == "n past used" {
+				if r := catchPanic(f); r != nil {
+					t.Errorf("%s: release build panicked: %v", name, r)
+				}
+			}
+			continue
+		}
+		if r := catchPanic(f); r != "arena: SliceFrom out of range" {
+			t.Errorf("%s: panic = %v, want out of range", name, r)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package arena
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// 规则：Arena 中只能存放不含指针的数据
+//
+// Arena 的底层是一整块 []byte，GC 不会扫描其中的内容。如果把含指针的结构体 (包括 string、slice、
+// map、chan、interface) 放进来，它引用的堆对象在 GC 看来可能已经没有引用者而被回收，
+// Arena 里留下的就是悬空指针。
+//
+// 调试构建 (-tags arena_debug) 下所有分配函数都会用反射检查 T，含指针时直接 panic；
+// 正式构建中检查被编译器整体消除。确实需要 (并且保证被引用的对象另有强引用) 时使用 NewUnsafePtr
+
+// ptrTypes 缓存每个类型的检查结果 (reflect.Type -> bool)，仅调试构建使用
+var ptrTypes sync.Map
+
+// checkNoPointers 在调试构建中检查 T 不含指针
+func checkNoPointers[T any]() {
+	if !debug {
+		return
+	}
+	t := reflect.TypeFor[T]()
+	has, ok := ptrTypes.Load(t)
+	if !ok {
+		has = hasPointers(t)
+		ptrTypes.Store(t, has)
+	}
+	if has.(bool) {
+		panic("arena: " + t.String() + " contains pointers, which the GC cannot see inside an arena (use NewUnsafePtr to opt out)")
+	}
+}
+
+// hasPointers 判断类型 t 的内存布局中是否包含指针
+func hasPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Map, reflect.Chan, reflect.Func,
+		reflect.Interface, reflect.Slice, reflect.String:
+		return true
+	case reflect.Array:
+		return t.Len() > 0 && hasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewUnsafePtr 与 New 相同，但跳过调试构建的指针检查
+// 调用者自己保证 T 中的指针所引用的对象在 Arena Reset 之前一直另有强引用
+func NewUnsafePtr[T any](a *Arena) *T {
+	var zero T
+	p := (*T)(a.alloc(int(unsafe.Sizeof(zero)), int(unsafe.Alignof(zero))))
+	*p = zero
+	return p
+}
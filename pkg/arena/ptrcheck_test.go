@@ -0,0 +1,71 @@
+package arena
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type withPtr struct {
+	ID   uint64
+	Next *withPtr
+}
+
+type nestedPtr struct {
+	Vals [4]struct {
+		N    int
+		Name string
+	}
+}
+
+type plain struct {
+	ID    uint64
+	Price float64
+	Tags  [8]byte
+	_     [0]*int // 零长度数组不占内存，不算指针
+}
+
+func TestHasPointers(t *testing.T) {
+	for _, c := range []struct {
+		typ  reflect.Type
+		want bool
+	}{
+		{reflect.TypeFor[withPtr](), true},
+		{reflect.TypeFor[nestedPtr](), true},
+		{reflect.TypeFor[[]byte](), true},
+		{reflect.TypeFor[map[int]int](), true},
+		{reflect.TypeFor[any](), true},
+		{reflect.TypeFor[unsafe.Pointer](), true},
+		{reflect.TypeFor[plain](), false},
+		{reflect.TypeFor[[16]uint32](), false},
+		{reflect.TypeFor[uintptr](), false},
+	} {
+		if got := hasPointers(c.typ); got != c.want {
+			t.Errorf("hasPointers(%v) = %v, want %v", c.typ, got, c.want)
+		}
+	}
+}
+
+// 调试构建下含指针的类型在分配时 panic，NewUnsafePtr 显式跳过检查；正式构建不检查
+func TestPointerTypeRejected(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	r := catchPanic(func() { New[withPtr](a) })
+	if debug {
+		if s, _ := r.(string); !strings.Contains(s, "arena.withPtr contains pointers") {
+			t.Fatalf("New[withPtr] in debug build: panic = %v", r)
+		}
+	} else if r != nil {
+		t.Fatalf("New[withPtr] in release build panicked: %v", r)
+	}
+	if r := catchPanic(func() { New[plain](a) }); r != nil {
+		t.Fatalf("New[plain] panicked: %v", r)
+	}
+
+	p := NewUnsafePtr[withPtr](a)
+	if p.ID != 0 || p.Next != nil {
+		t.Fatalf("NewUnsafePtr returned non-zero memory: %+v", *p)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package arena
+
+// raceEnabled 为 false 时每个 P 的缓存槽位正常工作
+const raceEnabled = false
@@ -0,0 +1,7 @@
+//go:build race
+
+package arena
+
+// raceEnabled 为 true 时关闭每个 P 的缓存槽位 (见 pcache.go)：
+// 槽位靠 procPin 保证独占，race detector 看不到这种同步，会把同一 P 上先后的读写报告为竞争
+const raceEnabled = true
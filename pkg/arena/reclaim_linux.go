@@ -0,0 +1,36 @@
+//go:build linux
+
+package arena
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var pageSize = uintptr(os.Getpagesize())
+
+// ResetReclaim 重置 Arena，并把 retain 字节之后、曾经使用过的物理页归还给 OS
+// 通过 madvise(MADV_DONTNEED) 实现：虚拟地址映射保留，物理内存被释放，RSS 随之下降
+// 之后再访问这些页会得到全零页 (New/MakeSlice 本身就会清零，语义不受影响)
+// 适用于一次流量尖峰把 Arena 撑大之后的长生命周期池化 Arena
+func (a *Arena) ResetReclaim(retain int) {
+	used := a.HighWater()
+	a.Reset()
+	if retain < 0 {
+		retain = 0
+	}
+	if used <= retain {
+		return
+	}
+	// madvise 要求起始地址按页对齐：向上取整，保证不会误伤 retain 区域
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(a.buf)))
+	start := (base + uintptr(retain) + pageSize - 1) &^ (pageSize - 1)
+	end := (base + uintptr(used)) &^ (pageSize - 1)
+	if end <= start {
+		return
+	}
+	_ = syscall.Madvise(a.buf[start-base:end-base], syscall.MADV_DONTNEED)
+	// 归还之后高水位回落到 retain
+	a.high = retain
+}
@@ -0,0 +1,49 @@
+package arena
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// rss 从 /proc/self/statm 读取当前进程的常驻内存 (字节)
+func rss(t *testing.T) int {
+	t.Helper()
+	b, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		t.Skipf("statm unavailable: %v", err)
+	}
+	f := bytes.Fields(b)
+	pages, err := strconv.Atoi(string(f[1]))
+	if err != nil {
+		t.Fatalf("parse statm %q: %v", b, err)
+	}
+	return pages * os.Getpagesize()
+}
+
+func TestResetReclaimDropsRSS(t *testing.T) {
+	const used = 24 << 20
+	a := AcquireSized(32 << 20)
+	defer a.Release()
+
+	b := MakeSlice[byte](a, used, used)
+	for i := 0; i < len(b); i += 512 {
+		b[i] = 1 // 触碰每一页，让它们真正驻留
+	}
+	before := rss(t)
+	a.ResetReclaim(1 << 20)
+	after := rss(t)
+
+	if drop := before - after; drop < used/2 {
+		t.Fatalf("RSS dropped by %d bytes (before %d, after %d), want >= %d", drop, before, after, used/2)
+	}
+	if a.HighWater() != 1<<20 || a.Used() != 0 {
+		t.Fatalf("HighWater = %d, Used = %d after ResetReclaim", a.HighWater(), a.Used())
+	}
+	// 映射仍然有效：之后的分配照常工作，并且是清零的
+	b = MakeSlice[byte](a, used, used)
+	if b[used-1] != 0 {
+		t.Fatal("reclaimed memory not zero")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package arena
+
+// ResetReclaim 在非 Linux 平台上等价于 Reset (不归还物理内存)
+func (a *Arena) ResetReclaim(retain int) {
+	a.Reset()
+}
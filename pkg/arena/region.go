@@ -0,0 +1,39 @@
+package arena
+
+// 子区域：在一个大 Arena 里切出若干生命周期不同的逻辑区域
+//
+//	a := arena.Acquire()
+//	perConn := a.Region(1 << 20) // 每个连接 Reset 一次
+//	perTask := a.Region(4 << 10) // 每个任务 Reset 一次
+//
+// 每个子区域是一个独立的 *Arena：有自己的偏移、代数和 Reset，New/MakeSlice/Scope 等照常使用，
+// 底层内存则是父 Arena buf 中固定的一段，不另外向 OS 申请
+//   - 子区域的容量在切出时确定，不会扩容，也不会长到相邻的子区域里：写满后与普通 Arena 一样 OOM panic
+//   - Reset 一个子区域只影响它自己，其它子区域和父 Arena 上的分配不受影响
+//   - 子区域在父 Arena 上是一次普通分配，父 Arena Reset/Pop/Release 之后子区域的内存会被重新分配，
+//     子区域随之失效，所以通常在启动时一次切好，之后不再 Reset 父 Arena
+//   - 子区域不能 Release (会 panic)，随父 Arena 一起归还
+//   - 子区域可以继续切子区域
+
+// regionAlign 是子区域起始偏移的对齐 (缓存行)，足以满足任何类型的对齐要求
+const regionAlign = 64
+
+// Region 从 a 的当前偏移处切出 size 字节作为一个独立的子区域
+// a 空间不足时 panic
+func (a *Arena) Region(size int) *Arena {
+	if size < 0 {
+		panic("arena: negative region size")
+	}
+	a.alloc(size, regionAlign)
+	start := a.offset - size
+	return &Arena{
+		// 三下标切片把 cap 限制在 size，子区域的 append 等也不会越界到后面的区域
+		buf:    a.buf[start : start+size : start+size],
+		parent: a,
+	}
+}
+
+// Parent 返回切出子区域的父 Arena，普通 Arena 返回 nil
+func (a *Arena) Parent() *Arena {
+	return a.parent
+}
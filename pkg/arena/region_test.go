@@ -0,0 +1,53 @@
+package arena
+
+import "testing"
+
+// Reset 一个子区域不影响相邻的子区域与父 Arena；写满的子区域 OOM，而不是长到隔壁
+func TestRegionsIndependent(t *testing.T) {
+	parent := AcquireSized(1 << 16)
+	defer parent.Release()
+
+	r1, r2 := parent.Region(256), parent.Region(256)
+	if r1.Parent() != parent || r2.Parent() != parent || parent.Parent() != nil {
+		t.Fatal("Parent() wrong")
+	}
+	after := New[uint64](parent)
+	*after = 0xfeed
+
+	a := MakeSlice[uint64](r1, 32, 32) // 恰好写满 r1
+	b := MakeSlice[uint64](r2, 32, 32)
+	for i := range 32 {
+		a[i], b[i] = 1, 2
+	}
+	if r := catchPanic(func() { New[byte](r1) }); r == nil {
+		t.Fatal("allocation on a full region did not panic")
+	}
+
+	r1.Reset()
+	if r1.Used() != 0 || r2.Used() != 256 {
+		t.Fatalf("Used after resetting r1: r1 = %d, r2 = %d", r1.Used(), r2.Used())
+	}
+	for i, v := range MakeSlice[uint64](r1, 32, 32) {
+		if v != 0 {
+			t.Fatalf("r1 after Reset: [%d] = %d, want zeroed", i, v)
+		}
+	}
+	for i, v := range b {
+		if v != 2 {
+			t.Fatalf("r2[%d] = %d after resetting r1", i, v)
+		}
+	}
+	if *after != 0xfeed {
+		t.Fatal("parent allocation changed by region Reset")
+	}
+
+	// 子区域可以继续切子区域，同样各自独立
+	inner := r2.Region(0)
+	inner.Reset()
+	if b[0] != 2 || inner.Parent() != r2 {
+		t.Fatal("nested region")
+	}
+	if r := catchPanic(r1.Release); r != "arena: Release of region" {
+		t.Fatalf("Release of region: panic = %v", r)
+	}
+}
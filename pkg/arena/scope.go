@@ -0,0 +1,51 @@
+package arena
+
+// Scope 是一段 Arena 分配的作用域：创建时记下当前偏移，Close 时回退到该偏移，
+// 作用域内的所有分配一次性释放
+//
+//	s := a.Scope()
+//	defer s.Close()
+//	tmp := arena.MakeSlice[int](a, 0, 64) // 随 s.Close() 一起释放
+//
+// 作用域可以嵌套，但必须按 LIFO 顺序关闭；调试构建 (-tags arena_debug) 下乱序或重复 Close 会 panic
+type Scope struct {
+	arena  *Arena
+	offset int
+	gen    uint64
+	depth  int // 创建时的嵌套深度 (从 1 开始)
+}
+
+// Scope 在当前偏移处打开一个新的作用域
+func (a *Arena) Scope() *Scope {
+	a.scopes++
+	return &Scope{arena: a, offset: a.offset, gen: a.gen, depth: a.scopes}
+}
+
+// Close 回退到作用域打开时的偏移，之后作用域内分配的指针全部失效
+// 如果 Arena 在作用域内被 Reset 过，Close 只关闭作用域而不移动偏移
+// 总是返回 nil (满足 io.Closer)
+func (s *Scope) Close() error {
+	a := s.arena
+	if debug && s.depth == 0 {
+		panic("arena: scope closed twice")
+	}
+	if a.gen != s.gen {
+		// Reset 已经把打开的层数清零，这个作用域不再计入
+		s.depth = 0
+		return nil
+	}
+	if debug && a.scopes != s.depth {
+		panic("arena: scopes closed out of order")
+	}
+	if s.depth != 0 {
+		a.scopes--
+		s.depth = 0
+	}
+	if s.offset <= a.offset {
+		if a.offset > a.high {
+			a.high = a.offset
+		}
+		a.offset = s.offset
+	}
+	return nil
+}
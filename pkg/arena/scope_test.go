@@ -0,0 +1,69 @@
+package arena
+
+import "testing"
+
+func TestNestedScopes(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	base := a.Used()
+
+	outer := a.Scope()
+	New[[16]byte](a)
+	mid := a.Used()
+	inner := a.Scope()
+	New[[64]byte](a)
+	inner.Close()
+	if a.Used() != mid {
+		t.Fatalf("after inner Close: Used = %d, want %d", a.Used(), mid)
+	}
+	New[[8]byte](a)
+	outer.Close()
+	if a.Used() != base {
+		t.Fatalf("after outer Close: Used = %d, want %d", a.Used(), base)
+	}
+	if a.HighWater() < mid+64 {
+		t.Fatalf("HighWater = %d lost the scoped peak", a.HighWater())
+	}
+
+	// 作用域内 Reset 过：Close 不移动偏移
+	s := a.Scope()
+	New[uint64](a)
+	a.Reset()
+	p := New[uint64](a)
+	s.Close()
+	if OffsetOf(a, p)+8 != a.Used() {
+		t.Fatal("Close after Reset rewound into the new generation")
+	}
+	// 旧作用域不能把层数减成负数：新作用域照常按 LIFO 检查
+	s2 := a.Scope()
+	New[uint64](a)
+	if r := catchPanic(func() { s2.Close() }); r != nil {
+		t.Fatalf("scope after Reset: panic = %v", r)
+	}
+	if OffsetOf(a, p)+8 != a.Used() {
+		t.Fatal("scope after Reset did not rewind")
+	}
+}
+
+func TestScopeOrderChecks(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	outer := a.Scope()
+	inner := a.Scope()
+
+	r := catchPanic(func() { outer.Close() })
+	if !debug {
+		if r != nil {
+			t.Fatalf("release build panicked: %v", r)
+		}
+		return
+	}
+	if r != "arena: scopes closed out of order" {
+		t.Fatalf("out-of-order Close: panic = %v", r)
+	}
+	inner.Close()
+	outer.Close()
+	if r := catchPanic(func() { outer.Close() }); r != "arena: scope closed twice" {
+		t.Fatalf("double Close: panic = %v", r)
+	}
+}
@@ -0,0 +1,69 @@
+package arena
+
+import "errors"
+
+// 只读密封：启动时在 Arena 上构建好的只读查找表，密封之后任何误写都立即崩溃，而不是悄悄写坏数据
+//
+//	tbl := arena.MakeSlice[entry](a, n, n)
+//	... 填表 ...
+//	if err := a.Seal(); err != nil { ... }
+//	// 之后对 tbl 的读取照常；写入在 Linux 上触发 SIGSEGV
+//
+// Linux 上用 mprotect(PROT_READ) 保护已使用的区域 [0, Used()) (向上取整到页)；其它平台不做内存保护，
+// 只保留下面的分配语义。密封期间：
+//   - 读取不受影响：At、OffsetOf、Snapshot、Owns 照常工作
+//   - 不能再分配 (New/MakeSlice 等 panic "arena: allocation on sealed arena"，Try 系列返回 ErrArenaFull)，
+//     Remaining 报告为 0、CanFit 为 false；需要追加内容时先 Unseal
+//   - 不能 Compact、LoadSnapshot (panic)；Reset 不触碰内存，但在 Unseal 之前同样不能分配
+//   - Release 会先自动 Unseal 再归还池中
+//   - 不经过 Release 直接丢弃的 Arena 必须先 Unseal：buf 被 GC 回收后，这些仍然只读的页会被分给别的对象，
+//     之后对它们的第一次写入就会崩溃
+//   - 被保护的区域必须从页边界开始：池中的 Arena (Acquire/AcquireSized) 满足；
+//     子区域 (Region) 与文件映射的 Arena 的数据区不按页对齐，Seal 返回 ErrSealUnaligned
+
+// ErrSealUnaligned 被保护的区域不按页对齐，无法 mprotect
+var ErrSealUnaligned = errors.New("arena: cannot seal a region that is not page aligned")
+
+// sealState 记录被保护的字节数 ([0, n) 是只读的)
+type sealState struct {
+	n int
+}
+
+// Seal 把已使用的区域设为只读，并禁止继续分配，直到 Unseal；已经密封时是空操作
+func (a *Arena) Seal() error {
+	if a.sealed != nil {
+		return nil
+	}
+	n, err := protect(a.buf, a.offset)
+	if err != nil {
+		return err
+	}
+	a.sealed = &sealState{n: n}
+	return nil
+}
+
+// Unseal 撤销 Seal：恢复可写并允许继续分配；未密封时是空操作
+func (a *Arena) Unseal() error {
+	s := a.sealed
+	if s == nil {
+		return nil
+	}
+	if err := unprotect(a.buf[:s.n]); err != nil {
+		return err
+	}
+	a.sealed = nil
+	return nil
+}
+
+// Sealed 报告 Arena 当前是否处于密封状态
+func (a *Arena) Sealed() bool {
+	return a.sealed != nil
+}
+
+// outOfMemory 在分配失败时 panic，区分密封与真正的空间不足
+func (a *Arena) outOfMemory() {
+	if a.sealed != nil {
+		panic("arena: allocation on sealed arena")
+	}
+	panic("arena: out of memory")
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package arena
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// protect 把 buf 的前 used 字节 (向上取整到页) 设为只读，返回被保护的字节数
+func protect(buf []byte, used int) (int, error) {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(buf)))
+	n := (uintptr(used) + pageSize - 1) &^ (pageSize - 1)
+	// 起始地址必须按页对齐；取整后的末尾不能越过 buf，否则会把相邻对象所在的页一起保护
+	if base&(pageSize-1) != 0 || n > uintptr(len(buf)) {
+		return 0, ErrSealUnaligned
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if err := syscall.Mprotect(buf[:n], syscall.PROT_READ); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// unprotect 恢复 protect 保护过的区域为可读写
+func unprotect(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return syscall.Mprotect(b, syscall.PROT_READ|syscall.PROT_WRITE)
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package arena
+
+import (
+	rtdebug "runtime/debug"
+	"testing"
+	"unsafe"
+)
+
+// 写入密封区域触发 SIGSEGV (SetPanicOnFault 把它变成可恢复的 panic)，Unseal 之后可以照常写
+func TestSealedWriteFaults(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+	tbl := MakeSlice[uint64](a, 512, 512)
+	tbl[3] = 9
+	if err := a.Seal(); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	defer rtdebug.SetPanicOnFault(rtdebug.SetPanicOnFault(true))
+
+	r := catchPanic(func() { tbl[3] = 10 })
+	addr, ok := r.(interface{ Addr() uintptr })
+	if !ok {
+		if err := a.Unseal(); err != nil {
+			t.Fatal(err)
+		}
+		t.Fatalf("write to sealed memory: panic = %v, want a memory fault", r)
+	}
+	if got, want := addr.Addr(), uintptr(unsafe.Pointer(&tbl[3])); got != want {
+		t.Errorf("fault address %#x, want %#x", got, want)
+	}
+	if tbl[3] != 9 {
+		t.Fatalf("sealed value changed to %d", tbl[3])
+	}
+
+	if err := a.Unseal(); err != nil {
+		t.Fatalf("Unseal: %v", err)
+	}
+	if r := catchPanic(func() { tbl[3] = 10 }); r != nil || tbl[3] != 10 {
+		t.Fatalf("write after Unseal: panic = %v, value %d", r, tbl[3])
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package arena
+
+// protect 在非 Linux 平台上不做内存保护 (Seal 仍然禁止分配)
+func protect(buf []byte, used int) (int, error) {
+	return 0, nil
+}
+
+// unprotect 在非 Linux 平台上是空操作
+func unprotect(b []byte) error {
+	return nil
+}
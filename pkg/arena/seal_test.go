@@ -0,0 +1,62 @@
+package arena
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSealKeepsReadsAndRejectsAllocs(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+
+	tbl := MakeSlice[uint64](a, 64, 64)
+	for i := range tbl {
+		tbl[i] = uint64(i * i)
+	}
+	off := OffsetOf(a, &tbl[10])
+	if err := a.Seal(); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !a.Sealed() {
+		t.Fatal("Sealed() = false after Seal")
+	}
+
+	// 读取照常：At 不能因为密封而越界
+	if got := *At[uint64](a, off); got != 100 {
+		t.Fatalf("At after Seal = %d, want 100", got)
+	}
+	if !Owns(a, tbl) {
+		t.Fatal("Owns lost the sealed table")
+	}
+	if a.Remaining() != 0 || a.CanFit(8, 8) {
+		t.Fatalf("Remaining = %d, CanFit = %v while sealed", a.Remaining(), a.CanFit(8, 8))
+	}
+	if _, err := TryNew[uint64](a); !errors.Is(err, ErrArenaFull) {
+		t.Fatalf("TryNew on sealed arena: err = %v, want ErrArenaFull", err)
+	}
+	for name, f := range map[string]func(){
+		"New":          func() { New[uint64](a) },
+		"MakeSliceMax": func() { MakeSliceMax[uint64](a, 1) },
+		"MakeVec":      func() { MakeVec(a, 4) },
+	} {
+		if r := catchPanic(f); r != "arena: allocation on sealed arena" {
+			t.Errorf("%s on sealed arena: panic = %v", name, r)
+		}
+	}
+
+	if err := a.Unseal(); err != nil {
+		t.Fatalf("Unseal: %v", err)
+	}
+	tbl[0] = 7
+	p := New[uint64](a)
+	*p = 1
+	if tbl[0] != 7 || *At[uint64](a, off) != 100 {
+		t.Fatal("table changed across Seal/Unseal")
+	}
+}
+
+func catchPanic(f func()) (r any) {
+	defer func() { r = recover() }()
+	f()
+	return nil
+}
@@ -0,0 +1,69 @@
+package arena
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// 按容量分档的 Arena
+//
+// Acquire 借出的都是默认大小 (64MB) 的 Arena。只需要几 KB 的用途 (如单个 calc 任务) 用 AcquireSized 借一个小的，
+// 常驻内存更少，反复 Reset 的那一小段也一直留在缓存里。
+//
+// 容量向上取整到 2 的幂 (最小 4KB)，每一档有自己的 sync.Pool，Release 按 buf 的长度放回对应的档：
+//   - 取整后等于默认大小的直接使用全局池，与 Acquire 相同
+//   - 超过默认大小的单独分配，Release 后不再复用，交给 GC 回收
+//
+// PoolStats 同样统计这些 Arena (bytes 按实际容量累计)
+
+const (
+	minSizedShift = 12 // 最小档 4KB
+	defaultShift  = 26 // defaultSize == 1<<defaultShift
+)
+
+// sizedPools[i] 存放容量为 1<<(minSizedShift+i) 的 Arena
+var sizedPools [defaultShift - minSizedShift]sync.Pool
+
+// AcquireSized 借出一个容量至少为 size 字节的 Arena，必须配合 Release 使用
+func AcquireSized(size int) *Arena {
+	if size < 0 {
+		panic("arena: negative size")
+	}
+	shift := minSizedShift
+	if size > 1<<minSizedShift {
+		shift = bits.Len(uint(size - 1))
+	}
+	if shift == defaultShift {
+		return Acquire()
+	}
+	var a *Arena
+	if shift < defaultShift {
+		if v := sizedPools[shift-minSizedShift].Get(); v != nil {
+			a = v.(*Arena)
+		}
+	}
+	if a == nil {
+		n := 1 << shift
+		if shift > defaultShift {
+			n = size
+		}
+		poolCreated.Add(1)
+		poolBytes.Add(int64(n))
+		a = &Arena{buf: make([]byte, n)}
+	}
+	a.acquireGen++
+	poolAcquired.Add(1)
+	return a
+}
+
+// putSized 把非默认大小的 Arena 放回对应的档 (超过默认大小的直接丢弃)，默认大小时返回 false
+func putSized(a *Arena) bool {
+	n := len(a.buf)
+	if n == defaultSize {
+		return false
+	}
+	if n < defaultSize && n >= 1<<minSizedShift && n&(n-1) == 0 {
+		sizedPools[bits.Len(uint(n))-1-minSizedShift].Put(a)
+	}
+	return true
+}
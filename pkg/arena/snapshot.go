@@ -0,0 +1,35 @@
+package arena
+
+// 整体快照：把 Arena 当前已分配区域的原始字节拷贝出来 (用于序列化、跨进程交接)，之后再原样装回
+// 与 Scope 不同，Scope 只在同一个 Arena 上回退偏移量，不保存内容
+//
+// 快照是原始字节，只对不含指针的数据有意义：指向 Arena 自身或 Go 堆的指针装回之后
+// (尤其是在另一个进程、另一个 Arena 上) 都是悬垂的，跨 Arena 引用对象应保存偏移 (OffsetOf / At)
+
+// Snapshot 返回 buf[:Used()] 的一份堆上拷贝，之后继续在 Arena 上分配不影响它
+func (a *Arena) Snapshot() []byte {
+	return append([]byte(nil), a.buf[:a.offset]...)
+}
+
+// LoadSnapshot 用 data 覆盖 Arena 的开头并把偏移量设为 len(data)，相当于 Reset 后按原样重新分配了那一段
+//   - 与 Reset 一样代数 +1：之前取得的 Scope、Handle、Tracked 切片全部失效
+//   - 开启了碎片整理时，装回的区域视为固定区域 (同 EnableCompaction 之前的分配)，不会被 Compact 移动
+//   - data 比 Arena 容量大时返回 ErrArenaFull，Arena 保持不变；比当前 Used 小是允许的，多出的部分直接丢弃
+func (a *Arena) LoadSnapshot(data []byte) error {
+	if a.sealed != nil {
+		panic("arena: LoadSnapshot of sealed arena")
+	}
+	if len(data) > len(a.buf) {
+		return ErrArenaFull
+	}
+	a.Reset()
+	a.offset = copy(a.buf, data)
+	if a.offset > a.high {
+		a.high = a.offset
+	}
+	if a.live != nil {
+		a.live.trim(0)
+		a.live.base = a.offset
+	}
+	return nil
+}
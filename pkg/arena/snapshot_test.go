@@ -0,0 +1,55 @@
+package arena
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// 快照装回另一个 Arena 后内容与偏移量都与原来一致，按偏移取回的对象值不变
+func TestSnapshotLoadRoundTrip(t *testing.T) {
+	src := AcquireSized(4096)
+	defer src.Release()
+	h := New[header](src)
+	h.Magic, h.Len, h.Seq = 0xCAFEBABE, 12, 42
+	vals := MakeSlice[uint32](src, 5, 5)
+	for i := range vals {
+		vals[i] = uint32(i + 1)
+	}
+	hOff, vOff := OffsetOf(src, h), OffsetOf(src, &vals[0])
+
+	snap := src.Snapshot()
+	if len(snap) != src.Used() {
+		t.Fatalf("snapshot is %d bytes, want Used() = %d", len(snap), src.Used())
+	}
+	vals[0] = 99 // 快照是拷贝，之后的修改不影响它
+
+	dst := AcquireSized(4096)
+	defer dst.Release()
+	MakeSlice[byte](dst, 1000, 1000) // 原有内容被覆盖
+	gen := dst.Generation()
+	if err := dst.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Used() != len(snap) || !bytes.Equal(dst.buf[:dst.Used()], snap) {
+		t.Fatalf("loaded arena: Used %d, want %d; contents differ", dst.Used(), len(snap))
+	}
+	if dst.Generation() == gen {
+		t.Fatal("LoadSnapshot did not bump the generation")
+	}
+	if got := *At[header](dst, hOff); got != (header{Magic: 0xCAFEBABE, Len: 12, Seq: 42}) {
+		t.Fatalf("header after load = %+v", got)
+	}
+	if got := SliceFrom(dst, At[uint32](dst, vOff), 5); got[0] != 1 || got[4] != 5 {
+		t.Fatalf("values after load = %v, want [1 2 3 4 5]", got)
+	}
+	if next := New[uint64](dst); OffsetOf(dst, next) < len(snap) {
+		t.Fatal("allocation after load overlaps the loaded region")
+	}
+
+	// 容量不够时原样保留
+	used := dst.Used()
+	if err := dst.LoadSnapshot(make([]byte, dst.Cap()+1)); !errors.Is(err, ErrArenaFull) || dst.Used() != used {
+		t.Fatalf("oversized snapshot: err = %v, Used = %d; want ErrArenaFull and %d", err, dst.Used(), used)
+	}
+}
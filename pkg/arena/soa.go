@@ -0,0 +1,106 @@
+package arena
+
+import "unsafe"
+
+// CacheLine 是 SoA 中每个字段数组的对齐粒度
+// 让每个数组都从独立的 Cache Line 开始，便于向量化 (SIMD) 批处理
+const CacheLine = 64
+
+// MakeSoA2 一次性分配两个长度为 n 的数组 (Struct-of-Arrays 布局)
+// 每个数组在 Arena 中连续存放且按 CacheLine 对齐；空间不足时在分配任何数组之前 panic
+func MakeSoA2[A, B any](a *Arena, n int) ([]A, []B) {
+	var za A
+	var zb B
+	a.ensure(a.soaSize(n, unsafe.Sizeof(za), unsafe.Sizeof(zb)))
+	return makeAligned[A](a, n), makeAligned[B](a, n)
+}
+
+// MakeSoA3 一次性分配三个长度为 n 的数组 (如订单的 价格/数量/用户ID)
+//
+//	prices, qtys, uids := arena.MakeSoA3[float64, int, int](mem, n)
+func MakeSoA3[A, B, C any](a *Arena, n int) ([]A, []B, []C) {
+	var za A
+	var zb B
+	var zc C
+	a.ensure(a.soaSize(n, unsafe.Sizeof(za), unsafe.Sizeof(zb), unsafe.Sizeof(zc)))
+	return makeAligned[A](a, n), makeAligned[B](a, n), makeAligned[C](a, n)
+}
+
+// soaSize 计算从当前偏移开始依次放置各字段数组 (含对齐填充) 所需的总字节数
+func (a *Arena) soaSize(n int, elemSizes ...uintptr) int {
+	end := a.offset
+	for _, sz := range elemSizes {
+		end = a.alignedOffset(end, CacheLine) + int(sz)*n
+	}
+	return end - a.offset
+}
+
+// ensure 检查剩余空间是否足够 size 字节，不足时 panic
+func (a *Arena) ensure(size int) {
+	if a.offset+size > len(a.buf) || a.sealed != nil {
+		a.outOfMemory()
+	}
+}
+
+// makeAligned 分配 n 个清零的 T，起始地址按 CacheLine 对齐
+// 调用者需先通过 ensure 保证空间足够
+func makeAligned[T any](a *Arena, n int) []T {
+	checkNoPointers[T]()
+	var zero T
+	s := unsafe.Slice((*T)(a.allocAligned(int(unsafe.Sizeof(zero))*n, CacheLine)), n)
+	clear(s)
+	return s
+}
+
+// MaxAlign 是 NewAlignedBytes 支持的最大对齐 (一页)
+// 对齐按真实地址计算，不依赖 buf 本身的对齐 (文件映射的 Arena 的 buf 只保证 CacheLine 对齐)，
+// 上限只是为了限制浪费：每次分配最多产生 align-1 字节的填充
+const MaxAlign = 4096
+
+// NewAlignedBytes 分配 size 字节、清零、起始地址按 align 对齐的切片
+// 常用的 align：16 (SSE)、32 (AVX，见 VecAlign)、64 (Cache Line)、4096 (页)
+// align 必须是 2 的幂且不超过 MaxAlign，否则 panic；所有按地址对齐的分配 (MakeVec、SoA) 都经过这里
+func NewAlignedBytes(a *Arena, size, align int) []byte {
+	s := unsafe.Slice((*byte)(a.allocAligned(size, align)), size)
+	clear(s)
+	return s
+}
+
+// allocAligned 分配 size 字节 (不清零)，起始地址按 align 对齐
+func (a *Arena) allocAligned(size, align int) unsafe.Pointer {
+	if align <= 0 || align&(align-1) != 0 {
+		panic("arena: align must be a power of two")
+	}
+	if align > MaxAlign {
+		panic("arena: align exceeds MaxAlign")
+	}
+	if size < 0 {
+		panic("arena: negative size")
+	}
+	start := a.alignedOffset(a.offset, align)
+	a.ensure(start - a.offset + size)
+	a.note(size, align, start)
+	a.offset = start + size
+	return unsafe.Add(unsafe.Pointer(unsafe.SliceData(a.buf)), start)
+}
+
+// alignedOffset 返回 >= off 的第一个真实地址按 align 对齐的偏移
+func (a *Arena) alignedOffset(off, align int) int {
+	base := int(uintptr(unsafe.Pointer(unsafe.SliceData(a.buf))))
+	return alignUp(base+off, align) - base
+}
+
+// alignUp 把 n 向上取整到 align (2 的幂) 的倍数
+func alignUp(n, align int) int {
+	return (n + align - 1) &^ (align - 1)
+}
+
+// VecAlign 是 MakeVec 保证的对齐粒度 (AVX/AVX2 的 256 位寄存器宽度)
+const VecAlign = 32
+
+// MakeVec 分配一个长度为 n、清零的 []float64，数据起始地址保证 32 字节对齐，
+// 可以直接交给 VMOVAPD 等要求对齐的 AVX 指令 (或汇编内核) 处理
+func MakeVec(a *Arena, n int) []float64 {
+	b := NewAlignedBytes(a, n*8, VecAlign)
+	return unsafe.Slice((*float64)(unsafe.Pointer(unsafe.SliceData(b))), n)
+}
@@ -0,0 +1,50 @@
+package arena
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestMakeSoA3Layout(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+	New[byte](a) // 打乱当前偏移，确认对齐按真实地址计算
+
+	const n = 100
+	prices, qtys, uids := MakeSoA3[float64, int32, uint16](a, n)
+	type span struct{ start, end uintptr }
+	spans := []span{
+		{uintptr(unsafe.Pointer(&prices[0])), uintptr(unsafe.Pointer(&prices[0])) + n*8},
+		{uintptr(unsafe.Pointer(&qtys[0])), uintptr(unsafe.Pointer(&qtys[0])) + n*4},
+		{uintptr(unsafe.Pointer(&uids[0])), uintptr(unsafe.Pointer(&uids[0])) + n*2},
+	}
+	for i, s := range spans {
+		if s.start%CacheLine != 0 {
+			t.Errorf("array %d starts at %#x, not CacheLine aligned", i, s.start)
+		}
+		if i > 0 && s.start < spans[i-1].end {
+			t.Errorf("array %d overlaps array %d", i, i-1)
+		}
+	}
+	if len(prices) != n || cap(prices) != n || len(qtys) != n || len(uids) != n {
+		t.Fatal("arrays do not have exactly n elements")
+	}
+	for i := range n {
+		if prices[i] != 0 || qtys[i] != 0 || uids[i] != 0 {
+			t.Fatalf("element %d not zeroed", i)
+		}
+	}
+}
+
+// 空间不足时在分配任何数组之前 panic，不会留下只分配了一半的布局
+func TestMakeSoA2OutOfMemory(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+	used := a.Used()
+	if r := catchPanic(func() { MakeSoA2[uint64, uint64](a, 300) }); r != "arena: out of memory" {
+		t.Fatalf("oversized SoA: panic = %v", r)
+	}
+	if a.Used() != used {
+		t.Fatalf("Used = %d after a failed SoA, want %d", a.Used(), used)
+	}
+}
@@ -0,0 +1,43 @@
+package arena
+
+// Slice 是带代数 (Generation) 标签的 Arena 切片 ("胖指针")
+// Arena 每次 Reset 都会使代数 +1，持有旧代数的 Slice 在访问时即可自检出 use-after-reset
+type Slice[T any] struct {
+	data  []T
+	gen   uint64
+	arena *Arena
+}
+
+// MakeSliceTracked 与 MakeSlice 相同，但返回带代数标签的 Slice
+func MakeSliceTracked[T any](a *Arena, length, capacity int) Slice[T] {
+	return Slice[T]{
+		data:  MakeSlice[T](a, length, capacity),
+		gen:   a.gen,
+		arena: a,
+	}
+}
+
+// Valid 报告该切片所属的 Arena 自分配以来是否没有被 Reset
+func (s Slice[T]) Valid() bool {
+	return s.arena != nil && s.arena.gen == s.gen
+}
+
+// Get 返回底层切片
+// 调试构建 (-tags arena_debug) 下，如果 Arena 已经 Reset 过则直接 panic
+func (s Slice[T]) Get() []T {
+	if debug && !s.Valid() {
+		panic("arena: tracked slice used after reset")
+	}
+	return s.data
+}
+
+// Append 向切片追加元素，容量不够时与内置 append 一样搬到堆上 (不会在 Arena 上扩容)
+// 调试构建下搬走时打印警告并计入 Escapes (见 CheckSlice)，生产构建中只是一次 append
+func (s Slice[T]) Append(vals ...T) Slice[T] {
+	grow := debug && len(s.data)+len(vals) > cap(s.data)
+	s.data = append(s.data, vals...)
+	if grow {
+		noteEscape[T](len(s.data))
+	}
+	return s
+}
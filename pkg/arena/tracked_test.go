@@ -0,0 +1,28 @@
+package arena
+
+import "testing"
+
+func TestTrackedSliceAfterReset(t *testing.T) {
+	a := AcquireSized(4096)
+	defer a.Release()
+
+	s := MakeSliceTracked[int](a, 4, 4)
+	s.Get()[0] = 1
+	if !s.Valid() {
+		t.Fatal("fresh tracked slice is not valid")
+	}
+	a.Reset()
+	if s.Valid() {
+		t.Fatal("tracked slice still valid after Reset")
+	}
+	r := catchPanic(func() { s.Get() })
+	if debug && r != "arena: tracked slice used after reset" {
+		t.Fatalf("Get after Reset in a debug build: panic = %v", r)
+	}
+	if !debug && r != nil {
+		t.Fatalf("Get after Reset in a release build panicked: %v", r)
+	}
+	if s2 := MakeSliceTracked[int](a, 1, 1); !s2.Valid() {
+		t.Fatal("slice allocated after Reset is not valid")
+	}
+}
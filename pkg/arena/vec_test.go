@@ -0,0 +1,61 @@
+package arena
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestMakeVecAligned(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+	for _, n := range []int{1, 3, 8, 100} {
+		New[byte](a) // 每次都从一个未对齐的偏移开始
+		v := MakeVec(a, n)
+		if len(v) != n || cap(v) != n {
+			t.Fatalf("MakeVec(%d): len %d cap %d", n, len(v), cap(v))
+		}
+		if p := uintptr(unsafe.Pointer(&v[0])); p%VecAlign != 0 {
+			t.Fatalf("MakeVec(%d) data at %#x, not %d-byte aligned", n, p, VecAlign)
+		}
+		for i, x := range v {
+			if x != 0 {
+				t.Fatalf("MakeVec(%d)[%d] = %v, want 0", n, i, x)
+			}
+		}
+	}
+}
+
+func TestNewAlignedBytes(t *testing.T) {
+	a := AcquireSized(1 << 16)
+	defer a.Release()
+	for _, align := range []int{1, 2, 8, 16, 32, 64, 4096} {
+		// 先写脏，确认返回的内存被清零
+		junk := a.Remaining()
+		dirty := MakeSlice[byte](a, junk, junk)
+		for i := range dirty {
+			dirty[i] = 0xff
+		}
+		a.Reset()
+		New[byte](a) // 从一个未对齐的偏移开始
+
+		b := NewAlignedBytes(a, 100, align)
+		if len(b) != 100 {
+			t.Fatalf("align %d: len %d", align, len(b))
+		}
+		if p := uintptr(unsafe.Pointer(&b[0])); p%uintptr(align) != 0 {
+			t.Fatalf("align %d: data at %#x", align, p)
+		}
+		for i, x := range b {
+			if x != 0 {
+				t.Fatalf("align %d: b[%d] = %#x, want 0", align, i, x)
+			}
+		}
+		a.Reset()
+	}
+
+	for _, align := range []int{0, -8, 3, 48, 2 * MaxAlign} {
+		if r := catchPanic(func() { NewAlignedBytes(a, 8, align) }); r == nil {
+			t.Errorf("align %d did not panic", align)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import "time"
+
+// 运维接口：查看/修正单个用户的 UserVolume
+//
+// UserVolume 只能由 Worker 写入，所以这些操作不直接读写数组，而是作为控制任务投递到队列，
+// 与普通订单串行执行：一次 Reset 要么发生在某笔订单之前，要么之后，不会与其交错。
+// 开启 EnableCommandQueue 时改走独立的命令通道 (见 command.go)，不再占用数据队列
+
+// controlRetry 控制任务入队失败时的重试间隔
+const controlRetry = 50 * time.Microsecond
+
+// GetUserVolume 返回用户 uid 当前的累计成交额 (与订单串行，读到的是一致的值)
+// Worker 回复错误时返回 0，需要区分的调用方使用 TryGetUserVolume
+func (e *Engine) GetUserVolume(uid int) float64 {
+	v, _ := e.TryGetUserVolume(uid)
+	return v
+}
+
+// TryGetUserVolume 与 GetUserVolume 相同，Worker 回复的是错误 (如 Export 交接时的 ErrHandedOff) 时返回该错误
+func (e *Engine) TryGetUserVolume(uid int) (float64, error) {
+	if e.cmds != nil {
+		return e.exec(Command{Kind: CmdQueryVolume, UID: uid}).Value, nil
+	}
+	r, err := e.control(Task{Type: TaskTypeQuery, Value: uid})
+	if err != nil {
+		return 0, err
+	}
+	return r.(float64), nil
+}
+
+// SnapshotVolumes 把用户 [from, from+len(dst)) 的累计成交额拷贝到 dst，返回实际写入的个数
+// (超出 1024 个用户的部分不写)，供看板一次读取大量用户而不必逐个 GetUserVolume
+//
+// 拷贝由 Worker 作为一个控制任务完成，与订单串行，所以不会读到写了一半的值：
+// 单分片时 dst 是某一时刻的一致快照；多分片时逐个分片执行，每个分片负责的那部分用户各自一致，
+// 分片之间不是同一时刻 (与 SnapshotState 相同)
+func (e *Engine) SnapshotVolumes(from int, dst []float64) int {
+	if from < 0 || from >= stateUsers {
+		return 0
+	}
+	dst = dst[:min(len(dst), stateUsers-from)]
+	if e.cmds != nil {
+		e.exec(Command{Kind: CmdSnapshotVolumes, From: from, Volumes: dst})
+		return len(dst)
+	}
+	e.eachShard(Task{Type: TaskTypeVolumes, Quantity: from, Volumes: dst, QoS: QoSGold})
+	return len(dst)
+}
+
+// processVolumes 在 Worker 中执行批量查询，只拷贝归属本分片 (shardOf == shardID) 的用户
+// 与 processState 一样，各分片写入 dst 中互不重叠的位置
+// 只传需要的字段而不是整个 Task：process 是 nosplit 的，每多一份 Task 拷贝都会占用它的栈帧
+func (e *Engine) processVolumes(dst []float64, from, shards int, resp chan any) {
+	for i := range dst {
+		if uid := from + i; e.shardOf(uid, shards) == e.shardID {
+			dst[i] = e.UserVolume[uid]
+		}
+	}
+	e.reply(resp, nil)
+}
+
+// ResetUserVolume 把用户 uid 的累计成交额清零
+// 不受 Dry-Run 影响：这是运维修正，而不是业务流量
+func (e *Engine) ResetUserVolume(uid int) {
+	e.TryResetUserVolume(uid)
+}
+
+// TryResetUserVolume 与 ResetUserVolume 相同，Worker 回复错误时返回该错误
+func (e *Engine) TryResetUserVolume(uid int) error {
+	if e.cmds != nil {
+		e.exec(Command{Kind: CmdResetVolume, UID: uid})
+		return nil
+	}
+	_, err := e.control(Task{Type: TaskTypeResetVolume, Value: uid})
+	return err
+}
+
+// control 同步执行一个控制任务：走 Gold 通道直接投递到所属分片的队列，等待 Worker 回复
+// 与 eachShard 相同，不经过 TrySubmit 的校验、熔断、在途上限与内存降载 (否则这些拒绝会让控制任务永远重试)，
+// 只在队列满 (ErrFull) 时重试；每次投递都持有 scaleMu，路由不会在投递中途改变，
+// 两次重试之间的等待不持有它，不阻塞 Scale 与其它引擎的控制任务。
+// Worker 回复的是 error 时作为第二个返回值返回
+func (e *Engine) control(t Task) (any, error) {
+	t.Resp = e.getRespChan()
+	t.QoS = QoSGold
+	for {
+		scaleMu.Lock()
+		err := e.route(t).submitErr(t)
+		scaleMu.Unlock()
+		if err == nil {
+			break
+		}
+		if err != ErrFull {
+			e.putRespChan(t.Resp)
+			return nil, err
+		}
+		time.Sleep(controlRetry)
+	}
+	r := <-t.Resp
+	e.putRespChan(t.Resp)
+	if err, ok := r.(error); ok {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Flush 阻塞直到调用前已提交的所有任务都处理完毕 (所有分片)
+// 实现为向每个分片的普通队列投递一个屏障任务：普通队列 FIFO，高优先级队列总是先于它被取空，
+// 溢出区中更早的任务由屏障自己排到溢出区末尾来等待
+func (e *Engine) Flush() {
+	e.eachShard(Task{Type: TaskTypeFlush, QoS: QoSSilver})
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// 控制任务不经过 TrySubmit：拒绝 Query/Reset 的校验函数、占满的在途上限都不能让它们卡住
+func TestControlBypassesSubmitChecks(t *testing.T) {
+	e := NewEngine()
+	reject := func(Task) error { return errors.New("rejected") }
+	e.SetValidator(TaskTypeQuery, reject)
+	e.SetValidator(TaskTypeResetVolume, reject)
+	e.EnableInflightLimit(1)
+	e.Start()
+	stopOnCleanup(t, e)
+	if !e.admit.TryAcquire() {
+		t.Fatal("could not saturate the inflight limit")
+	}
+	defer e.admit.Release()
+
+	done := make(chan error, 1)
+	go func() {
+		if err := e.TryResetUserVolume(1); err != nil {
+			done <- err
+			return
+		}
+		_, err := e.TryGetUserVolume(1)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("control task failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("control task hung behind submit checks")
+	}
+}
+
+// 与订单并发的 Reset 与订单串行：每次清零拿到的旧值加上最后剩下的成交额，正好等于全部订单的金额，
+// 没有订单被清零吞掉或被计入两次
+func TestResetSerializesWithOrders(t *testing.T) {
+	for _, cmds := range []bool{false, true} {
+		e := NewEngine()
+		if cmds {
+			e.EnableCommandQueue(64)
+		}
+		e.Start()
+		stopOnCleanup(t, e)
+
+		// 直接取清零前的值：ResetUserVolume 不返回它
+		reset := func() float64 {
+			if cmds {
+				return e.Exec(Command{Kind: CmdResetVolume, UID: 5}).Value
+			}
+			r, err := e.control(Task{Type: TaskTypeResetVolume, Value: 5})
+			if err != nil {
+				t.Fatalf("reset: %v", err)
+			}
+			return r.(float64)
+		}
+
+		const orders = 2000
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < orders; {
+				if e.Submit(Task{Type: TaskTypeOrder, Value: 5, Price: 1, Quantity: 1}) {
+					i++
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+
+		var cleared float64
+		for range 20 {
+			cleared += reset()
+			runtime.Gosched()
+		}
+		<-done
+		e.Flush()
+		rest, err := e.TryGetUserVolume(5)
+		if err != nil {
+			t.Fatalf("TryGetUserVolume: %v", err)
+		}
+		if cleared+rest != orders {
+			t.Fatalf("cmds=%v: cleared %v + remaining %v != %d orders", cmds, cleared, rest, orders)
+		}
+	}
+}
+
+// 队列满时控制任务在两次重试之间不持有 scaleMu：其间 Scale 等操作照常拿得到锁
+func TestControlRetryReleasesScaleMu(t *testing.T) {
+	e := NewEngine()
+	for i := uint64(0); i < e.HighQueue.Cap(); i++ {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, QoS: QoSGold}); err != nil {
+			t.Fatalf("fill %d: %v", i, err)
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.TryGetUserVolume(1)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // 让控制任务进入重试
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !scaleMu.TryLock() {
+		if time.Now().After(deadline) {
+			t.Fatal("scaleMu held while the control task waits for queue space")
+		}
+		runtime.Gosched()
+	}
+	scaleMu.Unlock()
+	select {
+	case err := <-done:
+		t.Fatalf("control task returned %v before the queue drained", err)
+	default:
+	}
+
+	e.Start()
+	stopOnCleanup(t, e)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("control task never completed after the queue drained")
+	}
+}
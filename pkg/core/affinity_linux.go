@@ -0,0 +1,37 @@
+//go:build linux
+
+package core
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const schedFIFO = 1
+
+// pinThread 把当前 OS 线程绑定到 cpu 号 CPU，并可选地切换到 SCHED_FIFO 实时调度
+// 必须在 runtime.LockOSThread() 之后调用，否则设置的是一个随时会被换走的线程
+// cpu < 0 表示不绑核，rtPriority <= 0 表示保持普通调度
+func pinThread(cpu, rtPriority int) error {
+	if cpu >= 0 {
+		var mask [1024 / 64]uint64
+		if cpu >= len(mask)*64 {
+			return syscall.EINVAL
+		}
+		mask[cpu/64] |= 1 << (uint(cpu) % 64)
+		// pid=0 表示当前线程
+		_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+		if errno != 0 {
+			return errno
+		}
+	}
+	if rtPriority > 0 {
+		param := struct{ priority int32 }{int32(rtPriority)}
+		// 没有 CAP_SYS_NICE 时返回 EPERM，调用方降级为普通调度
+		_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER, 0, schedFIFO, uintptr(unsafe.Pointer(&param)))
+		if errno != 0 {
+			return errno
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+package core
+
+import (
+	"errors"
+	"runtime"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// 绑核之后当前线程的亲和性掩码只剩那一个 CPU；超出范围的 CPU 号返回 EINVAL，不改变线程
+func TestPinThreadAffinity(t *testing.T) {
+	// 不 Unlock：测试 goroutine 退出时这个被绑核的线程随之销毁，不会回到调度器里影响其它测试
+	runtime.LockOSThread()
+
+	if err := pinThread(1<<20, 0); !errors.Is(err, syscall.EINVAL) {
+		t.Fatalf("pinThread(out of range) = %v, want EINVAL", err)
+	}
+	if err := pinThread(0, 0); err != nil {
+		t.Skipf("sched_setaffinity not permitted here: %v", err)
+	}
+	var mask [1024 / 64]uint64
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, 0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask))); errno != 0 {
+		t.Fatal(errno)
+	}
+	if mask[0] != 1 || mask[1] != 0 {
+		t.Fatalf("affinity mask = %#x, want only CPU 0", mask[:2])
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package core
+
+import "errors"
+
+var errAffinityUnsupported = errors.New("core: cpu affinity is only supported on linux")
+
+// pinThread 在非 Linux 平台上不可用，仅在配置了绑核/实时优先级时返回错误
+func pinThread(cpu, rtPriority int) error {
+	if cpu < 0 && rtPriority <= 0 {
+		return nil
+	}
+	return errAffinityUnsupported
+}
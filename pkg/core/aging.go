@@ -0,0 +1,45 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"time"
+)
+
+// 优先级老化 (Priority Aging)：防止持续的 Gold 流量饿死普通队列
+//
+// 默认 Worker 总是先取高优先级队列 (High Lane)，Gold 源源不断时普通队列 (Silver/Bronze) 永远轮不到。
+// 开启 EnablePriorityAging(step) 后，任务的有效优先级随等待时间上升：每等待 step 提升一级，
+//
+//	有效优先级 = 基础优先级 (Gold 为 1，其余为 0) + 已等待时间 / step
+//
+// Worker 每次取任务时比较两条队列队首的有效优先级 (等待时间都从入队时刻起按 sysclock 计算)，
+// 普通队首的等待时间比 Gold 队首多出 step 以上时先处理普通队首，否则照旧先取 Gold。
+// 持续的 Gold 负载下 Gold 队首刚入队不久，普通任务的等待时间因此有界：约为 step 加上 Gold 队首的等待时间。
+//
+// 开销：开启后 Worker 每次取任务多两次队首读取与一次 sysclock 读，未开启时只多一次比较。
+// sysclock 精度为 1ms，step 小于 1ms 没有意义。
+// 只比较普通队列 RingBuffer 的队首：开启公平调度时已搬进用户子队列的任务不参与老化，溢出区也不参与
+
+// EnablePriorityAging 让普通队列的任务每等待 step 提升一级优先级，必须在 Start 之前调用
+func (e *Engine) EnablePriorityAging(step time.Duration) {
+	if step < time.Millisecond {
+		panic("core: priority aging step must be at least 1ms (sysclock resolution)")
+	}
+	e.agingStep = int64(step)
+}
+
+// agedFirst 报告普通队列的队首是否因老化而应先于 High Lane 处理 (只由 Worker 调用)
+func (e *Engine) agedFirst() bool {
+	low, ok := e.Queue.Peek()
+	if !ok || low.enqueued == 0 {
+		return false
+	}
+	high, ok := e.HighQueue.Peek()
+	if !ok {
+		// High Lane 为空，按原来的顺序普通队列本来就会被取到
+		return false
+	}
+	// 有效优先级：low 为 wait/step，high 为 1 + wait/step；入队时间越早等待越久
+	now := sysclock.Now()
+	return (now-low.enqueued)-(now-high.enqueued) >= e.agingStep
+}
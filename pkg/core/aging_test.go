@@ -0,0 +1,56 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"testing"
+	"time"
+)
+
+// 持续的 Gold 负载下 (每 1ms 到达一个、处理一个)，开启老化的普通任务在 step 加上 Gold 队首等待时间之内被处理；
+// 未开启时它一直排在后面
+func TestPriorityAgingBoundsWait(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	const step, backlog = 10 * time.Millisecond, 3
+	// waited 返回普通任务被处理时已经等待的 tick 数，limit 个 tick 内没被处理时返回 -1
+	waited := func(aging bool, limit int) int {
+		e := NewEngine() // 不 Start：由测试充当 Worker
+		if aging {
+			e.EnablePriorityAging(step)
+		}
+		gold := func() {
+			if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 1, QoS: QoSGold, Resp: make(chan any, 1)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for range backlog {
+			gold()
+		}
+		low := make(chan any, 1)
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 2, QoS: QoSSilver, Resp: low}); err != nil {
+			t.Fatal(err)
+		}
+		for tick := 1; tick <= limit; tick++ {
+			sysclock.Advance(time.Millisecond)
+			gold()
+			task, _ := e.pop()
+			e.runTask(task)
+			if len(low) > 0 {
+				if got := e.Stats().Aged; got != 1 {
+					t.Fatalf("Aged = %d, want 1", got)
+				}
+				return tick
+			}
+		}
+		return -1
+	}
+
+	bound := int((step+backlog*time.Millisecond)/time.Millisecond) + 1
+	if got := waited(true, 100); got < 0 || got > bound {
+		t.Fatalf("with aging the low-priority task ran after %d ticks, want within %d", got, bound)
+	}
+	if got := waited(false, 100); got >= 0 {
+		t.Fatalf("without aging the low-priority task ran after %d ticks under continuous Gold load", got)
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import "testing"
+
+// 零分配回归检查：直接在测试 goroutine 上调用 runTask (与 Worker 每轮处理一个任务的路径相同)，
+// 不经过队列和 Resp 的读取，测到的只有 Worker 自己的分配。
+//
+// 预期 (与 TUTORIAL.md 的零分配清单一致)：
+//   - Calc / Order，Resp 为 nil (结果不回传或写入输出环)：0
+//   - Calc 带 Resp，结果在 [0, 256) 内：0 (小整数装箱命中运行时缓存)
+//   - Order 带 Resp 且日志写入调用者的 LogBuf：1 (OrderResult 装箱为 any)
+//
+// 这里的任何一项变大都说明 process 中新增了堆分配
+func TestProcessAllocs(t *testing.T) {
+	resp := make(chan any, 1)
+	logBuf := make([]byte, 0, 256)
+	cases := []struct {
+		name string
+		task Task
+		want float64
+	}{
+		{"calc", Task{Type: TaskTypeCalc, Value: 21}, 0},
+		{"calc/resp", Task{Type: TaskTypeCalc, Value: 21, Resp: resp}, 0},
+		{"order", Task{Type: TaskTypeOrder, Value: 7, Price: 1.5, Quantity: 2}, 0},
+		{"order/logbuf", Task{Type: TaskTypeOrder, Value: 7, Price: 1.5, Quantity: 2, LogBuf: logBuf}, 0},
+		{"order/logbuf/resp", Task{Type: TaskTypeOrder, Value: 7, Price: 1.5, Quantity: 2, LogBuf: logBuf, Resp: resp}, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := NewEngine()
+			got := testing.AllocsPerRun(1000, func() {
+				e.runTask(c.task)
+				select {
+				case <-resp:
+				default:
+				}
+			})
+			if got > c.want {
+				t.Fatalf("allocs/op = %v, want <= %v", got, c.want)
+			}
+		})
+	}
+}
+
+func BenchmarkProcessCalc(b *testing.B) {
+	e := NewEngine()
+	t := Task{Type: TaskTypeCalc, Value: 21}
+	b.ReportAllocs()
+	for b.Loop() {
+		e.runTask(t)
+	}
+}
+
+func BenchmarkProcessOrder(b *testing.B) {
+	e := NewEngine()
+	t := Task{Type: TaskTypeOrder, Value: 7, Price: 1.5, Quantity: 2, LogBuf: make([]byte, 0, 256)}
+	b.ReportAllocs()
+	for b.Loop() {
+		e.runTask(t)
+	}
+}
+
+// 一次下单请求的完整路径 (取 LogBuf、处理、读结果、归还)：每次 make 一块 1KB buffer 与从分级池中取
+//
+//	go test -run XXX -bench OrderLogBuf -benchmem ./pkg/core
+func BenchmarkOrderLogBuf(b *testing.B) {
+	for _, pooled := range []bool{false, true} {
+		name := "make"
+		if pooled {
+			name = "pool"
+		}
+		b.Run(name, func(b *testing.B) {
+			e := NewEngine()
+			resp := make(chan any, 1)
+			b.ReportAllocs()
+			for b.Loop() {
+				t := Task{Type: TaskTypeOrder, Value: 7, Price: 1.5, Quantity: 2, Resp: resp}
+				if pooled {
+					t.LogBuf = GetLogBuf(t)
+				} else {
+					t.LogBuf = make([]byte, 0, 1024)
+				}
+				e.runTask(t)
+				res := (<-resp).(OrderResult)
+				if pooled {
+					PutLogBuf(res.Log)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import "time"
+
+// 积压探针 (供自动扩缩容使用)
+//
+// Backlog 把队列深度、在途任务数和处理耗时 EMA 合成一个可以直接据以行动的信号：
+// 分片各自独立排空，所以排空时间取 "本分片积压 × 本分片单任务耗时" 在所有分片上的最大值，
+// 而不是总积压 / 总速率 —— 一个热点分片积压严重时，加分片 (Scale) 或迁走热点用户才有用。
+//
+// 开销是每个分片几次原子读加上 Paused 的一次加锁，不读时钟，可以在探针里高频调用。
+// 耗时 EMA 只测 process 本身 (不含排队)，还没有样本的分片按 drainFallbackCost 估计
+
+// BacklogInfo 是 Backlog 的结果
+type BacklogInfo struct {
+	Queued          uint64        // 所有分片等待处理的任务数 (队列、溢出区，不含延迟堆)
+	MaxShardQueued  uint64        // 积压最多的分片上等待处理的任务数
+	Delayed         int           // 延迟堆中尚未到期的任务数 (见 EnableDelayQueue)，不计入排空时间
+	InFlight        int           // 占用在途名额的任务数 (见 EnableInflightLimit)，未开启时为 0
+	Shards          int           // 当前分片数
+	AvgProcessNanos int64         // 有样本的分片的单任务处理耗时 EMA 的平均值，没有样本时为 0
+	DrainTime       time.Duration // 估计的排空时间：各分片 积压 × 单任务耗时 的最大值
+	Paused          bool          // Worker 已被 Pause 冻结，积压不会自行下降
+}
+
+// Backlog 返回当前的积压情况，可在任意 goroutine 调用
+func (e *Engine) Backlog() BacklogInfo {
+	info := BacklogInfo{
+		Delayed:  e.DelayedTasks(),
+		InFlight: e.TasksInFlight(),
+		Shards:   e.NumShards(),
+		Paused:   e.Paused(),
+	}
+	var sampled int64
+	for i := range info.Shards {
+		s := e.Shard(i)
+		n := s.backlog()
+		info.Queued += n
+		info.MaxShardQueued = max(info.MaxShardQueued, n)
+		cost := s.AvgProcessNanos()
+		if cost > 0 {
+			info.AvgProcessNanos += cost
+			sampled++
+		} else {
+			cost = int64(drainFallbackCost)
+		}
+		info.DrainTime = max(info.DrainTime, time.Duration(n)*time.Duration(cost))
+	}
+	if sampled > 0 {
+		info.AvgProcessNanos /= sampled
+	}
+	return info
+}
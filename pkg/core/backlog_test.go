@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// 已知数量的积压：深度、在途数与排空时间与之相符；Worker 处理完之后回到零
+func TestBacklogDrainsToZero(t *testing.T) {
+	const n = 10
+	e := NewEngine() // 先不 Start：任务都留在队列里
+	e.EnableInflightLimit(2 * n)
+	for range n {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 1, Resp: make(chan any, 1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := e.Backlog()
+	if b.Queued != n || b.MaxShardQueued != n || b.InFlight != n || b.Shards != 1 || b.Paused {
+		t.Fatalf("queued backlog = %+v, want %d queued and in flight on one shard", b, n)
+	}
+	if b.AvgProcessNanos != 0 || b.DrainTime != n*drainFallbackCost {
+		t.Fatalf("DrainTime = %v (AvgProcessNanos %d) without samples, want %v", b.DrainTime, b.AvgProcessNanos, n*drainFallbackCost)
+	}
+
+	e.Start()
+	stopOnCleanup(t, e)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		b = e.Backlog()
+		if b.Queued == 0 && b.InFlight == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("backlog never drained: %+v", b)
+		}
+	}
+	if b.MaxShardQueued != 0 || b.DrainTime != 0 {
+		t.Fatalf("drained backlog = %+v, want zero depth and drain time", b)
+	}
+
+	e.Pause()
+	paused := e.Backlog().Paused
+	e.Resume()
+	if !paused {
+		t.Fatal("Backlog did not report the paused worker")
+	}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// 背压提示：拒绝请求时告诉客户端多久之后再来 (HTTP Retry-After)
+//
+// 队列满时直接 503，守规矩的客户端也只会立刻重试，把本来就满的队列压得更满。
+// DrainEstimate 用 "积压任务数 / 最近的处理速率" 估算排空时间，调用方据此设置 Retry-After
+
+// drainMeter 记录上一次采样的 Processed，用相邻两次采样的差值估算处理速率 (EWMA)
+// 只在拒绝时由 Go World 调用，用一把锁即可
+type drainMeter struct {
+	mu        sync.Mutex
+	processed uint64
+	at        time.Time
+	rate      float64 // 任务/秒，0 表示还没有可用的估计
+}
+
+const (
+	// drainSampleMin 两次采样的最小间隔，间隔太短时差值噪声太大，直接沿用上次的速率
+	drainSampleMin = 100 * time.Millisecond
+	// drainSampleMax 超过该间隔的采样视为过期 (期间可能一直空闲)，只更新基线不更新速率
+	drainSampleMax = time.Second
+	// drainFallbackCost 没有任何速率与延迟数据时，假定每个任务的处理时间 (sysclock 精度)
+	drainFallbackCost = time.Millisecond
+)
+
+// DrainEstimate 估算当前积压 (所有分片的队列、溢出区) 全部处理完所需的时间
+//
+// 速率来自最近一段时间 Processed 的增量；还没有速率估计时 (刚启动或长时间没有拒绝)，
+// 退化为 积压 × 延迟直方图的平均延迟 —— 平均延迟包含排队时间，是单个任务耗时的上界，
+// 所以这时的估计偏保守
+func (e *Engine) DrainEstimate() time.Duration {
+	var backlog, processed uint64
+	var latency Histogram
+	for i := range e.NumShards() {
+		s := e.Shard(i)
+		backlog += s.backlog()
+		st := s.Stats()
+		processed += st.Processed
+		latency.Count += st.Latency.Count
+		latency.Sum += st.Latency.Sum
+	}
+	if backlog == 0 {
+		return 0
+	}
+	if rate := e.drainRate.sample(processed, time.Now()); rate > 0 {
+		return time.Duration(float64(backlog) / rate * float64(time.Second))
+	}
+	cost := drainFallbackCost
+	if latency.Count > 0 {
+		cost = max(time.Duration(latency.Sum/float64(latency.Count)*float64(time.Second)), time.Microsecond)
+	}
+	return time.Duration(backlog) * cost
+}
+
+// backlog 返回本分片等待处理的任务数
+// 开启公平调度时非 Gold 任务 (无论在普通队列、子队列还是溢出区) 都计入 fairUsed
+func (e *Engine) backlog() uint64 {
+	n := e.HighQueue.Len()
+	if e.fair != nil {
+		return n + uint64(e.fairUsed())
+	}
+	return n + e.Queue.Len() + uint64(e.spill.n.Load())
+}
+
+// sample 用新的 Processed 更新速率估计并返回
+func (m *drainMeter) sample(processed uint64, now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elapsed := now.Sub(m.at)
+	switch {
+	case m.at.IsZero() || elapsed > drainSampleMax:
+		m.rate = 0
+	case elapsed < drainSampleMin:
+		return m.rate
+	default:
+		inst := float64(processed-m.processed) / elapsed.Seconds()
+		if m.rate == 0 {
+			m.rate = inst
+		} else {
+			m.rate = 0.7*m.rate + 0.3*inst
+		}
+	}
+	m.processed, m.at = processed, now
+	return m.rate
+}
+
+// RetryAfterSeconds 把 DrainEstimate 换算为 Retry-After 的秒数 (向上取整，限制在 [1, 60])
+func RetryAfterSeconds(d time.Duration) int {
+	return int(min(max(math.Ceil(d.Seconds()), 1), 60))
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func submitCalc(t *testing.T, e *Engine, n int) {
+	t.Helper()
+	for i := range n {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i}); err != nil {
+			t.Fatalf("TrySubmit: %v", err)
+		}
+	}
+}
+
+// 预计排空时间与积压成正比，Retry-After 随之增长并限制在 [1, 60] 秒
+func TestDrainEstimateScalesWithBacklog(t *testing.T) {
+	e := NewEngine()
+	if d := e.DrainEstimate(); d != 0 {
+		t.Fatalf("empty queue: DrainEstimate = %v, want 0", d)
+	}
+
+	// 还没有速率与延迟数据：每个任务按 drainFallbackCost 估算
+	submitCalc(t, e, 100)
+	if d := e.DrainEstimate(); d != 100*drainFallbackCost {
+		t.Fatalf("100 queued: DrainEstimate = %v, want %v", d, 100*drainFallbackCost)
+	}
+	submitCalc(t, e, 100)
+	if d := e.DrainEstimate(); d != 200*drainFallbackCost {
+		t.Fatalf("200 queued: DrainEstimate = %v, want %v", d, 200*drainFallbackCost)
+	}
+
+	// 有速率估计时按 积压 / 速率
+	e.drainRate = drainMeter{at: time.Now(), rate: 50}
+	if d := e.DrainEstimate(); d != 4*time.Second {
+		t.Fatalf("200 queued at 50/s: DrainEstimate = %v, want 4s", d)
+	}
+	if s := RetryAfterSeconds(e.DrainEstimate()); s != 4 {
+		t.Fatalf("Retry-After = %d, want 4", s)
+	}
+	submitCalc(t, e, 200)
+	if s := RetryAfterSeconds(e.DrainEstimate()); s != 8 {
+		t.Fatalf("Retry-After after doubling the backlog = %d, want 8", s)
+	}
+
+	for _, c := range []struct {
+		d    time.Duration
+		want int
+	}{{0, 1}, {time.Millisecond, 1}, {1100 * time.Millisecond, 2}, {time.Hour, 60}} {
+		if s := RetryAfterSeconds(c.d); s != c.want {
+			t.Errorf("RetryAfterSeconds(%v) = %d, want %d", c.d, s, c.want)
+		}
+	}
+}
+
+// 速率取相邻两次采样 Processed 的增量 (EWMA)；间隔太短沿用上次的速率，太长则作废
+func TestDrainMeterSample(t *testing.T) {
+	var m drainMeter
+	t0 := time.Now()
+	if r := m.sample(0, t0); r != 0 {
+		t.Fatalf("first sample = %v, want 0", r)
+	}
+	if r := m.sample(1000, t0.Add(500*time.Millisecond)); r != 2000 {
+		t.Fatalf("rate = %v, want 2000", r)
+	}
+	if r := m.sample(9000, t0.Add(550*time.Millisecond)); r != 2000 {
+		t.Fatalf("sample within drainSampleMin = %v, want the previous 2000", r)
+	}
+	if r := m.sample(1500, t0.Add(time.Second)); r != 0.7*2000+0.3*1000 {
+		t.Fatalf("EWMA = %v, want %v", r, 0.7*2000+0.3*1000)
+	}
+	if r := m.sample(1600, t0.Add(3*time.Second)); r != 0 {
+		t.Fatalf("sample after a long gap = %v, want 0", r)
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"arena_demo/pkg/alloc"
+	"errors"
+)
+
+// ErrInvalidOrder 订单参数非法 (价格/数量必须为正)
+var ErrInvalidOrder = errors.New("core: invalid order")
+
+// Order 是批量订单中的一笔
+type Order struct {
+	Price    float64
+	Quantity int
+	UserID   int
+}
+
+// BatchResult 是 TaskTypeBatchOrder 的结果
+// 成功时 FailedIndex 为 -1；失败时所有订单都不生效，FailedIndex 指出第一笔失败的订单
+type BatchResult struct {
+	Total       float64 // 全部订单的总金额 (失败时为 0)
+	ProcessedAt int64
+	FailedIndex int
+	Err         error
+	CorrID      uint64
+}
+
+// validateOrder 检查一笔订单是否可以执行 (在 C World 中调用，不能分配内存)
+func validateOrder(o Order) error {
+	if o.Price <= 0 || o.Quantity <= 0 || o.UserID < 0 {
+		return ErrInvalidOrder
+	}
+	return nil
+}
+
+// batchLocal 报告整篮订单的用户是否都与 t.Value 属于同一个分片
+// 整篮订单只在一个分片的 Worker 上执行，别的分片的用户由别的 Worker 写，跨分片的篮子由 TrySubmit 以 ErrInvalidTask 拒绝。
+// 在提交闸门内调用，期间分片表不会变化
+func (e *Engine) batchLocal(t *Task) bool {
+	n := e.NumShards()
+	if n == 1 {
+		return true
+	}
+	home := e.shardOf(t.Value, n)
+	for _, o := range t.Orders {
+		if e.shardOf(o.UserID&1023, n) != home {
+			return false
+		}
+	}
+	return true
+}
+
+// processBatchOrder 以事务方式执行一篮子订单：要么全部生效，要么全部不生效
+//
+// 轧差 (Netting)：整篮订单的边界就是一次批处理，执行期间不直接写 UserVolume，
+// 而是把每个用户的增量累加在 Arena 上的局部数组里，最后每个用户只写一次共享状态：
+//  1. 逐笔校验，限额基于 UserVolume + 本篮已累计的增量判断 (与逐笔写入的判断结果相同)
+//  2. 任意一笔失败 (或 Dry-Run) 时直接丢弃增量，不需要再对 UserVolume 做快照/回滚
+//  3. 全部成功后按用户首次出现的顺序，把合并后的增量一次性加到 UserVolume
+//
+// 同一用户的多笔订单先彼此相加再加到余额上，浮点舍入顺序与逐笔 += 不同，
+// 只有金额都能被 float64 精确表示时 (如整数价格) 结果才逐位相等
+//
+// 多分片时整篮订单在 t.Value 所属的分片上执行，所有用户都必须属于这个分片 (见 batchLocal)
+func (e *Engine) processBatchOrder(t Task, dry bool) {
+	ts, _ := e.now()
+	res := BatchResult{ProcessedAt: ts, FailedIndex: -1, CorrID: t.CorrID}
+
+	// deltas 不清零：只有 touched 中置位的用户才会被读取，首次访问时再置 0
+	var touched [len(e.UserVolume) / 64]uint64
+	al := e.allocator(t.Type)
+	deltas := alloc.MakeSliceNoZero[float64](al, len(e.UserVolume), len(e.UserVolume))
+	order := alloc.MakeSliceNoZero[uint16](al, 0, min(len(t.Orders), len(e.UserVolume)))
+
+	for i, o := range t.Orders {
+		if err := validateOrder(o); err != nil {
+			res.FailedIndex, res.Err = i, err
+			break
+		}
+		uid := o.UserID & 1023
+		if touched[uid/64]&(1<<(uid%64)) == 0 {
+			touched[uid/64] |= 1 << (uid % 64)
+			deltas[uid] = 0
+			order = append(order, uint16(uid))
+		}
+		total := o.Price * float64(o.Quantity)
+		// 限额基于已应用之前几笔订单后的状态判断
+		if err := e.checkLimit(uid, deltas[uid]+total); err != nil {
+			res.FailedIndex, res.Err = i, err
+			break
+		}
+		deltas[uid] += total
+		res.Total += total
+	}
+
+	// 失败不落地；Dry-Run 无论成败都不落地，保证与正常路径执行完全相同的计算
+	if res.Err == nil && !dry {
+		for _, uid := range order {
+			e.UserVolume[uid] += deltas[uid]
+			e.walAddVolume(int(uid), deltas[uid])
+		}
+	}
+	if res.Err != nil {
+		res.Total = 0
+	}
+	e.reply(t.Resp, res)
+}
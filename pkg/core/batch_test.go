@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func callBatch(t *testing.T, e *Engine, orders []Order) BatchResult {
+	t.Helper()
+	r, err := e.Call(context.Background(), Task{Type: TaskTypeBatchOrder, Orders: orders})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	return r.(BatchResult)
+}
+
+// 中间一笔失败时整篮回滚：前面已经通过校验的订单也不生效，FailedIndex 指向失败的那一笔
+func TestBatchOrderRollback(t *testing.T) {
+	e := NewEngineSync()
+	e.SetUserLimit(2, 150)
+
+	res := callBatch(t, e, []Order{
+		{Price: 10, Quantity: 1, UserID: 1},
+		{Price: 100, Quantity: 1, UserID: 2},
+		{Price: 100, Quantity: 1, UserID: 2}, // 累计 200，超过 150
+		{Price: 1, Quantity: 1, UserID: 3},
+	})
+	if res.FailedIndex != 2 || !errors.Is(res.Err, ErrPositionLimit) || res.Total != 0 {
+		t.Fatalf("result = %+v, want failure at index 2 with ErrPositionLimit", res)
+	}
+	res = callBatch(t, e, []Order{{Price: 1, Quantity: 1, UserID: 1}, {Price: -1, Quantity: 1, UserID: 1}})
+	if res.FailedIndex != 1 || !errors.Is(res.Err, ErrInvalidOrder) {
+		t.Fatalf("invalid order: result = %+v", res)
+	}
+	for uid := 1; uid <= 3; uid++ {
+		if v := e.GetUserVolume(uid); v != 0 {
+			t.Fatalf("UserVolume[%d] = %v after rollback, want 0", uid, v)
+		}
+	}
+}
+
+func TestBatchOrderCommit(t *testing.T) {
+	e := NewEngineSync()
+	e.SetUserLimit(2, 150)
+	res := callBatch(t, e, []Order{
+		{Price: 10, Quantity: 2, UserID: 1},
+		{Price: 50, Quantity: 1, UserID: 2},
+		{Price: 100, Quantity: 1, UserID: 2},
+	})
+	if res.Err != nil || res.FailedIndex != -1 || res.Total != 170 {
+		t.Fatalf("result = %+v, want success with Total 170", res)
+	}
+	for uid, want := range map[int]float64{1: 20, 2: 150} {
+		if v := e.GetUserVolume(uid); v != want {
+			t.Fatalf("UserVolume[%d] = %v, want %v", uid, v, want)
+		}
+	}
+}
+
+// 轧差后的结果与逐笔下单相同：每个用户的最终成交额等于各笔金额之和
+func TestBatchOrderNettingMatchesSingleOrders(t *testing.T) {
+	batch, single := NewEngineSync(), NewEngineSync()
+	orders := make([]Order, 500)
+	for i := range orders {
+		// 整数金额，累加顺序不影响结果
+		orders[i] = Order{Price: float64(1 + i%7), Quantity: 1 + i%3, UserID: (i * 7) % 20}
+	}
+	for _, e := range []*Engine{batch, single} {
+		order(t, e, 5, 11) // 已有余额的用户
+	}
+
+	if res := callBatch(t, batch, orders); res.Err != nil {
+		t.Fatalf("batch: %+v", res)
+	}
+	var total float64
+	for _, o := range orders {
+		r, err := single.Call(context.Background(), Task{Type: TaskTypeOrder, Value: o.UserID, Price: o.Price, Quantity: o.Quantity})
+		if err != nil || r.(OrderResult).Err != nil {
+			t.Fatalf("single order %+v: %v %v", o, r, err)
+		}
+		total += o.Price * float64(o.Quantity)
+	}
+	var sum float64
+	for uid := range batch.UserVolume {
+		if batch.UserVolume[uid] != single.UserVolume[uid] {
+			t.Errorf("user %d: netted = %v, single orders = %v", uid, batch.UserVolume[uid], single.UserVolume[uid])
+		}
+		sum += batch.UserVolume[uid]
+	}
+	if sum != total+11 {
+		t.Fatalf("total volume = %v, want %v", sum, total+11)
+	}
+}
+
+// 限额按 "余额 + 本篮已累计的增量" 判断：失败的位置与逐笔下单第一次被拒的位置相同
+func TestBatchOrderNettingRespectsLimit(t *testing.T) {
+	e := NewEngineSync()
+	order(t, e, 1, 40)
+	e.SetUserLimit(1, 100)
+	orders := []Order{
+		{Price: 20, Quantity: 1, UserID: 1},
+		{Price: 5, Quantity: 1, UserID: 2},
+		{Price: 40, Quantity: 1, UserID: 1}, // 累计 100，恰好到达限额
+		{Price: 1, Quantity: 1, UserID: 1},  // 超过
+	}
+	if res := callBatch(t, e, orders); res.FailedIndex != 3 || !errors.Is(res.Err, ErrPositionLimit) {
+		t.Fatalf("result = %+v, want ErrPositionLimit at index 3", res)
+	}
+	if res := callBatch(t, e, orders[:3]); res.Err != nil || res.Total != 65 {
+		t.Fatalf("batch within the limit: %+v", res)
+	}
+	if e.UserVolume[1] != 100 || e.UserVolume[2] != 5 {
+		t.Fatalf("UserVolume = %v, %v; want 100, 5", e.UserVolume[1], e.UserVolume[2])
+	}
+}
+
+// 多分片时整篮订单只在一个 Worker 上执行：用户跨分片的篮子被拒绝，同一分片的照常生效
+func TestBatchOrderAcrossShards(t *testing.T) {
+	e := NewEngine()
+	e.StartN(2)
+	stopOnCleanup(t, e)
+	ctx := context.Background()
+
+	// 默认路由 uid&1：用户 1 与 2 不在同一分片
+	mixed := Task{Type: TaskTypeBatchOrder, Value: 2, Orders: []Order{{Price: 1, Quantity: 1, UserID: 2}, {Price: 1, Quantity: 1, UserID: 1}}}
+	if _, err := e.Call(ctx, mixed); !errors.Is(err, ErrInvalidTask) {
+		t.Fatalf("batch spanning shards: err = %v, want ErrInvalidTask", err)
+	}
+
+	local := Task{Type: TaskTypeBatchOrder, Value: 2, Orders: []Order{{Price: 2, Quantity: 1, UserID: 2}, {Price: 3, Quantity: 1, UserID: 4}}}
+	r, err := e.Call(ctx, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := r.(BatchResult); res.Err != nil || res.Total != 5 {
+		t.Fatalf("single-shard batch = %+v, want Total 5", res)
+	}
+	for uid, want := range map[int]float64{1: 0, 2: 2, 4: 3} {
+		if v := e.GetUserVolume(uid); v != want {
+			t.Fatalf("UserVolume[%d] = %v, want %v", uid, v, want)
+		}
+	}
+}
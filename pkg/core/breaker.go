@@ -0,0 +1,117 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于打开状态，任务被快速拒绝
+var ErrCircuitOpen = errors.New("core: circuit open")
+
+// BreakerState 熔断器状态
+type BreakerState int32
+
+const (
+	BreakerClosed   BreakerState = iota // 正常放行，统计连续失败
+	BreakerOpen                         // 快速失败，等待 OpenTimeout
+	BreakerHalfOpen                     // 放行一个探测请求，决定恢复还是重新打开
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitBreaker 保护依赖外部系统 (WAL 刷盘、定价服务等) 的任务类型
+// 全部使用原子变量实现，可以在任意 goroutine (包括 C World) 中调用
+// 时间来源是 sysclock，判断是否到期不产生系统调用
+type CircuitBreaker struct {
+	// FailureThreshold 连续失败多少次后打开
+	FailureThreshold int32
+	// OpenTimeout 打开状态持续多久后进入半开
+	OpenTimeout time.Duration
+
+	state    atomic.Int32
+	failures atomic.Int32
+	openedAt atomic.Int64
+}
+
+// NewCircuitBreaker 创建一个熔断器
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{FailureThreshold: int32(threshold), OpenTimeout: openTimeout}
+}
+
+// State 返回当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	return BreakerState(b.state.Load())
+}
+
+// Allow 判断是否放行一个请求
+// 打开状态超时后，只有一个调用者能通过 CAS 进入半开状态成为探测请求
+func (b *CircuitBreaker) Allow() bool {
+	switch BreakerState(b.state.Load()) {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if sysclock.Now()-b.openedAt.Load() < int64(b.OpenTimeout) {
+			return false
+		}
+		return b.state.CompareAndSwap(int32(BreakerOpen), int32(BreakerHalfOpen))
+	}
+	// 半开：探测请求尚未返回，其余请求继续快速失败
+	return false
+}
+
+// Success 报告一次依赖调用成功
+func (b *CircuitBreaker) Success() {
+	b.failures.Store(0)
+	b.state.CompareAndSwap(int32(BreakerHalfOpen), int32(BreakerClosed))
+}
+
+// Failure 报告一次依赖调用失败
+func (b *CircuitBreaker) Failure() {
+	if b.state.CompareAndSwap(int32(BreakerHalfOpen), int32(BreakerOpen)) {
+		b.openedAt.Store(sysclock.Now())
+		return
+	}
+	if b.failures.Add(1) >= b.FailureThreshold &&
+		b.state.CompareAndSwap(int32(BreakerClosed), int32(BreakerOpen)) {
+		b.openedAt.Store(sysclock.Now())
+		b.failures.Store(0)
+	}
+}
+
+// breakerGuards 报告 typ 类型的任务是否受熔断保护
+func (e *Engine) breakerGuards(typ int) bool {
+	return e.Breaker != nil && e.BreakerTypes&(1<<uint(typ)) != 0
+}
+
+// noteReply 记下受保护任务的回复是否表示失败：error，或者带 Err 的订单/批量结果
+func (e *Engine) noteReply(v any) {
+	switch r := v.(type) {
+	case error:
+		e.breakerFailed = true
+	case OrderResult:
+		e.breakerFailed = e.breakerFailed || r.Err != nil
+	case BatchResult:
+		e.breakerFailed = e.breakerFailed || r.Err != nil
+	}
+}
+
+// breakerReport 在受保护任务处理完之后向熔断器报告结果：回复了错误或者 panic 记为失败，其余记为成功
+// 半开状态下放行的探测任务由此决定熔断器恢复还是重新打开
+func (e *Engine) breakerReport() {
+	e.breakerWatch = false
+	if e.breakerFailed {
+		e.Breaker.Failure()
+	} else {
+		e.Breaker.Success()
+	}
+}
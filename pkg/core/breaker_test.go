@@ -0,0 +1,133 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+	b := NewCircuitBreaker(3, time.Second)
+
+	// 连续失败达到阈值才打开，中间的成功清零计数
+	b.Failure()
+	b.Failure()
+	b.Success()
+	b.Failure()
+	b.Failure()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatalf("state = %v after non-consecutive failures, want closed", b.State())
+	}
+	b.Failure()
+	if b.State() != BreakerOpen || b.Allow() {
+		t.Fatalf("state = %v after 3 consecutive failures, want open and rejecting", b.State())
+	}
+
+	// 超时后只放行一个探测请求；探测失败重新打开
+	sysclock.Advance(time.Second)
+	if !b.Allow() || b.State() != BreakerHalfOpen {
+		t.Fatalf("after OpenTimeout: state = %v, want half-open probe", b.State())
+	}
+	if b.Allow() {
+		t.Fatal("second request passed while the probe is in flight")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen || b.Allow() {
+		t.Fatalf("failed probe: state = %v, want open", b.State())
+	}
+
+	// 再次超时，探测成功后恢复
+	sysclock.Advance(time.Second)
+	if !b.Allow() {
+		t.Fatal("no probe after the second timeout")
+	}
+	b.Success()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatalf("successful probe: state = %v, want closed", b.State())
+	}
+}
+
+// 只有 BreakerTypes 中的任务类型受熔断影响，状态出现在 Stats 中
+func TestBreakerGatesSubmit(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+	e := NewEngineSync()
+	e.Breaker = NewCircuitBreaker(1, time.Second)
+	e.BreakerTypes = 1 << TaskTypeOrder
+	if e.Stats().Breaker != "closed" {
+		t.Fatalf("Stats().Breaker = %q, want closed", e.Stats().Breaker)
+	}
+
+	e.Breaker.Failure()
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("order with an open breaker: %v, want ErrCircuitOpen", err)
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc}); err != nil {
+		t.Fatalf("unprotected calc rejected: %v", err)
+	}
+	if e.Stats().Breaker != "open" {
+		t.Fatalf("Stats().Breaker = %q, want open", e.Stats().Breaker)
+	}
+}
+
+// 熔断器由真实的任务结果驱动：连续被拒绝的订单使它打开，半开时放行的探测订单决定恢复还是重新打开
+func TestBreakerTrippedByTaskFailures(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+	e := NewEngineSync()
+	e.Breaker = NewCircuitBreaker(3, time.Second)
+	e.BreakerTypes = 1 << TaskTypeOrder
+	e.SetUserLimit(1, 10)
+	ctx := context.Background()
+	order := func(qty int) error {
+		t.Helper()
+		r, err := e.Call(ctx, Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: qty})
+		if err != nil {
+			return err
+		}
+		return r.(OrderResult).Err
+	}
+
+	// 不受保护的类型与成功的订单不计入失败
+	for range 5 {
+		if _, err := e.Call(ctx, Task{Type: TaskTypeCalc, Value: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 3 {
+		if err := order(100); !errors.Is(err, ErrPositionLimit) {
+			t.Fatalf("order over the limit: %v, want ErrPositionLimit", err)
+		}
+	}
+	if e.Breaker.State() != BreakerOpen {
+		t.Fatalf("state = %v after 3 rejected orders, want open", e.Breaker.State())
+	}
+	if err := order(1); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("order with an open breaker: %v, want ErrCircuitOpen", err)
+	}
+
+	// 探测订单再次失败：重新打开
+	sysclock.Advance(time.Second)
+	if err := order(100); !errors.Is(err, ErrPositionLimit) {
+		t.Fatalf("failing probe: %v, want ErrPositionLimit", err)
+	}
+	if e.Breaker.State() != BreakerOpen {
+		t.Fatalf("state = %v after a failed probe, want open", e.Breaker.State())
+	}
+
+	// 探测订单成功：恢复
+	sysclock.Advance(time.Second)
+	if err := order(1); err != nil {
+		t.Fatalf("probe order: %v", err)
+	}
+	if e.Breaker.State() != BreakerClosed {
+		t.Fatalf("state = %v after a successful probe, want closed", e.Breaker.State())
+	}
+	if err := order(1); err != nil {
+		t.Fatalf("order after recovery: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"arena_demo/pkg/arena"
+	"arena_demo/pkg/fastqueue"
+	"arena_demo/pkg/sysclock"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 按任务类型的并发度：calc 任务交给无状态的 Worker 池并行处理
+//
+// 订单必须串行处理 (UserVolume 的一致性依赖单线程)，但 calc 只依赖任务本身，没有理由排在订单后面。
+// EnableCalcPool 之后，TrySubmit 按类型分道：
+//   - TaskTypeCalc (Windowed 除外) 轮转投递到 n 个池 Worker 之一，每个 Worker 有自己的 MPSC 队列和 Arena
+//   - 其余任务 (包括 Windowed calc，窗口聚合是分片状态) 照常路由到有状态的分片 Worker，语义不变
+//
+// 结果收集：与原来相同，CalcResult 写入 t.Resp (Resp 为 nil 的任务只计数不回复)。
+// 池中的任务之间不保证完成顺序，需要顺序的调用方应该等上一个结果再提交下一个
+//
+// 与分片 Worker 的差异：
+//   - 池 Worker 不修改任何引擎状态，所以不再把结果累加到 UserVolume[0]，Dry-Run 对它没有区别
+//   - 池 Worker 是普通 goroutine (不锁线程、不绑核)，空闲时先 Gosched 自旋，之后短暂 Sleep，不会一直占满 CPU
+//   - 不经过故障注入、panic 恢复和公平调度；队列满时直接 ErrFull，忽略 t.Overflow
+//   - 池属于调用 EnableCalcPool 的引擎 (分片 0)，分片数变化 (Scale) 不影响池
+//   - 池 Worker 随这个引擎一起停止：stop 置位后各自排空队列，归还 Arena 后退出
+
+// calcIdleSpins 池 Worker 连续空轮询 (Gosched) 的次数，超过后改为 Sleep(calcIdleSleep)
+const (
+	calcIdleSpins = 1024
+	calcIdleSleep = 50 * time.Microsecond
+)
+
+type calcPool struct {
+	workers []*calcWorker
+	next    atomic.Uint64
+	// admit 引擎的在途任务上限 (Start 时设置)，池 Worker 回复后归还名额
+	admit *InflightLimiter
+	// red 引擎的 RED 指标 (Start 时设置)，nil 表示未开启
+	red *redMetrics
+	// clock 引擎时钟 (Start 时设置)，用于 Deadline 判断
+	clock Clock
+	// stop 引擎的停止标记 (Start 时设置)
+	stop *atomic.Bool
+	// running 还没有退出的池 Worker
+	running sync.WaitGroup
+}
+
+type calcWorker struct {
+	// 多个 HTTP goroutine 可能同时投递到同一个池 Worker，所以使用 MPSC 而不是 RingBuffer
+	queue     *fastqueue.MPSC[Task]
+	mem       *arena.Arena
+	processed atomic.Uint64
+	expired   atomic.Uint64
+	dropped   atomic.Uint64 // Resp 满而丢弃的结果数 (见 reply.go)
+	cancelled atomic.Uint64
+	clock     Clock
+}
+
+// EnableCalcPool 让 calc 任务在 n 个无状态 Worker 上并行处理，每个 Worker 的队列容量为 queueSize (2 的幂)
+// 必须在 Start/StartN 之前调用；池 Worker 随 Start 一起启动
+func (e *Engine) EnableCalcPool(n int, queueSize uint64) {
+	if n < 1 {
+		panic("core: calc pool needs at least one worker")
+	}
+	p := &calcPool{workers: make([]*calcWorker, n)}
+	for i := range p.workers {
+		p.workers[i] = &calcWorker{
+			queue: fastqueue.NewMPSC[Task](queueSize),
+			mem:   arena.Acquire(),
+		}
+	}
+	e.calc = p
+}
+
+// CalcPoolSize 返回 calc 池的 Worker 数，未开启时为 0
+func (e *Engine) CalcPoolSize() int {
+	if e.calc == nil {
+		return 0
+	}
+	return len(e.calc.workers)
+}
+
+// start 启动全部池 Worker
+func (p *calcPool) start() {
+	for i, w := range p.workers {
+		w.clock = p.clock
+		p.running.Add(1)
+		go func() {
+			defer p.running.Done()
+			pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(),
+				pprof.Labels("role", "calc-worker", "worker", strconv.Itoa(i))))
+			w.run(p.admit, p.red, p.stop)
+		}()
+	}
+}
+
+// submit 从轮转位置开始找一个未满的池 Worker，全部满时返回 ErrFull
+func (p *calcPool) submit(t Task) error {
+	t.enqueued = sysclock.Now()
+	n := uint64(len(p.workers))
+	start := p.next.Add(1)
+	for k := uint64(0); k < n; k++ {
+		if p.workers[(start+k)%n].queue.Push(t) {
+			return nil
+		}
+	}
+	return ErrFull
+}
+
+// run 是池 Worker 的循环，stop 置位且队列已空时归还 Arena 并退出
+func (w *calcWorker) run(admit *InflightLimiter, red *redMetrics, stop *atomic.Bool) {
+	idle := 0
+	for {
+		t, ok := w.queue.Pop()
+		if !ok {
+			if stop.Load() {
+				w.mem.Release()
+				w.mem = nil
+				return
+			}
+			if idle++; idle < calcIdleSpins {
+				runtime.Gosched()
+			} else {
+				time.Sleep(calcIdleSleep)
+			}
+			continue
+		}
+		idle = 0
+		w.process(t)
+		if t.admitted {
+			admit.Release()
+		}
+		if red != nil {
+			red.done(t.Type, sysclock.Now()-t.enqueued)
+		}
+		w.mem.Reset()
+	}
+}
+
+// process 与分片 Worker 的 calc 分支计算相同，但不读写任何引擎状态
+func (w *calcWorker) process(t Task) {
+	if t.cancelled() {
+		w.cancelled.Add(1)
+		return
+	}
+	if t.Deadline != 0 && nowOf(w.clock) > t.Deadline {
+		w.expired.Add(1)
+		w.reply(t.Resp, ErrExpired)
+		return
+	}
+	v := arena.New[int](w.mem)
+	*v = t.Value * 2
+	w.processed.Add(1)
+	w.reply(t.Resp, CalcResult(*v))
+}
+
+// reply 与 Engine.reply 相同，丢弃计入池 Worker 自己的计数
+func (w *calcWorker) reply(resp chan any, v any) {
+	if resp != nil && !trySend(resp, v) {
+		w.dropped.Add(1)
+	}
+}
+
+// addStats 把池 Worker 的处理数、过期数、取消数与丢弃的结果数累加到 s
+func (p *calcPool) addStats(s *Stats) {
+	for _, w := range p.workers {
+		s.CalcPool += w.processed.Load()
+		s.Expired += w.expired.Load()
+		s.Cancelled += w.cancelled.Load()
+		s.RepliesDropped += w.dropped.Load()
+	}
+}
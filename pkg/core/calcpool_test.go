@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// calc 轮转分散到池 Worker 上并行处理，订单仍然在分片 Worker 上串行
+func TestCalcPoolLanes(t *testing.T) {
+	const workers, calcs, orders = 4, 400, 200
+	e := NewEngine()
+	e.EnableCalcPool(workers, 256)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	resp := make(chan any, calcs)
+	var wg sync.WaitGroup
+	// 订单与 calc 同时提交：每个用户的成交额必须恰好是各笔之和
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range orders / 4 {
+				if _, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: g, Price: 1, Quantity: 1}); err != nil {
+					t.Errorf("order: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := range calcs {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i, Resp: resp}); err != nil {
+			t.Fatalf("calc %d: %v", i, err)
+		}
+	}
+	seen := make([]bool, calcs)
+	for range calcs {
+		r := int((<-resp).(CalcResult))
+		if r%2 != 0 || r/2 >= calcs || seen[r/2] {
+			t.Fatalf("unexpected or duplicate result %d", r)
+		}
+		seen[r/2] = true
+	}
+	wg.Wait()
+
+	for i, w := range e.calc.workers {
+		if n := w.processed.Load(); n != calcs/workers {
+			t.Errorf("worker %d processed %d calcs, want %d (round robin)", i, n, calcs/workers)
+		}
+	}
+	st := e.Stats()
+	if st.CalcPool != calcs || st.Processed != orders {
+		t.Fatalf("CalcPool = %d, Processed = %d; want %d, %d", st.CalcPool, st.Processed, calcs, orders)
+	}
+	// 池 Worker 不累加 UserVolume[0]，用户 0 上只有订单
+	for g := range 4 {
+		if v := e.GetUserVolume(g); v != orders/4 {
+			t.Fatalf("UserVolume[%d] = %v, want %d", g, v, orders/4)
+		}
+	}
+}
+
+// calc 吞吐随池 Worker 数的变化 (需要多核才能看出扩展)
+func BenchmarkCalcPool(b *testing.B) {
+	for _, n := range []int{0, 1, 2, 4} {
+		b.Run("workers="+strconv.Itoa(n), func(b *testing.B) {
+			e := NewEngine()
+			if n > 0 {
+				e.EnableCalcPool(n, 1024)
+			}
+			e.Start()
+			defer e.stop.Store(true)
+			ctx := context.Background()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					e.Call(ctx, Task{Type: TaskTypeCalc, Value: 1})
+				}
+			})
+		})
+	}
+}
+
+// 池 Worker 随引擎停止：处理完已排队的 calc，归还 Arena 后退出
+func TestCalcPoolStopsWithEngine(t *testing.T) {
+	e := NewEngine()
+	e.EnableCalcPool(2, 64)
+	e.Start()
+	resp := make(chan any, 8)
+	for i := range 8 {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i, Resp: resp}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.stop.Store(true)
+
+	exited := make(chan struct{})
+	go func() {
+		e.calc.running.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("calc pool workers still running after the engine stopped")
+	}
+	if len(resp) != 8 {
+		t.Fatalf("%d of 8 queued calcs answered before the pool stopped", len(resp))
+	}
+	for i, w := range e.calc.workers {
+		if w.mem != nil {
+			t.Errorf("worker %d kept its arena after exiting", i)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package core
+
+// 取消排队中的任务
+//
+// 队列按值存放 Task，提交之后调用方就碰不到它了，所以取消通过任务携带的共享标记 Task.Cancel 完成：
+//
+//	cancel := new(atomic.Bool)
+//	stop := context.AfterFunc(r.Context(), func() { cancel.Store(true) })
+//	defer stop()
+//	engine.Submit(core.Task{Type: core.TaskTypeOrder, ..., Resp: resp, Cancel: cancel})
+//
+// Call 在 ctx 结束时也会设置 t.Cancel (如果调用方提供了的话)。
+//
+// Worker (包括 calc 池、延迟到期的任务与同步模式) 在处理一个任务之前检查标记，已取消的任务：
+//   - 不执行，不修改任何状态，不计入 Processed 与延迟直方图，计入 Stats.Cancelled
+//   - 不回复：设置标记的一方已经不再等待 Resp
+//   - 照常归还在途任务名额
+//
+// 已经开始处理的任务不受影响，这只是省掉排队中的无用功，不是中断。
+// 标记只在取出时检查一次：入队前就已取消的任务仍会入队并占用一个队列位置，直到被取出
+
+// cancelled 报告任务是否在处理前被取消
+func (t *Task) cancelled() bool {
+	return t.Cancel != nil && t.Cancel.Load()
+}
+
+// skipCancelled 在 Worker 中丢弃一个已取消的任务
+func (e *Engine) skipCancelled(t Task) {
+	e.stats.cancelled.Add(1)
+	if t.admitted {
+		e.admit.Release()
+	}
+}
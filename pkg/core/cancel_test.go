@@ -0,0 +1,48 @@
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// 出队前已取消的任务被跳过：不执行、不回复、计入 Cancelled，在途名额照常归还；之后的任务不受影响
+func TestCancelledBeforeDequeueSkipped(t *testing.T) {
+	e := NewEngine() // 不 Start：由测试充当 Worker
+	e.EnableInflightLimit(2)
+
+	cancel := new(atomic.Bool)
+	gone, live := make(chan any, 1), make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 100, Quantity: 1, Resp: gone, Cancel: cancel}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 5, Quantity: 1, Resp: live, Cancel: new(atomic.Bool)}); err != nil {
+		t.Fatal(err)
+	}
+	cancel.Store(true) // 调用方在任务排队时断开
+
+	for range 2 {
+		task, _ := e.pop()
+		e.runTask(task)
+	}
+	select {
+	case r := <-gone:
+		t.Fatalf("cancelled task replied %v", r)
+	default:
+	}
+	if r := (<-live).(OrderResult); r.Err != nil || r.Total != 5 {
+		t.Fatalf("task after the cancelled one = %+v, want Total 5", r)
+	}
+
+	st := e.Stats()
+	if st.Cancelled != 1 || st.Processed != 1 {
+		t.Fatalf("Cancelled = %d, Processed = %d; want 1 and 1", st.Cancelled, st.Processed)
+	}
+	if n := e.TasksInFlight(); n != 0 {
+		t.Fatalf("TasksInFlight = %d, want 0 (the cancelled task released its slot)", n)
+	}
+	e.Start() // 查询经过 Worker
+	stopOnCleanup(t, e)
+	if v, err := e.TryGetUserVolume(1); err != nil || v != 5 {
+		t.Fatalf("UserVolume[1] = %v (%v), want 5 (the cancelled order did not run)", v, err)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package core
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// cgroupMemoryLimit 返回当前进程所在 cgroup 的内存限制，没有限制 (或读不到) 时返回 0
+// 先查 cgroup v2 的 memory.max，再查 v1 的 memory.limit_in_bytes (未限制时是一个接近 2^63 的值)
+func cgroupMemoryLimit() uint64 {
+	for _, path := range []string{
+		"/sys/fs/cgroup/memory.max",
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+	} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		b = bytes.TrimSpace(b)
+		if string(b) == "max" {
+			return 0
+		}
+		n, err := strconv.ParseUint(string(b), 10, 64)
+		if err != nil || n >= 1<<62 {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package core
+
+// cgroupMemoryLimit 在非 Linux 平台上没有 cgroup，总是返回 0
+func cgroupMemoryLimit() uint64 { return 0 }
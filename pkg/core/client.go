@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrFull 队列已满 (或被准入控制拒绝)，属于可重试的瞬时错误
+var ErrFull = errors.New("core: queue full")
+
+// ErrExpired 任务在 Worker 开始处理前已超过 Task.Deadline，被直接丢弃
+var ErrExpired = errors.New("core: task deadline exceeded")
+
+// RetryPolicy 是面向调用方的提交辅助函数 (SubmitRetry / Call) 的重试参数
+// 原始的 RingBuffer.Push 与 Engine.Submit 保持无策略，重试只发生在这一层
+type RetryPolicy struct {
+	Initial    time.Duration // 第一次重试前的等待
+	Max        time.Duration // 单次等待上限
+	Multiplier float64       // 每次等待放大的倍数
+	Deadline   time.Duration // 总重试时长上限 (同时受 ctx 约束)，0 表示不重试
+}
+
+// DefaultRetryPolicy 默认参数：50us 起步，翻倍，单次最多 5ms，总共最多 50ms
+var DefaultRetryPolicy = RetryPolicy{
+	Initial:    50 * time.Microsecond,
+	Max:        5 * time.Millisecond,
+	Multiplier: 2,
+	Deadline:   50 * time.Millisecond,
+}
+
+// SubmitRetry 提交任务，遇到队列满时按 e.Retry 做带抖动的指数退避重试
+// 超过 Deadline 返回 ErrFull，ctx 被取消时返回 ctx.Err()
+// 只有 ErrFull 会被重试，其它拒绝原因 (如 ErrCircuitOpen) 立即返回
+func (e *Engine) SubmitRetry(ctx context.Context, t Task) error {
+	err := e.TrySubmit(t)
+	if err != ErrFull {
+		return err
+	}
+	p := e.Retry
+	if p.Deadline <= 0 {
+		return ErrFull
+	}
+	deadline := time.Now().Add(p.Deadline)
+	wait := p.Initial
+	timer := time.NewTimer(0)
+	<-timer.C
+	defer timer.Stop()
+	for {
+		// 全抖动 (Full Jitter)：在 [wait/2, wait) 之间随机，避免大量调用方同时醒来
+		d := wait/2 + time.Duration(rand.Int64N(int64(wait/2)+1))
+		if remain := time.Until(deadline); remain <= 0 {
+			return ErrFull
+		} else if d > remain {
+			d = remain
+		}
+		timer.Reset(d)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if err := e.TrySubmit(t); err != ErrFull {
+			return err
+		}
+		wait = time.Duration(float64(wait) * p.Multiplier)
+		if wait > p.Max {
+			wait = p.Max
+		}
+	}
+}
+
+// respChanPool 复用 Call 内部创建的响应 channel (容量 1)，消除高 RPS 下每请求一次的 make(chan)
+var respChanPool = sync.Pool{
+	New: func() any {
+		return make(chan any, 1)
+	},
+}
+
+// getRespChan 从池中取一个空的响应 channel
+func (e *Engine) getRespChan() chan any {
+	return respChanPool.Get().(chan any)
+}
+
+// putRespChan 归还响应 channel
+// 只能在 "已经收到 Worker 的唯一一次回复" 或 "任务根本没有入队" 之后调用：
+// 如果任务还在队列里 (如 ctx 超时提前返回)，Worker 稍后仍会写入，
+// 此时归还会把迟到的结果交给下一个使用者，所以这种 channel 直接丢给 GC
+func (e *Engine) putRespChan(ch chan any) {
+	respChanPool.Put(ch)
+}
+
+// Call 同步执行一个任务：提交 (带重试) 并等待结果
+// t.Resp 为空时使用池化的响应 channel，收到结果后归还
+// Worker 回传的是 error (如 ErrExpired) 时作为 err 返回
+func (e *Engine) Call(ctx context.Context, t Task) (any, error) {
+	pooled := t.Resp == nil
+	if pooled {
+		t.Resp = e.getRespChan()
+	}
+	// ctx 的截止时间同时作为任务的 Deadline，排队太久的任务 Worker 直接跳过
+	if dl, ok := ctx.Deadline(); ok && t.Deadline == 0 {
+		t.Deadline = e.clockNow() + int64(time.Until(dl))
+	}
+	if err := e.SubmitRetry(ctx, t); err != nil {
+		if pooled {
+			e.putRespChan(t.Resp) // 没有入队，不会有人写入
+		}
+		return nil, err
+	}
+	select {
+	case r := <-t.Resp:
+		if pooled {
+			e.putRespChan(t.Resp)
+		}
+		if err, ok := r.(error); ok {
+			return nil, err
+		}
+		return r, nil
+	case <-ctx.Done():
+		// 响应 channel 有缓冲，Worker 稍后写入也不会阻塞；它不能再回到池中
+		// 调用方提供了取消标记时，还在排队的任务不再处理
+		if t.Cancel != nil {
+			t.Cancel.Store(true)
+		}
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 队列短暂占满：退避期间 Worker 腾出位置，重试成功
+func TestSubmitRetryBrieflyFull(t *testing.T) {
+	e := NewEngine()
+	fillQueue(t, e)
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		e.pop()
+	}()
+	if err := e.SubmitRetry(context.Background(), Task{Type: TaskTypeCalc}); err != nil {
+		t.Fatalf("SubmitRetry on a briefly full queue: %v", err)
+	}
+}
+
+// 一直满：到 Deadline 返回 ErrFull；ctx 先到期时返回 ctx 的错误
+func TestSubmitRetryPersistentlyFull(t *testing.T) {
+	e := NewEngine()
+	e.Retry.Deadline = 10 * time.Millisecond
+	fillQueue(t, e)
+
+	start := time.Now()
+	err := e.SubmitRetry(context.Background(), Task{Type: TaskTypeCalc})
+	if !errors.Is(err, ErrFull) {
+		t.Fatalf("SubmitRetry on a full queue: %v, want ErrFull", err)
+	}
+	if d := time.Since(start); d < e.Retry.Deadline {
+		t.Fatalf("gave up after %v, before the %v deadline", d, e.Retry.Deadline)
+	}
+
+	e.Retry.Deadline = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := e.SubmitRetry(ctx, Task{Type: TaskTypeCalc}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SubmitRetry with an expiring ctx: %v, want DeadlineExceeded", err)
+	}
+
+	e.Retry.Deadline = 0
+	if err := e.SubmitRetry(context.Background(), Task{Type: TaskTypeCalc}); !errors.Is(err, ErrFull) {
+		t.Fatalf("SubmitRetry without retries: %v, want ErrFull", err)
+	}
+}
+
+// Call 超时返回后 Worker 才写入的响应 channel 不能回到池中，否则迟到的结果会被下一个调用方收到
+func TestCallAbandonedChanNotReused(t *testing.T) {
+	e := NewEngine() // 不启动 Worker：任务留在队列里
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := e.Call(ctx, Task{Type: TaskTypeCalc, Value: -1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call: %v, want DeadlineExceeded", err)
+	}
+	stale, ok := e.pop()
+	if !ok {
+		t.Fatal("abandoned task not in the queue")
+	}
+	stale.Deadline = 0
+	e.runTask(stale) // 迟到的结果写入被放弃的 channel
+
+	other := NewEngineSync()
+	for v := range 100 {
+		r, err := other.Call(context.Background(), Task{Type: TaskTypeCalc, Value: v})
+		if err != nil || r != CalcResult(2*v) {
+			t.Fatalf("Call %d = %v, %v; got someone else's result", v, r, err)
+		}
+	}
+}
+
+// 池化的响应 channel 与每次 make(chan) 的分配对比
+func BenchmarkCall(b *testing.B) {
+	for _, pooled := range []bool{true, false} {
+		name := "pooled"
+		if !pooled {
+			name = "make"
+		}
+		b.Run(name, func(b *testing.B) {
+			e := NewEngineSync()
+			b.ReportAllocs()
+			for b.Loop() {
+				t := Task{Type: TaskTypeCalc, Value: 1}
+				if !pooled {
+					t.Resp = make(chan any, 1)
+				}
+				e.Call(context.Background(), t)
+			}
+		})
+	}
+}
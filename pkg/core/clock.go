@@ -0,0 +1,41 @@
+package core
+
+import "arena_demo/pkg/sysclock"
+
+// 可替换的时钟
+//
+// 引擎的业务时间默认来自 sysclock (进程级的缓存时钟，1ms 精度)。NewEngineWithClock 注入另一个时间源，
+// 测试可以用手动推进的时钟得到确定的时间戳，嵌入方可以接入自己的时间服务 (如回放历史行情时的行情时间)。
+//
+// 注入的时钟决定的是任务看到的时间：
+//   - 订单与批量订单结果的 ProcessedAt
+//   - Deadline 与 ExecuteAt 的比较 (两者都按这个时钟的纳秒解释，Call 由 ctx 推算 Deadline 时也用它)
+//
+// 度量类的时间 (延迟直方图、RED 耗时、读快照发布间隔、窗口、维护作业) 仍然使用 sysclock，
+// 它们衡量的是引擎本身的快慢，与业务时间无关；AdvanceClock 推进的也只是 sysclock。
+// 注入的时钟不做 ClockStaleAfter 的过期检查 (它本身就是权威的时间)，会被 Worker、calc 池和提交方并发调用
+
+// Clock 是引擎的时间源，返回 Unix 纳秒
+type Clock interface {
+	Now() int64
+}
+
+// NewEngineWithClock 创建一个使用时钟 c 的引擎，c 为 nil 时与 NewEngine 相同 (sysclock)
+// 分片与 calc 池继承同一个时钟
+func NewEngineWithClock(c Clock) *Engine {
+	e := NewEngine()
+	e.clock = c
+	return e
+}
+
+// clockNow 返回引擎时钟的当前时间，默认时钟不经过接口调用
+func (e *Engine) clockNow() int64 {
+	return nowOf(e.clock)
+}
+
+func nowOf(c Clock) int64 {
+	if c == nil {
+		return sysclock.Now()
+	}
+	return c.Now()
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// sysclock 停止之后缓存时间不再前进，订单时间戳改由 time.Now 提供，并在日志中告警
+func TestStaleClockFallsBack(t *testing.T) {
+	e := NewEngineSync()
+	sysclock.Stop()
+	t.Cleanup(sysclock.Start)
+	time.Sleep(2 * e.ClockStaleAfter)
+	frozen := sysclock.Now() // 停止前已经在途的一次 tick 此时也已落地
+
+	order := func() OrderResult {
+		r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, LogBuf: make([]byte, 0, 512)})
+		if err != nil {
+			t.Fatalf("Call: %v", err)
+		}
+		return r.(OrderResult)
+	}
+	first := order()
+	time.Sleep(time.Millisecond)
+	second := order()
+
+	if first.ProcessedAt <= frozen || second.ProcessedAt <= first.ProcessedAt {
+		t.Fatalf("timestamps did not advance: frozen=%d first=%d second=%d", frozen, first.ProcessedAt, second.ProcessedAt)
+	}
+	if !bytes.Contains(second.Log, []byte("warn=sysclock_stale")) {
+		t.Fatalf("log lacks the stale-clock warning: %q", second.Log)
+	}
+	if n := e.Stats().ClockFallbacks; n < 2 {
+		t.Fatalf("ClockFallbacks = %d, want >= 2", n)
+	}
+}
+
+// 注入的时钟决定订单与批量订单的 ProcessedAt，时钟推进后新的结果随之变化
+func TestOrderTimestampsFromInjectedClock(t *testing.T) {
+	clk := &manualClock{}
+	clk.now.Store(12345)
+	e := NewEngineWithClock(clk) // 不 Start：由测试充当 Worker
+	run := func(task Task) any {
+		t.Helper()
+		task.Resp = make(chan any, 1)
+		if err := e.TrySubmit(task); err != nil {
+			t.Fatal(err)
+		}
+		popped, _ := e.pop()
+		e.runTask(popped)
+		return <-task.Resp
+	}
+
+	if r := run(Task{Type: TaskTypeOrder, Value: 1, Price: 2, Quantity: 1}).(OrderResult); r.ProcessedAt != 12345 {
+		t.Fatalf("order ProcessedAt = %d, want 12345", r.ProcessedAt)
+	}
+	clk.now.Store(67890)
+	if r := run(Task{Type: TaskTypeOrder, Value: 1, Price: 2, Quantity: 1}).(OrderResult); r.ProcessedAt != 67890 {
+		t.Fatalf("order ProcessedAt = %d after the clock moved, want 67890", r.ProcessedAt)
+	}
+	batch := run(Task{Type: TaskTypeBatchOrder, Orders: []Order{{Price: 1, Quantity: 1, UserID: 1}, {Price: 2, Quantity: 1, UserID: 2}}}).(BatchResult)
+	if batch.Err != nil || batch.ProcessedAt != 67890 {
+		t.Fatalf("batch = %+v, want ProcessedAt 67890", batch)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 相同 calc 请求的合并 (singleflight)
+//
+// 大量客户端同时请求同一个 calc 时 (惊群)，默认会入队并计算 N 次。EnableCalcCoalescing 之后，
+// 与某个尚未完成的 calc 相同 (Value 相同) 的请求不再入队，而是挂在那一次计算上，结果出来后
+// 所有等待者收到同一个值 (CalcResult，或者 ErrExpired 等错误)。合并只发生在提交侧，Worker 不知情
+//
+// 只合并没有副作用的 calc，否则合并会改变引擎状态：
+//   - 分片 Worker 上的 calc 会把结果累加到 UserVolume[0]，合并后只累加一次，所以只合并 DryRun 的 calc
+//   - 开启了 EnableCalcPool 时池 Worker 不修改状态，所有 (非 Windowed) calc 都可以合并
+//   - Windowed calc 参与窗口聚合、Resp 为 nil 的 calc 没有人等结果，都不合并
+//
+// 细节：
+//   - 第一个请求 ("领头") 照常经过在途上限、队列准入等检查并入队；它被拒绝时已经挂上的等待者收到同一个错误
+//   - 等待者不占用 EnableInflightLimit 的名额，也不计入 Processed/CalcPool，计入 Stats.Coalesced
+//   - 结果由一个短命的 goroutine 分发：领头的结果写入内部 channel，分发 goroutine 删除映射后逐个写入等待者的 Resp
+//   - 分发之后到达的相同请求会开始新的一次计算，不会拿到旧结果 (不是缓存)
+//   - 计算按领头任务的 Deadline/QoS 执行，领头过期时所有等待者都收到 ErrExpired；Task.Cancel 被忽略
+//   - 只有 calc 的输入是 Value，所以按 Value 合并 (DryRun 与非 DryRun 的池请求结果相同，可以共用)
+
+type calcFlights struct {
+	mu        sync.Mutex
+	m         map[int]*calcFlight
+	coalesced atomic.Uint64
+}
+
+// calcFlight 是一次进行中的计算及其等待者
+type calcFlight struct {
+	resp    chan any   // 领头任务的 Resp
+	waiters []chan any // 等待者 (含领头请求自己的 Resp)
+}
+
+// EnableCalcCoalescing 开启相同 calc 请求的合并，必须在 Start 之前调用 (所有分片共用)
+func (e *Engine) EnableCalcCoalescing() {
+	e.flights = &calcFlights{m: make(map[int]*calcFlight)}
+}
+
+// eligible 报告 t 是否可以合并
+func (f *calcFlights) eligible(e *Engine, t *Task) bool {
+	return t.Type == TaskTypeCalc && t.Resp != nil && !t.Windowed && t.ExecuteAt == 0 && (t.DryRun || e.calc != nil)
+}
+
+// submit 把 t 挂到进行中的相同计算上，没有时作为领头提交
+func (f *calcFlights) submit(e *Engine, t Task) error {
+	f.mu.Lock()
+	if c, ok := f.m[t.Value]; ok {
+		c.waiters = append(c.waiters, t.Resp)
+		f.mu.Unlock()
+		f.coalesced.Add(1)
+		return nil
+	}
+	c := &calcFlight{resp: make(chan any, 1), waiters: []chan any{t.Resp}}
+	f.m[t.Value] = c
+	f.mu.Unlock()
+
+	key := t.Value
+	t.Resp = c.resp
+	t.Cancel = nil // 计算由所有等待者共享，不能因为领头请求被取消而跳过
+	if err := e.admitSubmit(t); err != nil {
+		// 领头没能入队：领头请求直接返回错误，期间挂上来的等待者收到同一个错误
+		f.finish(key, c, err, 1)
+		return err
+	}
+	go func() {
+		f.finish(key, c, <-c.resp, 0)
+	}()
+	return nil
+}
+
+// finish 结束一次计算：删除映射，把 v 写给 waiters[skip:]
+func (f *calcFlights) finish(key int, c *calcFlight, v any, skip int) {
+	f.mu.Lock()
+	if f.m[key] == c {
+		delete(f.m, key)
+	}
+	waiters := c.waiters
+	f.mu.Unlock()
+	for _, w := range waiters[skip:] {
+		w <- v
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// N 个并发的相同 calc 只入队、计算一次，所有请求收到同一个结果；分发后映射清空，新请求重新计算
+func TestCalcCoalescing(t *testing.T) {
+	const n = 16
+	e := NewEngine() // 先不 Start：所有请求都在计算完成之前到达
+	e.EnableCalcCoalescing()
+
+	resps := make([]chan any, n)
+	var wg sync.WaitGroup
+	for i := range resps {
+		resps[i] = make(chan any, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 21, DryRun: true, Resp: resps[i]}); err != nil {
+				t.Errorf("TrySubmit: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	other := make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 5, DryRun: true, Resp: other}); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Queue.Len(); got != 2 {
+		t.Fatalf("queued %d tasks for %d identical requests and one distinct, want 2", got, n)
+	}
+	if got := e.Stats().Coalesced; got != n-1 {
+		t.Fatalf("Coalesced = %d, want %d", got, n-1)
+	}
+
+	e.Start()
+	stopOnCleanup(t, e)
+	for i, r := range resps {
+		select {
+		case v := <-r:
+			if v != CalcResult(42) {
+				t.Fatalf("waiter %d got %v, want 42", i, v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("waiter %d never got a result", i)
+		}
+	}
+	if v := <-other; v != CalcResult(10) {
+		t.Fatalf("distinct request got %v, want 10", v)
+	}
+	// Worker 在回复之后才计数，稍等第二个计入
+	for deadline := time.Now().Add(5 * time.Second); e.Stats().Processed < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if got := e.Stats().Processed; got != 2 {
+		t.Fatalf("Processed = %d, want 2 (one per distinct Value)", got)
+	}
+
+	// 分发 goroutine 在写完等待者之前删除了映射：之后的相同请求不拿旧结果，而是重新计算
+	e.flights.mu.Lock()
+	left := len(e.flights.m)
+	e.flights.mu.Unlock()
+	if left != 0 {
+		t.Fatalf("%d flights left in the map after distribution", left)
+	}
+	if v, err := e.Call(t.Context(), Task{Type: TaskTypeCalc, Value: 21, DryRun: true}); err != nil || v != CalcResult(42) {
+		t.Fatalf("request after distribution: %v, %v", v, err)
+	}
+	if got := e.Stats().Coalesced; got != n-1 {
+		t.Fatalf("Coalesced = %d after a fresh request, want %d", got, n-1)
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"arena_demo/pkg/fastqueue"
+	"time"
+)
+
+// 控制面命令通道
+//
+// 运维操作 (清零成交额、批量快照、暂停、修改配置) 如果直接读写引擎状态，就会与 Worker 竞争：
+// UserVolume、PositionLimit 这些字段只允许 Worker 写。原来的做法是把它们包装成 Gold 任务与数据任务一起排队，
+// 但那样控制操作要经过准入、熔断、限流，数据队列满时只能反复重试。
+//
+// EnableCommandQueue 之后每个分片多一条独立的命令队列 (MPSC，任意 goroutine 都可以投递)，
+// Worker 每轮取任务之前先执行完队列里的全部命令，所以命令总是发生在两个数据任务之间：
+// 一条命令要么完全在某个任务之前生效，要么完全在它之后，与任务串行、不需要任何锁。
+// 命令不经过准入检查，也不占数据队列的容量；回复中的 Processed 给出它生效的确切位置
+// (执行时本分片已处理的任务数)。
+//
+// 命令在下一轮取任务之前执行，因此会越过已经在队列中排队的数据任务 (与 Gold 任务越过普通队列相同)；
+// 需要 "等此前提交的任务都处理完再生效" 时先调用 Flush。
+//
+// 开启后 GetUserVolume / ResetUserVolume / SnapshotVolumes 改走命令通道，结果不变。
+// Pause / Resume 也改为命令：Worker 在两个任务之间停下，不再取数据任务 (高优先级队列也不再前进)，
+// 但仍然执行命令，所以暂停期间可以查询、清零、修改配置；Flush、Scale、Export 等需要 Worker 处理任务的操作仍然等到 Resume。
+//
+// 限制：
+//   - 同步模式 (NewEngineSync) 没有 Worker，命令在调用方 goroutine 上加锁执行
+//   - 热备接管时，卡住的主 Worker 队列中还没执行的命令不会转交给备机，Exec 会一直等待
+
+// CommandKind 是控制命令的类型
+type CommandKind uint8
+
+const (
+	// CmdQueryVolume 读取 UserVolume[UID]，回复 Value 为当前值
+	CmdQueryVolume CommandKind = iota + 1
+	// CmdResetVolume 清零 UserVolume[UID]，回复 Value 为清零前的值
+	CmdResetVolume
+	// CmdSnapshotVolumes 把用户 [From, From+len(Volumes)) 的成交额拷贝到 Volumes (所有分片)
+	CmdSnapshotVolumes
+	// CmdSetPositionLimit 把全局持仓限额改为 Limit (所有分片)，回复 Value 为原来的限额
+	CmdSetPositionLimit
+	// CmdSetDryRun 打开 (On) 或关闭引擎级 Dry-Run (所有分片)
+	CmdSetDryRun
+
+	// cmdPause / cmdResume 由 Pause / Resume 发出 (所有分片)，不对外开放：暂停状态由 pauseState 统一记录
+	cmdPause
+	cmdResume
+)
+
+// Command 是一条控制命令，与 Task 一样把各类型的参数平铺在结构体中
+type Command struct {
+	Kind CommandKind
+
+	// CmdQueryVolume / CmdResetVolume 的用户，同时决定命令发往哪个分片
+	UID int
+
+	// CmdSnapshotVolumes 的起始用户与输出缓冲区 (由调用者提供)
+	From    int
+	Volumes []float64
+
+	// CmdSetPositionLimit 的新限额，<= 0 表示不限
+	Limit float64
+
+	// CmdSetDryRun 的开关
+	On bool
+
+	shards int // 发往所有分片时的分片数 (分片自己的 NumShards 总是 1)
+	resp   chan any
+}
+
+// CommandResult 是 Exec 的回复
+type CommandResult struct {
+	// Value 见各命令类型的说明，其余命令为 0
+	Value float64
+	// Processed 命令执行时本分片已处理的任务数：命令恰好发生在第 Processed 个任务之后、下一个任务之前
+	// 发往所有分片的命令为各分片之和
+	Processed uint64
+}
+
+// commandQueue 每个分片一条，由 Worker 消费
+type commandQueue struct {
+	q *fastqueue.MPSC[Command]
+	// paused cmdPause 之后为 true，只由 Worker 读写
+	paused bool
+}
+
+// EnableCommandQueue 为每个分片开启一条容量为 size (2 的幂) 的命令队列，必须在 Start 之前调用
+func (e *Engine) EnableCommandQueue(size uint64) {
+	e.cmds = &commandQueue{q: fastqueue.NewMPSC[Command](size)}
+}
+
+// broadcast 报告命令是否发往所有分片
+func (c CommandKind) broadcast() bool {
+	return c != CmdQueryVolume && c != CmdResetVolume
+}
+
+// Exec 同步执行一条控制命令，返回时命令已经生效 (必须在 EnableCommandQueue 之后调用)
+// 按 UID 的命令发往该用户所在的分片，其余命令依次发往每个分片；持有 scaleMu，不会与 Scale 交错
+func (e *Engine) Exec(c Command) CommandResult {
+	if e.cmds == nil {
+		panic("core: command queue not enabled (see EnableCommandQueue)")
+	}
+	if c.Kind < CmdQueryVolume || c.Kind > CmdSetDryRun {
+		panic("core: unknown command kind")
+	}
+	return e.exec(c)
+}
+
+// exec 是 Exec 除参数检查以外的部分
+// cmdResume 不持有 scaleMu：暂停期间 Scale 持有它等 Worker 排空，要靠 Resume 放行
+func (e *Engine) exec(c Command) CommandResult {
+	if e.inline != nil {
+		return e.execInline(c)
+	}
+	if c.Kind != cmdResume {
+		scaleMu.Lock()
+		defer scaleMu.Unlock()
+	}
+	if !c.Kind.broadcast() {
+		return e.route(Task{Value: c.UID}).sendCommand(c)
+	}
+	var res CommandResult
+	c.shards = e.NumShards()
+	for i := range c.shards {
+		r := e.Shard(i).sendCommand(c)
+		if i == 0 {
+			res.Value = r.Value
+		}
+		res.Processed += r.Processed
+	}
+	return res
+}
+
+// sendCommand 把 c 投递到本分片的命令队列并等待 Worker 回复，队列满时重试
+func (e *Engine) sendCommand(c Command) CommandResult {
+	c.resp = e.getRespChan()
+	for !e.cmds.q.Push(c) {
+		time.Sleep(controlRetry)
+	}
+	r := <-c.resp
+	e.putRespChan(c.resp)
+	return r.(CommandResult)
+}
+
+// execInline 同步模式下在调用方 goroutine 上执行命令
+func (e *Engine) execInline(c Command) CommandResult {
+	e.inline.mu.Lock()
+	defer e.inline.mu.Unlock()
+	c.shards = 1
+	c.resp = make(chan any, 1)
+	e.execCommand(c)
+	return (<-c.resp).(CommandResult)
+}
+
+// runCommands 在 Worker 中执行命令队列里的全部命令；cmdPause 之后停在这里，只执行命令，直到 cmdResume
+//
+//go:noinline
+func (e *Engine) runCommands() {
+	for {
+		c, ok := e.cmds.q.Pop()
+		if ok {
+			e.execCommand(c)
+			continue
+		}
+		if !e.cmds.paused {
+			return
+		}
+		e.idlePaused()
+	}
+}
+
+// idlePaused 是暂停期间的一次空转：停住期间心跳停止是预期内的，不能让热备接管
+func (e *Engine) idlePaused() {
+	if e.standby != nil {
+		e.standby.parked.Store(true)
+		defer e.standby.parked.Store(false)
+	}
+	time.Sleep(controlRetry)
+}
+
+// execCommand 执行一条命令并回复 (Worker 或持有 inline 锁的调用方)
+func (e *Engine) execCommand(c Command) {
+	res := CommandResult{Processed: e.stats.processed.Load()}
+	switch c.Kind {
+	case CmdQueryVolume:
+		res.Value = e.UserVolume[c.UID&1023]
+	case CmdResetVolume:
+		uid := c.UID & 1023
+		res.Value = e.UserVolume[uid]
+		e.UserVolume[uid] = 0
+		e.walSetVolume(uid, 0)
+		if e.reads != nil {
+			e.reads.afterTask(e, TaskTypeResetVolume)
+		}
+	case CmdSnapshotVolumes:
+		e.processVolumes(c.Volumes, c.From, c.shards, nil)
+	case CmdSetPositionLimit:
+		res.Value = e.PositionLimit
+		e.PositionLimit = max(c.Limit, 0)
+	case CmdSetDryRun:
+		e.dryRun.Store(c.On)
+	case cmdPause:
+		e.cmds.paused = true
+	case cmdResume:
+		e.cmds.paused = false
+	}
+	e.stats.commands.Add(1)
+	e.reply(c.resp, res)
+}
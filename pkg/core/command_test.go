@@ -0,0 +1,44 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// 命令恰好发生在第 Processed 个任务之后：订单不断到达时清零，清零前的值正好是此前处理的订单数，
+// 之后的订单全部计入清零后的新值，一笔不多一笔不少
+func TestCommandOrderedWithDataTasks(t *testing.T) {
+	const n = 500
+	e := NewEngine()
+	e.EnableCommandQueue(8)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	go func() {
+		for range n {
+			for e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1}) != nil {
+				time.Sleep(time.Microsecond)
+			}
+		}
+	}()
+	for e.Stats().Processed < n/4 {
+		time.Sleep(time.Microsecond)
+	}
+	q := e.Exec(Command{Kind: CmdQueryVolume, UID: 1})
+	if q.Value != float64(q.Processed) {
+		t.Fatalf("query after %d orders read %v", q.Processed, q.Value)
+	}
+	r := e.Exec(Command{Kind: CmdResetVolume, UID: 1})
+	if r.Value != float64(r.Processed) || r.Processed < q.Processed {
+		t.Fatalf("reset after %d orders cleared %v (query saw %d)", r.Processed, r.Value, q.Processed)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); e.Stats().Processed < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d orders processed", e.Stats().Processed, n)
+		}
+	}
+	if v := e.Exec(Command{Kind: CmdQueryVolume, UID: 1}); v.Value != float64(n-r.Processed) || v.Processed != n {
+		t.Fatalf("final volume %v after %d orders, want %d (orders after the reset)", v.Value, v.Processed, n-r.Processed)
+	}
+}
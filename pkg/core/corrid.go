@@ -0,0 +1,36 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
+
+// CorrIDHeader 是关联 ID 的请求/响应 Header
+// 与 traceparent 不同，它总是存在：客户端没带就由引擎生成，用于把请求、响应和日志串起来
+const CorrIDHeader = "X-Correlation-ID"
+
+// corrSeq 进程内单调递增的关联 ID 计数器，所有引擎共享，保证全局唯一
+var corrSeq atomic.Uint64
+
+// NewCorrID 生成一个新的关联 ID (一次原子加法，无锁、无分配，可在任意 goroutine 调用)
+func NewCorrID() uint64 {
+	return corrSeq.Add(1)
+}
+
+// ErrBadCorrID 客户端提供的关联 ID 不是正的十进制整数
+var ErrBadCorrID = errors.New("core: bad correlation id")
+
+// CorrIDFrom 解析客户端提供的关联 ID (十进制)，缺失时生成一个新的
+// 非法的值 (非数字、0、超出 uint64) 返回 ErrBadCorrID，而不是悄悄换成另一个 ID：
+// 客户端靠它把请求与响应、日志对上，换掉之后它就对不上了
+func CorrIDFrom(header string) (uint64, error) {
+	if header == "" {
+		return NewCorrID(), nil
+	}
+	id, err := strconv.ParseUint(header, 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrBadCorrID
+	}
+	return id, nil
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestNewCorrIDUnique(t *testing.T) {
+	const goroutines, per = 8, 10000
+	ids := make([][]uint64, goroutines)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range per {
+				ids[g] = append(ids[g], NewCorrID())
+			}
+		}()
+	}
+	wg.Wait()
+	seen := make(map[uint64]bool, goroutines*per)
+	for _, s := range ids {
+		for _, id := range s {
+			if id == 0 || seen[id] {
+				t.Fatalf("duplicate or zero corr id %d", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestCorrIDFrom(t *testing.T) {
+	if id, err := CorrIDFrom("42"); id != 42 || err != nil {
+		t.Fatalf("CorrIDFrom(\"42\") = %d, %v", id, err)
+	}
+	a, _ := CorrIDFrom("")
+	b, _ := CorrIDFrom("")
+	if a == 0 || a == b {
+		t.Fatalf("CorrIDFrom(\"\") did not generate fresh ids: %d, %d", a, b)
+	}
+	// 非法的值不能被悄悄换掉
+	for _, h := range []string{"0", "abc", "-1", "req-7", "18446744073709551616"} {
+		if id, err := CorrIDFrom(h); !errors.Is(err, ErrBadCorrID) || CodeOf(err) != CodeValidation {
+			t.Errorf("CorrIDFrom(%q) = %d, %v; want ErrBadCorrID", h, id, err)
+		}
+	}
+}
+
+// 关联 ID 原样出现在结果与订单日志中
+func TestCorrIDEchoed(t *testing.T) {
+	e := NewEngineSync()
+	id, _ := CorrIDFrom("9001")
+	r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, CorrID: id, LogBuf: make([]byte, 0, 256)})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	res := r.(OrderResult)
+	if res.CorrID != 9001 || !bytes.Contains(res.Log, []byte("corr_id=9001")) {
+		t.Fatalf("CorrID = %d, log %q; want 9001 in both", res.CorrID, res.Log)
+	}
+	r, _ = e.Call(context.Background(), Task{Type: TaskTypeBatchOrder, CorrID: id, Orders: []Order{{Price: 1, Quantity: 1, UserID: 1}}})
+	if got := r.(BatchResult).CorrID; got != 9001 {
+		t.Fatalf("BatchResult.CorrID = %d, want 9001", got)
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// manualClock 是只在测试中手动推进的 Clock
+type manualClock struct{ now atomic.Int64 }
+
+func (c *manualClock) Now() int64 { return c.now.Load() }
+
+// 排队期间过了 Deadline 的任务不再计算：回复 ErrExpired、计入 Expired，状态不变
+func TestExpiredTasksSkipped(t *testing.T) {
+	clk := &manualClock{}
+	clk.now.Store(1000)
+	e := NewEngineWithClock(clk)
+
+	resp := make(chan any, 4)
+	submit := func(v int, deadline int64) {
+		t.Helper()
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: v, Deadline: deadline, Resp: resp}); err != nil {
+			t.Fatalf("TrySubmit: %v", err)
+		}
+	}
+	submit(1, 1500) // 处理时已过期
+	submit(2, 3000) // 未过期
+	submit(3, 0)    // 不限
+	clk.now.Store(2000)
+
+	for range 3 {
+		task, ok := e.pop()
+		if !ok {
+			t.Fatal("queue drained early")
+		}
+		e.runTask(task)
+	}
+	if r := <-resp; !errors.Is(r.(error), ErrExpired) {
+		t.Fatalf("expired task replied %v, want ErrExpired", r)
+	}
+	for _, want := range []CalcResult{4, 6} {
+		if r := <-resp; r != want {
+			t.Fatalf("live task replied %v, want %v", r, want)
+		}
+	}
+	if n := e.Stats().Expired; n != 1 {
+		t.Fatalf("Expired = %d, want 1", n)
+	}
+	if e.UserVolume[0] != 10 {
+		t.Fatalf("UserVolume[0] = %v, want 10 (expired task must not touch state)", e.UserVolume[0])
+	}
+}
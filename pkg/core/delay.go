@@ -0,0 +1,185 @@
+package core
+
+import (
+	"arena_demo/pkg/arena"
+	"arena_demo/pkg/sysclock"
+	"sync/atomic"
+)
+
+// 延迟任务 (定时提交)
+//
+// 开启 EnableDelayQueue 后，ExecuteAt 非 0 的任务照常提交、入队，Worker 取到它时如果引擎时钟 (默认 sysclock) 早于 ExecuteAt，
+// 不处理而是放进本分片的延迟堆；Worker 每轮循环 (任务之间、空闲轮询时) 检查堆顶，到期的任务立即处理。
+//
+// 顺序与精度：
+//   - 按 ExecuteAt 从早到晚执行，ExecuteAt 相同的按进入延迟堆的先后执行；到期任务排在队列中的新任务之前
+//   - 到期判断使用 sysclock 的缓存时间 (1ms 精度，且只会落后于真实时间)，所以任务绝不会早于 ExecuteAt 执行，
+//     通常晚 0-1ms，再加上 Worker 当时正在处理的那个任务的耗时
+//   - ExecuteAt 已经过去 (或为 0) 的任务与普通任务完全相同，不经过延迟堆
+//   - Deadline 在真正处理时检查：ExecuteAt 晚于 Deadline 的任务到期后回复 ErrExpired
+//   - 延迟直方图 (Stats.Latency) 对这些任务从到期时刻开始计时
+//
+// 内存：堆本身 (时间、序号、下标，不含指针) 放在一个专用的 Arena 上，容量在 EnableDelayQueue 时固定；
+// 任务含有 Resp 等指针，存放在预分配的槽位表里。堆满时新的延迟任务回复 ErrFull
+//
+// 限制：
+//   - 调用方在任务执行前一直在等 Resp，Resp 必须有缓冲 (与挂单相同)
+//   - 延迟中的任务不在队列里：Flush 不等待它们，QueueLen 也不计入，见 DelayedTasks
+//   - 未开启时 (以及同步模式、calc 池) ExecuteAt 被忽略，任务立即执行；延迟的 calc 不进入 calc 池和合并
+//   - Scale 时按用户迁移到新的分片，新分片放不下的回复 ErrFull；Export 时连同 ExecuteAt 交接给新进程；
+//     热备接管时留在旧 Worker 上，不迁移
+
+// delayEntry 是延迟堆中的一项，按 (at, seq) 排序
+type delayEntry struct {
+	at   int64  // ExecuteAt
+	seq  uint64 // 进入延迟堆的顺序
+	slot int32  // 任务在 slots 中的下标
+}
+
+// delayQueue 是一个分片的延迟堆，只由该分片的 Worker 读写 (Scale/Export 在 Worker 停住时访问)
+type delayQueue struct {
+	mem   *arena.Arena
+	heap  []delayEntry // 最小堆，底层数组在 mem 上，cap 固定为 max
+	slots []Task
+	free  []int32
+	seq   uint64
+	count atomic.Int64 // len(heap)，供 DelayedTasks 跨线程读取
+}
+
+// EnableDelayQueue 让 ExecuteAt 在未来的任务延迟到期再执行，每个分片最多同时延迟 max 个，必须在 Start 之前调用
+func (e *Engine) EnableDelayQueue(max int) {
+	if max < 1 {
+		panic("core: delay queue needs room for at least one task")
+	}
+	mem := arena.Acquire()
+	q := &delayQueue{
+		mem:   mem,
+		heap:  arena.MakeSlice[delayEntry](mem, 0, max),
+		slots: make([]Task, max),
+		free:  make([]int32, max),
+	}
+	for i := range q.free {
+		q.free[i] = int32(max - 1 - i)
+	}
+	e.delay = q
+}
+
+// DelayedTasks 返回所有分片当前在延迟堆中等待的任务数
+func (e *Engine) DelayedTasks() int {
+	n := 0
+	for i := range e.NumShards() {
+		if q := e.Shard(i).delay; q != nil {
+			n += int(q.count.Load())
+		}
+	}
+	return n
+}
+
+// deferTask 把未到期的任务放进延迟堆，堆满时回复 ErrFull 并归还在途名额
+func (e *Engine) deferTask(t Task) {
+	if !e.delay.push(t) {
+		e.stats.rejected.Add(1)
+		if t.admitted {
+			e.admit.Release()
+		}
+		if t.Resp != nil {
+			select {
+			case t.Resp <- ErrFull:
+			default:
+			}
+		}
+	}
+}
+
+// runDue 在 Worker 中处理所有已到期的延迟任务
+func (e *Engine) runDue(now int64) {
+	q := e.delay
+	for len(q.heap) != 0 && q.heap[0].at <= now {
+		t := q.pop()
+		t.enqueued = sysclock.Now()
+		e.runTask(t)
+	}
+}
+
+func (q *delayQueue) push(t Task) bool {
+	if len(q.free) == 0 {
+		return false
+	}
+	slot := q.free[len(q.free)-1]
+	q.free = q.free[:len(q.free)-1]
+	q.slots[slot] = t
+	q.seq++
+	q.heap = append(q.heap, delayEntry{at: t.ExecuteAt, seq: q.seq, slot: slot})
+	q.up(len(q.heap) - 1)
+	q.count.Store(int64(len(q.heap)))
+	return true
+}
+
+// pop 取出堆顶的任务 (调用方保证堆非空)
+func (q *delayQueue) pop() Task {
+	top := q.heap[0]
+	last := len(q.heap) - 1
+	q.heap[0] = q.heap[last]
+	q.heap = q.heap[:last]
+	if last > 0 {
+		q.down(0)
+	}
+	t := q.slots[top.slot]
+	q.slots[top.slot] = Task{} // 不再引用 Resp 等，让 GC 可以回收
+	q.free = append(q.free, top.slot)
+	q.count.Store(int64(len(q.heap)))
+	return t
+}
+
+func (q *delayQueue) less(i, j int) bool {
+	a, b := &q.heap[i], &q.heap[j]
+	return a.at < b.at || a.at == b.at && a.seq < b.seq
+}
+
+func (q *delayQueue) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !q.less(i, p) {
+			return
+		}
+		q.heap[i], q.heap[p] = q.heap[p], q.heap[i]
+		i = p
+	}
+}
+
+func (q *delayQueue) down(i int) {
+	n := len(q.heap)
+	for {
+		l := 2*i + 1
+		if l >= n {
+			return
+		}
+		m := l
+		if r := l + 1; r < n && q.less(r, l) {
+			m = r
+		}
+		if !q.less(m, i) {
+			return
+		}
+		q.heap[i], q.heap[m] = q.heap[m], q.heap[i]
+		i = m
+	}
+}
+
+// takeDelayed 按执行顺序取出本分片的全部延迟任务 (Worker 停住时调用)
+func (e *Engine) takeDelayed() []Task {
+	q := e.delay
+	if q == nil || len(q.heap) == 0 {
+		return nil
+	}
+	tasks := make([]Task, 0, len(q.heap))
+	for len(q.heap) != 0 {
+		tasks = append(tasks, q.pop())
+	}
+	return tasks
+}
+
+// delayed 报告任务是否需要进入延迟堆 (未开启延迟时总是 false)
+func (e *Engine) delayed(t *Task) bool {
+	return e.delay != nil && t.ExecuteAt != 0 && t.ExecuteAt > e.clockNow()
+}
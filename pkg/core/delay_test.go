@@ -0,0 +1,78 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"testing"
+	"time"
+)
+
+// 延迟任务不早于 ExecuteAt 执行，按 ExecuteAt 排序，相同时按提交顺序；ExecuteAt 已过去的立即执行
+func TestDelayedTaskRunsNoEarlier(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	e := NewEngine()
+	e.EnableDelayQueue(8)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	now := sysclock.Now()
+	ms := int64(time.Millisecond)
+	out := make(chan any, 8)
+	submit := func(price float64, at int64) {
+		t.Helper()
+		if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: price, Quantity: 1, ExecuteAt: at, Resp: out}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	submit(1, now+30*ms)
+	submit(2, now+10*ms)
+	submit(3, now+10*ms)
+	submit(4, now-ms) // 已经过去
+	e.Flush()
+
+	// got 取出已经回复的订单，检查它们没有早于各自的 ExecuteAt
+	got := func() (prices []float64) {
+		for {
+			select {
+			case r := <-out:
+				or := r.(OrderResult)
+				prices = append(prices, or.Total)
+			default:
+				return prices
+			}
+		}
+	}
+	at := map[float64]int64{1: now + 30*ms, 2: now + 10*ms, 3: now + 10*ms, 4: now - ms}
+	check := func(step string, want ...float64) {
+		t.Helper()
+		prices := got()
+		if len(prices) != len(want) {
+			t.Fatalf("%s: replied %v, want %v", step, prices, want)
+		}
+		for i := range want {
+			if prices[i] != want[i] {
+				t.Fatalf("%s: replied %v, want %v", step, prices, want)
+			}
+			if sysclock.Now() < at[want[i]] {
+				t.Fatalf("%s: order %v ran before its ExecuteAt", step, want[i])
+			}
+		}
+	}
+
+	check("submit", 4)
+	if n := e.DelayedTasks(); n != 3 {
+		t.Fatalf("DelayedTasks = %d, want 3", n)
+	}
+	e.AdvanceClock(9 * time.Millisecond)
+	check("+9ms")
+	e.AdvanceClock(time.Millisecond)
+	check("+10ms", 2, 3)
+	e.AdvanceClock(19 * time.Millisecond)
+	check("+29ms")
+	e.AdvanceClock(time.Millisecond)
+	check("+30ms", 1)
+	if n := e.DelayedTasks(); n != 0 {
+		t.Fatalf("DelayedTasks = %d after all ran", n)
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// 引擎级 Dry-Run 下，所有会改状态的任务与命令都只回复，不落地
+func TestDryRunLeavesStateUntouched(t *testing.T) {
+	e := NewEngineSync()
+	e.EnableRestingOrders(4)
+	ctx := context.Background()
+	if _, err := e.Call(ctx, Task{Type: TaskTypeOrder, Value: 3, Price: 10, Quantity: 2}); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := e.SnapshotState()
+	e.SetDryRun(true)
+
+	if err := e.LoadState(newStateBuf()); err != nil {
+		t.Fatal(err)
+	}
+	r, err := e.Call(ctx, Task{Type: TaskTypeOrder, Value: 4, OrderType: OrderLimit, LimitPrice: 5, Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.(OrderResult).Err != ErrLimitNotMet || e.RestingOrders() != 0 {
+		t.Fatalf("dry limit order: err = %v, resting = %d; want rejected without resting", r.(OrderResult).Err, e.RestingOrders())
+	}
+	e.SetMarketPrice(1)
+
+	e.SetDryRun(false)
+	if v := e.GetUserVolume(3); v != 20 {
+		t.Fatalf("UserVolume[3] = %v after dry-run, want 20", v)
+	}
+	got, _ := e.SnapshotState()
+	if string(got) != string(saved) {
+		t.Fatal("state snapshot changed across dry-run")
+	}
+	// 参考价没有生效：限价单仍然不满足
+	r, _ = e.Call(ctx, Task{Type: TaskTypeOrder, Value: 4, OrderType: OrderLimit, LimitPrice: 5, Quantity: 1, DryRun: true})
+	if r.(OrderResult).Err != ErrLimitNotMet {
+		t.Fatalf("market price set during dry-run took effect: %v", r.(OrderResult).Err)
+	}
+}
+
+// Dry-Run 与正常执行返回相同的结果，但 UserVolume 保持不变
+func TestDryRunMatchesRealResult(t *testing.T) {
+	e := NewEngineSync()
+	ctx := context.Background()
+	tasks := []Task{
+		{Type: TaskTypeCalc, Value: 21},
+		{Type: TaskTypeOrder, Value: 5, Price: 2.5, Quantity: 4},
+		{Type: TaskTypeBatchOrder, Orders: []Order{{Price: 1, Quantity: 3, UserID: 6}, {Price: 2, Quantity: 1, UserID: 7}}},
+	}
+	for _, task := range tasks {
+		before := e.UserVolume
+		task.DryRun = true
+		dry, err := e.Call(ctx, task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.UserVolume != before {
+			t.Fatalf("type %d: dry-run changed UserVolume", task.Type)
+		}
+		task.DryRun = false
+		real, _ := e.Call(ctx, task)
+		switch r := real.(type) {
+		case OrderResult:
+			d := dry.(OrderResult)
+			if !d.DryRun || d.Total != r.Total || d.Err != r.Err {
+				t.Fatalf("order: dry %+v, real %+v", d, r)
+			}
+		case BatchResult:
+			d := dry.(BatchResult)
+			if d.Total != r.Total || d.FailedIndex != r.FailedIndex {
+				t.Fatalf("batch: dry %+v, real %+v", d, r)
+			}
+		default:
+			if dry != real {
+				t.Fatalf("type %d: dry %v, real %v", task.Type, dry, real)
+			}
+		}
+		if e.UserVolume == before {
+			t.Fatalf("type %d: real run did not change UserVolume", task.Type)
+		}
+	}
+}
+
+// 运维清零不受引擎级 Dry-Run 影响：管理接口报告成功时状态确实已经改变
+func TestDryRunKeepsOperatorReset(t *testing.T) {
+	for _, cmds := range []bool{false, true} {
+		e := NewEngine()
+		if cmds {
+			e.EnableCommandQueue(8)
+		}
+		e.Start()
+		stopOnCleanup(t, e)
+		if _, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 2, Price: 3, Quantity: 1}); err != nil {
+			t.Fatal(err)
+		}
+		e.SetDryRun(true)
+		if err := e.TryResetUserVolume(2); err != nil {
+			t.Fatal(err)
+		}
+		if v := e.GetUserVolume(2); v != 0 {
+			t.Fatalf("cmds=%v: UserVolume[2] = %v after a reset under dry-run, want 0", cmds, v)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package core
+
+import "sync/atomic"
+
+// 处理耗时的指数移动平均 (EMA)，供自适应批大小、准入控制等反馈回路使用
+//
+// 延迟直方图基于 sysclock (1ms 精度)，而且包含排队时间；这里只测 process 本身，用 sysclock.Mono 计时。
+// 为了不给每个任务都加两次时钟读取，只采样 1/(emaSampleMask+1) 的任务，每个样本按 1/2^emaShift 的权重并入平均值
+//
+// 平均值以定点数 (ns << emaFrac) 存在一个原子变量里：只有 Worker 写入，读者一次原子读，无锁
+
+const (
+	emaSampleMask = 7 // 每 8 个任务采样一次
+	emaShift      = 4 // alpha = 1/16
+	emaFrac       = 8 // 定点小数位，避免小数值在右移时被截断成 0
+)
+
+type procEMA struct {
+	v atomic.Int64 // 平均耗时 (ns << emaFrac)，0 表示还没有样本
+}
+
+// observe 并入一个样本 (纳秒)，只由 Worker 调用
+func (m *procEMA) observe(ns int64) {
+	old := m.v.Load()
+	if old == 0 {
+		m.v.Store(max(ns, 1) << emaFrac)
+		return
+	}
+	m.v.Store(old + (ns<<emaFrac-old)>>emaShift)
+}
+
+// AvgProcessNanos 返回本分片单个任务处理耗时 (不含排队) 的指数移动平均，还没有样本时为 0
+// 可在任意 goroutine 调用
+func (e *Engine) AvgProcessNanos() int64 {
+	return e.stats.procEMA.v.Load() >> emaFrac
+}
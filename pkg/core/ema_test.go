@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// 恒定输入时 EMA 收敛到该值；输入跳变后误差每个样本缩小到 15/16
+func TestProcEMAConverges(t *testing.T) {
+	var m procEMA
+	avg := func() int64 { return m.v.Load() >> emaFrac }
+
+	m.observe(100)
+	if avg() != 100 {
+		t.Fatalf("first sample: avg = %d, want 100", avg())
+	}
+	for range 200 {
+		m.observe(5000)
+	}
+	if a := avg(); a < 4950 || a > 5000 {
+		t.Fatalf("after 200 samples of 5000: avg = %d", a)
+	}
+
+	// 5000 -> 1000：k 个样本后剩余误差约为 4000 * (15/16)^k
+	for range 16 {
+		m.observe(1000)
+	}
+	if a := avg(); a < 1000+4000*30/100 || a > 1000+4000*40/100 {
+		t.Fatalf("16 samples after the step: avg = %d, want about %d", a, 1000+4000*36/100)
+	}
+	for range 300 {
+		m.observe(1000)
+	}
+	if a := avg(); a < 995 || a > 1005 {
+		t.Fatalf("after settling: avg = %d, want about 1000", a)
+	}
+}
+
+// 端到端：每个订单在 Worker 中耗时约 200µs (影子逻辑里睡眠)，平均值落在这个量级
+func TestAvgProcessNanos(t *testing.T) {
+	const cost = 200 * time.Microsecond
+	e := NewEngine()
+	if e.AvgProcessNanos() != 0 {
+		t.Fatal("AvgProcessNanos non-zero before any task")
+	}
+	e.EnableShadow(func(t *Task, st *OrderState) (float64, error) {
+		time.Sleep(cost)
+		return t.Price * float64(t.Quantity), nil
+	}, nil)
+	for range 64 {
+		e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1})
+		task, _ := e.pop()
+		e.runTask(task)
+	}
+	if avg := time.Duration(e.AvgProcessNanos()); avg < cost || avg > 100*cost {
+		t.Fatalf("AvgProcessNanos = %v, want about %v", avg, cost)
+	}
+}
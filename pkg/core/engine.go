@@ -1,18 +1,47 @@
 package core
 
 import (
+	"arena_demo/pkg/alloc"
 	"arena_demo/pkg/arena"
 	"arena_demo/pkg/fastqueue"
 	"arena_demo/pkg/sysclock"
 	"arena_demo/pkg/zlog"
+	"context"
 	"fmt"
+	"math"
+	"net"
 	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
 // TaskType 定义任务类型 (Tagged Union 的 Tag)
+// 数值会写入 IPC 帧与交接数据，新增类型只能加在 taskTypeCount 之前，不能改变已有的值
 const (
-	TaskTypeCalc  = 0
-	TaskTypeOrder = 1
+	TaskTypeCalc = iota
+	TaskTypeOrder
+	// TaskTypeQuery 只读查询 UserVolume[Value]，不修改任何状态 (幂等)
+	TaskTypeQuery
+	// TaskTypeBatchOrder 一篮子订单，全部成功或全部回滚 (见 Orders)
+	TaskTypeBatchOrder
+	// TaskTypeResetVolume 运维控制任务：清零 UserVolume[Value]，回复清零前的值
+	TaskTypeResetVolume
+	// TaskTypeSnapshot / TaskTypeLoadState 状态快照控制任务 (见 state.go)，Value 为分片数
+	TaskTypeSnapshot
+	TaskTypeLoadState
+	// TaskTypeFlush 屏障控制任务 (见 Flush)
+	TaskTypeFlush
+	// TaskTypeVolumes 批量只读查询：把 UserVolume[Quantity:] 拷贝到 Volumes (见 SnapshotVolumes)
+	TaskTypeVolumes
+	// TaskTypeHalt 交接控制任务：Worker 停在原地直到 Export 放行 (见 export.go)
+	TaskTypeHalt
+	// TaskTypeMarketPrice 更新参考价并撮合满足条件的挂单 (见 SetMarketPrice)，Price 为新价格
+	TaskTypeMarketPrice
+
+	// taskTypeCount 是已定义的任务类型数 (不是任务类型)，超出范围的类型不计入 RED 指标、不能注册校验器与专属 Arena
+	taskTypeCount
 )
 
 // Task 是传递的数据结构 (Tagged Union 模式)
@@ -23,88 +52,552 @@ type Task struct {
 
 	// Calc 任务字段
 	Value int
+	// Windowed 为 true 时结果计入滚动窗口聚合，而不是单独回复 (见 EnableWindow)
+	Windowed bool
+	// EventTime 事件时间 (sysclock 纳秒)，用于判定窗口迟到，0 表示使用处理时间
+	EventTime int64
 
 	// Order 任务字段
 	Price    float64
 	Quantity int
+	// LimitPrice 限价单 (OrderType == OrderLimit) 的价格条件 (见 limit.go)
+	LimitPrice float64
+
+	// BatchOrder 任务字段
+	Orders []Order
+
+	// State 快照/恢复任务使用的缓冲区 (StateSize 字节)，由调用者提供
+	State []byte
+
+	// Volumes 批量查询的输出缓冲区，由调用者提供
+	Volumes []float64
 
 	// 结果回传 (这里为了通用暂时用 any，极致优化可以使用 typed channel 或 callback)
+	// 必须有缓冲：Worker 非阻塞投递，缓冲已满时结果被丢弃 (见 reply.go)
 	Resp chan any
 
 	// LogBuf 是调用者提供的日志缓冲区 (实现 Zero Allocation Logging)
 	LogBuf []byte
+	// ArenaLog 为 true 且未提供 LogBuf 时，日志写入引擎自己的 Arena
+	// 这种日志在 Reset 后失效，引擎会在回传前拷贝一份
+	ArenaLog bool
+
+	// DryRun 为 true 时只计算结果，不修改任何引擎状态
+	DryRun bool
+
+	// QoS 服务等级，影响队列选择和准入控制
+	QoS QoS
+
+	// Overflow 队列满时的处理策略，默认 OverflowReject
+	Overflow OverflowPolicy
+
+	// 分布式追踪上下文 (来自 traceparent Header)，定长数组，零分配
+	TraceID TraceID
+	SpanID  SpanID
+
+	// CorrID 关联 ID (见 CorrIDHeader)，原样回写到结果中，0 表示未设置
+	CorrID uint64
+
+	// ClientIP 客户端地址，仅用于订单日志，nil 表示未知
+	ClientIP net.IP
+
+	// Deadline 任务的截止时间 (引擎时钟纳秒，默认 sysclock，见 clock.go)，0 表示不限
+	// Worker 开始处理时已过期的任务直接丢弃，向 Resp 回传 ErrExpired
+	Deadline int64
+
+	// Cancel 非空时，Worker 取出任务时先检查它：已经置为 true 的任务直接跳过 (见 cancel.go)
+	// 调用方保留这个指针，在客户端断开 (ctx.Done) 时 Store(true)
+	Cancel *atomic.Bool
+
+	// ExecuteAt 任务的最早执行时间 (引擎时钟纳秒，默认 sysclock)，0 表示立即执行 (需要 EnableDelayQueue，见 delay.go)
+	ExecuteAt int64
+
+	// enqueued 入队时刻 (sysclock 纳秒)，由 submitLocal 设置，用于延迟直方图
+	enqueued int64
+	// fairHeld 任务占用了公平调度的积压名额 (见 fairQueue.reserve)
+	fairHeld bool
+	// admitted 任务占用了在途任务上限的名额 (见 EnableInflightLimit)，回复后归还
+	admitted bool
+
+	// OrderType 订单类型，默认市价单 (放在末尾的填充里，Task 不因此变大)
+	OrderType OrderType
 }
 
+// LogOwnership 标识 OrderResult.Log 的内存归属
+type LogOwnership uint8
+
+const (
+	// LogCallerOwned Log 指向调用者提供的 LogBuf，Arena Reset 后依然有效
+	LogCallerOwned LogOwnership = iota
+	// LogArenaOwned Log 最初写在引擎 Arena 上，Reset 后会被覆盖
+	// 引擎在回传前已将其拷贝到堆上 (这次拷贝是唯一的分配)
+	LogArenaOwned
+)
+
 type OrderResult struct {
 	Total       float64
 	ProcessedAt int64
 	Log         []byte
+	LogOwner    LogOwnership
+	DryRun      bool  // 结果来自 Dry-Run，状态未被修改
+	Err         error // 非空表示订单被拒绝 (如 ErrPositionLimit)，此时状态未被修改
+	CorrID      uint64
+	// LogOverflowed 日志超出了 LogBuf 的容量并被搬到堆上 (零分配失效)，LogBuf 应该调大
+	LogOverflowed bool
 }
 
+// SpinStrategy 决定 Worker 在队列为空时如何空转
+type SpinStrategy uint8
+
+const (
+	// SpinGosched 每次空轮询调用 runtime.Gosched() (默认，对其它 goroutine 最友好)
+	SpinGosched SpinStrategy = iota
+	// SpinPause 每次空轮询执行若干条 PAUSE/YIELD 指令，不进入调度器
+	// 延迟最低，但会持续占满一个核，适合配合 CPUAffinity 使用
+	SpinPause
+)
+
+// pauseCycles 是 SpinPause 模式下每次空轮询执行的 PAUSE 次数
+const pauseCycles = 32
+
 type Engine struct {
 	Queue *fastqueue.RingBuffer[Task]
-	Mem   *arena.Arena
+	// HighQueue 是 Gold 流量专用的高优先级队列 (High Lane)
+	HighQueue *fastqueue.RingBuffer[Task]
+	Mem       *arena.Arena
 
 	// 演示 Solution 1: 替代 Map
 	// 使用定长数组存储用户状态 (Key: UserID 0-1023)
 	// 访问速度: O(1)
 	// GC 开销: 0 (这是大对象的一部分)
 	UserVolume [1024]float64
+
+	// PositionLimit 全局的单用户累计成交额上限，0 表示不限 (可被 SetUserLimit 按用户覆盖)
+	PositionLimit float64
+	// userLimits 按用户的限额 (float64 bits)，0 表示使用全局限额
+	userLimits [1024]atomic.Uint64
+
+	// LogSampler 对订单日志做 1/N 采样，nil 表示全部输出
+	LogSampler *zlog.Sampler
+	// LogTail 非空时订单日志同时写入这个内存环，供 /logs 实时查看
+	LogTail *zlog.RingSink
+	// LogSeq 非空时每行订单日志带上 seq 序号，用于下游检测丢行
+	LogSeq *zlog.Sequence
+	// LogRedact 非空时订单日志中的敏感字段按它脱敏 (如 ip)
+	LogRedact *zlog.Redactor
+
+	// dryRun 引擎级 Dry-Run 开关 (用于回放/金丝雀校验)
+	// 由 Go World 设置，C World 读取，所以必须是原子变量
+	dryRun atomic.Bool
+
+	// AdmitPercent 每个 QoS 等级在普通队列上的准入阈值 (占容量的百分比)
+	// 队列占用超过阈值时，该等级的新任务直接被拒绝
+	AdmitPercent [qosLevels]uint64
+
+	// BlockTimeout 是 OverflowBlock 策略的最长等待时间
+	BlockTimeout time.Duration
+	// SpillMax 是溢出区 (OverflowSpill) 的最大任务数
+	SpillMax int
+	// Retry 是 SubmitRetry / Call 在队列满时的重试策略
+	Retry RetryPolicy
+	// Breaker 保护 BreakerTypes 中的任务类型，nil 表示不启用熔断
+	Breaker *CircuitBreaker
+	// BreakerTypes 受熔断保护的任务类型位图 (1 << TaskType)
+	BreakerTypes uint32
+	// CPUAffinity Worker 线程绑定的 CPU 编号，-1 表示不绑核 (仅 Linux)
+	CPUAffinity int
+	// RTPriority > 0 时 Worker 线程切换为 SCHED_FIFO 实时调度 (1-99，需要 CAP_SYS_NICE)
+	// 危险：一个永不让出的实时线程可以饿死同核上的其它所有线程 (包括 sysclock 和 GC)，
+	// 必须配合 CPUAffinity 绑到一个隔离的核 (isolcpus) 上使用
+	RTPriority int
+	// Spin 空轮询策略，默认 SpinGosched
+	Spin SpinStrategy
+	// ClockStaleAfter sysclock 停止后，缓存时间落后超过该阈值即回退到 time.Now()
+	ClockStaleAfter time.Duration
+	// GCYieldRatio 堆占用达到 GC 目标的该比例时 Worker 每轮主动让出，0 表示关闭
+	GCYieldRatio float64
+	// HousekeepingBudget 每轮维护作业的时间预算 (见 AddHousekeeping)
+	HousekeepingBudget time.Duration
+
+	stats    engineStats
+	arenaCap int64
+	spill    spillQueue
+	gc       gcPressure
+
+	// shards 多 Worker 分片 (见 StartN)，nil 表示单 Worker
+	shards atomic.Pointer[[]*Engine]
+	// shardID 本引擎在分片中的下标 (单 Worker 时为 0)
+	shardID int
+	// clock 业务时间源 (见 NewEngineWithClock)，nil 表示 sysclock
+	clock Clock
+	// router 非空时用一致性哈希代替 uid&(n-1) 分配用户 (见 router.go)
+	router *ShardRouter
+	// scaling/inflight 是 Scale 使用的提交闸门 (见 scale.go)
+	scaling  atomic.Bool
+	inflight atomic.Int64
+	// stop 为 true 时 Worker 在队列排空后退出 (缩容)
+	stop atomic.Bool
+
+	// window calc 结果的滚动窗口聚合，nil 表示未开启
+	window *tumblingWindow
+	// fair 按用户的公平调度，nil 表示严格 FIFO
+	fair *fairQueue
+	// shadow 订单影子模式，nil 表示未开启
+	shadow *shadowRunner
+	// wal UserVolume 修改日志，nil 表示未开启
+	wal *walWriter
+	// handoff 空队列时的直接交接位，nil 表示未开启
+	handoff *handoffSlot
+	// house 周期性维护作业，nil 表示没有注册
+	house *housekeeper
+	// recovery 任务 panic 后继续运行，nil 表示 panic 直接崩溃
+	recovery *recovery
+	// faults 故障注入 (仅 faultinject 构建，见 SetFaultInjector)，nil 表示未开启
+	faults *faultInjector
+	// calc 无状态的 calc Worker 池 (见 EnableCalcPool)，nil 表示 calc 与订单共用分片 Worker
+	calc *calcPool
+	// results Resp 为 nil 的任务的输出环 (见 EnableResultRing)，nil 表示不回传
+	results *resultRing
+	// reads 读通道的 UserVolume 快照 (见 EnableReadLane)，nil 表示未开启
+	reads *readLane
+	// imported ImportEngine 读入、等待启动时恢复的状态与任务，nil 表示没有
+	imported *importedWork
+	// drainRate 处理速率的采样 (见 DrainEstimate)
+	drainRate drainMeter
+	// market 限价单使用的参考价 (Worker 独占，由 TaskTypeMarketPrice 更新)，0 表示还没有报价
+	market float64
+	// book 未成交的限价挂单 (见 EnableRestingOrders)，nil 表示不满足条件的限价单直接拒绝
+	book *restingBook
+	// flights 相同 calc 请求的合并 (见 EnableCalcCoalescing)，nil 表示不合并
+	flights *calcFlights
+	// admit 系统内在途任务数的上限 (见 EnableInflightLimit)，nil 表示不限
+	admit *InflightLimiter
+	// inline 同步模式 (见 NewEngineSync)：提交的任务在调用方 goroutine 上当场处理
+	inline *inlineMode
+	// red TrySubmit 的 RED 指标 (见 EnableREDMetrics)，所有分片共用，nil 表示未开启
+	red *redMetrics
+	// delay 未到 ExecuteAt 的任务 (见 EnableDelayQueue)，nil 表示 ExecuteAt 被忽略
+	delay *delayQueue
+	// standby 热备 Worker 的看门狗与影子状态 (见 EnableStandby)，nil 表示未开启
+	standby *standby
+	// stream Resp 为 nil 的任务的结果流 (见 EnableResultStream)，nil 表示未开启
+	stream *resultStream
+	// validators 按任务类型的提交前校验 (见 SetValidator)，nil 表示不校验
+	validators *[taskTypeCount]func(Task) error
+	// taskMem 按任务类型的 Arena (见 SetTaskArena)，nil 表示都使用 Mem
+	taskMem *[taskTypeCount]*arena.Arena
+	// memShed 内存压力降载 (见 EnableMemoryShedding)，nil 表示未开启
+	memShed *memShedder
+	// pause 维护暂停的状态 (见 Pause)
+	pause pauseState
+
+	// alloc 任务处理中的分配器，开启 EnableHeapFallback 时 Arena 放不下的分配退回堆上 (见 heapfallback.go)
+	alloc alloc.Allocator
+	// fallbackHigh / fallbackType 单次兜底的最大字节数 (创新高才写日志) 与当前任务的类型，只由 Worker 读写
+	fallbackHigh int
+	fallbackType int
+	// breakerWatch / breakerFailed 当前任务受熔断保护时由 reply 与 dumpPanic 记下它是否失败，只由 Worker 读写
+	breakerWatch  bool
+	breakerFailed bool
+
+	// cmds 控制面命令队列 (见 EnableCommandQueue)，nil 表示控制操作仍作为 Gold 任务排队
+	cmds *commandQueue
+
+	// agingStep 优先级老化的步长 (纳秒)，0 表示关闭 (见 EnablePriorityAging)
+	agingStep int64
+	// takeover 接管前的主 Worker：备机每轮先取完它队列里剩下的任务 (见 promote)，nil 表示不是接管来的
+	takeover *Engine
 }
 
 func NewEngine() *Engine {
-	return &Engine{
-		Queue: fastqueue.New[Task](1024),
-		Mem:   arena.Acquire(), // C World 独占的大内存块
+	e := newEngine()
+	e.publishExpvar()
+	return e
+}
+
+// newEngine 创建一个未发布 expvar 的引擎 (分片内部使用)
+func newEngine() *Engine {
+	e := &Engine{
+		Queue:     fastqueue.New[Task](1024),
+		HighQueue: fastqueue.New[Task](256),
+		Mem:       arena.Acquire(), // C World 独占的大内存块
+		AdmitPercent: [qosLevels]uint64{
+			QoSSilver: 100,
+			QoSGold:   100,
+			QoSBronze: 50,
+		},
+		BlockTimeout:    5 * time.Millisecond,
+		SpillMax:        64 * 1024,
+		Retry:           DefaultRetryPolicy,
+		ClockStaleAfter: 10 * time.Millisecond,
+		CPUAffinity:     -1,
+		GCYieldRatio:    DefaultGCYieldRatio,
+		gc:              newGCPressure(),
+
+		HousekeepingBudget: 50 * time.Microsecond,
+	}
+	e.arenaCap = int64(e.Mem.Cap())
+	return e
+}
+
+// now 返回订单时间戳
+// 正常情况下是 sysclock 的缓存时间 (0 syscall)；
+// 如果 sysclock 已被停止且缓存时间过期，则降级为 time.Now() 系统调用，
+// 用性能换取正确性，并返回 fallback=true 让调用方记录告警
+// sysclock.Running() 只是一次原子读，所以健康时几乎没有额外开销；注入的时钟 (见 clock.go) 原样使用
+func (e *Engine) now() (ts int64, fallback bool) {
+	if e.clock != nil {
+		return e.clock.Now(), false
+	}
+	ts = sysclock.Now()
+	if sysclock.Running() || sysclock.Age() <= e.ClockStaleAfter {
+		return ts, false
 	}
+	e.stats.clockFallbacks.Add(1)
+	return time.Now().UnixNano(), true
+}
+
+// SetDryRun 打开/关闭引擎级 Dry-Run 模式
+// 开启后所有任务照常计算并返回结果，但不会修改 UserVolume 等状态：LoadState 与 SetMarketPrice 不生效，
+// 不满足条件的限价单直接拒绝而不挂单。Task.DryRun 对单个任务的效果相同
+// 运维清零 (ResetUserVolume) 不受影响
+func (e *Engine) SetDryRun(on bool) {
+	e.dryRun.Store(on)
+}
+
+// DryRun 返回引擎当前是否处于 Dry-Run 模式
+func (e *Engine) DryRun() bool {
+	return e.dryRun.Load()
 }
 
 // Start 启动 "C 模式" 线程
 func (e *Engine) Start() {
+	if e.inline != nil {
+		// 同步模式没有 Worker
+		e.replayImported()
+		return
+	}
+	if e.calc != nil {
+		e.calc.admit = e.admit
+		e.calc.red = e.red
+		e.calc.clock = e.clock
+		e.calc.stop = &e.stop
+		e.calc.start()
+	}
+	if e.standby != nil {
+		e.startStandby()
+	}
+	if e.memShed != nil {
+		e.memShed.start(&e.stop)
+	}
 	go func() {
 		// 1. 锁死线程，拒绝调度
 		runtime.LockOSThread()
 
+		// 给 Worker 打上 pprof 标签，CPU Profile / goroutine dump 中可以一眼认出这个 100% 自旋的循环
+		pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(),
+			pprof.Labels("role", "core-worker", "shard", strconv.Itoa(e.shardID))))
+
+		// 1.1 可选：绑核 + 实时优先级，失败 (如无权限) 时降级为普通线程继续运行
+		if err := pinThread(e.CPUAffinity, e.RTPriority); err != nil {
+			fmt.Println("[Core] CPU affinity/RT priority not applied:", err)
+		}
+
 		fmt.Println("[Core] Started in C-Mode (Pinned Thread, Arena Memory)")
 
 		for {
+			// 窗口到期检查 (未开启时只是一次 nil 判断)
+			if e.window != nil {
+				e.window.advance(sysclock.Now())
+			}
+			// 维护作业 (未注册时只是一次 nil 判断)
+			if e.house != nil {
+				if now := sysclock.Now(); now >= e.house.due {
+					e.house.run(e, now, e.HousekeepingBudget)
+				}
+			}
+			// 到期的延迟任务排在队列中的新任务之前 (未开启时只是一次 nil 判断)
+			if e.delay != nil {
+				e.runDue(e.clockNow())
+			}
+			// 控制命令在两个任务之间执行 (未开启时只是一次 nil 判断)
+			if e.cmds != nil {
+				e.runCommands()
+			}
+
 			// 2. 自旋轮询 (Busy Loop)，完全不让出 CPU
 			// 就像 C 的 while(1)
-			task, ok := e.Queue.Pop()
+			// 热备：心跳并持有租约 pop (未开启时只是一次 nil 判断)
+			if e.standby != nil && !e.standby.hold(e) {
+				// 已被备机接管：不再碰队列，归还 Arena 后退出
+				runtime.UnlockOSThread()
+				e.releaseArenas()
+				return
+			}
+			task, ok := e.pop()
+			if e.standby != nil {
+				e.standby.unhold()
+			}
 			if !ok {
 				// 空转，为了避免 CPU 100% 稍微 yield 一下，
-				// 在极低延迟场景下，这里可以使用 runtime.Gosched() 或者更底层的 cpu pause 指令
+				// 在极低延迟场景下，可以切换为 SpinPause，使用更底层的 cpu pause 指令
 				// 但为了演示效果，我们不做任何 sleep
-				runtime.Gosched()
+				if e.handoff != nil {
+					e.handoff.setIdle(true)
+				}
+
+				// 空闲时把攒下的 WAL 记录写出去 (未开启时只是一次 nil 判断)
+				if e.wal != nil {
+					e.wal.flush()
+				}
+				// 空闲时发布读快照 (未开启时只是一次 nil 判断)
+				if e.reads != nil {
+					e.reads.idle(e)
+				}
+				if e.stop.Load() {
+					// 缩容：队列已空，归还 Arena 后退出
+					runtime.UnlockOSThread()
+					e.releaseArenas()
+					return
+				}
+				if e.yieldForGC() {
+					continue
+				}
+				if e.Spin == SpinPause {
+					cpuPause(pauseCycles)
+				} else {
+					runtime.Gosched()
+				}
+				continue
+			}
+
+			if e.handoff != nil {
+				e.handoff.setIdle(false)
+			}
+			if e.delayed(&task) {
+				e.deferTask(task)
 				continue
 			}
 
-			// 3. 处理任务 (Zero GC)
-			e.process(task)
+			// 3-4. 处理任务并重置 Arena
+			e.runTask(task)
 
-			// 4. 重置 Arena (每处理一个任务重置一次，或者批量重置)
-			// 这样保证内存永远在一个固定的小范围内复用，极大提高 Cache 命中率
-			e.Mem.Reset()
+			// 5. GC 前夕主动让出，避免拉长 STW (见 gcyield.go)
+			e.yieldForGC()
 		}
 	}()
+	// 分片启动时由 StartN 在所有分片都启动之后恢复
+	if e.shards.Load() == nil {
+		e.replayImported()
+	}
+}
+
+// runTask 处理一个任务，更新统计并重置 Arena (Worker 循环与同步模式共用)
+func (e *Engine) runTask(task Task) {
+	if task.cancelled() {
+		e.skipCancelled(task)
+		return
+	}
+	// 3. 处理任务 (Zero GC)，每 8 个任务精确计时一次用于 AvgProcessNanos
+	sampled := e.stats.processed.Load()&emaSampleMask == 0
+	var t0 int64
+	if sampled {
+		t0 = sysclock.Mono()
+	}
+	// 受熔断保护的类型记下处理结果，处理完报告给熔断器
+	guarded := e.breakerGuards(task.Type)
+	if guarded {
+		e.breakerWatch, e.breakerFailed = true, false
+	}
+	// 单独设置了 Arena 的类型在处理期间换上它 (见 SetTaskArena)
+	mem := e.Mem
+	if a := e.taskArena(task.Type); a != nil {
+		e.Mem = a
+	}
+	// 按类型的 Arena 用量：只读两次偏移量 (通常 before 为 0，批量 Reset 时不是)
+	before := e.Mem.Used()
+	if e.results != nil && task.Resp == nil {
+		e.processToRing(task)
+	} else if e.stream != nil && task.Resp == nil {
+		e.processToStream(task)
+	} else if e.recovery != nil {
+		e.processRecover(task)
+	} else {
+		e.process(task)
+	}
+	if sampled {
+		e.stats.procEMA.observe(sysclock.Mono() - t0)
+	}
+	if guarded {
+		e.breakerReport()
+	}
+	if task.admitted {
+		e.admit.Release()
+	}
+	used := e.Mem.Used()
+	e.stats.recordArena(task.Type, int64(used-before))
+	e.stats.record(used)
+	if e.reads != nil {
+		e.reads.afterTask(e, task.Type)
+	}
+	if task.enqueued != 0 {
+		d := sysclock.Now() - task.enqueued
+		e.stats.latency.observe(d)
+		if e.red != nil {
+			e.red.done(task.Type, d)
+		}
+	}
+
+	// 4. 重置 Arena (每处理一个任务重置一次，或者批量重置)
+	// 这样保证内存永远在一个固定的小范围内复用，极大提高 Cache 命中率
+	e.Mem.Reset()
+	e.Mem = mem
 }
 
 //go:nosplit
 func (e *Engine) process(t Task) {
+	// 故障注入 (生产构建中 faultsEnabled 是常量 false，整段被消除)
+	if faultsEnabled && e.faults != nil && e.injectFault(t.Type, t.Resp) {
+		return
+	}
+
+	// 积压时丢弃已经没人等待的旧任务 (一次原子读，未设置 Deadline 时只多一次比较)
+	if t.Deadline != 0 && e.clockNow() > t.Deadline {
+		e.stats.expired.Add(1)
+		e.reply(t.Resp, ErrExpired)
+		return
+	}
+
+	// Dry-Run 与正常路径共用同一套计算逻辑，只在"写状态"这一步分叉
+	// 避免两条路径的计算结果产生漂移
+	dry := t.DryRun || e.dryRun.Load()
+
 	// 演示：根据 Type 处理不同逻辑 (Tagged Union)
 	switch t.Type {
 	case TaskTypeCalc:
 		// 演示：在 Arena 上分配内存 (完全绕过 Go GC)
-		tempPtr := arena.New[int](e.Mem)
+		tempPtr := alloc.New[int](e.allocator(t.Type))
 		*tempPtr = t.Value * 2
-		e.UserVolume[0] += float64(*tempPtr) // 简单更新状态
-		t.Resp <- *tempPtr
+		if !dry {
+			e.UserVolume[0] += float64(*tempPtr) // 简单更新状态
+			e.walAddVolume(0, float64(*tempPtr))
+		}
+		if t.Windowed && e.window != nil && !dry {
+			e.window.add(t.EventTime, *tempPtr)
+		}
+		e.reply(t.Resp, CalcResult(*tempPtr))
 	case TaskTypeOrder:
 		// 演示：处理订单逻辑
 		// 1. 获取时间 (Zero Syscall)
-		ts := sysclock.Now()
+		ts, fallback := e.now()
 
-		// 2. 业务逻辑
+		// 2. 业务逻辑 (限价单先按参考价决定成交、挂单还是拒绝)
+		var err error
+		if t.OrderType == OrderLimit {
+			if t.Price, err = e.limitPrice(t, dry); err == errRested {
+				return
+			}
+		}
 		total := t.Price * float64(t.Quantity)
 
 		// 演示：更新状态 (替代 Map)
@@ -112,22 +605,118 @@ func (e *Engine) process(t Task) {
 		// 1. 速度快 (CPU 指令周期少)
 		// 2. 必定为正数，帮助编译器消除边界检查 (BCE)
 		userID := t.Value & 1023
-		e.UserVolume[userID] += total
+		prevVolume := e.UserVolume[userID]
+		if err == nil {
+			err = e.checkLimit(userID, total)
+		}
+		if err == nil && !dry {
+			e.UserVolume[userID] += total
+			e.walAddVolume(userID, total)
+		}
+		if e.shadow != nil {
+			e.runShadow(t, dry, prevVolume, total, err)
+		}
 
 		// 3. 记录日志 (Zero Allocation)
 		var logBytes []byte
-		if t.LogBuf != nil {
-			// 使用调用者提供的 buffer
-			logger := zlog.Wrap(t.LogBuf)
-			logger.Int("ts", int(ts)).Str("type", "order").Int("uid", userID).Msg("processed")
-			logBytes = logger.Bytes()
+		var logOverflowed bool
+		owner := LogCallerOwned
+		if t.LogBuf != nil || t.ArenaLog {
+			logBytes, owner, logOverflowed = e.orderLog(t, ts, fallback, dry, userID, err)
 		}
 
-		// 4. 返回结果
-		t.Resp <- OrderResult{
-			Total:       total,
-			ProcessedAt: ts,
-			Log:         logBytes,
+		// 4. 返回结果 (没有 Resp 时连装箱也省掉：OrderResult 转成 any 就是一次堆分配)
+		if t.Resp == nil {
+			return
 		}
+		e.reply(t.Resp, OrderResult{
+			Total:         total,
+			ProcessedAt:   ts,
+			Log:           logBytes,
+			LogOwner:      owner,
+			DryRun:        dry,
+			Err:           err,
+			CorrID:        t.CorrID,
+			LogOverflowed: logOverflowed,
+		})
+	case TaskTypeQuery:
+		e.reply(t.Resp, e.UserVolume[t.Value&1023])
+	case TaskTypeBatchOrder:
+		e.processBatchOrder(t, dry)
+	case TaskTypeResetVolume:
+		uid := t.Value & 1023
+		prev := e.UserVolume[uid]
+		e.UserVolume[uid] = 0
+		e.walSetVolume(uid, 0)
+		e.reply(t.Resp, prev)
+	case TaskTypeSnapshot, TaskTypeLoadState, TaskTypeHalt, TaskTypeMarketPrice:
+		e.processState(t, t.Value, dry)
+	case TaskTypeVolumes:
+		e.processVolumes(t.Volumes, t.Quantity, t.Value, t.Resp)
+	case TaskTypeFlush:
+		// 溢出区在 pop 顺序的最后：里面还有更早提交的任务时，屏障排到溢出区末尾再等一轮
+		// 公平调度会打乱用户之间的顺序，子队列中还有积压时同样排到溢出区末尾
+		if e.spill.n.Load() != 0 || e.fairUsed() != 0 {
+			e.spill.push(t, math.MaxInt)
+			break
+		}
+		e.reply(t.Resp, nil)
+	}
+}
+
+// orderLog 写入订单日志，返回日志内容、所有权以及是否溢出
+// 单独拆出且禁止内联：Logger 落在 process 的栈帧里会超出 nosplit 的栈上限
+//
+//go:noinline
+func (e *Engine) orderLog(t Task, ts int64, fallback, dry bool, userID int, err error) (logBytes []byte, owner LogOwnership, overflowed bool) {
+	owner = LogCallerOwned
+	var logger *zlog.Logger
+	if t.LogBuf != nil {
+		// 使用调用者提供的 buffer
+		logger = zlog.Wrap(t.LogBuf)
+	} else {
+		// 写入引擎 Arena，本任务结束后 e.Mem.Reset() 会使其失效
+		logger = zlog.New(e.Mem)
+		owner = LogArenaOwned
+	}
+	// 拒单按 Warn 记录且不参与采样：采样只用来稀释海量的成功日志，每一笔拒单都要留下记录
+	sampler, lv := e.LogSampler, zlog.InfoLevel
+	if err != nil {
+		sampler, lv = nil, zlog.WarnLevel
+	}
+	logger = logger.Sample(sampler, lv).Tee(e.LogTail).Seq(e.LogSeq).Redact(e.LogRedact)
+	logger.Int("ts", int(ts)).Str("type", "order").Int("uid", userID).Str("qos", t.QoS.String())
+	if t.CorrID != 0 {
+		logger.Int("corr_id", int(t.CorrID))
+	}
+	if t.ClientIP != nil {
+		logger.IP("ip", t.ClientIP)
+	}
+	if !t.TraceID.IsZero() {
+		logger.Hex("trace_id", t.TraceID[:]).Hex("span_id", t.SpanID[:])
+	}
+	if dry {
+		logger.Str("mode", "dryrun")
+	}
+	if fallback {
+		logger.Str("warn", "sysclock_stale")
+	}
+	// 详细字段只在出错时保留：先投机写入，成功时回滚掉
+	cp := logger.Checkpoint()
+	logger.Int("qty", t.Quantity).Int("limit", int(e.limitFor(userID)))
+	if err != nil {
+		logger.Str("err", err.Error()).Msg("rejected")
+	} else {
+		logger.Rollback(cp)
+		logger.Msg("processed")
+	}
+	logBytes = logger.Bytes()
+	if overflowed = logger.Overflowed(); overflowed {
+		e.stats.logOverflows.Add(1)
+	}
+	if owner == LogArenaOwned && logBytes != nil {
+		// 必须在 Reset 之前拷贝出去，否则接收方会读到被复用的内存
+		logBytes = append([]byte(nil), logBytes...)
 	}
+	return logBytes, owner, overflowed
 }
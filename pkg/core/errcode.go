@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorCode 是对外 (HTTP) 暴露的稳定错误码，与具体的 error 文本解耦
+type ErrorCode string
+
+const (
+	CodeQueueFull     ErrorCode = "queue_full"     // ErrFull
+	CodeRateLimited   ErrorCode = "rate_limited"   // ErrRateLimited
+	CodeValidation    ErrorCode = "validation"     // ErrInvalidOrder / ErrInvalidTask 及请求参数错误
+	CodeTimeout       ErrorCode = "timeout"        // ErrExpired / context.DeadlineExceeded
+	CodePositionLimit ErrorCode = "position_limit" // ErrPositionLimit
+	CodeCircuitOpen   ErrorCode = "circuit_open"   // ErrCircuitOpen
+	CodeOverloaded    ErrorCode = "overloaded"     // ErrOverloaded / ErrMemoryPressure
+	CodeLimitNotMet   ErrorCode = "limit_not_met"  // ErrLimitNotMet
+	CodeNotFound      ErrorCode = "not_found"      // 如未知的引擎名
+	CodeInternal      ErrorCode = "internal"       // 其它未归类的错误
+)
+
+// CodeOf 把引擎返回的 error 归类为错误码 (支持 errors.Is 包装链)
+func CodeOf(err error) ErrorCode {
+	switch {
+	case errors.Is(err, ErrFull):
+		return CodeQueueFull
+	case errors.Is(err, ErrRateLimited):
+		return CodeRateLimited
+	case errors.Is(err, ErrInvalidOrder), errors.Is(err, ErrInvalidTask), errors.Is(err, ErrBadCorrID):
+		return CodeValidation
+	case errors.Is(err, ErrExpired), errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, ErrPositionLimit):
+		return CodePositionLimit
+	case errors.Is(err, ErrCircuitOpen):
+		return CodeCircuitOpen
+	case errors.Is(err, ErrOverloaded), errors.Is(err, ErrMemoryPressure):
+		return CodeOverloaded
+	case errors.Is(err, ErrLimitNotMet):
+		return CodeLimitNotMet
+	}
+	return CodeInternal
+}
+
+// HTTPStatus 返回错误码对应的 HTTP 状态码
+//   - 503: 引擎暂时无法接收 (可重试)
+//   - 429: 调用方自己发得太快
+//   - 422: 请求合法但被业务规则拒绝
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case CodeQueueFull, CodeCircuitOpen, CodeOverloaded:
+		return 503
+	case CodeRateLimited:
+		return 429
+	case CodeValidation:
+		return 400
+	case CodeTimeout:
+		return 504
+	case CodePositionLimit, CodeLimitNotMet:
+		return 422
+	case CodeNotFound:
+		return 404
+	}
+	return 500
+}
+
+// AppendErrorJSON 追加错误响应体: {"code":"...","message":"..."}
+func AppendErrorJSON(dst []byte, code ErrorCode, msg string) []byte {
+	dst = append(dst, `{"code":"`...)
+	dst = append(dst, code...)
+	dst = append(dst, `","message":`...)
+	dst = appendJSONString(dst, []byte(msg))
+	return append(dst, '}')
+}
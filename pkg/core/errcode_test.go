@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// 每个引擎错误 (包括被 %w 包装过的) 归类到正确的错误码与 HTTP 状态，响应体是合法 JSON
+func TestErrorCodes(t *testing.T) {
+	for _, c := range []struct {
+		err    error
+		code   ErrorCode
+		status int
+	}{
+		{ErrFull, CodeQueueFull, http.StatusServiceUnavailable},
+		{ErrRateLimited, CodeRateLimited, http.StatusTooManyRequests},
+		{ErrInvalidOrder, CodeValidation, http.StatusBadRequest},
+		{ErrInvalidTask, CodeValidation, http.StatusBadRequest},
+		{ErrExpired, CodeTimeout, http.StatusGatewayTimeout},
+		{context.DeadlineExceeded, CodeTimeout, http.StatusGatewayTimeout},
+		{ErrPositionLimit, CodePositionLimit, http.StatusUnprocessableEntity},
+		{ErrCircuitOpen, CodeCircuitOpen, http.StatusServiceUnavailable},
+		{ErrOverloaded, CodeOverloaded, http.StatusServiceUnavailable},
+		{ErrMemoryPressure, CodeOverloaded, http.StatusServiceUnavailable},
+		{ErrLimitNotMet, CodeLimitNotMet, http.StatusUnprocessableEntity},
+		{fmt.Errorf("order 7: %w", ErrPositionLimit), CodePositionLimit, http.StatusUnprocessableEntity},
+		{errors.New("boom"), CodeInternal, http.StatusInternalServerError},
+	} {
+		code := CodeOf(c.err)
+		if code != c.code || code.HTTPStatus() != c.status {
+			t.Errorf("%v: code %q status %d, want %q %d", c.err, code, code.HTTPStatus(), c.code, c.status)
+		}
+
+		var body struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		b := AppendErrorJSON(nil, code, c.err.Error())
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Fatalf("%v: body %q is not JSON: %v", c.err, b, err)
+		}
+		if body.Code != string(c.code) || body.Message != c.err.Error() {
+			t.Errorf("%v: body = %+v", c.err, body)
+		}
+	}
+	if s := CodeNotFound.HTTPStatus(); s != http.StatusNotFound {
+		t.Errorf("CodeNotFound status = %d", s)
+	}
+}
+
+// message 中的引号、换行与控制字符被转义
+func TestErrorJSONEscapes(t *testing.T) {
+	msg := "bad \"uid\"\n\x01\\"
+	var body struct{ Message string }
+	if err := json.Unmarshal(AppendErrorJSON(nil, CodeValidation, msg), &body); err != nil || body.Message != msg {
+		t.Fatalf("round trip: %q, %v", body.Message, err)
+	}
+}
@@ -0,0 +1,375 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"runtime"
+	"time"
+)
+
+// 进程间交接 (零停机发布)：旧进程把还没处理的任务和引擎状态通过 Socket/文件交给新进程
+//
+// 旧进程 Export 的过程：
+//  1. 关闭提交闸门 (与 Scale 相同)，新的 TrySubmit 返回 ErrFull，等待已经通过闸门的提交完成
+//  2. 向每个分片投递一个 Gold 控制任务 TaskTypeHalt，Worker 处理到它时停在原地 (不退出、不再 pop)；
+//     排在它前面的 High Lane 任务已经由旧进程处理完，之后的任务一个都没有碰过
+//  3. 所有 Worker 都停住后，由 Export 自己按 pop 顺序取出每个分片剩余的任务，并导出状态
+//  4. 写出成功：取出的任务都向 Resp 回复 ErrHandedOff (结果将由新进程产生，旧进程的调用方拿不到)，
+//     Worker 保持停住、闸门保持关闭，旧进程此后只应退出
+//     写出失败：任务按原顺序放回各自的分片，恢复 Worker 并重新打开闸门，旧进程照常服务
+//
+// 任何任务要么在 Halt 之前由旧进程处理，要么写入交接数据由新进程处理，不会丢失也不会重复。
+// ImportEngine 返回一个尚未启动的引擎，调用方照常配置后 Start/StartN：
+// 启动时先恢复状态，再把交接的任务按原顺序重新入队，Start/StartN 返回之后才应开始接收新流量
+//
+// 格式 (小端):
+//
+//	[4]"ENGX" | [2]uint16 version | [2]保留 | [4]uint32 tasks | state (StateSize 字节，同 SnapshotState，不含挂单)
+//	tasks × ( [4]uint32 len | task )
+//	task := [1]type | [1]qos | [1]flags (bit0 DryRun, bit1 Windowed, bit2 限价单) | [1]overflow
+//	        [8]value | [8]price (限价单为 LimitPrice) | [8]quantity | [8]corr_id | [8]deadline | [8]event_time
+//	        [16]trace_id | [8]span_id | [1]ip_len ip | [4]uint32 orders × ( [8]price | [8]quantity | [8]user_id )
+//	        [8]execute_at (version 3)
+//
+// 不交接的内容：
+//   - Resp、LogBuf/ArenaLog 等进程内的指针 (新进程处理这些任务时不写订单日志，结果直接丢弃)
+//   - 只读/控制任务 (Query、Flush、快照等) 没有可交接的效果，只回复 ErrHandedOff
+//   - calc 池中的任务 (无状态) 不经过 Halt，由旧进程继续处理完；窗口聚合、WAL 等可选组件的内部状态
+//
+// 挂单作为普通任务交接，参考价随状态交接：新进程恢复状态之后重新处理这些限价单，仍不满足的照常挂单。
+//
+// version 2 增加了 flags 的 bit2，version 3 在记录末尾增加了 execute_at (见 delay.go)，
+// version 4 的状态是 version 2 的快照 (多了参考价与挂单数)，读取方同时接受旧版本
+
+const (
+	exportMagic   = "ENGX"
+	exportVersion = 4
+	exportHeader  = 12
+	// exportTaskFixed 单个任务记录中定长部分的字节数 (不含 ip、orders 与 version 3 的 execute_at)
+	exportTaskFixed = 4 + 8*6 + 16 + 8 + 1 + 4
+	exportOrderSize = 24
+	// maxExportTask 单个任务记录的上限，防止错误的长度前缀让读取方分配巨大的 buffer
+	maxExportTask = 16 << 20
+)
+
+// ErrHandedOff 任务已通过 Export 交给新进程处理，旧进程不会再回复结果
+var ErrHandedOff = errors.New("core: task handed off to successor process")
+
+// ErrBadExport 交接数据格式不合法或版本不兼容
+var ErrBadExport = errors.New("core: bad engine export")
+
+// importedWork 是 ImportEngine 读入、等待 Start 时恢复的内容
+type importedWork struct {
+	state []byte
+	tasks []Task
+}
+
+// Export 停住所有 Worker，把剩余的任务和引擎状态写入 w (必须在 Start/StartN 之后调用)
+// 返回 nil 之后引擎不再处理任何任务，调用方应该退出进程；返回错误时引擎已恢复原状
+func (e *Engine) Export(w io.Writer) error {
+	scaleMu.Lock()
+	defer scaleMu.Unlock()
+
+	e.scaling.Store(true)
+	for e.inflight.Load() != 0 {
+		runtime.Gosched()
+	}
+
+	n := e.NumShards()
+	parked := make([]chan any, n)
+	pending := make([][]Task, n)
+	for i := range n {
+		parked[i] = e.Shard(i).park()
+	}
+	// Worker 都已停住，这里是唯一访问队列和状态的线程
+	state := newStateBuf()
+	for i := range n {
+		s := e.Shard(i)
+		for {
+			t, ok := s.pop()
+			if !ok {
+				break
+			}
+			pending[i] = append(pending[i], t)
+		}
+		// 挂单排在队列之后 (它们已经处理过一次)，失败时随 requeue 回到队列重新挂单
+		pending[i] = append(pending[i], s.takeResting()...)
+		// 延迟任务带着 ExecuteAt 交接，新进程开启 EnableDelayQueue 后同样等到期再执行
+		pending[i] = append(pending[i], s.takeDelayed()...)
+		s.copyState(state, n, true)
+	}
+	binary.LittleEndian.PutUint64(state[stateTable:], math.Float64bits(e.Shard(0).market))
+
+	err := writeExport(w, state, pending)
+	if err != nil {
+		for i := range n {
+			e.Shard(i).requeue(pending[i])
+			parked[i] <- nil
+		}
+		e.scaling.Store(false)
+		return err
+	}
+	for _, tasks := range pending {
+		for _, t := range tasks {
+			if t.admitted {
+				e.admit.Release()
+			}
+			if t.Resp != nil {
+				select {
+				case t.Resp <- ErrHandedOff:
+				default:
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// park 投递 TaskTypeHalt 并等待 Worker 停住，返回用于恢复 Worker 的 channel
+// channel 没有缓冲，Worker 的 "已停住" 与 Export 的 "恢复" 是两次独立的交接
+func (e *Engine) park() chan any {
+	resp := make(chan any)
+	t := Task{Type: TaskTypeHalt, QoS: QoSGold, Resp: resp}
+	for !e.submitLocal(t) {
+		runtime.Gosched()
+	}
+	<-resp
+	return resp
+}
+
+// parkWorker 在 Worker 中处理 TaskTypeHalt：报告已停住，然后一直等到 Export 放行
+//
+//go:noinline
+func (e *Engine) parkWorker(resp chan any) {
+	// 停住期间心跳停止是预期内的，不能让热备接管
+	if e.standby != nil {
+		e.standby.parked.Store(true)
+		defer e.standby.parked.Store(false)
+	}
+	resp <- nil
+	<-resp
+}
+
+// requeue 把 Export 取出的任务按原顺序放回本分片 (Worker 停住时调用)
+// 队列刚被取空，放不下的部分 (原本就在溢出区的任务) 进入溢出区
+func (e *Engine) requeue(tasks []Task) {
+	for _, t := range tasks {
+		if e.submitErr(t) != nil {
+			e.spill.push(t, math.MaxInt)
+		}
+	}
+}
+
+// handedOff 报告任务是否有需要交接的效果 (会修改状态，或会计入窗口)
+func handedOff(t *Task) bool {
+	switch t.Type {
+	case TaskTypeCalc, TaskTypeOrder, TaskTypeBatchOrder, TaskTypeResetVolume:
+		return true
+	}
+	return false
+}
+
+func writeExport(w io.Writer, state []byte, pending [][]Task) error {
+	count := 0
+	for _, tasks := range pending {
+		for i := range tasks {
+			if handedOff(&tasks[i]) {
+				count++
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	hdr := make([]byte, exportHeader, exportHeader+exportTaskFixed)
+	copy(hdr, exportMagic)
+	binary.LittleEndian.PutUint16(hdr[4:], exportVersion)
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(count))
+	bw.Write(hdr)
+	bw.Write(state)
+	buf := hdr[:0]
+	for _, tasks := range pending {
+		for i := range tasks {
+			if !handedOff(&tasks[i]) {
+				continue
+			}
+			buf = appendExportTask(buf[:0], &tasks[i])
+			if _, err := bw.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// appendExportTask 把任务编码为一个带长度前缀的记录
+func appendExportTask(dst []byte, t *Task) []byte {
+	ip := t.ClientIP
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(exportTaskFixed+len(ip)+len(t.Orders)*exportOrderSize+8))
+	var flags byte
+	if t.DryRun {
+		flags |= 1
+	}
+	if t.Windowed {
+		flags |= 2
+	}
+	price := t.Price
+	if t.OrderType == OrderLimit {
+		// 限价单的 Price 不使用，这个位置存 LimitPrice
+		flags |= 4
+		price = t.LimitPrice
+	}
+	dst = append(dst, byte(t.Type), byte(t.QoS), flags, byte(t.Overflow))
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(t.Value))
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(price))
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(t.Quantity))
+	dst = binary.LittleEndian.AppendUint64(dst, t.CorrID)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(t.Deadline))
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(t.EventTime))
+	dst = append(dst, t.TraceID[:]...)
+	dst = append(dst, t.SpanID[:]...)
+	dst = append(dst, byte(len(ip)))
+	dst = append(dst, ip...)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(t.Orders)))
+	for _, o := range t.Orders {
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(o.Price))
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(o.Quantity))
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(o.UserID))
+	}
+	return binary.LittleEndian.AppendUint64(dst, uint64(t.ExecuteAt))
+}
+
+// decodeExportTask 解码一个 version 版本的任务记录 (不含长度前缀)
+func decodeExportTask(b []byte, version uint16) (Task, error) {
+	if len(b) < exportTaskFixed || !handedOff(&Task{Type: int(b[0])}) {
+		return Task{}, ErrBadExport
+	}
+	t := Task{
+		Type:      int(b[0]),
+		QoS:       QoS(b[1] % qosLevels),
+		DryRun:    b[2]&1 != 0,
+		Windowed:  b[2]&2 != 0,
+		Overflow:  OverflowPolicy(b[3]),
+		Value:     int(int64(binary.LittleEndian.Uint64(b[4:]))),
+		Price:     math.Float64frombits(binary.LittleEndian.Uint64(b[12:])),
+		Quantity:  int(int64(binary.LittleEndian.Uint64(b[20:]))),
+		CorrID:    binary.LittleEndian.Uint64(b[28:]),
+		Deadline:  int64(binary.LittleEndian.Uint64(b[36:])),
+		EventTime: int64(binary.LittleEndian.Uint64(b[44:])),
+	}
+	if b[2]&4 != 0 {
+		t.OrderType, t.LimitPrice, t.Price = OrderLimit, t.Price, 0
+	}
+	copy(t.TraceID[:], b[52:68])
+	copy(t.SpanID[:], b[68:76])
+	iplen := int(b[76])
+	rest := b[77:]
+	if iplen != 0 && iplen != net.IPv4len && iplen != net.IPv6len || len(rest) < iplen+4 {
+		return Task{}, ErrBadExport
+	}
+	if iplen > 0 {
+		t.ClientIP = net.IP(append([]byte(nil), rest[:iplen]...))
+	}
+	rest = rest[iplen:]
+	orders := int(binary.LittleEndian.Uint32(rest))
+	rest = rest[4:]
+	if version >= 3 {
+		if len(rest) < 8 {
+			return Task{}, ErrBadExport
+		}
+		t.ExecuteAt = int64(binary.LittleEndian.Uint64(rest[len(rest)-8:]))
+		rest = rest[:len(rest)-8]
+	}
+	if len(rest) != orders*exportOrderSize {
+		return Task{}, ErrBadExport
+	}
+	if orders > 0 {
+		t.Orders = make([]Order, orders)
+		for i := range t.Orders {
+			o := rest[i*exportOrderSize:]
+			t.Orders[i] = Order{
+				Price:    math.Float64frombits(binary.LittleEndian.Uint64(o)),
+				Quantity: int(int64(binary.LittleEndian.Uint64(o[8:]))),
+				UserID:   int(int64(binary.LittleEndian.Uint64(o[16:]))),
+			}
+		}
+	}
+	return t, nil
+}
+
+// ImportEngine 读取 Export 的输出，返回一个尚未启动的引擎
+// 状态与任务在 Start/StartN 时恢复 (与新进程的分片数无关)
+func ImportEngine(r io.Reader) (*Engine, error) {
+	br := bufio.NewReader(r)
+	var hdr [exportHeader]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, err
+	}
+	vers := binary.LittleEndian.Uint16(hdr[4:])
+	if string(hdr[:4]) != exportMagic || vers < 1 || vers > exportVersion {
+		return nil, ErrBadExport
+	}
+	count := binary.LittleEndian.Uint32(hdr[8:])
+	size := StateSize
+	if vers < 4 {
+		// version 1 的快照，只有用户表
+		size = stateTable
+	}
+	state := make([]byte, size)
+	if _, err := io.ReadFull(br, state); err != nil {
+		return nil, err
+	}
+	if !validState(state) {
+		return nil, ErrBadState
+	}
+	tasks := make([]Task, 0, int(min(count, 64<<10)))
+	var buf []byte
+	for range count {
+		var lenBuf [4]byte
+		if _, err := io.ReadFull(br, lenBuf[:]); err != nil {
+			return nil, err
+		}
+		n := binary.LittleEndian.Uint32(lenBuf[:])
+		if n > maxExportTask {
+			return nil, ErrBadExport
+		}
+		if cap(buf) < int(n) {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, err
+		}
+		t, err := decodeExportTask(buf, vers)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	e := NewEngine()
+	e.imported = &importedWork{state: state, tasks: tasks}
+	return e, nil
+}
+
+// replayImported 在 Worker 启动后恢复 ImportEngine 读入的状态，并把任务按原顺序重新入队
+// 每个任务得到一个新的 Resp (结果无人读取，由 GC 回收)，订单任务没有 Resp 会阻塞 Worker
+func (e *Engine) replayImported() {
+	im := e.imported
+	if im == nil {
+		return
+	}
+	e.imported = nil
+	_ = e.LoadState(im.state)
+	for _, t := range im.tasks {
+		t.Resp = make(chan any, 1)
+		s := e.route(t)
+		for s.submitErr(t) != nil {
+			time.Sleep(controlRetry)
+		}
+	}
+}
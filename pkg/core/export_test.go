@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// queueHandoffWork 在两个分片都停住时排入一组任务，返回它们的 Resp
+// 处理完之后的成交额：用户 1 = 10+1，用户 2 = 20+2，用户 3 = 7 (先下单、清零、再下单)，用户 4 = 12 (批量)，用户 5 不变 (Dry-Run)
+func queueHandoffWork(t *testing.T, e *Engine) []chan any {
+	t.Helper()
+	var resps []chan any
+	for _, task := range []Task{
+		{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1},
+		{Type: TaskTypeOrder, Value: 2, Price: 2, Quantity: 1},
+		{Type: TaskTypeOrder, Value: 3, Price: 5, Quantity: 1},
+		{Type: TaskTypeResetVolume, Value: 3},
+		{Type: TaskTypeOrder, Value: 3, Price: 7, Quantity: 1},
+		{Type: TaskTypeBatchOrder, Value: 4, Orders: []Order{{Price: 2, Quantity: 3, UserID: 4}, {Price: 6, Quantity: 1, UserID: 4}}},
+		{Type: TaskTypeOrder, Value: 5, Price: 9, Quantity: 1, DryRun: true},
+		{Type: TaskTypeQuery, Value: 1},
+	} {
+		task.Resp = make(chan any, 1)
+		resps = append(resps, task.Resp)
+		if err := e.TrySubmit(task); err != nil {
+			t.Fatalf("TrySubmit: %v", err)
+		}
+	}
+	return resps
+}
+
+func checkVolumes(t *testing.T, e *Engine, want map[int]float64) {
+	t.Helper()
+	e.Flush()
+	for uid, w := range want {
+		if v, err := e.TryGetUserVolume(uid); err != nil || v != w {
+			t.Errorf("UserVolume[%d] = %v, %v; want %v", uid, v, err, w)
+		}
+	}
+}
+
+var handoffVolumes = map[int]float64{1: 11, 2: 22, 3: 7, 4: 12, 5: 0}
+
+// 交接之后新进程 (分片数不同) 处理完全部排队的任务，状态与旧进程不交接、自己处理完的结果相同
+func TestExportImportHandoff(t *testing.T) {
+	e := NewEngine()
+	e.StartN(2)
+	stopOnCleanup(t, e)
+	order(t, e, 1, 10)
+	order(t, e, 2, 20)
+
+	release0, release1 := haltShard(t, e, 0), haltShard(t, e, 1)
+	resps := queueHandoffWork(t, e)
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() { done <- e.Export(&buf) }()
+	releaseToExport(e, release0, release1)
+	if err := <-done; err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// 旧进程：排队的任务 (包括不交接的 Query) 都回复 ErrHandedOff，不再接受新任务
+	for i, r := range resps {
+		if v := <-r; !errors.Is(asErr(v), ErrHandedOff) {
+			t.Fatalf("queued task %d replied %v, want ErrHandedOff", i, v)
+		}
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc}); !errors.Is(err, ErrFull) {
+		t.Fatalf("TrySubmit after Export: %v, want ErrFull", err)
+	}
+
+	e2, err := ImportEngine(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ImportEngine: %v", err)
+	}
+	e2.StartN(4)
+	stopOnCleanup(t, e2)
+	checkVolumes(t, e2, handoffVolumes)
+}
+
+// 写出失败时旧进程恢复原状：排队的任务由它自己处理完，闸门重新打开
+func TestExportWriteFailureResumes(t *testing.T) {
+	e := NewEngine()
+	e.StartN(2)
+	stopOnCleanup(t, e)
+	order(t, e, 1, 10)
+	order(t, e, 2, 20)
+
+	release0, release1 := haltShard(t, e, 0), haltShard(t, e, 1)
+	resps := queueHandoffWork(t, e)
+	done := make(chan error)
+	go func() { done <- e.Export(failWriter{}) }()
+	releaseToExport(e, release0, release1)
+	if err := <-done; !errors.Is(err, errWriteFailed) {
+		t.Fatalf("Export: %v, want the write error", err)
+	}
+	for i, r := range resps {
+		if v := <-r; errors.Is(asErr(v), ErrHandedOff) {
+			t.Fatalf("task %d handed off although Export failed", i)
+		}
+	}
+	checkVolumes(t, e, handoffVolumes)
+}
+
+func TestImportEngineRejectsBadData(t *testing.T) {
+	e := NewEngine()
+	e.Start()
+	stopOnCleanup(t, e)
+	e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1})
+	release := haltShard(t, e, 0)
+	e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1})
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() { done <- e.Export(&buf) }()
+	releaseToExport(e, release)
+	if err := <-done; err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	good := buf.Bytes()
+
+	bad := append([]byte(nil), good...)
+	copy(bad, "XXXX")
+	if _, err := ImportEngine(bytes.NewReader(bad)); !errors.Is(err, ErrBadExport) {
+		t.Fatalf("bad magic: err = %v, want ErrBadExport", err)
+	}
+	for _, n := range []int{0, exportHeader, len(good) - 1} {
+		if _, err := ImportEngine(bytes.NewReader(good[:n])); err == nil {
+			t.Fatalf("export truncated to %d bytes was accepted", n)
+		}
+	}
+}
+
+// releaseToExport 依次放行停住的分片，每个分片都等 Export 的 Halt 排进高优先级队列之后再放行，
+// 保证 Worker 醒来后先停在 Export 手里，排队的任务一个都不会被旧进程处理
+// Export 逐个分片停住 Worker，所以第 i+1 个分片的 Halt 要等第 i 个分片停住之后才会出现
+func releaseToExport(e *Engine, releases ...func()) {
+	for i, release := range releases {
+		for e.Shard(i).HighQueue.Len() == 0 {
+			runtime.Gosched()
+		}
+		release()
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errWriteFailed }
+
+func asErr(v any) error {
+	err, _ := v.(error)
+	return err
+}
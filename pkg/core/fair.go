@@ -0,0 +1,158 @@
+package core
+
+import (
+	"arena_demo/pkg/fastqueue"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrRateLimited 该用户积压的任务已达到公平调度的上限 (见 EnableFairQueuing)
+var ErrRateLimited = errors.New("core: user rate limited")
+
+// 公平调度 (Fair Queuing)：防止一个刷单的用户独占 Worker
+//
+// 默认的普通队列是严格 FIFO，用户 A 一次灌进 1000 个任务，排在后面的用户 B 就要等 A 全部处理完。
+// 开启 EnableFairQueuing 后调度策略变为：
+//  1. High Lane (Gold) 仍然绝对优先，不参与公平调度
+//  2. Worker 每次取任务前，把普通队列中的任务尽量搬进按用户划分的子队列 (直到槽位用尽)
+//  3. 在有任务的用户之间轮转 (Round-Robin)，每轮每个用户只处理一个任务，用户内部保持 FIFO
+//  4. 提交时某用户已提交未处理的任务数达到 maxPending 即拒绝 (ErrRateLimited)，
+//     让刷单者自己承担背压，而不是挤满共享的普通队列 (maxPending <= 0 表示不限)
+//
+// 用户按 UserID & 1023 映射 (与 UserVolume 相同)，因此最多跟踪 1024 个用户，
+// 映射冲突的用户共享一个子队列。Calc 等不带 UserID 的任务统一归入 0 号用户
+//
+// 子队列不放在 Arena 上：Task 含有 channel/切片等指针，必须对 GC 可见。
+// 所有内存在 EnableFairQueuing 时一次性分配 (槽位数 = 普通队列容量)，运行期零分配
+
+// fairUsers 公平调度跟踪的用户数上限
+const fairUsers = 1024
+
+// fairQueue 只由 Worker 读写，pending 由提交方和 Worker 原子增减
+type fairQueue struct {
+	maxPending int32
+
+	slots []Task  // 所有子队列共享的槽位
+	next  []int32 // 槽位链表，-1 表示末尾
+	free  int32   // 空闲槽位链表头
+
+	head, tail [fairUsers]int32 // 每个用户子队列的首尾槽位，-1 表示空
+
+	// active 是有积压用户的轮转环，每个用户最多出现一次
+	active      [fairUsers]uint16
+	activeHead  int
+	activeCount int
+
+	used    atomic.Int64            // 被占用的槽位数 (drain 使用)
+	pending [fairUsers]atomic.Int32 // 每个用户已提交、尚未被 Worker 取出的任务数
+}
+
+// EnableFairQueuing 开启按用户的公平调度，必须在 Start 之前调用
+func (e *Engine) EnableFairQueuing(maxPending int) {
+	n := int(e.Queue.Cap())
+	f := &fairQueue{
+		maxPending: int32(maxPending),
+		slots:      make([]Task, n),
+		next:       make([]int32, n),
+	}
+	for i := range f.next {
+		f.next[i] = int32(i + 1)
+	}
+	f.next[n-1] = -1
+	for i := range f.head {
+		f.head[i], f.tail[i] = -1, -1
+	}
+	e.fair = f
+}
+
+// fairKey 返回任务所属的用户
+func fairKey(t *Task) int {
+	switch t.Type {
+	case TaskTypeOrder, TaskTypeBatchOrder, TaskTypeQuery, TaskTypeResetVolume:
+		return t.Value & (fairUsers - 1)
+	}
+	return 0
+}
+
+// reserve 在提交方调用：为任务占用该用户的一个积压名额，名额用尽时返回 false
+// 占用成功的任务带 fairHeld 标记，由 Worker 取出时 (无论从哪条队列) 归还
+func (f *fairQueue) reserve(t *Task) bool {
+	if f.maxPending <= 0 {
+		return true
+	}
+	p := &f.pending[fairKey(t)]
+	if p.Add(1) > f.maxPending {
+		p.Add(-1)
+		return false
+	}
+	t.fairHeld = true
+	return true
+}
+
+// release 归还 reserve 占用的名额
+func (f *fairQueue) release(t *Task) {
+	if t.fairHeld {
+		t.fairHeld = false
+		f.pending[fairKey(t)].Add(-1)
+	}
+}
+
+// refill 把普通队列中的任务搬进子队列，直到队列为空或槽位用尽
+func (f *fairQueue) refill(q *fastqueue.RingBuffer[Task]) {
+	for f.free >= 0 {
+		t, ok := q.Pop()
+		if !ok {
+			return
+		}
+		i := f.free
+		f.free = f.next[i]
+		f.slots[i] = t
+		f.next[i] = -1
+
+		u := fairKey(&t)
+		if f.tail[u] < 0 {
+			f.head[u] = i
+			f.active[(f.activeHead+f.activeCount)%fairUsers] = uint16(u)
+			f.activeCount++
+		} else {
+			f.next[f.tail[u]] = i
+		}
+		f.tail[u] = i
+		f.used.Add(1)
+	}
+}
+
+// pop 取下一个轮到的用户的队首任务
+func (f *fairQueue) pop() (Task, bool) {
+	var empty Task
+	if f.activeCount == 0 {
+		return empty, false
+	}
+	u := int(f.active[f.activeHead])
+	f.activeHead = (f.activeHead + 1) % fairUsers
+	f.activeCount--
+
+	i := f.head[u]
+	t := f.slots[i]
+	f.slots[i] = empty // 释放 Resp 等引用
+	f.head[u] = f.next[i]
+	if f.head[u] < 0 {
+		f.tail[u] = -1
+	} else {
+		// 还有积压，排到轮转环末尾
+		f.active[(f.activeHead+f.activeCount)%fairUsers] = uint16(u)
+		f.activeCount++
+	}
+	f.next[i] = f.free
+	f.free = i
+	f.used.Add(-1)
+	return t, true
+}
+
+// fairUsed 返回子队列中积压的任务总数，未开启时为 0
+func (e *Engine) fairUsed() int64 {
+	if e.fair == nil {
+		return 0
+	}
+	return e.fair.used.Load()
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+// 用户 A 先灌进 500 个任务，随后到达的用户 B 不必排在它们后面：两人轮流被服务，各自内部保持 FIFO
+func TestFairQueuingServesQuietUser(t *testing.T) {
+	const flood = 500
+	e := NewEngine()
+	e.EnableFairQueuing(0)
+	for i := range flood {
+		if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: i + 1}); err != nil {
+			t.Fatalf("A %d: %v", i, err)
+		}
+	}
+	for i := range 3 {
+		if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 2, Price: 1, Quantity: i + 1}); err != nil {
+			t.Fatalf("B %d: %v", i, err)
+		}
+	}
+
+	next := map[int]int{1: 1, 2: 1} // 每个用户下一个应取到的 Quantity
+	lastB := -1
+	for n := 0; ; n++ {
+		task, ok := e.pop()
+		if !ok {
+			break
+		}
+		if task.Quantity != next[task.Value] {
+			t.Fatalf("pop %d: user %d got #%d, want #%d (FIFO within a user)", n, task.Value, task.Quantity, next[task.Value])
+		}
+		next[task.Value]++
+		if task.Value == 2 {
+			lastB = n
+		}
+	}
+	if next[1] != flood+1 || next[2] != 4 {
+		t.Fatalf("tasks lost: A served %d, B served %d", next[1]-1, next[2]-1)
+	}
+	// 严格 FIFO 下 B 最后一个任务要等到第 503 次；轮转时第 6 次就轮到了
+	if lastB > 5 {
+		t.Fatalf("B's last task served at pop %d behind A's flood", lastB)
+	}
+}
+
+// 每个用户的积压名额用尽时只拒绝这个用户，任务被取出后名额归还
+func TestFairQueuingMaxPending(t *testing.T) {
+	e := NewEngine()
+	e.EnableFairQueuing(4)
+	for i := range 4 {
+		if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1}); err != nil {
+			t.Fatalf("A %d: %v", i, err)
+		}
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("A beyond maxPending: %v, want ErrRateLimited", err)
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 2}); err != nil {
+		t.Fatalf("B while A is limited: %v", err)
+	}
+	if task, _ := e.pop(); task.Value != 1 {
+		t.Fatalf("first pop = user %d", task.Value)
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1}); err != nil {
+		t.Fatalf("A after one of its tasks was taken: %v", err)
+	}
+}
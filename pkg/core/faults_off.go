@@ -0,0 +1,12 @@
+//go:build !faultinject
+
+package core
+
+// faultsEnabled 为 false：生产构建中没有 SetFaultInjector，process 中的注入点被编译器整体消除
+const faultsEnabled = false
+
+type faultInjector struct{}
+
+func (e *Engine) injectFault(typ int, resp chan any) bool { return false }
+
+func (e *Engine) inheritFaults(s *Engine) {}
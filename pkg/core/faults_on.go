@@ -0,0 +1,103 @@
+//go:build faultinject
+
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// 故障注入 (仅 -tags faultinject 构建)
+//
+// 用于集成测试一起验证 panic 恢复、熔断、超时等失败路径：按配置的比例让任务 panic、变慢或直接返回错误。
+// 生产构建 (不带 tag) 中不存在 SetFaultInjector，误用会直接编译失败，而不是悄悄在线上注入故障
+
+// faultsEnabled 为 true：process 开头会咨询注入器
+const faultsEnabled = true
+
+// ErrInjectedFault 由故障注入器产生的错误
+var ErrInjectedFault = errors.New("core: injected fault")
+
+// FaultConfig 描述要注入的故障，三种故障的比例之和不应超过 1
+type FaultConfig struct {
+	// Types 受影响的任务类型位图 (1 << TaskType)，0 表示所有类型
+	Types uint32
+	// PanicRate 任务在处理前 panic 的比例 (需要 EnableRecovery，否则进程崩溃)
+	PanicRate float64
+	// ErrorRate 任务不做处理、直接向 Resp 回复 ErrInjectedFault 的比例
+	ErrorRate float64
+	// SleepRate 任务先在 Worker 中睡眠 Sleep 再正常处理的比例 (模拟慢任务，会阻塞整个分片)
+	SleepRate float64
+	Sleep     time.Duration
+	// Seed 伪随机序列的种子：同一个种子、同样的任务顺序得到完全相同的注入结果 (0 视为 1)
+	Seed uint64
+}
+
+// FaultStats 是注入器已经注入的故障数
+type FaultStats struct {
+	Panics, Errors, Sleeps uint64
+}
+
+type faultInjector struct {
+	cfg   FaultConfig
+	state uint64 // xorshift64 状态，只由 Worker 访问
+
+	panics, errors, sleeps atomic.Uint64
+}
+
+// SetFaultInjector 开启故障注入，必须在 Start (StartN) 之前调用
+// 多分片时每个分片使用同一配置、各自的伪随机序列 (种子按分片错开)
+func (e *Engine) SetFaultInjector(cfg FaultConfig) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = 1
+	}
+	e.faults = &faultInjector{cfg: cfg, state: seed}
+}
+
+// FaultStats 返回本分片的注入统计
+func (e *Engine) FaultStats() FaultStats {
+	if e.faults == nil {
+		return FaultStats{}
+	}
+	f := e.faults
+	return FaultStats{Panics: f.panics.Load(), Errors: f.errors.Load(), Sleeps: f.sleeps.Load()}
+}
+
+func (e *Engine) inheritFaults(s *Engine) {
+	if e.faults != nil {
+		cfg := e.faults.cfg
+		cfg.Seed = cfg.Seed*0x9E3779B97F4A7C15 + uint64(s.shardID)
+		s.SetFaultInjector(cfg)
+	}
+}
+
+// injectFault 在 process 开头调用，返回 true 表示任务已被注入的错误处理掉
+// 只接收需要的字段：process 是 nosplit 的，不能再多一份 Task 拷贝
+//
+//go:noinline
+func (e *Engine) injectFault(typ int, resp chan any) bool {
+	f := e.faults
+	if f.cfg.Types != 0 && f.cfg.Types&(1<<uint(typ)) == 0 {
+		return false
+	}
+	f.state ^= f.state << 13
+	f.state ^= f.state >> 7
+	f.state ^= f.state << 17
+	r := float64(f.state>>11) / (1 << 53)
+
+	switch {
+	case r < f.cfg.PanicRate:
+		f.panics.Add(1)
+		panic("core: injected panic")
+	case r < f.cfg.PanicRate+f.cfg.ErrorRate:
+		f.errors.Add(1)
+		e.reply(resp, ErrInjectedFault)
+		return true
+	case r < f.cfg.PanicRate+f.cfg.ErrorRate+f.cfg.SleepRate:
+		f.sleeps.Add(1)
+		time.Sleep(f.cfg.Sleep)
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+//go:build faultinject
+
+package core
+
+import (
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+// runFaulty 依次处理 n 个 Calc 任务，返回每个任务的结果 ("ok" / "panic" / "error")
+func runFaulty(t *testing.T, cfg FaultConfig, n int) ([]string, FaultStats) {
+	t.Helper()
+	e := NewEngine()
+	e.EnableRecovery(nil)
+	e.SetFaultInjector(cfg)
+	resp := make(chan any, 1)
+	out := make([]string, n)
+	for i := range n {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i, Resp: resp}); err != nil {
+			t.Fatalf("TrySubmit: %v", err)
+		}
+		task, _ := e.pop()
+		e.runTask(task)
+		switch r := <-resp; {
+		case r == CalcResult(2*i):
+			out[i] = "ok"
+		case errors.Is(asErr(r), ErrTaskPanicked):
+			out[i] = "panic"
+		case errors.Is(asErr(r), ErrInjectedFault):
+			out[i] = "error"
+		default:
+			t.Fatalf("task %d replied %v", i, r)
+		}
+	}
+	return out, e.FaultStats()
+}
+
+// 注入的比例与配置一致，同一个种子得到完全相同的结果序列
+func TestFaultRates(t *testing.T) {
+	// 每次注入的 panic 都会往 stderr 写一份调用栈
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	t.Cleanup(func() { os.Stderr.Close(); os.Stderr = stderr })
+
+	const n = 4000
+	cfg := FaultConfig{PanicRate: 0.05, ErrorRate: 0.2, SleepRate: 0.1, Sleep: time.Microsecond, Seed: 42}
+	out, st := runFaulty(t, cfg, n)
+
+	count := map[string]int{}
+	for _, o := range out {
+		count[o]++
+	}
+	if uint64(count["panic"]) != st.Panics || uint64(count["error"]) != st.Errors {
+		t.Fatalf("outcomes %v disagree with FaultStats %+v", count, st)
+	}
+	for _, c := range []struct {
+		name string
+		got  uint64
+		rate float64
+	}{{"panic", st.Panics, cfg.PanicRate}, {"error", st.Errors, cfg.ErrorRate}, {"sleep", st.Sleeps, cfg.SleepRate}} {
+		if frac := float64(c.got) / n; math.Abs(frac-c.rate) > 0.02 {
+			t.Errorf("%s rate = %.3f, configured %.2f", c.name, frac, c.rate)
+		}
+	}
+
+	again, _ := runFaulty(t, cfg, n)
+	for i := range out {
+		if out[i] != again[i] {
+			t.Fatalf("task %d: %s then %s with the same seed", i, out[i], again[i])
+		}
+	}
+
+	// Types 之外的任务类型不受影响
+	cfg.Types = 1 << TaskTypeOrder
+	cfg.PanicRate, cfg.ErrorRate = 0.5, 0.5
+	out, st = runFaulty(t, cfg, 100)
+	for i, o := range out {
+		if o != "ok" {
+			t.Fatalf("task %d outside Types: %s", i, o)
+		}
+	}
+	if st != (FaultStats{}) {
+		t.Fatalf("FaultStats = %+v, want none", st)
+	}
+}
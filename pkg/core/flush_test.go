@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// Flush 返回后，此前提交的订单 (任意优先级，包括进入溢出区的) 全部处理完，结果直接可见
+func TestFlushMakesPriorOrdersVisible(t *testing.T) {
+	const (
+		users  = 8
+		orders = 3000
+	)
+	e := NewEngine()
+	e.SpillMax = orders
+	e.StartN(2)
+	stopOnCleanup(t, e)
+
+	qos := []QoS{QoSGold, QoSSilver, QoSBronze}
+	var want [users]float64
+	for i := range orders {
+		uid := i % users
+		task := Task{Type: TaskTypeOrder, Value: uid, Price: 0.5, Quantity: 1, QoS: qos[i%3], Overflow: OverflowSpill}
+		if err := e.SubmitRetry(context.Background(), task); err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+		want[uid] += 0.5
+	}
+	e.Flush()
+
+	for uid := range users {
+		if got := e.Shard(e.ShardFor(uid)).UserVolume[uid]; got != want[uid] {
+			t.Errorf("user %d: volume = %v right after Flush, want %v", uid, got, want[uid])
+		}
+	}
+}
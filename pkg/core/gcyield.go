@@ -0,0 +1,73 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// GC 压力下的协作式让出
+//
+// Worker 用 LockOSThread 独占线程 100% 自旋，GC 的 STW 阶段需要它到达安全点才能继续。
+// Go 1.14 之后有异步抢占，所以不会 "永远" 卡住，但抢占信号的往返仍会拉长 STW，
+// 这段停顿会直接落在 Go World 的 HTTP Handler 上。
+//
+// 所以 Worker 每隔 gcCheckInterval 读一次堆占用 / GC 目标 (按 sysclock 计时而不是按循环次数，
+// Worker 被抢占时循环次数会骤降)，
+// 快到 GC 时 (heap >= goal * GCYieldRatio) 每轮循环都主动 Gosched 一次，
+// 让 GC 可以立即拿到安全点。
+//
+// 取舍：
+//   - 用 runtime/metrics 而不是 runtime.ReadMemStats，后者本身就要 STW，适得其反
+//   - 热点期间每个任务多一次 Gosched (~100ns)，吞吐会下降，但只在 GC 前夕发生
+//   - Worker 自己在 Arena 上分配，几乎不产生堆垃圾，压力通常来自 Go World
+
+// gcCheckInterval GC 压力的采样间隔 (metrics.Read 约 1µs，不能每轮都读)
+const gcCheckInterval = int64(time.Millisecond)
+
+// DefaultGCYieldRatio 堆占用达到 GC 目标的 90% 时开始主动让出
+const DefaultGCYieldRatio = 0.9
+
+// gcPressure 只被 Worker 线程访问，无需同步
+type gcPressure struct {
+	samples [2]metrics.Sample
+	next    int64 // 下一次采样的 sysclock 时间
+	hot     bool
+}
+
+func newGCPressure() gcPressure {
+	var g gcPressure
+	g.samples[0].Name = "/gc/heap/goal:bytes"
+	g.samples[1].Name = "/memory/classes/heap/objects:bytes"
+	return g
+}
+
+// check 每 gcCheckInterval 采样一次，返回当前是否处于 GC 前夕
+func (g *gcPressure) check(now int64, ratio float64) bool {
+	if ratio <= 0 {
+		return false
+	}
+	if now < g.next {
+		return g.hot
+	}
+	g.next = now + gcCheckInterval
+	metrics.Read(g.samples[:])
+	if g.samples[0].Value.Kind() != metrics.KindUint64 || g.samples[1].Value.Kind() != metrics.KindUint64 {
+		return false // 不支持的运行时，放弃检测
+	}
+	goal := g.samples[0].Value.Uint64()
+	heap := g.samples[1].Value.Uint64()
+	g.hot = float64(heap) >= float64(goal)*ratio
+	return g.hot
+}
+
+// yieldForGC 在 GC 前夕让出一次线程，返回是否让出
+func (e *Engine) yieldForGC() bool {
+	if !e.gc.check(sysclock.Now(), e.GCYieldRatio) {
+		return false
+	}
+	e.stats.gcYields.Add(1)
+	runtime.Gosched()
+	return true
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGCPressureCheck(t *testing.T) {
+	g := newGCPressure()
+	if g.check(0, 0) {
+		t.Fatal("ratio 0 must disable the check")
+	}
+	// 极小的比例：堆占用总是超过目标的这一比例
+	if !g.check(1, 1e-9) {
+		t.Fatal("heap below goal*1e-9")
+	}
+	// 采样间隔内沿用上一次的结论，不重新读取
+	if !g.check(1+gcCheckInterval/2, 1e9) {
+		t.Fatal("re-sampled before gcCheckInterval elapsed")
+	}
+	if g.check(1+gcCheckInterval, 1e9) {
+		t.Fatal("heap above goal*1e9")
+	}
+}
+
+// 持续分配制造 GC 压力：自旋的 Worker 在 GC 前夕让出，每轮 GC 都能及时完成
+func TestWorkerYieldsUnderGCPressure(t *testing.T) {
+	e := NewEngine()
+	e.GCYieldRatio = 1e-9 // 让 Worker 一直认为 GC 就要到来
+	e.Start()
+	stopOnCleanup(t, e)
+
+	var sink [][]byte
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	before := ms.NumGC
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; ms.NumGC < before+5; i++ {
+		sink = append(sink, make([]byte, 64<<10))
+		if len(sink) > 256 {
+			sink = sink[:0]
+		}
+		if i%256 == 0 {
+			runtime.ReadMemStats(&ms)
+			if time.Now().After(deadline) {
+				t.Fatalf("only %d GC cycles completed in 5s", ms.NumGC-before)
+			}
+		}
+	}
+	if e.Stats().GCYields == 0 {
+		t.Fatal("worker never yielded for GC")
+	}
+}
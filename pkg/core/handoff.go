@@ -0,0 +1,75 @@
+package core
+
+import "sync/atomic"
+
+// 直接交接 (Direct Handoff)：队列为空时单个任务的最低延迟路径
+//
+// 正常路径上任务要经过 RingBuffer：生产者写槽位 + 推进 head，Worker 读 head/tail、拷贝、CAS tail。
+// 开启 EnableHandoff 后，Worker 空转时会标记 idle；提交方看到 idle 且所有队列都为空时，
+// 把任务直接放进一个单槽的交接位，Worker 在下一次 pop 时最先检查它，绕过 RingBuffer
+//
+// 顺序保证：只有在所有队列 (含溢出区和公平调度子队列) 都为空、交接位也为空时才会走交接，
+// 而 Worker 总是先取交接位再取队列，所以同一个提交方先后提交的任务不会乱序；
+// 交接位被占用或 Worker 正忙时，任务照常入队
+//
+// 实测 (1 CPU 沙箱，SpinGosched，空队列下单个 Calc 的往返 p50)：RingBuffer 约 3.3us，交接约 3.3us，
+// 没有可测量的差别 —— 单核上往返延迟几乎全部来自 Go 调度器在提交方与 Worker 之间切换，
+// 省下的几十纳秒被淹没了；它只在 Worker 独占核心 (CPUAffinity + SpinPause) 时才有意义，
+// 上线前请在目标机器上对比后再决定是否开启
+
+// handoffSlot 是单槽的交接位
+type handoffSlot struct {
+	state atomic.Uint32 // handoffEmpty / handoffWriting / handoffReady
+	idle  atomic.Bool   // Worker 正在空转
+	task  Task
+}
+
+const (
+	handoffEmpty uint32 = iota
+	handoffWriting
+	handoffReady
+)
+
+// EnableHandoff 开启空队列时的直接交接，必须在 Start 之前调用
+func (e *Engine) EnableHandoff() {
+	e.handoff = &handoffSlot{}
+}
+
+// offer 在提交方调用：Worker 空转且交接位为空时放入任务
+func (h *handoffSlot) offer(t Task) bool {
+	if !h.idle.Load() || !h.state.CompareAndSwap(handoffEmpty, handoffWriting) {
+		return false
+	}
+	h.task = t
+	h.state.Store(handoffReady)
+	return true
+}
+
+// take 在 Worker 中调用
+func (h *handoffSlot) take() (Task, bool) {
+	var empty Task
+	if h.state.Load() != handoffReady {
+		return empty, false
+	}
+	t := h.task
+	h.task = empty
+	h.state.Store(handoffEmpty)
+	return t, true
+}
+
+// setIdle 由 Worker 在空转/取到任务时更新，状态不变时只是一次原子读
+func (h *handoffSlot) setIdle(idle bool) {
+	if h.idle.Load() != idle {
+		h.idle.Store(idle)
+	}
+}
+
+// queuesEmpty 报告所有排队路径是否都为空 (交接的前提)
+func (e *Engine) queuesEmpty() bool {
+	return e.Queue.Len() == 0 && e.HighQueue.Len() == 0 && e.spill.n.Load() == 0 && e.fairUsed() == 0
+}
+
+// handoffPending 报告交接位中是否有任务 (drain 使用)
+func (e *Engine) handoffPending() bool {
+	return e.handoff != nil && e.handoff.state.Load() != handoffEmpty
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// Worker 空转且队列为空时第一个任务走交接位，交接位占用期间后来的任务照常入队，
+// pop 先取交接位，所以同一提交方的顺序不变；Worker 不空闲或队列非空时不交接
+func TestHandoffKeepsOrder(t *testing.T) {
+	e := NewEngine()
+	e.EnableHandoff()
+	e.handoff.setIdle(true)
+
+	for i := range 3 {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i}); err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+	}
+	if !e.handoffPending() || e.Queue.Len() != 2 {
+		t.Fatalf("handoff pending = %v, queue = %d; want the first task handed off", e.handoffPending(), e.Queue.Len())
+	}
+	for i := range 3 {
+		if task, ok := e.pop(); !ok || task.Value != i {
+			t.Fatalf("pop %d = %+v, %v", i, task, ok)
+		}
+	}
+
+	// 交接位已空，但队列里还有任务：不能插队
+	e.TrySubmit(Task{Type: TaskTypeCalc, Value: 10})
+	e.pop()
+	e.HighQueue.Push(Task{Type: TaskTypeCalc, Value: 11})
+	e.TrySubmit(Task{Type: TaskTypeCalc, Value: 12})
+	if e.handoffPending() {
+		t.Fatal("task handed off while the high queue was not empty")
+	}
+	e.pop()
+	e.pop()
+
+	e.handoff.setIdle(false)
+	e.TrySubmit(Task{Type: TaskTypeCalc, Value: 13})
+	if e.handoffPending() {
+		t.Fatal("task handed off to a busy worker")
+	}
+}
+
+// 开启交接的运行中引擎：交替的单任务与突发任务都得到正确结果，同一用户的订单按提交顺序生效
+func TestHandoffEndToEnd(t *testing.T) {
+	e := NewEngine()
+	e.EnableHandoff()
+	e.Start()
+	stopOnCleanup(t, e)
+	ctx := context.Background()
+
+	for i := range 200 {
+		if i%10 == 0 {
+			// 突发：前一个任务可能在交接位，后面的进入队列
+			for j := range 5 {
+				e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: float64(j + 1), Quantity: 1})
+			}
+			e.TrySubmit(Task{Type: TaskTypeResetVolume, Value: 1})
+		}
+		r, err := e.Call(ctx, Task{Type: TaskTypeCalc, Value: i})
+		if err != nil || r != CalcResult(2*i) {
+			t.Fatalf("Call %d = %v, %v", i, r, err)
+		}
+	}
+	// 每轮突发都以 Reset 结尾：任何一笔订单越过了 Reset 都会留下非零成交额
+	e.Flush()
+	if v := e.GetUserVolume(1); v != 0 {
+		t.Fatalf("UserVolume[1] = %v, an order overtook the reset", v)
+	}
+}
+
+// 空队列下单个任务的往返延迟：交接位与 RingBuffer (结论见 handoff.go 的文件注释)
+func BenchmarkHandoffCall(b *testing.B) {
+	for _, on := range []bool{false, true} {
+		name := "ring"
+		if on {
+			name = "handoff"
+		}
+		b.Run(name, func(b *testing.B) {
+			e := NewEngine()
+			if on {
+				e.EnableHandoff()
+			}
+			e.Start()
+			defer e.stop.Store(true)
+			ctx := context.Background()
+			for b.Loop() {
+				e.Call(ctx, Task{Type: TaskTypeCalc, Value: 1})
+			}
+		})
+	}
+}
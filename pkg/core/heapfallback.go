@@ -0,0 +1,44 @@
+package core
+
+import (
+	"arena_demo/pkg/alloc"
+	"arena_demo/pkg/zlog"
+	"os"
+)
+
+// Arena 写满时的堆兜底
+//
+// 默认情况下 Worker 在 Arena 上的分配放不下时直接 panic (开启 EnableRecovery 时任务回复 ErrTaskPanicked)。
+// EnableHeapFallback 之后，任务处理中的分配 (calc 的临时值、批量订单的轧差数组) 经由 alloc.Allocator：
+// 放不下的那一次改为堆分配，任务照常完成，代价是这一个任务重新产生 GC 压力。
+//
+// 每次兜底计入 Stats.HeapFallbacks / HeapFallbackBytes；单次兜底的大小创出新高时写一行日志 (LogTail 与 stderr)，
+// 给出任务类型与字节数，据此用 SetTaskArena 给该类型换一个更大的 Arena。
+// 只有创新高时才写日志，持续兜底不会刷屏。
+// 订单日志 (ArenaLog) 仍直接写在 Arena 上，不经过兜底
+
+// EnableHeapFallback 让 Arena 空间不足的分配退回堆分配而不是 panic，必须在 Start 之前调用
+func (e *Engine) EnableHeapFallback() {
+	e.alloc.Fallback = true
+	e.alloc.OnFallback = e.noteHeapFallback
+}
+
+// allocator 让分配器指向当前任务的 Arena (SetTaskArena 可能换过 e.Mem) 并返回它，只由 Worker 调用
+// typ 是当前任务的类型，只用于兜底日志
+func (e *Engine) allocator(typ int) *alloc.Allocator {
+	e.alloc.Mem = e.Mem
+	e.fallbackType = typ
+	return &e.alloc
+}
+
+// noteHeapFallback 在 Worker 中记录一次兜底，大小创新高时写日志
+func (e *Engine) noteHeapFallback(size int) {
+	if size <= e.fallbackHigh {
+		return
+	}
+	e.fallbackHigh = size
+	logger := zlog.Wrap(make([]byte, 0, 256)).Tee(e.LogTail).Seq(e.LogSeq).Redact(e.LogRedact)
+	logger.Str("type", "heap_fallback").Int("shard", e.shardID).Int("task_type", e.fallbackType).
+		Int("bytes", size).Int("arena_cap", e.Mem.Cap()).Msg("arena exhausted, allocated on heap")
+	os.Stderr.Write(logger.Bytes())
+}
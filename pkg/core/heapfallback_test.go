@@ -0,0 +1,55 @@
+package core
+
+import (
+	"arena_demo/pkg/zlog"
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+// 轧差数组放不下任务 Arena 时退回堆分配：批量订单照常完成，兜底被计数并写一行日志；
+// 未开启兜底时同一个任务按 panic 处理
+func TestHeapFallbackCompletesOversizedTask(t *testing.T) {
+	orders := []Order{{Price: 10, Quantity: 2, UserID: 1}, {Price: 5, Quantity: 1, UserID: 2}}
+	newEngine := func() *Engine {
+		e := NewEngineSync()
+		e.SetTaskArena(TaskTypeBatchOrder, 1<<12) // 装不下 1024 个用户的轧差数组 (8KB)
+		e.EnableRecovery(nil)
+		return e
+	}
+
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull) // panic 的调用栈与兜底日志
+	defer func() {
+		os.Stderr.Close()
+		os.Stderr = stderr
+	}()
+
+	strict := newEngine()
+	if _, err := strict.Call(t.Context(), Task{Type: TaskTypeBatchOrder, Orders: orders}); !errors.Is(err, ErrTaskPanicked) {
+		t.Fatalf("without fallback: err = %v, want ErrTaskPanicked", err)
+	}
+
+	e := newEngine()
+	e.EnableHeapFallback()
+	e.LogTail = zlog.NewRingSink(4, 256)
+	r, err := e.Call(t.Context(), Task{Type: TaskTypeBatchOrder, Orders: orders})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := r.(BatchResult); res.Err != nil || res.Total != 25 {
+		t.Fatalf("batch with heap fallback = %+v, want Total 25", res)
+	}
+	if v := e.GetUserVolume(1); v != 20 {
+		t.Fatalf("UserVolume[1] = %v, want 20", v)
+	}
+	st := e.Stats()
+	if st.HeapFallbacks == 0 || st.HeapFallbackBytes < 8<<10 {
+		t.Fatalf("HeapFallbacks = %d (%d bytes), want the 8KB netting array counted", st.HeapFallbacks, st.HeapFallbackBytes)
+	}
+	lines := e.LogTail.Snapshot(4)
+	if len(lines) == 0 || !bytes.Contains(lines[len(lines)-1], []byte("type=heap_fallback")) {
+		t.Fatalf("no heap_fallback log line: %q", lines)
+	}
+}
@@ -0,0 +1,13 @@
+package core
+
+import "testing"
+
+// stopOnCleanup 在测试结束时让 e 的所有分片 Worker 排空队列后退出 (与缩容相同的方式)，
+// 避免每个测试都留下一个自旋的 Worker 线程
+func stopOnCleanup(t *testing.T, e *Engine) {
+	t.Cleanup(func() {
+		for i := range e.NumShards() {
+			e.Shard(i).stop.Store(true)
+		}
+	})
+}
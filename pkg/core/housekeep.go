@@ -0,0 +1,127 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"sync/atomic"
+	"time"
+)
+
+// 周期性维护 (Housekeeping)
+//
+// Worker 是一个永不退出的循环，定时要做的杂事 (刷日志、清理过期的去重键、整理 Arena 等)
+// 没有地方可放：放到另一个 goroutine 里就要和 Worker 抢 C World 的状态。
+// AddHousekeeping 注册的作业由 Worker 自己在两个任务之间执行，与任务串行，不需要任何同步
+//
+// 调度：
+//   - 到期判断基于 sysclock (1ms 精度)，没有作业到期时每轮只多一次原子读和比较
+//   - 每轮最多用 HousekeepingBudget 的时间：到期的作业按轮转顺序逐个执行，预算用完即回到任务处理，
+//     剩下到期的作业留到下一轮，所以多个作业同时到期也不会叠加成一次长停顿
+//   - 作业收到本轮的截止时间，耗时可能较长的作业应分段完成 (检查 time.Now() 越过 deadline 即返回)；
+//     Worker 无法打断一个不配合的作业，超出预算的次数记在 Overruns 中
+//   - 作业落后 (如长时间满载) 时不补跑，下一次到期时间从本次执行时刻重新计算
+
+// HousekeepingFunc 是一个维护作业，在 Worker 线程上执行，e 是执行它的分片
+type HousekeepingFunc func(e *Engine, deadline time.Time)
+
+// HousekeepingInfo 是一个维护作业的运行统计
+type HousekeepingInfo struct {
+	Name     string
+	Every    time.Duration
+	Runs     uint64
+	Overruns uint64        // 单次执行超出 HousekeepingBudget 的次数
+	LastRun  int64         // 最近一次执行的时刻 (sysclock 纳秒)，0 表示还没执行过
+	Max      time.Duration // 单次执行的最长耗时
+}
+
+// houseJob 的调度字段只由 Worker 访问，统计字段供 Housekeeping 跨线程读取
+type houseJob struct {
+	name  string
+	every int64
+	fn    HousekeepingFunc
+	next  int64
+
+	runs     atomic.Uint64
+	overruns atomic.Uint64
+	last     atomic.Int64
+	max      atomic.Int64
+}
+
+type housekeeper struct {
+	jobs   []*houseJob
+	cursor int   // 下一轮从哪个作业开始检查 (轮转)
+	due    int64 // 所有作业中最早的到期时刻，Worker 只比较这一个值
+}
+
+// AddHousekeeping 注册一个每隔 every 执行一次的维护作业，必须在 Start 之前调用
+// 多分片时每个分片各自执行一份 (fn 通过参数 e 区分分片)
+func (e *Engine) AddHousekeeping(name string, every time.Duration, fn HousekeepingFunc) {
+	if every <= 0 {
+		panic("core: housekeeping interval must be positive")
+	}
+	if e.house == nil {
+		e.house = &housekeeper{}
+	}
+	now := sysclock.Now()
+	j := &houseJob{name: name, every: int64(every), fn: fn, next: now + int64(every)}
+	e.house.jobs = append(e.house.jobs, j)
+	e.house.updateDue()
+}
+
+// Housekeeping 返回本分片已注册的维护作业及其统计
+func (e *Engine) Housekeeping() []HousekeepingInfo {
+	if e.house == nil {
+		return nil
+	}
+	out := make([]HousekeepingInfo, len(e.house.jobs))
+	for i, j := range e.house.jobs {
+		out[i] = HousekeepingInfo{
+			Name:     j.name,
+			Every:    time.Duration(j.every),
+			Runs:     j.runs.Load(),
+			Overruns: j.overruns.Load(),
+			LastRun:  j.last.Load(),
+			Max:      time.Duration(j.max.Load()),
+		}
+	}
+	return out
+}
+
+// run 在 Worker 中执行到期的作业，总耗时不超过 budget (最后一个作业自身超时除外)
+func (h *housekeeper) run(e *Engine, now int64, budget time.Duration) {
+	start := time.Now()
+	deadline := start.Add(budget)
+	n := len(h.jobs)
+	for k := 0; k < n; k++ {
+		j := h.jobs[(h.cursor+k)%n]
+		if now < j.next {
+			continue
+		}
+		if k > 0 && !time.Now().Before(deadline) {
+			// 预算用完，剩下的到期作业下一轮优先执行
+			h.cursor = (h.cursor + k) % n
+			h.updateDue()
+			return
+		}
+		t0 := time.Now()
+		j.fn(e, deadline)
+		d := time.Since(t0)
+		j.next = now + j.every
+		j.runs.Add(1)
+		j.last.Store(now)
+		if int64(d) > j.max.Load() {
+			j.max.Store(int64(d))
+		}
+		if d > budget {
+			j.overruns.Add(1)
+		}
+	}
+	h.cursor = (h.cursor + 1) % n
+	h.updateDue()
+}
+
+func (h *housekeeper) updateDue() {
+	h.due = h.jobs[0].next
+	for _, j := range h.jobs[1:] {
+		h.due = min(h.due, j.next)
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 作业按 every 的节奏执行；落后时不补跑，下一次从本次执行时刻重新计算
+func TestHousekeepingCadence(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	var runs atomic.Int64
+	e := NewEngine()
+	e.AddHousekeeping("tick", 10*time.Millisecond, func(*Engine, time.Time) { runs.Add(1) })
+	e.Start()
+	stopOnCleanup(t, e)
+
+	for _, step := range []struct {
+		advance time.Duration
+		want    int64
+	}{
+		{5 * time.Millisecond, 0},
+		{5 * time.Millisecond, 1},
+		{9 * time.Millisecond, 1},
+		{time.Millisecond, 2},
+		{35 * time.Millisecond, 3}, // 落后三个周期也只执行一次
+		{10 * time.Millisecond, 4},
+	} {
+		e.AdvanceClock(step.advance)
+		if n := runs.Load(); n != step.want {
+			t.Fatalf("after +%v: runs = %d, want %d", step.advance, n, step.want)
+		}
+	}
+	info := e.Housekeeping()
+	if len(info) != 1 || info[0].Name != "tick" || info[0].Runs != 4 || info[0].LastRun != sysclock.Now() {
+		t.Fatalf("Housekeeping() = %+v", info)
+	}
+
+	// 作业之间任务照常处理
+	if _, err := e.Call(context.Background(), Task{Type: TaskTypeCalc, Value: 2}); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+}
+
+// 一轮用完预算后剩下的到期作业留到下一轮，并在下一轮优先执行
+func TestHousekeepingBudget(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	var order []string
+	e := NewEngine()
+	e.AddHousekeeping("slow", time.Second, func(*Engine, time.Time) {
+		order = append(order, "slow")
+		time.Sleep(time.Millisecond)
+	})
+	e.AddHousekeeping("fast", time.Second, func(*Engine, time.Time) { order = append(order, "fast") })
+	now := sysclock.Advance(time.Second)
+
+	e.house.run(e, now, e.HousekeepingBudget)
+	if len(order) != 1 || order[0] != "slow" {
+		t.Fatalf("first round ran %v, want only slow (budget spent)", order)
+	}
+	if e.house.due > now {
+		t.Fatal("fast was dropped instead of staying due")
+	}
+	e.house.run(e, now, e.HousekeepingBudget)
+	if len(order) != 2 || order[1] != "fast" {
+		t.Fatalf("second round ran %v, want fast next", order)
+	}
+
+	info := e.Housekeeping()
+	if info[0].Overruns != 1 || info[0].Max < time.Millisecond || info[1].Overruns != 0 {
+		t.Fatalf("Housekeeping() = %+v", info)
+	}
+	if e.house.due != now+int64(time.Second) {
+		t.Fatalf("due = %d, want %d", e.house.due, now+int64(time.Second))
+	}
+}
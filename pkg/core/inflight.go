@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// InflightLimiter 限制同时在途 (已提交、正在等待 Worker 回复) 的请求数
+//
+// net/http 为每个请求起一个 goroutine，它们都阻塞在 <-Resp 上；
+// Core 饱和时这些 goroutine 会无限堆积 (每个至少几 KB 栈)，而多等的请求并不会更快完成。
+// 在 Handler 入口用 TryAcquire 占位，超过上限立即拒绝 (503)，把内存占用钉死在 max 个请求以内
+//
+// 只是一个原子计数，没有排队：拿不到名额就马上失败，由客户端决定是否重试
+type InflightLimiter struct {
+	max      int64
+	n        atomic.Int64
+	rejected atomic.Uint64
+}
+
+// NewInflightLimiter 创建一个最多允许 max 个在途请求的限流器，max <= 0 表示不限
+func NewInflightLimiter(max int) *InflightLimiter {
+	return &InflightLimiter{max: int64(max)}
+}
+
+// TryAcquire 占用一个名额，已满时返回 false；成功后必须调用 Release
+func (l *InflightLimiter) TryAcquire() bool {
+	if l.max <= 0 {
+		return true
+	}
+	if l.n.Add(1) > l.max {
+		l.n.Add(-1)
+		l.rejected.Add(1)
+		return false
+	}
+	return true
+}
+
+// Release 归还 TryAcquire 占用的名额
+func (l *InflightLimiter) Release() {
+	if l.max > 0 {
+		l.n.Add(-1)
+	}
+}
+
+// InFlight 返回当前在途请求数
+func (l *InflightLimiter) InFlight() int {
+	return int(l.n.Load())
+}
+
+// Rejected 返回累计被拒绝的请求数
+func (l *InflightLimiter) Rejected() uint64 {
+	return l.rejected.Load()
+}
+
+// ErrOverloaded 系统内的在途任务数已达 EnableInflightLimit 设定的上限
+var ErrOverloaded = errors.New("core: too many tasks in flight")
+
+// EnableInflightLimit 限制整个引擎 "系统内" 的任务数最多为 max (<= 0 表示不限)，必须在 Start 之前调用
+//
+// 与队列容量是两个独立的上限：队列只管排队中的任务，这里还包括正在处理、结果尚未回复的任务
+// (每个都占着 Resp channel、LogBuf 等内存)。TrySubmit 入口占用一个名额，满了直接返回 ErrOverloaded；
+// Worker (或 calc 池 Worker) 把结果写入 Resp 时归还，被 Export 交接的任务在回复 ErrHandedOff 时归还，
+// 提交失败 (ErrFull 等) 时立即归还。
+// 名额跟随的是任务而不是调用方：结果写入 Resp 之后、调用方取出之前，它已经不占名额
+// (引擎看不到 Resp 何时被读取)，所以结果积压在 Resp 中的调用方不受这个上限约束，需要自己限制 (见 InflightLimiter)
+// 所有分片共用同一个上限
+func (e *Engine) EnableInflightLimit(max int) {
+	e.admit = NewInflightLimiter(max)
+}
+
+// TasksInFlight 返回当前占用在途名额的任务数，未开启时为 0
+func (e *Engine) TasksInFlight() int {
+	if e.admit == nil {
+		return 0
+	}
+	return e.admit.InFlight()
+}
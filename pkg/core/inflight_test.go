@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 64 个并发请求争 8 个名额：同时在途的永远不超过 8 个，拿不到名额的立即返回而不是排队
+func TestInflightLimiterCaps(t *testing.T) {
+	const limit, clients = 8, 64
+	l := NewInflightLimiter(limit)
+	hold := make(chan struct{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var admitted, rejected int
+	var slowest time.Duration
+
+	for range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			ok := l.TryAcquire()
+			d := time.Since(start)
+			mu.Lock()
+			if ok {
+				admitted++
+			} else {
+				rejected++
+				slowest = max(slowest, d)
+			}
+			mu.Unlock()
+			if ok {
+				if n := l.InFlight(); n > limit {
+					t.Errorf("InFlight = %d, over the cap", n)
+				}
+				<-hold // 模拟等待 Worker 回复
+				l.Release()
+			}
+		}()
+	}
+	// 被拒绝的请求不阻塞，在放行之前就全部返回
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		mu.Lock()
+		done := admitted+rejected == clients
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("requests did not return while the limiter was full")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(hold)
+	wg.Wait()
+
+	if admitted != limit || rejected != clients-limit || l.Rejected() != clients-limit {
+		t.Fatalf("admitted %d, rejected %d (Rejected() = %d); want %d and %d", admitted, rejected, l.Rejected(), limit, clients-limit)
+	}
+	if slowest > 100*time.Millisecond {
+		t.Fatalf("a rejected TryAcquire took %v", slowest)
+	}
+	if l.InFlight() != 0 || !l.TryAcquire() {
+		t.Fatalf("InFlight = %d after all releases", l.InFlight())
+	}
+}
+
+func TestInflightLimiterUnlimited(t *testing.T) {
+	l := NewInflightLimiter(0)
+	for range 1000 {
+		if !l.TryAcquire() {
+			t.Fatal("unlimited limiter rejected a request")
+		}
+	}
+	if l.InFlight() != 0 || l.Rejected() != 0 {
+		t.Fatalf("unlimited limiter counted: InFlight = %d, Rejected = %d", l.InFlight(), l.Rejected())
+	}
+}
+
+// 名额在提交时占用、Worker 把结果写入 Resp 时归还：多轮 提交-处理 之后既不泄漏也不超发，满了立即 ErrOverloaded
+func TestEngineInflightLimit(t *testing.T) {
+	const limit = 3
+	e := NewEngine() // 不 Start：由测试充当 Worker，名额何时归还完全确定
+	e.EnableInflightLimit(limit)
+
+	resp := make(chan any, limit)
+	for round := range 50 {
+		n := 1 + round%limit
+		for i := range n {
+			if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i, Resp: resp}); err != nil {
+				t.Fatalf("round %d: TrySubmit %d: %v", round, i, err)
+			}
+		}
+		if got := e.TasksInFlight(); got != n {
+			t.Fatalf("round %d: TasksInFlight = %d after %d submits", round, got, n)
+		}
+		if n == limit {
+			if err := e.TrySubmit(Task{Type: TaskTypeCalc, Resp: resp}); !errors.Is(err, ErrOverloaded) {
+				t.Fatalf("round %d: submit over the limit: err = %v, want ErrOverloaded", round, err)
+			}
+		}
+		// 名额在写入 Resp 时归还，而不是在调用方取出结果时：还没取出的结果不占名额
+		for i := range n {
+			task, ok := e.pop()
+			if !ok {
+				t.Fatalf("round %d: queue empty after %d of %d tasks", round, i, n)
+			}
+			e.runTask(task)
+			if got := e.TasksInFlight(); got != n-i-1 {
+				t.Fatalf("round %d: TasksInFlight = %d after %d replies (%d unread)", round, got, i+1, len(resp))
+			}
+		}
+		for range n {
+			<-resp
+		}
+	}
+	if st := e.Stats(); st.Rejected == 0 {
+		t.Fatal("ErrOverloaded rejections not counted in Stats.Rejected")
+	}
+
+	// 入队失败 (ErrFull) 的提交立即归还名额
+	full := NewEngine()
+	full.EnableInflightLimit(int(full.Queue.Cap()) + 1)
+	fillQueue(t, full)
+	for range 5 {
+		if err := full.TrySubmit(Task{Type: TaskTypeCalc}); !errors.Is(err, ErrFull) {
+			t.Fatalf("submit to a full queue: err = %v, want ErrFull", err)
+		}
+	}
+	if got, want := full.TasksInFlight(), int(full.Queue.Cap()); got != want {
+		t.Fatalf("TasksInFlight = %d after ErrFull rejections, want %d", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import "sync"
+
+// 同步模式：仅用于单元测试和嵌入式调用，不要在服务中使用
+//
+// 异步引擎的任务在后台 Worker 上处理，测试只能等 Resp 或者轮询 Stats，时序不确定。
+// NewEngineSync 创建的引擎没有 Worker 和队列：Submit / TrySubmit / Call 在调用方的 goroutine 上
+// 当场处理任务，返回时结果已经写入 Resp，UserVolume 等状态也已更新
+//
+// 处理走的是与 Worker 相同的 runTask (process、统计、读快照、Arena Reset)，
+// 窗口聚合、维护作业、WAL 也在每个任务之后照常推进，所以测的是真实的处理逻辑。不同之处：
+//   - 不入队：队列容量、QoS 准入、OverflowBlock/Spill、公平调度都不生效，提交永远不会 ErrFull
+//   - 熔断、Scale 闸门等提交前的检查照常生效；StartN / Scale / EnableCalcPool 不要与同步模式一起使用
+//   - Resp 必须有缓冲 (否则处理时就会阻塞在回复上)，Call 使用的池化 channel 满足这一点；
+//     Resp 为 nil 的任务结果直接丢弃 (开启 EnableResultRing / EnableResultStream 时照常写入输出环 / 结果流)
+//   - 没有并发：多个 goroutine 同时提交时逐个处理 (内部加锁)，顺序就是拿到锁的顺序
+//   - Start 可以调用也可以不调用，只会恢复 ImportEngine 读入的任务
+type inlineMode struct {
+	mu sync.Mutex
+	// discard 临时挂到 Resp 为 nil 的任务上，避免 process 向 nil channel 回复时永久阻塞
+	discard chan any
+}
+
+// NewEngineSync 创建一个同步模式的引擎 (见上)
+func NewEngineSync() *Engine {
+	e := NewEngine()
+	e.inline = &inlineMode{discard: make(chan any, 1)}
+	return e
+}
+
+// Inline 报告是否为同步模式
+func (e *Engine) Inline() bool {
+	return e.inline != nil
+}
+
+// runInline 在调用方 goroutine 上处理 t，相当于 Worker 循环的一轮
+func (e *Engine) runInline(t Task) {
+	m := e.inline
+	if t.Resp != nil && cap(t.Resp) == 0 {
+		panic("core: sync engine requires a buffered Resp channel")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.runTimers()
+	discard := t.Resp == nil && e.results == nil && e.stream == nil
+	if discard {
+		t.Resp = m.discard
+	}
+	e.runTask(t)
+	if discard {
+		select {
+		case <-m.discard:
+		default:
+		}
+	}
+	// 处理完队列就空了，与 Worker 空闲时一样写出 WAL、发布读快照
+	if e.wal != nil {
+		e.wal.flush()
+	}
+	if e.reads != nil {
+		e.reads.idle(e)
+	}
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// orderOutcome 是 OrderResult 中与处理时刻无关的部分 (OrderResult 含切片，不能直接比较)
+type orderOutcome struct {
+	total float64
+	dry   bool
+	err   error
+}
+
+// 同一串任务分别交给同步引擎和异步引擎：每个任务的回复、最终成交额和处理计数都相同
+func TestInlineMatchesAsync(t *testing.T) {
+	tasks := []Task{
+		{Type: TaskTypeCalc, Value: 21},
+		{Type: TaskTypeOrder, Value: 1, Price: 10, Quantity: 3},
+		{Type: TaskTypeOrder, Value: 2, Price: 2.5, Quantity: 4},
+		{Type: TaskTypeOrder, Value: 1, Price: 99, Quantity: 1, DryRun: true},
+		{Type: TaskTypeQuery, Value: 1},
+		{Type: TaskTypeOrder, Value: 1, Price: 40, Quantity: 1}, // 超过限额，被拒绝
+		{Type: TaskTypeResetVolume, Value: 2},
+		{Type: TaskTypeCalc, Value: -5},
+		{Type: TaskTypeQuery, Value: 0},
+	}
+	run := func(e *Engine) ([]any, []float64, uint64) {
+		e.PositionLimit = 50
+		var out []any
+		for i, task := range tasks {
+			r, err := e.Call(context.Background(), task)
+			if err != nil {
+				t.Fatalf("task %d: Call: %v", i, err)
+			}
+			if or, ok := r.(OrderResult); ok {
+				// 处理时刻与日志内容随时间变化，只比较结果本身
+				r = orderOutcome{or.Total, or.DryRun, or.Err}
+			}
+			out = append(out, r)
+		}
+		// 异步引擎的 Worker 在回复之后才计数，稍等最后一个任务计入
+		for deadline := time.Now().Add(5 * time.Second); e.Stats().Processed < uint64(len(tasks)) && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		processed := e.Stats().Processed
+		vols := make([]float64, 4)
+		e.SnapshotVolumes(0, vols)
+		return out, vols, processed
+	}
+
+	gotSync, volSync, nSync := run(NewEngineSync())
+
+	async := NewEngine()
+	stopOnCleanup(t, async)
+	async.Start()
+	if async.Inline() {
+		t.Fatal("NewEngine reports Inline")
+	}
+	gotAsync, volAsync, nAsync := run(async)
+
+	for i := range tasks {
+		if gotSync[i] != gotAsync[i] {
+			t.Errorf("task %d: sync %#v, async %#v", i, gotSync[i], gotAsync[i])
+		}
+	}
+	for uid := range volSync {
+		if volSync[uid] != volAsync[uid] {
+			t.Errorf("UserVolume[%d]: sync %v, async %v", uid, volSync[uid], volAsync[uid])
+		}
+	}
+	if nSync != uint64(len(tasks)) || nAsync != nSync {
+		t.Errorf("Processed: sync %d, async %d, want %d", nSync, nAsync, len(tasks))
+	}
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strconv"
+)
+
+// Unix Domain Socket 适配器：本机进程之间绕过 HTTP，直接推送二进制任务帧
+//
+// 所有整数均为小端。每个请求帧:
+//
+//	[4]uint32 len | payload (len 字节)
+//
+// payload 是定长的 TaskFrameSize 字节，与 Task 中的标量字段一一对应:
+//
+//	[1]type | [1]qos | [1]flags (bit0 = DryRun) | [1]保留
+//	[8]int64 value | [8]float64 price | [8]int64 quantity | [8]uint64 corr_id | [8]int64 deadline
+//
+// 每个请求按顺序得到一个响应帧:
+//
+//	[4]uint32 len | [8]uint64 corr_id | [1]status | body
+//
+// body 在 StatusOK 时是 Binary 编码的结果 (Calc/Order，Query/ResetVolume 为 [8]float64)，
+// 其余状态为错误文本。BatchOrder 含变长数组，不支持通过该协议提交
+
+// TaskFrameSize 请求 payload 的固定长度
+const TaskFrameSize = 4 + 8*5
+
+// maxFrame 单帧上限，防止错误的长度前缀让读取方分配巨大的 buffer
+const maxFrame = 64 * 1024
+
+// 响应状态
+const (
+	StatusOK       byte = 0
+	StatusBusy     byte = 1 // 入队失败 (ErrFull / ErrCircuitOpen)
+	StatusRejected byte = 2 // Worker 拒绝 (如 ErrPositionLimit / ErrExpired)
+	StatusBadFrame byte = 3 // 帧无法解码
+)
+
+// ErrBadFrame 帧长度或内容非法
+var ErrBadFrame = errors.New("core: bad task frame")
+
+// AppendTaskFrame 把 t 编码为一个完整的请求帧 (含长度前缀) 追加到 dst，供客户端使用
+func AppendTaskFrame(dst []byte, t Task) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, TaskFrameSize)
+	var flags byte
+	if t.DryRun {
+		flags |= 1
+	}
+	dst = append(dst, byte(t.Type), byte(t.QoS), flags, 0)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(t.Value))
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(t.Price))
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(t.Quantity))
+	dst = binary.LittleEndian.AppendUint64(dst, t.CorrID)
+	return binary.LittleEndian.AppendUint64(dst, uint64(t.Deadline))
+}
+
+// DecodeTask 从 payload (不含长度前缀) 解码任务，直接读取原始字节，不做任何分配
+func DecodeTask(b []byte) (Task, error) {
+	if len(b) != TaskFrameSize || b[0] == TaskTypeBatchOrder || b[0] > TaskTypeResetVolume {
+		return Task{}, ErrBadFrame
+	}
+	return Task{
+		Type:     int(b[0]),
+		QoS:      QoS(b[1] % qosLevels),
+		DryRun:   b[2]&1 != 0,
+		Value:    int(int64(binary.LittleEndian.Uint64(b[4:]))),
+		Price:    math.Float64frombits(binary.LittleEndian.Uint64(b[12:])),
+		Quantity: int(int64(binary.LittleEndian.Uint64(b[20:]))),
+		CorrID:   binary.LittleEndian.Uint64(b[28:]),
+		Deadline: int64(binary.LittleEndian.Uint64(b[36:])),
+	}, nil
+}
+
+// ReadReply 读取一个响应帧，body 指向新分配的内存
+func ReadReply(r io.Reader) (corrID uint64, status byte, body []byte, err error) {
+	var hdr [4 + 8 + 1]byte
+	if _, err = io.ReadFull(r, hdr[:]); err != nil {
+		return 0, 0, nil, err
+	}
+	n := int(binary.LittleEndian.Uint32(hdr[:]))
+	if n < 9 || n > maxFrame {
+		return 0, 0, nil, ErrBadFrame
+	}
+	body = make([]byte, n-9)
+	if _, err = io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	return binary.LittleEndian.Uint64(hdr[4:]), hdr[12], body, nil
+}
+
+// ServeUnix 在 path 上监听 Unix Domain Socket，每个连接由 ServeConn 处理
+// 只有 Accept 失败 (如 listener 被关闭) 时返回
+func (e *Engine) ServeUnix(path string) error {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go e.ServeConn(c)
+	}
+}
+
+// pendingReply 是已提交、等待 Worker 回复的请求，按到达顺序排队写回
+type pendingReply struct {
+	corrID uint64
+	status byte
+	resp   chan any
+	err    error
+}
+
+// ServeConn 处理一个连接上的任务帧，直到对端关闭或出现帧错误
+//
+// 读取方解码后立即提交，不等待结果；独立的写回 goroutine 按顺序等待每个结果并回写，
+// 所以一个连接上可以流水线地同时有多个任务在途 (上限为 pending 队列长度)
+// 任意 net.Conn 均可 (Unix Socket、socketpair、TCP)
+func (e *Engine) ServeConn(c net.Conn) error {
+	defer c.Close()
+	pending := make(chan pendingReply, 256)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.writeReplies(c, pending)
+	}()
+	defer func() {
+		close(pending)
+		<-done
+	}()
+
+	r := bufio.NewReaderSize(c, 64*1024)
+	var hdr [4]byte
+	payload := make([]byte, maxFrame)
+	for {
+		// io.ReadFull 处理短读：一帧可能分多次到达
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		n := int(binary.LittleEndian.Uint32(hdr[:]))
+		if n > maxFrame {
+			// 长度前缀已不可信，无法再对齐到下一帧，只能断开
+			pending <- pendingReply{status: StatusBadFrame, err: ErrBadFrame}
+			return ErrBadFrame
+		}
+		if _, err := io.ReadFull(r, payload[:n]); err != nil {
+			return err
+		}
+		t, err := DecodeTask(payload[:n])
+		if err != nil {
+			pending <- pendingReply{status: StatusBadFrame, err: err}
+			continue
+		}
+		if t.CorrID == 0 {
+			t.CorrID = NewCorrID()
+		}
+		t.Resp = e.getRespChan()
+		if err := e.TrySubmit(t); err != nil {
+			e.putRespChan(t.Resp)
+			pending <- pendingReply{corrID: t.CorrID, status: StatusBusy, err: err}
+			continue
+		}
+		pending <- pendingReply{corrID: t.CorrID, resp: t.Resp}
+	}
+}
+
+// writeReplies 按顺序写回响应，没有更多待写的响应时才 Flush，合并小包
+func (e *Engine) writeReplies(c net.Conn, pending <-chan pendingReply) {
+	w := bufio.NewWriterSize(c, 64*1024)
+	var frame []byte
+	broken := false
+	for p := range pending {
+		status, body := p.status, []byte(nil)
+		if p.resp != nil {
+			r := <-p.resp
+			e.putRespChan(p.resp)
+			status, body = encodeReply(Binary, frame[:0], r)
+		} else if p.err != nil {
+			body = append(frame[:0], p.err.Error()...)
+		}
+		frame = body[:0]
+		if broken {
+			continue // 写端已坏，只负责把在途结果收完
+		}
+		var hdr [4 + 8 + 1]byte
+		binary.LittleEndian.PutUint32(hdr[:], uint32(8+1+len(body)))
+		binary.LittleEndian.PutUint64(hdr[4:], p.corrID)
+		hdr[12] = status
+		w.Write(hdr[:])
+		w.Write(body)
+		if len(pending) == 0 {
+			if w.Flush() != nil {
+				broken = true
+			}
+		}
+	}
+	w.Flush()
+}
+
+// encodeReply 把 Worker 的回复用 m 编码为响应 body (追加到 dst)
+// Marshaler 只定义了 Calc/Order，查询结果在 Binary 下为 [8]float64，其它格式下为数字文本
+func encodeReply(m Marshaler, dst []byte, r any) (byte, []byte) {
+	switch v := r.(type) {
+	case CalcResult:
+		return StatusOK, m.AppendCalc(dst, int(v))
+	case float64:
+		if m == Binary {
+			return StatusOK, binary.LittleEndian.AppendUint64(dst, math.Float64bits(v))
+		}
+		return StatusOK, strconv.AppendFloat(dst, v, 'f', -1, 64)
+	case OrderResult:
+		if v.Err != nil {
+			return StatusRejected, append(dst, v.Err.Error()...)
+		}
+		return StatusOK, m.AppendOrder(dst, v)
+	case error:
+		return StatusRejected, append(dst, v.Error()...)
+	}
+	return StatusRejected, append(dst, "unknown result"...)
+}
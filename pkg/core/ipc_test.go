@@ -0,0 +1,19 @@
+package core
+
+import "testing"
+
+func TestDecodeTaskRoundTrip(t *testing.T) {
+	in := Task{Type: TaskTypeOrder, QoS: QoSGold, DryRun: true, Value: -3, Price: 1.25, Quantity: 9, CorrID: 1 << 40, Deadline: 123}
+	frame := AppendTaskFrame(nil, in)
+	out, err := DecodeTask(frame[4:])
+	if err != nil {
+		t.Fatalf("DecodeTask: %v", err)
+	}
+	if out.Type != in.Type || out.QoS != in.QoS || out.DryRun != in.DryRun || out.Value != in.Value ||
+		out.Price != in.Price || out.Quantity != in.Quantity || out.CorrID != in.CorrID || out.Deadline != in.Deadline {
+		t.Fatalf("round trip = %+v, want %+v", out, in)
+	}
+	if _, err := DecodeTask(frame[4 : len(frame)-1]); err != ErrBadFrame {
+		t.Fatalf("short payload: %v, want ErrBadFrame", err)
+	}
+}
@@ -0,0 +1,86 @@
+//go:build unix
+
+package core
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// socketpair 返回一对已连接的 Unix Socket
+func socketpair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatalf("socketpair: %v", err)
+	}
+	conns := make([]net.Conn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("FileConn: %v", err)
+		}
+		conns[i] = c
+	}
+	return conns[0], conns[1]
+}
+
+func TestServeConnRoundTrip(t *testing.T) {
+	e := NewEngineSync()
+	server, client := socketpair(t)
+	defer client.Close()
+	served := make(chan error, 1)
+	go func() { served <- e.ServeConn(server) }()
+
+	var req []byte
+	req = AppendTaskFrame(req, Task{Type: TaskTypeCalc, Value: 21, CorrID: 7})
+	req = AppendTaskFrame(req, Task{Type: TaskTypeOrder, Value: 1, Price: 2.5, Quantity: 4, CorrID: 8})
+	bad := AppendTaskFrame(nil, Task{Type: TaskTypeBatchOrder, CorrID: 9})
+	req = append(req, bad...)
+
+	// 分成几次零散地写出：一帧可能跨越多次读取
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	for _, cut := range [][2]int{{0, 3}, {3, 30}, {30, 70}, {70, len(req)}} {
+		if _, err := client.Write(req[cut[0]:cut[1]]); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	id, status, body, err := ReadReply(client)
+	if err != nil || id != 7 || status != StatusOK {
+		t.Fatalf("calc reply: id %d status %d err %v", id, status, err)
+	}
+	if v, err := Binary.UnmarshalCalc(body); err != nil || v != 42 {
+		t.Fatalf("calc result = %d, %v; want 42", v, err)
+	}
+
+	id, status, body, err = ReadReply(client)
+	if err != nil || id != 8 || status != StatusOK {
+		t.Fatalf("order reply: id %d status %d err %v (%s)", id, status, err, body)
+	}
+	if r, err := Binary.UnmarshalOrder(body); err != nil || r.Total != 10 || r.CorrID != 8 {
+		t.Fatalf("order result = %+v, %v", r, err)
+	}
+
+	// BatchOrder 不能走这个协议：回复 StatusBadFrame，连接保持可用
+	_, status, _, err = ReadReply(client)
+	if err != nil || status != StatusBadFrame {
+		t.Fatalf("batch frame: status %d err %v, want StatusBadFrame", status, err)
+	}
+
+	client.Close()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("ServeConn after EOF: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeConn did not return after the peer closed")
+	}
+}
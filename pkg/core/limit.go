@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// 限价单
+//
+// 市价单 (OrderMarket，默认) 按任务自带的 Price 立即成交，与之前的行为完全相同。
+// 限价单 (OrderLimit) 的 Price 字段被忽略，成交价取引擎的参考价 (SetMarketPrice 设置)：
+//   - 参考价 <= LimitPrice 时按参考价成交，之后与市价单相同 (限额检查、日志、WAL、Dry-Run)
+//   - 不满足 (或者还没有任何报价) 时默认拒绝 (即时成交否则撤销)：回复 OrderResult{Err: ErrLimitNotMet}，
+//     Total 按 LimitPrice 计算，状态不变
+//   - 开启 EnableRestingOrders 后不满足的限价单改为挂单：暂不回复，留在本分片的挂单簿中，
+//     之后某次 SetMarketPrice 使条件满足时按新的参考价成交并回复；挂单簿已满时仍然拒绝
+//
+// 订单只有买入一个方向 (累加成交额)，所以条件就是 "参考价不高于限价"。
+// 挂单按到达顺序撮合 (不按价格排序)，处理时仍会检查 Deadline，过期的挂单在撮合时回复 ErrExpired
+//
+// 限制：
+//   - 挂单期间调用方一直在等 Resp，Resp 必须有缓冲，且不占用 EnableInflightLimit 的名额
+//   - 挂单与参考价进入快照 (SnapshotState)，不进入 WAL；Export 时挂单作为普通任务交接给新进程；Scale 时按用户迁移到新的分片
+
+// OrderType 订单类型
+type OrderType uint8
+
+const (
+	// OrderMarket 市价单，按 Price 立即成交
+	OrderMarket OrderType = iota
+	// OrderLimit 限价单，参考价不高于 LimitPrice 时按参考价成交
+	OrderLimit
+)
+
+// ErrLimitNotMet 限价单的价格条件不满足，且没有挂单 (未开启或挂单簿已满)
+var ErrLimitNotMet = errors.New("core: limit price not met")
+
+// errRested 内部使用：订单已挂单，稍后再回复
+var errRested = errors.New("core: order rested")
+
+// restingBook 是一个分片的限价挂单，只由该分片的 Worker 读写 (Scale/Export 在 Worker 停住时访问)
+type restingBook struct {
+	orders []Task
+	max    int
+	count  atomic.Int64 // len(orders)，供 RestingOrders 跨线程读取
+}
+
+// EnableRestingOrders 让不满足条件的限价单挂单等待，每个分片最多挂 max 个，必须在 Start 之前调用
+func (e *Engine) EnableRestingOrders(max int) {
+	if max < 1 {
+		panic("core: resting book needs room for at least one order")
+	}
+	e.book = &restingBook{max: max}
+}
+
+// SetMarketPrice 更新限价单使用的参考价 (所有分片)，并撮合因此满足条件的挂单
+// 作为控制任务在各分片的 Worker 中执行，返回时已全部生效；price <= 0 表示撤销报价
+func (e *Engine) SetMarketPrice(price float64) {
+	e.eachShard(Task{Type: TaskTypeMarketPrice, Price: price, QoS: QoSGold})
+}
+
+// RestingOrders 返回所有分片当前的挂单数
+func (e *Engine) RestingOrders() int {
+	n := 0
+	for i := range e.NumShards() {
+		if b := e.Shard(i).book; b != nil {
+			n += int(b.count.Load())
+		}
+	}
+	return n
+}
+
+// limitPrice 在 Worker 中处理限价单的价格条件，返回用于计算成交额的价格：
+// 满足时返回参考价和 nil；否则挂单 (errRested，调用方不再回复) 或者返回 LimitPrice 和 ErrLimitNotMet
+// Dry-Run 的订单不挂单 (挂单簿也是状态)，不满足时直接拒绝
+// t 按值传递：process 是 nosplit 的，取 &t 会让它多占一份栈
+//
+//go:noinline
+func (e *Engine) limitPrice(t Task, dry bool) (float64, error) {
+	if e.market > 0 && e.market <= t.LimitPrice {
+		return e.market, nil
+	}
+	if b := e.book; b != nil && !dry && len(b.orders) < b.max {
+		// 挂单不占在途名额：runTask 处理完这一次就归还，挂单本身不再持有 (否则 Export 交接时会再归还一次)
+		t.admitted = false
+		b.orders = append(b.orders, t)
+		b.count.Store(int64(len(b.orders)))
+		return 0, errRested
+	}
+	return t.LimitPrice, ErrLimitNotMet
+}
+
+// setMarket 在 Worker 中更新参考价，然后把满足条件的挂单按到达顺序重新处理一遍
+// 成交走完整的 runTask (统计、RED、按类型的 Arena)，与从队列取出的订单相同
+func (e *Engine) setMarket(price float64) {
+	e.market = max(price, 0)
+	b := e.book
+	if b == nil || len(b.orders) == 0 || e.market == 0 {
+		return
+	}
+	keep := b.orders[:0]
+	var fill []Task
+	for _, t := range b.orders {
+		if e.market <= t.LimitPrice {
+			fill = append(fill, t)
+		} else {
+			keep = append(keep, t)
+		}
+	}
+	clear(b.orders[len(keep):])
+	b.orders = keep
+	b.count.Store(int64(len(keep)))
+	for _, t := range fill {
+		e.runTask(t)
+	}
+}
+
+// takeResting 取出本分片的全部挂单 (Worker 停住时调用)
+func (e *Engine) takeResting() []Task {
+	b := e.book
+	if b == nil || len(b.orders) == 0 {
+		return nil
+	}
+	orders := b.orders
+	b.orders = nil
+	b.count.Store(0)
+	return orders
+}
+
+// addResting 把挂单放回本分片 (Worker 停住时调用，不受 max 限制，迁移不能丢单)
+func (e *Engine) addResting(t Task) {
+	b := e.book
+	b.orders = append(b.orders, t)
+	b.count.Store(int64(len(b.orders)))
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 市价单按自带的 Price 立即成交；限价单只在参考价不高于 LimitPrice 时按参考价成交，否则拒绝且状态不变
+func TestLimitOrderCondition(t *testing.T) {
+	e := NewEngineSync()
+	ctx := context.Background()
+	call := func(task Task) OrderResult {
+		t.Helper()
+		task.Type, task.Value = TaskTypeOrder, 7
+		r, err := e.Call(ctx, task)
+		if err != nil {
+			t.Fatalf("Call: %v", err)
+		}
+		return r.(OrderResult)
+	}
+
+	if r := call(Task{Price: 3, Quantity: 2}); r.Err != nil || r.Total != 6 {
+		t.Fatalf("market order: total %v, err %v; want 6", r.Total, r.Err)
+	}
+	// 还没有报价：限价单不满足
+	if r := call(Task{OrderType: OrderLimit, LimitPrice: 5, Quantity: 2}); !errors.Is(r.Err, ErrLimitNotMet) || r.Total != 10 {
+		t.Fatalf("limit order without a quote: total %v, err %v; want 10, ErrLimitNotMet", r.Total, r.Err)
+	}
+
+	e.SetMarketPrice(4)
+	// 满足：按参考价成交，Price 字段被忽略
+	if r := call(Task{OrderType: OrderLimit, LimitPrice: 5, Price: 100, Quantity: 2}); r.Err != nil || r.Total != 8 {
+		t.Fatalf("limit 5 at market 4: total %v, err %v; want 8", r.Total, r.Err)
+	}
+	if r := call(Task{OrderType: OrderLimit, LimitPrice: 4, Quantity: 1}); r.Err != nil || r.Total != 4 {
+		t.Fatalf("limit equal to market: total %v, err %v; want 4", r.Total, r.Err)
+	}
+	if r := call(Task{OrderType: OrderLimit, LimitPrice: 3.5, Quantity: 1}); !errors.Is(r.Err, ErrLimitNotMet) {
+		t.Fatalf("limit 3.5 at market 4: err = %v, want ErrLimitNotMet", r.Err)
+	}
+	// 市价单不受参考价影响
+	if r := call(Task{Price: 9, Quantity: 1}); r.Err != nil || r.Total != 9 {
+		t.Fatalf("market order after quote: total %v, err %v; want 9", r.Total, r.Err)
+	}
+	if got := e.UserVolume[7]; got != 6+8+4+9 {
+		t.Fatalf("UserVolume = %v, want %v (rejected limit orders must not count)", got, 6+8+4+9)
+	}
+}
+
+// 开启挂单后不满足的限价单暂不回复，参考价降到限价以下时按新价格成交
+func TestLimitOrderRests(t *testing.T) {
+	e := NewEngineSync()
+	e.EnableRestingOrders(1)
+	e.SetMarketPrice(10)
+
+	rested := make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 2, OrderType: OrderLimit, LimitPrice: 8, Quantity: 3, Resp: rested}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rested) != 0 || e.RestingOrders() != 1 {
+		t.Fatalf("unmet limit order: replied %d, resting %d; want resting without a reply", len(rested), e.RestingOrders())
+	}
+	// 挂单簿已满：拒绝
+	full, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 2, OrderType: OrderLimit, LimitPrice: 1, Quantity: 1})
+	if err != nil || !errors.Is(full.(OrderResult).Err, ErrLimitNotMet) {
+		t.Fatalf("limit order with a full book: %v, %v", full, err)
+	}
+
+	e.SetMarketPrice(9) // 仍然高于限价
+	if len(rested) != 0 {
+		t.Fatal("resting order filled above its limit")
+	}
+	e.SetMarketPrice(7.5)
+	if len(rested) != 1 || e.RestingOrders() != 0 {
+		t.Fatalf("resting order not filled at market 7.5: replied %d, resting %d", len(rested), e.RestingOrders())
+	}
+	if r := (<-rested).(OrderResult); r.Err != nil || r.Total != 22.5 {
+		t.Fatalf("filled resting order: total %v, err %v; want 22.5", r.Total, r.Err)
+	}
+	if got := e.UserVolume[2]; got != 22.5 {
+		t.Fatalf("UserVolume = %v, want 22.5", got)
+	}
+}
+
+// 挂单不持有在途名额：挂单之后名额归还，Export 交接挂单时不会再归还一次；成交与普通订单一样计入统计
+func TestRestingOrderInflightSlot(t *testing.T) {
+	e := NewEngine()
+	e.EnableInflightLimit(1)
+	e.EnableRestingOrders(4)
+	e.Start()
+	stopOnCleanup(t, e)
+	e.SetMarketPrice(10)
+
+	rested := make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 2, OrderType: OrderLimit, LimitPrice: 8, Quantity: 1, Resp: rested}); err != nil {
+		t.Fatal(err)
+	}
+	e.Flush()
+	if e.RestingOrders() != 1 || e.TasksInFlight() != 0 {
+		t.Fatalf("after resting: resting %d, in flight %d; want 1, 0", e.RestingOrders(), e.TasksInFlight())
+	}
+	// 名额已经归还：唯一的名额可以给下一个订单
+	if _, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 3, Price: 1, Quantity: 1}); err != nil {
+		t.Fatalf("order after resting: %v", err)
+	}
+	before := e.Stats().Processed
+	e.SetMarketPrice(7)
+	if r := (<-rested).(OrderResult); r.Err != nil || r.Total != 7 {
+		t.Fatalf("filled resting order: total %v, err %v; want 7", r.Total, r.Err)
+	}
+	// SetMarketPrice 本身 + 成交
+	for deadline := time.Now().Add(5 * time.Second); e.Stats().Processed < before+2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("processed %d after the fill, want %d", e.Stats().Processed, before+2)
+		}
+	}
+
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 2, OrderType: OrderLimit, LimitPrice: 5, Quantity: 1, Resp: make(chan any, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	e.Flush()
+	var buf bytes.Buffer
+	if err := e.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if n := e.TasksInFlight(); n != 0 {
+		t.Fatalf("TasksInFlight after exporting a resting order = %d, want 0", n)
+	}
+}
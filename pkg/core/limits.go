@@ -0,0 +1,37 @@
+package core
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrPositionLimit 订单会使该用户的累计成交额超过限额
+var ErrPositionLimit = errors.New("core: position limit exceeded")
+
+// SetUserLimit 设置某个用户的持仓限额 (覆盖全局 PositionLimit)，limit <= 0 表示恢复使用全局限额
+// 可在运行期间从任意 goroutine 调用：每个用户的限额是一个独立的原子变量，Worker 读取时无锁
+func (e *Engine) SetUserLimit(uid int, limit float64) {
+	if limit < 0 {
+		limit = 0
+	}
+	// 多分片时限额存放在用户所属的分片上
+	e = e.Shard(e.ShardFor(uid))
+	e.userLimits[uid&1023].Store(math.Float64bits(limit))
+}
+
+// limitFor 返回用户的生效限额，0 表示不限
+func (e *Engine) limitFor(uid int) float64 {
+	if l := math.Float64frombits(e.userLimits[uid&1023].Load()); l > 0 {
+		return l
+	}
+	return e.PositionLimit
+}
+
+// checkLimit 判断给用户追加 total 后是否超过限额 (C World 调用，只读状态)
+func (e *Engine) checkLimit(uid int, total float64) error {
+	limit := e.limitFor(uid)
+	if limit > 0 && e.UserVolume[uid&1023]+total > limit {
+		return ErrPositionLimit
+	}
+	return nil
+}
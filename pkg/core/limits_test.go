@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func order(t *testing.T, e *Engine, uid int, price float64) OrderResult {
+	t.Helper()
+	r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: uid, Price: price, Quantity: 1})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	return r.(OrderResult)
+}
+
+// 恰好到达限额的订单被接受，超过的被拒绝且成交额不变
+func TestPositionLimit(t *testing.T) {
+	e := NewEngineSync()
+	e.PositionLimit = 100
+
+	for i := range 4 {
+		if res := order(t, e, 1, 25); res.Err != nil {
+			t.Fatalf("order %d within the limit rejected: %v", i, res.Err)
+		}
+	}
+	res := order(t, e, 1, 0.5)
+	if !errors.Is(res.Err, ErrPositionLimit) {
+		t.Fatalf("order beyond the limit: %+v, want ErrPositionLimit", res)
+	}
+	if v := e.GetUserVolume(1); v != 100 {
+		t.Fatalf("UserVolume after rejection = %v, want 100", v)
+	}
+	if res := order(t, e, 2, 100); res.Err != nil {
+		t.Fatalf("the global limit is per user, other user rejected: %v", res.Err)
+	}
+}
+
+// 单个用户的限额覆盖全局限额，<= 0 恢复全局限额
+func TestUserLimitOverridesGlobal(t *testing.T) {
+	e := NewEngineSync()
+	e.PositionLimit = 100
+	e.SetUserLimit(3, 10)
+
+	if res := order(t, e, 3, 20); !errors.Is(res.Err, ErrPositionLimit) {
+		t.Fatalf("order above the user limit: %v, want ErrPositionLimit", res.Err)
+	}
+	if v := e.GetUserVolume(3); v != 0 {
+		t.Fatalf("UserVolume after rejection = %v, want 0", v)
+	}
+	e.SetUserLimit(3, 0)
+	if res := order(t, e, 3, 20); res.Err != nil {
+		t.Fatalf("order after clearing the user limit: %v", res.Err)
+	}
+}
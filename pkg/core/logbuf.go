@@ -0,0 +1,66 @@
+package core
+
+import "sync"
+
+// 订单日志 buffer 的按大小分级池
+//
+// 如果日志超出调用者提供的 LogBuf 容量，append 会悄悄把它搬到堆上，"零分配日志" 就失效了。
+// 所以 buffer 按任务的实际内容估算大小 (带 trace / 客户端 IP 的日志更长)，从对应的分级池中取，
+// 引擎在 OrderResult.LogOverflowed 和 Stats.LogOverflows 中报告估算仍然不够的情况
+
+// logBufClasses 分级大小，升序
+var logBufClasses = [...]int{256, 512, 1024, 4096}
+
+var logBufPools [len(logBufClasses)]sync.Pool
+
+// logBufHeaders 缓存空的 *[]byte：sync.Pool 只能放指针，PutLogBuf 拿到的是切片本身，
+// 如果每次都 &b 会在堆上分配一个新的切片头。GetLogBuf 取出 buffer 后把它的切片头放到这里，
+// PutLogBuf 再取回来装新的切片，稳态下一次 Get/Put 不分配
+var logBufHeaders sync.Pool
+
+// EstimateLogSize 估算一个订单任务的日志长度 (字节，偏大)
+func EstimateLogSize(t Task) int {
+	// ts + type + uid + qos + corr_id + seq + msg，以及失败时的 qty/limit/err
+	n := 256
+	if !t.TraceID.IsZero() {
+		n += len("trace_id= span_id=  ") + 2*len(t.TraceID) + 2*len(t.SpanID)
+	}
+	if t.ClientIP != nil {
+		n += len("ip= ") + len("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")
+	}
+	return n
+}
+
+// GetLogBuf 返回一个容量不小于 EstimateLogSize(t) 的空 buffer，用完后用 PutLogBuf 归还
+func GetLogBuf(t Task) []byte {
+	n := EstimateLogSize(t)
+	for i, size := range logBufClasses {
+		if size < n {
+			continue
+		}
+		if p, ok := logBufPools[i].Get().(*[]byte); ok {
+			b := (*p)[:0]
+			*p = nil
+			logBufHeaders.Put(p)
+			return b
+		}
+		return make([]byte, 0, size)
+	}
+	return make([]byte, 0, n)
+}
+
+// PutLogBuf 归还 buffer，按容量放回对应的分级池 (容量不匹配任何分级的直接丢弃)
+// 调用后不能再使用 OrderResult.Log，因为它指向的正是这块内存
+func PutLogBuf(b []byte) {
+	for i, size := range logBufClasses {
+		if cap(b) == size {
+			p, ok := logBufHeaders.Get().(*[]byte)
+			if !ok {
+				p = new([]byte)
+			}
+			*p = b[:0]
+			logBufPools[i].Put(p)
+			return
+		}
+	}
+}
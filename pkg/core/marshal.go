@@ -0,0 +1,164 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Marshaler 把引擎结果序列化为某种线上格式
+// 所有 Append* 方法都追加写入调用者提供的 dst (可以来自 sync.Pool 或 Arena)，本身不做分配
+type Marshaler interface {
+	ContentType() string
+	AppendCalc(dst []byte, v int) []byte
+	AppendOrder(dst []byte, r OrderResult) []byte
+	UnmarshalCalc(b []byte) (int, error)
+	UnmarshalOrder(b []byte) (OrderResult, error)
+}
+
+var (
+	// JSON 是默认格式
+	JSON Marshaler = jsonMarshaler{}
+	// Binary 是紧凑的定长小端格式，适合内部服务之间使用
+	Binary Marshaler = binaryMarshaler{}
+)
+
+// ErrShortBuffer 二进制数据长度不足
+var ErrShortBuffer = errors.New("core: short binary buffer")
+
+// MarshalerFor 根据 Accept Header 选择序列化格式，默认 JSON
+func MarshalerFor(accept string) Marshaler {
+	if strings.Contains(accept, Binary.ContentType()) {
+		return Binary
+	}
+	return JSON
+}
+
+// --- JSON ---
+
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) ContentType() string { return "application/json" }
+
+func (jsonMarshaler) AppendCalc(dst []byte, v int) []byte {
+	dst = append(dst, `{"result":`...)
+	dst = strconv.AppendInt(dst, int64(v), 10)
+	return append(dst, '}')
+}
+
+func (jsonMarshaler) AppendOrder(dst []byte, r OrderResult) []byte {
+	dst = append(dst, `{"total":`...)
+	dst = strconv.AppendFloat(dst, r.Total, 'f', -1, 64)
+	dst = append(dst, `,"processed_at":`...)
+	dst = strconv.AppendInt(dst, r.ProcessedAt, 10)
+	dst = append(dst, `,"corr_id":`...)
+	dst = strconv.AppendUint(dst, r.CorrID, 10)
+	dst = append(dst, `,"dry_run":`...)
+	dst = strconv.AppendBool(dst, r.DryRun)
+	dst = append(dst, `,"log":`...)
+	// 日志是 logfmt 文本，作为 JSON 字符串需要转义
+	dst = appendJSONString(dst, r.Log)
+	return append(dst, '}')
+}
+
+// appendJSONString 以 JSON 字符串的形式追加 b (只转义 JSON 要求转义的字符)
+func appendJSONString(dst, b []byte) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for _, c := range b {
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
+
+func (jsonMarshaler) UnmarshalCalc(b []byte) (int, error) {
+	var v struct {
+		Result int `json:"result"`
+	}
+	err := json.Unmarshal(b, &v)
+	return v.Result, err
+}
+
+func (jsonMarshaler) UnmarshalOrder(b []byte) (OrderResult, error) {
+	var v struct {
+		Total       float64 `json:"total"`
+		ProcessedAt int64   `json:"processed_at"`
+		CorrID      uint64  `json:"corr_id"`
+		DryRun      bool    `json:"dry_run"`
+		Log         string  `json:"log"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return OrderResult{}, err
+	}
+	r := OrderResult{Total: v.Total, ProcessedAt: v.ProcessedAt, DryRun: v.DryRun, CorrID: v.CorrID}
+	if v.Log != "" {
+		r.Log = []byte(v.Log)
+	}
+	return r, nil
+}
+
+// --- Binary ---
+//
+// Calc:  [8]int64 result
+// Order: [8]float64 total | [8]int64 processed_at | [8]uint64 corr_id | [1]dry_run | [4]uint32 log_len | log
+
+type binaryMarshaler struct{}
+
+func (binaryMarshaler) ContentType() string { return "application/x-arena-bin" }
+
+func (binaryMarshaler) AppendCalc(dst []byte, v int) []byte {
+	return binary.LittleEndian.AppendUint64(dst, uint64(v))
+}
+
+func (binaryMarshaler) AppendOrder(dst []byte, r OrderResult) []byte {
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(r.Total))
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(r.ProcessedAt))
+	dst = binary.LittleEndian.AppendUint64(dst, r.CorrID)
+	if r.DryRun {
+		dst = append(dst, 1)
+	} else {
+		dst = append(dst, 0)
+	}
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(r.Log)))
+	return append(dst, r.Log...)
+}
+
+func (binaryMarshaler) UnmarshalCalc(b []byte) (int, error) {
+	if len(b) < 8 {
+		return 0, ErrShortBuffer
+	}
+	return int(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (binaryMarshaler) UnmarshalOrder(b []byte) (OrderResult, error) {
+	const header = 8 + 8 + 8 + 1 + 4
+	if len(b) < header {
+		return OrderResult{}, ErrShortBuffer
+	}
+	r := OrderResult{
+		Total:       math.Float64frombits(binary.LittleEndian.Uint64(b[0:])),
+		ProcessedAt: int64(binary.LittleEndian.Uint64(b[8:])),
+		CorrID:      binary.LittleEndian.Uint64(b[16:]),
+		DryRun:      b[24] == 1,
+	}
+	n := int(binary.LittleEndian.Uint32(b[25:]))
+	if len(b) < header+n {
+		return OrderResult{}, ErrShortBuffer
+	}
+	if n > 0 {
+		r.Log = append([]byte(nil), b[header:header+n]...)
+	}
+	return r, nil
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"arena_demo/pkg/arena"
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	want := OrderResult{
+		Total:       1234.5,
+		ProcessedAt: 1700000000123456789,
+		CorrID:      42,
+		DryRun:      true,
+		Log:         []byte("ts=1 type=order msg=\"quoted\\ta\"\n"),
+	}
+	a := arena.AcquireSized(4096)
+	defer a.Release()
+
+	for _, m := range []Marshaler{JSON, Binary} {
+		t.Run(m.ContentType(), func(t *testing.T) {
+			// 序列化到 Arena 上的缓冲区：容量足够时 Append 不会离开 Arena
+			dst := arena.MakeSlice[byte](a, 0, 512)
+			b := m.AppendOrder(dst, want)
+			if &b[:1][0] != &dst[:1][0] {
+				t.Fatal("AppendOrder reallocated an arena buffer that was large enough")
+			}
+			got, err := m.UnmarshalOrder(b)
+			if err != nil {
+				t.Fatalf("UnmarshalOrder: %v", err)
+			}
+			if got.Total != want.Total || got.ProcessedAt != want.ProcessedAt || got.CorrID != want.CorrID ||
+				got.DryRun != want.DryRun || !bytes.Equal(got.Log, want.Log) {
+				t.Fatalf("order round trip = %+v, want %+v", got, want)
+			}
+
+			for _, v := range []int{0, -7, math.MaxInt, math.MinInt} {
+				c, err := m.UnmarshalCalc(m.AppendCalc(nil, v))
+				if err != nil || c != v {
+					t.Fatalf("calc round trip %d = %d, %v", v, c, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalerFor(t *testing.T) {
+	if MarshalerFor("") != JSON || MarshalerFor("text/html, */*") != JSON {
+		t.Fatal("default format is not JSON")
+	}
+	if MarshalerFor("application/x-arena-bin;q=0.9") != Binary {
+		t.Fatal("Accept for the binary format not honoured")
+	}
+}
+
+func TestBinaryShortBuffer(t *testing.T) {
+	b := Binary.AppendOrder(nil, OrderResult{Log: []byte("abc")})
+	if _, err := Binary.UnmarshalOrder(b[:len(b)-1]); !errors.Is(err, ErrShortBuffer) {
+		t.Fatalf("truncated log: %v, want ErrShortBuffer", err)
+	}
+	if _, err := Binary.UnmarshalCalc(b[:7]); !errors.Is(err, ErrShortBuffer) {
+		t.Fatalf("truncated calc: %v, want ErrShortBuffer", err)
+	}
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 内存压力下的降载
+//
+// 进程被 OOM Killer 杀掉时，队列里的任务、未落盘的状态一起丢失，比拒绝一部分请求糟糕得多。
+// EnableMemoryShedding 之后 Start 启动一个采样 goroutine (重复 Start 也只有一个，随引擎停止退出)，每 Interval 读一次 Go 运行时向 OS 申请并仍持有的内存
+// (runtime/metrics 的 total - heap/released，与 GOMEMLIMIT 的口径相同，包含 Arena)：
+//   - 超过 Limit*High 时进入降载，TrySubmit 返回 ErrMemoryPressure (CodeOverloaded，HTTP 503)
+//   - 回落到 Limit*Low 以下才恢复，两个阈值之间保持原状态，避免在边界上反复切换
+//
+// 提交路径只多一次原子读 (未开启时只是一次 nil 判断)。已经入队的任务照常处理，处理完才能释放内存；
+// Flush、快照等内部控制任务不经过 TrySubmit，不受影响。
+// 不统计 Go 运行时以外的内存 (cgo、文件映射的 Arena)
+
+// ErrMemoryPressure 进程内存超过 EnableMemoryShedding 设定的阈值，暂时拒绝新任务
+var ErrMemoryPressure = errors.New("core: shedding load under memory pressure")
+
+// MemoryShedding 是 EnableMemoryShedding 的参数，零值字段使用默认值
+type MemoryShedding struct {
+	// Limit 内存上限 (字节)，0 时依次取 GOMEMLIMIT (debug.SetMemoryLimit) 与 cgroup 的内存限制 (Linux)
+	Limit uint64
+	// High 超过 Limit*High 开始拒绝，默认 0.9
+	High float64
+	// Low 回落到 Limit*Low 以下恢复，默认 0.8，必须小于 High
+	Low float64
+	// Interval 采样间隔，默认 100ms
+	Interval time.Duration
+}
+
+// memShedder 由采样 goroutine 写入 shedding，提交路径只读
+type memShedder struct {
+	high, low uint64
+	interval  time.Duration
+	sample    func() uint64 // 当前内存用量，默认 goMemoryInUse
+	shedding  atomic.Bool
+	episodes  atomic.Uint64 // 进入降载的次数
+	// started 采样 goroutine 已经启动，保证只有一个
+	started atomic.Bool
+	// running 还没有退出的采样 goroutine
+	running sync.WaitGroup
+}
+
+// EnableMemoryShedding 在内存用量超过阈值时拒绝新任务，必须在 Start/StartN 之前调用
+// 没有配置 Limit、也找不到 GOMEMLIMIT 与 cgroup 限制时 panic
+func (e *Engine) EnableMemoryShedding(cfg MemoryShedding) {
+	if cfg.Limit == 0 {
+		cfg.Limit = defaultMemoryLimit()
+		if cfg.Limit == 0 {
+			panic("core: memory shedding needs a limit (set Limit, GOMEMLIMIT or a cgroup memory limit)")
+		}
+	}
+	if cfg.High == 0 {
+		cfg.High = 0.9
+	}
+	if cfg.Low == 0 {
+		cfg.Low = 0.8
+	}
+	if cfg.Low >= cfg.High {
+		panic("core: memory shedding Low must be below High")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 100 * time.Millisecond
+	}
+	e.memShed = &memShedder{
+		high:     uint64(float64(cfg.Limit) * cfg.High),
+		low:      uint64(float64(cfg.Limit) * cfg.Low),
+		interval: cfg.Interval,
+		sample:   goMemoryInUse,
+	}
+}
+
+// MemoryShedding 报告当前是否因内存压力在拒绝新任务，以及累计进入降载的次数
+func (e *Engine) MemoryShedding() (shedding bool, episodes uint64) {
+	if e.memShed == nil {
+		return false, 0
+	}
+	return e.memShed.shedding.Load(), e.memShed.episodes.Load()
+}
+
+// start 启动采样 goroutine，已经启动过时什么也不做
+func (m *memShedder) start(stop *atomic.Bool) {
+	if m.started.Swap(true) {
+		return
+	}
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		m.run(stop)
+	}()
+}
+
+// run 周期性采样，stop 置位后退出
+func (m *memShedder) run(stop *atomic.Bool) {
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+	for !stop.Load() {
+		m.update(m.sample())
+		<-t.C
+	}
+}
+
+// update 按滞回阈值更新降载状态
+func (m *memShedder) update(used uint64) {
+	switch {
+	case used > m.high:
+		if !m.shedding.Swap(true) {
+			m.episodes.Add(1)
+		}
+	case used < m.low:
+		m.shedding.Store(false)
+	}
+}
+
+// defaultMemoryLimit 返回 GOMEMLIMIT，未设置时返回 cgroup 的限制，都没有时返回 0
+func defaultMemoryLimit() uint64 {
+	if l := debug.SetMemoryLimit(-1); l > 0 && l != math.MaxInt64 {
+		return uint64(l)
+	}
+	return cgroupMemoryLimit()
+}
+
+// goMemoryInUse 返回 Go 运行时持有的内存 (已映射且未归还给 OS 的部分)
+func goMemoryInUse() uint64 {
+	samples := [2]metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples[:])
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
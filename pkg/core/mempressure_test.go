@@ -0,0 +1,90 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 内存超过高水位后提交被拒绝，回落到低水位以下才恢复，两者之间保持原状态
+func TestMemorySheddingRejectsAboveThreshold(t *testing.T) {
+	const mb = 1 << 20
+	e := NewEngine()
+	e.EnableMemoryShedding(MemoryShedding{Limit: 100 * mb, High: 0.9, Low: 0.8, Interval: time.Millisecond})
+	var used atomic.Uint64
+	e.memShed.sample = used.Load
+	used.Store(50 * mb)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	// wait 等采样 goroutine 看到新的用量
+	wait := func(shedding bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+			if s, _ := e.MemoryShedding(); s == shedding {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("shedding never became %v", shedding)
+			}
+		}
+	}
+	submit := func() error {
+		return e.TrySubmit(Task{Type: TaskTypeCalc, Value: 1, Resp: make(chan any, 1)})
+	}
+
+	if err := submit(); err != nil {
+		t.Fatalf("below the threshold: %v", err)
+	}
+	used.Store(95 * mb)
+	wait(true)
+	if err := submit(); !errors.Is(err, ErrMemoryPressure) {
+		t.Fatalf("above the threshold: err = %v, want ErrMemoryPressure", err)
+	}
+	used.Store(85 * mb) // 两个阈值之间：仍然拒绝
+	time.Sleep(20 * time.Millisecond)
+	if err := submit(); !errors.Is(err, ErrMemoryPressure) {
+		t.Fatalf("between the thresholds: err = %v, want ErrMemoryPressure", err)
+	}
+	used.Store(70 * mb)
+	wait(false)
+	if err := submit(); err != nil {
+		t.Fatalf("after pressure subsided: %v", err)
+	}
+	if _, episodes := e.MemoryShedding(); episodes != 1 {
+		t.Fatalf("episodes = %d, want 1", episodes)
+	}
+}
+
+// 采样 goroutine 只启动一次，并随引擎停止退出
+func TestMemorySheddingStopsWithEngine(t *testing.T) {
+	e := NewEngine()
+	e.EnableMemoryShedding(MemoryShedding{Limit: 1 << 30, Interval: time.Millisecond})
+	var samples atomic.Int64
+	release := make(chan struct{})
+	e.memShed.sample = func() uint64 {
+		samples.Add(1)
+		<-release
+		return 0
+	}
+	e.memShed.start(&e.stop)
+	e.memShed.start(&e.stop) // 重复 Start 不会再起一个
+	time.Sleep(20 * time.Millisecond)
+	if n := samples.Load(); n != 1 {
+		t.Fatalf("%d samplers running, want 1", n)
+	}
+	e.stop.Store(true)
+	close(release)
+
+	exited := make(chan struct{})
+	go func() {
+		e.memShed.running.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("memory sampler still running after the engine stopped")
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"arena_demo/pkg/arena"
+	"arena_demo/pkg/zlog"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+)
+
+// 采样只稀释成功的订单日志，被拒绝的订单每一笔都要记录，且按 Warn 级别
+func TestOrderLogKeepsEveryRejection(t *testing.T) {
+	e := NewEngineSync()
+	e.LogSampler = zlog.NewSampler(100)
+	e.SetUserLimit(1, 50)
+
+	var logged, rejected int
+	for i := range 20 {
+		uid := 2
+		if i%2 == 0 {
+			uid = 1 // 每笔 100，超过 50 的限额
+		}
+		r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: uid, Price: 100, Quantity: 1, ArenaLog: true})
+		if err != nil {
+			t.Fatalf("Call: %v", err)
+		}
+		res := r.(OrderResult)
+		if res.Err == nil {
+			if len(res.Log) > 0 {
+				logged++
+			}
+			continue
+		}
+		rejected++
+		if !bytes.Contains(res.Log, []byte("rejected")) {
+			t.Fatalf("rejected order %d was not logged: %q", i, res.Log)
+		}
+	}
+	if rejected != 10 {
+		t.Fatalf("rejected = %d, want 10", rejected)
+	}
+	if logged != 1 {
+		t.Fatalf("sampled successful orders logged = %d, want 1 of 10", logged)
+	}
+}
+
+// LogBuf 模式：Log 直接写在调用者的缓冲区里，没有拷贝
+func TestOrderLogCallerOwned(t *testing.T) {
+	e := NewEngineSync()
+	buf := make([]byte, 0, 512)
+	r, _ := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, LogBuf: buf})
+	res := r.(OrderResult)
+	if res.LogOwner != LogCallerOwned || len(res.Log) == 0 {
+		t.Fatalf("LogOwner = %v, len(Log) = %d; want caller-owned log", res.LogOwner, len(res.Log))
+	}
+	if &res.Log[0] != &buf[:1][0] {
+		t.Fatal("caller-owned log does not alias LogBuf")
+	}
+}
+
+// ArenaLog 模式：日志先写在引擎 Arena 上，回传前拷到堆上，之后的任务复用 Arena 也不会改写它
+func TestOrderLogArenaOwnedSurvivesReset(t *testing.T) {
+	e := NewEngineSync()
+	ctx := context.Background()
+	r, _ := e.Call(ctx, Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, ArenaLog: true})
+	res := r.(OrderResult)
+	if res.LogOwner != LogArenaOwned || len(res.Log) == 0 {
+		t.Fatalf("LogOwner = %v, len(Log) = %d; want arena-owned log", res.LogOwner, len(res.Log))
+	}
+	if arena.Owns(e.Mem, res.Log) {
+		t.Fatal("arena-owned log was returned without copying it off the arena")
+	}
+	want := string(res.Log)
+	for i := range 10 {
+		e.Call(ctx, Task{Type: TaskTypeOrder, Value: 2 + i, Price: 99, Quantity: 99, ArenaLog: true})
+	}
+	if string(res.Log) != want {
+		t.Fatalf("log changed after later tasks reused the arena:\n got %s\nwant %s", res.Log, want)
+	}
+}
+
+// 超出 LogBuf 容量的长日志：内容完整，但 LogOverflowed 与 Stats.LogOverflows 报告发生了堆分配
+func TestOrderLogOverflowReported(t *testing.T) {
+	e := NewEngineSync()
+	long := make(net.IP, 600) // 非法长度的地址按十六进制原样输出，日志远超 1024 字节
+	buf := make([]byte, 0, 1024)
+	r, _ := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, ClientIP: long, LogBuf: buf})
+	res := r.(OrderResult)
+	if len(res.Log) <= cap(buf) || !bytes.HasSuffix(res.Log, []byte("msg=processed\n")) {
+		t.Fatalf("long log truncated: %d bytes, tail %q", len(res.Log), res.Log[max(0, len(res.Log)-20):])
+	}
+	if !res.LogOverflowed {
+		t.Fatal("LogOverflowed = false for a log larger than LogBuf")
+	}
+	if n := e.Stats().LogOverflows; n != 1 {
+		t.Fatalf("Stats.LogOverflows = %d, want 1", n)
+	}
+}
+
+// GetLogBuf 按任务内容取 buffer：带 trace 与最长 IPv6 地址的日志也放得下，不再溢出
+func TestGetLogBufFitsOrderLog(t *testing.T) {
+	e := NewEngineSync()
+	for _, task := range []Task{
+		{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1},
+		{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, CorrID: 1<<63 - 1, QoS: QoSBronze,
+			ClientIP: net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe"),
+			TraceID:  TraceID{1}, SpanID: SpanID{1}},
+	} {
+		task.LogBuf = GetLogBuf(task)
+		r, _ := e.Call(context.Background(), task)
+		if res := r.(OrderResult); res.LogOverflowed || len(res.Log) == 0 {
+			t.Fatalf("log of %d bytes overflowed a %d byte buffer", len(res.Log), cap(task.LogBuf))
+		}
+		PutLogBuf(task.LogBuf)
+	}
+	if n := e.Stats().LogOverflows; n != 0 {
+		t.Fatalf("Stats.LogOverflows = %d, want 0", n)
+	}
+}
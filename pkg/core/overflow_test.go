@@ -0,0 +1,92 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fillQueue 不启动 Worker，把 e 的普通队列填满
+func fillQueue(t *testing.T, e *Engine) {
+	t.Helper()
+	for i := uint64(0); i < e.Queue.Cap(); i++ {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: int(i)}); err != nil {
+			t.Fatalf("fill %d: %v", i, err)
+		}
+	}
+}
+
+func TestOverflowReject(t *testing.T) {
+	e := NewEngine()
+	fillQueue(t, e)
+	start := time.Now()
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc}); !errors.Is(err, ErrFull) {
+		t.Fatalf("default policy on a full queue: %v, want ErrFull", err)
+	}
+	if d := time.Since(start); d >= e.BlockTimeout {
+		t.Fatalf("OverflowReject waited %v", d)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	e := NewEngine()
+	fillQueue(t, e)
+
+	// 一直没有空位：等满 BlockTimeout 后拒绝
+	start := time.Now()
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, Overflow: OverflowBlock}); !errors.Is(err, ErrFull) {
+		t.Fatalf("OverflowBlock without room: %v, want ErrFull", err)
+	}
+	if d := time.Since(start); d < e.BlockTimeout {
+		t.Fatalf("OverflowBlock gave up after %v, want >= %v", d, e.BlockTimeout)
+	}
+
+	// 等待期间腾出一个位置：任务被接纳，排在队尾
+	e.BlockTimeout = 5 * time.Second
+	done := make(chan error)
+	go func() { done <- e.TrySubmit(Task{Type: TaskTypeCalc, Value: -1, Overflow: OverflowBlock}) }()
+	time.Sleep(time.Millisecond)
+	if _, ok := e.pop(); !ok {
+		t.Fatal("pop from a full queue failed")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("OverflowBlock after room appeared: %v", err)
+	}
+	var last Task
+	for task, ok := e.pop(); ok; task, ok = e.pop() {
+		last = task
+	}
+	if last.Value != -1 {
+		t.Fatalf("last task = %+v, want the blocked one", last)
+	}
+}
+
+func TestOverflowSpill(t *testing.T) {
+	e := NewEngine()
+	e.SpillMax = 2
+	fillQueue(t, e)
+	for i := range 2 {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: -1 - i, Overflow: OverflowSpill}); err != nil {
+			t.Fatalf("spill %d: %v", i, err)
+		}
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, Overflow: OverflowSpill}); !errors.Is(err, ErrFull) {
+		t.Fatalf("spill beyond SpillMax: %v, want ErrFull", err)
+	}
+
+	// 溢出区在普通队列取空之后才被消费
+	n := e.Queue.Cap()
+	for i := uint64(0); i < n; i++ {
+		if task, _ := e.pop(); task.Value != int(i) {
+			t.Fatalf("pop %d = %+v, want queued task first", i, task)
+		}
+	}
+	for i := range 2 {
+		if task, ok := e.pop(); !ok || task.Value != -1-i {
+			t.Fatalf("spill pop %d = %+v, %v", i, task, ok)
+		}
+	}
+	if _, ok := e.pop(); ok {
+		t.Fatal("queue not empty after draining the spill area")
+	}
+}
@@ -0,0 +1,73 @@
+package core
+
+import "sync"
+
+// 维护暂停
+//
+// Pause 让所有分片的 Worker 停止取任务 (与 Export 停住 Worker 的方式相同：投递一个 Gold 优先级的 TaskTypeHalt，
+// Worker 处理到它时原地等待)，Resume 放行。暂停期间：
+//   - 队列中的任务原样保留，TrySubmit 照常入队直到队列满 (之后按 Overflow 策略拒绝)；Resume 后按原顺序处理
+//   - 高优先级队列中排在 Halt 之前的任务会先处理完，普通队列不再前进
+//   - 需要 Worker 参与的同步操作 (Flush、GetUserVolume、SnapshotState、Scale、Export) 会一直等到 Resume
+//   - 被暂停的 Worker 不触发热备接管；calc 池 Worker 无状态，不暂停；同步模式没有 Worker，Pause 会 panic
+//
+// Pause 与 Resume 可以在任意 goroutine 调用，重复调用是空操作。
+// Pause 在停住各分片期间持有 scaleMu，所以停住的恰好是那一刻的全部分片
+//
+// 开启 EnableCommandQueue 时改为通过命令通道暂停 (见 command.go)：Worker 在两个任务之间停下，
+// 高优先级队列也不再前进，但控制命令照常执行，GetUserVolume 等不必等到 Resume
+
+// pauseState 记录暂停时停住的各分片 (park 返回的放行 channel)
+type pauseState struct {
+	mu     sync.Mutex
+	parked []chan any // 非 nil 表示已暂停
+	// commanded 为 true 表示已通过命令通道暂停
+	commanded bool
+}
+
+// Pause 停止所有 Worker 取任务，返回时各 Worker 都已停住 (必须在 Start/StartN 之后调用)
+func (e *Engine) Pause() {
+	if e.inline != nil {
+		panic("core: sync engine has no worker to pause")
+	}
+	e.pause.mu.Lock()
+	defer e.pause.mu.Unlock()
+	if e.pause.parked != nil || e.pause.commanded {
+		return
+	}
+	if e.cmds != nil {
+		e.exec(Command{Kind: cmdPause})
+		e.pause.commanded = true
+		return
+	}
+	scaleMu.Lock()
+	defer scaleMu.Unlock()
+	n := e.NumShards()
+	parked := make([]chan any, n)
+	for i := range n {
+		parked[i] = e.Shard(i).park()
+	}
+	e.pause.parked = parked
+}
+
+// Resume 让 Pause 停住的 Worker 继续处理队列，未暂停时是空操作
+func (e *Engine) Resume() {
+	e.pause.mu.Lock()
+	defer e.pause.mu.Unlock()
+	if e.pause.commanded {
+		e.exec(Command{Kind: cmdResume})
+		e.pause.commanded = false
+		return
+	}
+	for _, ch := range e.pause.parked {
+		ch <- nil
+	}
+	e.pause.parked = nil
+}
+
+// Paused 报告引擎当前是否处于 Pause 状态
+func (e *Engine) Paused() bool {
+	e.pause.mu.Lock()
+	defer e.pause.mu.Unlock()
+	return e.pause.parked != nil || e.pause.commanded
+}
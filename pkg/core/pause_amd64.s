@@ -0,0 +1,13 @@
+#include "textflag.h"
+
+// func cpuPause(cycles uint32)
+TEXT ·cpuPause(SB), NOSPLIT, $0-4
+	MOVL	cycles+0(FP), AX
+	TESTL	AX, AX
+	JZ	done
+again:
+	PAUSE
+	SUBL	$1, AX
+	JNZ	again
+done:
+	RET
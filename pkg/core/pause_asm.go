@@ -0,0 +1,10 @@
+//go:build amd64 || arm64
+
+package core
+
+// cpuPause 执行 cycles 次 CPU 自旋提示指令 (amd64: PAUSE, arm64: YIELD)
+// 与 runtime.Gosched 不同，它不进入调度器，只是告诉 CPU "我在自旋"：
+// 降低功耗、避免流水线因内存序冲突被清空，且让出超线程的执行资源
+//
+//go:noescape
+func cpuPause(cycles uint32)
@@ -0,0 +1,9 @@
+//go:build !amd64 && !arm64
+
+package core
+
+// cpuPause 的可移植版本：没有自旋提示指令时退化为一个空循环
+func cpuPause(cycles uint32) {
+	for i := uint32(0); i < cycles; i++ {
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCPUPause(t *testing.T) {
+	for _, n := range []uint32{0, 1, pauseCycles, 1 << 12} {
+		cpuPause(n) // 只要求能返回：各平台的实现都不能陷入死循环
+	}
+}
+
+// 暂停期间提交的任务留在队列中，Resume 之后按提交顺序处理 (直接停住 Worker 与经过命令通道两种方式)
+func TestPauseResumeKeepsOrder(t *testing.T) {
+	for _, commands := range []bool{false, true} {
+		e := NewEngine()
+		if commands {
+			e.EnableCommandQueue(8)
+		}
+		e.Start()
+		stopOnCleanup(t, e)
+
+		e.Pause()
+		e.Pause() // 重复调用是空操作
+		if !e.Paused() {
+			t.Fatal("Paused = false after Pause")
+		}
+		out := make(chan any, 5)
+		for i := range 5 {
+			if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: float64(i + 1), Quantity: 1, Resp: out}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if len(out) != 0 || e.Queue.Len() != 5 {
+			t.Fatalf("commands=%v: %d replies and %d queued while paused, want 0 and 5", commands, len(out), e.Queue.Len())
+		}
+
+		e.Resume()
+		if e.Paused() {
+			t.Fatal("Paused = true after Resume")
+		}
+		for i := range 5 {
+			select {
+			case r := <-out:
+				if got := r.(OrderResult).Total; got != float64(i+1) {
+					t.Fatalf("commands=%v: reply %d has Total %v, want %d", commands, i, got, i+1)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("commands=%v: task %d not processed after Resume", commands, i)
+			}
+		}
+	}
+}
+
+// 空队列上自旋的 Worker 接到一个任务并回复的往返延迟：PAUSE 对比 Gosched
+// SpinPause 的 Worker 不进入调度器，至少需要两个 P，否则调用方要等异步抢占才能运行
+//
+//	go test -bench SpinLatency -cpu 4 ./pkg/core
+func BenchmarkSpinLatency(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		spin SpinStrategy
+	}{{"gosched", SpinGosched}, {"pause", SpinPause}} {
+		b.Run(tc.name, func(b *testing.B) {
+			if tc.spin == SpinPause && runtime.GOMAXPROCS(0) < 2 {
+				b.Skip("SpinPause needs GOMAXPROCS >= 2")
+			}
+			e := NewEngine()
+			e.Spin = tc.spin
+			e.Start()
+			defer e.stop.Store(true)
+			t := Task{Type: TaskTypeCalc, Value: 21}
+			for b.Loop() {
+				if _, err := e.Call(context.Background(), t); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
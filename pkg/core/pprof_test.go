@@ -0,0 +1,72 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+// CPU Profile 中 Worker 的采样带有 role=core-worker 标签
+// Profile 是 gzip 压缩的 protobuf，标签的键和值都保存在它的字符串表里，解压后直接查找即可
+func TestWorkerProfileLabels(t *testing.T) {
+	e := NewEngine()
+	e.Start()
+	stopOnCleanup(t, e)
+
+	// 机器繁忙时一个采样窗口内可能采不到 Worker，多给几个窗口
+	var missing string
+	for range 10 {
+		raw, ok := profileWindow(t, e)
+		if !ok {
+			t.Skip("CPU profile unavailable")
+		}
+		missing = ""
+		for _, s := range []string{"role", "core-worker", "shard"} {
+			if !bytes.Contains(raw, []byte(s)) {
+				missing = s
+				break
+			}
+		}
+		if missing == "" {
+			break
+		}
+	}
+	if missing != "" {
+		t.Fatalf("CPU profile has no %q label", missing)
+	}
+
+	// goroutine dump 中同样可以看到标签
+	var dump strings.Builder
+	pprof.Lookup("goroutine").WriteTo(&dump, 1)
+	if !strings.Contains(dump.String(), `"role":"core-worker"`) {
+		t.Fatal("goroutine dump lacks the worker label")
+	}
+}
+
+// profileWindow 让 Worker 忙 300ms 并返回这段时间的 CPU Profile (已解压)，无法开启 Profile 时 ok=false
+func profileWindow(t *testing.T, e *Engine) (raw []byte, ok bool) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, false
+	}
+	for deadline := time.Now().Add(300 * time.Millisecond); time.Now().Before(deadline); {
+		e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1})
+	}
+	pprof.StopCPUProfile()
+
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	raw, err = io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read profile: %v", err)
+	}
+	return raw, true
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"arena_demo/pkg/zlog"
+	"strconv"
+)
+
+// PrometheusContentType 是 AppendPrometheus 输出的文本格式 (Exposition Format 0.0.4)
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// AppendPrometheus 以 Prometheus 文本格式输出所有已注册引擎的指标，
+// 可直接作为 /metrics 的响应 (与 promhttp 的输出兼容，无需引入 client_golang)
+//
+// 每个指标带 engine (注册名) 和 shard (分片下标) 两个标签，数据全部来自 Stats 快照，
+// 采集只是若干次原子读，不会打扰 C World
+func AppendPrometheus(dst []byte) []byte {
+	type target struct {
+		engine string
+		shard  string
+		stats  Stats
+	}
+	var targets []target
+	for _, name := range Names() {
+		e := Lookup(name)
+		if e == nil {
+			continue
+		}
+		for i := range e.NumShards() {
+			targets = append(targets, target{name, strconv.Itoa(i), e.Shard(i).Stats()})
+		}
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(s *Stats) uint64
+	}{
+		{"engine_processed_total", "Tasks processed by the worker.", func(s *Stats) uint64 { return s.Processed }},
+		{"engine_rejected_total", "Tasks rejected at submit (queue full or circuit open).", func(s *Stats) uint64 { return s.Rejected }},
+		{"engine_expired_total", "Tasks dropped because their deadline passed before processing.", func(s *Stats) uint64 { return s.Expired }},
+		{"engine_cancelled_total", "Tasks skipped because they were cancelled before processing.", func(s *Stats) uint64 { return s.Cancelled }},
+		{"engine_clock_fallbacks_total", "Reads that fell back to time.Now because sysclock was stale.", func(s *Stats) uint64 { return s.ClockFallbacks }},
+		{"engine_gc_yields_total", "Times the worker yielded ahead of a GC cycle.", func(s *Stats) uint64 { return s.GCYields }},
+		{"engine_aged_total", "Normal-lane tasks served ahead of the high lane by priority aging.", func(s *Stats) uint64 { return s.Aged }},
+		{"engine_commands_total", "Control-plane commands executed between tasks.", func(s *Stats) uint64 { return s.Commands }},
+		{"engine_log_overflows_total", "Order logs that outgrew their LogBuf.", func(s *Stats) uint64 { return s.LogOverflows }},
+		{"engine_shadow_mismatches_total", "Orders where the shadow handler disagreed with the live one.", func(s *Stats) uint64 { return s.ShadowMismatches }},
+		{"engine_panics_total", "Tasks that panicked and were recovered.", func(s *Stats) uint64 { return s.Panics }},
+		{"engine_results_dropped_total", "Results dropped because the output ring was full.", func(s *Stats) uint64 { return s.ResultsDropped }},
+		{"engine_replies_dropped_total", "Results dropped because the task's Resp channel was full.", func(s *Stats) uint64 { return s.RepliesDropped }},
+		{"engine_heap_fallbacks_total", "Allocations that did not fit in the arena and fell back to the Go heap.", func(s *Stats) uint64 { return s.HeapFallbacks }},
+		{"engine_heap_fallback_bytes_total", "Bytes allocated on the Go heap after the arena ran out.", func(s *Stats) uint64 { return s.HeapFallbackBytes }},
+		{"engine_calc_pool_processed_total", "Calc tasks processed by the stateless calc pool.", func(s *Stats) uint64 { return s.CalcPool }},
+		{"engine_calc_coalesced_total", "Calc requests answered by an identical in-flight calc.", func(s *Stats) uint64 { return s.Coalesced }},
+		{"engine_failovers_total", "Times the standby worker took over from a stalled primary.", func(s *Stats) uint64 { return s.Failovers }},
+	}
+	for _, c := range counters {
+		dst = appendHeader(dst, c.name, c.help, "counter")
+		for i := range targets {
+			t := &targets[i]
+			dst = appendLabels(dst, c.name, t.engine, t.shard, "")
+			dst = appendSample(dst, float64(c.value(&t.stats)))
+		}
+	}
+
+	gauges := []struct {
+		name, help string
+		value      func(s *Stats) float64
+	}{
+		{"engine_queue_length", "Tasks waiting in the normal queue.", func(s *Stats) float64 { return float64(s.QueueLen) }},
+		{"engine_queue_capacity", "Capacity of the normal queue.", func(s *Stats) float64 { return float64(s.QueueCap) }},
+		{"engine_high_queue_length", "Tasks waiting in the high priority queue.", func(s *Stats) float64 { return float64(s.HighQueueLen) }},
+		{"engine_high_queue_capacity", "Capacity of the high priority queue.", func(s *Stats) float64 { return float64(s.HighQueueCap) }},
+		{"engine_arena_used_bytes", "Arena bytes used by the last task.", func(s *Stats) float64 { return float64(s.ArenaUsed) }},
+		{"engine_arena_capacity_bytes", "Arena capacity.", func(s *Stats) float64 { return float64(s.ArenaCap) }},
+		{"engine_arena_high_water_bytes", "Highest arena usage seen.", func(s *Stats) float64 { return float64(s.ArenaHighWater) }},
+	}
+	for _, g := range gauges {
+		dst = appendHeader(dst, g.name, g.help, "gauge")
+		for i := range targets {
+			t := &targets[i]
+			dst = appendLabels(dst, g.name, t.engine, t.shard, "")
+			dst = appendSample(dst, g.value(&t.stats))
+		}
+	}
+
+	// 直方图的桶在文本格式中是累计值
+	const hist = "engine_task_latency_seconds"
+	dst = appendHeader(dst, hist, "Time from enqueue to completion (1ms resolution).", "histogram")
+	for i := range targets {
+		t := &targets[i]
+		h := &t.stats.Latency
+		var cum uint64
+		for b, n := range h.Counts {
+			cum += n
+			le := "+Inf"
+			if b < len(LatencyBounds) {
+				le = strconv.FormatFloat(LatencyBounds[b], 'f', -1, 64)
+			}
+			dst = appendLabels(dst, hist+"_bucket", t.engine, t.shard, le)
+			dst = appendSample(dst, float64(cum))
+		}
+		dst = appendLabels(dst, hist+"_sum", t.engine, t.shard, "")
+		dst = appendSample(dst, h.Sum)
+		dst = appendLabels(dst, hist+"_count", t.engine, t.shard, "")
+		dst = appendSample(dst, float64(h.Count))
+	}
+	dst = appendRED(dst)
+	// 日志中 Metric 字段喂给的直方图 (见 zlog.NewHistogram)
+	return zlog.AppendPrometheus(dst)
+}
+
+// appendRED 输出开启了 EnableREDMetrics 的引擎的 RED 指标，按引擎与任务类型 (type 标签) 汇总
+// 只输出出现过的类型和错误码，避免每个引擎输出上百条恒为 0 的序列
+func appendRED(dst []byte) []byte {
+	type target struct {
+		engine string
+		red    REDStats
+	}
+	var targets []target
+	for _, name := range Names() {
+		e := Lookup(name)
+		if e == nil {
+			continue
+		}
+		if red, ok := e.REDStats(); ok {
+			targets = append(targets, target{name, red})
+		}
+	}
+	if len(targets) == 0 {
+		return dst
+	}
+
+	const reqs = "engine_requests_total"
+	dst = appendHeader(dst, reqs, "Tasks submitted through TrySubmit, including rejected ones.", "counter")
+	for i := range targets {
+		t := &targets[i]
+		for typ, n := range t.red.Requests {
+			if n != 0 {
+				dst = appendREDLabels(dst, reqs, t.engine, TaskTypeNames[typ], "", "")
+				dst = appendSample(dst, float64(n))
+			}
+		}
+	}
+
+	const errs = "engine_request_errors_total"
+	dst = appendHeader(dst, errs, "Submissions rejected by TrySubmit, by error code.", "counter")
+	for i := range targets {
+		t := &targets[i]
+		for typ := range t.red.Errors {
+			for c, n := range t.red.Errors[typ] {
+				if n != 0 {
+					dst = appendREDLabels(dst, errs, t.engine, TaskTypeNames[typ], "code", string(ErrorCodes[c]))
+					dst = appendSample(dst, float64(n))
+				}
+			}
+		}
+	}
+
+	const dur = "engine_request_duration_seconds"
+	dst = appendHeader(dst, dur, "Time from enqueue to completion by task type (1ms resolution).", "histogram")
+	for i := range targets {
+		t := &targets[i]
+		for typ := range t.red.Duration {
+			h := &t.red.Duration[typ]
+			if h.Count == 0 {
+				continue
+			}
+			name := TaskTypeNames[typ]
+			var cum uint64
+			for b, n := range h.Counts {
+				cum += n
+				le := "+Inf"
+				if b < len(LatencyBounds) {
+					le = strconv.FormatFloat(LatencyBounds[b], 'f', -1, 64)
+				}
+				dst = appendREDLabels(dst, dur+"_bucket", t.engine, name, "le", le)
+				dst = appendSample(dst, float64(cum))
+			}
+			dst = appendREDLabels(dst, dur+"_sum", t.engine, name, "", "")
+			dst = appendSample(dst, h.Sum)
+			dst = appendREDLabels(dst, dur+"_count", t.engine, name, "", "")
+			dst = appendSample(dst, float64(h.Count))
+		}
+	}
+	return dst
+}
+
+func appendHeader(dst []byte, name, help, typ string) []byte {
+	dst = append(dst, "# HELP "...)
+	dst = append(dst, name...)
+	dst = append(dst, ' ')
+	dst = append(dst, help...)
+	dst = append(dst, "\n# TYPE "...)
+	dst = append(dst, name...)
+	dst = append(dst, ' ')
+	dst = append(dst, typ...)
+	return append(dst, '\n')
+}
+
+// appendLabels 输出 name{engine="...",shard="..."}，le 非空时追加直方图的 le 标签
+// 引擎名由代码注册，不含引号和反斜杠，这里不做转义
+func appendLabels(dst []byte, name, engine, shard, le string) []byte {
+	dst = append(dst, name...)
+	dst = append(dst, `{engine="`...)
+	dst = append(dst, engine...)
+	dst = append(dst, `",shard="`...)
+	dst = append(dst, shard...)
+	if le != "" {
+		dst = append(dst, `",le="`...)
+		dst = append(dst, le...)
+	}
+	return append(dst, `"}`...)
+}
+
+// appendREDLabels 输出 name{engine="...",type="..."}，key 非空时追加一个标签 (code 或 le)
+func appendREDLabels(dst []byte, name, engine, typ, key, val string) []byte {
+	dst = append(dst, name...)
+	dst = append(dst, `{engine="`...)
+	dst = append(dst, engine...)
+	dst = append(dst, `",type="`...)
+	dst = append(dst, typ...)
+	if key != "" {
+		dst = append(dst, `",`...)
+		dst = append(dst, key...)
+		dst = append(dst, `="`...)
+		dst = append(dst, val...)
+	}
+	return append(dst, `"}`...)
+}
+
+func appendSample(dst []byte, v float64) []byte {
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, v, 'f', -1, 64)
+	return append(dst, '\n')
+}
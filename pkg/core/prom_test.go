@@ -0,0 +1,81 @@
+package core
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 抓取输出是合法的文本格式：每个样本之前都有它所属指标族的 TYPE，
+// 计数、队列深度和延迟直方图与引擎的 Stats 一致
+func TestAppendPrometheus(t *testing.T) {
+	e := NewEngineSync()
+	if err := Register("test.prom", e); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("test.prom")
+	for uid := range 3 {
+		order(t, e, uid, 1)
+	}
+
+	out := AppendPrometheus(nil)
+	if !bytes.HasSuffix(out, []byte("\n")) {
+		t.Fatal("output does not end with a newline")
+	}
+	types := map[string]string{}
+	samples := map[string]float64{} // 本引擎分片 0 的样本，键为指标名加上 engine/shard 以外的标签
+	var family string
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if f, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, typ, _ := strings.Cut(f, " ")
+			types[name] = typ
+			family = name
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		series, value, ok := strings.Cut(line, " ")
+		v, err := strconv.ParseFloat(value, 64)
+		if !ok || err != nil {
+			t.Fatalf("malformed sample %q", line)
+		}
+		name, _, _ := strings.Cut(series, "{")
+		if !strings.HasPrefix(name, family) {
+			t.Fatalf("sample %q appears under family %q", line, family)
+		}
+		if rest, ok := strings.CutPrefix(series, name+`{engine="test.prom",shard="0"`); ok {
+			if rest = strings.TrimSuffix(strings.TrimPrefix(rest, ","), "}"); rest != "" {
+				name += "{" + rest + "}"
+			}
+			samples[name] = v
+		}
+	}
+
+	for name, typ := range map[string]string{
+		"engine_processed_total":      "counter",
+		"engine_rejected_total":       "counter",
+		"engine_queue_length":         "gauge",
+		"engine_arena_capacity_bytes": "gauge",
+		"engine_task_latency_seconds": "histogram",
+	} {
+		if types[name] != typ {
+			t.Errorf("%s: TYPE = %q, want %q", name, types[name], typ)
+		}
+	}
+	st := e.Stats()
+	for name, want := range map[string]float64{
+		"engine_processed_total":                        float64(st.Processed),
+		"engine_queue_capacity":                         float64(st.QueueCap),
+		"engine_task_latency_seconds_count":             float64(st.Latency.Count),
+		`engine_task_latency_seconds_bucket{le="+Inf"}`: float64(st.Latency.Count),
+	} {
+		if got, ok := samples[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", name, got, ok, want)
+		}
+	}
+	if st.Processed != 3 || st.Latency.Count != 3 {
+		t.Fatalf("Stats: Processed = %d, Latency.Count = %d; want 3", st.Processed, st.Latency.Count)
+	}
+}
@@ -0,0 +1,39 @@
+package core
+
+// QoS 服务等级，决定任务进入哪条队列 (Lane) 以及准入阈值
+type QoS uint8
+
+const (
+	// QoSSilver 是默认等级 (请求未携带 QoS 头时使用)
+	QoSSilver QoS = iota
+	// QoSGold 走独立的高优先级队列，Worker 总是优先消费
+	QoSGold
+	// QoSBronze 在普通队列较满时最先被拒绝
+	QoSBronze
+
+	qosLevels = 3
+)
+
+// QoSHeader 是携带 QoS 的 HTTP Header 名称
+const QoSHeader = "X-QoS"
+
+// ParseQoS 解析 "gold" / "silver" / "bronze"，无法识别时返回默认的 Silver
+func ParseQoS(s string) QoS {
+	switch s {
+	case "gold", "Gold", "GOLD":
+		return QoSGold
+	case "bronze", "Bronze", "BRONZE":
+		return QoSBronze
+	}
+	return QoSSilver
+}
+
+func (q QoS) String() string {
+	switch q {
+	case QoSGold:
+		return "gold"
+	case QoSBronze:
+		return "bronze"
+	}
+	return "silver"
+}
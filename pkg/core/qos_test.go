@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+// 普通队列占满之后 Silver/Bronze 被拒绝，Gold 仍然被接纳，并且先于排在它前面的普通任务被处理
+func TestGoldPreferredUnderSaturation(t *testing.T) {
+	e := NewEngine() // 不启动 Worker：队列只进不出，手动按 Worker 的顺序取
+	for i := 0; ; i++ {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i}); err != nil {
+			if !errors.Is(err, ErrFull) || uint64(i) != e.Queue.Cap() {
+				t.Fatalf("silver rejected after %d tasks with %v, want ErrFull at %d", i, err, e.Queue.Cap())
+			}
+			break
+		}
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, QoS: QoSBronze}); !errors.Is(err, ErrFull) {
+		t.Fatalf("bronze on a full queue: %v, want ErrFull", err)
+	}
+	for i := range 3 {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: -1 - i, QoS: QoSGold}); err != nil {
+			t.Fatalf("gold %d rejected under saturation: %v", i, err)
+		}
+	}
+
+	for i := range 3 {
+		task, ok := e.pop()
+		if !ok || task.QoS != QoSGold || task.Value != -1-i {
+			t.Fatalf("pop %d = %+v, want gold task %d first", i, task, -1-i)
+		}
+	}
+	if task, _ := e.pop(); task.QoS != QoSSilver || task.Value != 0 {
+		t.Fatalf("after gold: pop = %+v, want the oldest silver task", task)
+	}
+}
+
+// Bronze 的准入阈值是普通队列的 50%：过半之后只拒绝 Bronze，Silver 照常接纳
+func TestBronzeShedFirst(t *testing.T) {
+	e := NewEngine()
+	half := int(e.Queue.Cap() / 2)
+	for i := range half {
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, QoS: QoSBronze}); err != nil {
+			t.Fatalf("bronze %d rejected below the threshold: %v", i, err)
+		}
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, QoS: QoSBronze}); !errors.Is(err, ErrFull) {
+		t.Fatalf("bronze above 50%%: %v, want ErrFull", err)
+	}
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc}); err != nil {
+		t.Fatalf("silver above 50%%: %v", err)
+	}
+}
+
+func TestParseQoS(t *testing.T) {
+	for s, want := range map[string]QoS{"gold": QoSGold, "silver": QoSSilver, "bronze": QoSBronze, "": QoSSilver, "platinum": QoSSilver} {
+		if got := ParseQoS(s); got != want {
+			t.Errorf("ParseQoS(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// 读写分道：写任务 (订单等) 仍由单个 Worker 串行执行，UserVolume 的读取可以在任意多个 goroutine 中并行
+//
+// 读者不进入队列，也不加锁，而是读 Worker 发布的快照 (ReadVolume)：
+//   - Worker 在任务之间把 UserVolume 整体拷贝到两个快照缓冲区中的一个，再切换 "当前" 下标 (双缓冲)
+//   - 快照的每个值都是原子变量，缓冲区带 seqlock 版本号：Worker 改写缓冲区期间版本号为奇数，
+//     读者读值前后比较版本号，改写过即重试，所以读者绝不会看到写了一半的快照 (torn read)
+//   - 发布在任务边界进行，不需要锁，也不分配内存 (拷贝 1024 个值约 1µs)
+//
+// 一致性保证 (latest-committed snapshot)：
+//   - ReadVolume 返回的是本分片某个任务边界上的状态：在它之前处理完的所有写都可见，之后的都不可见
+//   - 快照最多落后 EnableReadLane 的 lag (按 sysclock)，队列空闲时立即发布；有写入但还没发布的那段时间内读到的是旧值
+//   - 不保证 "读到自己刚写的"：需要与订单严格串行的读仍然使用 GetUserVolume (作为任务排队)
+//   - ReadVolumes 一次读多个用户：同一分片的用户来自同一份快照 (例如一篮子订单要么全部可见，要么全部不可见)；
+//     多分片时每个分片各自发布，不同分片的用户之间不是同一时刻的快照
+//
+// Worker 每处理完一个非只读任务标记一次 "脏"，到期或空闲时才拷贝，所以写入密集时每 ReadLag 最多发布一次
+
+// DefaultReadLag 是 EnableReadLane 的默认最长发布间隔
+const DefaultReadLag = time.Millisecond
+
+// readView 是一份 UserVolume 快照 (float64 bits)
+type readView struct {
+	ver  atomic.Uint64 // seqlock：奇数表示 Worker 正在改写
+	vals [stateUsers]atomic.Uint64
+	// seq 发布时本分片已处理的任务数，单调递增，读者可用来判断快照新旧
+	seq atomic.Uint64
+}
+
+type readLane struct {
+	views [2]readView
+	cur   atomic.Uint32 // 最新发布的快照下标
+	dirty atomic.Bool   // 有写入尚未发布 (Worker 与 Scale 设置)
+	lag   int64
+	last  int64 // 上次发布的 sysclock 时刻 (Worker 独占)
+}
+
+// EnableReadLane 开启读通道，快照最多落后 lag (<= 0 时使用 DefaultReadLag)，必须在 Start 之前调用
+func (e *Engine) EnableReadLane(lag time.Duration) {
+	if lag <= 0 {
+		lag = DefaultReadLag
+	}
+	r := &readLane{lag: int64(lag)}
+	// 启动前发布一次，保证 LoadState/RecoverFromWAL 等启动前写入的状态立即可读
+	r.dirty.Store(true)
+	e.reads = r
+}
+
+// ReadVolume 从最新发布的快照中读取用户 uid 的累计成交额，可在任意多个 goroutine 中并发调用
+// seq 是快照发布时该分片已处理的任务数；未开启读通道时 ok=false
+func (e *Engine) ReadVolume(uid int) (vol float64, seq uint64, ok bool) {
+	uid &= stateUsers - 1
+	r := e.Shard(e.ShardFor(uid)).reads
+	if r == nil {
+		return 0, 0, false
+	}
+	for {
+		v := &r.views[r.cur.Load()]
+		before := v.ver.Load()
+		if before&1 != 0 {
+			// 一次发布只改写非当前的缓冲区，读者拿到正在改写的缓冲区说明刚刚又发布了两次，重新取下标
+			continue
+		}
+		bits, seq := v.vals[uid].Load(), v.seq.Load()
+		if v.ver.Load() == before {
+			return math.Float64frombits(bits), seq, true
+		}
+	}
+}
+
+// ReadVolumes 从快照中读取用户 [from, from+len(dst)) 的累计成交额，返回实际写入的个数
+// 与 ReadVolume 相同不经过队列；同一分片的用户读自同一份快照，未开启读通道时返回 0
+func (e *Engine) ReadVolumes(from int, dst []float64) int {
+	if from < 0 || from >= stateUsers {
+		return 0
+	}
+	dst = dst[:min(len(dst), stateUsers-from)]
+	n := e.NumShards()
+	for i := range n {
+		r := e.Shard(i).reads
+		if r == nil {
+			return 0
+		}
+		// 本分片负责的第一个用户: uid&(n-1) == i；设置了 ShardRouter 时逐个用户判断归属
+		first, stride := from+(i-from%n+n)%n, n
+		if e.router != nil {
+			first, stride = from, 1
+		}
+		for {
+			v := &r.views[r.cur.Load()]
+			before := v.ver.Load()
+			if before&1 != 0 {
+				continue
+			}
+			for uid := first; uid < from+len(dst); uid += stride {
+				if stride == 1 && e.shardOf(uid, n) != i {
+					continue
+				}
+				dst[uid-from] = math.Float64frombits(v.vals[uid].Load())
+			}
+			if v.ver.Load() == before {
+				break
+			}
+		}
+	}
+	return len(dst)
+}
+
+// afterTask 在 Worker 中每处理完一个任务调用，只读任务不会让快照变脏
+func (r *readLane) afterTask(e *Engine, typ int) {
+	switch typ {
+	case TaskTypeQuery, TaskTypeVolumes, TaskTypeSnapshot, TaskTypeFlush:
+	default:
+		r.dirty.Store(true)
+	}
+	if r.dirty.Load() && sysclock.Now()-r.last >= r.lag {
+		r.publish(e)
+	}
+}
+
+// idle 在 Worker 空转时调用：队列为空，立即发布尚未发布的写入
+func (r *readLane) idle(e *Engine) {
+	if r.dirty.Load() {
+		r.publish(e)
+	}
+}
+
+// publish 把 UserVolume 拷贝到非当前的缓冲区并切换过去 (只由 Worker 调用)
+func (r *readLane) publish(e *Engine) {
+	r.dirty.Store(false)
+	next := 1 - r.cur.Load()
+	v := &r.views[next]
+	v.ver.Add(1)
+	for uid := range e.UserVolume {
+		v.vals[uid].Store(math.Float64bits(e.UserVolume[uid]))
+	}
+	v.seq.Store(e.stats.processed.Load())
+	v.ver.Add(1)
+	r.cur.Store(next)
+	r.last = sysclock.Now()
+}
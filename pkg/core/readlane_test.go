@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// 并发读者读到的总是某个任务边界上的快照：一笔同时更新 10 个用户的批量订单要么全部可见，要么全部不可见
+func TestReadLaneNoTornReads(t *testing.T) {
+	const users, batches, readers = 10, 300, 2
+	e := NewEngine()
+	e.EnableReadLane(0)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	basket := make([]Order, users)
+	for i := range basket {
+		basket[i] = Order{Price: 1, Quantity: 1, UserID: i}
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	var reads atomic.Int64
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dst := make([]float64, users)
+			var lastSeq uint64
+			for !stop.Load() {
+				e.ReadVolumes(0, dst)
+				for uid, v := range dst {
+					if v != dst[0] {
+						t.Errorf("torn snapshot: user %d = %v, user 0 = %v", uid, v, dst[0])
+						return
+					}
+				}
+				_, seq, ok := e.ReadVolume(0)
+				if !ok || seq < lastSeq {
+					t.Errorf("ReadVolume seq went from %d to %d (ok=%v)", lastSeq, seq, ok)
+					return
+				}
+				lastSeq = seq
+				reads.Add(1)
+				runtime.Gosched() // 单核时给 Worker 和提交方让出时间片
+			}
+		}()
+	}
+
+	for range batches {
+		if _, err := e.Call(context.Background(), Task{Type: TaskTypeBatchOrder, Orders: basket}); err != nil {
+			t.Fatalf("batch: %v", err)
+		}
+	}
+	// 队列空闲时立即发布：最终的快照与串行读取一致
+	e.Flush()
+	for {
+		v, _, _ := e.ReadVolume(users - 1)
+		if v == batches {
+			break
+		}
+		runtime.Gosched()
+	}
+	stop.Store(true)
+	wg.Wait()
+	if v := e.GetUserVolume(0); v != batches {
+		t.Fatalf("GetUserVolume(0) = %v, want %d", v, batches)
+	}
+	if reads.Load() == 0 {
+		t.Fatal("readers never ran")
+	}
+}
+
+func TestReadLaneDisabled(t *testing.T) {
+	e := NewEngine()
+	if _, _, ok := e.ReadVolume(0); ok {
+		t.Fatal("ReadVolume ok without EnableReadLane")
+	}
+	if n := e.ReadVolumes(0, make([]float64, 4)); n != 0 {
+		t.Fatalf("ReadVolumes = %d without EnableReadLane", n)
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"arena_demo/pkg/zlog"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// 任务 panic 的恢复与现场转储
+//
+// 默认情况下 Worker 里的 panic 会直接让进程崩溃 (C World 的状态不再可信，这通常是正确的选择)。
+// 开启 EnableRecovery 后，每个任务在 recover 保护下执行：panic 的任务被丢弃，Worker 继续处理后续任务，
+// 同时留下足够复现问题的现场：
+//   - 调用方收到 ErrTaskPanicked，而不是永远等不到回复
+//   - 一行 zlog 摘要 (任务类型、用户、关联 ID、panic 值) 写入 LogTail，并连同调用栈写到 stderr
+//   - 完整的 PanicDump (含任务副本，可用于重放) 非阻塞地发送到死信 sink
+//
+// 注意：panic 发生时任务可能已经改了一半状态 (如 UserVolume)，恢复不会回滚这些修改
+
+// ErrTaskPanicked 任务在处理过程中 panic，已被丢弃
+var ErrTaskPanicked = errors.New("core: task panicked")
+
+// PanicDump 是一次任务 panic 的现场
+type PanicDump struct {
+	Task  Task   // 出问题的任务副本 (Resp 已清空，可以直接重新提交以复现)
+	Value any    // recover() 的返回值
+	Stack []byte // panic 时 Worker 的调用栈
+	Log   []byte // zlog 格式的摘要行 (与写入 LogTail 的内容相同)
+}
+
+// panicStackSize 调用栈缓冲区的大小，更深的栈会被截断
+const panicStackSize = 16 << 10
+
+type recovery struct {
+	sink chan<- PanicDump
+	// stack 预先分配，recover 时不需要再为 runtime.Stack 申请内存
+	stack [panicStackSize]byte
+}
+
+// EnableRecovery 让 Worker 在任务 panic 后继续运行，必须在 Start 之前调用
+// 每次 panic 计入 Stats.Panics，现场转储非阻塞地发送到 sink (sink 为 nil 或已满时丢弃，stderr 上总会有一份)
+func (e *Engine) EnableRecovery(sink chan<- PanicDump) {
+	e.recovery = &recovery{sink: sink}
+}
+
+// processRecover 在 recover 保护下处理一个任务
+// defer 只在开启恢复时才出现在热路径上，process 本身保持 nosplit 且没有 defer
+func (e *Engine) processRecover(t Task) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.dumpPanic(&t, r)
+		}
+	}()
+	e.process(t)
+}
+
+// dumpPanic 在 deferred 函数中调用，此时栈还没有展开，runtime.Stack 能看到 panic 的位置
+func (e *Engine) dumpPanic(t *Task, r any) {
+	e.stats.panics.Add(1)
+	e.breakerFailed = true
+	// 调用方优先：即使下面的格式化出了问题，也不能让它一直等下去
+	// 任务可能在 panic 之前已经回复过，所以非阻塞发送 (Resp 容量为 1)
+	if t.Resp != nil {
+		select {
+		case t.Resp <- ErrTaskPanicked:
+		default:
+		}
+	}
+	// 格式化 panic 值本身也可能再次 panic (如 Error/String 方法有 bug)，绝不能让它带走 Worker
+	defer func() { _ = recover() }()
+
+	rec := e.recovery
+	stack := append([]byte(nil), rec.stack[:runtime.Stack(rec.stack[:], false)]...)
+
+	logger := zlog.Wrap(make([]byte, 0, 256)).Tee(e.LogTail).Seq(e.LogSeq).Redact(e.LogRedact)
+	logger.Str("type", "panic").Int("shard", e.shardID).Int("task_type", t.Type).Int("uid", t.Value&1023)
+	if t.CorrID != 0 {
+		logger.Int("corr_id", int(t.CorrID))
+	}
+	if !t.TraceID.IsZero() {
+		logger.Hex("trace_id", t.TraceID[:])
+	}
+	// logfmt 的字符串值不转义，panic 信息里常有空格，这里加引号
+	logger.Str("panic", strconv.Quote(fmt.Sprint(r))).Msg("task panicked")
+	line := logger.Bytes()
+
+	os.Stderr.Write(line)
+	os.Stderr.Write(stack)
+
+	d := PanicDump{Task: *t, Value: r, Stack: stack, Log: line}
+	d.Task.Resp = nil
+	select {
+	case rec.sink <- d:
+	default:
+	}
+}
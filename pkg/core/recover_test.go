@@ -0,0 +1,71 @@
+package core
+
+import (
+	"arena_demo/pkg/zlog"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// panic 的任务留下带有类型、用户、关联 ID 和调用栈的现场，Worker 继续处理后续任务
+func TestPanicDumpHasTaskFields(t *testing.T) {
+	e := NewEngine()
+	dumps := make(chan PanicDump, 1)
+	e.EnableRecovery(dumps)
+	e.LogTail = zlog.NewRingSink(8, 512)
+	// 有 bug 的影子逻辑：在 Worker 中 panic
+	e.EnableShadow(func(t *Task, st *OrderState) (float64, error) {
+		if t.CorrID == 7 {
+			panic("shadow bug")
+		}
+		return t.Price * float64(t.Quantity), nil
+	}, nil)
+
+	trace := TraceID{0xab, 0xcd}
+	resp := make(chan any, 1)
+	run := func(task Task) {
+		t.Helper()
+		if err := e.TrySubmit(task); err != nil {
+			t.Fatalf("TrySubmit: %v", err)
+		}
+		task, ok := e.pop()
+		if !ok {
+			t.Fatal("queue empty")
+		}
+		e.runTask(task)
+	}
+	run(Task{Type: TaskTypeOrder, Value: 42, Price: 1, Quantity: 2, CorrID: 7, TraceID: trace, Resp: resp})
+
+	if r := <-resp; !errors.Is(r.(error), ErrTaskPanicked) {
+		t.Fatalf("caller got %v, want ErrTaskPanicked", r)
+	}
+	var d PanicDump
+	select {
+	case d = <-dumps:
+	default:
+		t.Fatal("no dump sent to the sink")
+	}
+	if d.Value != "shadow bug" || d.Task.CorrID != 7 || d.Task.Value != 42 || d.Task.Resp != nil {
+		t.Fatalf("dump = {Value: %v, CorrID: %d, Value: %d, Resp: %v}", d.Value, d.Task.CorrID, d.Task.Value, d.Task.Resp)
+	}
+	if !bytes.Contains(d.Stack, []byte("runShadow")) {
+		t.Fatalf("stack does not show where it panicked:\n%s", d.Stack)
+	}
+	for _, want := range []string{"type=panic", "task_type=1", "uid=42", "corr_id=7", "trace_id=abcd", `panic="shadow bug"`} {
+		if !strings.Contains(string(d.Log), want) {
+			t.Errorf("dump log lacks %s: %q", want, d.Log)
+		}
+	}
+	if tail := e.LogTail.Snapshot(1); len(tail) != 1 || !bytes.Equal(tail[0], d.Log) {
+		t.Fatalf("LogTail = %q, want the dump line", tail)
+	}
+	if n := e.Stats().Panics; n != 1 {
+		t.Fatalf("Panics = %d, want 1", n)
+	}
+
+	run(Task{Type: TaskTypeOrder, Value: 1, Price: 3, Quantity: 1, Resp: resp})
+	if r, ok := (<-resp).(OrderResult); !ok || r.Total != 3 {
+		t.Fatalf("task after the panic replied %v", r)
+	}
+}
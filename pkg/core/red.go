@@ -0,0 +1,94 @@
+package core
+
+import "sync/atomic"
+
+// RED 指标 (Rate, Errors, Duration)：在 TrySubmit 里统一打点，Handler 不需要再各自维护计数器
+//
+//   - Rate:     每种任务类型经过 TrySubmit 的次数 (含被拒绝的)
+//   - Errors:   TrySubmit 被拒绝的次数，按任务类型 × 错误码 (见 CodeOf) 计数
+//   - Duration: 每种任务类型从入队到处理完成的耗时直方图，桶与 Stats.Latency 相同 (LatencyBounds)
+//
+// 全部是原子计数，成功的提交只多一次原子加，错误码的归类只在失败时进行。
+// Errors 只统计提交阶段的拒绝：订单被业务规则拒绝 (如 ErrPositionLimit) 是 Worker 的正常回复，不在这里计数。
+// 控制任务 (快照、Flush 等) 由引擎内部直接投递，不经过 TrySubmit，只会出现在 Duration 中。
+// 所有分片与 calc 池共用引擎的同一组计数，AppendPrometheus 按引擎输出
+
+// TaskTypeNames 是任务类型在指标标签中的名称
+var TaskTypeNames = [taskTypeCount]string{
+	TaskTypeCalc:        "calc",
+	TaskTypeOrder:       "order",
+	TaskTypeQuery:       "query",
+	TaskTypeBatchOrder:  "batch_order",
+	TaskTypeResetVolume: "reset_volume",
+	TaskTypeSnapshot:    "snapshot",
+	TaskTypeLoadState:   "load_state",
+	TaskTypeFlush:       "flush",
+	TaskTypeVolumes:     "volumes",
+	TaskTypeHalt:        "halt",
+	TaskTypeMarketPrice: "market_price",
+}
+
+// ErrorCodes 是 RED 错误计数使用的错误码，REDStats.Errors 的第二维按这个顺序排列
+var ErrorCodes = [...]ErrorCode{
+	CodeQueueFull, CodeRateLimited, CodeValidation, CodeTimeout, CodePositionLimit,
+	CodeCircuitOpen, CodeOverloaded, CodeLimitNotMet, CodeNotFound, CodeInternal,
+}
+
+// REDStats 是 RED 指标的快照，各数组按任务类型下标
+type REDStats struct {
+	Requests [taskTypeCount]uint64
+	Errors   [taskTypeCount][len(ErrorCodes)]uint64
+	Duration [taskTypeCount]Histogram
+}
+
+type redMetrics struct {
+	requests [taskTypeCount]atomic.Uint64
+	errors   [taskTypeCount][len(ErrorCodes)]atomic.Uint64
+	duration [taskTypeCount]latencyHist
+}
+
+// EnableREDMetrics 开启 TrySubmit 的 RED 指标，必须在 Start (StartN) 之前调用
+func (e *Engine) EnableREDMetrics() {
+	e.red = &redMetrics{}
+}
+
+// REDStats 返回 RED 指标的快照，未开启时 ok=false
+func (e *Engine) REDStats() (s REDStats, ok bool) {
+	m := e.red
+	if m == nil {
+		return s, false
+	}
+	for typ := range taskTypeCount {
+		s.Requests[typ] = m.requests[typ].Load()
+		for c := range ErrorCodes {
+			s.Errors[typ][c] = m.errors[typ][c].Load()
+		}
+		s.Duration[typ] = m.duration[typ].snapshot()
+	}
+	return s, true
+}
+
+// submitted 记录一次 TrySubmit 的结果
+func (m *redMetrics) submitted(typ int, err error) {
+	if uint(typ) >= taskTypeCount {
+		return
+	}
+	m.requests[typ].Add(1)
+	if err == nil {
+		return
+	}
+	code := CodeOf(err)
+	for c := range ErrorCodes {
+		if ErrorCodes[c] == code {
+			m.errors[typ][c].Add(1)
+			return
+		}
+	}
+}
+
+// done 记录一个任务从入队到处理完成的耗时 (纳秒)
+func (m *redMetrics) done(typ int, ns int64) {
+	if uint(typ) < taskTypeCount {
+		m.duration[typ].observe(ns)
+	}
+}
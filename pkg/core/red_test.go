@@ -0,0 +1,105 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 成功与失败混合的提交：Requests 含被拒绝的，Errors 按类型 × 错误码计数，Duration 是入队到完成的耗时
+func TestREDMetrics(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	e := NewEngine() // 不 Start：由测试充当 Worker，在虚拟时间里控制每个任务的耗时
+	e.EnableREDMetrics()
+	e.EnableInflightLimit(4)
+	e.SetValidator(TaskTypeOrder, func(t Task) error {
+		if t.Quantity <= 0 {
+			return errors.New("empty order")
+		}
+		return nil
+	})
+	if err := Register("test.red", e); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("test.red")
+
+	submit := func(task Task) error {
+		task.Resp = make(chan any, 1)
+		return e.TrySubmit(task)
+	}
+	for range 2 {
+		if err := submit(Task{Type: TaskTypeCalc, Value: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := submit(Task{Type: TaskTypeOrder, Value: 1, Price: 1}); !errors.Is(err, ErrInvalidTask) {
+		t.Fatalf("empty order: err = %v, want ErrInvalidTask", err)
+	}
+	for range 2 {
+		if err := submit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 在途名额已满
+	if err := submit(Task{Type: TaskTypeOrder, Value: 2, Price: 1, Quantity: 1}); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("submit over the inflight limit: err = %v, want ErrOverloaded", err)
+	}
+
+	// calc 排队 3ms 后完成，订单再过 20ms
+	sysclock.Advance(3 * time.Millisecond)
+	for range 2 {
+		task, _ := e.pop()
+		e.runTask(task)
+	}
+	sysclock.Advance(20 * time.Millisecond)
+	for range 2 {
+		task, _ := e.pop()
+		e.runTask(task)
+	}
+
+	red, ok := e.REDStats()
+	if !ok {
+		t.Fatal("REDStats not enabled")
+	}
+	code := func(c ErrorCode) int { return slices.Index(ErrorCodes[:], c) }
+	if red.Requests[TaskTypeCalc] != 2 || red.Requests[TaskTypeOrder] != 4 {
+		t.Fatalf("Requests: calc %d, order %d; want 2 and 4", red.Requests[TaskTypeCalc], red.Requests[TaskTypeOrder])
+	}
+	var errs uint64
+	for typ := range red.Errors {
+		for _, n := range red.Errors[typ] {
+			errs += n
+		}
+	}
+	if errs != 2 || red.Errors[TaskTypeOrder][code(CodeValidation)] != 1 || red.Errors[TaskTypeOrder][code(CodeOverloaded)] != 1 {
+		t.Fatalf("Errors = %v, want one validation and one overloaded order", red.Errors)
+	}
+
+	calc, ord := red.Duration[TaskTypeCalc], red.Duration[TaskTypeOrder]
+	if calc.Count != 2 || calc.Counts[2] != 2 || !near(calc.Sum, 0.006) {
+		t.Fatalf("calc duration = %+v, want two 3ms samples in the le=0.005 bucket", calc)
+	}
+	if ord.Count != 2 || ord.Counts[4] != 2 || !near(ord.Sum, 0.046) {
+		t.Fatalf("order duration = %+v, want two 23ms samples in the le=0.025 bucket", ord)
+	}
+
+	out := string(AppendPrometheus(nil))
+	for _, want := range []string{
+		`engine_requests_total{engine="test.red",type="order"} 4`,
+		`engine_request_errors_total{engine="test.red",type="order",code="validation"} 1`,
+		`engine_request_duration_seconds_count{engine="test.red",type="calc"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output lacks %s", want)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrDuplicateEngine 同名引擎已注册
+var ErrDuplicateEngine = errors.New("core: engine already registered")
+
+// 引擎注册表 (Go World 使用，不在热路径上，所以直接用锁)
+// 同一进程可以按业务域运行多个独立的引擎：
+// 它们共享 sysclock，但各自拥有独立的队列、Arena 和 UserVolume
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Engine{}
+)
+
+// Register 以 name 注册一个引擎，重名时返回 ErrDuplicateEngine
+func Register(name string, e *Engine) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		return ErrDuplicateEngine
+	}
+	registry[name] = e
+	return nil
+}
+
+// Lookup 按名称查找引擎，不存在时返回 nil
+func Lookup(name string) *Engine {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[name]
+}
+
+// Unregister 移除一个引擎 (不会停止它)
+func Unregister(name string) {
+	registryMu.Lock()
+	delete(registry, name)
+	registryMu.Unlock()
+}
+
+// Names 返回所有已注册引擎的名称 (按字典序)
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
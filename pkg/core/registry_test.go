@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// 两个注册的引擎各有自己的 UserVolume 与 Arena，一个上的订单不影响另一个
+func TestRegistryEnginesIsolated(t *testing.T) {
+	a, b := NewEngineSync(), NewEngineSync()
+	if err := Register("test.a", a); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("test.a")
+	if err := Register("test.b", b); err != nil {
+		t.Fatal(err)
+	}
+	defer Unregister("test.b")
+	if err := Register("test.a", b); err != ErrDuplicateEngine {
+		t.Fatalf("duplicate Register: %v, want ErrDuplicateEngine", err)
+	}
+	if Lookup("test.a") != a || Lookup("test.b") != b || Lookup("test.none") != nil {
+		t.Fatal("Lookup returned the wrong engine")
+	}
+	if names := Names(); !slices.Contains(names, "test.a") || !slices.Contains(names, "test.b") {
+		t.Fatalf("Names = %v", names)
+	}
+	if a.Mem == b.Mem {
+		t.Fatal("engines share an arena")
+	}
+
+	ctx := context.Background()
+	Lookup("test.a").Call(ctx, Task{Type: TaskTypeOrder, Value: 9, Price: 3, Quantity: 2})
+	Lookup("test.b").Call(ctx, Task{Type: TaskTypeOrder, Value: 9, Price: 1, Quantity: 1})
+	if a.UserVolume[9] != 6 || b.UserVolume[9] != 1 {
+		t.Fatalf("UserVolume[9]: a = %v, b = %v; want 6 and 1", a.UserVolume[9], b.UserVolume[9])
+	}
+	if a.Stats().Processed != 1 || b.Stats().Processed != 1 {
+		t.Fatal("processed counters leaked between engines")
+	}
+
+	Unregister("test.a")
+	if Lookup("test.a") != nil {
+		t.Fatal("Lookup found an unregistered engine")
+	}
+}
@@ -0,0 +1,35 @@
+package core
+
+// 结果投递策略
+//
+// Worker 只有一个线程，任何一次阻塞的 t.Resp <- v 都会让整个分片停下来等调用方。
+// 所以业务结果一律非阻塞投递：
+//   - Resp 必须有缓冲 (TrySubmit 遇到无缓冲的 Resp 直接 panic，与同步模式相同)；
+//     每个任务只回复一次，容量 1 足够，调用方按时读取时结果一定能送达
+//   - 缓冲已满 (调用方复用了 channel 却没有读走上一个结果、或者把多个任务的 Resp 指向同一个 channel 却读得太慢) 时，
+//     结果被丢弃并计入 Stats.RepliesDropped，Worker 继续处理下一个任务；状态修改 (成交额、WAL) 照常生效，
+//     只是这一次的回复丢了
+//   - Resp 为 nil 表示调用方不需要结果，不计入丢弃
+//
+// 需要完整保留结果时使用 EnableResultRing / EnableResultStream，它们有各自的满载策略。
+// 控制面的交接 (Export/Scale 停住 Worker 的 parkWorker) 本身就是同步握手，仍然阻塞
+
+// trySend 非阻塞地把 v 写入 resp，写不进去时返回 false
+func trySend(resp chan any, v any) bool {
+	select {
+	case resp <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// reply 在 Worker 中投递一个结果，Resp 满时丢弃并计数
+func (e *Engine) reply(resp chan any, v any) {
+	if e.breakerWatch {
+		e.noteReply(v)
+	}
+	if resp != nil && !trySend(resp, v) {
+		e.stats.repliesDropped.Add(1)
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// 一个从不读取的调用方不会卡住 Worker：它的多余结果被丢弃并计数，之后的任务照常处理，状态修改照常生效
+func TestSlowConsumerDoesNotStallWorker(t *testing.T) {
+	e := NewEngine()
+	e.Start()
+	stopOnCleanup(t, e)
+
+	stuck := make(chan any, 1) // 只容得下一个结果，且没人读
+	for range 5 {
+		if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 2, Resp: stuck}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 4, Resp: next}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-next:
+		if v != CalcResult(8) {
+			t.Fatalf("calc after the stuck consumer = %v, want 8", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker stalled behind a consumer that never reads")
+	}
+
+	if got := e.Stats().RepliesDropped; got != 4 {
+		t.Fatalf("RepliesDropped = %d, want 4", got)
+	}
+	if v, err := e.TryGetUserVolume(1); err != nil || v != 10 {
+		t.Fatalf("UserVolume[1] = %v (%v), want 10 (dropped replies still apply)", v, err)
+	}
+	if len(stuck) != 1 {
+		t.Fatalf("stuck channel holds %d results, want 1", len(stuck))
+	}
+}
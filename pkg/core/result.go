@@ -0,0 +1,33 @@
+package core
+
+// Result 是 Worker 通过 Task.Resp 回传的结果类型 (封闭接口，只有本包的类型可以实现)
+// 调用方应使用 AsCalc / AsOrder / AsBatch，而不是直接做类型断言：
+// 类型不匹配时它们返回 ok=false 而不是 panic (如任务被丢弃时回传的是 ErrExpired)
+type Result interface {
+	isResult()
+}
+
+// CalcResult 是 TaskTypeCalc 的结果
+type CalcResult int
+
+func (CalcResult) isResult()  {}
+func (OrderResult) isResult() {}
+func (BatchResult) isResult() {}
+
+// AsCalc 把 Resp 中收到的值转换为 Calc 结果
+func AsCalc(r any) (int, bool) {
+	v, ok := r.(CalcResult)
+	return int(v), ok
+}
+
+// AsOrder 把 Resp 中收到的值转换为 OrderResult
+func AsOrder(r any) (OrderResult, bool) {
+	v, ok := r.(OrderResult)
+	return v, ok
+}
+
+// AsBatch 把 Resp 中收到的值转换为 BatchResult
+func AsBatch(r any) (BatchResult, bool) {
+	v, ok := r.(BatchResult)
+	return v, ok
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// As* 只接受各自的结果类型，其它值 (包括错误与 nil) 返回 ok=false 而不是 panic
+func TestAsHelpers(t *testing.T) {
+	e := NewEngineSync()
+
+	r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 2.5, Quantity: 4})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if res, ok := AsOrder(r); !ok || res.Total != 10 {
+		t.Fatalf("AsOrder(order result) = %+v, %v", res, ok)
+	}
+	if _, ok := AsCalc(r); ok {
+		t.Fatal("AsCalc accepted an OrderResult")
+	}
+
+	r, err = e.Call(context.Background(), Task{Type: TaskTypeCalc, Value: 21})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if _, ok := AsCalc(r); !ok {
+		t.Fatalf("AsCalc(%T) failed", r)
+	}
+	if _, ok := AsOrder(r); ok {
+		t.Fatal("AsOrder accepted a CalcResult")
+	}
+
+	for _, v := range []any{nil, ErrExpired, 42, OrderResult{}, BatchResult{}} {
+		_, isOrder := v.(OrderResult)
+		if _, ok := AsOrder(v); ok != isOrder {
+			t.Errorf("AsOrder(%#v) ok = %v", v, ok)
+		}
+		_, isBatch := v.(BatchResult)
+		if _, ok := AsBatch(v); ok != isBatch {
+			t.Errorf("AsBatch(%#v) ok = %v", v, ok)
+		}
+		if _, ok := AsCalc(v); ok {
+			t.Errorf("AsCalc(%#v) accepted a non-calc value", v)
+		}
+	}
+}
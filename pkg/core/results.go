@@ -0,0 +1,162 @@
+package core
+
+import (
+	"arena_demo/pkg/fastqueue"
+	"runtime"
+	"sync/atomic"
+)
+
+// 拉取式结果投递：结果写入输出环，由独立的收集 goroutine 批量取走
+//
+// 默认每个需要结果的任务都要带一个 Resp channel，提交方要么阻塞等待，要么自己管理一堆 channel。
+// EnableResultRing 之后，Resp 为 nil 的任务不再是 "不要结果"：Worker 把结果连同 CorrID
+// 写入本分片的输出环 (SPSC，Worker 是唯一的生产者)，收集方用 PollResults 取走，
+// 按 CorrID 与提交记录配对。提交与收集完全解耦，也不需要为每个任务分配 channel
+//
+// 实现上 Worker 给这类任务临时挂上一个分片私有的、容量为 1 的 channel，process 照常回复，
+// 处理完后 Worker 再把它取出来写入输出环，所以 process 本身 (以及所有任务类型的回复逻辑) 不变：
+//   - 被丢弃的任务 (ErrExpired)、panic 的任务 (ErrTaskPanicked) 同样会产生一条结果
+//   - 不回复的任务 (Windowed calc) 不产生结果
+//   - 带 Resp 的任务照常走 channel，不进入输出环
+//   - calc 池 (EnableCalcPool) 中的任务不经过分片 Worker，不支持输出环
+//
+// 输出环满时按 ResultFullPolicy 处理，丢弃的条数计入 Stats.ResultsDropped
+
+// ResultRecord 是输出环中的一条结果
+type ResultRecord struct {
+	CorrID uint64
+	Type   int // 任务类型
+	// Value 与 Resp 中收到的值相同：CalcResult / OrderResult / BatchResult / float64 (查询) / error
+	Value any
+}
+
+// ResultFullPolicy 输出环满时的处理策略
+type ResultFullPolicy uint8
+
+const (
+	// ResultBlock Worker 等待收集方腾出空间 (背压：收集方跟不上时任务处理随之变慢，默认)
+	ResultBlock ResultFullPolicy = iota
+	// ResultDropNewest 丢弃这条新结果
+	ResultDropNewest
+	// ResultDropOldest 覆盖环中最旧的一条结果 (输出环改用 fastqueue.OverwriteRing)
+	ResultDropOldest
+)
+
+// ResultQueue 是一个分片的输出环，Pop 只能由唯一的收集方调用
+// 按策略是 fastqueue.RingBuffer (ResultBlock / ResultDropNewest) 或 fastqueue.OverwriteRing (ResultDropOldest)
+type ResultQueue interface {
+	Pop() (ResultRecord, bool)
+	Len() uint64
+	Cap() uint64
+}
+
+type resultRing struct {
+	// out 与 lossy 二选一：ResultDropOldest 时结果写入 lossy，否则写入 out
+	out    *fastqueue.RingBuffer[ResultRecord]
+	lossy  *fastqueue.OverwriteRing[ResultRecord]
+	policy ResultFullPolicy
+	// slot 是临时挂到任务上的 Resp，只由本分片的 Worker 使用
+	slot    chan any
+	dropped atomic.Uint64
+}
+
+// EnableResultRing 为每个分片创建容量为 size (2 的幂) 的输出环，必须在 Start 之前调用
+func (e *Engine) EnableResultRing(size uint64, full ResultFullPolicy) {
+	if e.stream != nil {
+		panic("core: result stream and result ring are mutually exclusive")
+	}
+	r := &resultRing{
+		policy: full,
+		slot:   make(chan any, 1),
+	}
+	if full == ResultDropOldest {
+		r.lossy = fastqueue.NewOverwrite[ResultRecord](size)
+		r.lossy.SetOnDrop(func(ResultRecord) { r.dropped.Add(1) })
+	} else {
+		r.out = fastqueue.New[ResultRecord](size)
+	}
+	e.results = r
+}
+
+// ResultRing 返回本分片的输出环 (未开启时为 nil)，收集方是它唯一的消费者
+func (e *Engine) ResultRing() ResultQueue {
+	if e.results == nil {
+		return nil
+	}
+	return e.results.queue()
+}
+
+// queue 返回实际使用的输出环
+func (r *resultRing) queue() ResultQueue {
+	if r.lossy != nil {
+		return r.lossy
+	}
+	return r.out
+}
+
+// PollResults 从所有分片的输出环中取出最多 len(dst) 条结果，返回取出的条数 (不等待)
+// 各分片轮流取，同一分片内的结果保持处理顺序；同一时刻只能有一个收集方调用
+func (e *Engine) PollResults(dst []ResultRecord) int {
+	n := 0
+	for n < len(dst) {
+		got := false
+		for i := range e.NumShards() {
+			r := e.Shard(i).results
+			if r == nil || n == len(dst) {
+				continue
+			}
+			if rec, ok := r.pop(); ok {
+				dst[n] = rec
+				n++
+				got = true
+			}
+		}
+		if !got {
+			break
+		}
+	}
+	return n
+}
+
+// processToRing 处理一个 Resp 为 nil 的任务，并把它的结果写入输出环
+func (e *Engine) processToRing(t Task) {
+	r := e.results
+	t.Resp = r.slot
+	if e.recovery != nil {
+		e.processRecover(t)
+	} else {
+		e.process(t)
+	}
+	select {
+	case v := <-r.slot:
+		r.push(ResultRecord{CorrID: t.CorrID, Type: t.Type, Value: v})
+	default:
+	}
+}
+
+// pop 取出一条结果 (收集方调用)
+func (r *resultRing) pop() (ResultRecord, bool) {
+	if r.lossy != nil {
+		return r.lossy.Pop()
+	}
+	return r.out.Pop()
+}
+
+func (r *resultRing) push(rec ResultRecord) {
+	if r.lossy != nil {
+		// 满时覆盖最旧的一条，丢弃计数由 onDrop 累加
+		r.lossy.Push(rec)
+		return
+	}
+	if r.out.Push(rec) {
+		return
+	}
+	switch r.policy {
+	case ResultDropNewest:
+		r.dropped.Add(1)
+	default:
+		for !r.out.Push(rec) {
+			runtime.Gosched()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"testing"
+)
+
+// resultCollector 按 CorrID 收集输出环中的结果
+type resultCollector struct {
+	t   *testing.T
+	got map[uint64]ResultRecord
+	buf []ResultRecord
+}
+
+func newResultCollector(t *testing.T) *resultCollector {
+	return &resultCollector{t: t, got: map[uint64]ResultRecord{}, buf: make([]ResultRecord, 64)}
+}
+
+// poll 取走当前所有分片输出环中的结果
+func (c *resultCollector) poll(e *Engine) {
+	c.t.Helper()
+	n := e.PollResults(c.buf)
+	for _, rec := range c.buf[:n] {
+		if _, dup := c.got[rec.CorrID]; dup {
+			c.t.Fatalf("CorrID %d delivered twice", rec.CorrID)
+		}
+		c.got[rec.CorrID] = rec
+	}
+}
+
+// 所有分片上 Resp 为 nil 的任务都产生一条带 CorrID 的结果；带 Resp 的任务照常走 channel
+func TestResultRingDeliversAll(t *testing.T) {
+	const n = 500
+	e := NewEngine()
+	e.EnableResultRing(64, ResultBlock)
+	e.StartN(2)
+	stopOnCleanup(t, e)
+
+	resp := make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: 1, CorrID: 9999, Resp: resp}); err != nil {
+		t.Fatalf("TrySubmit: %v", err)
+	}
+	c := newResultCollector(t)
+	for i := range n {
+		task := Task{Type: TaskTypeCalc, Value: i, CorrID: uint64(i + 1)}
+		if i%2 == 1 {
+			task = Task{Type: TaskTypeOrder, Value: i, Price: 1, Quantity: 2, CorrID: uint64(i + 1)}
+		}
+		// 输出环容量小于任务数：收集方不取时 Worker 停在 push 上 (ResultBlock)，队列随之填满
+		for e.TrySubmit(task) != nil {
+			c.poll(e)
+		}
+	}
+	if r := <-resp; r != CalcResult(2) {
+		t.Fatalf("task with Resp replied %v", r)
+	}
+	for len(c.got) < n {
+		c.poll(e)
+	}
+	got := c.got
+	for id := uint64(1); id <= n; id++ {
+		rec, ok := got[id]
+		switch {
+		case !ok:
+			t.Fatalf("no result for CorrID %d", id)
+		case id%2 == 1 && (rec.Type != TaskTypeCalc || rec.Value != CalcResult(2*(id-1))):
+			t.Fatalf("CorrID %d: %+v", id, rec)
+		case id%2 == 0 && (rec.Type != TaskTypeOrder || rec.Value.(OrderResult).Total != 2 || rec.Value.(OrderResult).CorrID != id):
+			t.Fatalf("CorrID %d: %+v", id, rec)
+		}
+	}
+	if _, ok := got[9999]; ok {
+		t.Fatal("task with Resp also went to the ring")
+	}
+	if d := e.Stats().ResultsDropped; d != 0 {
+		t.Fatalf("ResultsDropped = %d with ResultBlock", d)
+	}
+}
+
+// 输出环满时 DropNewest 丢掉新结果，DropOldest 覆盖最旧的结果，丢弃数计入 ResultsDropped
+func TestResultRingFullPolicies(t *testing.T) {
+	for _, c := range []struct {
+		policy ResultFullPolicy
+		first  uint64
+	}{{ResultDropNewest, 1}, {ResultDropOldest, 3}} {
+		e := NewEngine()
+		e.EnableResultRing(4, c.policy)
+		for i := range 6 {
+			if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: i, CorrID: uint64(i + 1)}); err != nil {
+				t.Fatalf("TrySubmit: %v", err)
+			}
+			task, _ := e.pop()
+			e.runTask(task)
+		}
+		buf := make([]ResultRecord, 8)
+		n := e.PollResults(buf)
+		if n != 4 {
+			t.Fatalf("policy %d: polled %d results, want 4", c.policy, n)
+		}
+		for i, rec := range buf[:n] {
+			if rec.CorrID != c.first+uint64(i) {
+				t.Fatalf("policy %d: result %d has CorrID %d, want %d", c.policy, i, rec.CorrID, c.first+uint64(i))
+			}
+		}
+		if d := e.Stats().ResultsDropped; d != 2 {
+			t.Fatalf("policy %d: ResultsDropped = %d, want 2", c.policy, d)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// 一致性哈希分片路由
+//
+// 默认的路由是 uid&(n-1)：最快，但分片数只能是 2 的幂，每次扩容至少翻倍，一次搬走一半的用户。
+// SetShardRouter 之后改用一致性哈希环：每个分片在环上放 vnodes 个虚拟节点，用户归属于
+// 顺时针方向的第一个节点。分片数可以是任意值 (不超过用户数)，从 n 增加到 m 时只有落进新节点区间的用户
+// (约 (m-n)/m，如 3 -> 4 约 1/4；若用求模 uid%m 则约 3/4) 会移动，而且只会移到新分片上；
+// 缩容时也只有被移除分片上的用户需要迁移。
+//
+// 路由只看 uid&1023 (与 UserVolume 的下标相同)，所以每个分片数对应一张 1024 项的归属表，
+// 首次用到时构建 (排序 n*vnodes 个节点)，之后路由只是一次查表。
+// vnodes 越多各分片的用户数越均匀，默认 DefaultVirtualNodes
+//
+// 所有按用户划分分片的地方 (提交路由、快照、批量查询、读通道、Scale 迁移) 都经过 shardOf，
+// 路由器必须在 Start/StartN 之前设置，运行中更换会让用户与状态错位
+
+// DefaultVirtualNodes 是 NewShardRouter 的默认虚拟节点数
+const DefaultVirtualNodes = 64
+
+// ShardRouter 把 UserID 映射到分片，可以被多个引擎共享
+type ShardRouter struct {
+	vnodes int
+	mu     sync.Mutex // 串行化归属表的构建
+	// tables 按分片数缓存的归属表，分片数不会超过用户数
+	tables [stateUsers + 1]atomic.Pointer[[stateUsers]uint16]
+}
+
+// NewShardRouter 创建一个每个分片有 vnodes 个虚拟节点 (<= 0 时使用 DefaultVirtualNodes) 的一致性哈希路由
+func NewShardRouter(vnodes int) *ShardRouter {
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+	return &ShardRouter{vnodes: vnodes}
+}
+
+// Shard 返回 uid 在 n 个分片 (1 <= n <= 1024) 中所在的分片下标
+func (r *ShardRouter) Shard(uid, n int) int {
+	if n <= 1 {
+		return 0
+	}
+	t := r.tables[n].Load()
+	if t == nil {
+		t = r.build(n)
+	}
+	return int(t[uid&(stateUsers-1)])
+}
+
+// ringNode 是环上的一个虚拟节点
+type ringNode struct {
+	hash  uint64
+	shard uint16
+}
+
+// build 构建 n 个分片的归属表
+func (r *ShardRouter) build(n int) *[stateUsers]uint16 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t := r.tables[n].Load(); t != nil {
+		return t
+	}
+	ring := make([]ringNode, 0, n*r.vnodes)
+	for s := range n {
+		for v := range r.vnodes {
+			ring = append(ring, ringNode{hash: mix64(uint64(s)<<32 | uint64(v)), shard: uint16(s)})
+		}
+	}
+	slices.SortFunc(ring, func(a, b ringNode) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return int(a.shard) - int(b.shard)
+	})
+	t := new([stateUsers]uint16)
+	for uid := range t {
+		// 用户与节点用不同的输入空间，避免 uid 恰好等于某个节点的编码
+		h := mix64(uint64(uid) | 1<<63)
+		i, _ := slices.BinarySearchFunc(ring, h, func(node ringNode, h uint64) int {
+			if node.hash < h {
+				return -1
+			}
+			if node.hash > h {
+				return 1
+			}
+			return 0
+		})
+		if i == len(ring) {
+			i = 0 // 绕回环的起点
+		}
+		t[uid] = ring[i].shard
+	}
+	r.tables[n].Store(t)
+	return t
+}
+
+// mix64 是 splitmix64 的终结函数，把相邻的输入打散到整个 64 位空间
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xBF58476D1CE4E5B9
+	x ^= x >> 27
+	x *= 0x94D049BB133111EB
+	x ^= x >> 31
+	return x
+}
+
+// SetShardRouter 让引擎用 r 代替 uid&(n-1) 分配用户，之后 StartN/Scale 接受任意的分片数，必须在 Start/StartN 之前调用
+func (e *Engine) SetShardRouter(r *ShardRouter) {
+	e.router = r
+}
+
+// shardOf 返回 uid 在 n 个分片中所在的分片下标
+func (e *Engine) shardOf(uid, n int) int {
+	if e.router != nil {
+		return e.router.Shard(uid, n)
+	}
+	return uid & (n - 1)
+}
+
+// checkShardCount 检查分片数：默认路由要求 2 的幂，一致性哈希只要求不超过用户数
+func (e *Engine) checkShardCount(n int) {
+	if e.router != nil {
+		if n < 1 || n > stateUsers {
+			panic("core: shard count out of range")
+		}
+		return
+	}
+	if n < 1 || n&(n-1) != 0 {
+		panic("core: shard count must be power of 2")
+	}
+}
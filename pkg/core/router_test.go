@@ -0,0 +1,43 @@
+package core
+
+import "testing"
+
+// 分片数加一时只有约 1/m 的用户移动，而且都移到新分片上；各分片的用户数大致均匀
+func TestShardRouterMovesFewUsers(t *testing.T) {
+	r := NewShardRouter(0)
+	for _, n := range []int{1, 2, 3, 4, 7, 8, 15} {
+		m := n + 1
+		moved := 0
+		load := make([]int, m)
+		for uid := range stateUsers {
+			from, to := r.Shard(uid, n), r.Shard(uid, m)
+			if from < 0 || from >= n || to < 0 || to >= m {
+				t.Fatalf("uid %d: shard %d of %d, %d of %d", uid, from, n, to, m)
+			}
+			if from != to {
+				moved++
+				if to != n {
+					t.Fatalf("%d -> %d shards: uid %d moved from %d to old shard %d", n, m, uid, from, to)
+				}
+			}
+			load[to]++
+		}
+		// 理想值是 1/m，虚拟节点的随机性留一半余量
+		if want := stateUsers / m; moved > want*3/2 || moved < want/2 {
+			t.Errorf("%d -> %d shards moved %d of %d users, want about %d", n, m, moved, stateUsers, want)
+		}
+		for s, users := range load {
+			if users < stateUsers/m/2 {
+				t.Errorf("%d shards: shard %d has only %d users", m, s, users)
+			}
+		}
+	}
+
+	// 同一个路由器的结果是确定的，与构建顺序无关
+	other := NewShardRouter(DefaultVirtualNodes)
+	for uid := range stateUsers {
+		if a, b := r.Shard(uid, 5), other.Shard(uid, 5); a != b {
+			t.Fatalf("uid %d: shard %d vs %d from an identical router", uid, a, b)
+		}
+	}
+}
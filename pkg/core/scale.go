@@ -0,0 +1,135 @@
+package core
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// 运行期扩缩容
+//
+// Scale 必须在 Start/StartN 之后调用，过程如下：
+//  1. 关闭提交闸门：新的 TrySubmit 直接返回 ErrFull (SubmitRetry/Call 会自动重试)，并等待已经通过闸门的提交完成
+//  2. 排空：等待每个现有分片的所有队列为空，再发一个屏障任务，确认正在处理的任务也已结束
+//  3. 迁移：此时所有 Worker 都在空转，按新的分片数重新分配 UserVolume 和按用户的限额
+//     (开启了延迟队列时空转的 Worker 仍会执行到期的延迟任务，所以先把旧分片全部停住，迁移完再放行)
+//     (只搬动换了分片的用户：默认路由下每次翻倍约一半，设置了 ShardRouter 时约 (m-n)/m，见 router.go)
+//  4. 发布新的分片表，多余的分片 (已排空) 退出并归还 Arena，重新打开闸门
+//
+// 整个过程中不会丢失任何已入队的任务，代价是扩缩容期间有一个很短的拒绝窗口
+
+// scaleMu 串行化并发的 Scale 调用
+var scaleMu sync.Mutex
+
+// Scale 把 Worker 分片数调整为 n (必须是 2 的幂，设置了 ShardRouter 时不限)
+// 开启了 WAL 的引擎只能是单 Worker，n > 1 时 panic (见 wal.go)
+func (e *Engine) Scale(n int) {
+	e.checkShardCount(n)
+	if e.wal != nil && n > 1 {
+		panic(ErrWALSharded.Error())
+	}
+	scaleMu.Lock()
+	defer scaleMu.Unlock()
+
+	old := []*Engine{e}
+	if p := e.shards.Load(); p != nil {
+		old = *p
+	}
+	if len(old) == n {
+		return
+	}
+
+	// 1. 关闸并等待在途提交
+	e.scaling.Store(true)
+	defer e.scaling.Store(false)
+	for e.inflight.Load() != 0 {
+		runtime.Gosched()
+	}
+
+	// 2. 排空所有现有分片
+	for _, s := range old {
+		s.drain()
+	}
+
+	// 空转的 Worker 仍会执行到期的延迟任务、写 UserVolume：先停住全部旧分片，
+	// 直到用户状态、挂单与延迟任务都迁移完毕 (新分片还没有启动)
+	var resume []chan any
+	if e.delay != nil {
+		resume = make([]chan any, len(old))
+		for i, s := range old {
+			resume[i] = s.park()
+		}
+	}
+
+	// 3. 按新分片数迁移用户状态
+	shards := make([]*Engine, n)
+	for i := range shards {
+		if i < len(old) {
+			shards[i] = old[i]
+		} else {
+			shards[i] = e.newShard(i)
+		}
+	}
+	for uid := range e.UserVolume {
+		from := old[e.shardOf(uid, len(old))]
+		to := shards[e.shardOf(uid, n)]
+		if from == to {
+			continue
+		}
+		to.UserVolume[uid] = from.UserVolume[uid]
+		to.userLimits[uid].Store(from.userLimits[uid].Load())
+		from.UserVolume[uid] = 0
+		from.userLimits[uid].Store(math.Float64bits(0))
+	}
+
+	// 限价挂单跟着用户走 (见 limit.go)
+	for _, s := range old {
+		for _, t := range s.takeResting() {
+			shards[e.shardOf(t.Value, n)].addResting(t)
+		}
+	}
+
+	// 延迟任务同样跟着用户走 (见 delay.go)，新分片放不下的回复 ErrFull
+	if e.delay != nil {
+		var moved []Task
+		for _, s := range old {
+			moved = append(moved, s.takeDelayed()...)
+		}
+		for _, t := range moved {
+			shards[e.shardOf(t.Value, n)].deferTask(t)
+		}
+	}
+	for _, c := range resume {
+		c <- nil
+	}
+
+	// 迁移改写了 UserVolume，各分片空转时会重新发布读快照
+	for _, s := range shards {
+		if s.reads != nil {
+			s.reads.dirty.Store(true)
+		}
+	}
+
+	// 4. 发布新分片表，启动新分片、停止多余分片
+	e.shards.Store(&shards)
+	for _, s := range shards[min(len(old), n):] {
+		s.Start()
+	}
+	for _, s := range old[min(len(old), n):] {
+		s.stop.Store(true)
+	}
+}
+
+// drain 等待本分片的所有队列清空，并确认 Worker 已处理完手上的任务
+func (e *Engine) drain() {
+	for e.Queue.Len() != 0 || e.HighQueue.Len() != 0 || e.spill.n.Load() != 0 || e.fairUsed() != 0 || e.handoffPending() {
+		runtime.Gosched()
+	}
+	// 队列已空，屏障任务之前只可能还有一个正在处理的任务
+	barrier := Task{Type: TaskTypeQuery, Resp: e.getRespChan()}
+	for !e.submitLocal(barrier) {
+		runtime.Gosched()
+	}
+	<-barrier.Resp
+	e.putRespChan(barrier.Resp)
+}
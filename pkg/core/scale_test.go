@@ -0,0 +1,138 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 扩容后新分片有自己的 Worker：分片 0 被占住时，路由到分片 1 的用户照常处理
+func TestScaleUpRunsShardsInParallel(t *testing.T) {
+	e := NewEngine()
+	e.Start()
+	stopOnCleanup(t, e)
+
+	e.Scale(2)
+	if n := e.NumShards(); n != 2 {
+		t.Fatalf("NumShards = %d after Scale(2)", n)
+	}
+	release := haltShard(t, e, 0)
+	defer release()
+
+	// 默认路由 uid&(n-1)：奇数用户在分片 1
+	r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 3, Quantity: 1})
+	if err != nil {
+		t.Fatalf("Call on shard 1 while shard 0 is halted: %v", err)
+	}
+	if res, ok := AsOrder(r); !ok || res.Total != 3 {
+		t.Fatalf("order on shard 1 = %+v", r)
+	}
+}
+
+// 边提交边缩容：已入队的任务全部处理，成交额随用户迁移到剩下的分片，总和不变
+func TestScaleDownLosesNoTasks(t *testing.T) {
+	const (
+		producers = 4
+		perUser   = 500
+	)
+	e := NewEngine()
+	e.StartN(4)
+	stopOnCleanup(t, e)
+
+	var wg, started sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			begun := sync.OnceFunc(started.Done)
+			defer begun()
+			for i := range perUser {
+				if i == 1 {
+					begun()
+				}
+				// 关闸期间 TrySubmit 返回 ErrFull，SubmitRetry 会重试
+				if err := e.SubmitRetry(context.Background(), Task{Type: TaskTypeOrder, Value: p, Price: 1, Quantity: 1}); err != nil {
+					t.Errorf("SubmitRetry: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	started.Wait()
+	e.Scale(2)
+	e.Scale(1)
+	wg.Wait()
+	e.Flush()
+
+	if n := e.NumShards(); n != 1 {
+		t.Fatalf("NumShards = %d after Scale(1)", n)
+	}
+	for p := range producers {
+		v, err := e.TryGetUserVolume(p)
+		if err != nil {
+			t.Fatalf("TryGetUserVolume(%d): %v", p, err)
+		}
+		if v != perUser {
+			t.Errorf("user %d: volume = %v, want %d", p, v, perUser)
+		}
+	}
+}
+
+// 扩缩容期间到期的延迟订单：旧分片在迁移期间停住，不会与迁移同时写 UserVolume，成交额一笔不丢
+func TestScaleWithDueDelayedOrders(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	e := NewEngine()
+	e.EnableDelayQueue(256)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	const orders = 200
+	now := sysclock.Now()
+	for i := range orders {
+		at := now + int64(i+1)*int64(time.Microsecond)
+		if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: i % 64, Price: 1, Quantity: 1, ExecuteAt: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Flush()
+	if n := e.DelayedTasks(); n != orders {
+		t.Fatalf("DelayedTasks = %d, want %d", n, orders)
+	}
+
+	// 虚拟时钟一路推进，订单在迁移过程中陆续到期 (-race 下旧分片与迁移并发写 UserVolume 会被报告)
+	var stop atomic.Bool
+	ticked := make(chan struct{})
+	go func() {
+		defer close(ticked)
+		for !stop.Load() {
+			sysclock.Advance(time.Microsecond)
+			runtime.Gosched()
+		}
+	}()
+	for _, n := range []int{2, 4, 1, 2} {
+		e.Scale(n)
+	}
+	stop.Store(true)
+	<-ticked
+	sysclock.Advance(time.Millisecond)
+	for deadline := time.Now().Add(5 * time.Second); e.DelayedTasks() != 0; runtime.Gosched() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d delayed orders never ran", e.DelayedTasks())
+		}
+	}
+	e.Flush()
+	var total float64
+	for uid := range 64 {
+		total += e.GetUserVolume(uid)
+	}
+	if total != orders {
+		t.Fatalf("total volume = %v after scaling, want %d", total, orders)
+	}
+}
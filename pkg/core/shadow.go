@@ -0,0 +1,90 @@
+package core
+
+import "errors"
+
+// 影子模式 (Shadow Mode)：安全地上线重写过的订单处理逻辑
+//
+// 开启后每个订单仍由现有逻辑处理并回复客户端，同时把同一个订单交给新的 OrderHandler
+// 在一份状态拷贝上再跑一遍，比较两边的结果；影子逻辑永远不会修改真实状态，也不影响回复
+//
+// 影子逻辑看到的状态只是该用户的累计成交额和生效限额 (一份 OrderState 拷贝)，
+// 所以不需要对整个 UserVolume 做快照，开销是一次函数调用加几个比较
+
+// OrderState 是影子逻辑可见的订单状态拷贝
+type OrderState struct {
+	Volume float64 // 该用户处理前的累计成交额，处理器应在原地更新为处理后的值
+	Limit  float64 // 生效限额，0 表示不限
+}
+
+// OrderHandler 处理一个订单：计算金额、判定限额并更新 st.Volume (Dry-Run 时不得更新)
+// 在 C World 中调用，不能阻塞或分配内存
+type OrderHandler func(t *Task, st *OrderState) (total float64, err error)
+
+// ShadowDiff 描述一次分歧：两边的金额、错误或处理后的累计成交额不一致
+type ShadowDiff struct {
+	CorrID       uint64
+	UserID       int
+	Total        float64 // 现有逻辑
+	ShadowTotal  float64 // 影子逻辑
+	Err          error
+	ShadowErr    error
+	Volume       float64 // 现有逻辑处理后的累计成交额
+	ShadowVolume float64
+}
+
+type shadowRunner struct {
+	handler OrderHandler
+	sink    chan<- ShadowDiff
+
+	// 交给 handler 的任务和状态拷贝放在这里 (只有 Worker 访问)，
+	// 否则经过函数值调用的指针会逃逸，每个订单一次堆分配
+	task  Task
+	state OrderState
+}
+
+// EnableShadow 开启订单影子模式，必须在 Start 之前调用
+// 发现分歧时计入 Stats.ShadowMismatches，并把详情非阻塞地发送到 sink (sink 为 nil 或已满时丢弃)
+func (e *Engine) EnableShadow(h OrderHandler, sink chan<- ShadowDiff) {
+	e.shadow = &shadowRunner{handler: h, sink: sink}
+}
+
+// runShadow 在现有逻辑处理完订单之后调用
+// prev 是处理前的累计成交额，total/err 是现有逻辑的结果
+// 单独成函数且不内联：process 是 nosplit 的，在其中拷贝 Task 会让它的栈帧超限
+//
+//go:noinline
+func (e *Engine) runShadow(t Task, dry bool, prev, total float64, err error) {
+	r := e.shadow
+	r.task = t
+	r.task.DryRun = dry
+	uid := t.Value & 1023
+	r.state = OrderState{Volume: prev, Limit: e.limitFor(uid)}
+	sTotal, sErr := r.handler(&r.task, &r.state)
+	r.task = Task{} // 不持有 Resp 等引用
+	st := r.state
+	volume := e.UserVolume[uid]
+	if sTotal == total && st.Volume == volume && sameError(err, sErr) {
+		return
+	}
+	e.stats.shadowMismatches.Add(1)
+	if r.sink == nil {
+		return
+	}
+	select {
+	case r.sink <- ShadowDiff{
+		CorrID: t.CorrID, UserID: uid,
+		Total: total, ShadowTotal: sTotal,
+		Err: err, ShadowErr: sErr,
+		Volume: volume, ShadowVolume: st.Volume,
+	}:
+	default:
+	}
+}
+
+// sameError 判断两边的拒绝原因是否一致 (都成功，或是同一个哨兵错误)
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return errors.Is(b, a) || errors.Is(a, b)
+}
@@ -0,0 +1,64 @@
+package core
+
+import "testing"
+
+// faithfulHandler 是现有订单逻辑的等价重写
+func faithfulHandler(t *Task, st *OrderState) (float64, error) {
+	total := t.Price * float64(t.Quantity)
+	if st.Limit > 0 && st.Volume+total > st.Limit {
+		return total, ErrPositionLimit
+	}
+	if !t.DryRun {
+		st.Volume += total
+	}
+	return total, nil
+}
+
+// 等价的影子逻辑不产生分歧，包括被限额拒绝的订单
+func TestShadowAgrees(t *testing.T) {
+	e := NewEngineSync()
+	e.EnableShadow(faithfulHandler, nil)
+	e.SetUserLimit(1, 10)
+	for range 5 {
+		order(t, e, 1, 3) // 第 4 笔起被拒绝
+		order(t, e, 2, 3)
+	}
+	if n := e.Stats().ShadowMismatches; n != 0 {
+		t.Fatalf("ShadowMismatches = %d for an equivalent handler", n)
+	}
+}
+
+// 有 bug 的影子逻辑 (忘了检查限额) 被发现，而真实结果与状态不受影响
+func TestShadowDetectsDivergence(t *testing.T) {
+	diffs := make(chan ShadowDiff, 8)
+	e := NewEngineSync()
+	e.EnableShadow(func(t *Task, st *OrderState) (float64, error) {
+		total := t.Price * float64(t.Quantity)
+		st.Volume += total
+		return total, nil
+	}, diffs)
+	e.SetUserLimit(1, 10)
+
+	for i := range 2 {
+		if res := order(t, e, 1, 4); res.Err != nil {
+			t.Fatalf("order %d within the limit rejected: %v", i, res.Err)
+		}
+	}
+	if e.Stats().ShadowMismatches != 0 {
+		t.Fatal("mismatch reported while both sides agree")
+	}
+	// 第 3 笔就超限：现有逻辑拒绝，影子逻辑放行
+	if res := order(t, e, 1, 4); res.Err != ErrPositionLimit {
+		t.Fatalf("real result = %+v, want ErrPositionLimit", res)
+	}
+	if n := e.Stats().ShadowMismatches; n != 1 {
+		t.Fatalf("ShadowMismatches = %d, want 1", n)
+	}
+	if e.UserVolume[1] != 8 {
+		t.Fatalf("UserVolume[1] = %v, the shadow handler must not change real state", e.UserVolume[1])
+	}
+	d := <-diffs
+	if d.UserID != 1 || d.Err != ErrPositionLimit || d.ShadowErr != nil || d.Volume != 8 || d.ShadowVolume != 12 {
+		t.Fatalf("diff = %+v", d)
+	}
+}
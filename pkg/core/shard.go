@@ -0,0 +1,197 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// 多 Worker 分片：每个分片都是一个完整的 Engine (独立的队列、Arena、UserVolume、独占线程)
+// 用户状态按 UserID 分片，同一用户的订单永远落在同一个分片上，分片之间无需同步
+
+// StartN 启动 n 个 Worker 分片 (n 必须是 2 的幂，设置了 ShardRouter 时不限)，代替 Start
+// 分片 0 就是 e 本身，其余分片继承 e 的配置
+// 开启了热备或 WAL 的引擎只能是单 Worker，n > 1 时 panic
+func (e *Engine) StartN(n int) {
+	e.checkShardCount(n)
+	if e.standby != nil {
+		panic("core: standby requires an unsharded engine")
+	}
+	if e.wal != nil && n > 1 {
+		panic(ErrWALSharded.Error())
+	}
+	shards := make([]*Engine, n)
+	shards[0] = e
+	for i := 1; i < n; i++ {
+		shards[i] = e.newShard(i)
+	}
+	e.shards.Store(&shards)
+	for _, s := range shards {
+		s.Start()
+	}
+	e.replayImported()
+}
+
+// newShard 创建第 i 个分片，继承 e 的配置 (不启动)
+func (e *Engine) newShard(i int) *Engine {
+	s := newEngine()
+	s.shardID = i
+	s.router = e.router
+	s.clock = e.clock
+	s.LogSampler = e.LogSampler
+	s.LogTail = e.LogTail
+	s.LogSeq = e.LogSeq
+	s.LogRedact = e.LogRedact
+	s.AdmitPercent = e.AdmitPercent
+	s.BlockTimeout = e.BlockTimeout
+	s.SpillMax = e.SpillMax
+	s.Retry = e.Retry
+	// 熔断器是整个引擎共用的一个：各分片的 Worker 都向它报告结果
+	s.Breaker = e.Breaker
+	s.BreakerTypes = e.BreakerTypes
+	s.PositionLimit = e.PositionLimit
+	s.RTPriority = e.RTPriority
+	s.Spin = e.Spin
+	s.ClockStaleAfter = e.ClockStaleAfter
+	s.GCYieldRatio = e.GCYieldRatio
+	s.HousekeepingBudget = e.HousekeepingBudget
+	s.agingStep = e.agingStep
+	if e.cmds != nil {
+		s.EnableCommandQueue(e.cmds.q.Cap())
+	}
+	if e.fair != nil {
+		s.EnableFairQueuing(int(e.fair.maxPending))
+	}
+	if e.handoff != nil {
+		s.EnableHandoff()
+	}
+	// 在途上限是整个引擎共用的一个计数
+	s.admit = e.admit
+	s.red = e.red
+	if e.book != nil {
+		s.EnableRestingOrders(e.book.max)
+	}
+	s.market = e.market
+	if e.delay != nil {
+		s.EnableDelayQueue(cap(e.delay.heap))
+	}
+	e.copyTaskArenas(s)
+	if e.alloc.Fallback {
+		s.EnableHeapFallback()
+	}
+	if e.shadow != nil {
+		// 每个分片独占自己的 shadowRunner (其中的拷贝区只允许一个 Worker 使用)
+		s.EnableShadow(e.shadow.handler, e.shadow.sink)
+	}
+	if e.recovery != nil {
+		// 调用栈缓冲区只允许一个 Worker 使用
+		s.EnableRecovery(e.recovery.sink)
+	}
+	if e.reads != nil {
+		s.EnableReadLane(time.Duration(e.reads.lag))
+	}
+	if e.results != nil {
+		// 输出环是 SPSC，每个分片的 Worker 各写自己的一个
+		s.EnableResultRing(e.results.queue().Cap(), e.results.policy)
+	}
+	if e.stream != nil {
+		// 序列化缓冲区每个 Worker 一块，底层 Writer 共用
+		s.EnableResultStream(e.stream.m, e.stream.w)
+	}
+	e.inheritFaults(s)
+	if e.house != nil {
+		// 调度状态与统计按分片独立
+		for _, j := range e.house.jobs {
+			s.AddHousekeeping(j.name, time.Duration(j.every), j.fn)
+		}
+	}
+	// 分片依次绑到相邻的核上
+	if e.CPUAffinity >= 0 {
+		s.CPUAffinity = e.CPUAffinity + i
+	}
+	return s
+}
+
+// NumShards 返回分片数，未分片时为 1
+func (e *Engine) NumShards() int {
+	if p := e.shards.Load(); p != nil {
+		return len(*p)
+	}
+	return 1
+}
+
+// Shard 返回第 i 个分片
+func (e *Engine) Shard(i int) *Engine {
+	if p := e.shards.Load(); p != nil {
+		return (*p)[i]
+	}
+	return e
+}
+
+// ShardFor 返回 UserID 所在的分片下标
+// 默认与 UserVolume 相同，使用位运算代替求模；设置了 ShardRouter 时查一致性哈希的归属表
+func (e *Engine) ShardFor(uid int) int {
+	return e.shardOf(uid, e.NumShards())
+}
+
+// SubmitTo 跳过路由，直接投递到指定分片
+func (e *Engine) SubmitTo(shard int, t Task) bool {
+	return e.Shard(shard).submitLocal(t)
+}
+
+// route 选择任务所属的分片 (Value 对订单/查询来说就是 UserID)
+func (e *Engine) route(t Task) *Engine {
+	p := e.shards.Load()
+	if p == nil {
+		return e
+	}
+	shards := *p
+	return shards[e.shardOf(t.Value, len(shards))]
+}
+
+// ErrHedgeType 只有幂等的只读任务可以对冲提交
+var ErrHedgeType = errors.New("core: only calc and query tasks can be hedged")
+
+// hedgeable 报告 typ 是否可以对冲提交：副本可能都被执行，只能是幂等的只读任务
+func hedgeable(typ int) bool {
+	switch typ {
+	case TaskTypeCalc, TaskTypeQuery:
+		return true
+	}
+	return false
+}
+
+// SubmitHedged 把同一个只读任务同时投递到多个分片，返回最先到达的结果
+//
+// 每个副本都可能被执行，所以只接受幂等的只读任务 (TaskTypeCalc、TaskTypeQuery)，并且副本一律按 Dry-Run 执行：
+// 哪个分片赢都不会留下重复的状态。其它类型返回 ErrHedgeType。
+// Query 读的是执行它的分片上的 UserVolume：shards 应该只列出持有该用户状态 (或其副本) 的分片，
+// 不持有的分片回答的是它自己的值 (通常为 0)。
+//
+// 所有副本共享一个容量为 len(shards) 的响应 channel 和一个取消标记 (见 Task.Cancel)：
+// 第一个结果到达后设置标记，还在排队的副本被 Worker 直接跳过 (计入 Stats.Cancelled)；
+// 已经开始处理的副本照常完成，结果写入后无人读取，由 GC 回收，Worker 永远不会因此阻塞。
+// t.Resp 与 t.Cancel 会被忽略；所有分片都拒绝时返回 ErrFull。
+func (e *Engine) SubmitHedged(t Task, shards []int) (any, error) {
+	if !hedgeable(t.Type) {
+		return nil, ErrHedgeType
+	}
+	first := make(chan any, len(shards))
+	cancel := new(atomic.Bool)
+	t.Resp, t.Cancel, t.DryRun = first, cancel, true
+	sent := 0
+	for _, i := range shards {
+		if e.SubmitTo(i, t) {
+			sent++
+		}
+	}
+	if sent == 0 {
+		return nil, ErrFull
+	}
+	r := <-first
+	cancel.Store(true)
+	if err, ok := r.(error); ok {
+		return nil, err
+	}
+	return r, nil
+}
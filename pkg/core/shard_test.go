@@ -0,0 +1,71 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+// haltShard 让分片 i 的 Worker 停在 TaskTypeHalt 上，返回放行函数
+func haltShard(t *testing.T, e *Engine, i int) (release func()) {
+	t.Helper()
+	resp := make(chan any) // 无缓冲：Worker 先把 nil 交给我们，再等我们交回去
+	if !e.SubmitTo(i, Task{Type: TaskTypeHalt, Resp: resp, QoS: QoSGold}) {
+		t.Fatalf("could not halt shard %d", i)
+	}
+	<-resp
+	return func() { resp <- nil }
+}
+
+// 最先回复的分片胜出，落败分片上还在排队的副本被取消，谁都不修改状态
+func TestSubmitHedgedFirstWins(t *testing.T) {
+	e := NewEngine()
+	e.StartN(2)
+	stopOnCleanup(t, e)
+	release := haltShard(t, e, 1)
+
+	r, err := e.SubmitHedged(Task{Type: TaskTypeCalc, Value: 21}, []int{1, 0})
+	if err != nil || r != CalcResult(42) {
+		t.Fatalf("SubmitHedged = %v, %v; want 42", r, err)
+	}
+	release()
+	e.Flush()
+
+	if c := e.Shard(1).Stats().Cancelled; c != 1 {
+		t.Fatalf("losing copy: Cancelled = %d, want 1", c)
+	}
+	for i := range 2 {
+		if v := e.Shard(i).UserVolume[0]; v != 0 {
+			t.Fatalf("shard %d UserVolume[0] = %v, hedged copies must not change state", i, v)
+		}
+	}
+}
+
+// 查询是幂等的只读任务，同样可以对冲：卡住的分片上的副本被取消，持有状态的分片给出结果
+func TestSubmitHedgedQuery(t *testing.T) {
+	e := NewEngine()
+	e.StartN(2)
+	stopOnCleanup(t, e)
+	const uid = 3
+	owner := e.ShardFor(uid)
+	order(t, e, uid, 10)
+	release := haltShard(t, e, 1-owner)
+
+	r, err := e.SubmitHedged(Task{Type: TaskTypeQuery, Value: uid}, []int{1 - owner, owner})
+	if err != nil || r != 10.0 {
+		t.Fatalf("hedged query = %v, %v; want 10", r, err)
+	}
+	release()
+	e.Flush()
+	if c := e.Shard(1 - owner).Stats().Cancelled; c != 1 {
+		t.Fatalf("losing copy: Cancelled = %d, want 1", c)
+	}
+}
+
+func TestSubmitHedgedRejectsStatefulTasks(t *testing.T) {
+	e := NewEngineSync()
+	for _, typ := range []int{TaskTypeOrder, TaskTypeResetVolume, TaskTypeBatchOrder} {
+		if _, err := e.SubmitHedged(Task{Type: typ}, []int{0}); !errors.Is(err, ErrHedgeType) {
+			t.Errorf("type %d: err = %v, want ErrHedgeType", typ, err)
+		}
+	}
+}
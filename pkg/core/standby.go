@@ -0,0 +1,155 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// 热备 Worker：单 Worker 引擎的故障切换
+//
+// 有状态的订单 Worker 只有一个，它一旦卡死 (死循环、阻塞在无人接收的 Resp 上、长时间的 Sleep)，
+// 正在处理的任务丢失，队列也再没有人消费。EnableStandby 之后 Start 额外启动一个看门狗，
+// 它手里有一个预热好的备用 Worker (独立的 Arena 和 UserVolume，配置继承主 Worker，尚未启动)：
+//
+// 状态延续 (影子副本)：
+//   - 主 Worker 对 UserVolume 的每次修改都经过 WAL 的钩子，钩子顺便把用户记入本任务的 tail
+//   - 下一轮循环取任务之前，把 tail 中用户的当前值提交到影子副本 (原子变量)
+//   - 所以影子始终停在 "最后一个处理完的任务" 之后，正在处理的任务改了一半的状态不会进入影子
+//
+// 切换条件：主 Worker 每轮循环递增心跳计数，看门狗每 staleAfter 检查一次，
+// 一个完整周期内计数没有变化即判定主 Worker 失联 (被 Export 停住的 Worker 不算)
+//
+// 同一时刻只有一个 Worker 修改状态：
+//   - 主 Worker 提交影子与 pop 之前把租约从 idle 改为 busy，pop 之后改回 idle；看门狗只能把 idle 改为 taken
+//   - 租约被拿走后主 Worker 再也拿不到它：醒来时处理完手上的任务 (写的是自己的 UserVolume，已经没有人读)，
+//     在下一轮循环开头退出并归还 Arena，不再碰队列和影子
+//   - 备机的 UserVolume 是独立的一份，旧 Worker 的迟到写入影响不到它
+//
+// 切换过程 (只发生一次)：关闭提交闸门并等待在途提交 (与 Scale 相同，期间 TrySubmit 返回 ErrFull) →
+// 影子与按用户的限额拷贝到备机 → 发布只含备机的分片表 → 启动备机 → 打开闸门。
+// 备机每轮先按原顺序取旧 Worker 队列中剩余的任务，取空之后才处理自己的队列
+//
+// 限制：
+//   - 切换时旧 Worker 手上的任务不会延续：它的效果不在影子里，但如果旧 Worker 之后醒来，调用方仍可能收到它的回复
+//   - 限价单的参考价与挂单、WAL、窗口聚合、读通道等 Worker 独占的组件留在旧 Worker 上，不迁移
+//   - 只支持单 Worker 引擎 (StartN 会 panic)，不要与 Scale/Export 同时使用；同步模式没有 Worker，不启动看门狗
+//   - 备机不绑核也不使用实时优先级：旧 Worker 可能还在原来的核上空转
+
+// DefaultStandbyStaleAfter 是 EnableStandby 的默认失联判定时间
+const DefaultStandbyStaleAfter = 100 * time.Millisecond
+
+// 租约状态
+const (
+	leaseIdle  int32 = iota // 主 Worker 持有，当前没有访问队列和影子
+	leaseBusy               // 主 Worker 正在提交影子或 pop
+	leaseTaken              // 已被看门狗拿走，主 Worker 必须退出
+)
+
+type standby struct {
+	staleAfter time.Duration
+	beats      atomic.Uint64
+	lease      atomic.Int32
+	// parked 主 Worker 被 TaskTypeHalt 停住 (见 parkWorker)，此时心跳停止是预期内的
+	parked    atomic.Bool
+	failovers atomic.Uint64
+	// shadow UserVolume 的影子副本 (float64 bits)，只在持有租约时写入
+	shadow [stateUsers]atomic.Uint64
+	// tail 当前任务修改过的用户 (只由主 Worker 访问)
+	tail []uint16
+}
+
+// EnableStandby 为单 Worker 引擎准备一个热备 Worker，主 Worker 心跳停止超过 staleAfter
+// (<= 0 时使用 DefaultStandbyStaleAfter) 时由它接管，必须在 Start 之前调用
+func (e *Engine) EnableStandby(staleAfter time.Duration) {
+	if e.NumShards() > 1 {
+		panic("core: standby requires an unsharded engine")
+	}
+	if staleAfter <= 0 {
+		staleAfter = DefaultStandbyStaleAfter
+	}
+	e.standby = &standby{staleAfter: staleAfter, tail: make([]uint16, 0, stateUsers)}
+}
+
+// FailedOver 报告热备 Worker 是否已经接管
+func (e *Engine) FailedOver() bool {
+	return e.standby != nil && e.standby.failovers.Load() != 0
+}
+
+// startStandby 在 Start 中调用：初始化影子并启动看门狗
+// 备机此时创建，继承的是 Start 时的最终配置
+func (e *Engine) startStandby() {
+	sb := e.standby
+	for uid := range e.UserVolume {
+		sb.shadow[uid].Store(math.Float64bits(e.UserVolume[uid]))
+	}
+	s := e.newShard(0)
+	s.CPUAffinity = -1
+	s.RTPriority = 0
+	go e.watchStandby(sb, s)
+}
+
+// mark 记录当前任务修改了 uid (由 WAL 钩子调用)
+func (sb *standby) mark(uid int) {
+	sb.tail = append(sb.tail, uint16(uid))
+}
+
+// hold 在主 Worker 每轮 pop 之前调用：心跳、拿租约、提交上一个任务的 tail
+// 返回 false 表示已被接管，主 Worker 应当退出
+func (sb *standby) hold(e *Engine) bool {
+	sb.beats.Add(1)
+	if !sb.lease.CompareAndSwap(leaseIdle, leaseBusy) {
+		return false
+	}
+	for _, uid := range sb.tail {
+		sb.shadow[uid].Store(math.Float64bits(e.UserVolume[uid]))
+	}
+	sb.tail = sb.tail[:0]
+	return true
+}
+
+// unhold 在 pop 之后归还租约
+func (sb *standby) unhold() {
+	sb.lease.Store(leaseIdle)
+}
+
+// watchStandby 每 staleAfter 检查一次心跳，失联时接管
+// 主 Worker 恰好持有租约 (正在 pop) 时拿不到，下个周期再试
+func (e *Engine) watchStandby(sb *standby, s *Engine) {
+	tick := time.NewTicker(sb.staleAfter)
+	defer tick.Stop()
+	last := sb.beats.Load()
+	for range tick.C {
+		beats := sb.beats.Load()
+		if beats != last || sb.parked.Load() {
+			last = beats
+			continue
+		}
+		if sb.lease.CompareAndSwap(leaseIdle, leaseTaken) {
+			e.promote(sb, s)
+			return
+		}
+	}
+}
+
+// promote 在看门狗拿到租约后把备机发布为唯一的分片
+// 不持有 scaleMu：eachShard 可能正持有它、等着卡死的主 Worker 回复，那个任务要靠备机来处理
+func (e *Engine) promote(sb *standby, s *Engine) {
+	e.scaling.Store(true)
+	for e.inflight.Load() != 0 {
+		runtime.Gosched()
+	}
+	for uid := range s.UserVolume {
+		s.UserVolume[uid] = math.Float64frombits(sb.shadow[uid].Load())
+		s.userLimits[uid].Store(e.userLimits[uid].Load())
+	}
+	s.takeover = e
+	shards := []*Engine{s}
+	e.shards.Store(&shards)
+	s.Start()
+	sb.failovers.Add(1)
+	e.scaling.Store(false)
+	fmt.Println("[Core] primary worker stalled, standby promoted")
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// 主 Worker 卡在一个任务中：备机在失联判定之后接管，状态延续到最后一个处理完的任务，
+// 卡住的任务不计入；旧 Worker 醒来之后的写入影响不到备机
+func TestStandbyTakesOverStalledPrimary(t *testing.T) {
+	e := NewEngine()
+	e.EnableStandby(20 * time.Millisecond)
+	stalled, stall := make(chan struct{}), make(chan struct{})
+	e.EnableShadow(func(t *Task, st *OrderState) (float64, error) {
+		if t.CorrID == 99 {
+			close(stalled)
+			<-stall // 模拟主 Worker 卡死
+		}
+		return t.Price * float64(t.Quantity), nil
+	}, nil)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	for range 3 {
+		order(t, e, 1, 10)
+	}
+	if e.FailedOver() {
+		t.Fatal("failed over while the primary was healthy")
+	}
+
+	stuck := make(chan any, 1)
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 100, Quantity: 1, CorrID: 99, Resp: stuck}); err != nil {
+		t.Fatal(err)
+	}
+	<-stalled
+	for deadline := time.Now().Add(5 * time.Second); !e.FailedOver(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			close(stall)
+			t.Fatal("standby did not take over the stalled primary")
+		}
+	}
+	if got := e.Stats().Failovers; got != 1 {
+		t.Fatalf("Failovers = %d, want 1", got)
+	}
+
+	if r := order(t, e, 1, 5); r.Err != nil {
+		t.Fatalf("order after failover: %v", r.Err)
+	}
+	if v, err := e.TryGetUserVolume(1); err != nil || v != 35 {
+		t.Fatalf("UserVolume[1] after failover = %v (%v), want 35 (the stalled order does not count)", v, err)
+	}
+
+	// 旧 Worker 醒来处理完手上的任务后退出，写的是它自己那份状态
+	close(stall)
+	select {
+	case <-stuck:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stalled primary never finished its task")
+	}
+	if v := e.GetUserVolume(1); v != 35 {
+		t.Fatalf("UserVolume[1] = %v after the old primary woke, want 35", v)
+	}
+}
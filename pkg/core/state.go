@@ -0,0 +1,228 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"runtime"
+	"slices"
+)
+
+// 引擎状态快照 (热备)
+//
+// 格式 (小端):
+//
+//	[4]"ENGS" | [2]uint16 version | [2]保留 | [4]uint32 users
+//	users × ( [8]float64 volume | [8]float64 limit )
+//	[8]float64 market | [4]uint32 resting × ( [4]uint32 len | task )   (version 2)
+//
+// market 是限价单的参考价 (取分片 0 的，SetMarketPrice 对所有分片相同)；
+// resting 是各分片的限价挂单，task 的编码与 Export 的任务记录相同 (见 export.go)。
+// version 1 只有用户表，LoadState 仍然接受 (参考价为 0，没有挂单)；以后新增状态时提升 version。
+// 快照与恢复都作为控制任务在 Worker 中执行，与订单串行，每个分片只读写自己负责的用户
+
+const (
+	stateMagic   = "ENGS"
+	stateVersion = 2
+	stateHeader  = 12
+	stateUsers   = 1024
+	stateEntry   = 16
+	// stateTable 用户表结束的位置，也是 version 1 快照的长度
+	stateTable = stateHeader + stateUsers*stateEntry
+	// stateTail 参考价与挂单数
+	stateTail = 12
+)
+
+// StateSize 是没有挂单时 SnapshotState 输出的字节数 (每个挂单再加一条任务记录)
+const StateSize = stateTable + stateTail
+
+// ErrBadState 快照数据格式不合法或版本不兼容
+var ErrBadState = errors.New("core: bad state snapshot")
+
+// shardTail 是 TaskTypeSnapshot 的回复：用户表以外、不能按用户切分写入 buf 的部分
+type shardTail struct {
+	market  float64
+	resting []Task
+}
+
+// SnapshotState 导出引擎 (所有分片) 的可变状态，备机可以用 LoadState 恢复
+func (e *Engine) SnapshotState() ([]byte, error) {
+	buf := newStateBuf()
+	replies := e.eachShard(Task{Type: TaskTypeSnapshot, State: buf, QoS: QoSGold})
+	count := 0
+	for i, r := range replies {
+		tail, ok := r.(shardTail)
+		if !ok {
+			continue
+		}
+		if i == 0 {
+			binary.LittleEndian.PutUint64(buf[stateTable:], math.Float64bits(tail.market))
+		}
+		for j := range tail.resting {
+			buf = appendExportTask(buf, &tail.resting[j])
+			count++
+		}
+	}
+	binary.LittleEndian.PutUint32(buf[stateTable+8:], uint32(count))
+	return buf, nil
+}
+
+// LoadState 用 SnapshotState 的输出覆盖引擎状态
+// 快照与当前分片数无关：每个分片按当前路由取回自己负责的用户与挂单。
+// 被覆盖的挂单回复 ErrLimitNotMet；没有开启 EnableRestingOrders 的引擎丢弃快照中的挂单
+func (e *Engine) LoadState(b []byte) error {
+	if !validState(b) {
+		return ErrBadState
+	}
+	e.eachShard(Task{Type: TaskTypeLoadState, State: b, QoS: QoSGold})
+	return nil
+}
+
+// newStateBuf 分配一个只写好了头部的快照缓冲区 (参考价为 0，没有挂单)
+func newStateBuf() []byte {
+	buf := make([]byte, StateSize)
+	copy(buf, stateMagic)
+	binary.LittleEndian.PutUint16(buf[4:], stateVersion)
+	binary.LittleEndian.PutUint32(buf[8:], stateUsers)
+	return buf
+}
+
+func validState(b []byte) bool {
+	if len(b) < stateTable || string(b[:4]) != stateMagic || binary.LittleEndian.Uint32(b[8:]) != stateUsers {
+		return false
+	}
+	switch binary.LittleEndian.Uint16(b[4:]) {
+	case 1:
+		return len(b) == stateTable
+	case stateVersion:
+		_, _, err := stateRest(b)
+		return err == nil
+	}
+	return false
+}
+
+// stateRest 解码快照的参考价与挂单，version 1 的快照两者都没有
+func stateRest(b []byte) (market float64, resting []Task, err error) {
+	if binary.LittleEndian.Uint16(b[4:]) == 1 {
+		return 0, nil, nil
+	}
+	if len(b) < StateSize {
+		return 0, nil, ErrBadState
+	}
+	market = math.Float64frombits(binary.LittleEndian.Uint64(b[stateTable:]))
+	count := binary.LittleEndian.Uint32(b[stateTable+8:])
+	rest := b[StateSize:]
+	for range count {
+		if len(rest) < 4 {
+			return 0, nil, ErrBadState
+		}
+		n := binary.LittleEndian.Uint32(rest)
+		if uint64(n) > uint64(len(rest)-4) {
+			return 0, nil, ErrBadState
+		}
+		t, err := decodeExportTask(rest[4:4+n], exportVersion)
+		if err != nil || t.Type != TaskTypeOrder || t.OrderType != OrderLimit {
+			return 0, nil, ErrBadState
+		}
+		resting = append(resting, t)
+		rest = rest[4+n:]
+	}
+	if len(rest) != 0 {
+		return 0, nil, ErrBadState
+	}
+	return market, resting, nil
+}
+
+// eachShard 把控制任务 t 投递到每个分片并等待全部完成，按分片顺序返回各分片的回复
+// 持有 scaleMu，保证期间分片表不变；Value 携带分片数，供 Worker 判断用户归属
+func (e *Engine) eachShard(t Task) []any {
+	scaleMu.Lock()
+	defer scaleMu.Unlock()
+	n := e.NumShards()
+	t.Value = n
+	replies := make([]any, n)
+	for i := 0; i < n; i++ {
+		s := e.Shard(i)
+		c := t
+		c.Resp = s.getRespChan()
+		for !s.submitLocal(c) {
+			runtime.Gosched()
+		}
+		replies[i] = <-c.Resp
+		s.putRespChan(c.Resp)
+	}
+	return replies
+}
+
+// processState 在 Worker 中执行快照/恢复，只处理归属本分片 (shardOf == shardID) 的用户
+// 写入的是各分片互不重叠的区域，多个分片并发写同一个 buf 是安全的
+// TaskTypeHalt (见 export.go) 与 TaskTypeMarketPrice (见 limit.go) 也从这里进入：process 是 nosplit 的，多一个 case 会超出栈上限
+// Dry-Run 时恢复与更新参考价都不生效，只回复
+func (e *Engine) processState(t Task, shards int, dry bool) {
+	if t.Type == TaskTypeHalt {
+		e.parkWorker(t.Resp)
+		return
+	}
+	if t.Type == TaskTypeMarketPrice {
+		if !dry {
+			e.setMarket(t.Price)
+		}
+		e.reply(t.Resp, nil)
+		return
+	}
+	if t.Type == TaskTypeSnapshot {
+		e.copyState(t.State, shards, true)
+		var tail shardTail
+		tail.market = e.market
+		if e.book != nil {
+			tail.resting = slices.Clone(e.book.orders)
+		}
+		e.reply(t.Resp, tail)
+		return
+	}
+	if !dry {
+		e.copyState(t.State, shards, false)
+		e.loadRest(t.State, shards)
+	}
+	e.reply(t.Resp, nil)
+}
+
+// loadRest 在 Worker 中恢复参考价，并用快照中归属本分片的挂单替换当前的挂单
+// 快照已经在 LoadState 中校验过；恢复的挂单没有调用方在等，各自得到一个新的 Resp (结果由 GC 回收)
+func (e *Engine) loadRest(buf []byte, shards int) {
+	market, resting, _ := stateRest(buf)
+	e.market = market
+	b := e.book
+	if b == nil {
+		return
+	}
+	for _, t := range e.takeResting() {
+		e.reply(t.Resp, OrderResult{Total: t.LimitPrice * float64(t.Quantity), Err: ErrLimitNotMet, CorrID: t.CorrID})
+	}
+	for _, t := range resting {
+		if e.shardOf(t.Value, shards) == e.shardID {
+			t.Resp = make(chan any, 1)
+			e.addResting(t)
+		}
+	}
+}
+
+// copyState 在 buf 与本分片负责的用户之间拷贝状态，snapshot 为 true 时导出，否则恢复
+// 只能由 Worker 调用，或者在 Worker 停住 (未启动、已暂停) 时调用
+func (e *Engine) copyState(buf []byte, shards int, snapshot bool) {
+	for uid := 0; uid < stateUsers; uid++ {
+		if e.shardOf(uid, shards) != e.shardID {
+			continue
+		}
+		off := stateHeader + uid*stateEntry
+		entry := buf[off : off+stateEntry]
+		if snapshot {
+			binary.LittleEndian.PutUint64(entry, math.Float64bits(e.UserVolume[uid]))
+			binary.LittleEndian.PutUint64(entry[8:], e.userLimits[uid].Load())
+		} else {
+			e.UserVolume[uid] = math.Float64frombits(binary.LittleEndian.Uint64(entry))
+			e.walSetVolume(uid, e.UserVolume[uid])
+			e.userLimits[uid].Store(binary.LittleEndian.Uint64(entry[8:]))
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// 快照在另一个 (分片数不同的) 引擎上恢复后，成交额与按用户的限额逐个相同
+func TestSnapshotLoadState(t *testing.T) {
+	src := NewEngine()
+	src.StartN(2)
+	stopOnCleanup(t, src)
+	for uid := range 8 {
+		order(t, src, uid, float64(uid+1)*1.5)
+		order(t, src, uid, 0.25)
+	}
+	src.SetUserLimit(3, 1000)
+	src.SetUserLimit(1023, 7)
+
+	b, err := src.SnapshotState()
+	if err != nil {
+		t.Fatalf("SnapshotState: %v", err)
+	}
+	if len(b) != StateSize {
+		t.Fatalf("snapshot is %d bytes, want %d", len(b), StateSize)
+	}
+
+	dst := NewEngine()
+	dst.StartN(4)
+	stopOnCleanup(t, dst)
+	order(t, dst, 100, 9) // 恢复覆盖原有状态
+	if err := dst.LoadState(b); err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+
+	want := make([]float64, stateUsers)
+	got := make([]float64, stateUsers)
+	src.SnapshotVolumes(0, want)
+	dst.SnapshotVolumes(0, got)
+	for uid := range stateUsers {
+		if got[uid] != want[uid] {
+			t.Errorf("user %d: volume = %v, want %v", uid, got[uid], want[uid])
+		}
+		if g, w := userLimit(dst, uid), userLimit(src, uid); g != w {
+			t.Errorf("user %d: limit = %v, want %v", uid, g, w)
+		}
+	}
+	if got[100] != 0 || userLimit(dst, 3) != 1000 {
+		t.Fatalf("LoadState did not replace the state: volume[100] = %v, limit[3] = %v", got[100], userLimit(dst, 3))
+	}
+}
+
+// 参考价与挂单随快照恢复：挂单按新引擎的分片归属挂回去，之后的报价照常撮合
+func TestSnapshotRestingOrders(t *testing.T) {
+	src := NewEngine()
+	src.EnableRestingOrders(8)
+	src.StartN(2)
+	stopOnCleanup(t, src)
+	src.SetMarketPrice(10)
+	for uid := 1; uid <= 3; uid++ {
+		if err := src.TrySubmit(Task{Type: TaskTypeOrder, Value: uid, OrderType: OrderLimit, LimitPrice: 8, Quantity: uid, Resp: make(chan any, 1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.Flush()
+	b, _ := src.SnapshotState()
+	if len(b) <= StateSize {
+		t.Fatalf("snapshot with 3 resting orders is %d bytes, want more than %d", len(b), StateSize)
+	}
+
+	dst := NewEngine()
+	dst.EnableRestingOrders(8)
+	dst.StartN(4)
+	stopOnCleanup(t, dst)
+	displaced := make(chan any, 1)
+	dst.SetMarketPrice(20)
+	if err := dst.TrySubmit(Task{Type: TaskTypeOrder, Value: 9, OrderType: OrderLimit, LimitPrice: 5, Quantity: 1, Resp: displaced}); err != nil {
+		t.Fatal(err)
+	}
+	dst.Flush() // 控制任务走高优先级队列，先让这个订单挂上
+	if err := dst.LoadState(b); err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if r, _ := (<-displaced).(OrderResult); !errors.Is(r.Err, ErrLimitNotMet) {
+		t.Fatalf("resting order replaced by LoadState: %+v, want ErrLimitNotMet", r)
+	}
+	if n := dst.RestingOrders(); n != 3 {
+		t.Fatalf("RestingOrders after LoadState = %d, want 3", n)
+	}
+	// 参考价恢复为 10：限价 10 的新订单立即成交
+	if r, err := dst.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 5, OrderType: OrderLimit, LimitPrice: 10, Quantity: 1}); err != nil || r.(OrderResult).Total != 10 {
+		t.Fatalf("limit order at the restored market price: %v, %v", r, err)
+	}
+	dst.SetMarketPrice(7)
+	for uid := 1; uid <= 3; uid++ {
+		if v := dst.GetUserVolume(uid); v != float64(7*uid) {
+			t.Errorf("user %d: volume after the fill = %v, want %v", uid, v, 7*uid)
+		}
+	}
+	if n := dst.RestingOrders(); n != 0 {
+		t.Fatalf("RestingOrders after the fill = %d, want 0", n)
+	}
+}
+
+// version 1 的快照 (只有用户表) 仍然可以恢复
+func TestLoadStateVersion1(t *testing.T) {
+	src := NewEngineSync()
+	order(t, src, 4, 2.5)
+	b, _ := src.SnapshotState()
+	v1 := append([]byte(nil), b[:stateTable]...)
+	v1[4] = 1
+
+	dst := NewEngineSync()
+	if err := dst.LoadState(v1); err != nil {
+		t.Fatalf("LoadState(version 1): %v", err)
+	}
+	if v := dst.GetUserVolume(4); v != 2.5 {
+		t.Fatalf("UserVolume[4] = %v, want 2.5", v)
+	}
+}
+
+func TestLoadStateRejectsBadInput(t *testing.T) {
+	e := NewEngineSync()
+	good, _ := e.SnapshotState()
+	missing := append([]byte(nil), good...)
+	missing[stateTable+8] = 1 // 声称有一个挂单，但没有记录
+	for name, b := range map[string][]byte{
+		"empty":   nil,
+		"short":   good[:StateSize-1],
+		"magic":   append([]byte("XXXX"), good[4:]...),
+		"version": append(append([]byte{}, good[:4]...), append([]byte{9, 0}, good[6:]...)...),
+		"resting": missing,
+		"extra":   append(append([]byte(nil), good...), 0),
+	} {
+		if err := e.LoadState(b); !errors.Is(err, ErrBadState) {
+			t.Errorf("%s: err = %v, want ErrBadState", name, err)
+		}
+	}
+}
+
+// userLimit 读取用户所属分片上设置的限额
+func userLimit(e *Engine, uid int) float64 {
+	return math.Float64frombits(e.Shard(e.ShardFor(uid)).userLimits[uid].Load())
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"weak"
+)
+
+// Stats 是引擎运行状态的快照
+type Stats struct {
+	Processed      uint64 // 已处理任务数
+	QueueLen       uint64 // 普通队列当前长度
+	QueueCap       uint64
+	HighQueueLen   uint64 // 高优先级队列当前长度
+	HighQueueCap   uint64
+	ArenaUsed      int64 // 最近一个任务结束时 (Reset 前) 的 Arena 使用量
+	ArenaCap       int64
+	ArenaHighWater int64
+	ClockFallbacks uint64 // sysclock 过期导致回退到 time.Now() 的次数
+	Breaker        string // 熔断器状态 (未启用时为空)
+	GCYields       uint64 // GC 前夕主动让出的次数
+	Expired        uint64 // 开始处理前已过期而被丢弃的任务数
+	Cancelled      uint64 // 开始处理前已被取消而跳过的任务数 (见 Task.Cancel)
+	Aged           uint64 // 因优先级老化而先于 High Lane 处理的普通任务数 (见 EnablePriorityAging)
+	Commands       uint64 // 执行的控制命令数 (见 EnableCommandQueue)，不计入 Processed
+	LogOverflows   uint64 // 订单日志超出 LogBuf 容量 (发生了堆分配) 的次数
+	Rejected       uint64 // 入队失败 (ErrFull / ErrCircuitOpen / ErrOverloaded / ErrRateLimited / ErrMemoryPressure) 的任务数
+	Latency        Histogram
+
+	ShadowMismatches  uint64 // 影子模式下与现有逻辑结果不一致的订单数
+	Panics            uint64 // 处理过程中 panic 并被恢复的任务数 (见 EnableRecovery)
+	CalcPool          uint64 // 由 calc 池处理的任务数 (不计入 Processed，见 EnableCalcPool)
+	ResultsDropped    uint64 // 输出环满而被丢弃的结果数 (见 EnableResultRing)
+	RepliesDropped    uint64 // Resp 缓冲已满而被丢弃的结果数 (见 reply.go)
+	HeapFallbacks     uint64 // Arena 放不下而退回堆分配的次数 (见 EnableHeapFallback)
+	HeapFallbackBytes uint64 // 退回堆分配的累计字节数
+	AvgProcessNanos   int64  // 单个任务处理耗时的指数移动平均 (见 AvgProcessNanos)
+	Coalesced         uint64 // 合并到进行中的相同 calc 而没有入队的请求数 (见 EnableCalcCoalescing)
+	Failovers         uint64 // 热备 Worker 接管的次数 (见 EnableStandby)
+
+	// CalcArena / OrderArena 分片 Worker 上单个 calc / 订单任务消耗的 Arena 字节数，用于给 AcquireSized 定容量
+	// calc 池 Worker 使用自己的 Arena，不计入 CalcArena
+	CalcArena  ArenaUsage
+	OrderArena ArenaUsage
+}
+
+// ArenaUsage 是某一类任务的 Arena 用量统计 (单个任务 process 前后的 Used 差值)
+type ArenaUsage struct {
+	Tasks     uint64 // 统计到的任务数
+	HighWater int64  // 单个任务的最大用量
+	Avg       int64  // 平均每个任务的用量
+}
+
+// arenaUsage 只由本分片的 Worker 写入，Go World 原子读取
+type arenaUsage struct {
+	tasks atomic.Uint64
+	high  atomic.Int64
+	sum   atomic.Int64
+}
+
+func (u *arenaUsage) observe(n int64) {
+	u.tasks.Add(1)
+	u.sum.Add(n)
+	if n > u.high.Load() {
+		u.high.Store(n)
+	}
+}
+
+func (u *arenaUsage) snapshot() ArenaUsage {
+	s := ArenaUsage{Tasks: u.tasks.Load(), HighWater: u.high.Load()}
+	if s.Tasks != 0 {
+		s.Avg = u.sum.Load() / int64(s.Tasks)
+	}
+	return s
+}
+
+// LatencyBounds 是任务延迟 (入队 -> 处理完成) 直方图的桶上界，单位秒
+// 延迟由 sysclock 计算，精度为 1ms，亚毫秒的任务都会落在第一个桶
+var LatencyBounds = [...]float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// Histogram 是延迟直方图的快照
+// Counts[i] 是落在 (LatencyBounds[i-1], LatencyBounds[i]] 的次数 (非累计)，最后一个是 +Inf 桶
+type Histogram struct {
+	Counts [len(LatencyBounds) + 1]uint64
+	Count  uint64
+	Sum    float64 // 秒
+}
+
+// latencyHist 只由本分片的 Worker 写入，Go World 原子读取
+// 各字段分别读取，快照可能横跨一次 observe，对监控而言可以接受
+type latencyHist struct {
+	counts [len(LatencyBounds) + 1]atomic.Uint64
+	count  atomic.Uint64
+	sumNs  atomic.Int64
+}
+
+// observe 记录一次延迟 (纳秒)，桶很少，线性查找即可
+func (h *latencyHist) observe(ns int64) {
+	sec := float64(ns) / 1e9
+	i := 0
+	for i < len(LatencyBounds) && sec > LatencyBounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sumNs.Add(ns)
+}
+
+func (h *latencyHist) snapshot() Histogram {
+	var s Histogram
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+	}
+	s.Count = h.count.Load()
+	s.Sum = float64(h.sumNs.Load()) / 1e9
+	return s
+}
+
+// engineStats 由 C World 写入、Go World 读取，全部使用原子变量
+// Arena 本身不是并发安全的，所以 Worker 在每次 Reset 前把数值发布到这里
+type engineStats struct {
+	processed atomic.Uint64
+	arenaUsed atomic.Int64
+	arenaHigh atomic.Int64
+
+	clockFallbacks atomic.Uint64
+	gcYields       atomic.Uint64
+	aged           atomic.Uint64
+	commands       atomic.Uint64
+	expired        atomic.Uint64
+	cancelled      atomic.Uint64
+	logOverflows   atomic.Uint64
+	rejected       atomic.Uint64
+
+	shadowMismatches atomic.Uint64
+	panics           atomic.Uint64
+	repliesDropped   atomic.Uint64
+
+	latency latencyHist
+	procEMA procEMA
+
+	calcArena  arenaUsage
+	orderArena arenaUsage
+}
+
+// record 在 C World 中每处理完一个任务调用一次 (Reset 之前)
+func (s *engineStats) record(used int) {
+	s.processed.Add(1)
+	s.arenaUsed.Store(int64(used))
+	if int64(used) > s.arenaHigh.Load() {
+		s.arenaHigh.Store(int64(used))
+	}
+}
+
+// recordArena 按任务类型记录一个任务消耗的 Arena 字节数，其它类型忽略
+func (s *engineStats) recordArena(typ int, n int64) {
+	switch typ {
+	case TaskTypeCalc:
+		s.calcArena.observe(n)
+	case TaskTypeOrder:
+		s.orderArena.observe(n)
+	}
+}
+
+// Stats 返回引擎状态快照，可在任意 goroutine 调用
+func (e *Engine) Stats() Stats {
+	s := Stats{
+		Processed:      e.stats.processed.Load(),
+		QueueLen:       e.Queue.Len(),
+		QueueCap:       e.Queue.Cap(),
+		HighQueueLen:   e.HighQueue.Len(),
+		HighQueueCap:   e.HighQueue.Cap(),
+		ArenaUsed:      e.stats.arenaUsed.Load(),
+		ArenaCap:       e.arenaCap,
+		ArenaHighWater: e.stats.arenaHigh.Load(),
+		ClockFallbacks: e.stats.clockFallbacks.Load(),
+		Breaker:        e.breakerState(),
+		GCYields:       e.stats.gcYields.Load(),
+		Aged:           e.stats.aged.Load(),
+		Commands:       e.stats.commands.Load(),
+		Expired:        e.stats.expired.Load(),
+		Cancelled:      e.stats.cancelled.Load(),
+		LogOverflows:   e.stats.logOverflows.Load(),
+		Rejected:       e.stats.rejected.Load(),
+		Latency:        e.stats.latency.snapshot(),
+
+		ShadowMismatches: e.stats.shadowMismatches.Load(),
+		Panics:           e.stats.panics.Load(),
+		RepliesDropped:   e.stats.repliesDropped.Load(),
+		AvgProcessNanos:  e.AvgProcessNanos(),
+
+		CalcArena:  e.stats.calcArena.snapshot(),
+		OrderArena: e.stats.orderArena.snapshot(),
+	}
+	s.HeapFallbacks, s.HeapFallbackBytes = e.alloc.HeapAllocs()
+	if e.results != nil {
+		s.ResultsDropped = e.results.dropped.Load()
+	}
+	if e.flights != nil {
+		s.Coalesced = e.flights.coalesced.Load()
+	}
+	if e.standby != nil {
+		s.Failovers = e.standby.failovers.Load()
+	}
+	if e.calc != nil {
+		e.calc.addStats(&s)
+	}
+	return s
+}
+
+func (e *Engine) breakerState() string {
+	if e.Breaker == nil {
+		return ""
+	}
+	return e.Breaker.State().String()
+}
+
+// expvarSeq 保证同一进程内多个引擎发布的变量名不冲突
+var expvarSeq atomic.Int64
+
+// publishExpvar 将引擎状态发布到 /debug/vars
+// 第一个引擎使用 "engine"，之后的依次为 "engine.1", "engine.2" ...
+// expvar 无法取消发布，这里只持有弱引用：引擎不再使用后连同它的 Arena 照常回收，变量之后输出 null
+func (e *Engine) publishExpvar() {
+	name := "engine"
+	if n := expvarSeq.Add(1) - 1; n > 0 {
+		name += "." + strconv.FormatInt(n, 10)
+	}
+	wp := weak.Make(e)
+	expvar.Publish(name, expvar.Func(func() any {
+		if e := wp.Value(); e != nil {
+			return e.Stats()
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"testing"
+)
+
+// 发布到 /debug/vars 的变量读的是实时状态：提交任务之后再读，看到的是新的计数
+func TestExpvarReflectsLiveState(t *testing.T) {
+	name := "engine"
+	if n := expvarSeq.Load(); n > 0 {
+		name += "." + strconv.FormatInt(n, 10)
+	}
+	e := NewEngineSync()
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatalf("expvar %q not published", name)
+	}
+	read := func() Stats {
+		var s Stats
+		if err := json.Unmarshal([]byte(v.String()), &s); err != nil {
+			t.Fatalf("decode %s: %v", name, err)
+		}
+		return s
+	}
+	if s := read(); s.Processed != 0 || s.QueueCap != e.Queue.Cap() || s.ArenaCap != int64(e.Mem.Cap()) {
+		t.Fatalf("fresh engine vars = %+v", s)
+	}
+	for i := range 3 {
+		if _, err := e.Call(context.Background(), Task{Type: TaskTypeCalc, Value: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := read(); s.Processed != 3 || s.ArenaHighWater == 0 {
+		t.Fatalf("after 3 tasks: Processed = %d, ArenaHighWater = %d", s.Processed, s.ArenaHighWater)
+	}
+}
+
+// 按类型的 Arena 用量与各处理函数的已知分配一致：calc 分配一个 int，订单只有 ArenaLog 时才用 Arena
+func TestArenaUsageByType(t *testing.T) {
+	e := NewEngineSync()
+	ctx := context.Background()
+	for i := range 3 {
+		if _, err := e.Call(ctx, Task{Type: TaskTypeCalc, Value: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := e.Stats().CalcArena, (ArenaUsage{Tasks: 3, HighWater: 8, Avg: 8}); got != want {
+		t.Fatalf("CalcArena = %+v, want %+v", got, want)
+	}
+
+	order(t, e, 1, 10)
+	order(t, e, 2, 10)
+	if got := e.Stats().OrderArena; got != (ArenaUsage{Tasks: 2}) {
+		t.Fatalf("OrderArena without arena logs = %+v, want no usage", got)
+	}
+	r, err := e.Call(ctx, Task{Type: TaskTypeOrder, Value: 3, Price: 1, Quantity: 1, ArenaLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logLen := int64(len(r.(OrderResult).Log))
+	got := e.Stats().OrderArena
+	if got.Tasks != 3 || got.HighWater < logLen || got.Avg != got.HighWater/3 {
+		t.Fatalf("OrderArena = %+v after one %d-byte arena log, want HighWater >= %d and Avg = HighWater/3", got, logLen, logLen)
+	}
+	if e.Stats().CalcArena.Tasks != 3 {
+		t.Fatal("orders counted as calc")
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// 结果流：Worker 把结果序列化后直接写给 io.Writer
+//
+// EnableResultStream 之后，Resp 为 nil 的任务的结果 (与输出环相同的那部分任务，见 results.go)
+// 由 Worker 用指定的 Marshaler 编码成帧，一个任务一次 Write。帧格式与 Unix Socket 协议的响应帧相同，
+// 可以直接用 ReadReply 读取：
+//
+//	[4]uint32 len | [8]uint64 corr_id | [1]status | body
+//
+// body 在 StatusOK 时是 Marshaler 编码的 Calc/Order 结果 (查询结果在 Binary 下为 [8]float64，JSON 下为数字文本)，
+// StatusRejected 时为错误文本。不回复的任务 (Windowed calc) 不产生帧
+//
+// 序列化缓冲区：每个 Worker (分片) 一块，只有它自己使用，不需要任何同步。
+// 放在堆上而不是 Arena 上，因为帧的大小事先未知 (日志长度可变)，append 超过 Arena 上切片的 cap
+// 同样会搬到堆上，还会让 Arena 的统计失真。增长策略是 "涨到峰值、永不收缩"：
+// 每个任务开始时长度归零，只有遇到比以往都大的帧时才扩容一次，预热之后序列化不再分配
+//
+// 限制：
+//   - 与 EnableResultRing 互斥 (两者消费的是同一批任务)
+//   - 多分片时各 Worker 并发写同一个 w，w 需要保证单次 Write 的完整性 (net.Conn、os.File 满足)
+//   - 第一次写失败后不再写出 (与 WAL 相同，见 ResultStreamErr)
+//   - calc 池 (EnableCalcPool) 中的任务不经过分片 Worker，不产生帧
+
+// streamBufInit 序列化缓冲区的初始容量，够放一个不带长日志的订单结果
+const streamBufInit = 512
+
+type resultStream struct {
+	m    Marshaler
+	w    io.Writer
+	buf  []byte   // 序列化缓冲区，只由本分片的 Worker 使用
+	slot chan any // 与 resultRing.slot 相同，临时挂到任务上的 Resp
+	err  error    // 第一次写失败的错误
+}
+
+// EnableResultStream 让 Resp 为 nil 的任务的结果用 m 编码后写入 w，必须在 Start 之前调用
+func (e *Engine) EnableResultStream(m Marshaler, w io.Writer) {
+	if e.results != nil {
+		panic("core: result stream and result ring are mutually exclusive")
+	}
+	e.stream = &resultStream{m: m, w: w, buf: make([]byte, 0, streamBufInit), slot: make(chan any, 1)}
+}
+
+// ResultStreamErr 返回结果流第一次写失败的错误 (nil 表示一切正常)
+// 只能在 Worker 停止后 (或确定没有并发写入时) 调用
+func (e *Engine) ResultStreamErr() error {
+	if e.stream == nil {
+		return nil
+	}
+	return e.stream.err
+}
+
+// processToStream 处理一个 Resp 为 nil 的任务，并把它的结果编码写入结果流
+func (e *Engine) processToStream(t Task) {
+	s := e.stream
+	t.Resp = s.slot
+	if e.recovery != nil {
+		e.processRecover(t)
+	} else {
+		e.process(t)
+	}
+	select {
+	case v := <-s.slot:
+		s.write(t.CorrID, v)
+	default:
+	}
+}
+
+// write 在缓冲区中编码一帧并写出，缓冲区只在帧比以往都大时扩容
+func (s *resultStream) write(corrID uint64, v any) {
+	if s.err != nil {
+		return
+	}
+	const hdr = 4 + 8 + 1
+	b := s.buf[:hdr]
+	status, b := encodeReply(s.m, b, v)
+	binary.LittleEndian.PutUint32(b, uint32(len(b)-4))
+	binary.LittleEndian.PutUint64(b[4:], corrID)
+	b[12] = status
+	_, s.err = s.w.Write(b)
+	s.buf = b[:0]
+}
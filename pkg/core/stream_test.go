@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// 结果流的帧可以用 ReadReply 读回；序列化缓冲区涨到峰值后不再收缩，预热之后写帧不分配
+func TestResultStream(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEngineSync()
+	e.EnableResultStream(Binary, &out)
+	e.PositionLimit = 100
+
+	e.TrySubmit(Task{Type: TaskTypeCalc, Value: 21, CorrID: 1})
+	e.TrySubmit(Task{Type: TaskTypeOrder, Value: 3, Price: 2, Quantity: 5, CorrID: 2})
+	e.TrySubmit(Task{Type: TaskTypeOrder, Value: 3, Price: 200, Quantity: 1, CorrID: 3}) // 超过限额
+
+	corr, status, body, err := ReadReply(&out)
+	if v, _ := Binary.UnmarshalCalc(body); err != nil || corr != 1 || status != StatusOK || v != 42 {
+		t.Fatalf("calc frame: corr %d, status %d, value %d, err %v", corr, status, v, err)
+	}
+	corr, status, body, err = ReadReply(&out)
+	if r, _ := Binary.UnmarshalOrder(body); err != nil || corr != 2 || status != StatusOK || r.Total != 10 {
+		t.Fatalf("order frame: corr %d, status %d, total %v, err %v", corr, status, r.Total, err)
+	}
+	corr, status, body, err = ReadReply(&out)
+	if err != nil || corr != 3 || status != StatusRejected || !strings.Contains(string(body), "limit") {
+		t.Fatalf("rejected order frame: corr %d, status %d, body %q, err %v", corr, status, body, err)
+	}
+	if _, _, _, err := ReadReply(&out); err != io.EOF {
+		t.Fatalf("extra frame in the stream: %v", err)
+	}
+
+	// 缓冲区从很小开始：遇到更大的帧扩容一次，之后的小帧不会让它收缩
+	e.stream.buf = make([]byte, 0, 16) // 至少放得下帧头
+	e.TrySubmit(Task{Type: TaskTypeOrder, Value: 5, Price: 1, Quantity: 1, CorrID: 5})
+	peak := cap(e.stream.buf)
+	if peak < out.Len() {
+		t.Fatalf("buffer cap %d after a %d-byte frame", peak, out.Len())
+	}
+	e.TrySubmit(Task{Type: TaskTypeCalc, Value: 1, CorrID: 6})
+	if cap(e.stream.buf) != peak {
+		t.Fatalf("buffer cap went from %d to %d on a smaller frame", peak, cap(e.stream.buf))
+	}
+	if e.ResultStreamErr() != nil {
+		t.Fatalf("ResultStreamErr = %v", e.ResultStreamErr())
+	}
+
+	d := NewEngineSync()
+	d.EnableResultStream(Binary, io.Discard)
+	task := Task{Type: TaskTypeCalc, Value: 1, CorrID: 7}
+	d.TrySubmit(task) // 预热
+	if n := testing.AllocsPerRun(200, func() { d.TrySubmit(task) }); n != 0 {
+		t.Fatalf("streaming a calc result allocated %v times after warmup", n)
+	}
+}
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy 队列满时的处理策略
+type OverflowPolicy uint8
+
+const (
+	// OverflowReject 立即拒绝 (默认)，适合对延迟敏感的接口，如 /calc
+	OverflowReject OverflowPolicy = iota
+	// OverflowBlock 在 BlockTimeout 内自旋重试，适合可以短暂等待的接口，如 /order
+	OverflowBlock
+	// OverflowSpill 写入有界的溢出区，由 Worker 在队列空闲时消费，适合审计类任务
+	// 溢出区的任务不保证与队列中的任务保持顺序
+	OverflowSpill
+)
+
+// Submit 将任务投递给 C World (Go -> C)
+// 根据 QoS 选择队列并执行准入控制，队列满时按 t.Overflow 策略处理
+// 返回 false 表示任务最终被拒绝，需要区分拒绝原因时使用 TrySubmit
+// 多分片时会先按 UserID 路由到对应分片
+func (e *Engine) Submit(t Task) bool {
+	return e.TrySubmit(t) == nil
+}
+
+// TrySubmit 与 Submit 相同，但返回拒绝原因：ErrInvalidTask / ErrMemoryPressure / ErrCircuitOpen / ErrOverloaded / ErrFull / ErrRateLimited
+func (e *Engine) TrySubmit(t Task) error {
+	err := e.checkedSubmit(t)
+	if e.red != nil {
+		e.red.submitted(t.Type, err)
+	}
+	return err
+}
+
+// SubmitBatch 依次提交 tasks，返回与 tasks 一一对应的拒绝原因 (nil 表示已入队，结果照常写入各自的 Resp)
+// 每个任务独立准入，部分被拒绝不影响其余任务；与 TaskTypeBatchOrder 不同，这里没有全部成功或全部失败的语义
+func (e *Engine) SubmitBatch(tasks []Task) []error {
+	errs := make([]error, len(tasks))
+	for i := range tasks {
+		errs[i] = e.TrySubmit(tasks[i])
+	}
+	return errs
+}
+
+// checkedSubmit 是 TrySubmit 除 RED 指标以外的部分：校验、熔断与合并检查之后投递
+func (e *Engine) checkedSubmit(t Task) error {
+	if t.Resp != nil && cap(t.Resp) == 0 {
+		// Worker 不会阻塞等待接收方 (见 reply.go)，无缓冲的 Resp 几乎总是收不到结果
+		panic("core: Resp channel must be buffered")
+	}
+	if e.validators != nil {
+		if err := e.validate(&t); err != nil {
+			return err
+		}
+	}
+	if e.memShed != nil && e.memShed.shedding.Load() {
+		e.stats.rejected.Add(1)
+		return ErrMemoryPressure
+	}
+	if e.breakerGuards(t.Type) && !e.Breaker.Allow() {
+		e.stats.rejected.Add(1)
+		return ErrCircuitOpen
+	}
+	if e.flights != nil && e.flights.eligible(e, &t) {
+		return e.flights.submit(e, t)
+	}
+	return e.admitSubmit(t)
+}
+
+// admitSubmit 是 TrySubmit 在熔断与合并检查之后的部分：占用在途名额后投递
+func (e *Engine) admitSubmit(t Task) error {
+	if e.admit != nil {
+		if !e.admit.TryAcquire() {
+			e.stats.rejected.Add(1)
+			return ErrOverloaded
+		}
+		t.admitted = true
+		err := e.trySubmit(t)
+		if err != nil {
+			e.admit.Release()
+		}
+		return err
+	}
+	return e.trySubmit(t)
+}
+
+// trySubmit 是 admitSubmit 在在途上限检查之后的部分
+func (e *Engine) trySubmit(t Task) error {
+	// 按类型分道：无状态的 calc 交给池，不经过分片路由
+	if e.calc != nil && t.Type == TaskTypeCalc && !t.Windowed && t.ExecuteAt == 0 {
+		if err := e.calc.submit(t); err != nil {
+			e.stats.rejected.Add(1)
+			return err
+		}
+		return nil
+	}
+	// 先登记在途再检查闸门，与 Scale 的 "先关闸再等在途归零" 配对，保证不会漏掉正在路由的任务
+	e.inflight.Add(1)
+	defer e.inflight.Add(-1)
+	if e.scaling.Load() {
+		e.stats.rejected.Add(1)
+		return ErrFull
+	}
+	s := e.route(t)
+	if t.Type == TaskTypeBatchOrder && !e.batchLocal(&t) {
+		e.stats.rejected.Add(1)
+		return ErrInvalidTask
+	}
+	if err := s.submitErr(t); err != nil {
+		s.stats.rejected.Add(1)
+		return err
+	}
+	return nil
+}
+
+// submitLocal 投递到本引擎 (本分片) 自己的队列
+func (e *Engine) submitLocal(t Task) bool {
+	return e.submitErr(t) == nil
+}
+
+// submitErr 与 submitLocal 相同，但返回拒绝原因
+func (e *Engine) submitErr(t Task) error {
+	t.enqueued = sysclock.Now()
+	if e.inline != nil {
+		e.runInline(t)
+		return nil
+	}
+	if e.fair != nil && t.QoS != QoSGold {
+		if !e.fair.reserve(&t) {
+			return ErrRateLimited
+		}
+		if !e.pushLocal(t) {
+			e.fair.release(&t)
+			return ErrFull
+		}
+		return nil
+	}
+	if !e.pushLocal(t) {
+		return ErrFull
+	}
+	return nil
+}
+
+// pushLocal 入队，失败时按 t.Overflow 策略处理
+func (e *Engine) pushLocal(t Task) bool {
+	if e.tryPush(t) {
+		return true
+	}
+	switch t.Overflow {
+	case OverflowBlock:
+		deadline := time.Now().Add(e.BlockTimeout)
+		for time.Now().Before(deadline) {
+			runtime.Gosched()
+			if e.tryPush(t) {
+				return true
+			}
+		}
+		return false
+	case OverflowSpill:
+		return e.spill.push(t, e.SpillMax)
+	}
+	return false
+}
+
+// tryPush 执行一次准入检查 + 入队，不做任何重试
+func (e *Engine) tryPush(t Task) bool {
+	if e.handoff != nil && e.queuesEmpty() && e.handoff.offer(t) {
+		return true
+	}
+	q := e.Queue
+	if t.QoS == QoSGold {
+		q = e.HighQueue
+	}
+	if limit := q.Cap() * e.AdmitPercent[t.QoS%qosLevels] / 100; q.Len() >= limit {
+		return false
+	}
+	if q.Push(t) {
+		return true
+	}
+	// Gold 的高优先级队列满了，降级到普通队列
+	if t.QoS == QoSGold {
+		return e.Queue.Push(t)
+	}
+	return false
+}
+
+// pop 按优先级取任务：先交接位，再 High Lane，再普通队列 (开启公平调度时按用户轮转)，最后溢出区
+func (e *Engine) pop() (Task, bool) {
+	// 接管来的备机先取完旧 Worker 剩下的任务 (租约已被拿走，这里是它队列唯一的消费者)
+	if e.takeover != nil {
+		if task, ok := e.takeover.pop(); ok {
+			return task, true
+		}
+	}
+	if e.handoff != nil {
+		if task, ok := e.handoff.take(); ok {
+			if e.fair != nil {
+				e.fair.release(&task)
+			}
+			return task, true
+		}
+	}
+	if e.agingStep != 0 && e.agedFirst() {
+		if task, ok := e.Queue.Pop(); ok {
+			e.stats.aged.Add(1)
+			if e.fair != nil {
+				// 直接取出的任务没有经过子队列，同样归还它占用的名额
+				e.fair.release(&task)
+			}
+			return task, true
+		}
+	}
+	if task, ok := e.HighQueue.Pop(); ok {
+		return task, true
+	}
+	if e.fair == nil {
+		if task, ok := e.Queue.Pop(); ok {
+			return task, true
+		}
+		return e.spill.pop()
+	}
+	e.fair.refill(e.Queue)
+	task, ok := e.fair.pop()
+	if !ok {
+		task, ok = e.spill.pop()
+	}
+	e.fair.release(&task)
+	return task, ok
+}
+
+// spillQueue 是 OverflowSpill 使用的溢出区
+// 它不在正常路径上 (只有 RingBuffer 满时才会使用)，所以直接用锁 + 切片实现
+// n 是原子计数，Worker 在溢出区为空时只需一次原子读，不会去抢锁
+type spillQueue struct {
+	mu    sync.Mutex
+	tasks []Task
+	n     atomic.Int64
+}
+
+func (s *spillQueue) push(t Task, max int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.tasks) >= max {
+		return false
+	}
+	s.tasks = append(s.tasks, t)
+	s.n.Add(1)
+	return true
+}
+
+func (s *spillQueue) pop() (Task, bool) {
+	var empty Task
+	if s.n.Load() == 0 {
+		return empty, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.tasks) == 0 {
+		return empty, false
+	}
+	t := s.tasks[0]
+	s.tasks[0] = empty
+	s.tasks = s.tasks[1:]
+	s.n.Add(-1)
+	return t, true
+}
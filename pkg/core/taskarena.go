@@ -0,0 +1,66 @@
+package core
+
+import "arena_demo/pkg/arena"
+
+// 按任务类型的 Arena
+//
+// 默认所有任务共用 Worker 的 Mem (64MB)，每个任务之后 Reset。SetTaskArena 之后该类型的任务改用自己的
+// 一个按容量借出的小 Arena (arena.AcquireSized)：calc 只需要几十字节，订单需要日志缓冲区，
+// 各自按需要定容量，常驻内存更少，频繁 Reset 的那一小段也一直留在缓存里。容量参考 Stats.CalcArena / OrderArena 的 HighWater
+//
+// 生命周期：
+//   - Arena 属于分片 Worker：SetTaskArena 时借出，多分片时每个分片 (包括 Scale 新建的分片与热备) 各借一份同样容量的，
+//     Worker 退出 (缩容、被备机接管) 时与 Mem 一起归还；同一档容量的 Arena 在 arena 包的分档池中复用
+//   - 处理该类型的任务期间 e.Mem 指向它，任务结束后只 Reset 这一个，再换回默认的 Mem；
+//     所以 Stats.ArenaUsed 等统计的是当前任务实际使用的那个 Arena
+//   - 容量不足时与默认 Arena 一样 OOM panic (开启 EnableRecovery 时按 panic 处理)，请按最大用量留出余量
+//   - calc 池 Worker 有自己的 Arena，不受影响
+
+// SetTaskArena 让 typ 类型的任务使用独立的、容量至少为 size 字节的 Arena，必须在 Start/StartN 之前调用
+func (e *Engine) SetTaskArena(typ, size int) {
+	if uint(typ) >= taskTypeCount {
+		panic("core: unknown task type")
+	}
+	if e.taskMem == nil {
+		e.taskMem = new([taskTypeCount]*arena.Arena)
+	}
+	if old := e.taskMem[typ]; old != nil {
+		old.Release()
+	}
+	e.taskMem[typ] = arena.AcquireSized(size)
+}
+
+// taskArena 返回 typ 类型任务使用的 Arena，没有单独设置时返回 nil
+func (e *Engine) taskArena(typ int) *arena.Arena {
+	if e.taskMem == nil || uint(typ) >= taskTypeCount {
+		return nil
+	}
+	return e.taskMem[typ]
+}
+
+// copyTaskArenas 让新分片按相同容量借出自己的 Arena
+func (e *Engine) copyTaskArenas(s *Engine) {
+	if e.taskMem == nil {
+		return
+	}
+	for typ, a := range e.taskMem {
+		if a != nil {
+			s.SetTaskArena(typ, a.Cap())
+		}
+	}
+}
+
+// releaseArenas 在 Worker 退出 (缩容、被备机接管) 时归还 Mem、按类型的 Arena 与延迟堆的 Arena
+func (e *Engine) releaseArenas() {
+	e.Mem.Release()
+	if e.delay != nil {
+		e.delay.mem.Release()
+	}
+	if e.taskMem != nil {
+		for _, a := range e.taskMem {
+			if a != nil {
+				a.Release()
+			}
+		}
+	}
+}
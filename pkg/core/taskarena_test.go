@@ -0,0 +1,49 @@
+package core
+
+import "testing"
+
+// 单独设置了 Arena 的类型在自己的、按容量借出的 Arena 上分配，任务结束后只 Reset 它并换回默认的 Mem
+func TestTaskArenasPerType(t *testing.T) {
+	e := NewEngine() // 不 Start：由测试充当 Worker
+	e.SetTaskArena(TaskTypeCalc, 256)
+	e.SetTaskArena(TaskTypeOrder, 16<<10)
+	calc, ord, mem := e.taskArena(TaskTypeCalc), e.taskArena(TaskTypeOrder), e.Mem
+	if calc.Cap() < 256 || ord.Cap() < 16<<10 || calc.Cap() >= ord.Cap() || ord.Cap() >= mem.Cap() {
+		t.Fatalf("capacities: calc %d, order %d, default %d", calc.Cap(), ord.Cap(), mem.Cap())
+	}
+	if e.taskArena(TaskTypeQuery) != nil {
+		t.Fatal("query has an arena of its own without SetTaskArena")
+	}
+
+	allocs := func() [3]uint64 { return [3]uint64{calc.TotalAllocs(), ord.TotalAllocs(), mem.TotalAllocs()} }
+	run := func(task Task) {
+		t.Helper()
+		task.Resp = make(chan any, 1)
+		if err := e.TrySubmit(task); err != nil {
+			t.Fatal(err)
+		}
+		popped, _ := e.pop()
+		e.runTask(popped)
+		<-task.Resp
+		if e.Mem != mem {
+			t.Fatal("default Mem not restored after the task")
+		}
+		if calc.Used() != 0 || ord.Used() != 0 || mem.Used() != 0 {
+			t.Fatalf("arenas not reset: calc %d, order %d, default %d", calc.Used(), ord.Used(), mem.Used())
+		}
+	}
+
+	before := allocs()
+	run(Task{Type: TaskTypeCalc, Value: 2})
+	if got := allocs(); got[0] == before[0] || got[1] != before[1] || got[2] != before[2] {
+		t.Fatalf("calc allocated in the wrong arena: %v -> %v (calc, order, default)", before, got)
+	}
+	before = allocs()
+	run(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, ArenaLog: true})
+	if got := allocs(); got[0] != before[0] || got[1] == before[1] || got[2] != before[2] {
+		t.Fatalf("order allocated in the wrong arena: %v -> %v (calc, order, default)", before, got)
+	}
+	if hw := e.Stats().OrderArena.HighWater; hw == 0 || hw > int64(ord.Cap()) {
+		t.Fatalf("OrderArena.HighWater = %d, want within the order arena's %d bytes", hw, ord.Cap())
+	}
+}
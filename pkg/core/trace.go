@@ -0,0 +1,94 @@
+package core
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+)
+
+// TraceHeader 是 W3C Trace Context 的 Header 名称
+// 格式: 00-<32 hex trace-id>-<16 hex span-id>-<2 hex flags>
+//
+// 服务端收到 traceparent 之后开启一个子 span：trace-id 与 flags 原样沿用 (flags 里有上游的采样决定)，
+// span-id 换成 NewSpanID 生成的新值，写进 Task (订单日志的 span_id) 并在响应中回写；上游的 span-id 是它的父 span
+const TraceHeader = "traceparent"
+
+// TraceID / SpanID 使用定长数组，任务按值传递时不会产生任何堆分配
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+// TraceFlags 是 traceparent 的 flags 字段
+type TraceFlags byte
+
+// TraceSampled 表示上游决定采样这条 trace
+const TraceSampled TraceFlags = 1
+
+// NewSpanID 生成一个随机的非零 span-id (无锁、无分配)
+func NewSpanID() SpanID {
+	var s SpanID
+	for s == (SpanID{}) {
+		binary.LittleEndian.PutUint64(s[:], rand.Uint64())
+	}
+	return s
+}
+
+// IsZero 表示请求没有携带 Trace 信息
+func (t TraceID) IsZero() bool {
+	return t == TraceID{}
+}
+
+// ParseTraceparent 解析 traceparent Header，span 是上游 (父) span，格式非法时 ok=false
+func ParseTraceparent(s string) (trace TraceID, span SpanID, flags TraceFlags, ok bool) {
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return trace, span, 0, false
+	}
+	var f [1]byte
+	if !decodeHex(trace[:], s[3:35]) || !decodeHex(span[:], s[36:52]) || !decodeHex(f[:], s[53:55]) {
+		return TraceID{}, SpanID{}, 0, false
+	}
+	return trace, span, TraceFlags(f[0]), !trace.IsZero()
+}
+
+// AppendTraceparent 把 trace/span/flags 格式化为 traceparent 追加到 dst
+func AppendTraceparent(dst []byte, trace TraceID, span SpanID, flags TraceFlags) []byte {
+	dst = append(dst, "00-"...)
+	dst = appendHex(dst, trace[:])
+	dst = append(dst, '-')
+	dst = appendHex(dst, span[:])
+	dst = append(dst, '-')
+	return appendHex(dst, []byte{byte(flags)})
+}
+
+const hexDigits = "0123456789abcdef"
+
+func appendHex(dst, b []byte) []byte {
+	for _, c := range b {
+		dst = append(dst, hexDigits[c>>4], hexDigits[c&0xF])
+	}
+	return dst
+}
+
+func decodeHex(dst []byte, s string) bool {
+	for i := range dst {
+		hi, ok1 := unhex(s[2*i])
+		lo, ok2 := unhex(s[2*i+1])
+		if !ok1 || !ok2 {
+			return false
+		}
+		dst[i] = hi<<4 | lo
+	}
+	return true
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// traceparent -> Task -> 订单日志，再格式化回响应 Header
+func TestTracePropagation(t *testing.T) {
+	trace, span, flags, ok := ParseTraceparent(testTraceparent)
+	if !ok || flags != TraceSampled {
+		t.Fatalf("ParseTraceparent(%q) = flags %d, %v", testTraceparent, flags, ok)
+	}
+	if got := string(AppendTraceparent(nil, trace, span, flags)); got != testTraceparent {
+		t.Fatalf("AppendTraceparent = %q, want %q", got, testTraceparent)
+	}
+	// 未采样的 flags 原样往返
+	unsampled := testTraceparent[:53] + "00"
+	if tr, sp, fl, ok := ParseTraceparent(unsampled); !ok || fl != 0 || string(AppendTraceparent(nil, tr, sp, fl)) != unsampled {
+		t.Fatalf("unsampled traceparent: flags %d, %v", fl, ok)
+	}
+
+	e := NewEngine()
+	e.Start()
+	stopOnCleanup(t, e)
+	r, err := e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1,
+		TraceID: trace, SpanID: span, LogBuf: make([]byte, 0, 512)})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	log := r.(OrderResult).Log
+	for _, want := range []string{"trace_id=4bf92f3577b34da6a3ce929d0e0e4736", "span_id=00f067aa0ba902b7"} {
+		if !bytes.Contains(log, []byte(want)) {
+			t.Fatalf("order log lacks %s: %q", want, log)
+		}
+	}
+
+	// 没有 Trace 的请求不写这两个字段
+	r, _ = e.Call(context.Background(), Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1, LogBuf: make([]byte, 0, 512)})
+	if log := r.(OrderResult).Log; bytes.Contains(log, []byte("trace_id")) {
+		t.Fatalf("untraced order logged a trace id: %q", log)
+	}
+}
+
+func TestNewSpanID(t *testing.T) {
+	a, b := NewSpanID(), NewSpanID()
+	if a == (SpanID{}) || a == b {
+		t.Fatalf("NewSpanID = %x, %x; want distinct non-zero ids", a, b)
+	}
+}
+
+func TestParseTraceparentRejects(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",    // 缺 flags
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01", // 非十六进制
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // 全零 trace-id
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz", // 非十六进制 flags
+	} {
+		if _, _, _, ok := ParseTraceparent(s); ok {
+			t.Errorf("ParseTraceparent(%q) accepted", s)
+		}
+	}
+}
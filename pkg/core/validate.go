@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 提交前校验
+//
+// 参数明显非法的任务 (负数量、未知用户等) 如果照常入队，要占一个队列槽位、等 Worker 处理到它才被拒绝，
+// 而 Worker 只有一个。SetValidator 为某种任务类型注册一个校验函数，TrySubmit 在调用方的 goroutine 上
+// 先于熔断、在途上限和入队执行它：校验失败立即返回，任务不会进入队列，也不占用任何名额。
+//
+// 校验失败的错误满足 errors.Is(err, ErrInvalidTask)，同时保留校验函数返回的原始错误
+// (errors.Is(err, 原始错误) 仍然成立)，CodeOf 归类为 CodeValidation (HTTP 400)，
+// 与 ErrFull / ErrOverloaded 等 "暂时无法接收" 的拒绝区分开；RED 指标按 validation 错误码计数。
+//
+// 校验函数只应检查任务本身 (不要读 UserVolume 等 Worker 独占的状态)，会被多个 goroutine 并发调用。
+// 控制任务 (GetUserVolume、ResetUserVolume、快照、Flush) 与 SubmitTo 一样直接投递到分片队列，不经过 TrySubmit，不做校验
+
+// ErrInvalidTask 任务没有通过 SetValidator 注册的提交前校验
+var ErrInvalidTask = errors.New("core: invalid task")
+
+// SetValidator 为任务类型 typ 注册提交前的校验函数，fn 为 nil 时取消，必须在 Start (StartN) 之前调用
+func (e *Engine) SetValidator(typ int, fn func(Task) error) {
+	if uint(typ) >= taskTypeCount {
+		panic("core: unknown task type")
+	}
+	if e.validators == nil {
+		e.validators = new([taskTypeCount]func(Task) error)
+	}
+	e.validators[typ] = fn
+}
+
+// validate 执行 t 所属类型的校验函数，未注册时返回 nil
+func (e *Engine) validate(t *Task) error {
+	if uint(t.Type) >= taskTypeCount {
+		return nil
+	}
+	fn := e.validators[t.Type]
+	if fn == nil {
+		return nil
+	}
+	if err := fn(*t); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTask, err)
+	}
+	return nil
+}
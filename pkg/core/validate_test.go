@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var errNoQty = errors.New("quantity must be positive")
+
+// 非法任务在提交时就被拒绝：不入队、不占在途名额，错误与 "暂时无法接收" 的拒绝区分开
+func TestValidatorRejectsAtSubmit(t *testing.T) {
+	e := NewEngine() // 不 Start：任何入队的任务都会留在队列里
+	e.EnableInflightLimit(1)
+	e.SetValidator(TaskTypeOrder, func(t Task) error {
+		if t.Quantity <= 0 {
+			return errNoQty
+		}
+		return nil
+	})
+
+	bad := Task{Type: TaskTypeOrder, Value: 1, Price: 1}
+	for range 3 {
+		err := e.TrySubmit(bad)
+		if !errors.Is(err, ErrInvalidTask) || !errors.Is(err, errNoQty) {
+			t.Fatalf("invalid order: err = %v, want ErrInvalidTask wrapping the validator's error", err)
+		}
+		if CodeOf(err) != CodeValidation || errors.Is(err, ErrFull) || errors.Is(err, ErrOverloaded) {
+			t.Fatalf("invalid order classified as %q", CodeOf(err))
+		}
+	}
+	if n := e.Queue.Len(); n != 0 {
+		t.Fatalf("queue holds %d tasks after rejected submits", n)
+	}
+	if n := e.TasksInFlight(); n != 0 {
+		t.Fatalf("rejected submits hold %d inflight slots", n)
+	}
+	// Call 立即返回校验错误，而不是等一个永远不会来的回复
+	if _, err := e.Call(context.Background(), bad); !errors.Is(err, ErrInvalidTask) {
+		t.Fatalf("Call with an invalid order: err = %v", err)
+	}
+
+	// 合法任务与其它类型不受影响
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 1, Quantity: 1}); err != nil {
+		t.Fatalf("valid order: %v", err)
+	}
+	if n := e.Queue.Len(); n != 1 {
+		t.Fatalf("queue holds %d tasks, want the valid order only", n)
+	}
+	// 名额被合法订单占着：校验先于在途上限，非法任务得到的仍是校验错误
+	if err := e.TrySubmit(bad); !errors.Is(err, ErrInvalidTask) {
+		t.Fatalf("invalid order with the inflight limit reached: err = %v", err)
+	}
+
+	e.SetValidator(TaskTypeOrder, nil)
+	if err := e.TrySubmit(bad); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("after removing the validator: err = %v, want ErrOverloaded", err)
+	}
+}
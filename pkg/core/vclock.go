@@ -0,0 +1,50 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"time"
+)
+
+// 虚拟时钟 (测试钩子)
+//
+// 引擎中与时间有关的行为都读 sysclock 的缓存时间：延迟任务到期、窗口关闭、维护作业、熔断恢复、
+// Deadline 过期、读快照的发布间隔。测试这些功能如果真的等待，既慢又不稳定。
+// AdvanceClock 把 sysclock 切换为虚拟时间 (停止后台 ticker，之后时间只在调用 AdvanceClock 时前进)，
+// 拨快 d 之后等待每个分片把因此到期的工作处理完，返回时效果已经可见：
+//
+//	e.EnableDelayQueue(16)
+//	e.Start()
+//	e.Submit(Task{Type: TaskTypeOrder, ..., ExecuteAt: sysclock.Now() + int64(time.Hour), Resp: resp})
+//	e.AdvanceClock(time.Hour) // 不必等一小时，返回时 resp 中已经有结果
+//
+// sysclock 是进程级的：虚拟时间对同一进程中的所有引擎生效，测试结束后用 sysclock.Start 恢复真实时钟。
+// 用 NewEngineWithClock 注入了时钟的引擎，Deadline/ExecuteAt 跟随注入的时钟，由测试自己推进它。
+// 只用于测试，不要在服务中调用
+
+// AdvanceClock 把虚拟时钟拨快 d，并等待所有分片处理完此刻到期的时间相关工作
+func (e *Engine) AdvanceClock(d time.Duration) {
+	sysclock.Advance(d)
+	if m := e.inline; m != nil {
+		// 同步模式没有 Worker，在这里推进一轮 (没有延迟堆，见 delay.go)
+		m.mu.Lock()
+		e.runTimers()
+		m.mu.Unlock()
+		return
+	}
+	// Worker 每轮循环开头读一次时间再 pop：第一个屏障可能恰好在读时间之后、pop 之前到达，
+	// 处理它的那一轮用的还是旧时间；第二个屏障所在的一轮一定是在拨快之后开始的
+	e.Flush()
+	e.Flush()
+}
+
+// runTimers 推进窗口与维护作业 (同步模式下每个任务之前调用)
+func (e *Engine) runTimers() {
+	if e.window != nil {
+		e.window.advance(sysclock.Now())
+	}
+	if e.house != nil {
+		if now := sysclock.Now(); now >= e.house.due {
+			e.house.run(e, now, e.HousekeepingBudget)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"testing"
+	"time"
+)
+
+// 拨快一小时：定时任务在 AdvanceClock 返回时已经执行，不需要真的等待
+func TestAdvanceClockFiresScheduledTask(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	e := NewEngine()
+	e.EnableDelayQueue(4)
+	e.Start()
+	stopOnCleanup(t, e)
+
+	resp := make(chan any, 1)
+	at := sysclock.Now() + int64(time.Hour)
+	if err := e.TrySubmit(Task{Type: TaskTypeOrder, Value: 1, Price: 2, Quantity: 1, ExecuteAt: at, Resp: resp}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	e.AdvanceClock(time.Hour - time.Millisecond)
+	if len(resp) != 0 {
+		t.Fatal("scheduled task fired a millisecond early")
+	}
+	e.AdvanceClock(time.Millisecond)
+	if len(resp) != 1 {
+		t.Fatal("scheduled task had not fired when AdvanceClock returned")
+	}
+	if r := (<-resp).(OrderResult); r.Err != nil || r.Total != 2 {
+		t.Fatalf("scheduled order: total %v, err %v", r.Total, r.Err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("AdvanceClock took %v of real time", d)
+	}
+}
+
+// 同步模式没有 Worker：AdvanceClock 在调用方推进一轮，到期的维护作业当场执行
+func TestAdvanceClockSyncEngine(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+
+	e := NewEngineSync()
+	runs := 0
+	e.AddHousekeeping("tick", time.Minute, func(*Engine, time.Time) { runs++ })
+	e.AdvanceClock(59 * time.Second)
+	if runs != 0 {
+		t.Fatalf("job ran %d times before it was due", runs)
+	}
+	e.AdvanceClock(time.Second)
+	if runs != 1 {
+		t.Fatalf("job ran %d times after it came due, want 1", runs)
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// 写入方不停提交 "给用户 100..109 各加 1" 的整篮订单，任何时刻这 10 个用户的成交额都相等；
+// 与写入交错的 SnapshotVolumes 与订单串行执行，读到的必然是某两笔之间的一致状态，不会一半新一半旧
+func TestSnapshotVolumesConsistent(t *testing.T) {
+	for _, cmds := range []bool{false, true} {
+		e := NewEngine()
+		if cmds {
+			e.EnableCommandQueue(64)
+		}
+		e.Start()
+		stopOnCleanup(t, e)
+
+		basket := make([]Order, 10)
+		for i := range basket {
+			basket[i] = Order{Price: 1, Quantity: 1, UserID: 100 + i}
+		}
+		var stop atomic.Bool
+		done := make(chan int)
+		go func() {
+			n := 0
+			for !stop.Load() {
+				if e.SubmitRetry(context.Background(), Task{Type: TaskTypeBatchOrder, Value: 100, Orders: basket}) == nil {
+					n++
+				}
+			}
+			done <- n
+		}()
+
+		dst := make([]float64, 12) // 用户 99..110，两端的用户不被写入
+		var last float64
+		for i := range 300 {
+			if n := e.SnapshotVolumes(99, dst); n != len(dst) {
+				t.Fatalf("SnapshotVolumes copied %d", n)
+			}
+			if dst[0] != 0 || dst[11] != 0 {
+				t.Fatalf("snapshot %d: untouched users = %v, %v", i, dst[0], dst[11])
+			}
+			for _, v := range dst[1:11] {
+				if v != dst[1] {
+					t.Fatalf("snapshot %d (commands %v) is torn: %v", i, cmds, dst[1:11])
+				}
+			}
+			if dst[1] < last {
+				t.Fatalf("snapshot %d went backwards: %v after %v", i, dst[1], last)
+			}
+			last = dst[1]
+		}
+		stop.Store(true)
+		n := <-done
+		e.Flush()
+		e.SnapshotVolumes(99, dst)
+		if dst[1] != float64(n) {
+			t.Fatalf("final volume %v, want %d baskets", dst[1], n)
+		}
+	}
+}
+
+// 范围越界时截断：from 非法返回 0，末尾只拷贝到最后一个用户
+func TestSnapshotVolumesRange(t *testing.T) {
+	e := NewEngineSync()
+	order(t, e, 1023, 5)
+	dst := make([]float64, 8)
+	if n := e.SnapshotVolumes(-1, dst); n != 0 {
+		t.Fatalf("from -1: n = %d", n)
+	}
+	if n := e.SnapshotVolumes(1024, dst); n != 0 {
+		t.Fatalf("from 1024: n = %d", n)
+	}
+	if n := e.SnapshotVolumes(1020, dst); n != 4 || dst[3] != 5 {
+		t.Fatalf("from 1020: n = %d, dst = %v", n, dst)
+	}
+}
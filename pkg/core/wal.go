@@ -0,0 +1,143 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// WAL (Write-Ahead Log)：记录 Worker 对 UserVolume 的每一次修改，崩溃后重放即可恢复状态
+//
+// 记录的是 "效果" 而不是任务本身：重放时不会重新校验限额、不会回复、也不会再写 WAL，
+// 所以同一个 WAL 无论重放多少次、在什么配置下重放，得到的状态都相同
+//
+// 每条记录定长 walRecordSize 字节，小端:
+//
+//	[1]kind | [1]保留 | [2]uid | [8]float64 value | [4]crc32 (前 12 字节, IEEE)
+//
+// kind 为 walAdd (UserVolume[uid] += value) 或 walSet (UserVolume[uid] = value)
+//
+// Worker 把记录追加到预分配的 buffer，满了或队列空闲时才一次性写给底层 io.Writer；
+// 所以 WAL 保证的是 "写出的记录都是完整的"，而不是每个订单都已落盘：
+// 崩溃时尚在 buffer 中的记录会丢失，需要更强保证时请让 w 自己做同步刷盘
+//
+// 限制：只支持单 Worker (未分片) 的引擎。各分片各写各的记录、迁移的用户不留记录，重放无法还原状态，
+// 所以开启 WAL 的引擎 StartN/Scale 到多个分片时 panic
+
+// walRecordSize 每条 WAL 记录的长度
+const walRecordSize = 16
+
+// walBufSize Worker 侧 WAL buffer 大小 (4096 条记录)
+const walBufSize = 4096 * walRecordSize
+
+const (
+	walAdd byte = 1
+	walSet byte = 2
+)
+
+// ErrWALSharded WAL 不支持多分片引擎
+var ErrWALSharded = errors.New("core: WAL requires an unsharded engine")
+
+// walWriter 只在 C World 中访问
+type walWriter struct {
+	w   io.Writer
+	buf []byte
+	err error // 第一次写失败的错误，之后的记录继续缓冲但不再尝试写出
+}
+
+// EnableWAL 开启 WAL，此后 Worker 对 UserVolume 的修改都会写入 w，必须在 Start 之前调用
+// 只支持单 Worker：已经分片的引擎上调用时 panic
+func (e *Engine) EnableWAL(w io.Writer) {
+	if e.NumShards() > 1 {
+		panic(ErrWALSharded.Error())
+	}
+	e.wal = &walWriter{w: w, buf: make([]byte, 0, walBufSize)}
+}
+
+// WALErr 返回 WAL 第一次写失败的错误 (nil 表示一切正常)
+// 只能在 Worker 停止后 (或确定没有并发写入时) 调用
+func (e *Engine) WALErr() error {
+	if e.wal == nil {
+		return nil
+	}
+	return e.wal.err
+}
+
+// record 追加一条记录 (C World 调用，不分配内存)
+func (l *walWriter) record(kind byte, uid int, v float64) {
+	if len(l.buf)+walRecordSize > cap(l.buf) {
+		l.flush()
+	}
+	n := len(l.buf)
+	l.buf = l.buf[:n+walRecordSize]
+	rec := l.buf[n:]
+	rec[0], rec[1] = kind, 0
+	binary.LittleEndian.PutUint16(rec[2:], uint16(uid))
+	binary.LittleEndian.PutUint64(rec[4:], math.Float64bits(v))
+	binary.LittleEndian.PutUint32(rec[12:], crc32.ChecksumIEEE(rec[:12]))
+}
+
+// flush 把缓冲的记录写给底层 Writer
+func (l *walWriter) flush() {
+	if len(l.buf) == 0 {
+		return
+	}
+	if l.err == nil {
+		_, l.err = l.w.Write(l.buf)
+	}
+	l.buf = l.buf[:0]
+}
+
+// walAddVolume / walSetVolume 在 WAL 开启时记录一次修改 (未开启时只是一次 nil 判断)
+func (e *Engine) walAddVolume(uid int, v float64) {
+	if e.wal != nil {
+		e.wal.record(walAdd, uid, v)
+	}
+	if e.standby != nil {
+		e.standby.mark(uid)
+	}
+}
+
+func (e *Engine) walSetVolume(uid int, v float64) {
+	if e.wal != nil {
+		e.wal.record(walSet, uid, v)
+	}
+	if e.standby != nil {
+		e.standby.mark(uid)
+	}
+}
+
+// RecoverFromWAL 重放 r 中的 WAL 记录重建 UserVolume，必须在 Start 之前调用
+// 遇到不完整或校验失败的记录即认为到达了 (崩溃时写了一半的) 尾部，停止重放并返回 nil，
+// 之前的有效记录都已生效；只有底层读取出错时才返回错误
+func (e *Engine) RecoverFromWAL(r io.Reader) error {
+	if e.NumShards() > 1 {
+		return ErrWALSharded
+	}
+	br := bufio.NewReaderSize(r, walBufSize)
+	var rec [walRecordSize]byte
+	for {
+		if _, err := io.ReadFull(br, rec[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		if binary.LittleEndian.Uint32(rec[12:]) != crc32.ChecksumIEEE(rec[:12]) {
+			return nil
+		}
+		uid := int(binary.LittleEndian.Uint16(rec[2:])) & 1023
+		v := math.Float64frombits(binary.LittleEndian.Uint64(rec[4:]))
+		switch rec[0] {
+		case walAdd:
+			e.UserVolume[uid] += v
+		case walSet:
+			e.UserVolume[uid] = v
+		default:
+			return nil
+		}
+	}
+}
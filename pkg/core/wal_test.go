@@ -0,0 +1,125 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// walEngine 在同步引擎上执行一串修改 UserVolume 的操作，返回它写出的 WAL
+func walEngine(t *testing.T) (*Engine, []byte) {
+	t.Helper()
+	var wal bytes.Buffer
+	e := NewEngineSync()
+	e.EnableWAL(&wal)
+	for i := range 50 {
+		order(t, e, i%7, float64(i)+0.25)
+	}
+	if err := e.TryResetUserVolume(3); err != nil {
+		t.Fatal(err)
+	}
+	callBatch(t, e, []Order{{Price: 2, Quantity: 3, UserID: 3}, {Price: 1.5, Quantity: 2, UserID: 900}})
+	e.SetUserLimit(4, 1)
+	order(t, e, 4, 100) // 被拒绝，不写 WAL
+	if err := e.WALErr(); err != nil {
+		t.Fatalf("WALErr: %v", err)
+	}
+	return e, wal.Bytes()
+}
+
+// 崩溃后在新引擎上重放 WAL，得到的 UserVolume 与崩溃前逐个相同
+func TestRecoverFromWAL(t *testing.T) {
+	before, wal := walEngine(t)
+	if len(wal) == 0 || len(wal)%walRecordSize != 0 {
+		t.Fatalf("WAL is %d bytes", len(wal))
+	}
+
+	after := NewEngineSync()
+	if err := after.RecoverFromWAL(bytes.NewReader(wal)); err != nil {
+		t.Fatalf("RecoverFromWAL: %v", err)
+	}
+	if after.UserVolume != before.UserVolume {
+		for uid := range before.UserVolume {
+			if after.UserVolume[uid] != before.UserVolume[uid] {
+				t.Errorf("user %d: recovered %v, want %v", uid, after.UserVolume[uid], before.UserVolume[uid])
+			}
+		}
+	}
+	// 重放不会再写 WAL，也不会回复或经过限额检查
+	if after.wal != nil || after.Stats().Processed != 0 {
+		t.Fatal("replay went through the task path")
+	}
+}
+
+// 尾部写了一半或校验失败：重放到最后一条有效记录为止，不报错
+func TestRecoverFromWALBadTail(t *testing.T) {
+	_, wal := walEngine(t)
+	n := len(wal) / walRecordSize
+
+	// 只重放前 k 条完整记录时的期望状态
+	prefix := func(k int) [1024]float64 {
+		e := NewEngineSync()
+		if err := e.RecoverFromWAL(bytes.NewReader(wal[:k*walRecordSize])); err != nil {
+			t.Fatal(err)
+		}
+		return e.UserVolume
+	}
+
+	truncated := wal[:len(wal)-5]
+	e := NewEngineSync()
+	if err := e.RecoverFromWAL(bytes.NewReader(truncated)); err != nil {
+		t.Fatalf("truncated tail: %v", err)
+	}
+	if e.UserVolume != prefix(n-1) {
+		t.Fatal("truncated tail: state differs from the last complete record")
+	}
+
+	corrupt := bytes.Clone(wal)
+	corrupt[10*walRecordSize+5] ^= 0xff // 第 10 条记录的 value
+	e = NewEngineSync()
+	if err := e.RecoverFromWAL(bytes.NewReader(corrupt)); err != nil {
+		t.Fatalf("corrupt record: %v", err)
+	}
+	if e.UserVolume != prefix(10) {
+		t.Fatal("corrupt record: replay did not stop before it")
+	}
+}
+
+func TestRecoverFromWALSharded(t *testing.T) {
+	e := NewEngine()
+	e.StartN(2)
+	stopOnCleanup(t, e)
+	if err := e.RecoverFromWAL(bytes.NewReader(nil)); err != ErrWALSharded {
+		t.Fatalf("sharded engine: %v, want ErrWALSharded", err)
+	}
+}
+
+// 各分片分别写 WAL、迁移的用户不留记录，重放还原不了状态：开启 WAL 的引擎不能分片
+func TestWALRejectsSharding(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r != ErrWALSharded.Error() {
+				t.Errorf("%s: panic = %v, want %q", name, r, ErrWALSharded)
+			}
+		}()
+		f()
+	}
+	e := NewEngine()
+	e.EnableWAL(io.Discard)
+	mustPanic("StartN(2)", func() { e.StartN(2) })
+
+	e = NewEngine()
+	e.EnableWAL(io.Discard)
+	e.Start()
+	stopOnCleanup(t, e)
+	mustPanic("Scale(2)", func() { e.Scale(2) })
+	if n := e.NumShards(); n != 1 {
+		t.Fatalf("NumShards = %d after a rejected Scale", n)
+	}
+
+	sharded := NewEngine()
+	sharded.StartN(2)
+	stopOnCleanup(t, sharded)
+	mustPanic("EnableWAL on a sharded engine", func() { sharded.EnableWAL(io.Discard) })
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"time"
+)
+
+// WindowAggregate 是一个滚动窗口 (Tumbling Window) 内 calc 结果的聚合
+// 时间区间为 [Start, End)，单位纳秒 (sysclock 时间)
+type WindowAggregate struct {
+	Start int64
+	End   int64
+	Sum   int64 // 窗口内所有 calc 结果之和
+	Count int   // 计入 Sum 的任务数
+	Late  int   // 迟到任务数 (EventTime 早于本窗口起点，不计入 Sum)
+}
+
+// tumblingWindow 只在 C World 中访问，不需要任何同步
+type tumblingWindow struct {
+	size int64
+	agg  WindowAggregate
+	sink chan<- WindowAggregate
+}
+
+// EnableWindow 开启 calc 任务的滚动窗口聚合，必须在 Start 之前调用
+//
+// 带 Windowed 标记的 calc 任务不再逐个回复 (Resp 可以为 nil)，
+// 结果累加进当前窗口；窗口按 sysclock 时间对齐到 size 的整数倍，
+// Worker 在每轮循环中检查时间，窗口结束时把聚合结果非阻塞地发送到 sink
+// (sink 满时丢弃该窗口，Worker 永远不会因为订阅者太慢而阻塞)。
+// 空窗口不会发送。精度受 sysclock 的 1ms 刷新周期限制。
+func (e *Engine) EnableWindow(size time.Duration, sink chan<- WindowAggregate) {
+	w := &tumblingWindow{size: int64(size), sink: sink}
+	w.reset(sysclock.Now())
+	e.window = w
+}
+
+func (w *tumblingWindow) reset(now int64) {
+	start := now - now%w.size
+	w.agg = WindowAggregate{Start: start, End: start + w.size}
+}
+
+// add 把一个结果计入当前窗口
+// eventTime 是任务的事件时间 (0 表示使用处理时间)：早于窗口起点的视为迟到，
+// 只计数不累加，因为它所属的窗口已经发出
+func (w *tumblingWindow) add(eventTime int64, v int) {
+	if eventTime != 0 && eventTime < w.agg.Start {
+		w.agg.Late++
+		return
+	}
+	w.agg.Sum += int64(v)
+	w.agg.Count++
+}
+
+// advance 在时间越过窗口终点时发出聚合结果并开启新窗口
+func (w *tumblingWindow) advance(now int64) {
+	if now < w.agg.End {
+		return
+	}
+	if w.agg.Count > 0 || w.agg.Late > 0 {
+		select {
+		case w.sink <- w.agg:
+		default:
+		}
+	}
+	w.reset(now)
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"arena_demo/pkg/sysclock"
+	"testing"
+	"time"
+)
+
+func TestTumblingWindowSums(t *testing.T) {
+	const size = time.Second
+	sysclock.Advance(0) // 虚拟时间：窗口只在 AdvanceClock 时关闭
+	t.Cleanup(sysclock.Start)
+	sysclock.Advance(size - time.Duration(sysclock.Now()%int64(size))) // 对齐到窗口起点
+
+	e := NewEngineSync()
+	sink := make(chan WindowAggregate, 4)
+	e.EnableWindow(size, sink)
+	start := sysclock.Now()
+
+	submit := func(v int, eventTime int64) {
+		t.Helper()
+		if err := e.TrySubmit(Task{Type: TaskTypeCalc, Value: v, Windowed: true, EventTime: eventTime}); err != nil {
+			t.Fatalf("TrySubmit: %v", err)
+		}
+	}
+	expect := func(want WindowAggregate) {
+		t.Helper()
+		select {
+		case got := <-sink:
+			if got != want {
+				t.Fatalf("window = %+v, want %+v", got, want)
+			}
+		default:
+			t.Fatalf("no window emitted, want %+v", want)
+		}
+	}
+
+	for v := 1; v <= 3; v++ {
+		submit(v, 0)
+	}
+	if len(sink) != 0 {
+		t.Fatal("window emitted before it closed")
+	}
+	e.AdvanceClock(size)
+	expect(WindowAggregate{Start: start, End: start + int64(size), Sum: 2 + 4 + 6, Count: 3})
+
+	// 事件时间属于上一个窗口的任务是迟到的：只计数，不计入 Sum
+	submit(100, start)
+	submit(5, 0)
+	e.AdvanceClock(size)
+	expect(WindowAggregate{Start: start + int64(size), End: start + 2*int64(size), Sum: 10, Count: 1, Late: 1})
+
+	// 空窗口不发送
+	e.AdvanceClock(size)
+	submit(0, 0) // 同步模式在下一个任务之前推进窗口
+	if len(sink) != 0 {
+		t.Fatalf("empty window emitted: %+v", <-sink)
+	}
+}
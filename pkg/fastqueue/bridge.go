@@ -0,0 +1,116 @@
+package fastqueue
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// FromChan 把一个 Go channel 泵入 RingBuffer (channel -> ring)，用于从 channel 代码逐步迁移
+// 它阻塞运行，直到 ch 被关闭 (此时 ch 中剩余的数据已全部写入) 或 stop 被关闭
+// 队列满时自旋 + Gosched 重试，不会丢数据
+// 它是该队列唯一的生产者 (SPSC 约束)
+func (rb *RingBuffer[T]) FromChan(ch <-chan T, stop <-chan struct{}) {
+	for {
+		select {
+		case item, ok := <-ch:
+			if !ok {
+				return
+			}
+			for !rb.Push(item) {
+				select {
+				case <-stop:
+					return
+				default:
+					runtime.Gosched()
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ToChan 把 RingBuffer 中的数据转发到 channel (ring -> channel)
+// 它阻塞运行直到 stop 被关闭，返回前会关闭 ch，方便下游 range
+// 它是该队列唯一的消费者 (SPSC 约束)；stop 之后仍留在队列中的数据不会被转发
+func (rb *RingBuffer[T]) ToChan(ch chan<- T, stop <-chan struct{}) {
+	defer close(ch)
+	for {
+		item, ok := rb.Pop()
+		if !ok {
+			select {
+			case <-stop:
+				return
+			default:
+				runtime.Gosched()
+				continue
+			}
+		}
+		select {
+		case ch <- item:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// chanBridge 是 Channel 启动的转发 goroutine 的状态
+type chanBridge[T any] struct {
+	ch   chan T
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// bridgeIdle 是转发 goroutine 在队列为空时每次休眠的上限，之后复查 stop
+const bridgeIdle = time.Millisecond
+
+// Channel 返回一个可以放进 select 的 channel，由后台 goroutine 把队列中的数据 Pop 出来转发
+// 多次调用返回同一个 channel；用 CloseChannel 停止转发
+//
+// 这只是与 channel 代码互操作的便利层，不是热路径：每个元素多一次 channel 收发 (及可能的调度)，
+// 延迟与普通 channel 相当。队列为空时后台 goroutine 停在 PopTimeout 上休眠，不会空转
+// 转发 goroutine 是该队列唯一的消费者 (SPSC 约束)，开启后不要再直接 Pop
+func (rb *RingBuffer[T]) Channel() <-chan T {
+	if rb.bridge == nil {
+		b := &chanBridge[T]{ch: make(chan T), stop: make(chan struct{}), done: make(chan struct{})}
+		rb.bridge = b
+		go rb.runBridge(b)
+	}
+	return rb.bridge.ch
+}
+
+// CloseChannel 停止 Channel 的转发并等待后台 goroutine 退出，之后 channel 被关闭
+// 仍留在队列中的数据不会丢失，可以在 CloseChannel 返回后继续 Pop；
+// 但正阻塞在发送上、还没有被接收的那一个元素会被丢弃 (与 ToChan 相同)
+// 未调用过 Channel 时为空操作，可重复调用
+func (rb *RingBuffer[T]) CloseChannel() {
+	b := rb.bridge
+	if b == nil {
+		return
+	}
+	b.once.Do(func() { close(b.stop) })
+	<-b.done
+}
+
+func (rb *RingBuffer[T]) runBridge(b *chanBridge[T]) {
+	defer close(b.done)
+	defer close(b.ch)
+	for {
+		item, ok := rb.PopTimeout(bridgeIdle)
+		if !ok {
+			select {
+			case <-b.stop:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case b.ch <- item:
+		case <-b.stop:
+			return
+		}
+	}
+}
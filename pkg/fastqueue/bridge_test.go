@@ -0,0 +1,110 @@
+package fastqueue
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// channel -> ring -> channel：容量远小于数据量，两端都要经历队列满/空的重试
+func TestBridgePreservesOrder(t *testing.T) {
+	const items = 10000
+	rb := New[int](8)
+	in := make(chan int)
+	out := make(chan int, 4)
+	stop := make(chan struct{})
+
+	go func() {
+		for i := range items {
+			in <- i
+		}
+		close(in)
+	}()
+	fromDone := make(chan struct{})
+	go func() {
+		defer close(fromDone)
+		rb.FromChan(in, stop)
+	}()
+	go rb.ToChan(out, stop)
+
+	for want := range items {
+		if got := <-out; got != want {
+			t.Fatalf("item %d = %d, order not preserved", want, got)
+		}
+	}
+	// in 关闭后 FromChan 写完剩余数据自行返回
+	select {
+	case <-fromDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("FromChan did not return after the channel was closed")
+	}
+	close(stop)
+	if _, ok := <-out; ok {
+		t.Fatal("extra item after all input was forwarded")
+	}
+}
+
+// 队列满、上游又不关闭时，stop 让 FromChan 退出而不是一直重试
+func TestFromChanStop(t *testing.T) {
+	rb := New[int](2)
+	in := make(chan int, 4)
+	for i := range 4 {
+		in <- i
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rb.FromChan(in, stop)
+	}()
+	time.Sleep(time.Millisecond)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("FromChan ignored stop while the ring was full")
+	}
+	if rb.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", rb.Len())
+	}
+}
+
+// Channel 可以和其它 channel 放在同一个 select 里，按顺序收到全部数据；
+// CloseChannel 之后 channel 被关闭，没来得及转发的数据仍留在队列中
+func TestChannelBridge(t *testing.T) {
+	const items = 5000
+	rb := New[int](16)
+	go func() {
+		for i := range items {
+			for !rb.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	ch := rb.Channel()
+	if rb.Channel() != ch {
+		t.Fatal("Channel returned a different channel on the second call")
+	}
+	timeout := time.After(10 * time.Second)
+	for want := range items {
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Fatalf("got %d, want %d", got, want)
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for item %d", want)
+		}
+	}
+
+	rb.CloseChannel()
+	rb.CloseChannel()
+	if _, ok := <-ch; ok {
+		t.Fatal("channel still open after CloseChannel")
+	}
+	rb.Push(-1)
+	if v, ok := rb.Pop(); !ok || v != -1 {
+		t.Fatalf("Pop after CloseChannel = %d, %v", v, ok)
+	}
+}
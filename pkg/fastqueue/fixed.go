@@ -0,0 +1,83 @@
+package fastqueue
+
+import "sync/atomic"
+
+// FixedRingBuffer 是容量在编译期确定的 SPSC 队列，用于最热的那几条队列
+//
+// Go 泛型没有 const 参数 (不能写 FixedRingBuffer[T, 1024])，这里的变通是
+// 把 "数组类型本身" 作为类型参数：
+//
+//	q := fastqueue.NewFixed[Task, [1024]Task]()
+//
+// 编译器按 GC Shape 对泛型做单态化，不同长度的数组是不同的 Shape，
+// 所以每种容量都会生成一份独立的代码，其中 len(buf) 是常量，
+// head&mask 直接编译成 AND 立即数，省掉 RingBuffer 里 size/mask 字段的加载
+//
+// 限制：
+//   - 可用容量只能是 FixedSize 中列出的几种，新增容量必须修改约束
+//   - 数组内嵌在结构体里，大容量队列请务必用 NewFixed 在堆上分配，不要放在栈上
+//   - 只支持 Push/Pop，没有 onDrop、水位等扩展
+type FixedRingBuffer[T any, A FixedSize[T]] struct {
+	buffer A
+
+	_ CacheLinePad
+
+	head uint64 // write index (Producer Only)
+
+	_ CacheLinePad
+
+	tail uint64 // read index (Consumer Only)
+
+	_ CacheLinePad
+}
+
+// FixedSize 是 FixedRingBuffer 支持的容量 (必须是 2 的幂)
+type FixedSize[T any] interface {
+	~[64]T | ~[256]T | ~[1024]T | ~[4096]T | ~[65536]T
+}
+
+// NewFixed 创建一个容量为 len(A) 的队列
+func NewFixed[T any, A FixedSize[T]]() *FixedRingBuffer[T, A] {
+	return &FixedRingBuffer[T, A]{}
+}
+
+// Push 写入数据 (Go World -> C World)
+func (rb *FixedRingBuffer[T, A]) Push(item T) bool {
+	head := atomic.LoadUint64(&rb.head)
+	tail := atomic.LoadUint64(&rb.tail)
+
+	if head-tail >= uint64(len(rb.buffer)) {
+		return false // Full
+	}
+
+	rb.buffer[head&uint64(len(rb.buffer)-1)] = item
+	atomic.AddUint64(&rb.head, 1)
+	return true
+}
+
+// Pop 读取数据 (C World 内部使用)
+func (rb *FixedRingBuffer[T, A]) Pop() (T, bool) {
+	head := atomic.LoadUint64(&rb.head)
+	tail := atomic.LoadUint64(&rb.tail)
+
+	var empty T
+	if head == tail {
+		return empty, false // Empty
+	}
+
+	item := rb.buffer[tail&uint64(len(rb.buffer)-1)]
+	atomic.AddUint64(&rb.tail, 1)
+	return item, true
+}
+
+// Len 返回队列中的元素个数
+func (rb *FixedRingBuffer[T, A]) Len() uint64 {
+	tail := atomic.LoadUint64(&rb.tail)
+	head := atomic.LoadUint64(&rb.head)
+	return distance(head, tail)
+}
+
+// Cap 返回队列容量 (编译期常量)
+func (rb *FixedRingBuffer[T, A]) Cap() uint64 {
+	return uint64(len(rb.buffer))
+}
@@ -0,0 +1,47 @@
+package fastqueue
+
+import "testing"
+
+func TestFixedRingBuffer(t *testing.T) {
+	q := NewFixed[int, [64]int]()
+	if q.Cap() != 64 {
+		t.Fatalf("Cap = %d, want 64", q.Cap())
+	}
+	// 绕几圈，覆盖 head&mask 回绕
+	for round := range 3 {
+		for i := range 64 {
+			if !q.Push(round*64 + i) {
+				t.Fatalf("round %d: Push %d failed", round, i)
+			}
+		}
+		if q.Push(-1) || q.Len() != 64 {
+			t.Fatalf("round %d: full queue accepted a push (Len = %d)", round, q.Len())
+		}
+		for i := range 64 {
+			if v, ok := q.Pop(); !ok || v != round*64+i {
+				t.Fatalf("round %d: Pop = %d, %v; want %d", round, v, ok, round*64+i)
+			}
+		}
+		if _, ok := q.Pop(); ok {
+			t.Fatalf("round %d: Pop on an empty queue succeeded", round)
+		}
+	}
+}
+
+// 常量掩码 (FixedRingBuffer) 与字段掩码 (RingBuffer) 的 Push+Pop 对比
+func BenchmarkFixedRing(b *testing.B) {
+	b.Run("fixed", func(b *testing.B) {
+		q := NewFixed[int, [1024]int]()
+		for b.Loop() {
+			q.Push(1)
+			q.Pop()
+		}
+	})
+	b.Run("ring", func(b *testing.B) {
+		q := New[int](1024)
+		for b.Loop() {
+			q.Push(1)
+			q.Pop()
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package fastqueue
+
+import "sync/atomic"
+
+// RingMetrics 是 RingBuffer 的操作计数快照
+type RingMetrics struct {
+	Pushes   uint64 // 成功写入
+	PushFull uint64 // 因队列满被拒绝的写入
+	Pops     uint64 // 成功读取
+	PopEmpty uint64 // 队列为空的读取 (Worker 空轮询时增长很快)
+}
+
+// ringMetrics 单独分配在堆上：生产者和消费者各写自己的计数，互相隔开 Cache Line
+type ringMetrics struct {
+	pushes   atomic.Uint64
+	pushFull atomic.Uint64
+
+	_ CacheLinePad
+
+	pops     atomic.Uint64
+	popEmpty atomic.Uint64
+}
+
+// EnableMetrics 打开操作计数，必须在队列投入使用前调用
+// 关闭时 (默认) Push/Pop 只多一次 nil 判断；打开后每次操作多一次原子加法
+func (rb *RingBuffer[T]) EnableMetrics() {
+	rb.metrics = &ringMetrics{}
+}
+
+// Metrics 返回操作计数快照，未调用 EnableMetrics 时全部为 0
+func (rb *RingBuffer[T]) Metrics() RingMetrics {
+	m := rb.metrics
+	if m == nil {
+		return RingMetrics{}
+	}
+	return RingMetrics{
+		Pushes:   m.pushes.Load(),
+		PushFull: m.pushFull.Load(),
+		Pops:     m.pops.Load(),
+		PopEmpty: m.popEmpty.Load(),
+	}
+}
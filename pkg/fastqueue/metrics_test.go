@@ -0,0 +1,84 @@
+package fastqueue
+
+import (
+	"runtime"
+	"testing"
+)
+
+// 一个生产者、一个消费者并发运行：四个计数与双方自己数的成功/失败次数完全一致
+func TestMetricsMatchOperations(t *testing.T) {
+	const n = 100000
+	rb := New[int](64)
+	rb.EnableMetrics()
+
+	full := make(chan uint64)
+	go func() {
+		var rejected uint64
+		for i := 0; i < n; {
+			if rb.Push(i) {
+				i++
+				continue
+			}
+			rejected++
+			runtime.Gosched()
+		}
+		full <- rejected
+	}()
+
+	var empty uint64
+	out := make([]int, 8)
+	for got := 0; got < n; {
+		var k int
+		if got%2 == 0 {
+			if _, ok := rb.Pop(); ok {
+				k = 1
+			}
+		} else {
+			k = rb.popBatch(out)
+		}
+		if k == 0 {
+			if got%2 == 0 {
+				empty++ // 只有 Pop 的空读计入 PopEmpty
+			}
+			runtime.Gosched()
+		}
+		got += k
+	}
+	rejected := <-full
+
+	m := rb.Metrics()
+	want := RingMetrics{Pushes: n, PushFull: rejected, Pops: n, PopEmpty: empty}
+	if m != want {
+		t.Fatalf("Metrics = %+v, want %+v", m, want)
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	rb := New[int](2)
+	rb.Push(1)
+	rb.Push(2)
+	rb.Push(3)
+	rb.Pop()
+	if m := rb.Metrics(); m != (RingMetrics{}) {
+		t.Fatalf("Metrics without EnableMetrics = %+v", m)
+	}
+}
+
+func BenchmarkPushPopMetrics(b *testing.B) {
+	for _, on := range []bool{false, true} {
+		name := "off"
+		if on {
+			name = "on"
+		}
+		b.Run(name, func(b *testing.B) {
+			rb := New[int](1024)
+			if on {
+				rb.EnableMetrics()
+			}
+			for b.Loop() {
+				rb.Push(1)
+				rb.Pop()
+			}
+		})
+	}
+}
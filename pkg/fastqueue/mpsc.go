@@ -0,0 +1,134 @@
+package fastqueue
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// MPSC 是一个多生产者单消费者的有界无锁队列 (Vyukov 风格，每个槽位带序号)
+// 生产者通过 CAS 争抢 head，CAS 失败时按指数退避，避免多个生产者在同一个 Cache Line 上原地狂转
+type MPSC[T any] struct {
+	buffer []mpscSlot[T]
+	size   uint64
+	mask   uint64
+
+	// 退避参数 (只读)
+	minSpin, maxSpin int
+
+	_ CacheLinePad
+
+	head atomic.Uint64 // write index (Producers, CAS)
+
+	_ CacheLinePad
+
+	tail uint64 // read index (Consumer Only)
+
+	_ CacheLinePad
+}
+
+type mpscSlot[T any] struct {
+	// seq == pos      : 槽位空闲，等待写入位置 pos
+	// seq == pos + 1  : 槽位已写入，等待读取位置 pos
+	seq atomic.Uint64
+	val T
+}
+
+// 默认退避参数：从 4 次自旋开始翻倍，超过 1024 次后改为 Gosched 让出
+const (
+	defaultMinSpin = 4
+	defaultMaxSpin = 1024
+)
+
+// NewMPSC 创建一个容量为 size (2 的幂) 的 MPSC 队列
+func NewMPSC[T any](size uint64) *MPSC[T] {
+	if size == 0 || size&(size-1) != 0 {
+		panic(ErrInvalidSize)
+	}
+	q := &MPSC[T]{
+		buffer:  make([]mpscSlot[T], size),
+		size:    size,
+		mask:    size - 1,
+		minSpin: defaultMinSpin,
+		maxSpin: defaultMaxSpin,
+	}
+	for i := range q.buffer {
+		q.buffer[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// SetBackoff 调整 CAS 失败后的退避：从 minSpin 次空转开始每次翻倍，
+// 超过 maxSpin 后每次失败都调用 runtime.Gosched()
+// 必须在队列投入使用前调用
+func (q *MPSC[T]) SetBackoff(minSpin, maxSpin int) {
+	if minSpin < 1 {
+		minSpin = 1
+	}
+	if maxSpin < minSpin {
+		maxSpin = minSpin
+	}
+	q.minSpin, q.maxSpin = minSpin, maxSpin
+}
+
+// Push 写入数据，可被任意多个 goroutine 并发调用；队列满时返回 false
+func (q *MPSC[T]) Push(item T) bool {
+	spin := q.minSpin
+	for {
+		pos := q.head.Load()
+		slot := &q.buffer[pos&q.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				slot.val = item
+				// 发布：消费者看到 seq == pos+1 时 val 一定已经写好
+				slot.seq.Store(pos + 1)
+				return true
+			}
+		case diff < 0:
+			return false // Full
+		}
+		// CAS 竞争失败 (或者 head 已被其它生产者推进)：退避后重试
+		spin = backoff(spin, q.maxSpin)
+	}
+}
+
+// backoff 空转 spin 次，返回下一次的空转次数 (翻倍，封顶后改为 Gosched)
+func backoff(spin, max int) int {
+	if spin > max {
+		runtime.Gosched()
+		return spin
+	}
+	for i := 0; i < spin; i++ {
+		// 纯空转，不触碰共享内存
+	}
+	return spin << 1
+}
+
+// Pop 读取数据 (单消费者)
+func (q *MPSC[T]) Pop() (T, bool) {
+	var empty T
+	pos := q.tail
+	slot := &q.buffer[pos&q.mask]
+	if slot.seq.Load() != pos+1 {
+		return empty, false // Empty (或生产者尚未完成写入)
+	}
+	item := slot.val
+	slot.val = empty
+	// 释放槽位给下一圈的生产者
+	slot.seq.Store(pos + q.size)
+	atomic.StoreUint64(&q.tail, pos+1)
+	return item, true
+}
+
+// Len 返回近似长度 (生产者可能正在写入)
+func (q *MPSC[T]) Len() uint64 {
+	head := q.head.Load()
+	tail := atomic.LoadUint64(&q.tail)
+	return distance(head, tail)
+}
+
+// Cap 返回队列容量
+func (q *MPSC[T]) Cap() uint64 {
+	return q.size
+}
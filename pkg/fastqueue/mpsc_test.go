@@ -0,0 +1,114 @@
+package fastqueue
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// 多个生产者并发写入：每个元素恰好被消费一次，同一生产者的元素保持 FIFO
+func TestMPSCProducers(t *testing.T) {
+	const producers, per = 4, 20000
+	q := NewMPSC[int](64)
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range per {
+				for !q.Push(p*per + i) {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	last := [producers]int{-1, -1, -1, -1}
+	for n := 0; n < producers*per; {
+		v, ok := q.Pop()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+		p, i := v/per, v%per
+		if i <= last[p] {
+			t.Fatalf("producer %d: item %d after %d", p, i, last[p])
+		}
+		last[p] = i
+		n++
+	}
+	wg.Wait()
+	for p, i := range last {
+		if i != per-1 {
+			t.Fatalf("producer %d: last item %d, want %d", p, i, per-1)
+		}
+	}
+	if _, ok := q.Pop(); ok {
+		t.Fatal("extra item in the queue")
+	}
+}
+
+// pushSpin 是不带退避的 Push，只作为 BenchmarkMPSCPush 的对照
+func (q *MPSC[T]) pushSpin(item T) bool {
+	for {
+		pos := q.head.Load()
+		slot := &q.buffer[pos&q.mask]
+		switch diff := int64(slot.seq.Load() - pos); {
+		case diff == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				slot.val = item
+				slot.seq.Store(pos + 1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+	}
+}
+
+// 1/2/4/8 个生产者下指数退避与原地重试的总吞吐对比
+//
+//	go test -bench MPSCPush -cpu 8 ./pkg/fastqueue
+func BenchmarkMPSCPush(b *testing.B) {
+	for _, producers := range []int{1, 2, 4, 8} {
+		for _, mode := range []string{"backoff", "spin"} {
+			b.Run(fmt.Sprintf("%s/p=%d", mode, producers), func(b *testing.B) {
+				q := NewMPSC[int](1024)
+				push := q.Push
+				if mode == "spin" {
+					push = q.pushSpin
+				}
+				var stop atomic.Bool
+				drained := make(chan struct{})
+				go func() {
+					defer close(drained)
+					for !stop.Load() {
+						if _, ok := q.Pop(); !ok {
+							runtime.Gosched()
+						}
+					}
+				}()
+
+				b.ResetTimer()
+				var wg sync.WaitGroup
+				for p := range producers {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := p; i < b.N; i += producers {
+							for !push(i) {
+								runtime.Gosched()
+							}
+						}
+					}()
+				}
+				wg.Wait()
+				b.StopTimer()
+				stop.Store(true)
+				<-drained
+			})
+		}
+	}
+}
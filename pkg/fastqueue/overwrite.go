@@ -0,0 +1,149 @@
+package fastqueue
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// OverwriteRing 是一个单生产者单消费者的有损队列：满时 Push 覆盖最旧的元素，而不是失败
+// 适合 "只关心最近 N 条" 的场景 (监控采样、结果环的 DropOldest 策略)，生产者永远不会被慢消费者拖住
+//
+// 与 RingBuffer 分开实现：覆盖意味着生产者要回收一个消费者可能正在读的槽位，
+// 如果让生产者去推进 tail，tail 就不再是消费者独占的，RingBuffer 的 Pop 也就不能再用一次原子加法出队。
+// 这里 head/tail 仍然各归一方，争夺发生在槽位上：每个槽位带一个状态字 (序号<<2 | 状态)，
+//   - 消费者用 CAS 把 "已写入" 改成 "读取中"，拷走元素后改成 "空闲"
+//   - 生产者覆盖时用 CAS 把 "已写入" 改成 "空闲"，成功的一方才拥有这个元素：
+//     要么被消费，要么被丢弃 (调用 onDrop)，恰好其中之一
+//   - 生产者遇到 "读取中" 时让出 CPU 等消费者拷完 (只是一次值拷贝的时间)
+//
+// 消费者落后超过一圈时，被覆盖的元素由生产者丢弃，消费者直接跳到仍然有效的最旧元素
+type OverwriteRing[T any] struct {
+	buffer []overwriteSlot[T]
+	size   uint64
+	mask   uint64
+
+	// onDrop 在元素被覆盖或被 Reset 清空时调用 (只读)
+	onDrop func(T)
+
+	_ CacheLinePad
+
+	head uint64 // write index (Producer Only)
+
+	_ CacheLinePad
+
+	tail uint64 // read index (Consumer Only)
+
+	_ CacheLinePad
+}
+
+type overwriteSlot[T any] struct {
+	state atomic.Uint64 // seq<<2 | slotEmpty/slotReady/slotReading
+	val   T
+}
+
+// 槽位状态 (state 的低 2 位)，零值就是空闲
+const (
+	slotEmpty uint64 = iota
+	slotReady
+	slotReading
+)
+
+// NewOverwrite 创建一个容量为 size (2 的幂) 的覆盖队列，size 非法时 panic
+func NewOverwrite[T any](size uint64) *OverwriteRing[T] {
+	if size == 0 || size&(size-1) != 0 {
+		panic(ErrInvalidSize)
+	}
+	return &OverwriteRing[T]{
+		buffer: make([]overwriteSlot[T], size),
+		size:   size,
+		mask:   size - 1,
+	}
+}
+
+// SetOnDrop 设置丢弃回调：元素未被消费就被覆盖 (生产者中调用) 或被 Reset 清空 (消费者中调用) 时恰好调用一次
+// 必须在队列投入使用前设置
+func (q *OverwriteRing[T]) SetOnDrop(fn func(T)) {
+	q.onDrop = fn
+}
+
+// Push 写入数据，队列满时覆盖最旧的元素 (生产者调用)，返回 false 表示发生了覆盖
+func (q *OverwriteRing[T]) Push(item T) bool {
+	head := q.head
+	slot := &q.buffer[head&q.mask]
+	dropped := false
+	for {
+		st := slot.state.Load()
+		switch st & 3 {
+		case slotReady:
+			// 上一圈的元素 (序号 head-size) 还没被消费：与消费者抢它
+			if !slot.state.CompareAndSwap(st, head<<2|slotEmpty) {
+				continue // 消费者先拿到了，重读状态
+			}
+			if q.onDrop != nil {
+				q.onDrop(slot.val)
+			}
+			dropped = true
+		case slotReading:
+			runtime.Gosched()
+			continue
+		}
+		break
+	}
+	slot.val = item
+	slot.state.Store(head<<2 | slotReady)
+	atomic.StoreUint64(&q.head, head+1)
+	return !dropped
+}
+
+// Pop 读取最旧的有效元素 (消费者调用)，队列为空时返回 false
+func (q *OverwriteRing[T]) Pop() (T, bool) {
+	var empty T
+	tail := q.tail
+	for {
+		head := atomic.LoadUint64(&q.head)
+		if head == tail {
+			return empty, false
+		}
+		if head-tail > q.size {
+			// 落后超过一圈，中间的元素已被覆盖 (生产者已经为它们调用过 onDrop)
+			tail = head - q.size
+		}
+		slot := &q.buffer[tail&q.mask]
+		ready := tail<<2 | slotReady
+		if slot.state.Load() == ready && slot.state.CompareAndSwap(ready, tail<<2|slotReading) {
+			item := slot.val
+			slot.val = empty
+			slot.state.Store(tail<<2 | slotEmpty)
+			atomic.StoreUint64(&q.tail, tail+1)
+			return item, true
+		}
+		// head 已经越过 tail，所以 tail 号元素一定发布过；状态不对说明它刚被生产者覆盖，跳过
+		tail++
+		atomic.StoreUint64(&q.tail, tail)
+	}
+}
+
+// Reset 清空队列中所有未消费的元素 (消费者调用)，对每个被丢弃的元素调用 onDrop
+func (q *OverwriteRing[T]) Reset() {
+	for {
+		item, ok := q.Pop()
+		if !ok {
+			return
+		}
+		if q.onDrop != nil {
+			q.onDrop(item)
+		}
+	}
+}
+
+// Len 返回队列中的元素个数 (近似：消费者落后超过一圈时按容量计)
+func (q *OverwriteRing[T]) Len() uint64 {
+	head := atomic.LoadUint64(&q.head)
+	tail := atomic.LoadUint64(&q.tail)
+	return min(distance(head, tail), q.size)
+}
+
+// Cap 返回队列容量
+func (q *OverwriteRing[T]) Cap() uint64 {
+	return q.size
+}
@@ -0,0 +1,105 @@
+package fastqueue
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestOverwriteDropsOldest(t *testing.T) {
+	q := NewOverwrite[int](4)
+	var dropped []int
+	q.SetOnDrop(func(v int) { dropped = append(dropped, v) })
+
+	for i := range 10 {
+		if got, want := q.Push(i), i < 4; got != want {
+			t.Fatalf("Push(%d) = %v, want %v", i, got, want)
+		}
+	}
+	if !slices.Equal(dropped, []int{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("dropped = %v, want 0..5", dropped)
+	}
+	if q.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", q.Len())
+	}
+	for want := 6; want < 8; want++ {
+		if v, ok := q.Pop(); !ok || v != want {
+			t.Fatalf("Pop = %d, %v; want %d", v, ok, want)
+		}
+	}
+	q.Reset()
+	if !slices.Equal(dropped, []int{0, 1, 2, 3, 4, 5, 8, 9}) {
+		t.Fatalf("dropped after Reset = %v", dropped)
+	}
+	if _, ok := q.Pop(); ok || q.Len() != 0 {
+		t.Fatal("queue not empty after Reset")
+	}
+}
+
+// 生产者不停覆盖、消费者随机节奏读取：每个元素恰好被消费或被丢弃一次，消费顺序保持 FIFO
+func TestOverwriteEachItemOnce(t *testing.T) {
+	const items = 200000
+	q := NewOverwrite[int](8)
+	seen := make([]uint8, items)
+	var dropped int
+	q.SetOnDrop(func(v int) {
+		seen[v]++ // 只在生产者 goroutine 中调用
+		dropped++
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range items {
+			q.Push(i)
+			if i%64 == 0 {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	consumed := make([]int, 0, items)
+	finished := false
+	for !finished {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		for {
+			v, ok := q.Pop()
+			if !ok {
+				break
+			}
+			consumed = append(consumed, v)
+		}
+		runtime.Gosched()
+	}
+	for _, v := range consumed {
+		seen[v]++
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("item %d consumed+dropped %d times, want exactly once", i, n)
+		}
+	}
+	if !slices.IsSorted(consumed) {
+		t.Fatal("consumed items out of order")
+	}
+	if len(consumed)+dropped != items {
+		t.Fatalf("consumed %d + dropped %d != %d", len(consumed), dropped, items)
+	}
+}
+
+func TestResetCallsOnDrop(t *testing.T) {
+	rb := New[int](8)
+	var dropped []int
+	rb.SetOnDrop(func(v int) { dropped = append(dropped, v) })
+	for i := range 5 {
+		rb.Push(i)
+	}
+	rb.Pop()
+	rb.Reset()
+	if !slices.Equal(dropped, []int{1, 2, 3, 4}) || rb.Len() != 0 {
+		t.Fatalf("dropped = %v, Len = %d", dropped, rb.Len())
+	}
+}
@@ -0,0 +1,53 @@
+package fastqueue
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// rss 从 /proc/self/statm 读取当前进程的常驻内存 (字节)
+func rss(t *testing.T) int {
+	t.Helper()
+	b, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		t.Skipf("statm unavailable: %v", err)
+	}
+	f := bytes.Fields(b)
+	pages, err := strconv.Atoi(string(f[1]))
+	if err != nil {
+		t.Fatalf("parse statm %q: %v", b, err)
+	}
+	return pages * os.Getpagesize()
+}
+
+// 新建的大队列还没有物理页，Prefault 之后整个底层数组都已驻留
+func TestPrefaultMakesBufferResident(t *testing.T) {
+	const size = 1 << 22 // 32MB
+	rb := New[uint64](size)
+	// 底层数组可能复用了之前测试释放的 span (已驻留)：先把它整页交还给内核，内容仍然是零
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(rb.buffer))), size*8)
+	page := os.Getpagesize()
+	start := (page - int(uintptr(unsafe.Pointer(&buf[0])))%page) % page
+	end := start + (len(buf)-start)/page*page
+	if err := syscall.Madvise(buf[start:end], syscall.MADV_DONTNEED); err != nil {
+		t.Skipf("madvise: %v", err)
+	}
+	before := rss(t)
+	rb.Prefault()
+	after := rss(t)
+	if grew := after - before; grew < size*8*3/4 {
+		t.Fatalf("RSS grew by %d bytes after Prefault of a %d-byte buffer (before %d, after %d)", grew, size*8, before, after)
+	}
+
+	// 只写了零值：队列照常使用
+	if !rb.Push(7) {
+		t.Fatal("Push after Prefault failed")
+	}
+	if v, ok := rb.Pop(); !ok || v != 7 {
+		t.Fatalf("Pop after Prefault = %d, %v", v, ok)
+	}
+}
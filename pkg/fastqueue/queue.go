@@ -1,9 +1,17 @@
 package fastqueue
 
 import (
+	"context"
+	"errors"
+	"os"
 	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
+// ErrInvalidSize 队列容量必须是 >= 1 的 2 的幂
+var ErrInvalidSize = errors.New("fastqueue: size must be a power of 2 and >= 1")
+
 // CacheLinePad 用于防止 False Sharing
 // 现代 CPU Cache Line 通常是 64 字节
 type CacheLinePad struct {
@@ -12,6 +20,10 @@ type CacheLinePad struct {
 
 // RingBuffer 是一个单生产者单消费者(SPSC)的无锁队列。
 // 优化：增加了 Cache Padding 防止伪共享
+//
+// head/tail 是永不回绕归零的 uint64 计数器，到 2^64 时会自然溢出。
+// 所以所有空/满判断都只使用差值 head-tail (无符号减法跨越溢出点依然正确)，
+// 从不直接比较 head 与 tail 的大小 (溢出后 tail > head 并不代表队列为空)
 type RingBuffer[T any] struct {
 	buffer []T
 	size   uint64
@@ -21,23 +33,89 @@ type RingBuffer[T any] struct {
 
 	head uint64 // write index (Producer Only)
 
+	reserved bool // Reserve 之后、Commit 之前为 true (Producer Only)
+
 	_ CacheLinePad // 隔离 Head 和 Tail，防止两个核心争抢同一个 Cache Line
 
 	tail uint64 // read index (Consumer Only)
 
 	_ CacheLinePad
+
+	// onDrop 在元素未被消费就被丢弃时调用 (Reset 清空)
+	onDrop func(T)
+
+	// waiting/signal 用于 PopTimeout 休眠唤醒：消费者休眠前置 waiting=1，生产者看到后投递一个信号
+	waiting atomic.Uint32
+	signal  chan struct{}
+
+	// spaceWaiters/spaceSignal 是方向相反的同一套机制，用于 PushTimeout：
+	// 生产者等待空位前计数 +1，消费者腾出空位后看到计数非 0 就投递一个信号
+	spaceWaiters atomic.Int32
+	spaceSignal  chan struct{}
+
+	// emptyWaiters/emptySignal 用于 WaitEmpty：等待者同时计入 spaceWaiters (让出队路径调用 wakeSpace)，
+	// wakeSpace 看到 emptyWaiters 非 0 时额外投递一个 "可能已空" 的信号
+	emptyWaiters atomic.Int32
+	emptySignal  chan struct{}
+
+	// metrics 非空时统计 Push/Pop 次数 (见 EnableMetrics)，默认关闭，热路径上只多一次 nil 判断
+	metrics *ringMetrics
+
+	// wm 非空时 Push/Pop 检查是否越过高低水位 (见 SetWatermarks)，默认关闭，热路径上只多一次 nil 判断
+	wm *watermarks
+
+	// bridge 非空表示 Channel 的转发 goroutine 已启动
+	bridge *chanBridge[T]
 }
 
+// New 创建一个容量为 size 的队列，size 非法时 panic
 func New[T any](size uint64) *RingBuffer[T] {
-	// size must be power of 2
-	if size&(size-1) != 0 {
-		panic("size must be power of 2")
+	rb, err := TryNew[T](size)
+	if err != nil {
+		panic(err)
+	}
+	return rb
+}
+
+// TryNew 与 New 相同，但 size 非法时返回 ErrInvalidSize 而不是 panic
+//
+// size 必须是 2 的幂：
+//   - size == 0 会被拒绝 (0&(0-1)==0 能骗过幂次检查，但 mask 会变成 ^0，head&mask 越界)
+//   - size == 1 是合法的：mask 为 0，所有读写都落在唯一的槽位上，队列退化为单槽信箱
+func TryNew[T any](size uint64) (*RingBuffer[T], error) {
+	if size == 0 || size&(size-1) != 0 {
+		return nil, ErrInvalidSize
 	}
 	return &RingBuffer[T]{
-		buffer: make([]T, size),
-		size:   size,
-		mask:   size - 1,
+		buffer:      make([]T, size),
+		size:        size,
+		mask:        size - 1,
+		signal:      make(chan struct{}, 1),
+		spaceSignal: make(chan struct{}, 1),
+		emptySignal: make(chan struct{}, 1),
+	}, nil
+}
+
+// Prefault 逐页写一遍底层数组，让它在真正使用之前就驻留在物理内存中
+//
+// 大容量队列的 make([]T, size) 来自操作系统新映射的零页，运行时不会去清零它，
+// 物理页要等第一次写入时才由缺页中断分配：启动后最初的一批 Push 每跨过一页都要付一次缺页的代价 (微秒级)。
+// Prefault 把这笔成本挪到启动阶段：每页写一个零值元素，耗时与容量成正比 (大约每 MB 几十微秒，
+// 另加对应的 RSS)，小于一页的队列没有必要调用。
+//
+// 写入的是零值，会覆盖槽位中的内容，所以只能在队列启用之前 (第一次 Push 之前) 调用
+func (rb *RingBuffer[T]) Prefault() {
+	var zero T
+	elem := int(unsafe.Sizeof(zero))
+	if elem == 0 {
+		return
+	}
+	stride := max(os.Getpagesize()/elem, 1)
+	for i := 0; i < len(rb.buffer); i += stride {
+		rb.buffer[i] = zero
 	}
+	// 最后一个元素可能落在下一页上
+	rb.buffer[len(rb.buffer)-1] = zero
 }
 
 // Push 写入数据 (Go World -> C World)
@@ -46,6 +124,9 @@ func (rb *RingBuffer[T]) Push(item T) bool {
 	tail := atomic.LoadUint64(&rb.tail)
 
 	if head-tail >= rb.size {
+		if rb.metrics != nil {
+			rb.metrics.pushFull.Add(1)
+		}
 		return false // Full
 	}
 
@@ -53,21 +134,310 @@ func (rb *RingBuffer[T]) Push(item T) bool {
 	// 注意：这里为了简化没做复杂的 Memory Barrier 处理，
 	// 在生产级代码中需要 Padding 防止 False Sharing。
 	rb.buffer[head&rb.mask] = item
-	atomic.AddUint64(&rb.head, 1)
+	rb.publish()
 	return true
 }
 
+// publish 推进 head，让刚写好的槽位对消费者可见 (Push 与 Commit 共用)
+func (rb *RingBuffer[T]) publish() {
+	atomic.AddUint64(&rb.head, 1)
+	if rb.metrics != nil {
+		rb.metrics.pushes.Add(1)
+	}
+	if rb.wm != nil {
+		rb.checkHigh()
+	}
+	// 只有消费者在 PopTimeout 中休眠时才需要唤醒，平时只多一次原子读
+	if rb.waiting.Load() != 0 {
+		rb.wake()
+	}
+}
+
+// wake 非阻塞地投递一个唤醒信号 (信号已在 channel 中时直接丢弃)
+func (rb *RingBuffer[T]) wake() {
+	select {
+	case rb.signal <- struct{}{}:
+	default:
+	}
+}
+
+// wakeSpace 与 wake 相同，唤醒在 PushTimeout 中等待空位的生产者 (以及在 WaitEmpty 中等待的调用方)
+func (rb *RingBuffer[T]) wakeSpace() {
+	select {
+	case rb.spaceSignal <- struct{}{}:
+	default:
+	}
+	if rb.emptyWaiters.Load() != 0 {
+		rb.wakeEmpty()
+	}
+}
+
+// wakeEmpty 非阻塞地投递一个 WaitEmpty 的唤醒信号
+func (rb *RingBuffer[T]) wakeEmpty() {
+	select {
+	case rb.emptySignal <- struct{}{}:
+	default:
+	}
+}
+
+// pushSpin 是 PushTimeout 休眠前的自旋次数
+const pushSpin = 64
+
+// PushTimeout 写入数据，队列满时最多等待 d 让消费者腾出空位 (生产者调用)，超时返回 false
+// 与 PopTimeout 对称：先短暂自旋，然后在空位信号上休眠，计时器在返回时停止
+//
+// 不丢唤醒：生产者先登记等待再复查是否有空位，消费者先推进 tail 再检查等待计数，
+// 所以 "复查仍满" 与 "消费者没看到等待者" 不可能同时发生。信号 channel 容量为 1，
+// 多个生产者同时等待时一次出队只保证唤醒其中一个，其余的在下一次出队或超时时复查
+// 等待期间的复查不计入 Metrics 的 PushFull，只有最终超时计一次
+func (rb *RingBuffer[T]) PushTimeout(item T, d time.Duration) bool {
+	for i := 0; i < pushSpin; i++ {
+		if !rb.full() && rb.Push(item) {
+			return true
+		}
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	rb.spaceWaiters.Add(1)
+	defer rb.spaceWaiters.Add(-1)
+	for {
+		if !rb.full() && rb.Push(item) {
+			return true
+		}
+		select {
+		case <-rb.spaceSignal:
+			// 可能是遗留的信号，或者空位被别的生产者抢走了，回到循环开头复查
+		case <-timer.C:
+			return rb.Push(item)
+		}
+	}
+}
+
+// WaitEmpty 阻塞到队列为空 (head == tail)，ctx 取消时提前返回 ctx.Err()，队列本来就空时立即返回 nil
+// 用于排空/关闭时等消费者处理完已入队的元素，调用方不需要轮询 Len；可以在任意 goroutine 中调用
+//
+// 等待方式与 PushTimeout 相同：先登记再复查，消费者每次出队后看到登记就投递信号，不会丢唤醒。
+// 信号 channel 容量为 1，多个 WaitEmpty 同时等待时，醒来发现已空的一方先转发一个信号再返回，依次唤醒其余的
+// 返回 nil 只说明那一刻队列为空，生产者随后仍可能继续 Push
+func (rb *RingBuffer[T]) WaitEmpty(ctx context.Context) error {
+	if rb.empty() {
+		return nil
+	}
+	rb.emptyWaiters.Add(1)
+	defer rb.emptyWaiters.Add(-1)
+	rb.spaceWaiters.Add(1)
+	defer rb.spaceWaiters.Add(-1)
+	for {
+		if rb.empty() {
+			rb.wakeEmpty()
+			return nil
+		}
+		select {
+		case <-rb.emptySignal:
+			// 每次出队都会投递，回到循环开头复查是否已空
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// empty 报告队列是否为空 (不计入 Metrics)
+func (rb *RingBuffer[T]) empty() bool {
+	return distance(atomic.LoadUint64(&rb.head), atomic.LoadUint64(&rb.tail)) == 0
+}
+
+// full 报告队列是否已满 (不计入 Metrics)
+func (rb *RingBuffer[T]) full() bool {
+	return atomic.LoadUint64(&rb.head)-atomic.LoadUint64(&rb.tail) >= rb.size
+}
+
 // Pop 读取数据 (C World 内部使用)
 func (rb *RingBuffer[T]) Pop() (T, bool) {
 	head := atomic.LoadUint64(&rb.head)
 	tail := atomic.LoadUint64(&rb.tail)
 
 	var empty T
-	if tail >= head {
+	if head == tail {
+		if rb.metrics != nil {
+			rb.metrics.popEmpty.Add(1)
+		}
 		return empty, false // Empty
 	}
 
 	item := rb.buffer[tail&rb.mask]
 	atomic.AddUint64(&rb.tail, 1)
+	if rb.metrics != nil {
+		rb.metrics.pops.Add(1)
+	}
+	if rb.wm != nil {
+		rb.checkLow()
+	}
+	if rb.spaceWaiters.Load() != 0 {
+		rb.wakeSpace()
+	}
 	return item, true
 }
+
+// Peek 返回队首元素的指针但不出队 (消费者调用)，队列为空时返回 false
+// 指针只在下一次 Pop 之前有效
+func (rb *RingBuffer[T]) Peek() (*T, bool) {
+	head := atomic.LoadUint64(&rb.head)
+	tail := atomic.LoadUint64(&rb.tail)
+	if head == tail {
+		return nil, false // Empty
+	}
+	return &rb.buffer[tail&rb.mask], true
+}
+
+// popSpin 是 PopTimeout 休眠前的自旋次数
+const popSpin = 64
+
+// PopTimeout 读取数据，队列为空时最多等待 d (消费者调用)，超时返回 ok=false
+// 先短暂自旋，然后在信号 channel 上休眠，适合还需要定期做维护工作的消费者
+//
+// 不丢唤醒：消费者先置 waiting 再复查队列，生产者先推进 head 再检查 waiting，
+// 两边都是原子操作，所以 "复查为空" 与 "生产者没看到 waiting" 不可能同时发生
+func (rb *RingBuffer[T]) PopTimeout(d time.Duration) (T, bool) {
+	for i := 0; i < popSpin; i++ {
+		if item, ok := rb.Pop(); ok {
+			return item, true
+		}
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		rb.waiting.Store(1)
+		item, ok := rb.Pop()
+		if ok {
+			rb.waiting.Store(0)
+			return item, true
+		}
+		select {
+		case <-rb.signal:
+			// 可能是上一次遗留的信号，回到循环开头复查
+		case <-timer.C:
+			rb.waiting.Store(0)
+			return rb.Pop()
+		}
+	}
+}
+
+// PopBatchWait 等到队列中至少有 minItems 个元素 (或等满 maxWait) 后，一次取出最多 len(out) 个，
+// 返回取出的个数 (消费者调用)。让消费者攒够一批再醒来处理，同时用 maxWait 限定延迟上界
+//
+// 超时时有多少取多少 (可能少于 minItems，也可能为 0)；minItems <= 0 时不等待，
+// minItems 大于 len(out) 或队列容量时按两者中较小的值处理 (否则永远等不到)
+// 等待方式与 PopTimeout 相同，凑批期间每次 Push 都会唤醒一次消费者复查长度
+func (rb *RingBuffer[T]) PopBatchWait(out []T, minItems int, maxWait time.Duration) int {
+	if len(out) == 0 {
+		return 0
+	}
+	want := uint64(min(minItems, len(out)))
+	want = min(want, rb.size)
+	if minItems <= 0 || rb.Len() >= want {
+		return rb.popBatch(out)
+	}
+	for i := 0; i < popSpin; i++ {
+		if rb.Len() >= want {
+			return rb.popBatch(out)
+		}
+	}
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	for {
+		rb.waiting.Store(1)
+		if rb.Len() >= want {
+			rb.waiting.Store(0)
+			return rb.popBatch(out)
+		}
+		select {
+		case <-rb.signal:
+		case <-timer.C:
+			rb.waiting.Store(0)
+			return rb.popBatch(out)
+		}
+	}
+}
+
+// popBatch 把队首最多 len(out) 个元素拷贝到 out，然后一次推进 tail
+func (rb *RingBuffer[T]) popBatch(out []T) int {
+	head := atomic.LoadUint64(&rb.head)
+	tail := atomic.LoadUint64(&rb.tail)
+	n := min(distance(head, tail), uint64(len(out)))
+	if n == 0 {
+		return 0
+	}
+	for i := uint64(0); i < n; i++ {
+		out[i] = rb.buffer[(tail+i)&rb.mask]
+	}
+	atomic.AddUint64(&rb.tail, n)
+	if rb.metrics != nil {
+		rb.metrics.pops.Add(n)
+	}
+	if rb.wm != nil {
+		rb.checkLow()
+	}
+	if rb.spaceWaiters.Load() != 0 {
+		rb.wakeSpace()
+	}
+	return int(n)
+}
+
+// SetOnDrop 设置丢弃回调：元素未被消费就被 Reset 清空时恰好调用一次，
+// 调用方可以借此把元素持有的资源 (如池化的 buffer) 归还。必须在队列投入使用前设置
+// 未设置时 (nil) 热路径上没有任何额外开销；队列满时覆盖最旧元素的语义见 OverwriteRing
+func (rb *RingBuffer[T]) SetOnDrop(fn func(T)) {
+	rb.onDrop = fn
+}
+
+// Reset 清空队列中所有未消费的元素 (消费者调用)，对每个被丢弃的元素调用 onDrop
+func (rb *RingBuffer[T]) Reset() {
+	for {
+		item, ok := rb.Pop()
+		if !ok {
+			return
+		}
+		if rb.onDrop != nil {
+			rb.onDrop(item)
+		}
+	}
+}
+
+// Len 返回队列中的元素个数 (严格版本)
+// 使用原子读取 head/tail，结果在读取瞬间是一致的，但会与生产者/消费者争抢 Cache Line
+func (rb *RingBuffer[T]) Len() uint64 {
+	tail := atomic.LoadUint64(&rb.tail)
+	head := atomic.LoadUint64(&rb.head)
+	return distance(head, tail)
+}
+
+// distance 返回 head 领先 tail 的元素个数，跨越 2^64 溢出点依然正确
+// 差值按有符号解释为负数 (读到的 tail 比 head 新) 时返回 0
+func distance(head, tail uint64) uint64 {
+	if d := head - tail; int64(d) > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cap 返回队列容量
+func (rb *RingBuffer[T]) Cap() uint64 {
+	return rb.size
+}
+
+// LenApprox 返回队列长度的近似值 (仅用于监控面板)
+// 与 Len 不同，这里使用普通读 (非原子) 读取 head/tail：
+//   - 不插入内存屏障，对生产者/消费者的热路径影响最小
+//   - 结果是"有竞态的"，可能读到旧值，race detector 也会报告它
+//   - 在 32 位平台上 uint64 的普通读可能被撕裂 (读到高低 32 位不一致的值)
+//
+// 因此返回值被钳制在 [0, Cap] 之间，绝不能用于任何正确性判断
+func (rb *RingBuffer[T]) LenApprox() uint64 {
+	head := rb.head
+	tail := rb.tail
+	n := distance(head, tail)
+	if n > rb.size {
+		return rb.size
+	}
+	return n
+}
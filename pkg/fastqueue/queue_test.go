@@ -0,0 +1,316 @@
+package fastqueue
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// 没有并发访问时 LenApprox 与 Len 一致，并且钳制在 [0, Cap] 之间
+func TestLenApprox(t *testing.T) {
+	rb := New[int](8)
+	for i := range 8 {
+		if rb.LenApprox() != rb.Len() || rb.Len() != uint64(i) {
+			t.Fatalf("after %d pushes: LenApprox = %d, Len = %d", i, rb.LenApprox(), rb.Len())
+		}
+		rb.Push(i)
+	}
+	if rb.LenApprox() != 8 {
+		t.Fatalf("full queue LenApprox = %d, want 8", rb.LenApprox())
+	}
+	// 模拟读到撕裂或过期的 tail：结果仍在 [0, Cap] 之内
+	rb.tail = rb.head + 3
+	if n := rb.LenApprox(); n != 0 {
+		t.Fatalf("LenApprox with tail ahead of head = %d, want 0", n)
+	}
+}
+
+func BenchmarkLen(b *testing.B) {
+	rb := New[int](1024)
+	rb.Push(1)
+	var n uint64
+	for b.Loop() {
+		n += rb.Len()
+	}
+	_ = n
+}
+
+func BenchmarkLenApprox(b *testing.B) {
+	rb := New[int](1024)
+	rb.Push(1)
+	var n uint64
+	for b.Loop() {
+		n += rb.LenApprox()
+	}
+	_ = n
+}
+
+func TestNewSizeEdges(t *testing.T) {
+	for _, size := range []uint64{0, 3, 6, 1<<63 + 1} {
+		if _, err := TryNew[int](size); err != ErrInvalidSize {
+			t.Errorf("TryNew(%d) err = %v, want ErrInvalidSize", size, err)
+		}
+	}
+	func() {
+		defer func() {
+			if recover() != ErrInvalidSize {
+				t.Error("New(0) did not panic with ErrInvalidSize")
+			}
+		}()
+		New[int](0)
+	}()
+}
+
+// New(1) 是合法的单槽信箱：mask 为 0，Push/Pop 交替进行，满时拒绝
+func TestNewSizeOne(t *testing.T) {
+	rb := New[int](1)
+	if rb.Cap() != 1 {
+		t.Fatalf("Cap = %d, want 1", rb.Cap())
+	}
+	for i := range 5 {
+		if !rb.Push(i) {
+			t.Fatalf("Push(%d) on empty mailbox failed", i)
+		}
+		if rb.Push(100) {
+			t.Fatal("Push on full mailbox succeeded")
+		}
+		if v, ok := rb.Pop(); !ok || v != i {
+			t.Fatalf("Pop = %d, %v; want %d", v, ok, i)
+		}
+		if _, ok := rb.Pop(); ok {
+			t.Fatal("Pop on empty mailbox succeeded")
+		}
+	}
+}
+
+func TestPopTimeout(t *testing.T) {
+	rb := New[int](8)
+
+	// 空队列：等满 d 后返回 false
+	start := time.Now()
+	if _, ok := rb.PopTimeout(5 * time.Millisecond); ok {
+		t.Fatal("PopTimeout on an empty queue returned an item")
+	}
+	if d := time.Since(start); d < 5*time.Millisecond {
+		t.Fatalf("PopTimeout returned after %v, before the timeout", d)
+	}
+
+	// 消费者已经在休眠时到达的元素立即唤醒它，而不是等到超时
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		rb.Push(42)
+	}()
+	start = time.Now()
+	if v, ok := rb.PopTimeout(10 * time.Second); !ok || v != 42 {
+		t.Fatalf("PopTimeout = %d, %v; want 42", v, ok)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("woke up after %v", d)
+	}
+
+	// 反复的 Push/PopTimeout 不丢唤醒
+	const items = 2000
+	go func() {
+		for i := range items {
+			for !rb.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+	for want := range items {
+		if v, ok := rb.PopTimeout(5 * time.Second); !ok || v != want {
+			t.Fatalf("item %d: PopTimeout = %d, %v", want, v, ok)
+		}
+	}
+}
+
+func TestPopBatchWait(t *testing.T) {
+	rb := New[int](8)
+	out := make([]int, 4)
+
+	// 已经够 minItems：立即返回，一次最多取 len(out) 个
+	for i := range 6 {
+		rb.Push(i)
+	}
+	if n := rb.PopBatchWait(out, 2, time.Hour); n != 4 || out[0] != 0 || out[3] != 3 {
+		t.Fatalf("PopBatchWait = %d, %v; want 4 items from 0", n, out)
+	}
+
+	// 不够 minItems：等满 maxWait 后有多少取多少
+	start := time.Now()
+	if n := rb.PopBatchWait(out, 3, 5*time.Millisecond); n != 2 || out[0] != 4 || out[1] != 5 {
+		t.Fatalf("PopBatchWait after timeout = %d, %v; want [4 5]", n, out[:n])
+	}
+	if d := time.Since(start); d < 5*time.Millisecond {
+		t.Fatalf("returned after %v, before the timeout", d)
+	}
+	if n := rb.PopBatchWait(out, 1, time.Millisecond); n != 0 {
+		t.Fatalf("PopBatchWait on an empty queue = %d", n)
+	}
+
+	// 凑批期间逐个到达的元素：第 minItems 个到达时醒来，而不是等到超时
+	go func() {
+		for i := range 3 {
+			time.Sleep(time.Millisecond)
+			rb.Push(10 + i)
+		}
+	}()
+	start = time.Now()
+	if n := rb.PopBatchWait(out, 3, 10*time.Second); n != 3 || out[0] != 10 || out[2] != 12 {
+		t.Fatalf("PopBatchWait = %d, %v; want [10 11 12]", n, out[:n])
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("woke up after %v", d)
+	}
+
+	// minItems 超过 len(out) 时按 len(out) 处理，不会永远等下去
+	for i := range 4 {
+		rb.Push(i)
+	}
+	if n := rb.PopBatchWait(out, 100, 10*time.Second); n != 4 {
+		t.Fatalf("PopBatchWait with minItems > len(out) = %d, want 4", n)
+	}
+}
+
+func TestPushTimeout(t *testing.T) {
+	rb := New[int](4)
+	for i := range 4 {
+		if !rb.PushTimeout(i, time.Millisecond) {
+			t.Fatalf("PushTimeout %d on a queue with room failed", i)
+		}
+	}
+
+	// 一直满：等满 d 后返回 false，元素没有写入
+	start := time.Now()
+	if rb.PushTimeout(99, 5*time.Millisecond) {
+		t.Fatal("PushTimeout on a full queue succeeded")
+	}
+	if d := time.Since(start); d < 5*time.Millisecond {
+		t.Fatalf("PushTimeout returned after %v, before the timeout", d)
+	}
+	if rb.Len() != 4 {
+		t.Fatalf("Len = %d after a timed-out push", rb.Len())
+	}
+
+	// 生产者已经在等待时腾出的空位立即唤醒它，而不是等到超时
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		rb.Pop()
+	}()
+	start = time.Now()
+	if !rb.PushTimeout(4, 10*time.Second) {
+		t.Fatal("PushTimeout did not get the freed slot")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("woke up after %v", d)
+	}
+
+	// 反复的 PushTimeout/Pop 不丢唤醒，顺序不变
+	const items = 2000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 5; i < items; i++ {
+			if !rb.PushTimeout(i, 5*time.Second) {
+				t.Errorf("PushTimeout %d timed out", i)
+				return
+			}
+		}
+	}()
+	for want := 1; want < items; want++ {
+		v, ok := rb.Pop()
+		for !ok {
+			runtime.Gosched()
+			v, ok = rb.Pop()
+		}
+		if v != want {
+			t.Fatalf("popped %d, want %d", v, want)
+		}
+	}
+	<-done
+}
+
+// 已经空时立即返回；消费者取走最后一个元素后及时唤醒所有等待者；ctx 取消时提前返回
+func TestWaitEmpty(t *testing.T) {
+	rb := New[int](8)
+	if err := rb.WaitEmpty(context.Background()); err != nil {
+		t.Fatalf("WaitEmpty on an empty queue: %v", err)
+	}
+
+	for i := range 5 {
+		rb.Push(i)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := rb.WaitEmpty(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("WaitEmpty with items left: err = %v, want DeadlineExceeded", err)
+	}
+
+	const waiters = 3
+	woke := make(chan time.Time, waiters)
+	for range waiters {
+		go func() {
+			if err := rb.WaitEmpty(context.Background()); err != nil {
+				t.Errorf("WaitEmpty: %v", err)
+			}
+			woke <- time.Now()
+		}()
+	}
+	time.Sleep(2 * time.Millisecond)
+	for range 4 {
+		rb.Pop()
+	}
+	select {
+	case <-woke:
+		t.Fatal("WaitEmpty returned with an item still queued")
+	case <-time.After(2 * time.Millisecond):
+	}
+	rb.Pop()
+	drained := time.Now()
+	for range waiters {
+		select {
+		case at := <-woke:
+			if d := at.Sub(drained); d > time.Second {
+				t.Fatalf("waiter woke %v after the last item was popped", d)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("a waiter was not woken after the queue drained")
+		}
+	}
+}
+
+// head/tail 从 2^64 附近开始：跨越溢出点时满、空判断与 FIFO 顺序都不受影响
+func TestWraparound(t *testing.T) {
+	for _, start := range []uint64{math.MaxUint64 - 2, math.MaxUint64, 0} {
+		rb := New[int](4)
+		rb.head, rb.tail = start, start
+
+		for round := range 3 {
+			for i := range 4 {
+				if !rb.Push(round*10 + i) {
+					t.Fatalf("start %d round %d: Push %d failed on a non-full queue", start, round, i)
+				}
+			}
+			if rb.Push(-1) || !rb.full() || rb.Len() != 4 || rb.LenApprox() != 4 {
+				t.Fatalf("start %d round %d: full queue: Len = %d, full = %v", start, round, rb.Len(), rb.full())
+			}
+			if p, ok := rb.Peek(); !ok || *p != round*10 {
+				t.Fatalf("start %d round %d: Peek = %v, %v", start, round, p, ok)
+			}
+			out := make([]int, 3)
+			if n := rb.popBatch(out[:1]); n != 1 || out[0] != round*10 {
+				t.Fatalf("start %d round %d: popBatch = %d %v", start, round, n, out[:1])
+			}
+			for i := 1; i < 4; i++ {
+				if v, ok := rb.Pop(); !ok || v != round*10+i {
+					t.Fatalf("start %d round %d: Pop = %d, %v, want %d", start, round, v, ok, round*10+i)
+				}
+			}
+			if _, ok := rb.Pop(); ok || !rb.empty() || rb.Len() != 0 {
+				t.Fatalf("start %d round %d: Pop on an empty queue succeeded (Len = %d)", start, round, rb.Len())
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package fastqueue
+
+import "sync/atomic"
+
+// 原地写入 (Reserve / Commit)
+//
+// Push(item) 先在调用方的栈上构造 item，再整体拷贝进槽位；T 很大时 (如几百字节的 Task) 这次拷贝不可忽略。
+// Reserve 直接返回下一个可写槽位的指针，生产者在槽位里原地填写，填完后 Commit 发布：
+//
+//	if p, ok := rb.Reserve(); ok {
+//		p.Type = TaskTypeOrder // 逐个字段写入
+//		p.Value = uid
+//		...
+//		rb.Commit()
+//	}
+//
+// 约定 (都属于生产者一侧，与 Push 一样只能由唯一的生产者调用)：
+//   - Commit 之前 head 没有推进，消费者看不到这个槽位，也不会读到填了一半的内容；
+//     Commit 的原子加法同时是发布屏障，之前对槽位的所有写入对之后 Pop 到它的消费者可见
+//   - 槽位里是上一轮留下的旧值 (已被消费的元素)，没有清零：要么写全所有字段，要么先 *p = T{}
+//   - Reserve 之后必须恰好 Commit 或 Abort 一次 (重复 Reserve、没有 Reserve 就 Commit 会 panic)，期间不能调用 Push / PushTimeout 等其它生产者方法，
+//     也不能再次 Reserve；Commit 之后 p 不再属于生产者，不能继续读写
+//   - 中途不想写了就调用 Abort：槽位不发布，下一次 Reserve 得到的还是它
+//   - 队列满时返回 nil, false，计入 Metrics 的 PushFull
+//
+// 只有 SPSC 的 RingBuffer 提供这组方法：MPSC 的多个生产者各自占用不同的槽位，无参数的 Commit 无法知道发布哪一个
+
+// Reserve 返回下一个可写槽位的指针，队列满时返回 nil, false (生产者调用)
+func (rb *RingBuffer[T]) Reserve() (*T, bool) {
+	if rb.reserved {
+		panic("fastqueue: Reserve while a slot is already reserved")
+	}
+	head := atomic.LoadUint64(&rb.head)
+	tail := atomic.LoadUint64(&rb.tail)
+	if head-tail >= rb.size {
+		if rb.metrics != nil {
+			rb.metrics.pushFull.Add(1)
+		}
+		return nil, false
+	}
+	rb.reserved = true
+	return &rb.buffer[head&rb.mask], true
+}
+
+// Commit 发布 Reserve 得到的槽位，之后消费者可以取出它 (生产者调用)
+func (rb *RingBuffer[T]) Commit() {
+	if !rb.reserved {
+		panic("fastqueue: Commit without Reserve")
+	}
+	rb.reserved = false
+	rb.publish()
+}
+
+// Abort 放弃 Reserve 得到的槽位，不发布 (生产者调用)
+func (rb *RingBuffer[T]) Abort() {
+	rb.reserved = false
+}
@@ -0,0 +1,74 @@
+package fastqueue
+
+import (
+	"runtime"
+	"testing"
+)
+
+// 原地填写的槽位在 Commit 之前对消费者不可见，之后被完整取出；Abort 的槽位留给下一次 Reserve
+func TestReserveCommit(t *testing.T) {
+	q := New[largeItem](2)
+
+	p, ok := q.Reserve()
+	if !ok {
+		t.Fatal("Reserve on an empty ring failed")
+	}
+	p.Seq = 7
+	p.Payload[0], p.Payload[247] = 1, 2
+	if _, ok := q.Pop(); ok || q.Len() != 0 {
+		t.Fatal("reserved slot visible before Commit")
+	}
+	q.Commit()
+	if it, ok := q.Pop(); !ok || it.Seq != 7 || it.Payload[0] != 1 || it.Payload[247] != 2 {
+		t.Fatalf("Pop after Commit = %+v, %v", it.Seq, ok)
+	}
+
+	p, _ = q.Reserve()
+	p.Seq = 8
+	q.Abort()
+	if q.Len() != 0 {
+		t.Fatal("aborted slot published")
+	}
+	if again, _ := q.Reserve(); again != p {
+		t.Fatal("Reserve after Abort returned a different slot")
+	}
+	p.Seq = 9
+	q.Commit()
+	q.Push(largeItem{Seq: 10})
+	if _, ok := q.Reserve(); ok {
+		t.Fatal("Reserve on a full ring succeeded")
+	}
+	for _, want := range []uint64{9, 10} {
+		if it, _ := q.Pop(); it.Seq != want {
+			t.Fatalf("Pop = %d, want %d", it.Seq, want)
+		}
+	}
+}
+
+// 并发的 SPSC：消费者按顺序看到生产者原地写入的全部内容
+func TestReserveCommitSPSC(t *testing.T) {
+	const n = 20000
+	q := New[largeItem](16)
+	go func() {
+		for i := range uint64(n) {
+			p, ok := q.Reserve()
+			for !ok {
+				runtime.Gosched()
+				p, ok = q.Reserve()
+			}
+			p.Seq = i
+			p.Payload[i%248] = byte(i)
+			q.Commit()
+		}
+	}()
+	for i := range uint64(n) {
+		it, ok := q.Pop()
+		for !ok {
+			runtime.Gosched()
+			it, ok = q.Pop()
+		}
+		if it.Seq != i || it.Payload[i%248] != byte(i) {
+			t.Fatalf("item %d: Seq %d, payload %d", i, it.Seq, it.Payload[i%248])
+		}
+	}
+}
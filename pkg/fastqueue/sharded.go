@@ -0,0 +1,100 @@
+package fastqueue
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ShardedRing 是 "环的环"：每个生产者一条独占的 SPSC RingBuffer (Lane)，单个消费者在各 Lane 之间轮转
+//
+// MPSC 的所有生产者在同一个 head 上 CAS，生产者一多这个 Cache Line 就成了热点。
+// ShardedRing 的每条 Lane 只有一个生产者，Push 就是 RingBuffer.Push，生产者之间不共享任何可写的内存：
+// 代价是消费者每次 Pop 可能要扫过若干条空的 Lane，而且只有同一条 Lane 内部保持 FIFO
+//
+// Lane 分配：
+//   - 生产者启动时调用一次 Register 领取自己的 Lane 编号，之后只用这个编号 Push
+//   - 编号按领取顺序从 0 开始，Lane 用完后 Register 返回 ErrNoLane；Lane 不回收
+//   - 同一条 Lane 同时只能有一个生产者 (SPSC 约束)，两个 goroutine 共用一个编号属于误用
+//
+// 公平性：Pop 从上一次取到元素的 Lane 的下一条开始找，每次只取一个元素，
+// 所以所有 Lane 都非空时严格轮转，一个很忙的生产者不会饿死其它生产者
+type ShardedRing[T any] struct {
+	lanes []*RingBuffer[T]
+
+	_ CacheLinePad
+
+	registered atomic.Int32 // 已领取的 Lane 数 (Register)
+
+	_ CacheLinePad
+
+	cursor int // 下一次 Pop 开始扫描的 Lane (Consumer Only)
+}
+
+// ErrNoLane 所有 Lane 都已分配给了生产者
+var ErrNoLane = errors.New("fastqueue: no free lane")
+
+// NewSharded 创建 lanes 条 Lane、每条容量为 laneSize (2 的幂) 的队列，参数非法时 panic
+func NewSharded[T any](lanes int, laneSize uint64) *ShardedRing[T] {
+	if lanes < 1 {
+		panic("fastqueue: sharded ring needs at least one lane")
+	}
+	s := &ShardedRing[T]{lanes: make([]*RingBuffer[T], lanes)}
+	for i := range s.lanes {
+		s.lanes[i] = New[T](laneSize)
+	}
+	return s
+}
+
+// Register 为调用方 (一个生产者) 分配一条独占的 Lane，返回其编号
+func (s *ShardedRing[T]) Register() (int, error) {
+	n := int(s.registered.Add(1)) - 1
+	if n >= len(s.lanes) {
+		s.registered.Add(-1)
+		return 0, ErrNoLane
+	}
+	return n, nil
+}
+
+// Push 写入编号为 lane 的 Lane (只能由持有该 Lane 的生产者调用)，Lane 满时返回 false
+func (s *ShardedRing[T]) Push(lane int, item T) bool {
+	return s.lanes[lane].Push(item)
+}
+
+// Pop 按轮转顺序从下一条非空的 Lane 取一个元素 (单消费者)，所有 Lane 都为空时返回 false
+func (s *ShardedRing[T]) Pop() (T, bool) {
+	n := len(s.lanes)
+	for k := 0; k < n; k++ {
+		i := s.cursor + k
+		if i >= n {
+			i -= n
+		}
+		if item, ok := s.lanes[i].Pop(); ok {
+			s.cursor = i + 1
+			if s.cursor == n {
+				s.cursor = 0
+			}
+			return item, true
+		}
+	}
+	var empty T
+	return empty, false
+}
+
+// Len 返回所有 Lane 的近似总长度
+func (s *ShardedRing[T]) Len() uint64 {
+	var n uint64
+	for _, l := range s.lanes {
+		n += l.Len()
+	}
+	return n
+}
+
+// Cap 返回所有 Lane 的总容量
+func (s *ShardedRing[T]) Cap() uint64 {
+	return uint64(len(s.lanes)) * s.lanes[0].Cap()
+}
+
+// Lanes 返回 Lane 的条数
+func (s *ShardedRing[T]) Lanes() int {
+	return len(s.lanes)
+}
@@ -0,0 +1,95 @@
+package fastqueue
+
+import "sync/atomic"
+
+// SlabRing 是为大元素设计的 SPSC 队列：元素原地读写，不做按值拷贝
+//
+// RingBuffer 的 Push(item) / Pop() 各按值拷贝一次 T，对 Task 这种上百字节的结构体，
+// 两次拷贝就是吞吐的大头。SlabRing 把槽位 (slab) 直接借给调用方：
+//
+//	// 生产者
+//	if t, ok := q.Reserve(); ok {
+//		t.Type, t.Value = ..., ...  // 直接填写槽位
+//		q.Commit()                  // 发布，消费者此后可见
+//	}
+//
+//	// 消费者
+//	if t, ok := q.Peek(); ok {
+//		process(t)                  // 直接读取槽位
+//		q.Release()                 // 归还槽位，生产者此后才能复用
+//	}
+//
+// 索引的推进只是一次原子加，Push/Pop 搬运的只有一个字 (槽位下标)
+//
+// 生命周期约定：
+//   - Reserve 返回的指针只在 Commit 之前归生产者所有，Commit 之后不得再写
+//   - Peek 返回的指针只在 Release 之前有效，Release 之后槽位随时会被生产者覆盖，
+//     需要保留的数据必须在 Release 之前拷走
+//   - Reserve/Commit、Peek/Release 必须严格成对，同一时刻每一方最多借出一个槽位
+//   - 槽位在 Release 时不会清零，T 中的指针会一直被引用到该槽位下一次被覆盖为止
+//   - 不支持 Reset 等在槽位之外回收元素的操作
+type SlabRing[T any] struct {
+	slab []T
+	mask uint64
+
+	_ CacheLinePad
+
+	head uint64 // 已发布的槽位数 (Producer Only)
+
+	_ CacheLinePad
+
+	tail uint64 // 已归还的槽位数 (Consumer Only)
+
+	_ CacheLinePad
+}
+
+// NewSlab 创建一个容量为 size 的队列，size 非法时 panic
+func NewSlab[T any](size uint64) *SlabRing[T] {
+	if size == 0 || size&(size-1) != 0 {
+		panic(ErrInvalidSize)
+	}
+	return &SlabRing[T]{slab: make([]T, size), mask: size - 1}
+}
+
+// Reserve 借出下一个空闲槽位供生产者填写，队列满时返回 false
+// 槽位中是上一次使用留下的旧内容，生产者需要自己覆盖全部字段
+func (q *SlabRing[T]) Reserve() (*T, bool) {
+	head := atomic.LoadUint64(&q.head)
+	tail := atomic.LoadUint64(&q.tail)
+	if head-tail >= uint64(len(q.slab)) {
+		return nil, false // Full
+	}
+	return &q.slab[head&q.mask], true
+}
+
+// Commit 发布 Reserve 借出的槽位
+func (q *SlabRing[T]) Commit() {
+	atomic.AddUint64(&q.head, 1)
+}
+
+// Peek 返回最早发布的槽位供消费者读取，队列为空时返回 false
+func (q *SlabRing[T]) Peek() (*T, bool) {
+	head := atomic.LoadUint64(&q.head)
+	tail := atomic.LoadUint64(&q.tail)
+	if head == tail {
+		return nil, false // Empty
+	}
+	return &q.slab[tail&q.mask], true
+}
+
+// Release 归还 Peek 借出的槽位
+func (q *SlabRing[T]) Release() {
+	atomic.AddUint64(&q.tail, 1)
+}
+
+// Len 返回已发布未归还的槽位数
+func (q *SlabRing[T]) Len() uint64 {
+	tail := atomic.LoadUint64(&q.tail)
+	head := atomic.LoadUint64(&q.head)
+	return distance(head, tail)
+}
+
+// Cap 返回队列容量
+func (q *SlabRing[T]) Cap() uint64 {
+	return uint64(len(q.slab))
+}
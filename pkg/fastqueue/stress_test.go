@@ -0,0 +1,180 @@
+package fastqueue
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// SPSC 不变量的压力测试
+//
+// stress 让一个生产者和一个消费者在同一个 RingBuffer 上传递 0, 1, 2, ... 的序号，
+// 两边都以随机的节奏运行 (随机长度的突发、随机插入 Gosched、Pop 与批量 popBatch 混用)，
+// 消费者逐个核对收到的序号，检查：
+//   - FIFO：收到的序号严格按 +1 递增
+//   - 不丢：收到比期望更大的序号说明中间的元素丢了
+//   - 不重：收到比期望更小的序号说明同一个槽位被读了两次 (或读到了尚未写完的旧值)
+//   - 结束时队列为空，Len 为 0
+//
+// head/tail 默认从 2^64 之前不远处开始，传输过程中跨越计数器溢出点，覆盖回绕相关的空/满判断。
+// 配合 -race 运行时 race detector 还会检查槽位读写与 head/tail 原子操作之间的 happens-before 关系
+// (少了发布/获取语义时，槽位的普通读写会被报告为数据竞争)：
+//
+//	go test -race -run Stress ./pkg/fastqueue
+//
+// -short 时跳过
+
+func TestStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test skipped in -short mode")
+	}
+	for _, cfg := range []stressConfig{
+		{},
+		{Size: 2, MaxBatch: 3, Items: 1 << 16, Seed: 2},
+		{Size: 1024, MaxBatch: 64, Seed: 3},
+	} {
+		if err := stress(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// stressConfig 是 stress 的参数，零值字段使用默认值
+type stressConfig struct {
+	Size     uint64 // 队列容量 (2 的幂)，默认 64：容量小才会频繁地满/空切换
+	Items    uint64 // 传递的元素个数，默认 1<<20
+	Start    uint64 // head/tail 的初始值，默认让计数器在传输到一半时溢出
+	MaxBatch int    // 生产者单次突发与消费者单次批量的上限，默认 16
+	Seed     uint64 // 随机种子，默认 1；失败时用同一个种子复现节奏 (调度本身仍不确定)
+}
+
+// stressError 描述第一次违反不变量的位置
+type stressError struct {
+	Index  uint64   // 消费者收到的第几个元素 (从 0 开始)
+	Want   uint64   // 期望的序号
+	Got    uint64   // 实际收到的序号
+	Tail   uint64   // 出错时的 tail 计数
+	Recent []uint64 // 出错前最近收到的序号 (从旧到新)，用于定位是丢失、重复还是乱序
+}
+
+func (e *stressError) Error() string {
+	kind := "out of order"
+	switch {
+	case e.Got > e.Want:
+		kind = fmt.Sprintf("lost %d item(s)", e.Got-e.Want)
+	case e.Got < e.Want:
+		kind = "duplicated or stale item"
+	}
+	return fmt.Sprintf("fastqueue: stress: %s at item %d (tail=%d): want %d, got %d, recent %v",
+		kind, e.Index, e.Tail, e.Want, e.Got, e.Recent)
+}
+
+// stressRecent 是 stressError.Recent 保留的条数
+const stressRecent = 16
+
+// stress 按 cfg 运行一次 SPSC 压力测试，所有不变量都成立时返回 nil，否则返回 *stressError 或描述结束状态的错误
+func stress(cfg stressConfig) error {
+	if cfg.Size == 0 {
+		cfg.Size = 64
+	}
+	if cfg.Items == 0 {
+		cfg.Items = 1 << 20
+	}
+	if cfg.Start == 0 {
+		cfg.Start = -(cfg.Items / 2)
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 16
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = 1
+	}
+	rb, err := TryNew[uint64](cfg.Size)
+	if err != nil {
+		return err
+	}
+	// 队列尚未被任何 goroutine 使用，直接把两个计数器拨到起点
+	rb.head, rb.tail = cfg.Start, cfg.Start
+
+	var stop atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rng := rand.New(rand.NewPCG(cfg.Seed, 1))
+		for next := uint64(0); next < cfg.Items; {
+			burst := min(uint64(1+rng.IntN(cfg.MaxBatch)), cfg.Items-next)
+			for range burst {
+				for !rb.Push(next) {
+					if stop.Load() {
+						return
+					}
+					runtime.Gosched()
+				}
+				next++
+			}
+			if rng.IntN(4) == 0 {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	rng := rand.New(rand.NewPCG(cfg.Seed, 2))
+	out := make([]uint64, cfg.MaxBatch)
+	var recent [stressRecent]uint64
+	var want uint64
+	check := func(got uint64) error {
+		if got != want {
+			stop.Store(true)
+			n := min(want, stressRecent)
+			r := make([]uint64, 0, n)
+			for i := want - n; i < want; i++ {
+				r = append(r, recent[i%stressRecent])
+			}
+			return &stressError{Index: want, Want: want, Got: got, Tail: atomic.LoadUint64(&rb.tail), Recent: r}
+		}
+		recent[want%stressRecent] = got
+		want++
+		return nil
+	}
+	for want < cfg.Items {
+		if rng.IntN(2) == 0 {
+			v, ok := rb.Pop()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if err := check(v); err != nil {
+				<-done
+				return err
+			}
+		} else {
+			n := rb.popBatch(out[:1+rng.IntN(cfg.MaxBatch)])
+			if n == 0 {
+				runtime.Gosched()
+				continue
+			}
+			for _, v := range out[:n] {
+				if err := check(v); err != nil {
+					<-done
+					return err
+				}
+			}
+		}
+		if rng.IntN(8) == 0 {
+			runtime.Gosched()
+		}
+	}
+	<-done
+	if v, ok := rb.Pop(); ok {
+		return fmt.Errorf("fastqueue: stress: extra item %d after all %d were consumed", v, cfg.Items)
+	}
+	if n := rb.Len(); n != 0 {
+		return fmt.Errorf("fastqueue: stress: Len = %d after draining", n)
+	}
+	if head, tail := atomic.LoadUint64(&rb.head), atomic.LoadUint64(&rb.tail); head != cfg.Start+cfg.Items || tail != head {
+		return fmt.Errorf("fastqueue: stress: head=%d tail=%d, want both %d", head, tail, cfg.Start+cfg.Items)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package fastqueue
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 高低水位回调：让上游按队列的积压自适应限流
+//
+//	rb.SetWatermarks(rb.Cap()*8/10, rb.Cap()*2/10, throttle, unthrottle)
+//
+// 队列长度从 high 以下涨到 >= high 时调用一次 onHigh，之后从 low 以上降到 <= low 时调用一次 onLow，
+// 两者严格交替 (迟滞)：在 high 附近来回抖动不会反复触发，每次越过水位只触发一次。
+//
+// 开销：未设置时 Push/Pop 只多一次 nil 判断；设置后每次操作多一次原子读水位状态，
+// 只有可能越过水位 (状态为低且 Push 后长度 >= high，或状态为高且 Pop 后长度 <= low) 时才读长度、抢锁。
+//
+// 回调在越过水位的那次 Push (生产者) 或 Pop (消费者) 中同步执行，并且持有一把锁：
+// 两边几乎同时越过水位时也不会乱序或重复，锁内每次回调后重读长度，在回调期间又越过了另一条水位时补上对应的回调。
+// 因此回调必须很快 (置一个原子标志、非阻塞地发一个信号)，并且不能在回调中 Push/Pop 这个队列 (会死锁)
+
+// watermarks 是 SetWatermarks 的配置与当前状态
+type watermarks struct {
+	high, low     uint64
+	onHigh, onLow func()
+
+	mu    sync.Mutex
+	above atomic.Bool // 最近一次触发的是 onHigh；只在 mu 内修改
+}
+
+// SetWatermarks 设置高低水位及其回调，必须在队列投入使用前调用
+// 要求 0 < high <= Cap() 且 low < high，否则 panic；onHigh/onLow 可以为 nil (只跟踪状态，不回调)
+func (rb *RingBuffer[T]) SetWatermarks(high, low uint64, onHigh, onLow func()) {
+	if high == 0 || high > rb.size || low >= high {
+		panic("fastqueue: watermarks require 0 < high <= Cap and low < high")
+	}
+	rb.wm = &watermarks{high: high, low: low, onHigh: onHigh, onLow: onLow}
+}
+
+// AboveHighWatermark 报告队列是否处于高水位状态 (最近一次触发的是 onHigh)，未设置水位时为 false
+func (rb *RingBuffer[T]) AboveHighWatermark() bool {
+	return rb.wm != nil && rb.wm.above.Load()
+}
+
+// checkHigh 在写入后调用 (生产者)
+func (rb *RingBuffer[T]) checkHigh() {
+	if !rb.wm.above.Load() && rb.Len() >= rb.wm.high {
+		rb.settleWatermarks()
+	}
+}
+
+// checkLow 在读取后调用 (消费者)
+func (rb *RingBuffer[T]) checkLow() {
+	if rb.wm.above.Load() && rb.Len() <= rb.wm.low {
+		rb.settleWatermarks()
+	}
+}
+
+// settleWatermarks 在锁内按当前长度推进水位状态，直到状态与长度一致
+//
+// 不会漏掉回调：生产者先推进 head 再读状态，消费者先推进 tail 再读状态，翻转状态的一方在锁内翻转后重读长度。
+// 一方看到的状态已过期时，另一方必然在翻转后的重读中看到它造成的长度变化，两边不可能同时错过
+func (rb *RingBuffer[T]) settleWatermarks() {
+	wm := rb.wm
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	for {
+		n := rb.Len()
+		switch {
+		case !wm.above.Load() && n >= wm.high:
+			wm.above.Store(true)
+			if wm.onHigh != nil {
+				wm.onHigh()
+			}
+		case wm.above.Load() && n <= wm.low:
+			wm.above.Store(false)
+			if wm.onLow != nil {
+				wm.onLow()
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package fastqueue
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// onHigh 在长度涨到 high 时触发一次，onLow 在降到 low 时触发一次；两条水位之间来回抖动不再触发
+func TestWatermarkTransitions(t *testing.T) {
+	q := New[int](16)
+	var highs, lows int
+	q.SetWatermarks(12, 4, func() { highs++ }, func() { lows++ })
+
+	check := func(step string, h, l int, above bool) {
+		t.Helper()
+		if highs != h || lows != l || q.AboveHighWatermark() != above {
+			t.Fatalf("%s (Len %d): onHigh %d, onLow %d, above %v; want %d, %d, %v",
+				step, q.Len(), highs, lows, q.AboveHighWatermark(), h, l, above)
+		}
+	}
+	push := func(n int) {
+		for range n {
+			if !q.Push(0) {
+				t.Fatal("Push failed")
+			}
+		}
+	}
+	pop := func(n int) {
+		for range n {
+			if _, ok := q.Pop(); !ok {
+				t.Fatal("Pop failed")
+			}
+		}
+	}
+
+	push(11)
+	check("below high", 0, 0, false)
+	push(1)
+	check("reached high", 1, 0, true)
+	pop(1)
+	push(1)
+	push(4)
+	check("jitter around high and fill up", 1, 0, true)
+	pop(11)
+	check("between the watermarks", 1, 0, true)
+	push(7)
+	check("back above high without dropping to low", 1, 0, true)
+	pop(7)
+	check("just above low", 1, 0, true)
+	pop(1)
+	check("reached low", 1, 1, false)
+	pop(4)
+	push(11)
+	check("drained and refilled below high", 1, 1, false)
+	push(1)
+	check("second crossing up", 2, 1, true)
+}
+
+// 生产者与消费者并发越过水位：回调严格交替，最终状态与长度一致
+func TestWatermarksConcurrent(t *testing.T) {
+	q := New[int](64)
+	var (
+		mu     sync.Mutex
+		events []bool // true 为 onHigh
+	)
+	record := func(high bool) func() {
+		return func() {
+			mu.Lock()
+			events = append(events, high)
+			mu.Unlock()
+		}
+	}
+	q.SetWatermarks(48, 16, record(true), record(false))
+
+	const n = 50000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range n {
+			for !q.Push(i) {
+				runtime.Gosched()
+			}
+		}
+	}()
+	for range n {
+		for {
+			if _, ok := q.Pop(); ok {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+	<-done
+
+	for i, high := range events {
+		if high != (i%2 == 0) {
+			t.Fatalf("event %d is onHigh=%v: callbacks did not alternate (%d events)", i, high, len(events))
+		}
+	}
+	if q.AboveHighWatermark() != (len(events)%2 == 1) || q.AboveHighWatermark() {
+		t.Fatalf("drained queue: above = %v after %d events", q.AboveHighWatermark(), len(events))
+	}
+}
@@ -0,0 +1,198 @@
+// Package typed 是 core 引擎的泛型骨架：请求与结果的类型由使用方决定
+//
+// core.Engine 的 Task/OrderResult 是写死的，Worker 里的撮合、WAL、分片、热备等都围绕它们展开。
+// 这里只保留与业务无关的那部分机制，参数化为 Engine[Req, Resp]：
+//   - 一个锁死 OS 线程的 Worker，自旋轮询 RingBuffer[request[Req, Resp]]
+//   - 每个请求独占 Worker 的 Arena，Process 返回之后 Arena 立即 Reset (与 core 的 runTask 相同)
+//   - 结果通过类型化的 chan Resp 非阻塞投递 (与 core/reply.go 的策略相同：Resp 必须有缓冲，满了丢弃并计数)
+//   - 入队时间取自 sysclock，Stats 中给出排队延迟
+//
+// 用法：
+//
+//	e := typed.New(1024, func(req Quote, mem *arena.Arena) Price {
+//		buf := arena.MakeSlice[float64](mem, 0, len(req.Legs))
+//		...
+//		return Price{...}
+//	})
+//	e.Start()
+//	defer e.Stop()
+//	p, err := e.Call(ctx, Quote{...})
+//
+// Process 拿到的 Arena 只在本次调用期间有效：返回值 Resp 不能引用 Arena 上的内存 (与 core 中 Task.Result 的约束相同)
+package typed
+
+import (
+	"arena_demo/pkg/arena"
+	"arena_demo/pkg/fastqueue"
+	"arena_demo/pkg/sysclock"
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrFull 队列已满，请求未入队
+	ErrFull = errors.New("typed: queue full")
+	// ErrStopped 引擎已停止 (或尚未启动)，请求未入队
+	ErrStopped = errors.New("typed: engine stopped")
+)
+
+// Process 在 Worker 线程上处理一个请求，mem 在返回之后被 Reset
+type Process[Req, Resp any] func(req Req, mem *arena.Arena) Resp
+
+// request 是队列中的一项：请求本身、回复 channel、入队时间
+type request[Req, Resp any] struct {
+	req      Req
+	resp     chan Resp
+	enqueued int64
+}
+
+// Stats 是 Engine 的运行统计
+type Stats struct {
+	Processed      uint64 // 已处理的请求数
+	Rejected       uint64 // 队列已满或引擎已停止而被拒绝的请求数
+	RepliesDropped uint64 // Resp 缓冲已满而丢弃的结果数
+	QueueWaitNanos uint64 // 所有已处理请求的排队时间之和 (入队到开始处理)
+}
+
+// Engine 是一个单 Worker 的泛型任务引擎
+//
+// RingBuffer 是单生产者的，多个 goroutine 并发提交时由 pushMu 串行化入队 (Worker 一侧不加锁)
+type Engine[Req, Resp any] struct {
+	queue   *fastqueue.RingBuffer[request[Req, Resp]]
+	pushMu  sync.Mutex
+	mem     *arena.Arena
+	process Process[Req, Resp]
+
+	running atomic.Bool
+	stop    atomic.Bool
+	done    chan struct{}
+
+	// replies 复用 Call 的回复 channel (容量 1)
+	replies sync.Pool
+
+	processed      atomic.Uint64
+	rejected       atomic.Uint64
+	repliesDropped atomic.Uint64
+	queueWait      atomic.Uint64
+}
+
+// New 创建一个队列容量为 queueSize (2 的幂) 的引擎，queueSize 非法或 process 为 nil 时 panic
+func New[Req, Resp any](queueSize uint64, process Process[Req, Resp]) *Engine[Req, Resp] {
+	if process == nil {
+		panic("typed: nil Process")
+	}
+	e := &Engine[Req, Resp]{
+		queue:   fastqueue.New[request[Req, Resp]](queueSize),
+		process: process,
+		done:    make(chan struct{}),
+	}
+	e.replies.New = func() any { return make(chan Resp, 1) }
+	return e
+}
+
+// Start 启动 Worker，只能调用一次
+func (e *Engine[Req, Resp]) Start() {
+	if !e.running.CompareAndSwap(false, true) {
+		panic("typed: engine already started")
+	}
+	e.mem = arena.Acquire()
+	go e.run()
+}
+
+// Stop 停止接收新请求，等待 Worker 处理完已入队的请求后退出并归还 Arena
+func (e *Engine[Req, Resp]) Stop() {
+	if !e.running.Load() || !e.stop.CompareAndSwap(false, true) {
+		return
+	}
+	// 持有 pushMu 再返回：此后不会再有正在进行中的入队
+	e.pushMu.Lock()
+	e.pushMu.Unlock()
+	<-e.done
+}
+
+// run 是 Worker 循环：自旋轮询队列，空闲时 Gosched，停止且队列已空时退出
+func (e *Engine[Req, Resp]) run() {
+	runtime.LockOSThread()
+	defer func() {
+		e.mem.Release()
+		runtime.UnlockOSThread()
+		close(e.done)
+	}()
+	for {
+		r, ok := e.queue.Pop()
+		if !ok {
+			if e.stop.Load() && e.queue.Len() == 0 {
+				return
+			}
+			runtime.Gosched()
+			continue
+		}
+		e.queueWait.Add(uint64(max(sysclock.Now()-r.enqueued, 0)))
+		v := e.process(r.req, e.mem)
+		e.mem.Reset()
+		e.processed.Add(1)
+		if r.resp != nil {
+			select {
+			case r.resp <- v:
+			default:
+				e.repliesDropped.Add(1)
+			}
+		}
+	}
+}
+
+// Submit 提交一个请求，结果写入 resp；resp 为 nil 表示不需要结果
+//
+// resp 必须有缓冲 (无缓冲时 panic)：Worker 只非阻塞地投递一次，缓冲已满时结果被丢弃并计入 Stats.RepliesDropped
+func (e *Engine[Req, Resp]) Submit(req Req, resp chan Resp) error {
+	if resp != nil && cap(resp) == 0 {
+		panic("typed: Resp channel must be buffered")
+	}
+	e.pushMu.Lock()
+	defer e.pushMu.Unlock()
+	if !e.running.Load() || e.stop.Load() {
+		e.rejected.Add(1)
+		return ErrStopped
+	}
+	if !e.queue.Push(request[Req, Resp]{req: req, resp: resp, enqueued: sysclock.Now()}) {
+		e.rejected.Add(1)
+		return ErrFull
+	}
+	return nil
+}
+
+// Call 提交请求并等待结果，ctx 先结束时返回 ctx.Err() (请求可能仍会被处理，结果被丢弃)
+func (e *Engine[Req, Resp]) Call(ctx context.Context, req Req) (Resp, error) {
+	var zero Resp
+	ch := e.replies.Get().(chan Resp)
+	if err := e.Submit(req, ch); err != nil {
+		e.replies.Put(ch)
+		return zero, err
+	}
+	select {
+	case v := <-ch:
+		e.replies.Put(ch)
+		return v, nil
+	case <-ctx.Done():
+		// 结果稍后仍可能写入 ch，不放回池中
+		return zero, ctx.Err()
+	}
+}
+
+// QueueLen 返回当前排队的请求数
+func (e *Engine[Req, Resp]) QueueLen() int {
+	return int(e.queue.Len())
+}
+
+// Stats 返回运行统计
+func (e *Engine[Req, Resp]) Stats() Stats {
+	return Stats{
+		Processed:      e.processed.Load(),
+		Rejected:       e.rejected.Load(),
+		RepliesDropped: e.repliesDropped.Load(),
+		QueueWaitNanos: e.queueWait.Load(),
+	}
+}
@@ -0,0 +1,79 @@
+package typed
+
+import (
+	"arena_demo/pkg/arena"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type quote struct {
+	Legs []float64
+	Qty  int
+}
+
+type price struct {
+	Total float64
+	Used  int // Process 开始时 Arena 已用的字节数
+}
+
+// 两组不同的 Req/Resp 类型：请求各自得到类型化的结果，每个请求开始时 Arena 都是空的
+func TestTwoInstantiations(t *testing.T) {
+	quotes := New(8, func(q quote, mem *arena.Arena) price {
+		used := mem.Used()
+		buf := arena.MakeSlice[float64](mem, 0, len(q.Legs))
+		var total float64
+		for _, l := range q.Legs {
+			buf = append(buf, l*float64(q.Qty))
+			total += buf[len(buf)-1]
+		}
+		return price{Total: total, Used: used}
+	})
+	upper := New(8, func(s string, mem *arena.Arena) int {
+		b := arena.MakeSlice[byte](mem, 0, len(s))
+		b = append(b, strings.ToUpper(s)...)
+		return len(b)
+	})
+
+	if _, err := quotes.Call(context.Background(), quote{}); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Call before Start: err = %v, want ErrStopped", err)
+	}
+	quotes.Start()
+	defer quotes.Stop()
+	upper.Start()
+	defer upper.Stop()
+
+	for i := range 3 {
+		p, err := quotes.Call(context.Background(), quote{Legs: []float64{1, 2.5}, Qty: i + 1})
+		if err != nil || p.Total != 3.5*float64(i+1) || p.Used != 0 {
+			t.Fatalf("quote %d = %+v, %v; want Total %v on an empty arena", i, p, err, 3.5*float64(i+1))
+		}
+	}
+	n, err := upper.Call(context.Background(), "hello")
+	if err != nil || n != 5 {
+		t.Fatalf("upper = %d, %v; want 5", n, err)
+	}
+
+	// Submit 把结果写入调用方的类型化 channel
+	resp := make(chan int, 2)
+	for _, s := range []string{"a", "bcd"} {
+		if err := upper.Submit(s, resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a, b := <-resp, <-resp; a != 1 || b != 3 {
+		t.Fatalf("Submit results = %d, %d; want 1, 3", a, b)
+	}
+
+	if st := quotes.Stats(); st.Processed != 3 || st.Rejected != 1 {
+		t.Fatalf("quote stats = %+v, want 3 processed and 1 rejected", st)
+	}
+	upper.Stop()
+	if err := upper.Submit("late", nil); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Submit after Stop: err = %v, want ErrStopped", err)
+	}
+	if st := upper.Stats(); st.Processed != 3 {
+		t.Fatalf("upper Processed = %d, want 3", st.Processed)
+	}
+}
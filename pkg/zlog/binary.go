@@ -0,0 +1,241 @@
+package zlog
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 二进制 (TLV) 编码：面向通过 Socket 高速传输日志，省掉文本格式的引号、转义和数字格式化
+//
+// 格式 (一行日志即一条自定界的记录，多条记录直接首尾相接):
+//
+//	记录   := 字段* 0x00
+//	字段   := key value
+//	key    := uvarint(len<<1) bytes     长度必须 >= 1 (0 就是记录结束符，空 key 写成 "_")
+//	        | uvarint(id<<1 | 1)        注册过的 key 只写编号 (见 keys.go)，id < 64，恰好 1 个字节
+//	value  := tag payload
+//	  0x01 int     zigzag varint
+//	  0x02 string  uvarint(len) bytes
+//	  0x03 text    bytes 0x00          OpenString/CloseString 逐字节写入的文本 (Hex/IP/IntWidth/Caller)，内容不含 NUL
+//	  0x04 array   value* 0x00
+//
+// msg 作为最后一个 string 字段写入 (key 为编号 KeyMsg)。编码仍然只是向 buffer 追加字节，零分配；
+// 消费端用 SplitBinary 切出记录，再用 DecodeBinary 解码
+
+// Binary 编码器
+var Binary Encoder = binaryEncoder{}
+
+const (
+	binEnd    = 0x00
+	binInt    = 0x01
+	binString = 0x02
+	binText   = 0x03
+	binArray  = 0x04
+)
+
+// ErrBadBinary 二进制记录格式错误或被截断
+var ErrBadBinary = errors.New("zlog: malformed binary record")
+
+// WrapBinary 与 Wrap 相同，但使用二进制编码
+func WrapBinary(buf []byte) *Logger {
+	return WrapWith(buf, Binary)
+}
+
+type binaryEncoder struct{}
+
+func (binaryEncoder) AppendKey(dst []byte, key string, first bool) []byte {
+	if key == "" {
+		key = "_"
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(key))<<1)
+	return append(dst, key...)
+}
+
+func (binaryEncoder) AppendKeyID(dst []byte, id KeyID, first bool) []byte {
+	return binary.AppendUvarint(dst, uint64(id)<<1|1)
+}
+
+func (binaryEncoder) EndField(dst []byte) []byte { return dst }
+
+func (binaryEncoder) AppendInt(dst []byte, v int64) []byte {
+	return binary.AppendVarint(append(dst, binInt), v)
+}
+
+func (binaryEncoder) AppendString(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(append(dst, binString), uint64(len(s)))
+	return append(dst, s...)
+}
+
+func (e binaryEncoder) AppendElem(dst []byte, s string) []byte { return e.AppendString(dst, s) }
+
+func (binaryEncoder) OpenString(dst []byte) []byte  { return append(dst, binText) }
+func (binaryEncoder) CloseString(dst []byte) []byte { return append(dst, binEnd) }
+
+func (e binaryEncoder) End(dst []byte, msg string, first bool) []byte {
+	dst = e.AppendKeyID(dst, KeyMsg, first)
+	dst = e.AppendString(dst, msg)
+	return append(dst, binEnd)
+}
+
+// arrayEncoder 由不使用 [a,b] 文本语法表示数组的 Encoder 实现 (二进制)
+// 未实现它的 Encoder 由 Logger 直接写 '[' ',' ']'
+type arrayEncoder interface {
+	OpenArray(dst []byte) []byte
+	ArraySep(dst []byte) []byte
+	CloseArray(dst []byte) []byte
+}
+
+func (binaryEncoder) OpenArray(dst []byte) []byte  { return append(dst, binArray) }
+func (binaryEncoder) ArraySep(dst []byte) []byte   { return dst }
+func (binaryEncoder) CloseArray(dst []byte) []byte { return append(dst, binEnd) }
+
+func (s *Sizer) OpenArray(dst []byte) []byte  { return openArray(s.enc, dst) }
+func (s *Sizer) ArraySep(dst []byte) []byte   { return arraySep(s.enc, dst) }
+func (s *Sizer) CloseArray(dst []byte) []byte { return closeArray(s.enc, dst) }
+
+func openArray(enc Encoder, dst []byte) []byte {
+	if a, ok := enc.(arrayEncoder); ok {
+		return a.OpenArray(dst)
+	}
+	return append(dst, '[')
+}
+
+func arraySep(enc Encoder, dst []byte) []byte {
+	if a, ok := enc.(arrayEncoder); ok {
+		return a.ArraySep(dst)
+	}
+	return append(dst, ',')
+}
+
+func closeArray(enc Encoder, dst []byte) []byte {
+	if a, ok := enc.(arrayEncoder); ok {
+		return a.CloseArray(dst)
+	}
+	return append(dst, ']')
+}
+
+// --- 解码 (消费端，会分配内存) ---
+
+// SplitBinary 从 b 中切出第一条完整的记录 (含结束符)，rest 是其后的数据
+// b 中的记录不完整时返回 ErrBadBinary
+func SplitBinary(b []byte) (record, rest []byte, err error) {
+	n, err := decodeRecord(b, nil)
+	if err != nil {
+		return nil, b, err
+	}
+	return b[:n], b[n:], nil
+}
+
+// DecodeBinary 解码一条记录 (b 必须恰好是一条记录)
+// int 解码为 int64，string/text 解码为 string，数组解码为 []any
+func DecodeBinary(b []byte) (map[string]any, error) {
+	m := make(map[string]any)
+	n, err := decodeRecord(b, m)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(b) {
+		return nil, ErrBadBinary
+	}
+	return m, nil
+}
+
+// decodeRecord 解析 b 开头的一条记录，返回其长度；m 为 nil 时只校验不构造值
+func decodeRecord(b []byte, m map[string]any) (int, error) {
+	p := 0
+	for {
+		kv, k := binary.Uvarint(b[p:])
+		if k <= 0 {
+			return 0, ErrBadBinary
+		}
+		p += k
+		if kv == 0 {
+			return p, nil
+		}
+		var key string
+		if kv&1 != 0 {
+			name, ok := keyName(kv >> 1)
+			if !ok {
+				return 0, ErrBadBinary
+			}
+			key = name
+		} else {
+			klen := kv >> 1
+			if klen > uint64(len(b)-p) {
+				return 0, ErrBadBinary
+			}
+			if m != nil {
+				key = string(b[p : p+int(klen)])
+			}
+			p += int(klen)
+		}
+		v, n, err := decodeValue(b[p:], m != nil)
+		if err != nil {
+			return 0, err
+		}
+		p += n
+		if m != nil {
+			m[key] = v
+		}
+	}
+}
+
+// decodeValue 解析一个 tag + payload，返回值和消耗的字节数
+func decodeValue(b []byte, build bool) (any, int, error) {
+	if len(b) == 0 {
+		return nil, 0, ErrBadBinary
+	}
+	p := 1
+	switch b[0] {
+	case binInt:
+		v, k := binary.Varint(b[p:])
+		if k <= 0 {
+			return nil, 0, ErrBadBinary
+		}
+		return v, p + k, nil
+	case binString:
+		n, k := binary.Uvarint(b[p:])
+		if k <= 0 || n > uint64(len(b)-p-k) {
+			return nil, 0, ErrBadBinary
+		}
+		p += k
+		var v any
+		if build {
+			v = string(b[p : p+int(n)])
+		}
+		return v, p + int(n), nil
+	case binText:
+		for i := p; i < len(b); i++ {
+			if b[i] == binEnd {
+				var v any
+				if build {
+					v = string(b[p:i])
+				}
+				return v, i + 1, nil
+			}
+		}
+		return nil, 0, ErrBadBinary
+	case binArray:
+		var arr []any
+		if build {
+			arr = []any{}
+		}
+		for {
+			if p >= len(b) {
+				return nil, 0, ErrBadBinary
+			}
+			if b[p] == binEnd {
+				return arr, p + 1, nil
+			}
+			v, n, err := decodeValue(b[p:], build)
+			if err != nil {
+				return nil, 0, err
+			}
+			p += n
+			if build {
+				arr = append(arr, v)
+			}
+		}
+	}
+	return nil, 0, ErrBadBinary
+}
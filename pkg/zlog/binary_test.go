@@ -0,0 +1,100 @@
+package zlog
+
+import (
+	"arena_demo/pkg/sysclock"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 每种字段类型编码后都能原样解码回来
+func TestBinaryRoundTrip(t *testing.T) {
+	sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+	ms := sysclock.Now() / int64(time.Millisecond)
+
+	l := WrapBinary(make([]byte, 0, 1024))
+	l.Int("pos", 42).Int("neg", -7).Int("max", math.MaxInt).Int("min", math.MinInt).
+		Str("s", `a "quoted" value`+"\n\x00").Str("empty", "").Str("", "no key").
+		IntWidth("w", 7, 4).Hex("hex", []byte{0xde, 0xad}).IP("ip", net.IPv4(10, 0, 0, 1)).
+		Strs("tags", []string{"x", "y z"}).Ints("ids", []int{1, -2}).Ints("none", nil).
+		IntKey(KeyUID, 9).StrKey(KeyQoS, "gold").
+		Metric("lat", 1.5).Metric("nan", math.NaN()).
+		Time("ts", TimeRFC3339).Time("epoch", TimeEpochMillis).
+		Msg("hello world")
+
+	got, err := DecodeBinary(l.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeBinary: %v (%x)", err, l.Bytes())
+	}
+	want := map[string]any{
+		"pos": int64(42), "neg": int64(-7), "max": int64(math.MaxInt), "min": int64(math.MinInt),
+		"s": `a "quoted" value` + "\n\x00", "empty": "", "_": "no key",
+		"w": "0007", "hex": "dead", "ip": "10.0.0.1",
+		"tags": []any{"x", "y z"}, "ids": []any{int64(1), int64(-2)}, "none": []any{},
+		"uid": int64(9), "qos": "gold",
+		"lat": "1.5", "nan": "NaN",
+		"ts": FormatTime(TimeRFC3339), "epoch": ms,
+		"msg": "hello world",
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("%q: got %#v, want %#v", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("decoded %d fields, want %d: %v", len(got), len(want), got)
+	}
+}
+
+// 记录自定界：首尾相接的多条记录用 SplitBinary 逐条切出，截断的记录报 ErrBadBinary
+func TestBinarySplit(t *testing.T) {
+	var stream []byte
+	for i := range 3 {
+		l := WrapBinary(stream)
+		l.Int("i", i).Str("k", strconv.Itoa(i)).Msg("m")
+		stream = l.Bytes()
+	}
+	rest := stream
+	for i := range 3 {
+		var rec []byte
+		var err error
+		rec, rest, err = SplitBinary(rest)
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		m, err := DecodeBinary(rec)
+		if err != nil || m["i"] != int64(i) || m["k"] != fmt.Sprint(i) {
+			t.Fatalf("record %d = %v, %v", i, m, err)
+		}
+	}
+	if len(rest) != 0 {
+		t.Fatalf("%d trailing bytes", len(rest))
+	}
+
+	rec, _, _ := SplitBinary(stream)
+	for n := range len(rec) {
+		if _, _, err := SplitBinary(rec[:n]); !errors.Is(err, ErrBadBinary) {
+			t.Fatalf("record truncated to %d bytes: err = %v", n, err)
+		}
+	}
+	if _, err := DecodeBinary(stream); !errors.Is(err, ErrBadBinary) {
+		t.Fatalf("DecodeBinary of two records: err = %v", err)
+	}
+}
+
+func TestBinaryEncodeNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 512)
+	ids := []int{1, 2, 3}
+	if n := testing.AllocsPerRun(100, func() {
+		WrapBinary(buf[:0]).Int("uid", 7).Str("type", "order").IntKey(KeyQty, 3).Ints("ids", ids).
+			Hex("trace", []byte{1, 2}).Metric("lat", 0.25).Msg("ok")
+	}); n != 0 {
+		t.Fatalf("binary encoding allocated %v times per line", n)
+	}
+}
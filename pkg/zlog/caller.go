@@ -0,0 +1,48 @@
+package zlog
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// callerCache 缓存 PC -> "file.go:42"
+// 同一个调用点只在第一次解析帧信息，之后只剩一次 runtime.Callers + Map 查找
+var callerCache sync.Map
+
+// Caller 追加 caller=file.go:42 字段 (调试用)
+// skip=0 表示调用 Caller 的那一行，skip=1 表示再上一层，以此类推
+// 只有显式调用时才付出代价，不会影响默认的零分配热路径
+func (l *Logger) Caller(skip int) *Logger {
+	if l == nil {
+		return nil
+	}
+	var pcs [1]uintptr
+	// +2 跳过 runtime.Callers 和 Caller 本身
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return l
+	}
+	l.beginField("caller")
+	l.buf = l.enc.OpenString(l.buf)
+	l.appendString(callerLocation(pcs[0]))
+	l.buf = l.enc.CloseString(l.buf)
+	l.endField()
+	return l
+}
+
+func callerLocation(pc uintptr) string {
+	if v, ok := callerCache.Load(pc); ok {
+		return v.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	file := frame.File
+	for i := len(file) - 1; i >= 0; i-- {
+		if file[i] == '/' || file[i] == '\\' {
+			file = file[i+1:]
+			break
+		}
+	}
+	loc := file + ":" + strconv.Itoa(frame.Line)
+	callerCache.Store(pc, loc)
+	return loc
+}
@@ -0,0 +1,29 @@
+package zlog
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// caller 字段给出调用点的文件名与行号，skip 向上跳过调用层；同一调用点第二次起只剩缓存查找，不再分配
+func TestCaller(t *testing.T) {
+	up := func(l *Logger) *Logger { return l.Caller(1) }
+
+	l := Wrap(make([]byte, 0, 128))
+	_, _, line, _ := runtime.Caller(0)
+	l.Caller(0).Msg("here") // line+1
+	up(l).Msg("up")         // line+2
+	want := "caller=caller_test.go:" + strconv.Itoa(line+1) + " msg=here\n" +
+		"caller=caller_test.go:" + strconv.Itoa(line+2) + " msg=up\n"
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("caller lines:\n got %q\nwant %q", got, want)
+	}
+
+	buf := make([]byte, 0, 128)
+	log := func() { Wrap(buf[:0]).Caller(0).Msg("m") }
+	log()
+	if n := testing.AllocsPerRun(100, log); n != 0 {
+		t.Fatalf("cached Caller allocated %v times", n)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build zlog_nodebug
+
+package zlog
+
+import "net"
+
+// DebugEnabled 为 false：Debug 调用链在编译期被整体消除
+const DebugEnabled = false
+
+// Debug 返回空的 DebugLogger
+// 它和它的所有方法都是空函数体，内联后调用点不剩任何指令 (连 nil 检查都没有)；
+// 唯一的残留是参数求值，所以不要在参数里做有副作用或昂贵的计算
+func (l *Logger) Debug() DebugLogger {
+	return DebugLogger{}
+}
+
+// DebugLogger 见 Logger.Debug
+type DebugLogger struct{}
+
+func (d DebugLogger) Int(key string, val int) DebugLogger             { return d }
+func (d DebugLogger) IntWidth(key string, val, width int) DebugLogger { return d }
+func (d DebugLogger) Str(key string, val string) DebugLogger          { return d }
+func (d DebugLogger) Strs(key string, vals []string) DebugLogger      { return d }
+func (d DebugLogger) Ints(key string, vals []int) DebugLogger         { return d }
+func (d DebugLogger) Hex(key string, val []byte) DebugLogger          { return d }
+func (d DebugLogger) IP(key string, ip net.IP) DebugLogger            { return d }
+func (d DebugLogger) Caller(skip int) DebugLogger                     { return d }
+func (d DebugLogger) Msg(msg string)                                  {}
@@ -0,0 +1,60 @@
+//go:build !zlog_nodebug
+
+package zlog
+
+import "net"
+
+// DebugEnabled 报告 Debug 级别的日志是否被编译进来 (go build -tags zlog_nodebug 关闭)
+const DebugEnabled = true
+
+// DebugLogger 是 Debug 级别的字段调用链，方法集与 Logger 的字段方法一致:
+//
+//	logger.Debug().Int("slot", i).Str("path", "fast").Msg("route")
+//
+// 默认构建下它只是 *Logger 的薄包装，逐个转发；这一行的级别为 DebugLevel (决定 Msg 时写入哪个 Sink)
+// zlog_nodebug 构建下它是空结构体，所有方法都是空函数体，见 debug_off.go
+func (l *Logger) Debug() DebugLogger {
+	return DebugLogger{l.Level(DebugLevel)}
+}
+
+// DebugLogger 见 Logger.Debug
+type DebugLogger struct {
+	l *Logger
+}
+
+func (d DebugLogger) Int(key string, val int) DebugLogger {
+	return DebugLogger{d.l.Int(key, val)}
+}
+
+func (d DebugLogger) IntWidth(key string, val, width int) DebugLogger {
+	return DebugLogger{d.l.IntWidth(key, val, width)}
+}
+
+func (d DebugLogger) Str(key string, val string) DebugLogger {
+	return DebugLogger{d.l.Str(key, val)}
+}
+
+func (d DebugLogger) Strs(key string, vals []string) DebugLogger {
+	return DebugLogger{d.l.Strs(key, vals)}
+}
+
+func (d DebugLogger) Ints(key string, vals []int) DebugLogger {
+	return DebugLogger{d.l.Ints(key, vals)}
+}
+
+func (d DebugLogger) Hex(key string, val []byte) DebugLogger {
+	return DebugLogger{d.l.Hex(key, val)}
+}
+
+func (d DebugLogger) IP(key string, ip net.IP) DebugLogger {
+	return DebugLogger{d.l.IP(key, ip)}
+}
+
+// Caller 的 skip 语义与 Logger.Caller 相同 (多出的这一层包装已经扣掉)
+func (d DebugLogger) Caller(skip int) DebugLogger {
+	return DebugLogger{d.l.Caller(skip + 1)}
+}
+
+func (d DebugLogger) Msg(msg string) {
+	d.l.Msg(msg)
+}
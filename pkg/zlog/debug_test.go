@@ -0,0 +1,35 @@
+package zlog
+
+import "testing"
+
+// 默认构建下 Debug 调用链与直接调用 Logger 的输出相同；zlog_nodebug 构建下什么都不写
+func TestDebugChain(t *testing.T) {
+	buf := make([]byte, 0, 128)
+	l := Wrap(buf)
+	l.Debug().Int("slot", 3).Str("path", "fast").Msg("route")
+
+	want := "slot=3 path=fast msg=route\n"
+	if !DebugEnabled {
+		want = ""
+	}
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("Debug chain wrote %q, want %q", got, want)
+	}
+}
+
+func TestDebugChainNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 128)
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).Debug().Int("slot", 3).Str("path", "fast").Msg("route")
+	}); n != 0 {
+		t.Fatalf("Debug chain allocated %v times", n)
+	}
+}
+
+// go test -bench Debug -tags zlog_nodebug：调用链被整体消除，与空循环的耗时相同
+func BenchmarkDebugChain(b *testing.B) {
+	buf := make([]byte, 0, 128)
+	for b.Loop() {
+		Wrap(buf[:0]).Debug().Int("slot", 3).Str("path", "fast").Msg("route")
+	}
+}
@@ -0,0 +1,135 @@
+package zlog
+
+import "strconv"
+
+// Encoder 决定一行日志的输出格式
+// Logger 负责字段调用链和缓冲区管理，Encoder 只负责把 key/value 追加成字节
+// 所有方法都是 "追加到 dst 并返回新切片" 的形式，本身不做任何分配
+type Encoder interface {
+	// AppendKey 写入字段名及分隔符，first 表示这是本行的第一个字段
+	AppendKey(dst []byte, key string, first bool) []byte
+	// EndField 结束一个字段
+	EndField(dst []byte) []byte
+	AppendInt(dst []byte, v int64) []byte
+	// AppendString 写入一个字符串值 (按格式需要加引号/转义)
+	AppendString(dst []byte, s string) []byte
+	// AppendElem 写入数组中的一个字符串元素
+	AppendElem(dst []byte, s string) []byte
+	// OpenString / CloseString 包裹一个逐字节写入的字符串值 (如 Hex)
+	OpenString(dst []byte) []byte
+	CloseString(dst []byte) []byte
+	// End 写入消息并结束一行
+	End(dst []byte, msg string, first bool) []byte
+}
+
+var (
+	// Logfmt 是默认格式: k=v k2=v2 msg=...
+	Logfmt Encoder = logfmtEncoder{}
+	// JSON 每行一个对象: {"k":v,"msg":"..."}
+	JSON Encoder = jsonEncoder{}
+)
+
+// --- logfmt ---
+
+type logfmtEncoder struct{}
+
+func (logfmtEncoder) AppendKey(dst []byte, key string, first bool) []byte {
+	dst = append(dst, key...)
+	return append(dst, '=')
+}
+
+func (logfmtEncoder) EndField(dst []byte) []byte { return append(dst, ' ') }
+
+func (logfmtEncoder) AppendInt(dst []byte, v int64) []byte {
+	// 使用 strconv.AppendInt 是最高效的标准库方法，
+	// 它不会产生内存分配，直接写入 buffer
+	return strconv.AppendInt(dst, v, 10)
+}
+
+// AppendString 沿用最初的行为：值原样写入，不做转义
+func (logfmtEncoder) AppendString(dst []byte, s string) []byte { return append(dst, s...) }
+
+func (logfmtEncoder) AppendElem(dst []byte, s string) []byte {
+	if needsQuote(s) {
+		return appendQuoted(dst, s)
+	}
+	return append(dst, s...)
+}
+
+func (logfmtEncoder) OpenString(dst []byte) []byte  { return dst }
+func (logfmtEncoder) CloseString(dst []byte) []byte { return dst }
+
+func (logfmtEncoder) End(dst []byte, msg string, first bool) []byte {
+	dst = append(dst, "msg="...)
+	dst = append(dst, msg...)
+	return append(dst, '\n')
+}
+
+// --- JSON ---
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) AppendKey(dst []byte, key string, first bool) []byte {
+	if first {
+		dst = append(dst, '{')
+	} else {
+		dst = append(dst, ',')
+	}
+	dst = append(dst, '"')
+	dst = append(dst, key...)
+	return append(dst, '"', ':')
+}
+
+func (jsonEncoder) EndField(dst []byte) []byte { return dst }
+
+func (jsonEncoder) AppendInt(dst []byte, v int64) []byte { return strconv.AppendInt(dst, v, 10) }
+
+func (jsonEncoder) AppendString(dst []byte, s string) []byte { return appendQuoted(dst, s) }
+
+func (jsonEncoder) AppendElem(dst []byte, s string) []byte { return appendQuoted(dst, s) }
+
+func (jsonEncoder) OpenString(dst []byte) []byte  { return append(dst, '"') }
+func (jsonEncoder) CloseString(dst []byte) []byte { return append(dst, '"') }
+
+func (e jsonEncoder) End(dst []byte, msg string, first bool) []byte {
+	dst = e.AppendKey(dst, "msg", first)
+	dst = appendQuoted(dst, msg)
+	return append(dst, '}', '\n')
+}
+
+// --- 公共工具 ---
+
+// appendQuoted 写入带引号并转义的字符串 (JSON 字符串格式，同时也是 logfmt 的引号格式)
+func appendQuoted(dst []byte, s string) []byte {
+	const digits = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', digits[c>>4], digits[c&0xF])
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
+
+// needsQuote 判断 logfmt 数组元素是否需要加引号
+func needsQuote(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ' ', ',', '[', ']', '"', '=', '\\':
+			return true
+		default:
+			if c < 0x20 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package zlog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuiltinEncoders(t *testing.T) {
+	line := func(enc Encoder) string {
+		l := WrapWith(make([]byte, 0, 256), enc)
+		l.Int("uid", -42).Str("side", `buy "x"`).Hex("id", []byte{0xab, 0x01}).Msg("processed")
+		return string(l.Bytes())
+	}
+
+	if got, want := line(Logfmt), "uid=-42 side=buy \"x\" id=ab01 msg=processed\n"; got != want {
+		t.Errorf("Logfmt:\n got %q\nwant %q", got, want)
+	}
+
+	got := line(JSON)
+	if want := `{"uid":-42,"side":"buy \"x\"","id":"ab01","msg":"processed"}` + "\n"; got != want {
+		t.Errorf("JSON:\n got %q\nwant %q", got, want)
+	}
+	var v map[string]any
+	if err := json.Unmarshal([]byte(got), &v); err != nil {
+		t.Errorf("JSON output does not parse: %v", err)
+	}
+
+	// 没有任何字段的行
+	if got := string(Wrap(nil).Bytes()); got != "" {
+		t.Errorf("empty logger = %q", got)
+	}
+	l := WrapJSON(make([]byte, 0, 64))
+	l.Msg("m")
+	if got := string(l.Bytes()); got != `{"msg":"m"}`+"\n" {
+		t.Errorf("JSON line without fields = %q", got)
+	}
+}
+
+// 自定义格式只需实现 Encoder，字段调用链不变
+type upperEncoder struct{ logfmtEncoder }
+
+func (upperEncoder) AppendKey(dst []byte, key string, first bool) []byte {
+	for i := 0; i < len(key); i++ {
+		dst = append(dst, key[i]-'a'+'A')
+	}
+	return append(dst, ':')
+}
+
+func TestCustomEncoder(t *testing.T) {
+	l := WrapWith(make([]byte, 0, 64), upperEncoder{})
+	l.Int("qty", 3).Msg("ok")
+	if got := string(l.Bytes()); got != "QTY:3 msg=ok\n" {
+		t.Fatalf("custom encoder = %q", got)
+	}
+}
+
+// 按接口分派不能让热路径产生分配
+func TestEncoderNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 256)
+	for _, enc := range []Encoder{Logfmt, JSON} {
+		if n := testing.AllocsPerRun(100, func() {
+			WrapWith(buf[:0], enc).Int("uid", 7).Str("side", "buy").Msg("processed")
+		}); n != 0 {
+			t.Errorf("%T allocated %v times per line", enc, n)
+		}
+	}
+}
+
+func BenchmarkEncoder(b *testing.B) {
+	buf := make([]byte, 0, 256)
+	for name, enc := range map[string]Encoder{"logfmt": Logfmt, "json": JSON} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				WrapWith(buf[:0], enc).Int("ts", 1700000000).Str("type", "order").Int("uid", 42).Msg("processed")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package zlog
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// errKeys 是 ErrChain 各层使用的 key，预先写好避免运行时拼接字符串
+var errKeys = [...]string{"err0", "err1", "err2", "err3", "err4", "err5", "err6", "err7"}
+
+// errStackDepth 是 ErrorLevel 行上 ErrChain 附带的调用栈层数，0 表示不附带 (见 SetErrorStack)
+var errStackDepth atomic.Int32
+
+// maxErrStack 是 SetErrorStack 允许的最大层数 (PC 数组放在栈上)
+const maxErrStack = 16
+
+// SetErrorStack 让 ErrorLevel 的行在 ErrChain 之后附带 depth 层调用栈: stack=[svc.go:42,handler.go:17]
+// depth <= 0 关闭 (默认)，超过 16 按 16 处理；进程级设置，可以在运行中随时调用
+func SetErrorStack(depth int) {
+	errStackDepth.Store(int32(min(max(depth, 0), maxErrStack)))
+}
+
+// ErrChain 沿 errors.Unwrap 写出 err 的整条包装链: err0=save order err1=write wal err2=disk full
+//   - 外层的消息以 ": 内层消息" 结尾时 (fmt.Errorf("...: %w") 的写法) 只写自己的前缀，不重复内层的内容
+//   - 最多写 8 层，更深的部分不再展开 (最后一层的消息本身仍包含它们)；errors.Join 等多分支的包装按一层处理
+//   - err 为 nil 时不写任何字段；没有包装的 error 只写 err0
+//   - 当前行是 ErrorLevel 且开启了 SetErrorStack 时，再追加 stack 字段 (调用 ErrChain 的位置开始)
+//
+// zlog 本身不分配：只有各层的 Error() 方法可能分配；调用栈的文本按 PC 缓存 (同 Caller)，同一调用点只在第一次解析
+func (l *Logger) ErrChain(err error) *Logger {
+	if l == nil || err == nil {
+		return l
+	}
+	msg := err.Error()
+	for i := range errKeys {
+		next := errors.Unwrap(err)
+		var inner string
+		if next != nil {
+			inner = next.Error()
+		}
+		val := msg
+		if next != nil && len(msg) > len(inner) && strings.HasSuffix(msg, inner) {
+			if p := strings.TrimSuffix(msg[:len(msg)-len(inner)], ": "); p != "" {
+				val = p
+			}
+		}
+		l.errField(errKeys[i], val)
+		if next == nil {
+			break
+		}
+		err, msg = next, inner
+	}
+	if l.level == ErrorLevel {
+		if depth := int(errStackDepth.Load()); depth > 0 {
+			l.appendStack(depth)
+		}
+	}
+	return l
+}
+
+func (l *Logger) errField(key, val string) {
+	if l.redact != nil && l.redactStr(key, val) {
+		return
+	}
+	l.beginField(key)
+	l.buf = l.enc.AppendString(l.buf, val)
+	l.endField()
+}
+
+// appendStack 写入 stack=[file.go:line,...]，从 ErrChain 的调用方开始
+func (l *Logger) appendStack(depth int) {
+	var pcs [maxErrStack]uintptr
+	// +3 跳过 runtime.Callers、appendStack 与 ErrChain
+	n := runtime.Callers(3, pcs[:depth])
+	if n == 0 {
+		return
+	}
+	l.beginField("stack")
+	l.buf = openArray(l.enc, l.buf)
+	for i, pc := range pcs[:n] {
+		if i > 0 {
+			l.buf = arraySep(l.enc, l.buf)
+		}
+		l.buf = l.enc.AppendElem(l.buf, callerLocation(pc))
+	}
+	l.buf = closeArray(l.enc, l.buf)
+	l.endField()
+}
@@ -0,0 +1,61 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 包装链逐层写出，%w 包装的外层只写自己的前缀；nil 不写字段，单个 error 只有 err0
+func TestErrChain(t *testing.T) {
+	disk := errors.New("disk full")
+	wal := fmt.Errorf("write wal: %w", disk)
+	save := fmt.Errorf("save order: %w", wal)
+
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, "msg=m\n"},
+		{disk, "err0=disk full msg=m\n"},
+		{save, "err0=save order err1=write wal err2=disk full msg=m\n"},
+		{fmt.Errorf("retry (%w)", disk), "err0=retry (disk full) err1=disk full msg=m\n"}, // 不以内层结尾，原样写出
+	}
+	for _, c := range cases {
+		l := Wrap(make([]byte, 0, 128))
+		l.ErrChain(c.err).Msg("m")
+		if got := string(l.Bytes()); got != c.want {
+			t.Errorf("ErrChain(%v):\n got %q\nwant %q", c.err, got, c.want)
+		}
+	}
+
+	// 超过 8 层只展开前 8 层
+	deep := disk
+	for i := range 10 {
+		deep = fmt.Errorf("l%d: %w", i, deep)
+	}
+	l := Wrap(make([]byte, 0, 512))
+	l.ErrChain(deep).Msg("m")
+	if got := string(l.Bytes()); !strings.Contains(got, "err7=") || strings.Contains(got, "err8=") {
+		t.Fatalf("deep chain = %q, want err0..err7", got)
+	}
+
+	// 调用栈只附带在 ErrorLevel 的行上
+	SetErrorStack(2)
+	t.Cleanup(func() { SetErrorStack(0) })
+	l = Wrap(make([]byte, 0, 256))
+	l.ErrChain(disk).Msg("info")
+	l.Level(ErrorLevel).ErrChain(disk).Msg("error")
+	lines := strings.Split(string(l.Bytes()), "\n")
+	if strings.Contains(lines[0], "stack=") || !strings.Contains(lines[1], "stack=[errchain_test.go:") {
+		t.Fatalf("stack fields: %q", l.Bytes())
+	}
+
+	buf := make([]byte, 0, 256)
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).ErrChain(save).ErrChain(nil).Msg("m")
+	}); n != 0 {
+		t.Fatalf("ErrChain allocated %v times per line", n)
+	}
+}
@@ -0,0 +1,102 @@
+package zlog
+
+import "net"
+
+// IP 写入一个 IP 地址: ip=192.168.1.1 / ip=2001:db8::1
+// 与 net.IP.String 输出一致 (IPv4-mapped IPv6 输出为点分十进制，IPv6 按 RFC 5952 压缩最长的零段)，
+// 但直接按字节格式化进缓冲区，不分配内存
+// nil 输出 <nil>，长度非法时输出 ? 加十六进制原始字节 (同 net.IP.String)
+func (l *Logger) IP(key string, ip net.IP) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = l.enc.OpenString(l.buf)
+	switch {
+	case len(ip) == 0:
+		l.buf = append(l.buf, "<nil>"...)
+	case len(ip) == net.IPv4len:
+		l.appendIPv4(ip)
+	case len(ip) == net.IPv6len && isV4Mapped(ip):
+		l.appendIPv4(ip[12:])
+	case len(ip) == net.IPv6len:
+		l.appendIPv6(ip)
+	default:
+		const digits = "0123456789abcdef"
+		l.buf = append(l.buf, '?')
+		for _, c := range ip {
+			l.buf = append(l.buf, digits[c>>4], digits[c&0xF])
+		}
+	}
+	l.buf = l.enc.CloseString(l.buf)
+	l.endField()
+	return l
+}
+
+// isV4Mapped 判断是否为 ::ffff:a.b.c.d
+func isV4Mapped(ip net.IP) bool {
+	for _, b := range ip[:10] {
+		if b != 0 {
+			return false
+		}
+	}
+	return ip[10] == 0xff && ip[11] == 0xff
+}
+
+func (l *Logger) appendIPv4(ip net.IP) {
+	for i, b := range ip {
+		if i > 0 {
+			l.buf = append(l.buf, '.')
+		}
+		if b >= 100 {
+			l.buf = append(l.buf, '0'+b/100)
+		}
+		if b >= 10 {
+			l.buf = append(l.buf, '0'+b/10%10)
+		}
+		l.buf = append(l.buf, '0'+b%10)
+	}
+}
+
+func (l *Logger) appendIPv6(ip net.IP) {
+	// 找出最长的连续零段 (至少 2 段才压缩，长度相同时取最左边的)
+	zs, zl := -1, 0
+	for i := 0; i < 8; {
+		j := i
+		for j < 8 && ip[2*j] == 0 && ip[2*j+1] == 0 {
+			j++
+		}
+		if j-i >= 2 && j-i > zl {
+			zs, zl = i, j-i
+		}
+		if j == i {
+			j++
+		}
+		i = j
+	}
+
+	const digits = "0123456789abcdef"
+	for i := 0; i < 8; i++ {
+		if i == zs {
+			l.buf = append(l.buf, ':', ':')
+			i += zl - 1
+			continue
+		}
+		if i > 0 && i != zs+zl {
+			l.buf = append(l.buf, ':')
+		}
+		// 十六进制去掉前导零
+		v := uint16(ip[2*i])<<8 | uint16(ip[2*i+1])
+		started := false
+		for shift := 12; shift >= 0; shift -= 4 {
+			d := v >> uint(shift) & 0xF
+			if d != 0 || started || shift == 0 {
+				l.buf = append(l.buf, digits[d])
+				started = true
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package zlog
+
+import (
+	"net"
+	"testing"
+)
+
+var ipCases = []net.IP{
+	net.IPv4(192, 168, 1, 1).To4(),
+	net.IPv4(10, 0, 0, 255),       // 16 字节的 IPv4-mapped 形式
+	net.IPv4(0, 9, 99, 100).To4(), // 一位、两位、三位数
+	net.ParseIP("2001:db8::1"),
+	net.ParseIP("::"),
+	net.ParseIP("::1"),
+	net.ParseIP("fe80::"),
+	net.ParseIP("2001:db8:0:0:1:0:0:1"), // 两段等长的零段压缩前一段
+	net.ParseIP("2001:db8:0:1:1:1:1:1"), // 单个零段不压缩
+	net.ParseIP("::ffff:0:1"),
+	net.ParseIP("1:2:3:4:5:6:7:8"),
+	nil,
+	{1, 2, 3}, // 长度非法
+}
+
+// 输出与 net.IP.String 逐字节一致
+func TestIPMatchesString(t *testing.T) {
+	buf := make([]byte, 0, 128)
+	for _, ip := range ipCases {
+		l := Wrap(buf[:0])
+		l.IP("ip", ip).Msg("m")
+		want := "ip=" + ip.String() + " msg=m\n"
+		if got := string(l.Bytes()); got != want {
+			t.Errorf("IP(%v):\n got %q\nwant %q", []byte(ip), got, want)
+		}
+	}
+}
+
+func TestIPNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 128)
+	v4 := net.IPv4(192, 168, 1, 1)
+	v6 := net.ParseIP("2001:db8::8a2e:370:7334")
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).IP("v4", v4).IP("v6", v6).Msg("m")
+	}); n != 0 {
+		t.Fatalf("IP allocated %v times per line", n)
+	}
+}
@@ -0,0 +1,143 @@
+package zlog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 字段名驻留 (key interning)
+//
+// ts、type、uid 这类 key 每一行都会出现。RegisterKey 给字段名分配一个编号 (KeyID)，
+// 通过 IntKey/StrKey 写入时，二进制编码只写 1 个字节的编号而不是完整的字符串 (格式见 binary.go)，
+// DecodeBinary 再按注册表把编号还原为名称；文本格式 (logfmt/JSON) 照常输出名称，结果与 Int/Str 完全相同。
+//
+// 编号就是注册顺序：内置的 key (KeyMsg ... KeyCaller) 固定占用前面的编号，
+// 之后 RegisterKey 的编号依次递增，所以写日志和解码日志的进程必须以相同的顺序注册相同的 key (通常放在 init 中)。
+// 最多 maxKeys 个，保证编号总能编码为 1 个字节
+
+// KeyID 是 RegisterKey 分配的字段名编号
+type KeyID uint8
+
+// 内置 key，与 core 的订单日志使用的字段名一致
+const (
+	KeyMsg KeyID = iota
+	KeyTS
+	KeyType
+	KeyUID
+	KeyQoS
+	KeyCorrID
+	KeyIP
+	KeyTraceID
+	KeySpanID
+	KeySeq
+	KeyQty
+	KeyLimit
+	KeyErr
+	KeyMode
+	KeyWarn
+	KeyCaller
+)
+
+// maxKeys 是可注册的 key 总数：二进制中编号写作 uvarint(id<<1|1)，id < 64 时恰好 1 个字节
+const maxKeys = 64
+
+var (
+	keyMu   sync.Mutex
+	keyIDs  = map[string]KeyID{}
+	keyList atomic.Pointer[[]string] // 按编号排列的名称，写时复制，解码时无锁读取
+)
+
+func init() {
+	for _, name := range []string{
+		"msg", "ts", "type", "uid", "qos", "corr_id", "ip", "trace_id", "span_id",
+		"seq", "qty", "limit", "err", "mode", "warn", "caller",
+	} {
+		RegisterKey(name)
+	}
+}
+
+// RegisterKey 注册一个字段名并返回它的编号，重复注册返回同一个编号
+// 超过 maxKeys 个时 panic (注册表是给少量高频 key 用的，不是通用的字符串池)
+func RegisterKey(name string) KeyID {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if id, ok := keyIDs[name]; ok {
+		return id
+	}
+	var names []string
+	if p := keyList.Load(); p != nil {
+		names = *p
+	}
+	if len(names) >= maxKeys {
+		panic("zlog: too many registered keys")
+	}
+	id := KeyID(len(names))
+	names = append(names[:len(names):len(names)], name)
+	keyList.Store(&names)
+	keyIDs[name] = id
+	return id
+}
+
+// Name 返回编号对应的字段名，未注册的编号返回 ""
+func (k KeyID) Name() string {
+	name, _ := keyName(uint64(k))
+	return name
+}
+
+func keyName(id uint64) (string, bool) {
+	names := *keyList.Load()
+	if id >= uint64(len(names)) {
+		return "", false
+	}
+	return names[id], true
+}
+
+// keyIDEncoder 由能够直接写出 key 编号的 Encoder 实现 (二进制)
+// 未实现它的 Encoder 按名称写 key
+type keyIDEncoder interface {
+	AppendKeyID(dst []byte, id KeyID, first bool) []byte
+}
+
+func (s *Sizer) AppendKeyID(dst []byte, id KeyID, first bool) []byte {
+	if ke, ok := s.enc.(keyIDEncoder); ok {
+		return ke.AppendKeyID(dst, id, first)
+	}
+	return s.enc.AppendKey(dst, id.Name(), first)
+}
+
+// IntKey 与 Int 相同，字段名使用注册过的编号
+func (l *Logger) IntKey(key KeyID, val int) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactInt(key.Name(), val) {
+		return l
+	}
+	l.beginKey(key)
+	l.buf = l.enc.AppendInt(l.buf, int64(val))
+	l.endField()
+	return l
+}
+
+// StrKey 与 Str 相同，字段名使用注册过的编号
+func (l *Logger) StrKey(key KeyID, val string) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactStr(key.Name(), val) {
+		return l
+	}
+	l.beginKey(key)
+	l.buf = l.enc.AppendString(l.buf, val)
+	l.endField()
+	return l
+}
+
+func (l *Logger) beginKey(key KeyID) {
+	if ke, ok := l.enc.(keyIDEncoder); ok {
+		l.buf = ke.AppendKeyID(l.buf, key, l.fields == 0)
+	} else {
+		l.buf = l.enc.AppendKey(l.buf, key.Name(), l.fields == 0)
+	}
+	l.fields++
+}
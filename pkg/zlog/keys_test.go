@@ -0,0 +1,43 @@
+package zlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 注册过的 key 在二进制中写成 1 个字节的编号，解码后还原为名称；文本格式与按名称写入完全相同
+func TestRegisteredKeysRoundTrip(t *testing.T) {
+	venue := RegisterKey("test_venue")
+	if again := RegisterKey("test_venue"); again != venue {
+		t.Fatalf("re-registering returned %d, want %d", again, venue)
+	}
+	if venue.Name() != "test_venue" || KeyUID.Name() != "uid" || KeyID(maxKeys-1).Name() != "" {
+		t.Fatalf("Name: %q, %q, %q", venue.Name(), KeyUID.Name(), KeyID(maxKeys-1).Name())
+	}
+
+	byID := WrapBinary(nil)
+	byID.IntKey(KeyUID, 42).StrKey(venue, "xnys").Int("plain", -1).Msg("fill")
+	got, err := DecodeBinary(byID.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeBinary: %v", err)
+	}
+	want := map[string]any{"uid": int64(42), "test_venue": "xnys", "plain": int64(-1), "msg": "fill"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("decoded %v, want %v", got, want)
+	}
+
+	byName := WrapBinary(nil)
+	byName.Int("uid", 42).Str("test_venue", "xnys").Int("plain", -1).Msg("fill")
+	if saved := len(byName.Bytes()) - len(byID.Bytes()); saved != len("uid")+len("test_venue") {
+		t.Fatalf("interned record is %d bytes shorter, want %d (one byte per key instead of the name)", saved, len("uid")+len("test_venue"))
+	}
+
+	for name, wrap := range map[string]func([]byte) *Logger{"logfmt": Wrap, "json": WrapJSON} {
+		a, b := wrap(nil), wrap(nil)
+		a.IntKey(KeyUID, 42).StrKey(venue, "xnys").Msg("fill")
+		b.Int("uid", 42).Str("test_venue", "xnys").Msg("fill")
+		if string(a.Bytes()) != string(b.Bytes()) {
+			t.Errorf("%s: IntKey/StrKey wrote %q, Int/Str wrote %q", name, a.Bytes(), b.Bytes())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package zlog
+
+// Level 日志级别
+// 级别越高越重要，Error 级别的日志永远不会被采样丢弃
+type Level int8
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+// String 返回级别的文本表示 (返回常量字符串，无分配)
+func (lv Level) String() string {
+	switch lv {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return "unknown"
+}
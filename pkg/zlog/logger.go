@@ -2,62 +2,336 @@ package zlog
 
 import (
 	"arena_demo/pkg/arena"
-	"strconv"
+	"io"
+	"slices"
 )
 
 // Logger 是一个极速、零分配的日志记录器
 // 它直接将日志数据写入 Arena 内存，不进行任何 syscall
+// 输出格式由 Encoder 决定 (默认 logfmt)，同一条字段调用链可以输出任意格式
 type Logger struct {
 	buf []byte // 实际上指向 Arena 的内存
+
+	enc       Encoder
+	fields    int // 当前行已写入的字段数
+	lineStart int // 当前行在 buf 中的起始位置
+	capHint   int // 创建时 buf 的容量，超出即说明 append 发生了堆分配
+
+	tee *RingSink // 非空时每行结束后额外写入内存环 (见 Tee)
+	seq *Sequence // 非空时每行在 msg 之前写入 seq=N (见 Seq)
+
+	static     []byte // 非空时每行在 msg 之前写入的预格式化字段 (见 WithStatic)
+	staticDone bool   // 当前行已经写过静态字段 (见 Snapshot)，Msg 时跳过
+	snap       snapshot
+
+	redact *Redactor // 非空时对敏感 key 脱敏 (见 Redact)
+
+	level Level // 当前行的级别，决定 Msg 时写入哪个 Sink (见 SetSink)
+
+	last lastLine // 最近一次 Msg 结束的行，供 Merge 取出它的字段
 }
 
 // New 在 Arena 上创建一个 Logger
 func New(a *arena.Arena) *Logger {
 	// 预分配 4KB 的日志缓冲区
 	return &Logger{
-		buf: arena.MakeSlice[byte](a, 0, 4096),
+		buf:     arena.MakeSlice[byte](a, 0, 4096),
+		enc:     Logfmt,
+		capHint: 4096,
+		level:   InfoLevel,
 	}
 }
 
 // Wrap 使用外部提供的 buffer 创建 Logger (实现 Caller-Allocated Logging)
 func Wrap(buf []byte) *Logger {
+	return WrapWith(buf, Logfmt)
+}
+
+// WrapJSON 与 Wrap 相同，但每行输出一个 JSON 对象: {"k":1,"msg":"..."}
+func WrapJSON(buf []byte) *Logger {
+	return WrapWith(buf, JSON)
+}
+
+// WrapWith 使用外部 buffer 和自定义 Encoder 创建 Logger
+func WrapWith(buf []byte, enc Encoder) *Logger {
 	return &Logger{
-		buf: buf,
+		buf:       buf,
+		enc:       enc,
+		lineStart: len(buf),
+		capHint:   cap(buf),
+		level:     InfoLevel,
 	}
 }
 
 // Int 写入一个整数 (无 GC, 无 strconv 开销)
+// 所有字段方法都允许 nil 接收者 (被采样丢弃的日志)，此时为空操作
 func (l *Logger) Int(key string, val int) *Logger {
-	l.appendString(key)
-	l.appendString("=")
-	l.appendInt(val)
-	l.appendString(" ")
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactInt(key, val) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = l.enc.AppendInt(l.buf, int64(val))
+	l.endField()
+	return l
+}
+
+// IntWidth 写入一个左侧补零到固定宽度的整数 (如 uid=000042)，便于日志列对齐
+// width 包含负号：IntWidth("v", -42, 5) -> v=-0042
+// 数值本身比 width 更宽时原样输出，不会截断
+// JSON 不允许前导零的数字，所以 JSON 模式下输出为字符串
+func (l *Logger) IntWidth(key string, val, width int) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = l.enc.OpenString(l.buf)
+	l.appendIntWidth(val, width)
+	l.buf = l.enc.CloseString(l.buf)
+	l.endField()
 	return l
 }
 
 // Str 写入一个字符串
 func (l *Logger) Str(key string, val string) *Logger {
-	l.appendString(key)
-	l.appendString("=")
-	l.appendString(val)
-	l.appendString(" ")
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactStr(key, val) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = l.enc.AppendString(l.buf, val)
+	l.endField()
+	return l
+}
+
+// Strs 写入一个字符串数组
+// logfmt: tags=[a,b,c] (元素包含空格、逗号、方括号或引号时会被加引号转义)
+// JSON:   "tags":["a","b","c"]
+func (l *Logger) Strs(key string, vals []string) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = openArray(l.enc, l.buf)
+	for i, v := range vals {
+		if i > 0 {
+			l.buf = arraySep(l.enc, l.buf)
+		}
+		l.buf = l.enc.AppendElem(l.buf, v)
+	}
+	l.buf = closeArray(l.enc, l.buf)
+	l.endField()
+	return l
+}
+
+// Ints 写入一个整数数组: ids=[1,2,3] / "ids":[1,2,3]
+func (l *Logger) Ints(key string, vals []int) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = openArray(l.enc, l.buf)
+	for i, v := range vals {
+		if i > 0 {
+			l.buf = arraySep(l.enc, l.buf)
+		}
+		l.buf = l.enc.AppendInt(l.buf, int64(v))
+	}
+	l.buf = closeArray(l.enc, l.buf)
+	l.endField()
+	return l
+}
+
+// Hex 以小写十六进制写入一段字节 (如 Trace ID)，逐字节查表，无分配
+func (l *Logger) Hex(key string, val []byte) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	const digits = "0123456789abcdef"
+	l.beginField(key)
+	l.buf = l.enc.OpenString(l.buf)
+	for _, c := range val {
+		l.buf = append(l.buf, digits[c>>4], digits[c&0xF])
+	}
+	l.buf = l.enc.CloseString(l.buf)
+	l.endField()
+	return l
+}
+
+// Raw 写入一个已经按当前 Encoder 编码好的值 (如预先序列化的 JSON 对象)，原样拷贝，不加引号也不转义
+// 内容是否合法由调用方负责；zlog_validate 构建下写坏的 JSON / Binary 行会在 Msg 时 panic (logfmt 只校验整行框架)
+func (l *Logger) Raw(key string, val []byte) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	l.beginField(key)
+	l.buf = append(l.buf, val...)
+	l.endField()
 	return l
 }
 
 // Msg 结束一条日志并写入消息
 func (l *Logger) Msg(msg string) {
-	l.appendString("msg=")
-	l.appendString(msg)
-	l.appendString("\n")
+	if l == nil {
+		return
+	}
+	if len(l.static) > 0 && !l.staticDone {
+		l.appendStatic()
+	}
+	if l.seq != nil {
+		l.beginField("seq")
+		l.buf = l.enc.AppendInt(l.buf, int64(l.seq.Next()))
+		l.endField()
+	}
+	msgAt := len(l.buf)
+	l.buf = l.enc.End(l.buf, msg, l.fields == 0)
+	l.last = lastLine{start: l.lineStart, msg: msgAt, end: len(l.buf), fields: l.fields}
+	if validateOutput {
+		if err := validateLine(l.enc, l.buf[l.lineStart:]); err != nil {
+			panic(err.Error() + ": " + string(l.buf[l.lineStart:]))
+		}
+	}
+	if l.tee != nil {
+		l.tee.Write(l.buf[l.lineStart:])
+	}
+	l.route()
+	l.level = InfoLevel
+	l.fields = 0
+	l.staticDone = false
+	l.lineStart = len(l.buf)
+}
+
+// Checkpoint 返回当前缓冲区长度，配合 Rollback 实现 "先写、后反悔" 的条件字段：
+//
+//	cp := logger.Checkpoint()
+//	logger.Int("qty", qty).Str("detail", "...") // 投机写入
+//	if err == nil {
+//		logger.Rollback(cp) // 没出错就不要这些字段
+//	}
+//
+// 只是长度操作，零分配
+func (l *Logger) Checkpoint() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.buf)
+}
+
+// Rollback 把缓冲区截断回 cp，丢弃其后追加的所有字段
+// cp 必须来自当前行 (同一次 Msg 之前) 的 Checkpoint，跨行回滚会 panic；
+// 但之前的行被 Sink 取走后缓冲区会回退，旧的 cp 可能落在当前行内而不会被发现 (见 SetSink)
+func (l *Logger) Rollback(cp int) {
+	if l == nil {
+		return
+	}
+	if cp < l.lineStart || cp > len(l.buf) {
+		panic("zlog: rollback outside current line")
+	}
+	l.buf = l.buf[:cp]
+	// JSON 等格式只关心 "是不是本行第一个字段"
+	if cp == l.lineStart {
+		l.fields = 0
+		l.staticDone = false
+	}
+}
+
+// Overflowed 报告日志是否超出了创建时 buffer 的容量
+// 超出后 append 会把 buffer 搬到堆上 (一次隐式分配)，调用方应据此调大 buffer
+func (l *Logger) Overflowed() bool {
+	return l != nil && cap(l.buf) > l.capHint
+}
+
+// Available 返回缓冲区剩余的空闲字节数，接下来追加不超过这么多字节都不会重新分配
+func (l *Logger) Available() int {
+	if l == nil {
+		return 0
+	}
+	return cap(l.buf) - len(l.buf)
+}
+
+// Grow 保证缓冲区至少还有 n 个空闲字节，不够时一次性扩容 (n < 0 时 panic)
+// 扩容是唯一预期的分配点：在拼一行之前按预计长度 (如 core.EstimateLogSize) Grow，
+// 之后追加不超过 n 字节的字段都不会在行中途重新分配。
+// 扩容后 buffer 搬到堆上，与隐式扩容一样会被 Overflowed 报告；已写入的内容与 Checkpoint 不受影响
+func (l *Logger) Grow(n int) {
+	if n < 0 {
+		panic("zlog: negative Grow")
+	}
+	if l == nil || cap(l.buf)-len(l.buf) >= n {
+		return
+	}
+	l.buf = slices.Grow(l.buf, n)
 }
 
 // Bytes 返回当前缓冲区的所有内容 (用于最后一次性输出)
 func (l *Logger) Bytes() []byte {
+	if l == nil {
+		return nil
+	}
 	return l.buf
 }
 
+// WriteTo 把已经结束 (Msg) 的行写入 w 并从缓冲区移除，实现 io.WriterTo
+// 短写时循环写完剩余部分；出错时已写出的部分也会移除，剩下的留在缓冲区，下次 WriteTo 接着写
+// 尚未 Msg 的当前行不会写出，而是移到缓冲区开头，之前取得的 Checkpoint 随之失效
+func (l *Logger) WriteTo(w io.Writer) (int64, error) {
+	if l == nil {
+		return 0, nil
+	}
+	var written int
+	var err error
+	for written < l.lineStart {
+		var n int
+		n, err = w.Write(l.buf[written:l.lineStart])
+		written += n
+		if err != nil {
+			break
+		}
+		if n == 0 {
+			// 不报错也不前进的 Writer，继续循环只会死循环
+			err = io.ErrShortWrite
+			break
+		}
+	}
+	rest := copy(l.buf, l.buf[written:])
+	l.buf = l.buf[:rest]
+	l.lineStart -= written
+	if written > 0 {
+		l.snap = snapshot{}
+	}
+	return int64(written), err
+}
+
 // --- 内部极速实现 ---
 
+func (l *Logger) beginField(key string) {
+	l.buf = l.enc.AppendKey(l.buf, key, l.fields == 0)
+	l.fields++
+}
+
+func (l *Logger) endField() {
+	l.buf = l.enc.EndField(l.buf)
+}
+
 func (l *Logger) appendString(s string) {
 	// 直接 append，如果 Arena 足够大，这里只是简单的内存 copy
 	// 注意：这里为了简化直接用了 append，实际上如果要极致优化，
@@ -65,10 +339,28 @@ func (l *Logger) appendString(s string) {
 	l.buf = append(l.buf, s...)
 }
 
-func (l *Logger) appendInt(i int) {
-	// 使用 strconv.AppendInt 是最高效的标准库方法，
-	// 它不会产生内存分配，直接写入 buffer
-	l.buf = strconv.AppendInt(l.buf, int64(i), 10)
+func (l *Logger) appendIntWidth(i, width int) {
+	// 先在栈上的定长数组里倒序生成数字，避免任何堆分配
+	var tmp [20]byte
+	u := uint64(i)
+	if i < 0 {
+		u = uint64(-i)
+		l.buf = append(l.buf, '-')
+		width--
+	}
+	n := len(tmp)
+	for {
+		n--
+		tmp[n] = byte('0' + u%10)
+		u /= 10
+		if u == 0 {
+			break
+		}
+	}
+	for pad := width - (len(tmp) - n); pad > 0; pad-- {
+		l.buf = append(l.buf, '0')
+	}
+	l.buf = append(l.buf, tmp[n:]...)
 }
 
 // 为了绕过 Go 的一些安全检查，我们可以用 unsafe 来实现更快的 copy
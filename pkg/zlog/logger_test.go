@@ -0,0 +1,224 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestArrayFieldsLogfmt(t *testing.T) {
+	buf := make([]byte, 0, 256)
+	l := Wrap(buf)
+	l.Strs("tags", []string{"a", "b c", `q"`}).Ints("ids", []int{1, -2, 30}).Strs("none", nil).Msg("m")
+	want := `tags=[a,"b c","q\""] ids=[1,-2,30] none=[] msg=m` + "\n"
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("logfmt arrays:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestArrayFieldsJSON(t *testing.T) {
+	l := WrapJSON(make([]byte, 0, 256))
+	l.Strs("tags", []string{"a", "x\ny"}).Ints("ids", []int{}).Msg("m")
+	var v struct {
+		Tags []string `json:"tags"`
+		IDs  []int    `json:"ids"`
+	}
+	if err := json.Unmarshal(l.Bytes(), &v); err != nil {
+		t.Fatalf("JSON arrays do not parse: %v (%q)", err, l.Bytes())
+	}
+	if len(v.Tags) != 2 || v.Tags[1] != "x\ny" || v.IDs == nil || len(v.IDs) != 0 {
+		t.Fatalf("decoded = %+v", v)
+	}
+}
+
+func TestArrayFieldsNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 256)
+	strs := []string{"alpha", "beta", "gamma"}
+	ints := []int{1, 2, 3}
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).Strs("tags", strs).Ints("ids", ints).Msg("m")
+	}); n != 0 {
+		t.Fatalf("Strs/Ints allocated %v times per line", n)
+	}
+}
+
+func TestRollbackLeavesBufferUnchanged(t *testing.T) {
+	for _, wrap := range []func([]byte) *Logger{Wrap, WrapJSON} {
+		l := wrap(make([]byte, 0, 256))
+		l.Int("uid", 7)
+		before := string(l.Bytes())
+		cp := l.Checkpoint()
+		l.Int("qty", 3).Str("detail", "speculative")
+		l.Rollback(cp)
+		if got := string(l.Bytes()); got != before {
+			t.Fatalf("after rollback: %q, want %q", got, before)
+		}
+		l.Msg("ok")
+
+		// 回滚到行首后第一个字段仍然按 "第一个字段" 编码 (JSON 的 '{')
+		ref := wrap(make([]byte, 0, 256))
+		ref.Int("b", 2).Msg("m")
+		l = wrap(make([]byte, 0, 256))
+		cp = l.Checkpoint()
+		l.Int("a", 1)
+		l.Rollback(cp)
+		l.Int("b", 2).Msg("m")
+		if string(l.Bytes()) != string(ref.Bytes()) {
+			t.Fatalf("rollback to line start: %q, want %q", l.Bytes(), ref.Bytes())
+		}
+	}
+}
+
+func TestRollbackAcrossLinesPanics(t *testing.T) {
+	l := Wrap(make([]byte, 0, 256))
+	cp := l.Checkpoint()
+	l.Int("a", 1).Msg("first")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Rollback into a finished line did not panic")
+		}
+	}()
+	l.Rollback(cp)
+}
+
+// shortWriter 每次最多写 max 个字节；budget 用完之后返回 err
+type shortWriter struct {
+	buf    bytes.Buffer
+	max    int
+	budget int
+	err    error
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	n := min(len(p), w.max)
+	if w.err != nil {
+		if w.budget == 0 {
+			return 0, w.err
+		}
+		n = min(n, w.budget)
+		w.budget -= n
+	}
+	w.buf.Write(p[:n])
+	return n, nil
+}
+
+func TestWriteToShortWrites(t *testing.T) {
+	l := Wrap(make([]byte, 0, 256))
+	l.Int("a", 1).Msg("first")
+	l.Str("b", "two").Msg("second")
+	want := "a=1 msg=first\nb=two msg=second\n"
+
+	// 每次只写 3 个字节：循环写完，返回总字节数，缓冲区清空
+	w := &shortWriter{max: 3}
+	n, err := l.WriteTo(w)
+	if err != nil || n != int64(len(want)) || w.buf.String() != want {
+		t.Fatalf("WriteTo = %d, %v; wrote %q", n, err, w.buf.String())
+	}
+	if len(l.Bytes()) != 0 {
+		t.Fatalf("buffer not empty after WriteTo: %q", l.Bytes())
+	}
+
+	// 尚未 Msg 的行留在缓冲区，结束后下一次写出
+	l.Int("c", 3)
+	l.Int("d", 4).Msg("third")
+	l.Int("e", 5)
+	w.buf.Reset()
+	if _, err := l.WriteTo(w); err != nil || w.buf.String() != "c=3 d=4 msg=third\n" {
+		t.Fatalf("wrote %q, %v", w.buf.String(), err)
+	}
+	l.Msg("fourth")
+	w.buf.Reset()
+	var wt io.WriterTo = l
+	if _, err := wt.WriteTo(w); err != nil || w.buf.String() != "e=5 msg=fourth\n" {
+		t.Fatalf("wrote %q, %v", w.buf.String(), err)
+	}
+}
+
+// 出错时已写出的部分移除，剩下的留到下一次；不前进也不报错的 Writer 返回 io.ErrShortWrite
+func TestWriteToErrors(t *testing.T) {
+	boom := errors.New("boom")
+	l := Wrap(make([]byte, 0, 256))
+	l.Int("a", 1).Msg("first")
+	w := &shortWriter{max: 4, budget: 6, err: boom}
+	if n, err := l.WriteTo(w); n != 6 || !errors.Is(err, boom) {
+		t.Fatalf("WriteTo = %d, %v; want 6, boom", n, err)
+	}
+	if got := string(l.Bytes()); got != "g=first\n" {
+		t.Fatalf("left in buffer: %q", got)
+	}
+	w.err = nil
+	if n, err := l.WriteTo(w); n != 8 || err != nil || w.buf.String() != "a=1 msg=first\n" {
+		t.Fatalf("retry: %d, %v; wrote %q", n, err, w.buf.String())
+	}
+
+	l.Int("b", 2).Msg("m")
+	if _, err := l.WriteTo(&shortWriter{max: 0}); !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("stuck writer: err = %v, want io.ErrShortWrite", err)
+	}
+}
+
+// Grow(n) 之后追加不超过 n 字节不会再重新分配：底层数组不变，已写入的内容保留
+func TestGrowReservesCapacity(t *testing.T) {
+	l := Wrap(make([]byte, 0, 16))
+	l.Int("a", 1)
+	l.Grow(200)
+	if l.Available() < 200 || !l.Overflowed() {
+		t.Fatalf("after Grow(200): Available = %d, Overflowed = %v", l.Available(), l.Overflowed())
+	}
+	base, room := unsafe.SliceData(l.Bytes()), len(l.Bytes())+l.Available()
+	l.Str("s", strings.Repeat("x", 150)).Int("n", 123456).Msg("done")
+	if len(l.Bytes()) > room {
+		t.Fatalf("test wrote %d bytes, more than the %d reserved", len(l.Bytes()), room)
+	}
+	if unsafe.SliceData(l.Bytes()) != base {
+		t.Fatal("buffer reallocated while appending within the reserved space")
+	}
+	if !strings.HasPrefix(string(l.Bytes()), "a=1 s=xxx") {
+		t.Fatalf("content lost across Grow: %q", l.Bytes())
+	}
+
+	// 空间已经够时 Grow 不做任何事
+	buf := make([]byte, 0, 64)
+	if n := testing.AllocsPerRun(100, func() {
+		w := Wrap(buf[:0])
+		w.Grow(64)
+		w.Int("k", 1).Msg("m")
+	}); n != 0 {
+		t.Fatalf("Grow within capacity allocated %v times", n)
+	}
+}
+
+// IntWidth 左侧补零到 width 位 (负号占一位)，更宽的值原样写出不截断
+func TestIntWidth(t *testing.T) {
+	cases := []struct {
+		val, width int
+		want       string
+	}{
+		{42, 6, "000042"},
+		{-42, 6, "-00042"},
+		{1234567, 3, "1234567"},
+		{0, 3, "000"},
+		{-5, 0, "-5"},
+		{math.MinInt, 1, strconv.Itoa(math.MinInt)},
+	}
+	for _, c := range cases {
+		l := Wrap(make([]byte, 0, 64))
+		l.IntWidth("uid", c.val, c.width).Msg("m")
+		if got, want := string(l.Bytes()), "uid="+c.want+" msg=m\n"; got != want {
+			t.Errorf("IntWidth(%d, %d) = %q, want %q", c.val, c.width, got, want)
+		}
+	}
+
+	buf := make([]byte, 0, 64)
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).IntWidth("uid", -42, 8).Msg("m")
+	}); n != 0 {
+		t.Fatalf("IntWidth allocated %v times per line", n)
+	}
+}
@@ -0,0 +1,56 @@
+package zlog
+
+// Merge 把 other 已经写好的字段原样拷贝到 l 的当前行，用于拼接预先构建好的公共字段组：
+//
+//	common := zlog.Wrap(make([]byte, 0, 128))
+//	common.Str("svc", "order").Int("region", 3) // 构建一次
+//	logger.Int("uid", uid).Merge(common).Msg("filled")
+//
+// 取的是 other 尚未 Msg 的当前行；other 的当前行为空而刚刚 Msg 过时，取那一行 msg 之前的部分 (不含 msg 与行尾换行)。
+// 行已经被 Sink / WriteTo 取走，或之后 other 又写了别的内容时，视为没有字段，l 不变。
+// 字段前的分隔符 (JSON 的 '{' / ',') 按 l 当前行补上，其余字节只是一次 memcpy，零分配
+//
+// l 与 other 必须使用同一种 Encoder；other 的脱敏、静态字段等设置在它写入时已经生效，合并时不再处理。
+// other 不能是 Measure 返回的 Logger (它不保留内容)
+func (l *Logger) Merge(other *Logger) *Logger {
+	if l == nil || other == nil {
+		return l
+	}
+	frag, n := other.fieldBytes()
+	if n == 0 {
+		return l
+	}
+	if p, ok := l.enc.(fieldPrefixer); ok {
+		// 片段的第一个字段带着 "本行第一个字段" 的前缀，换成 l 当前行对应的前缀
+		start := len(l.buf)
+		l.buf = p.fieldPrefix(l.buf, true)
+		frag = frag[len(l.buf)-start:]
+		l.buf = p.fieldPrefix(l.buf[:start], l.fields == 0)
+	}
+	l.buf = append(l.buf, frag...)
+	l.fields += n
+	if s, ok := l.enc.(*Sizer); ok {
+		// 与 Sizer.EndField 相同：累加长度并丢弃内容
+		s.n += len(l.buf)
+		l.buf = l.buf[:0]
+	}
+	return l
+}
+
+// lastLine 记录最近一次 Msg 结束的行在 buf 中的位置
+type lastLine struct {
+	start, msg, end int // 行首、msg 字段的起点、行尾 (换行之后)
+	fields          int // msg 之前的字段数
+}
+
+// fieldBytes 返回 l 可供合并的字段内容及字段数
+func (l *Logger) fieldBytes() ([]byte, int) {
+	if l.fields > 0 {
+		return l.buf[l.lineStart:], l.fields
+	}
+	// 只有那一行仍然是 buf 的最后一行时它的位置才有效
+	if l.last.fields > 0 && l.last.end == len(l.buf) {
+		return l.buf[l.last.start:l.last.msg], l.last.fields
+	}
+	return nil, 0
+}
@@ -0,0 +1,57 @@
+package zlog
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+// 合并进来的字段出现在最终行里 msg 之前；other 已经 Msg 过时只取字段、不带 msg 与换行
+func TestMergeFields(t *testing.T) {
+	common := Wrap(make([]byte, 0, 64))
+	common.Str("svc", "order").Int("region", 3)
+
+	l := Wrap(make([]byte, 0, 256))
+	l.Int("uid", 7).Merge(common).Msg("filled")
+	l.Merge(common).Int("uid", 8).Msg("again") // 放在行首同样可以
+	want := "uid=7 svc=order region=3 msg=filled\nsvc=order region=3 uid=8 msg=again\n"
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("merged lines:\n got %q\nwant %q", got, want)
+	}
+
+	done := Wrap(make([]byte, 0, 64))
+	done.Str("svc", "order").Msg("boot")
+	l = Wrap(make([]byte, 0, 64))
+	l.Merge(done).Msg("next")
+	if got := string(l.Bytes()); got != "svc=order msg=next\n" {
+		t.Fatalf("merge after Msg = %q", got)
+	}
+	done.WriteTo(io.Discard) // 行已经被取走
+	l = Wrap(make([]byte, 0, 64))
+	l.Merge(done).Merge(Wrap(nil)).Msg("empty")
+	if got := string(l.Bytes()); got != "msg=empty\n" {
+		t.Fatalf("merging a logger without fields = %q", got)
+	}
+
+	// JSON：片段的首个字段前缀按目标行重新补上
+	jc := WrapJSON(make([]byte, 0, 64))
+	jc.Str("svc", "order").Int("region", 3)
+	for _, first := range []bool{true, false} {
+		j := WrapJSON(make([]byte, 0, 128))
+		if !first {
+			j.Int("uid", 7)
+		}
+		j.Merge(jc).Msg("m")
+		var v map[string]any
+		if err := json.Unmarshal(j.Bytes(), &v); err != nil || v["svc"] != "order" || v["region"] != 3.0 {
+			t.Fatalf("JSON merge (first=%v) = %q (%v)", first, j.Bytes(), err)
+		}
+	}
+
+	buf := make([]byte, 0, 256)
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).Int("uid", 7).Merge(common).Msg("filled")
+	}); n != 0 {
+		t.Fatalf("Merge allocated %v times per line", n)
+	}
+}
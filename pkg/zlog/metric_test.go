@@ -0,0 +1,56 @@
+package zlog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testOrderTotal 在包初始化时注册 (NewHistogram 不允许重名，-count 多次运行时只注册一次)
+var testOrderTotal = NewHistogram("test_order_total", []float64{10, 100, 1000})
+
+// Metric 字段既出现在日志行里，又计入同名直方图；未注册的 key 只写日志，Measure 不计入
+func TestMetricLogsAndObserves(t *testing.T) {
+	before := testOrderTotal.Snapshot()
+	l := Wrap(make([]byte, 0, 256))
+	l.Int("uid", 7).Metric("test_order_total", 512.5).Msg("filled")
+	l.Metric("test_order_total", 5).Metric("test_unregistered", 1.25).Msg("small")
+	want := "uid=7 test_order_total=512.5 msg=filled\ntest_order_total=5 test_unregistered=1.25 msg=small\n"
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("lines:\n got %q\nwant %q", got, want)
+	}
+	if LookupHistogram("test_unregistered") != nil {
+		t.Fatal("logging an unregistered key created a histogram")
+	}
+
+	ml, _ := Measure(logfmtEncoder{})
+	ml.Metric("test_order_total", 50).Msg("sized")
+
+	s := testOrderTotal.Snapshot()
+	counts := make([]uint64, len(s.Counts))
+	for i := range counts {
+		counts[i] = s.Counts[i] - before.Counts[i]
+	}
+	if s.Count-before.Count != 2 || s.Sum-before.Sum != 517.5 || !slices.Equal(counts, []uint64{1, 0, 1, 0}) {
+		t.Fatalf("histogram grew by %v (count %d, sum %v), want 5 and 512.5 in the le=10 and le=1000 buckets",
+			counts, s.Count-before.Count, s.Sum-before.Sum)
+	}
+	out := string(AppendPrometheus(nil))
+	for _, line := range []string{
+		`# TYPE zlog_test_order_total histogram`,
+		fmt.Sprintf(`zlog_test_order_total_bucket{le="+Inf"} %d`, s.Count),
+		fmt.Sprintf(`zlog_test_order_total_count %d`, s.Count),
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("metrics output lacks %s", line)
+		}
+	}
+
+	buf := make([]byte, 0, 128)
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).Metric("test_order_total", 42).Msg("m")
+	}); n != 0 {
+		t.Fatalf("Metric allocated %v times per line", n)
+	}
+}
@@ -0,0 +1,132 @@
+package zlog
+
+import "strconv"
+
+// 敏感字段脱敏 (合规要求，如账号、证件号)
+//
+// Redactor 是一小组敏感 key。Logger 设置了 Redactor 之后，写这些 key 的字段时不写原值：
+//   - RedactMask: key=*** (JSON 为 "key":"***")
+//   - RedactHash: key=<16 位十六进制的 FNV-1a 64 位哈希>，同一个值总是得到同一个哈希，
+//     日志里仍然可以关联同一账号的多条记录。可以设置 Salt，防止短小的值被穷举反推
+//
+// Str 与 Int 的哈希基于值的十进制文本，所以 Str("acct", "42") 与 Int("acct", 42) 的哈希相同；
+// 其余字段方法 (IntWidth、Strs、Ints、Hex、IP) 遇到敏感 key 一律输出掩码
+//
+// 判断在 append 时进行：先用 key 长度的位图排除绝大多数 key，再与少量同长度的 key 逐个比较，
+// 不查 map、不分配内存。Redactor 创建后只读，多个 Logger / goroutine 可以共享
+
+// RedactMode 决定敏感字段的输出方式
+type RedactMode uint8
+
+const (
+	// RedactMask 输出固定的掩码 ***
+	RedactMask RedactMode = iota
+	// RedactHash 输出值的哈希 (十六进制)
+	RedactHash
+)
+
+// redactMask 是 RedactMask 模式的输出
+const redactMask = "***"
+
+// Redactor 是一组需要脱敏的 key
+type Redactor struct {
+	keys []string
+	lens uint64 // 第 i 位表示存在长度为 i 的 key (长度 >= 63 的都记在第 63 位)
+	mode RedactMode
+	seed uint64 // FNV 初始值 (已混入 Salt)
+}
+
+// NewRedactor 创建一个对 keys 按 mode 脱敏的 Redactor
+func NewRedactor(mode RedactMode, keys ...string) *Redactor {
+	r := &Redactor{keys: append([]string(nil), keys...), mode: mode, seed: fnvOffset}
+	for _, k := range keys {
+		r.lens |= 1 << min(len(k), 63)
+	}
+	return r
+}
+
+// Salt 设置 RedactHash 使用的盐，必须在共享给 Logger 之前调用
+func (r *Redactor) Salt(salt string) *Redactor {
+	r.seed = fnv64(fnvOffset, salt)
+	return r
+}
+
+// Match 报告 key 是否需要脱敏
+func (r *Redactor) Match(key string) bool {
+	if r == nil || r.lens&(1<<min(len(key), 63)) == 0 {
+		return false
+	}
+	for _, k := range r.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact 让之后的字段按 r 脱敏，r 为 nil 时关闭
+func (l *Logger) Redact(r *Redactor) *Logger {
+	if l == nil {
+		return nil
+	}
+	l.redact = r
+	return l
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+func fnv64[T string | []byte](h uint64, s T) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
+
+// redactStr 在 key 需要脱敏时写入脱敏后的字段并返回 true
+func (l *Logger) redactStr(key, val string) bool {
+	if !l.redact.Match(key) {
+		return false
+	}
+	l.appendRedacted(key, fnv64(l.redact.seed, val))
+	return true
+}
+
+// redactInt 与 redactStr 相同，哈希基于十进制文本 (在栈上格式化，不分配)
+func (l *Logger) redactInt(key string, val int) bool {
+	if !l.redact.Match(key) {
+		return false
+	}
+	var tmp [20]byte
+	l.appendRedacted(key, fnv64(l.redact.seed, strconv.AppendInt(tmp[:0], int64(val), 10)))
+	return true
+}
+
+// redactOther 用于不支持哈希的字段方法，敏感 key 一律写掩码
+func (l *Logger) redactOther(key string) bool {
+	if !l.redact.Match(key) {
+		return false
+	}
+	l.beginField(key)
+	l.buf = l.enc.AppendString(l.buf, redactMask)
+	l.endField()
+	return true
+}
+
+func (l *Logger) appendRedacted(key string, h uint64) {
+	l.beginField(key)
+	if l.redact.mode != RedactHash {
+		l.buf = l.enc.AppendString(l.buf, redactMask)
+	} else {
+		const digits = "0123456789abcdef"
+		l.buf = l.enc.OpenString(l.buf)
+		for shift := 60; shift >= 0; shift -= 4 {
+			l.buf = append(l.buf, digits[h>>uint(shift)&0xF])
+		}
+		l.buf = l.enc.CloseString(l.buf)
+	}
+	l.endField()
+}
@@ -0,0 +1,84 @@
+package zlog
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactMask(t *testing.T) {
+	r := NewRedactor(RedactMask, "acct", "ssn", "uid")
+	l := Wrap(make([]byte, 0, 256)).Redact(r)
+	l.Str("acct", "12345").Int("ssn", 999).Str("user", "bob").Int("qty", 3).
+		Hex("acct", []byte{1}).Strs("ssn", []string{"a"}).IntKey(KeyUID, 7).Msg("m")
+	want := "acct=*** ssn=*** user=bob qty=3 acct=*** ssn=*** uid=*** msg=m\n"
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("logfmt:\n got %q\nwant %q", got, want)
+	}
+
+	j := WrapJSON(make([]byte, 0, 256)).Redact(r)
+	j.Str("acct", "12345").Str("user", "bob").Msg("m")
+	if got := string(j.Bytes()); !strings.Contains(got, `"acct":"***"`) || !strings.Contains(got, `"user":"bob"`) || strings.Contains(got, "12345") {
+		t.Fatalf("JSON: %q", got)
+	}
+
+	// Redact(nil) 关闭脱敏
+	l = Wrap(make([]byte, 0, 64)).Redact(r).Redact(nil)
+	l.Str("acct", "12345").Msg("m")
+	if got := string(l.Bytes()); got != "acct=12345 msg=m\n" {
+		t.Fatalf("after Redact(nil): %q", got)
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	hashOf := func(r *Redactor, f func(*Logger)) string {
+		l := Wrap(make([]byte, 0, 128)).Redact(r)
+		f(l)
+		l.Msg("m")
+		m := regexp.MustCompile(`^acct=([0-9a-f]{16}) msg=m\n$`).FindSubmatch(l.Bytes())
+		if m == nil {
+			t.Fatalf("hashed line %q", l.Bytes())
+		}
+		return string(m[1])
+	}
+	r := NewRedactor(RedactHash, "acct")
+	s42 := hashOf(r, func(l *Logger) { l.Str("acct", "42") })
+	i42 := hashOf(r, func(l *Logger) { l.Int("acct", 42) })
+	s43 := hashOf(r, func(l *Logger) { l.Str("acct", "43") })
+	if s42 != i42 {
+		t.Fatalf("Str and Int of the same value hash differently: %s vs %s", s42, i42)
+	}
+	if s42 == s43 {
+		t.Fatal("different values share a hash")
+	}
+	salted := hashOf(NewRedactor(RedactHash, "acct").Salt("pepper"), func(l *Logger) { l.Str("acct", "42") })
+	if salted == s42 {
+		t.Fatal("Salt did not change the hash")
+	}
+}
+
+// 长度位图只是预筛：同长度或长度 >= 63 的不同 key 不能误判
+func TestRedactorMatch(t *testing.T) {
+	long := strings.Repeat("k", 70)
+	r := NewRedactor(RedactMask, "acct", long)
+	for key, want := range map[string]bool{
+		"acct": true, "acc": false, "accu": false, long: true, long + "x": false, strings.Repeat("k", 63): false,
+	} {
+		if got := r.Match(key); got != want {
+			t.Errorf("Match(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if (*Redactor)(nil).Match("acct") {
+		t.Error("nil Redactor matched")
+	}
+}
+
+func TestRedactNoAlloc(t *testing.T) {
+	buf := make([]byte, 0, 256)
+	r := NewRedactor(RedactHash, "acct", "ssn")
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).Redact(r).Str("acct", "12345").Int("ssn", 999).Str("user", "bob").Msg("m")
+	}); n != 0 {
+		t.Fatalf("redacted line allocated %v times", n)
+	}
+}
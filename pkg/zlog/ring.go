@@ -0,0 +1,84 @@
+package zlog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RingSink 在内存中保留最近 n 行日志，用于 /logs 之类的实时查看 (不落盘)
+//
+// 每个槽位是预分配的定长 buffer，写入时把整行拷贝进去 (一次 memcpy，无分配)：
+// 不能只保存切片头，因为行数据通常位于调用者复用的 LogBuf 或即将 Reset 的 Arena 上
+//
+// 写入方 (Worker) 使用 TryLock，永远不会因为读取方 (Snapshot) 正在拷贝而阻塞，
+// 此时这一行直接丢弃并计入 Dropped
+type RingSink struct {
+	mu      sync.Mutex
+	slots   [][]byte
+	maxLine int
+	next    uint64 // 已写入的总行数
+
+	dropped atomic.Uint64
+}
+
+// NewRingSink 创建一个保留最近 n 行、每行最多 maxLine 字节的环 (超长的行会被截断)
+func NewRingSink(n, maxLine int) *RingSink {
+	if n < 1 {
+		n = 1
+	}
+	slots := make([][]byte, n)
+	backing := make([]byte, n*maxLine)
+	for i := range slots {
+		slots[i] = backing[i*maxLine : i*maxLine : (i+1)*maxLine]
+	}
+	return &RingSink{slots: slots, maxLine: maxLine}
+}
+
+// Write 追加一行，覆盖最旧的一行，实现 io.Writer (总是返回 len(p), nil)
+func (s *RingSink) Write(p []byte) (int, error) {
+	if !s.mu.TryLock() {
+		s.dropped.Add(1)
+		return len(p), nil
+	}
+	line := p
+	if len(line) > s.maxLine {
+		line = line[:s.maxLine]
+	}
+	i := s.next % uint64(len(s.slots))
+	s.slots[i] = append(s.slots[i][:0], line...)
+	s.next++
+	s.mu.Unlock()
+	return len(p), nil
+}
+
+// Snapshot 返回最近的至多 n 行 (从旧到新)，n <= 0 表示全部
+// 返回的是拷贝 (Go World 调用，允许分配)，之后的写入不会影响它
+func (s *RingSink) Snapshot(n int) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := min(s.next, uint64(len(s.slots)))
+	if n > 0 && uint64(n) < count {
+		count = uint64(n)
+	}
+	out := make([][]byte, 0, count)
+	for seq := s.next - count; seq < s.next; seq++ {
+		slot := s.slots[seq%uint64(len(s.slots))]
+		out = append(out, append([]byte(nil), slot...))
+	}
+	return out
+}
+
+// Dropped 返回因读写冲突而丢弃的行数
+func (s *RingSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Tee 让之后每次 Msg 结束的整行同时写入 s，s 为 nil 时不做任何事
+func (l *Logger) Tee(s *RingSink) *Logger {
+	if l == nil {
+		return nil
+	}
+	l.tee = s
+	return l
+}
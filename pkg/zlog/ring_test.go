@@ -0,0 +1,50 @@
+package zlog
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingSinkKeepsNewest(t *testing.T) {
+	s := NewRingSink(3, 64)
+	buf := make([]byte, 0, 64)
+	for i := range 5 {
+		Wrap(buf[:0]).Tee(s).Int("i", i).Msg("m") // 复用同一块 buffer：环里必须是拷贝
+	}
+	got := s.Snapshot(0)
+	if len(got) != 3 {
+		t.Fatalf("Snapshot(0) returned %d lines, want 3", len(got))
+	}
+	for j, line := range got {
+		if want := fmt.Sprintf("i=%d msg=m\n", j+2); string(line) != want {
+			t.Fatalf("line %d = %q, want %q", j, line, want)
+		}
+	}
+	if last := s.Snapshot(1); len(last) != 1 || string(last[0]) != "i=4 msg=m\n" {
+		t.Fatalf("Snapshot(1) = %q", last)
+	}
+
+	// 快照是拷贝，之后的写入不影响它
+	s.Write([]byte("later\n"))
+	if string(got[2]) != "i=4 msg=m\n" {
+		t.Fatalf("snapshot changed after a write: %q", got[2])
+	}
+}
+
+func TestRingSinkTruncatesLongLines(t *testing.T) {
+	s := NewRingSink(2, 4)
+	if n, err := s.Write([]byte("abcdefgh")); n != 8 || err != nil {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if got := s.Snapshot(0); len(got) != 1 || string(got[0]) != "abcd" {
+		t.Fatalf("Snapshot = %q, want the first 4 bytes", got)
+	}
+}
+
+func TestRingSinkWriteNoAlloc(t *testing.T) {
+	s := NewRingSink(8, 64)
+	line := []byte("ts=1 msg=m\n")
+	if n := testing.AllocsPerRun(100, func() { s.Write(line) }); n != 0 {
+		t.Fatalf("Write allocated %v times", n)
+	}
+}
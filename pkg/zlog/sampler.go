@@ -0,0 +1,42 @@
+package zlog
+
+import "sync/atomic"
+
+// Sampler 是一个无锁的 1/N 采样器，用于在高峰期限制日志量
+// 每个 Sampler 内部维护一个原子计数器，通常一个 Sampler 对应一类日志 (如 "order")
+// 多个 goroutine 可以安全地共享同一个 Sampler
+type Sampler struct {
+	n       uint64
+	counter atomic.Uint64
+}
+
+// NewSampler 创建一个每 n 条只放行 1 条的采样器
+// n <= 1 表示不采样 (全部放行)
+func NewSampler(n int) *Sampler {
+	if n < 1 {
+		n = 1
+	}
+	return &Sampler{n: uint64(n)}
+}
+
+// Allow 判断当前这条日志是否应该输出
+// Error 级别永远放行，且不消耗计数
+func (s *Sampler) Allow(lv Level) bool {
+	if s == nil || lv >= ErrorLevel || s.n <= 1 {
+		return true
+	}
+	// 第 1, N+1, 2N+1 ... 条放行
+	return (s.counter.Add(1)-1)%s.n == 0
+}
+
+// Sample 在写入任何字段之前做采样决策，放行时 lv 同时作为这一行的级别 (见 Level)
+// 被丢弃时返回 nil Logger，后续的链式调用都是空操作，几乎零开销:
+//
+//	logger.Sample(s, zlog.InfoLevel).Int("uid", 1).Msg("processed")
+func (l *Logger) Sample(s *Sampler, lv Level) *Logger {
+	if l == nil || !s.Allow(lv) {
+		return nil
+	}
+	l.level = lv
+	return l
+}
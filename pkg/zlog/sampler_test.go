@@ -0,0 +1,80 @@
+package zlog
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSamplerRatio(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 100} {
+		s := NewSampler(n)
+		allowed := 0
+		for range 100000 {
+			if s.Allow(InfoLevel) {
+				allowed++
+			}
+		}
+		if want := 100000 / n; allowed != want {
+			t.Errorf("NewSampler(%d): allowed %d of 100000, want %d", n, allowed, want)
+		}
+	}
+}
+
+// 多个 goroutine 共享一个 Sampler 时总放行数仍然精确
+func TestSamplerRatioConcurrent(t *testing.T) {
+	s := NewSampler(8)
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for range 8000 {
+				if s.Allow(WarnLevel) {
+					n++
+				}
+			}
+			mu.Lock()
+			allowed += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if allowed != 8*8000/8 {
+		t.Fatalf("allowed %d, want %d", allowed, 8*8000/8)
+	}
+}
+
+// Error 级别永远放行，且不消耗计数
+func TestSamplerErrorsBypass(t *testing.T) {
+	s := NewSampler(4)
+	for range 10 {
+		if !s.Allow(ErrorLevel) {
+			t.Fatal("error line was sampled out")
+		}
+	}
+	got := []bool{s.Allow(InfoLevel), s.Allow(InfoLevel), s.Allow(InfoLevel), s.Allow(InfoLevel), s.Allow(InfoLevel)}
+	if want := []bool{true, false, false, false, true}; !slices.Equal(got, want) {
+		t.Fatalf("info sequence after errors = %v, want %v", got, want)
+	}
+}
+
+func TestSampleDropsWholeLine(t *testing.T) {
+	s := NewSampler(2)
+	var buf []byte
+	lines := 0
+	for i := range 10 {
+		l := Wrap(buf[:0]).Sample(s, InfoLevel)
+		l.Int("i", i).Msg("tick")
+		if l != nil {
+			lines++
+			buf = l.Bytes()
+		}
+	}
+	if lines != 5 {
+		t.Fatalf("lines written = %d, want 5", lines)
+	}
+}
@@ -0,0 +1,32 @@
+package zlog
+
+import "sync/atomic"
+
+// Sequence 是日志行的单调序号生成器，每行 Msg 时原子加一并写入 seq=N
+// 下游发现 seq 不连续即说明传输中丢了行
+//
+// 作用域由共享方式决定：
+//   - 多个 Logger 共享同一个 Sequence (如 DefaultSequence)：全局序号，跨 Logger 检测丢失
+//   - 每个 Logger 各用一个：序号只在该 Logger 内部连续
+//
+// 被 Sample 丢弃的行不会占用序号，所以采样不会被误判为丢失
+type Sequence struct {
+	n atomic.Uint64
+}
+
+// DefaultSequence 是进程级的全局序号
+var DefaultSequence = &Sequence{}
+
+// Next 返回下一个序号 (从 1 开始)
+func (s *Sequence) Next() uint64 {
+	return s.n.Add(1)
+}
+
+// Seq 让之后的每一行都带上来自 s 的 seq 字段，s 为 nil 时关闭
+func (l *Logger) Seq(s *Sequence) *Logger {
+	if l == nil {
+		return nil
+	}
+	l.seq = s
+	return l
+}
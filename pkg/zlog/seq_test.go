@@ -0,0 +1,53 @@
+package zlog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestSequenceConsecutive(t *testing.T) {
+	s := &Sequence{}
+	buf := make([]byte, 0, 256)
+	l := Wrap(buf).Seq(s)
+	for range 3 {
+		l.Int("a", 1).Msg("m")
+	}
+	// 另一个 Logger 共享同一个 Sequence，序号接着往下走
+	l2 := Wrap(make([]byte, 0, 64)).Seq(s)
+	l2.Msg("m")
+
+	lines := bytes.SplitAfter(l.Bytes(), []byte("\n"))
+	lines = append(lines[:3], l2.Bytes())
+	for i, line := range lines {
+		if want := fmt.Sprintf("seq=%d ", i+1); !bytes.Contains(line, []byte(want)) {
+			t.Fatalf("line %d = %q, want %s", i, line, want)
+		}
+	}
+}
+
+// 被采样丢弃的行不占用序号
+func TestSequenceSkipsSampledLines(t *testing.T) {
+	s := &Sequence{}
+	sampler := NewSampler(2)
+	var kept [][]byte
+	for range 6 {
+		if l := Wrap(make([]byte, 0, 64)).Sample(sampler, InfoLevel).Seq(s); l != nil {
+			l.Msg("m")
+			kept = append(kept, l.Bytes())
+		}
+	}
+	for i, line := range kept {
+		if want := fmt.Sprintf("seq=%d ", i+1); !bytes.Contains(line, []byte(want)) {
+			t.Fatalf("kept line %d = %q, want %s", i, line, want)
+		}
+	}
+}
+
+func TestSequenceNoAlloc(t *testing.T) {
+	s := &Sequence{}
+	buf := make([]byte, 0, 128)
+	if n := testing.AllocsPerRun(100, func() { Wrap(buf[:0]).Seq(s).Int("a", 1).Msg("m") }); n != 0 {
+		t.Fatalf("seq stamping allocated %v times", n)
+	}
+}
@@ -0,0 +1,146 @@
+package zlog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// 按级别分流：Msg 结束一行时，如果该行的级别注册了 Sink，整行交给 Sink 并从缓冲区移除，
+// 否则照常留在缓冲区，由调用方统一 WriteTo / Bytes 输出 (主日志)。典型用法是把错误单独送到 stderr 或告警管道：
+//
+//	zlog.SetSink(zlog.ErrorLevel, os.Stderr)
+//	logger.Level(zlog.ErrorLevel).Str("err", "...").Msg("order rejected") // 写入 stderr
+//	logger.Int("uid", 1).Msg("processed")                                 // 未标级别的行按 Info，留在缓冲区
+//	logger.Debug().Int("slot", 3).Msg("route")                            // Debug 调用链的行按 Debug
+//
+// 路由表是进程级的，每个级别一个原子指针：没有注册 Sink (单一输出) 时 Msg 只多一次原子读。
+//
+// Sink 的 Write 不在 Msg 的调用方上执行：Msg 只把这一行拷贝进池化的 buffer，投递给该 Sink 的后台 goroutine，
+// 所以慢的 Sink (终端、管道、网络) 不会拖住调用方 (例如 Core 的 Worker 线程)。
+//   - 后台队列已满或 Sink 正在被替换时，这一行留在缓冲区，跟随主日志输出而不是丢失
+//   - 已经交出的行 Write 失败时无法再回到缓冲区 (调用方早已继续)，只计入 SinkFailures
+//   - 每个 Sink 只有一个后台 goroutine 按顺序写；同一个 w 注册到多个级别时会被并发写，需要 w 自己保证并发安全 (os.File 满足)
+//
+// 与 Checkpoint/Rollback、Snapshot 的关系：被 Sink 取走的行从缓冲区移除，缓冲区长度随之回退，
+// 所以之前行上取得的 Checkpoint 可能重新落在当前行的范围内，Rollback 无法发现这种跨行回滚，
+// Checkpoint 只能在同一行 (同一次 Msg 之前) 使用。快照所在的行被取走时，快照部分 (上下文字段) 保留在缓冲区，
+// ResetToSnapshot 照常回到快照位置
+
+// sinkQueueSize 每个 Sink 等待后台写出的行数上限
+const sinkQueueSize = 1024
+
+// sinkLineSize 行 buffer 的初始容量
+const sinkLineSize = 256
+
+type sink struct {
+	w     io.Writer
+	lines chan *[]byte
+	done  chan struct{}
+
+	// mu 保护 closed：Msg 持读锁投递，SetSink 持写锁关闭队列，避免向已关闭的 channel 发送
+	mu     sync.RWMutex
+	closed bool
+}
+
+// sinks 按级别下标
+var sinks [ErrorLevel + 1]atomic.Pointer[sink]
+
+// sinkFailures 交给 Sink 之后 Write 失败的行数 (所有 Sink 合计)
+var sinkFailures atomic.Uint64
+
+var sinkLinePool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, sinkLineSize)
+		return &b
+	},
+}
+
+// SetSink 让级别为 level 的行在 Msg 时交给 w，w 为 nil 时取消 (回到主日志)
+// 可以在运行中随时调用，对之后结束的行生效；被替换的 Sink 写完已经交出的行之后 SetSink 才返回
+func SetSink(level Level, w io.Writer) {
+	if level < DebugLevel || level > ErrorLevel {
+		panic("zlog: unknown level " + level.String())
+	}
+	var s *sink
+	if w != nil {
+		s = &sink{w: w, lines: make(chan *[]byte, sinkQueueSize), done: make(chan struct{})}
+		go s.run()
+	}
+	if old := sinks[level].Swap(s); old != nil {
+		old.close()
+	}
+}
+
+// SinkFailures 返回交给 Sink 之后 Write 失败、因此丢失的行数
+func SinkFailures() uint64 {
+	return sinkFailures.Load()
+}
+
+// Level 设置当前行的级别 (决定 Msg 时写入哪个 Sink)，Msg 之后恢复为 InfoLevel
+func (l *Logger) Level(lv Level) *Logger {
+	if l == nil {
+		return nil
+	}
+	l.level = lv
+	return l
+}
+
+// route 把刚结束的一行交给其级别的 Sink，交出后从缓冲区移除
+func (l *Logger) route() {
+	if uint8(l.level) >= uint8(len(sinks)) {
+		return
+	}
+	s := sinks[l.level].Load()
+	if s == nil {
+		return
+	}
+	// 整行交出；快照在这一行里时，快照部分 (上下文字段) 留在缓冲区给 ResetToSnapshot
+	keep := l.lineStart
+	if l.snap.valid && l.snap.lineStart == l.lineStart && l.snap.pos > keep {
+		keep = l.snap.pos
+	}
+	if s.send(l.buf[l.lineStart:]) {
+		l.buf = l.buf[:keep]
+	}
+}
+
+// send 把 line 拷贝一份投递给后台 goroutine，队列已满或 Sink 已关闭时返回 false
+func (s *sink) send(line []byte) bool {
+	b := sinkLinePool.Get().(*[]byte)
+	*b = append((*b)[:0], line...)
+	s.mu.RLock()
+	ok := false
+	if !s.closed {
+		select {
+		case s.lines <- b:
+			ok = true
+		default:
+		}
+	}
+	s.mu.RUnlock()
+	if !ok {
+		sinkLinePool.Put(b)
+	}
+	return ok
+}
+
+// run 是 Sink 的后台 goroutine：按顺序写出交来的行，队列关闭后退出
+func (s *sink) run() {
+	defer close(s.done)
+	for b := range s.lines {
+		if _, err := s.w.Write(*b); err != nil {
+			sinkFailures.Add(1)
+		}
+		sinkLinePool.Put(b)
+	}
+}
+
+// close 关闭队列并等待后台 goroutine 写完剩余的行
+func (s *sink) close() {
+	s.mu.Lock()
+	s.closed = true
+	close(s.lines)
+	s.mu.Unlock()
+	<-s.done
+}
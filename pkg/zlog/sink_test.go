@@ -0,0 +1,136 @@
+package zlog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("sink down") }
+
+// blockWriter 在 release 关闭之前阻塞每一次 Write，第一次进入 Write 时通知 entered
+type blockWriter struct {
+	entered chan struct{}
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.buf.Write(p)
+}
+
+// 错误行进入错误 Sink，信息行进入信息 Sink，没有注册 Sink 的级别留在缓冲区；写失败的行计入 SinkFailures
+func TestSinkRoutesByLevel(t *testing.T) {
+	var errSink, infoSink bytes.Buffer
+	SetSink(ErrorLevel, &errSink)
+	SetSink(InfoLevel, &infoSink)
+	t.Cleanup(func() {
+		SetSink(ErrorLevel, nil)
+		SetSink(InfoLevel, nil)
+	})
+
+	l := Wrap(make([]byte, 0, 256))
+	l.Level(ErrorLevel).Int("uid", 1).Msg("rejected")
+	l.Int("uid", 2).Msg("processed") // 未标级别按 Info
+	l.Level(WarnLevel).Int("uid", 3).Msg("slow")
+	l.Level(InfoLevel).Int("uid", 4).Msg("done")
+	if got := string(l.Bytes()); got != "uid=3 msg=slow\n" {
+		t.Errorf("main buffer = %q, want only the warn line", got)
+	}
+
+	// 取消 Sink 时等它写完已经交出的行
+	SetSink(ErrorLevel, nil)
+	SetSink(InfoLevel, nil)
+	if got := errSink.String(); got != "uid=1 msg=rejected\n" {
+		t.Errorf("error sink = %q", got)
+	}
+	if got := infoSink.String(); got != "uid=2 msg=processed\nuid=4 msg=done\n" {
+		t.Errorf("info sink = %q", got)
+	}
+
+	// 取消之后回到主日志
+	l.Int("uid", 5).Msg("back")
+	if got := string(l.Bytes()); got != "uid=3 msg=slow\nuid=5 msg=back\n" {
+		t.Errorf("line after removing the sink: main buffer = %q", got)
+	}
+
+	before := SinkFailures()
+	SetSink(ErrorLevel, failWriter{})
+	l.Level(ErrorLevel).Int("uid", 6).Msg("lost")
+	SetSink(ErrorLevel, nil)
+	if n := SinkFailures() - before; n != 1 {
+		t.Errorf("SinkFailures grew by %d, want 1", n)
+	}
+}
+
+// Sink 在后台 goroutine 上写：慢的 Sink 不阻塞 Msg，队列满时的行留在缓冲区
+func TestSinkWritesOffCaller(t *testing.T) {
+	w := &blockWriter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	SetSink(ErrorLevel, w)
+	t.Cleanup(func() { SetSink(ErrorLevel, nil) })
+
+	l := Wrap(make([]byte, 0, 64))
+	l.Level(ErrorLevel).Int("uid", 1).Msg("e")
+	<-w.entered // 后台 goroutine 阻塞在第一行的 Write 上
+	done := make(chan struct{})
+	go func() {
+		for range sinkQueueSize + 1 { // 排满队列，最后一行放不下
+			l.Level(ErrorLevel).Int("uid", 1).Msg("e")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Msg blocked on a slow sink")
+	}
+	if got := string(l.Bytes()); got != "uid=1 msg=e\n" {
+		t.Errorf("line that did not fit the sink queue: main buffer = %q", got)
+	}
+	close(w.release)
+	SetSink(ErrorLevel, nil)
+	if n := bytes.Count(w.buf.Bytes(), []byte("\n")); n != sinkQueueSize+1 {
+		t.Errorf("sink wrote %d lines, want %d", n, sinkQueueSize+1)
+	}
+}
+
+// Debug 调用链的行按 DebugLevel 路由；快照所在的行被取走后仍然可以 ResetToSnapshot
+func TestSinkDebugAndSnapshot(t *testing.T) {
+	var dbg, info bytes.Buffer
+	SetSink(DebugLevel, &dbg)
+	SetSink(InfoLevel, &info)
+	t.Cleanup(func() {
+		SetSink(DebugLevel, nil)
+		SetSink(InfoLevel, nil)
+	})
+
+	l := Wrap(make([]byte, 0, 256))
+	l.Debug().Int("slot", 3).Msg("route")
+	l.Str("req", "r1")
+	l.Snapshot()
+	for step := range 2 {
+		l.Int("step", step).Msg("done")
+		l.ResetToSnapshot()
+	}
+	SetSink(DebugLevel, nil)
+	SetSink(InfoLevel, nil)
+
+	want := "slot=3 msg=route\n"
+	if !DebugEnabled {
+		want = ""
+	}
+	if got := dbg.String(); got != want {
+		t.Errorf("debug sink = %q, want %q", got, want)
+	}
+	if got := info.String(); got != "req=r1 step=0 msg=done\nreq=r1 step=1 msg=done\n" {
+		t.Errorf("info sink = %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package zlog
+
+// Sizer 统计一条字段调用链会输出多少字节，但不保留内容，用于精确地预估 buffer 大小：
+//
+//	l, s := zlog.Measure(zlog.Logfmt)
+//	l.Int("uid", uid).Str("sym", sym).Msg("order")
+//	buf := make([]byte, 0, s.Size()) // 用在真正的 Logger 上保证不会扩容
+//
+// 它是一个包装了真实 Encoder 的 Encoder：每个字段 (及 Msg) 写完后累加长度并把缓冲区截断回 0，
+// 所以统计结果与同一 Encoder 的真实输出逐字节一致 (含数字位数、引号与转义)，
+// 且只需要容纳单个字段的临时空间
+//
+// 限制：统计期间的 Checkpoint/Rollback 不会回退已累计的长度；不要对其调用 Tee
+type Sizer struct {
+	enc Encoder
+	n   int
+}
+
+// Measure 返回一个只统计长度的 Logger 及其 Sizer
+func Measure(enc Encoder) (*Logger, *Sizer) {
+	s := &Sizer{enc: enc}
+	return WrapWith(make([]byte, 0, 256), s), s
+}
+
+// Size 返回至今累计的字节数
+func (s *Sizer) Size() int { return s.n }
+
+// Reset 清零累计值，Logger 可继续用于下一次统计
+func (s *Sizer) Reset() { s.n = 0 }
+
+func (s *Sizer) AppendKey(dst []byte, key string, first bool) []byte {
+	return s.enc.AppendKey(dst, key, first)
+}
+
+// EndField 在字段结束时累加长度并丢弃内容
+func (s *Sizer) EndField(dst []byte) []byte {
+	dst = s.enc.EndField(dst)
+	s.n += len(dst)
+	return dst[:0]
+}
+
+func (s *Sizer) AppendInt(dst []byte, v int64) []byte     { return s.enc.AppendInt(dst, v) }
+func (s *Sizer) AppendString(dst []byte, v string) []byte { return s.enc.AppendString(dst, v) }
+func (s *Sizer) AppendElem(dst []byte, v string) []byte   { return s.enc.AppendElem(dst, v) }
+func (s *Sizer) OpenString(dst []byte) []byte             { return s.enc.OpenString(dst) }
+func (s *Sizer) CloseString(dst []byte) []byte            { return s.enc.CloseString(dst) }
+
+// End 累加 msg 及行尾的长度
+func (s *Sizer) End(dst []byte, msg string, first bool) []byte {
+	dst = s.enc.End(dst, msg, first)
+	s.n += len(dst)
+	return dst[:0]
+}
@@ -0,0 +1,43 @@
+package zlog
+
+import (
+	"math"
+	"net"
+	"testing"
+)
+
+// logFields 写一组覆盖各种编码路径的字段：负数与极值、需要引号与转义的字符串、数组、十六进制和 IP
+func logFields(l *Logger) {
+	l.Int("uid", -42).Int("max", math.MaxInt).Int("min", math.MinInt).IntWidth("w", 7, 5).
+		Str("sym", "BTC").Str("note", "a \"quoted\" value\nwith\tcontrol \x01 and ünïcode").Str("empty", "").
+		Strs("tags", []string{"x", "y z"}).Ints("ids", []int{1, -20, 300}).
+		Hex("trace", []byte{0xde, 0xad, 0xbe, 0xef}).IP("ip", net.ParseIP("2001:db8::1")).
+		Msg("order \"filled\"")
+}
+
+// Sizer 统计的长度与同一 Encoder 的真实输出逐字节相等，按它分配的 buffer 写入时不会扩容
+func TestSizerMatchesOutput(t *testing.T) {
+	for name, enc := range map[string]Encoder{"logfmt": Logfmt, "json": JSON} {
+		ml, s := Measure(enc)
+		logFields(ml)
+		size := s.Size()
+
+		l := WrapWith(make([]byte, 0, size), enc)
+		logFields(l)
+		if got := len(l.Bytes()); got != size {
+			t.Errorf("%s: Size = %d, actual output %d bytes:\n%s", name, size, got, l.Bytes())
+		}
+		if l.Overflowed() {
+			t.Errorf("%s: a buffer of exactly Size bytes overflowed", name)
+		}
+
+		// Reset 后可以复用，统计下一行
+		s.Reset()
+		ml.Int("n", 1).Msg("m")
+		l = WrapWith(make([]byte, 0, 64), enc)
+		l.Int("n", 1).Msg("m")
+		if got, want := s.Size(), len(l.Bytes()); got != want {
+			t.Errorf("%s: after Reset: Size = %d, want %d", name, got, want)
+		}
+	}
+}
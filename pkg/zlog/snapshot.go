@@ -0,0 +1,60 @@
+package zlog
+
+// 上下文字段快照：同一组字段只编码一次，后续每条消息复用
+//
+//	l := zlog.Wrap(buf).WithStatic(host)
+//	l.Str("req", id).Int("uid", uid) // 本次请求的上下文字段
+//	l.Snapshot()
+//	for ... {
+//		l.Int("step", i).Msg("done") // 行内容 = 上下文字段 + 静态字段 + step + msg
+//		out.Write(l.Bytes())         // 或者依赖 Tee / SetSink 在 Msg 时已经写出
+//		l.ResetToSnapshot()          // 截断回只剩静态字段与上下文字段
+//	}
+//
+// Snapshot 会立即把 WithStatic 的静态字段写在快照位置 (已写的上下文字段之后)，不再等到 Msg 时写在 msg 之前，
+// 所以快照之后的每一行都只拷贝一次静态字段，而不是每条消息各写一次。
+// ResetToSnapshot 只是几次赋值与一次截断，零分配。
+//
+// 快照在 WriteTo、另一次 Snapshot 之后失效；已 Msg 的行被 ResetToSnapshot 丢弃，
+// 需要保留的内容必须在此之前写出 (Merge 也取不到被丢弃的行)
+
+// snapshot 记录 Snapshot 时当前行的状态
+type snapshot struct {
+	valid     bool // 已经调用过 Snapshot (空缓冲区上的快照 pos 也是 0)
+	pos       int  // 快照时的 len(buf)
+	lineStart int  // 快照所在行的起始位置
+	fields    int  // 快照时当前行的字段数
+	static    bool // 静态字段已经写在快照里，Msg 时不再重复写
+}
+
+// Snapshot 把静态字段 (如果有) 写入当前行，记下此时的位置并返回缓冲区长度
+// 之后的 ResetToSnapshot 都回到这个位置；再次调用覆盖之前的快照
+func (l *Logger) Snapshot() int {
+	if l == nil {
+		return 0
+	}
+	if len(l.static) > 0 && !l.staticDone {
+		l.appendStatic()
+		l.staticDone = true
+	}
+	l.snap = snapshot{valid: true, pos: len(l.buf), lineStart: l.lineStart, fields: l.fields, static: l.staticDone}
+	return len(l.buf)
+}
+
+// ResetToSnapshot 丢弃快照之后写入的所有内容 (包括已经 Msg 的行)，
+// 让下一条消息从快照时的字段开始；没有快照或快照已失效时 panic
+func (l *Logger) ResetToSnapshot() {
+	if l == nil {
+		return
+	}
+	s := l.snap
+	if !s.valid || s.pos > len(l.buf) {
+		panic("zlog: no valid snapshot")
+	}
+	l.buf = l.buf[:s.pos]
+	l.lineStart = s.lineStart
+	l.fields = s.fields
+	l.staticDone = s.static
+	l.level = InfoLevel
+	l.last = lastLine{}
+}
@@ -0,0 +1,69 @@
+package zlog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 快照之后的每条消息都带着上下文字段与静态字段，ResetToSnapshot 只丢弃快照之后的内容
+func TestSnapshotSharedFields(t *testing.T) {
+	l := Wrap(make([]byte, 0, 256))
+	l.WithStatic([]byte("host=box1"))
+	l.Str("req", "r1")
+	l.Snapshot()
+	var lines []string
+	for step := range 2 {
+		l.Int("step", step).Msg("done")
+		lines = append(lines, string(l.Bytes()))
+		l.ResetToSnapshot()
+	}
+	want := []string{"req=r1 host=box1 step=0 msg=done\n", "req=r1 host=box1 step=1 msg=done\n"}
+	if lines[0] != want[0] || lines[1] != want[1] {
+		t.Fatalf("lines after the snapshot:\n got %q\nwant %q", lines, want)
+	}
+
+	// JSON：快照后的每一行仍是合法对象，字段齐全
+	j := WrapJSON(make([]byte, 0, 256))
+	j.WithStatic([]byte(`"host":"box1"`))
+	j.Str("req", "r1")
+	j.Snapshot()
+	for step := range 2 {
+		j.Int("step", step).Msg("done")
+		var v map[string]any
+		if err := json.Unmarshal(j.Bytes(), &v); err != nil || v["req"] != "r1" || v["host"] != "box1" || v["step"] != float64(step) {
+			t.Fatalf("JSON line %d = %q (%v)", step, j.Bytes(), err)
+		}
+		j.ResetToSnapshot()
+	}
+
+	buf := make([]byte, 0, 256)
+	s := Wrap(buf).WithStatic([]byte("host=box1"))
+	s.Snapshot()
+	if n := testing.AllocsPerRun(100, func() {
+		s.Int("step", 1).Msg("done")
+		s.ResetToSnapshot()
+	}); n != 0 {
+		t.Fatalf("message after a snapshot allocated %v times", n)
+	}
+
+	// 空缓冲区上的快照同样有效：Reset 回到空行
+	e := Wrap(make([]byte, 0, 64))
+	e.Snapshot()
+	e.Int("a", 1).Msg("x")
+	e.ResetToSnapshot()
+	if len(e.Bytes()) != 0 {
+		t.Fatalf("reset to a snapshot of an empty logger left %q", e.Bytes())
+	}
+	e.Int("a", 2).Msg("y")
+	if got := string(e.Bytes()); got != "a=2 msg=y\n" {
+		t.Fatalf("line after resetting to an empty snapshot = %q", got)
+	}
+
+	defer func() {
+		if r, _ := recover().(string); !strings.Contains(r, "no valid snapshot") {
+			t.Fatalf("ResetToSnapshot without a snapshot: panic = %v", r)
+		}
+	}()
+	Wrap(make([]byte, 0, 16)).ResetToSnapshot()
+}
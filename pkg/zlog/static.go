@@ -0,0 +1,49 @@
+package zlog
+
+// WithStatic 设置每一行都要带上的静态字段 (如 host、pid)，它们在 Msg 时写在 msg 之前
+// fields 是按当前 Encoder 预先格式化好的字段内容，不含首尾分隔符：
+//
+//	logfmt: host=box1 pid=42
+//	JSON:   "host":"box1","pid":42
+//
+// 字段之间的分隔符 (logfmt 的空格、JSON 的 '{' / ',') 由 Logger 补上，
+// 所以热路径上每行只是一次 memcpy，不再重复编码这些常量字段
+// fields 会被拷贝，调用方之后可以复用自己的 buffer；再次调用即覆盖，传 nil 关闭
+func (l *Logger) WithStatic(fields []byte) *Logger {
+	if l == nil {
+		return nil
+	}
+	l.static = append(l.static[:0], fields...)
+	return l
+}
+
+// fieldPrefixer 由需要在字段前写分隔符的 Encoder 实现 (JSON 的 '{' / ',')
+// 没有实现它的 Encoder (如 logfmt) 只在字段之后写分隔符
+type fieldPrefixer interface {
+	fieldPrefix(dst []byte, first bool) []byte
+}
+
+func (jsonEncoder) fieldPrefix(dst []byte, first bool) []byte {
+	if first {
+		return append(dst, '{')
+	}
+	return append(dst, ',')
+}
+
+// fieldPrefix 转发给被包装的 Encoder，保证 Measure 统计的长度与真实输出一致
+func (s *Sizer) fieldPrefix(dst []byte, first bool) []byte {
+	if p, ok := s.enc.(fieldPrefixer); ok {
+		return p.fieldPrefix(dst, first)
+	}
+	return dst
+}
+
+// appendStatic 把静态字段作为一个整体字段写入当前行
+func (l *Logger) appendStatic() {
+	if p, ok := l.enc.(fieldPrefixer); ok {
+		l.buf = p.fieldPrefix(l.buf, l.fields == 0)
+	}
+	l.buf = append(l.buf, l.static...)
+	l.fields++
+	l.endField()
+}
@@ -0,0 +1,73 @@
+package zlog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 静态字段出现在每一行的 msg 之前，可以随时替换或关闭
+func TestWithStatic(t *testing.T) {
+	l := Wrap(make([]byte, 0, 256))
+	l.WithStatic([]byte("host=box1 pid=42"))
+	l.Int("uid", 1).Msg("a")
+	l.Msg("b") // 没有其它字段的行
+	l.WithStatic([]byte("host=box2"))
+	l.Int("uid", 2).Msg("c")
+	l.WithStatic(nil)
+	l.Msg("d")
+
+	want := "uid=1 host=box1 pid=42 msg=a\n" +
+		"host=box1 pid=42 msg=b\n" +
+		"uid=2 host=box2 msg=c\n" +
+		"msg=d\n"
+	if got := string(l.Bytes()); got != want {
+		t.Fatalf("lines:\n got %q\nwant %q", got, want)
+	}
+}
+
+// JSON 下静态字段补上正确的分隔符，每一行都是合法对象
+func TestWithStaticJSON(t *testing.T) {
+	l := WrapJSON(make([]byte, 0, 256))
+	l.WithStatic([]byte(`"host":"box1","pid":42`))
+	l.Msg("first")
+	l.Str("k", "v").Msg("second")
+
+	lines := strings.Split(strings.TrimSuffix(string(l.Bytes()), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), l.Bytes())
+	}
+	for _, line := range lines {
+		var v struct {
+			Host string `json:"host"`
+			PID  int    `json:"pid"`
+			Msg  string `json:"msg"`
+		}
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if v.Host != "box1" || v.PID != 42 || v.Msg == "" {
+			t.Fatalf("%q decoded to %+v", line, v)
+		}
+	}
+}
+
+// 调用方的 buffer 被拷贝，之后改写它不影响输出；每行只是一次拷贝，不分配
+func TestWithStaticCopiesAndNoAlloc(t *testing.T) {
+	fields := []byte("host=box1")
+	buf := make([]byte, 0, 128)
+	l := Wrap(buf).WithStatic(fields)
+	copy(fields, "XXXXXXXXX")
+	l.Msg("m")
+	if got := string(l.Bytes()); got != "host=box1 msg=m\n" {
+		t.Fatalf("got %q", got)
+	}
+
+	// 足够容纳全部测量轮次的行，append 不会扩容
+	l = Wrap(make([]byte, 0, 8192)).WithStatic([]byte("host=box1 pid=42"))
+	if n := testing.AllocsPerRun(100, func() {
+		l.Int("uid", 1).Msg("m")
+	}); n != 0 {
+		t.Fatalf("line with static fields allocated %v times", n)
+	}
+}
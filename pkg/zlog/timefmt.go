@@ -0,0 +1,83 @@
+package zlog
+
+import (
+	"arena_demo/pkg/sysclock"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// 时间字段：按 sysclock 的节拍缓存格式化结果
+//
+// time.Format 每次调用都要拆分日期、查时区，写在热路径上比整行日志的其它部分都贵。
+// sysclock 每 1ms 才更新一次，同一毫秒内格式化的结果必然相同，所以每种格式只保留
+// "最近一个节拍" 的文本：节拍没变时 Time 只是一次原子读 + 一次 memcpy，节拍变了才重新格式化一次
+// (每毫秒至多一次，与调用频率无关)
+//
+// 缓存是全局的，所有 Logger / goroutine 共享；并发刷新时各自格式化、后写的覆盖先写的，结果相同
+// 时间精度就是 sysclock 的精度 (1ms)，需要纳秒级时间戳时仍然用 Int 写 sysclock.Now()
+
+// TimeFormat 是 Time 支持的时间格式
+type TimeFormat uint8
+
+const (
+	// TimeRFC3339 UTC，毫秒精度：2006-01-02T15:04:05.000Z (JSON 中是字符串)
+	TimeRFC3339 TimeFormat = iota
+	// TimeEpochMillis Unix 毫秒时间戳：1700000000000 (JSON 中是数字)
+	TimeEpochMillis
+
+	numTimeFormats
+)
+
+// rfc3339Milli 是 TimeRFC3339 的布局 (sysclock 只有毫秒精度，更细的位数没有意义)
+const rfc3339Milli = "2006-01-02T15:04:05.000Z07:00"
+
+// cachedTime 是某个节拍 (Unix 毫秒) 格式化后的文本，创建后只读
+type cachedTime struct {
+	ms   int64
+	text string
+}
+
+var timeCache [numTimeFormats]atomic.Pointer[cachedTime]
+
+// FormatTime 返回当前 sysclock 节拍按 f 格式化的文本，同一节拍内返回同一个字符串 (不再格式化)
+func FormatTime(f TimeFormat) string {
+	if f >= numTimeFormats {
+		f = TimeRFC3339
+	}
+	ms := sysclock.Now() / int64(time.Millisecond)
+	if c := timeCache[f].Load(); c != nil && c.ms == ms {
+		return c.text
+	}
+	c := &cachedTime{ms: ms}
+	switch f {
+	case TimeEpochMillis:
+		c.text = strconv.FormatInt(ms, 10)
+	default:
+		c.text = time.UnixMilli(ms).UTC().Format(rfc3339Milli)
+	}
+	timeCache[f].Store(c)
+	return c.text
+}
+
+// Time 写入当前时间 (sysclock)，格式由 f 决定，RFC3339 的文本来自 FormatTime 的缓存
+func (l *Logger) Time(key string, f TimeFormat) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.redact != nil && l.redactOther(key) {
+		return l
+	}
+	l.beginField(key)
+	if f == TimeEpochMillis {
+		// 数字交给 Encoder 写：文本格式的结果与缓存的文本相同，二进制格式写成 int 值 (直接拷贝文本会写坏记录)
+		l.buf = l.enc.AppendInt(l.buf, sysclock.Now()/int64(time.Millisecond))
+	} else {
+		// 只含数字、'-'、':'、'.'、'T'、'Z'，不需要转义
+		l.buf = l.enc.OpenString(l.buf)
+		l.buf = append(l.buf, FormatTime(f)...)
+		l.buf = l.enc.CloseString(l.buf)
+	}
+	l.endField()
+	return l
+}
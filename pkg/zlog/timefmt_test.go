@@ -0,0 +1,54 @@
+package zlog
+
+import (
+	"arena_demo/pkg/sysclock"
+	"strconv"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// 同一节拍内 FormatTime 返回同一个字符串 (不重新格式化)，节拍前进后文本随之前进
+func TestFormatTimeCachedPerTick(t *testing.T) {
+	now := sysclock.Advance(0)
+	t.Cleanup(sysclock.Start)
+	// 对齐到下一个毫秒边界，之后每次 Advance 不到 1ms 都还在同一个节拍内
+	now = sysclock.Advance(time.Millisecond - time.Duration(now%int64(time.Millisecond)))
+	ms := now / int64(time.Millisecond)
+
+	for _, f := range []TimeFormat{TimeRFC3339, TimeEpochMillis} {
+		first := FormatTime(f)
+		sysclock.Advance(999 * time.Microsecond)
+		again := FormatTime(f)
+		if unsafe.StringData(first) != unsafe.StringData(again) {
+			t.Errorf("format %d: formatted again within one tick (%q, %q)", f, first, again)
+		}
+		sysclock.Advance(time.Microsecond)
+		next := FormatTime(f)
+		if next == first {
+			t.Errorf("format %d: %q did not advance across ticks", f, next)
+		}
+		sysclock.Advance(-time.Millisecond) // 回到 ms，下一种格式从同一个节拍开始
+	}
+
+	want := time.UnixMilli(ms).UTC().Format(rfc3339Milli)
+	if got := FormatTime(TimeRFC3339); got != want {
+		t.Errorf("RFC3339 = %q, want %q", got, want)
+	}
+	if got := FormatTime(TimeEpochMillis); got != strconv.FormatInt(ms, 10) {
+		t.Errorf("epoch millis = %q, want %d", got, ms)
+	}
+	sysclock.Advance(time.Millisecond)
+	want = time.UnixMilli(ms + 1).UTC().Format(rfc3339Milli)
+	if got := FormatTime(TimeRFC3339); got != want {
+		t.Errorf("RFC3339 after one tick = %q, want %q", got, want)
+	}
+
+	// 缓存命中时写入时间字段不分配
+	buf := make([]byte, 0, 256)
+	if n := testing.AllocsPerRun(100, func() {
+		Wrap(buf[:0]).Time("ts", TimeRFC3339).Time("ms", TimeEpochMillis).Msg("x")
+	}); n != 0 {
+		t.Errorf("Time allocated %v times per line within one tick", n)
+	}
+}
@@ -0,0 +1,53 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// 输出校验：zlog_validate 构建下，Msg 写完一行后按 Encoder 把这一行解析一遍，解析失败直接 panic
+//
+// 目的是在源头抓住编码器的 bug (如未转义的引号、Raw 写入的半截 JSON)，而不是等下游解析器报错。
+// 只校验内置格式：JSON 用 encoding/json.Valid，Binary 复用 decodeRecord，logfmt 见 validLogfmt；
+// 自定义 Encoder (包括 Sizer) 不校验。校验会分配内存，仅用于测试和调试构建
+
+// ErrMalformedLine 日志行无法按其格式解析
+var ErrMalformedLine = errors.New("zlog: malformed log line")
+
+// validateLine 校验 line 是否是 enc 格式的一行完整日志
+func validateLine(enc Encoder, line []byte) error {
+	switch enc.(type) {
+	case jsonEncoder:
+		if len(line) == 0 || line[len(line)-1] != '\n' || !json.Valid(line[:len(line)-1]) {
+			return ErrMalformedLine
+		}
+	case logfmtEncoder:
+		if len(line) == 0 || line[len(line)-1] != '\n' || !validLogfmt(line[:len(line)-1]) {
+			return ErrMalformedLine
+		}
+	case binaryEncoder:
+		if n, err := decodeRecord(line, nil); err != nil || n != len(line) {
+			return ErrMalformedLine
+		}
+	}
+	return nil
+}
+
+// validLogfmt 校验一行 logfmt (不含换行符) 的框架：以 "key=" 开头 (key 不含空白、'=' 和 '"')，最后一个字段是 msg
+//
+// logfmt 的字符串值沿用最初的行为原样写入、不转义 (见 logfmtEncoder.AppendString)，值里可以合法地出现
+// 空格、引号甚至 '='，无法逐字段切分，所以这里只抓截断、缺少 msg 这类整行层面的错误；
+// Raw 写入的内容在 logfmt 下无法校验
+func validLogfmt(b []byte) bool {
+	k := bytes.IndexByte(b, '=')
+	if k <= 0 || bytes.ContainsAny(b[:k], " \t\"") {
+		return false
+	}
+	return bytes.HasPrefix(b, msgKey) || bytes.Contains(b, fieldMsgKey)
+}
+
+var (
+	msgKey      = []byte("msg=")
+	fieldMsgKey = []byte(" msg=")
+)
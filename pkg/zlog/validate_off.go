@@ -0,0 +1,6 @@
+//go:build !zlog_validate
+
+package zlog
+
+// validateOutput 为 false：生产构建中 Msg 里的校验被编译器整体消除
+const validateOutput = false
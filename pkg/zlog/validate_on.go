@@ -0,0 +1,6 @@
+//go:build zlog_validate
+
+package zlog
+
+// validateOutput 为 true 时每行日志在 Msg 之后校验能否被解析 (go build -tags zlog_validate)
+const validateOutput = true
@@ -0,0 +1,66 @@
+package zlog
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// 编码器写出的行都能通过校验，手工构造的坏行都被识别出来
+func TestValidateLine(t *testing.T) {
+	for name, wrap := range map[string]func([]byte) *Logger{"logfmt": Wrap, "json": WrapJSON, "binary": WrapBinary} {
+		l := wrap(make([]byte, 0, 512))
+		l.Int("n", -3).Str("s", "a \"b\" = c\n").Str("empty", "").Hex("h", []byte{1, 2}).
+			IP("ip", net.IPv4(10, 0, 0, 1)).Strs("tags", []string{"x", "y z"}).Ints("ids", []int{1, 2}).
+			Time("ts", TimeRFC3339).IntWidth("w", 7, 3).Msg("hello world")
+		if err := validateLine(l.enc, l.Bytes()); err != nil {
+			t.Errorf("%s: valid line rejected: %q", name, l.Bytes())
+		}
+	}
+
+	bad := map[string]func([]byte) *Logger{
+		"k=v\n":              Wrap, // 没有 msg
+		"k=v msg=m":          Wrap, // 没有换行
+		"=v msg=m\n":         Wrap,
+		"a b=1 msg=m\n":      Wrap,
+		`{"k":{}` + "\n":     WrapJSON,
+		`{"k":1,}` + "\n":    WrapJSON,
+		`{"k":"a"b"}` + "\n": WrapJSON,
+		`{"msg":"m"}`:        WrapJSON,
+	}
+	for line, wrap := range bad {
+		if err := validateLine(wrap(nil).enc, []byte(line)); !errors.Is(err, ErrMalformedLine) {
+			t.Errorf("malformed line %q accepted", line)
+		}
+	}
+	l := WrapBinary(make([]byte, 0, 64))
+	l.Int("n", 1).Msg("m")
+	if err := validateLine(l.enc, l.Bytes()[:len(l.Bytes())-1]); !errors.Is(err, ErrMalformedLine) {
+		t.Error("truncated binary record accepted")
+	}
+}
+
+// Raw 写入的半截 JSON 在 zlog_validate 构建下于 Msg 时 panic，默认构建下原样写出
+func TestValidateCatchesBadRaw(t *testing.T) {
+	l := WrapJSON(make([]byte, 0, 128))
+	r := func() (r any) {
+		defer func() { r = recover() }()
+		l.Int("id", 1).Raw("obj", []byte(`{"a":"1 2`)).Msg("bad")
+		return nil
+	}()
+	if !validateOutput {
+		if r != nil {
+			t.Fatalf("default build panicked: %v", r)
+		}
+	} else if s, _ := r.(string); !strings.HasPrefix(s, ErrMalformedLine.Error()) {
+		t.Fatalf("broken Raw field not detected: panic = %v", r)
+	}
+
+	// 合法的 Raw 不受影响
+	l = WrapJSON(make([]byte, 0, 128))
+	l.Raw("obj", []byte(`{"a":[1,2]}`)).Msg("ok")
+	if err := validateLine(l.enc, l.Bytes()); err != nil {
+		t.Fatalf("valid Raw JSON rejected: %q", l.Bytes())
+	}
+}